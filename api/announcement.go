@@ -0,0 +1,51 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// AnnouncementPath is the server announcement endpoint. It is not part of the
+// API spec yet, servers without it answer 404.
+const AnnouncementPath = APIBase + "/announcement"
+
+// ErrNotSupported is returned for endpoints the server does not provide
+var ErrNotSupported = errors.New("not supported by this server")
+
+// Announcement is a message of the server admins to every user
+type Announcement struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Severity  string    `json:"severity,omitempty"` // "info", "warning" or "critical"
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetAnnouncement retrieves the current server announcement, nil when there
+// is none. Servers without announcements return ErrNotSupported.
+func (c *Client) GetAnnouncement(ctx context.Context) (*Announcement, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, AnnouncementPath, nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusNotImplemented:
+		_ = resp.Body.Close()
+		return nil, ErrNotSupported
+	case http.StatusNoContent:
+		_ = resp.Body.Close()
+		return nil, nil
+	}
+
+	var announcement Announcement
+	if err := parseResponse(resp, &announcement); err != nil {
+		return nil, err
+	}
+	if announcement.ID == "" {
+		return nil, nil
+	}
+	return &announcement, nil
+}
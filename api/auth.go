@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -40,7 +41,7 @@ func (c *Client) Authenticate(ctx context.Context, nickname, apikey string) (str
 		if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Message != "" {
 			return "", &apiErr
 		}
-		return "", fmt.Errorf("authentication failed: HTTP %d: %s", resp.StatusCode, string(body))
+		return "", &StatusError{Op: "authentication", StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	// Response is just a plain string token
@@ -53,8 +54,7 @@ func (c *Client) Authenticate(ctx context.Context, nickname, apikey string) (str
 	// Store credentials for auto-refresh
 	c.SetCredentials(nickname, apikey)
 
-	// JWT tokens from Neper expire in 5 minutes
-	c.SetToken(token, time.Now().Add(5*time.Minute))
+	c.SetToken(token, TokenExpiry(token, time.Now()))
 
 	return token, nil
 }
@@ -93,7 +93,7 @@ func (c *Client) RefreshToken(ctx context.Context) (string, error) {
 		if err := json.Unmarshal(body, &apiErr); err == nil && apiErr.Message != "" {
 			return "", &apiErr
 		}
-		return "", fmt.Errorf("token refresh failed: HTTP %d: %s", resp.StatusCode, string(body))
+		return "", &StatusError{Op: "token refresh", StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
 	// Response is just a plain string token
@@ -103,12 +103,50 @@ func (c *Client) RefreshToken(ctx context.Context) (string, error) {
 		token = token[1 : len(token)-1]
 	}
 
-	// JWT tokens from Neper expire in 5 minutes
-	c.SetToken(token, time.Now().Add(5*time.Minute))
+	c.SetToken(token, TokenExpiry(token, time.Now()))
 
 	return token, nil
 }
 
+// DefaultTokenLifetime is the lifetime of tokens whose expiry can't be read,
+// that of the tokens issued by Neper
+const DefaultTokenLifetime = 5 * time.Minute
+
+// TokenExpiry returns the local time a JWT received at receivedAt expires, from
+// its exp claim. The lifetime (exp - iat) is applied to the local clock when
+// the token tells when it was issued, so that a server clock ahead or behind
+// doesn't shift the refreshes. Tokens without a readable expiry are assumed to
+// last DefaultTokenLifetime.
+func TokenExpiry(token string, receivedAt time.Time) time.Time {
+	fallback := receivedAt.Add(DefaultTokenLifetime)
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return fallback
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return fallback
+	}
+	var claims struct {
+		Exp int64 `json:"exp"`
+		Iat int64 `json:"iat"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return fallback
+	}
+
+	if claims.Iat != 0 && claims.Exp > claims.Iat {
+		return receivedAt.Add(time.Duration(claims.Exp-claims.Iat) * time.Second)
+	}
+	expiry := time.Unix(claims.Exp, 0)
+	if !expiry.After(receivedAt) {
+		// Already expired by our clock: the clocks disagree, don't trust it
+		return fallback
+	}
+	return expiry
+}
+
 // GetUserInfo retrieves the current user information
 func (c *Client) GetUserInfo(ctx context.Context) (*UserInfo, error) {
 	var userInfo UserInfo
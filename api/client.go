@@ -4,8 +4,10 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"sync"
@@ -20,6 +22,9 @@ type Client struct {
 	HTTPClient *http.Client
 	token      string
 	tokenExp   time.Time
+	grace      chan struct{} // closed when the grace period ends, nil outside of it
+	clockSkew  time.Duration // server clock minus local clock, from the last Date header
+	clockKnown bool
 	mu         sync.RWMutex
 
 	// Credentials for auto-refresh
@@ -48,6 +53,50 @@ func (c *Client) SetToken(token string, expiry time.Time) {
 	defer c.mu.Unlock()
 	c.token = token
 	c.tokenExp = expiry
+
+	// A fresh token releases the requests held by the grace period
+	if c.grace != nil {
+		close(c.grace)
+		c.grace = nil
+	}
+}
+
+// BeginGrace holds the authenticated requests until a new token is set or
+// EndGrace is called, rather than letting them fail while the token can't be
+// refreshed, e.g. while the server restarts
+func (c *Client) BeginGrace() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.grace == nil {
+		c.grace = make(chan struct{})
+	}
+}
+
+// EndGrace releases the requests held since BeginGrace
+func (c *Client) EndGrace() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.grace != nil {
+		close(c.grace)
+		c.grace = nil
+	}
+}
+
+// waitForGrace blocks while the client is in a grace period
+func (c *Client) waitForGrace(ctx context.Context) error {
+	c.mu.RLock()
+	grace := c.grace
+	c.mu.RUnlock()
+
+	if grace == nil {
+		return nil
+	}
+	select {
+	case <-grace:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // GetToken returns the current JWT token
@@ -57,6 +106,13 @@ func (c *Client) GetToken() string {
 	return c.token
 }
 
+// TokenExpiry returns when the current JWT token expires
+func (c *Client) TokenExpiry() time.Time {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tokenExp
+}
+
 // IsTokenValid checks if the current token is still valid
 func (c *Client) IsTokenValid() bool {
 	c.mu.RLock()
@@ -78,6 +134,12 @@ func (c *Client) SetCredentials(nickname, apikey string) {
 
 // doRequest performs an HTTP request with automatic token refresh
 func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}, requireAuth bool) (*http.Response, error) {
+	if requireAuth {
+		if err := c.waitForGrace(ctx); err != nil {
+			return nil, err
+		}
+	}
+
 	// Auto-refresh token if needed and we have credentials
 	if requireAuth && !c.IsTokenValid() {
 		c.mu.RLock()
@@ -134,14 +196,39 @@ func (c *Client) doRequestRaw(ctx context.Context, method, path string, body int
 	}
 
 	// Execute request
+	sent := time.Now()
 	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
+	c.recordClock(resp, sent, time.Now())
 
 	return resp, nil
 }
 
+// recordClock measures how far the server clock is from ours with the Date
+// header of a response, taken as sent halfway through the round trip
+func (c *Client) recordClock(resp *http.Response, sent, received time.Time) {
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return
+	}
+	midpoint := sent.Add(received.Sub(sent) / 2)
+	c.mu.Lock()
+	c.clockSkew = date.Sub(midpoint)
+	c.clockKnown = true
+	c.mu.Unlock()
+}
+
+// ClockSkew returns how far the server clock is ahead of the local clock, as
+// of the last response, and whether the server told its time. Date headers
+// have a one second resolution.
+func (c *Client) ClockSkew() (time.Duration, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clockSkew, c.clockKnown
+}
+
 // parseResponse parses a JSON response into the provided interface
 func parseResponse(resp *http.Response, v interface{}) error {
 	defer func() {
@@ -183,6 +270,36 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("API error %d: %s", e.Code, e.Message)
 }
 
+// StatusError is an unsuccessful HTTP response without an API error body
+type StatusError struct {
+	Op         string // what failed, e.g. "authentication"
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s failed: HTTP %d: %s", e.Op, e.StatusCode, e.Body)
+}
+
+// IsTransient tells whether an error is likely to go away by itself: network
+// failures and server errors, as seen while a server restarts
+func IsTransient(err error) bool {
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code >= 500
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	return false
+}
+
 // =============================================================================
 // HTTP method helpers
 // =============================================================================
@@ -232,6 +349,21 @@ func (c *Client) delete(ctx context.Context, path string) error {
 	return parseResponse(resp, nil)
 }
 
+// optional performs a request to an endpoint outside of the API spec and
+// parses the response into v. Servers without the endpoint return ErrNotSupported.
+func (c *Client) optional(ctx context.Context, method, path string, body, v interface{}) error {
+	resp, err := c.doRequest(ctx, method, path, body, true)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		_ = resp.Body.Close()
+		return ErrNotSupported
+	}
+	return parseResponse(resp, v)
+}
+
 // downloadBinary performs a GET request and returns the raw binary response body
 func (c *Client) downloadBinary(ctx context.Context, path string) ([]byte, error) {
 	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, true)
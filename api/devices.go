@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// UserProfileDevicePath returns the endpoint of a machine of a user. It is
+// not part of the API spec yet, servers without it answer 404.
+func UserProfileDevicePath(userProfileID, deviceID string) string {
+	return fmt.Sprintf("%s/devices/%s", UserProfilePath(userProfileID), url.PathEscape(deviceID))
+}
+
+// Device is a machine a user is connected from
+type Device struct {
+	ID         string    `json:"id"`
+	Machine    string    `json:"machine"`    // host name, for display
+	Monitoring bool      `json:"monitoring"` // the machine auto-uploads order files
+	ClaimedAt  time.Time `json:"claimed_at"` // when the machine last took the auto-upload over
+	LastSeen   time.Time `json:"last_seen"`
+}
+
+// ReportDevice tells the server this machine is connected and returns all
+// the machines of the user, this one included. Servers without device
+// tracking return ErrNotSupported.
+func (c *Client) ReportDevice(ctx context.Context, userProfileID string, device *Device) ([]Device, error) {
+	var devices []Device
+	if err := c.optional(ctx, http.MethodPut, UserProfileDevicePath(userProfileID, device.ID), device, &devices); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
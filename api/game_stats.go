@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// LeaderboardPath is the server-wide leaderboard endpoint. It is not part of
+// the API spec yet, servers without it answer 404.
+const LeaderboardPath = APIBase + "/leaderboard"
+
+// SessionStatsPath returns the endpoint of the end-of-game statistics of a
+// session. It is not part of the API spec yet, servers without it answer 404.
+func SessionStatsPath(sessionID string) string {
+	return SessionPath(sessionID) + "/stats"
+}
+
+// GameStatsPlayer is the final standing of a player of a finished game
+type GameStatsPlayer struct {
+	PlayerOrder int64  `json:"player_order"`
+	Race        string `json:"race"`          // plural name
+	PRT         string `json:"prt,omitempty"` // primary racial trait code, empty when unknown
+	Score       int    `json:"score"`
+	Rank        int    `json:"rank"` // 0 when unknown
+}
+
+// GameStats are the end-of-game statistics of a session, as seen in the
+// final turn file of the player submitting them
+type GameStats struct {
+	FinalYear int               `json:"final_year"`
+	Years     int               `json:"years"` // years played
+	Players   []GameStatsPlayer `json:"players"`
+}
+
+// LeaderboardEntry is a line of the server-wide leaderboard
+type LeaderboardEntry struct {
+	Rank        int       `json:"rank"`
+	Nickname    string    `json:"nickname"`
+	Games       int       `json:"games"`
+	Wins        int       `json:"wins"`
+	BestScore   int       `json:"best_score"`
+	FavoritePRT string    `json:"favorite_prt,omitempty"`
+	LastGameAt  time.Time `json:"last_game_at"`
+}
+
+// SubmitGameStats submits the statistics of a finished game to the server
+// leaderboard. Submitting again replaces the previous statistics of the
+// player. Servers without a leaderboard return ErrNotSupported.
+func (c *Client) SubmitGameStats(ctx context.Context, sessionID string, stats *GameStats) error {
+	return c.optional(ctx, http.MethodPut, SessionStatsPath(sessionID), stats, nil)
+}
+
+// GetLeaderboard retrieves the server-wide leaderboard, best first. Servers
+// without a leaderboard return ErrNotSupported.
+func (c *Client) GetLeaderboard(ctx context.Context) ([]LeaderboardEntry, error) {
+	var entries []LeaderboardEntry
+	if err := c.optional(ctx, http.MethodGet, LeaderboardPath, nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
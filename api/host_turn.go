@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// SessionHostTurnPath returns the endpoint a manager uploads a turn generated
+// outside of the server to. It is not part of the API spec yet, servers without
+// it answer 404.
+func SessionHostTurnPath(sessionID string) string {
+	return SessionPath(sessionID) + "/turns/host"
+}
+
+// GeneratedTurn is a year generated by the host of a session with its own
+// copy of stars.exe, all files base64 encoded
+type GeneratedTurn struct {
+	Year     int      `json:"year"`      // year of the generated turn files
+	HostFile string   `json:"host_file"` // game.hst after generation
+	Universe string   `json:"universe"`  // game.xy
+	Turns    []string `json:"turns"`     // game.m1 to game.mN, by player order
+}
+
+// UploadGeneratedTurn pushes a locally generated year to the server, which
+// replaces its own generation of that year. Managers only, servers without
+// the endpoint return ErrNotSupported.
+func (c *Client) UploadGeneratedTurn(ctx context.Context, sessionID string, turn *GeneratedTurn) error {
+	resp, err := c.doRequest(ctx, http.MethodPost, SessionHostTurnPath(sessionID), turn, true)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented {
+		_ = resp.Body.Close()
+		return ErrNotSupported
+	}
+	return parseResponse(resp, nil)
+}
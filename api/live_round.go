@@ -0,0 +1,65 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// SessionLiveRoundPath returns the endpoint of the live round of a session.
+// It is not part of the API spec yet, servers without it answer 404.
+func SessionLiveRoundPath(sessionID string) string {
+	return SessionPath(sessionID) + "/live-round"
+}
+
+// SessionLiveRoundResponsePath returns the endpoint of the ready-check
+// response of the current user to the live round of a session
+func SessionLiveRoundResponsePath(sessionID string) string {
+	return SessionLiveRoundPath(sessionID) + "/response"
+}
+
+// LiveRound is a countdown a session host starts for all the players to
+// play the same year together
+type LiveRound struct {
+	ID               string          `json:"id"`
+	Year             int             `json:"year"`
+	StartedBy        string          `json:"started_by"` // user profile ID of the host
+	EndsAt           time.Time       `json:"ends_at"`
+	RemainingSeconds int             `json:"remaining_seconds"` // as of the response, immune to clock skew
+	Responses        map[string]bool `json:"responses"`         // user profile ID -> ready, missing when not answered
+}
+
+// GetLiveRound retrieves the live round of a session, nil when none is
+// running. Servers without live rounds return ErrNotSupported.
+func (c *Client) GetLiveRound(ctx context.Context, sessionID string) (*LiveRound, error) {
+	var round *LiveRound
+	if err := c.optional(ctx, http.MethodGet, SessionLiveRoundPath(sessionID), nil, &round); err != nil {
+		return nil, err
+	}
+	return round, nil
+}
+
+// StartLiveRound starts a live round of a session lasting duration (manager only)
+func (c *Client) StartLiveRound(ctx context.Context, sessionID string, duration time.Duration) (*LiveRound, error) {
+	body := map[string]int{"duration_seconds": int(duration.Seconds())}
+	var round LiveRound
+	if err := c.optional(ctx, http.MethodPost, SessionLiveRoundPath(sessionID), body, &round); err != nil {
+		return nil, err
+	}
+	return &round, nil
+}
+
+// EndLiveRound stops the live round of a session (manager only)
+func (c *Client) EndLiveRound(ctx context.Context, sessionID string) error {
+	return c.optional(ctx, http.MethodDelete, SessionLiveRoundPath(sessionID), nil, nil)
+}
+
+// RespondLiveRound answers the ready-check of the live round of a session
+func (c *Client) RespondLiveRound(ctx context.Context, sessionID string, ready bool) (*LiveRound, error) {
+	body := map[string]bool{"ready": ready}
+	var round LiveRound
+	if err := c.optional(ctx, http.MethodPut, SessionLiveRoundResponsePath(sessionID), body, &round); err != nil {
+		return nil, err
+	}
+	return &round, nil
+}
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// SessionTurnRetractPath returns the endpoint retracting the orders of the
+// user for a year of a session. It is not part of the API spec yet, servers
+// without it answer 404.
+func SessionTurnRetractPath(sessionID string, year int) string {
+	return SessionTurnPath(sessionID, year) + "/retract"
+}
+
+// RetractTurn withdraws the orders the user submitted for a year, as if none
+// were submitted, while the year was not generated yet. Servers without
+// retraction return ErrNotSupported.
+func (c *Client) RetractTurn(ctx context.Context, sessionID string, year int) error {
+	resp, err := c.doRequest(ctx, http.MethodPost, SessionTurnRetractPath(sessionID, year), nil, true)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		_ = resp.Body.Close()
+		return ErrNotSupported
+	}
+	return parseResponse(resp, nil)
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// SessionPlayerTurnPath returns the endpoint a manager submits the orders of
+// a player for a year of a session to. It is not part of the API spec yet,
+// servers without it answer 404.
+func SessionPlayerTurnPath(sessionID string, year, playerOrder int) string {
+	return fmt.Sprintf("%s/players/%d", SessionTurnPath(sessionID, year), playerOrder)
+}
+
+// SubmitTurnForPlayer submits orders for a year on behalf of a player, whose
+// 0-indexed order is playerOrder. Managers only, servers without the endpoint
+// return ErrNotSupported.
+func (c *Client) SubmitTurnForPlayer(ctx context.Context, sessionID string, year, playerOrder int, order *Order) error {
+	resp, err := c.doRequest(ctx, http.MethodPut, SessionPlayerTurnPath(sessionID, year, playerOrder), order, true)
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		_ = resp.Body.Close()
+		return ErrNotSupported
+	}
+	return parseResponse(resp, nil)
+}
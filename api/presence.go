@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// PresencePath is the endpoint of the presence heartbeat of the current
+// user. It is not part of the API spec yet, servers without it answer 404.
+const PresencePath = "/presence"
+
+// SessionPresencePath returns the endpoint of the presence of the members of a session
+func SessionPresencePath(sessionID string) string {
+	return SessionPath(sessionID) + "/presence"
+}
+
+// Presence tells whether a user is connected to the server
+type Presence struct {
+	UserProfileID string    `json:"user_profile_id"`
+	Online        bool      `json:"online"`
+	LastSeen      time.Time `json:"last_seen"`
+}
+
+// SendHeartbeat tells the server the current user is online. Servers
+// without presence return ErrNotSupported.
+func (c *Client) SendHeartbeat(ctx context.Context) error {
+	return c.optional(ctx, http.MethodPut, PresencePath, nil, nil)
+}
+
+// GetSessionPresence retrieves the presence of the members of a session.
+// Servers without presence return ErrNotSupported.
+func (c *Client) GetSessionPresence(ctx context.Context, sessionID string) ([]Presence, error) {
+	var presence []Presence
+	if err := c.optional(ctx, http.MethodGet, SessionPresencePath(sessionID), nil, &presence); err != nil {
+		return nil, err
+	}
+	return presence, nil
+}
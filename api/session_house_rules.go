@@ -0,0 +1,29 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// SessionHouseRulesPath returns the endpoint of the house rules a host set
+// for a session, on top of the game rules. It is not part of the API spec
+// yet, servers without it answer 404.
+func SessionHouseRulesPath(sessionID string) string {
+	return SessionPath(sessionID) + "/house-rules"
+}
+
+// SessionHouseRules are the rules a host set for a session that Stars! does
+// not enforce itself: RequireRacePassword makes password protected races mandatory
+type SessionHouseRules struct {
+	RequireRacePassword bool `json:"require_race_password"`
+}
+
+// GetSessionHouseRules retrieves the house rules of a session. Servers
+// without house rules return ErrNotSupported.
+func (c *Client) GetSessionHouseRules(ctx context.Context, sessionID string) (*SessionHouseRules, error) {
+	var rules SessionHouseRules
+	if err := c.optional(ctx, http.MethodGet, SessionHouseRulesPath(sessionID), nil, &rules); err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
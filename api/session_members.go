@@ -0,0 +1,30 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// SessionDemotePath returns the endpoint demoting a manager of a session to a
+// plain member. It is not part of the API spec yet, servers without it answer 404.
+func SessionDemotePath(sessionID, userProfileID string) string {
+	return SessionPath(sessionID) + "/demote/" + userProfileID
+}
+
+// DemoteMember makes a manager of a session a plain member again (manager
+// only). Servers that cannot demote managers return ErrNotSupported.
+func (c *Client) DemoteMember(ctx context.Context, sessionID, memberID string) error {
+	return c.optional(ctx, http.MethodPost, SessionDemotePath(sessionID, memberID), nil, nil)
+}
+
+// SessionMemberPath returns the endpoint of a member of a session. It is not
+// part of the API spec yet, servers without it answer 404.
+func SessionMemberPath(sessionID, userProfileID string) string {
+	return SessionPath(sessionID) + "/members/" + userProfileID
+}
+
+// RemoveMember removes a member from a session (manager only). Servers that
+// cannot remove members return ErrNotSupported.
+func (c *Client) RemoveMember(ctx context.Context, sessionID, memberID string) error {
+	return c.optional(ctx, http.MethodDelete, SessionMemberPath(sessionID, memberID), nil, nil)
+}
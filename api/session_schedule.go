@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// SessionSchedulePath returns the endpoint of the turn schedule a host set
+// for a session. It is not part of the API spec yet, servers without it
+// answer 404.
+func SessionSchedulePath(sessionID string) string {
+	return SessionPath(sessionID) + "/schedule"
+}
+
+// SessionSchedule is the turn cadence of a session: turns are generated
+// every CadenceHours, the next one at NextGenerationAt
+type SessionSchedule struct {
+	CadenceHours     int       `json:"cadence_hours"`
+	NextGenerationAt time.Time `json:"next_generation_at"`
+}
+
+// GetSessionSchedule retrieves the turn schedule of a session, nil when the
+// host set none (an empty schedule). Servers without turn schedules return
+// ErrNotSupported.
+func (c *Client) GetSessionSchedule(ctx context.Context, sessionID string) (*SessionSchedule, error) {
+	var schedule SessionSchedule
+	if err := c.optional(ctx, http.MethodGet, SessionSchedulePath(sessionID), nil, &schedule); err != nil {
+		return nil, err
+	}
+	if schedule.NextGenerationAt.IsZero() {
+		return nil, nil
+	}
+	return &schedule, nil
+}
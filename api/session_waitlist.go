@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// SessionWaitlistPath returns the endpoint of the waiting list of a full
+// session. It is not part of the API spec yet, servers without it answer 404.
+func SessionWaitlistPath(sessionID string) string {
+	return SessionPath(sessionID) + "/waitlist"
+}
+
+// SessionWaitlistEntryPath returns the endpoint of a user on the waiting list
+func SessionWaitlistEntryPath(sessionID, userProfileID string) string {
+	return SessionWaitlistPath(sessionID) + "/" + url.PathEscape(userProfileID)
+}
+
+// WaitlistEntry is a user waiting for a slot in a full session
+type WaitlistEntry struct {
+	UserProfileID string    `json:"user_profile_id"`
+	Position      int       `json:"position"` // 1 for the next user offered a slot
+	JoinedAt      time.Time `json:"joined_at"`
+	NotifiedAt    time.Time `json:"notified_at,omitempty"` // When a slot was offered, zero if not yet
+}
+
+// GetWaitlist retrieves the waiting list of a session (manager only).
+// Servers without waiting lists return ErrNotSupported.
+func (c *Client) GetWaitlist(ctx context.Context, sessionID string) ([]WaitlistEntry, error) {
+	var entries []WaitlistEntry
+	if err := c.optional(ctx, http.MethodGet, SessionWaitlistPath(sessionID), nil, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// JoinWaitlist puts the current user on the waiting list of a full session
+func (c *Client) JoinWaitlist(ctx context.Context, sessionID string) (*WaitlistEntry, error) {
+	var entry WaitlistEntry
+	if err := c.optional(ctx, http.MethodPost, SessionWaitlistPath(sessionID), nil, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// MoveWaitlistEntry moves a user to a position of the waiting list (manager only)
+func (c *Client) MoveWaitlistEntry(ctx context.Context, sessionID, userProfileID string, position int) ([]WaitlistEntry, error) {
+	body := map[string]int{"position": position}
+	var entries []WaitlistEntry
+	if err := c.optional(ctx, http.MethodPut, SessionWaitlistEntryPath(sessionID, userProfileID), body, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// RemoveWaitlistEntry takes a user off the waiting list. Users can remove
+// themselves, managers can remove anyone.
+func (c *Client) RemoveWaitlistEntry(ctx context.Context, sessionID, userProfileID string) error {
+	return c.optional(ctx, http.MethodDelete, SessionWaitlistEntryPath(sessionID, userProfileID), nil, nil)
+}
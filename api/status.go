@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+)
+
+// StatusPath is the server health endpoint. It is not part of the API spec
+// yet, servers without it answer 404.
+const StatusPath = APIBase + "/status"
+
+// Server states reported by the status endpoint
+const (
+	ServerStatusOK          = "ok"
+	ServerStatusDegraded    = "degraded"
+	ServerStatusMaintenance = "maintenance"
+)
+
+// ServerStatus is the health of a server and its planned maintenance windows
+type ServerStatus struct {
+	Status      string              `json:"status"`
+	Message     string              `json:"message,omitempty"`
+	Maintenance []MaintenanceWindow `json:"maintenance,omitempty"`
+}
+
+// MaintenanceWindow is a period the server is planned to be unavailable
+type MaintenanceWindow struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// ActiveMaintenance returns the maintenance window covering now, if any
+func (s *ServerStatus) ActiveMaintenance(now time.Time) *MaintenanceWindow {
+	for i, w := range s.Maintenance {
+		if !now.Before(w.Start) && now.Before(w.End) {
+			return &s.Maintenance[i]
+		}
+	}
+	return nil
+}
+
+// GetServerStatus retrieves the health of the server. It needs no
+// authentication, so it also answers while logging in fails. Servers without
+// the endpoint return ErrNotSupported.
+func (c *Client) GetServerStatus(ctx context.Context) (*ServerStatus, error) {
+	resp, err := c.doRequestRaw(ctx, http.MethodGet, StatusPath, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusNotImplemented:
+		_ = resp.Body.Close()
+		return nil, ErrNotSupported
+	case http.StatusServiceUnavailable:
+		// A server in maintenance may still describe itself
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		var status ServerStatus
+		if json.Unmarshal(body, &status) == nil && status.Status != "" {
+			return &status, nil
+		}
+		return nil, &StatusError{Op: "status", StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var status ServerStatus
+	if err := parseResponse(resp, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// SessionSyncPath returns the sync token endpoint of a session. It is not part
+// of the API spec yet, servers without it answer 404.
+func SessionSyncPath(sessionID string) string {
+	return SessionPath(sessionID) + "/sync"
+}
+
+// SyncToken is the last state of a session the server acknowledged for the
+// user, so that another machine playing the same session can tell it is stale
+type SyncToken struct {
+	Year      int       `json:"year"`       // year of the orders
+	OrderHash string    `json:"order_hash"` // SHA256 of the uploaded order file
+	Machine   string    `json:"machine,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetSyncToken retrieves the sync token of the user for a session, nil when
+// none was stored yet. Servers without sync tokens return ErrNotSupported.
+func (c *Client) GetSyncToken(ctx context.Context, sessionID string) (*SyncToken, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, SessionSyncPath(sessionID), nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusNotImplemented:
+		_ = resp.Body.Close()
+		return nil, ErrNotSupported
+	case http.StatusNoContent:
+		_ = resp.Body.Close()
+		return nil, nil
+	}
+
+	var token SyncToken
+	if err := parseResponse(resp, &token); err != nil {
+		return nil, err
+	}
+	if token.OrderHash == "" {
+		return nil, nil
+	}
+	return &token, nil
+}
+
+// SetSyncToken stores the sync token of the user for a session. Servers
+// without sync tokens return ErrNotSupported.
+func (c *Client) SetSyncToken(ctx context.Context, sessionID string, token *SyncToken) error {
+	resp, err := c.doRequest(ctx, http.MethodPut, SessionSyncPath(sessionID), token, true)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented {
+		_ = resp.Body.Close()
+		return ErrNotSupported
+	}
+	return parseResponse(resp, nil)
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// SessionTurnReadsPath returns the read receipts endpoint of a year of a
+// session. It is not part of the API spec yet, servers without it answer 404.
+func SessionTurnReadsPath(sessionID string, year int) string {
+	return SessionTurnPath(sessionID, year) + "/reads"
+}
+
+// TurnRead is a player opening their turn of a year
+type TurnRead struct {
+	PlayerOrder int       `json:"player_order"` // 0-15
+	ReadAt      time.Time `json:"read_at"`
+}
+
+// MarkTurnRead tells the server the user opened their turn of a year. Servers
+// without read receipts return ErrNotSupported.
+func (c *Client) MarkTurnRead(ctx context.Context, sessionID string, year int) error {
+	resp, err := c.doRequest(ctx, http.MethodPut, SessionTurnReadsPath(sessionID, year), nil, true)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented {
+		_ = resp.Body.Close()
+		return ErrNotSupported
+	}
+	return parseResponse(resp, nil)
+}
+
+// GetTurnReads retrieves which players opened their turn of a year (session
+// hosts only). Servers without read receipts return ErrNotSupported.
+func (c *Client) GetTurnReads(ctx context.Context, sessionID string, year int) ([]TurnRead, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, SessionTurnReadsPath(sessionID, year), nil, true)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusNotImplemented {
+		_ = resp.Body.Close()
+		return nil, ErrNotSupported
+	}
+
+	var reads []TurnRead
+	if err := parseResponse(resp, &reads); err != nil {
+		return nil, err
+	}
+	return reads, nil
+}
@@ -80,4 +80,11 @@ const (
 	NotificationTypeOrderStatus         = "order_status"
 	NotificationTypePendingRegistration = "pending_registration"
 	NotificationTypePlayerControl       = "player_control"
+	NotificationTypeSessionWaitlist     = "session_waitlist"
+	NotificationTypePresence            = "presence"
+	NotificationTypeLiveRound           = "live_round"
 )
+
+// NotificationActionSlotOpen is sent with NotificationTypeSessionWaitlist to
+// the next waitlisted user when a session slot opens
+const NotificationActionSlotOpen = "slot_open"
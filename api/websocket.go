@@ -2,6 +2,8 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -15,13 +17,44 @@ import (
 )
 
 const (
-	// Time allowed to read the next pong message from the server
-	pongWait = 60 * time.Second
+	// Default time allowed to read the next message (or ping/pong) from the server
+	DefaultPongWait = 60 * time.Second
+
+	// Default interval between pings sent to the server
+	DefaultPingInterval = 30 * time.Second
 
 	// Time allowed to write a message to the server
 	writeWait = 10 * time.Second
+
+	// Shortest ping interval the adaptive mode goes down to
+	minPingInterval = 5 * time.Second
+
+	// Connection lifetime after which the adaptive mode considers the link stable again
+	stableAfter = 5 * time.Minute
+
+	// Shortest interval between two checks of the silence watchdog
+	minWatchdogInterval = time.Second
 )
 
+// HeartbeatConfig controls the keep-alive of the notification connection
+type HeartbeatConfig struct {
+	PingInterval time.Duration // interval between client pings, 0 disables them
+	PongWait     time.Duration // the connection is considered dead after this long without traffic
+
+	// Adaptive halves the ping interval (down to 5s) after each dropped
+	// connection, so dead links on flaky networks are detected sooner, and
+	// goes back to PingInterval once a connection stays up for 5 minutes
+	Adaptive bool
+}
+
+// DefaultHeartbeatConfig returns the default keep-alive settings
+func DefaultHeartbeatConfig() HeartbeatConfig {
+	return HeartbeatConfig{
+		PingInterval: DefaultPingInterval,
+		PongWait:     DefaultPongWait,
+	}
+}
+
 // safeDeref returns the dereferenced string or empty string if nil
 func safeDeref(s *string) string {
 	if s == nil {
@@ -41,14 +74,53 @@ type NotificationClient struct {
 	connected     bool
 	reconnects    int
 	reconnecting  bool // Flag to suppress errors during intentional reconnection
+	heartbeat     HeartbeatConfig
+	drops         int // Consecutive dropped connections, for the adaptive heartbeat
+
+	// Silence watchdog: the last frame (message, ping or pong) received, on
+	// the wall clock, whether the watchdog closed the current connection, and
+	// how many connections died silently
+	lastActivity time.Time
+	silent       bool
+	silentDeaths int
+
+	// Session subscriptions: when set, per-session notifications of other
+	// sessions are dropped. nil means no filter (everything is delivered).
+	sessions map[string]bool
 }
 
 // NewNotificationClient creates a new notification client for the given server URL
 func NewNotificationClient(baseURL string) *NotificationClient {
 	return &NotificationClient{
-		baseURL: baseURL,
-		done:    make(chan struct{}),
+		baseURL:   baseURL,
+		done:      make(chan struct{}),
+		heartbeat: DefaultHeartbeatConfig(),
+	}
+}
+
+// SetHeartbeat sets the keep-alive settings, applied from the next connection
+func (nc *NotificationClient) SetHeartbeat(config HeartbeatConfig) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	if config.PongWait <= 0 {
+		config.PongWait = DefaultPongWait
+	}
+	nc.heartbeat = config
+}
+
+// heartbeatIntervals returns the ping interval and read wait of a new connection.
+// Must be called with nc.mu held.
+func (nc *NotificationClient) heartbeatIntervals() (time.Duration, time.Duration) {
+	interval, readWait := nc.heartbeat.PingInterval, nc.heartbeat.PongWait
+	if !nc.heartbeat.Adaptive || interval <= 0 || nc.drops == 0 {
+		return interval, readWait
 	}
+
+	interval >>= min(nc.drops, 3)
+	interval = max(interval, minPingInterval)
+	// Our pings are answered, so a dead link can be declared after a few missed pongs
+	readWait = min(readWait, 3*interval)
+	return interval, readWait
 }
 
 // SetOnNotify sets the callback for received notifications
@@ -65,6 +137,65 @@ func (nc *NotificationClient) SetOnError(callback func(error)) {
 	nc.onError = callback
 }
 
+// SetSessionSubscriptions replaces the sessions whose notifications are delivered.
+// The server broadcasts every resource change, so the filtering happens here:
+// session_turn, order_status and player_control notifications of other sessions
+// are dropped. Session, invitation and other lobby notifications always go through.
+func (nc *NotificationClient) SetSessionSubscriptions(sessionIDs []string) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.sessions = make(map[string]bool, len(sessionIDs))
+	for _, id := range sessionIDs {
+		nc.sessions[id] = true
+	}
+}
+
+// Subscribe adds a session to the subscriptions
+func (nc *NotificationClient) Subscribe(sessionID string) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	if nc.sessions == nil {
+		return // No filter, already receiving everything
+	}
+	nc.sessions[sessionID] = true
+}
+
+// Unsubscribe removes a session from the subscriptions
+func (nc *NotificationClient) Unsubscribe(sessionID string) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	delete(nc.sessions, sessionID)
+}
+
+// ClearSessionSubscriptions removes the filter, delivering all notifications again
+func (nc *NotificationClient) ClearSessionSubscriptions() {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	nc.sessions = nil
+}
+
+// subscribed returns whether a notification passes the session subscriptions.
+// Must be called with nc.mu held.
+func (nc *NotificationClient) subscribed(n async.ResourceChange) bool {
+	if nc.sessions == nil {
+		return true
+	}
+
+	switch safeDeref(n.Type) {
+	case NotificationTypeSessionTurn, NotificationTypeOrderStatus, NotificationTypeLiveRound:
+		return nc.sessions[safeDeref(n.ID)]
+	case NotificationTypePlayerControl:
+		meta, ok := n.Metadata.(map[string]interface{})
+		if !ok {
+			return true
+		}
+		sessionID, ok := meta["session_id"].(string)
+		return !ok || nc.sessions[sessionID]
+	default:
+		return true
+	}
+}
+
 // Connect establishes the WebSocket connection with the given JWT token
 func (nc *NotificationClient) Connect(token string) error {
 	nc.mu.Lock()
@@ -84,9 +215,10 @@ func (nc *NotificationClient) Connect(token string) error {
 	headers := http.Header{}
 	headers.Set("Authorization", "Bearer "+token)
 
-	// Connect with custom headers
+	// Connect with custom headers, negotiating permessage-deflate
 	dialer := websocket.Dialer{
-		HandshakeTimeout: 10 * time.Second,
+		HandshakeTimeout:  10 * time.Second,
+		EnableCompression: true,
 	}
 
 	conn, _, err := dialer.Dial(wsURL, headers)
@@ -97,10 +229,14 @@ func (nc *NotificationClient) Connect(token string) error {
 	nc.conn = conn
 	nc.connected = true
 	nc.reconnects = 0
+	nc.lastActivity = time.Now().Round(0)
+	nc.silent = false
+	pingInterval, pongWait := nc.heartbeatIntervals()
 
 	// Set up ping/pong handlers
 	// The server sends pings every 30 seconds, we need to respond with pongs
 	conn.SetPongHandler(func(string) error {
+		nc.touch()
 		if err := conn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
 			logger.WebSocket.Warn().Err(err).Msg("Failed to set read deadline")
 		}
@@ -109,6 +245,7 @@ func (nc *NotificationClient) Connect(token string) error {
 
 	// Also handle pings from server by automatically responding with pong
 	conn.SetPingHandler(func(appData string) error {
+		nc.touch()
 		if err := conn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
 			logger.WebSocket.Warn().Err(err).Msg("Failed to set read deadline")
 		}
@@ -124,9 +261,21 @@ func (nc *NotificationClient) Connect(token string) error {
 	}
 
 	// Start read loop in background
-	go nc.readLoop()
+	go nc.readLoop(pongWait)
+
+	// Keep the link alive from our side too, so dead links are detected on our schedule
+	if pingInterval > 0 {
+		go nc.pingLoop(conn, nc.done, pingInterval)
+	}
+
+	// Force a reconnection when nothing arrives for too long
+	go nc.watchdog(conn, nc.done, pongWait)
 
-	logger.WebSocket.Info().Str("url", wsURL).Msg("Connected")
+	logger.WebSocket.Info().
+		Str("url", wsURL).
+		Dur("pingInterval", pingInterval).
+		Dur("pongWait", pongWait).
+		Msg("Connected")
 	return nil
 }
 
@@ -164,6 +313,22 @@ func (nc *NotificationClient) IsConnected() bool {
 	return nc.connected
 }
 
+// SilentDeaths returns how many connections stayed open while nothing came
+// through anymore (e.g. a NAT mapping dropped without a FIN) and were
+// reconnected
+func (nc *NotificationClient) SilentDeaths() int {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	return nc.silentDeaths
+}
+
+// touch records that a frame was received on the current connection
+func (nc *NotificationClient) touch() {
+	nc.mu.Lock()
+	nc.lastActivity = time.Now().Round(0)
+	nc.mu.Unlock()
+}
+
 // buildWSURL converts the HTTP base URL to a WebSocket URL
 func (nc *NotificationClient) buildWSURL() (string, error) {
 	u, err := url.Parse(nc.baseURL)
@@ -189,8 +354,77 @@ func (nc *NotificationClient) buildWSURL() (string, error) {
 	return u.String(), nil
 }
 
+// pingLoop sends pings on a connection until it is closed
+func (nc *NotificationClient) pingLoop(conn *websocket.Conn, done chan struct{}, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	connectedAt := time.Now()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+				// The read loop reports the broken connection
+				return
+			}
+
+			if time.Since(connectedAt) >= stableAfter {
+				nc.mu.Lock()
+				nc.drops = 0
+				nc.mu.Unlock()
+			}
+		}
+	}
+}
+
+// watchdog closes a connection on which nothing (message, ping or pong) was
+// received for longer than window, so the read loop reports it and the
+// connection is reestablished. The read deadline catches most dead links,
+// the watchdog compares wall clock times so it also catches the links that
+// died while the computer slept, right after it wakes up.
+func (nc *NotificationClient) watchdog(conn *websocket.Conn, done chan struct{}, window time.Duration) {
+	ticker := time.NewTicker(max(window/4, minWatchdogInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			nc.mu.Lock()
+			if nc.conn != conn {
+				nc.mu.Unlock()
+				return // Replaced by a reconnection
+			}
+			silence := time.Now().Round(0).Sub(nc.lastActivity)
+			if silence <= window {
+				nc.mu.Unlock()
+				continue
+			}
+			nc.silent = true
+			nc.mu.Unlock()
+
+			logger.WebSocket.Warn().
+				Dur("silence", silence).
+				Msg("Nothing received for too long, forcing reconnection")
+			if err := conn.Close(); err != nil {
+				logger.WebSocket.Warn().Err(err).Msg("Failed to close silent connection")
+			}
+			return
+		}
+	}
+}
+
+// isTimeout returns whether a read error is the read deadline expiring
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // readLoop continuously reads messages from the WebSocket
-func (nc *NotificationClient) readLoop() {
+func (nc *NotificationClient) readLoop(pongWait time.Duration) {
 	defer func() {
 		nc.mu.Lock()
 		nc.connected = false
@@ -233,6 +467,18 @@ func (nc *NotificationClient) readLoop() {
 
 				// Only log and call error handler if this isn't an intentional reconnection
 				if !reconnecting {
+					nc.mu.Lock()
+					nc.drops++
+					silent := nc.silent || isTimeout(err)
+					if silent {
+						nc.silentDeaths++
+					}
+					nc.mu.Unlock()
+
+					if silent {
+						logger.WebSocket.Warn().Msg("Connection died silently")
+					}
+
 					logger.WebSocket.Error().Err(err).Msg("Read error")
 					if onError != nil {
 						onError(err)
@@ -242,6 +488,7 @@ func (nc *NotificationClient) readLoop() {
 			}
 
 			// Reset read deadline after receiving a message
+			nc.touch()
 			if err := conn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
 				logger.WebSocket.Warn().Err(err).Msg("Failed to reset read deadline")
 			}
@@ -259,11 +506,20 @@ func (nc *NotificationClient) readLoop() {
 				Str("action", safeDeref(notification.Action)).
 				Msg("Received notification")
 
-			// Call the notification handler
+			// Call the notification handler, unless filtered out by the subscriptions
 			nc.mu.Lock()
 			onNotify := nc.onNotify
+			subscribed := nc.subscribed(notification)
 			nc.mu.Unlock()
 
+			if !subscribed {
+				logger.WebSocket.Debug().
+					Str("type", safeDeref(notification.Type)).
+					Str("id", safeDeref(notification.ID)).
+					Msg("Dropped notification of unsubscribed session")
+				continue
+			}
+
 			if onNotify != nil {
 				onNotify(notification)
 			}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
 	"sync"
+	"time"
 
 	"github.com/gen2brain/beeep"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -10,11 +14,16 @@ import (
 	"github.com/neper-stars/astrum/api"
 	"github.com/neper-stars/astrum/database"
 	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/accessibility"
 	"github.com/neper-stars/astrum/lib/auth"
+	"github.com/neper-stars/astrum/lib/eventbatch"
 	"github.com/neper-stars/astrum/lib/filehash"
 	"github.com/neper-stars/astrum/lib/logger"
 	"github.com/neper-stars/astrum/lib/monitor"
 	"github.com/neper-stars/astrum/lib/notification"
+	"github.com/neper-stars/astrum/lib/platform"
+	"github.com/neper-stars/astrum/lib/telemetry"
+	"github.com/neper-stars/astrum/lib/workqueue"
 )
 
 // =============================================================================
@@ -23,8 +32,9 @@ import (
 
 // App struct holds application state and is exposed to the frontend
 type App struct {
-	ctx    context.Context
-	config *astrum.Config
+	ctx     context.Context
+	config  *astrum.Config
+	profile string // configuration profile, see astrum.ProfilePath
 
 	mu                   sync.RWMutex
 	clients              map[string]*api.Client           // serverURL -> client
@@ -33,19 +43,107 @@ type App struct {
 	orderMonitors        map[string]*monitor.Manager      // serverURL -> order file monitor
 	connections          map[string]*ConnectionState      // serverURL -> connection state
 	fileHashTracker      *filehash.Tracker                // tracks file hashes to avoid unnecessary writes
+	profileCaches        map[string]*userProfileCache     // serverURL -> cached user profiles
+	stopInvitationPolicy chan struct{}                    // closed on shutdown to stop the invitation policy job
+	stopAccessibility    chan struct{}                    // closed on shutdown to stop watching accessibility preferences
+	accessibility        accessibility.Preferences        // last known OS accessibility preferences
+	telemetry            *telemetry.Recorder              // usage counts for the opt-in telemetry
+	localAPIMu           sync.Mutex                       // guards localAPI
+	localAPI             *http.Server                     // localhost HTTP API, nil when stopped
+	hookRuns             []HookRunInfo                    // most recent hook runs, oldest first
+	mapTilesMu           sync.Mutex                       // serializes map tile rendering
+	timelineMu           sync.Mutex                       // serializes session timeline updates
+	ignoreMu             sync.Mutex                       // serializes ignore list updates
+	launchMu             sync.Mutex                       // serializes launch history updates
+	runningMu            sync.Mutex                       // guards running
+	running              map[*exec.Cmd]runningLaunch      // Stars! processes started by Astrum, until they exit
+	undoMu               sync.Mutex                       // guards undos
+	undos                map[string]pendingUndo           // undo token -> order upload that can still be undone
+	opsMu                sync.Mutex                       // guards operations and their state
+	operations           map[string]*operation            // opID -> long operation, see StartOperation
+	finishedGames        map[string]bool                  // serverURL|sessionID -> game-finished hook already run
+	ordersStatus         map[string]*OrdersStatusInfo     // serverURL|sessionID -> last known order status
+	statusFileMu         sync.Mutex                       // serializes status file writes
+	work                 *workqueue.Queue                 // background work, turn handling before bulk jobs
+	kioskMu              sync.Mutex                       // guards kioskFailures and kioskRetryAt
+	kioskFailures        int                              // wrong guest mode PINs in a row
+	kioskRetryAt         time.Time                        // no PIN accepted before, after too many wrong ones
+	autoConnectOnce      sync.Once                        // startup connections are made on the first page load only
+	autoConnectMu        sync.Mutex                       // guards autoConnect
+	autoConnect          map[string]*AutoConnectInfo      // serverURL -> startup connection status
+	keyringLocked        bool                             // the keyring could not be read and is waited for, guarded by mu
+	altConnMu            sync.Mutex                       // guards altConnections
+	altConnections       map[string]*altConnection        // serverURL|nickname -> connection of a session credential
+	connectMu            sync.Mutex                       // guards connecting
+	connecting           map[string]context.CancelFunc    // serverURL -> cancels the Connect in progress
+	raceWatcher          *monitor.RaceWatcher             // watches game directories and the workshop folder for races
+	raceDirs             map[string]watchedRaceDir        // directory -> session it belongs to, guarded by mu
+	generationMu         sync.Mutex                       // guards generations
+	generations          map[string]*GenerationStatusInfo // serverURL|sessionID -> last followed turn generation
+	turnCycleLast        string                           // serverURL|sessionID last opened by AdvanceTurnCycle, guarded by mu
+	noAnnouncements      map[string]bool                  // serverURL -> the server has no announcement endpoint, guarded by mu
+	noCapacity           map[string]bool                  // serverURL -> the server has no capacity endpoint, guarded by mu
+	noDevices            map[string]bool                  // serverURL -> the server has no device endpoint, guarded by mu
+	noPresence           map[string]bool                  // serverURL -> the server has no presence endpoint, guarded by mu
+	noDemote             map[string]bool                  // serverURL -> the server cannot demote managers, guarded by mu
+	noMemberRemoval      map[string]bool                  // serverURL -> the server cannot remove members, guarded by mu
+	noLeaderboard        map[string]bool                  // serverURL -> the server has no leaderboard, guarded by mu
+	noSchedule           map[string]bool                  // serverURL -> the server has no turn schedules, guarded by mu
+	deadlines            map[string]*DeadlineInfo         // serverURL|sessionID -> nearing deadline of our missing orders, guarded by mu
+	otherDevices         map[string][]DeviceInfo          // serverURL -> other machines the user is connected from, guarded by mu
+	serverStatuses       map[string]*ServerStatusInfo     // serverURL -> last known server status, guarded by mu
+	events               *eventbatch.Batcher              // delivers the events to the frontend, see emit
+	manifestMu           sync.Mutex                       // serializes game directory manifest updates
+	startupMu            sync.Mutex                       // guards startupPhases and windowShownMs
+	startupPhases        []StartupPhaseInfo               // startup timing trace, see GetDiagnostics
+	windowShownMs        int64                            // milliseconds from process start to the frontend loaded
 	shuttingDown         bool                             // true when app is shutting down
-	notificationIcon     []byte                           // icon data for desktop notifications
+	notificationIcon     any                              // icon data or file for desktop notifications, see platform.NotificationIcon
+	instanceWarnings     []string                         // why running next to another instance is risky, see GetInstanceWarnings
+	safeStartReason      string                           // why the app started in safe mode after crashing, see GetSafeModeReason
 }
 
-// NewApp creates a new App instance
-func NewApp() *App {
-	return &App{
+// backgroundWorkers is the number of workers running background work
+const backgroundWorkers = 3
+
+// NewApp creates a new App instance using a configuration profile
+func NewApp(profile string) *App {
+	a := &App{
+		profile:              profile,
 		clients:              make(map[string]*api.Client),
 		authManagers:         make(map[string]*auth.Manager),
 		notificationManagers: make(map[string]*notification.Manager),
 		orderMonitors:        make(map[string]*monitor.Manager),
 		connections:          make(map[string]*ConnectionState),
+		profileCaches:        make(map[string]*userProfileCache),
+		stopInvitationPolicy: make(chan struct{}),
+		stopAccessibility:    make(chan struct{}),
+		finishedGames:        make(map[string]bool),
+		ordersStatus:         make(map[string]*OrdersStatusInfo),
+		autoConnect:          make(map[string]*AutoConnectInfo),
+		altConnections:       make(map[string]*altConnection),
+		connecting:           make(map[string]context.CancelFunc),
+		raceDirs:             make(map[string]watchedRaceDir),
+		generations:          make(map[string]*GenerationStatusInfo),
+		noAnnouncements:      make(map[string]bool),
+		noCapacity:           make(map[string]bool),
+		noDevices:            make(map[string]bool),
+		noPresence:           make(map[string]bool),
+		noDemote:             make(map[string]bool),
+		noMemberRemoval:      make(map[string]bool),
+		noLeaderboard:        make(map[string]bool),
+		noSchedule:           make(map[string]bool),
+		deadlines:            make(map[string]*DeadlineInfo),
+		otherDevices:         make(map[string][]DeviceInfo),
+		serverStatuses:       make(map[string]*ServerStatusInfo),
+		running:              make(map[*exec.Cmd]runningLaunch),
+		undos:                make(map[string]pendingUndo),
+		operations:           make(map[string]*operation),
+		work:                 workqueue.New(backgroundWorkers),
+		telemetry:            telemetry.NewRecorder(),
 	}
+	a.events = a.newEventBatcher()
+	return a
 }
 
 // SetNotificationIcon stores the embedded icon data for use in desktop notifications
@@ -53,36 +151,58 @@ func (a *App) SetNotificationIcon(iconData []byte) {
 	if len(iconData) == 0 {
 		return
 	}
-	a.notificationIcon = iconData
+	a.notificationIcon = platform.NotificationIcon(iconData, astrum.IconPath())
 	logger.App.Debug().Int("size", len(iconData)).Msg("Notification icon ready")
 }
 
+// SetInstanceWarnings stores the warnings of an instance started next to
+// another one with --allow-multiple
+func (a *App) SetInstanceWarnings(warnings []string) {
+	a.instanceWarnings = warnings
+}
+
 // startup is called when the app starts
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	start := a.tracePhase("runtime", processStart, false)
+
+	// Before anything that could crash: count this startup until it gets through
+	a.beginStartup()
 
 	// Set app name for desktop notifications
 	beeep.AppName = "Astrum"
 
 	// Open database (BBolt)
-	db, err := database.Open(astrum.ConfigPath())
+	db, err := database.Open(astrum.ProfilePath(a.profile))
 	if err != nil {
+		showErrorDialog(fmt.Sprintf("Failed to open database: %v", err))
 		logger.App.Fatal().Err(err).Msg("Failed to open database")
 	}
+	start = a.tracePhase("database", start, false)
 
 	// Create config
 	config, err := astrum.NewConfig(db)
 	if err != nil {
+		showErrorDialog(fmt.Sprintf("Failed to create config: %v", err))
 		logger.App.Fatal().Err(err).Msg("Failed to create config")
 	}
 	a.config = config
-
-	// Create file hash tracker with DB persistence
-	tracker, err := filehash.NewTracker(db)
-	if err != nil {
-		logger.App.Fatal().Err(err).Msg("Failed to create file hash tracker")
+	a.openLogFile()
+	a.applyLogOutput()
+	if err := a.config.InitProfile(a.profile); err != nil {
+		logger.App.Warn().Err(err).Str("profile", a.profile).Msg("Failed to initialize profile settings")
 	}
-	a.fileHashTracker = tracker
+
+	// Before any game file or directory is created
+	a.applyFilePermissions()
+
+	// File hash tracker with DB persistence, the hashes are loaded after the
+	// window is shown (see deferredStartup) or on first use
+	a.fileHashTracker = filehash.NewLazyTracker(db)
+
+	// Restore the usage counts not sent yet
+	a.restoreTelemetryState()
+	start = a.tracePhase("config", start, false)
 
 	// Ensure servers directory exists
 	if err := a.config.EnsureServersDir(); err != nil {
@@ -93,11 +213,46 @@ func (a *App) startup(ctx context.Context) {
 	if err := a.EnsureDefaultServer(); err != nil {
 		logger.App.Warn().Err(err).Msg("Failed to ensure default server")
 	}
+	start = a.tracePhase("servers", start, false)
 
 	// Restore window geometry from previous session
 	a.restoreWindowGeometry(ctx)
 
-	logger.App.Info().Msg("Application started successfully")
+	// Read the OS accessibility preferences and watch them for changes
+	a.mu.Lock()
+	a.accessibility = accessibility.Detect()
+	a.mu.Unlock()
+	go a.accessibilityLoop()
+	start = a.tracePhase("window", start, false)
+
+	// Periodically apply the invitation expiry and digest policy, and the submission digest
+	go a.invitationPolicyLoop()
+
+	// Watch for races created with the Stars! race wizard
+	if !a.inSafeStart() {
+		a.startRaceWatcher()
+		a.tracePhase("race watcher", start, false)
+	}
+
+	logger.App.Info().Str("profile", a.profile).Msg("Application started successfully")
+}
+
+// domReady is called when the frontend has loaded, and again on reloads
+func (a *App) domReady(ctx context.Context) {
+	// Connect now rather than in startup, so the frontend receives the status events
+	a.autoConnectOnce.Do(func() {
+		a.windowShown()
+		time.AfterFunc(startupGracePeriod, a.confirmStartup)
+		if a.inSafeStart() {
+			return // No connection nor local API after a crash loop
+		}
+		go a.deferredStartup()
+		go func() {
+			start := time.Now()
+			a.autoConnectServers()
+			a.tracePhase("auto-connect", start, true)
+		}()
+	})
 }
 
 // beforeClose is called before the window closes (while GTK window is still valid)
@@ -114,6 +269,12 @@ func (a *App) shutdown(ctx context.Context) {
 	a.mu.Lock()
 	a.shuttingDown = true
 	a.mu.Unlock()
+	a.events.Stop()
+
+	close(a.stopInvitationPolicy)
+	close(a.stopAccessibility)
+	a.stopLocalAPI()
+	a.work.Stop()
 
 	// Collect managers to disconnect (avoid holding lock during disconnect
 	// which would deadlock with the connection state callback)
@@ -133,18 +294,23 @@ func (a *App) shutdown(ctx context.Context) {
 		logger.App.Debug().Str("url", url).Msg("Stopping order monitor")
 		orderMonitors = append(orderMonitors, mgr)
 	}
+	raceWatcher := a.raceWatcher
 	a.mu.Unlock()
 
 	// Disconnect all managers (this may trigger callbacks that need the lock)
 	for _, mgr := range orderMonitors {
 		mgr.Stop()
 	}
+	if raceWatcher != nil {
+		raceWatcher.Stop()
+	}
 	for _, mgr := range notifManagers {
 		mgr.Disconnect()
 	}
 	for _, mgr := range authManagers {
 		mgr.Disconnect()
 	}
+	a.disconnectAltConnections("")
 
 	// Clear the maps
 	a.mu.Lock()
@@ -157,9 +323,13 @@ func (a *App) shutdown(ctx context.Context) {
 
 	// Close database
 	if a.config != nil {
+		a.saveTelemetryState()
 		a.config.OnShutdown()
 	}
 
+	// Exiting is not crashing
+	a.confirmStartup()
+
 	logger.App.Info().Msg("Application shutdown complete")
 }
 
@@ -167,39 +337,62 @@ func (a *App) shutdown(ctx context.Context) {
 // WINDOW GEOMETRY PERSISTENCE
 // =============================================================================
 
-// saveWindowGeometry saves the current window position and size
+// saveWindowGeometry saves the current window position, size and maximized
+// state for the current monitor setup
 func (a *App) saveWindowGeometry(ctx context.Context) {
 	if a.config == nil {
 		return
 	}
 
-	width, height := runtime.WindowGetSize(ctx)
-	x, y := runtime.WindowGetPosition(ctx)
+	screens := a.screensKey(ctx)
 
-	geom := &astrum.WindowGeometry{
-		X:      x,
-		Y:      y,
-		Width:  width,
-		Height: height,
+	var geom *astrum.WindowGeometry
+	if runtime.WindowIsMaximised(ctx) {
+		// The maximized size is the screen size: keep the size to restore
+		// when leaving the maximized state
+		saved, err := a.config.GetWindowGeometry(screens)
+		if err != nil || saved == nil {
+			saved = &astrum.WindowGeometry{Width: defaultWindowWidth, Height: defaultWindowHeight}
+		}
+		geom = &astrum.WindowGeometry{
+			X:         saved.X,
+			Y:         saved.Y,
+			Width:     saved.Width,
+			Height:    saved.Height,
+			Maximized: true,
+		}
+	} else {
+		width, height := runtime.WindowGetSize(ctx)
+		x, y := runtime.WindowGetPosition(ctx)
+		geom = &astrum.WindowGeometry{
+			X:      x,
+			Y:      y,
+			Width:  width,
+			Height: height,
+		}
 	}
 
-	if err := a.config.SetWindowGeometry(geom); err != nil {
+	if err := a.config.SetWindowGeometry(screens, geom); err != nil {
 		logger.App.Warn().Err(err).Msg("Failed to save window geometry")
 	} else {
 		logger.App.Debug().
-			Int("x", x).Int("y", y).
-			Int("width", width).Int("height", height).
+			Str("screens", screens).
+			Int("x", geom.X).Int("y", geom.Y).
+			Int("width", geom.Width).Int("height", geom.Height).
+			Bool("maximized", geom.Maximized).
 			Msg("Window geometry saved")
 	}
 }
 
-// restoreWindowGeometry restores the saved window position and size if valid
+// restoreWindowGeometry restores the window position, size and maximized
+// state saved for the current monitor setup, if valid
 func (a *App) restoreWindowGeometry(ctx context.Context) {
 	if a.config == nil {
 		return
 	}
 
-	geom, err := a.config.GetWindowGeometry()
+	screens := a.screensKey(ctx)
+	geom, err := a.config.GetWindowGeometry(screens)
 	if err != nil {
 		logger.App.Warn().Err(err).Msg("Failed to get saved window geometry")
 		return
@@ -221,13 +414,48 @@ func (a *App) restoreWindowGeometry(ctx context.Context) {
 	// Restore position and size
 	runtime.WindowSetPosition(ctx, geom.X, geom.Y)
 	runtime.WindowSetSize(ctx, geom.Width, geom.Height)
+	if geom.Maximized {
+		runtime.WindowMaximise(ctx)
+	}
 
 	logger.App.Debug().
+		Str("screens", screens).
 		Int("x", geom.X).Int("y", geom.Y).
 		Int("width", geom.Width).Int("height", geom.Height).
+		Bool("maximized", geom.Maximized).
 		Msg("Window geometry restored")
 }
 
+// ResetWindowLayout forgets the saved window geometries and brings the window
+// back to its default size, centered, e.g. when it was restored off-screen
+func (a *App) ResetWindowLayout() error {
+	if err := a.config.ResetWindowGeometry(); err != nil {
+		return fmt.Errorf("failed to reset window layout: %w", err)
+	}
+
+	runtime.WindowUnmaximise(a.ctx)
+	runtime.WindowSetSize(a.ctx, defaultWindowWidth, defaultWindowHeight)
+	runtime.WindowCenter(a.ctx)
+
+	logger.App.Info().Msg("Window layout reset")
+	return nil
+}
+
+// screensKey identifies the current monitor setup, empty if unknown
+func (a *App) screensKey(ctx context.Context) string {
+	screens, err := runtime.ScreenGetAll(ctx)
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to get screen info")
+		return ""
+	}
+
+	sizes := make([][2]int, 0, len(screens))
+	for _, screen := range screens {
+		sizes = append(sizes, [2]int{screen.Size.Width, screen.Size.Height})
+	}
+	return astrum.ScreensKey(sizes)
+}
+
 // isGeometryOnScreen checks if the window geometry would be reasonably visible
 // Wails Screen doesn't provide X/Y positions, so we validate against screen dimensions
 func (a *App) isGeometryOnScreen(ctx context.Context, geom *astrum.WindowGeometry) bool {
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"time"
+
+	"github.com/neper-stars/astrum/lib/accessibility"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// SYSTEM ACCESSIBILITY PREFERENCES
+// =============================================================================
+
+// accessibilityPollInterval is how often the OS preferences are checked for changes
+const accessibilityPollInterval = 15 * time.Second
+
+// GetSystemAccessibility returns the accessibility preferences of the operating system
+func (a *App) GetSystemAccessibility() *SystemAccessibilityInfo {
+	a.mu.RLock()
+	prefs := a.accessibility
+	a.mu.RUnlock()
+
+	return &SystemAccessibilityInfo{
+		ReducedMotion: prefs.ReducedMotion,
+		HighContrast:  prefs.HighContrast,
+	}
+}
+
+// accessibilityLoop watches the OS preferences, emitting
+// "accessibility:changed" (SystemAccessibilityInfo) when they change
+func (a *App) accessibilityLoop() {
+	ticker := time.NewTicker(accessibilityPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.stopAccessibility:
+			return
+		case <-ticker.C:
+			prefs := accessibility.Detect()
+
+			a.mu.Lock()
+			changed := prefs != a.accessibility
+			a.accessibility = prefs
+			shuttingDown := a.shuttingDown
+			a.mu.Unlock()
+
+			if !changed || shuttingDown {
+				continue
+			}
+			logger.App.Info().
+				Bool("reducedMotion", prefs.ReducedMotion).
+				Bool("highContrast", prefs.HighContrast).
+				Msg("System accessibility preferences changed")
+			a.emit("accessibility:changed", a.GetSystemAccessibility())
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// SERVER ANNOUNCEMENTS
+// =============================================================================
+
+// announcementInterval is how often the announcements of connected servers are checked
+const announcementInterval = 15 * time.Minute
+
+// cacheKeyAnnouncement is the server cache key of the last known announcement
+const cacheKeyAnnouncement = "announcement"
+
+// GetServerAnnouncement returns the current announcement of a server, nil
+// when there is none or the server does not publish announcements. While
+// offline the last known announcement is returned, flagged as stale.
+func (a *App) GetServerAnnouncement(serverURL string) (*ServerAnnouncementInfo, error) {
+	announcement, err := a.fetchAnnouncement(serverURL)
+	stale := false
+	if err != nil {
+		if !useServerCache(err) || !a.cachedServerData(serverURL, cacheKeyAnnouncement, &announcement) {
+			return nil, err
+		}
+		stale = true
+	}
+	if announcement == nil {
+		return nil, nil
+	}
+
+	info, err := a.announcementInfo(serverURL, announcement)
+	if err != nil {
+		return nil, err
+	}
+	info.Stale = stale
+	return info, nil
+}
+
+// MarkServerAnnouncementRead marks the last known announcement of a server as
+// read. An announcement shows as unread again when the admins update it.
+func (a *App) MarkServerAnnouncementRead(serverURL string) error {
+	var announcement *api.Announcement
+	if !a.cachedServerData(serverURL, cacheKeyAnnouncement, &announcement) || announcement == nil {
+		return fmt.Errorf("no announcement on %s", serverURL)
+	}
+	return a.config.SetAnnouncementRead(serverURL, announcementVersion(announcement))
+}
+
+// fetchAnnouncement retrieves the announcement of a server and caches it. A
+// server without announcements is remembered until the next connection.
+func (a *App) fetchAnnouncement(serverURL string) (*api.Announcement, error) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	unsupported := a.noAnnouncements[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk {
+		return nil, fmt.Errorf("%w: %s", ErrNotConnected, serverURL)
+	}
+	if unsupported {
+		return nil, nil
+	}
+
+	announcement, err := client.GetAnnouncement(mgr.GetContext())
+	if errors.Is(err, api.ErrNotSupported) {
+		a.mu.Lock()
+		a.noAnnouncements[serverURL] = true
+		a.mu.Unlock()
+		a.cacheServerData(serverURL, cacheKeyAnnouncement, nil)
+		logger.App.Debug().Str("serverUrl", serverURL).Msg("Server has no announcements")
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcement: %w", err)
+	}
+
+	a.cacheServerData(serverURL, cacheKeyAnnouncement, announcement)
+	return announcement, nil
+}
+
+// refreshAnnouncement fetches the announcement of a server and emits
+// "server:announcement" (serverURL, announcement or nil) when it changed
+func (a *App) refreshAnnouncement(serverURL string) {
+	if a.inMaintenance(serverURL) {
+		return
+	}
+
+	var previous *api.Announcement
+	a.cachedServerData(serverURL, cacheKeyAnnouncement, &previous)
+
+	announcement, err := a.fetchAnnouncement(serverURL)
+	if err != nil {
+		logger.App.Debug().Err(err).Str("serverUrl", serverURL).Msg("Failed to refresh announcement")
+		return
+	}
+	if sameAnnouncement(previous, announcement) {
+		return
+	}
+
+	var info *ServerAnnouncementInfo
+	if announcement != nil {
+		if info, err = a.announcementInfo(serverURL, announcement); err != nil {
+			logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to read announcement state")
+			return
+		}
+		logger.App.Info().Str("serverUrl", serverURL).Str("title", announcement.Title).Msg("Server announcement changed")
+	}
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("server:announcement", serverURL, info)
+	}
+}
+
+// refreshAnnouncementsForAll refreshes the announcements of every connected server
+func (a *App) refreshAnnouncementsForAll() {
+	a.mu.RLock()
+	serverURLs := make([]string, 0, len(a.clients))
+	for url := range a.clients {
+		serverURLs = append(serverURLs, url)
+	}
+	a.mu.RUnlock()
+	sort.Strings(serverURLs)
+
+	for _, url := range serverURLs {
+		a.refreshAnnouncement(url)
+	}
+}
+
+// announcementInfo converts an announcement, with its read state
+func (a *App) announcementInfo(serverURL string, announcement *api.Announcement) (*ServerAnnouncementInfo, error) {
+	read, err := a.config.GetAnnouncementRead(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	return &ServerAnnouncementInfo{
+		ID:        announcement.ID,
+		Title:     announcement.Title,
+		Message:   announcement.Message,
+		Severity:  announcement.Severity,
+		UpdatedAt: announcement.UpdatedAt,
+		Read:      read == announcementVersion(announcement),
+	}, nil
+}
+
+// announcementVersion identifies a version of an announcement for the read state
+func announcementVersion(announcement *api.Announcement) string {
+	return announcement.ID + "@" + announcement.UpdatedAt.UTC().Format(time.RFC3339Nano)
+}
+
+// sameAnnouncement tells whether two announcements are the same version
+func sameAnnouncement(x, y *api.Announcement) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return announcementVersion(x) == announcementVersion(y)
+}
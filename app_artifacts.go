@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/neper-stars/astrum/lib/artifact"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// GENERATED ARTIFACTS
+// =============================================================================
+
+// artifactDirs maps the game directory subfolders holding generated files to their kind
+var artifactDirs = map[string]string{
+	"maps":             "map",
+	"exports":          "export",
+	screenshotsDirName: "screenshot",
+	"plugins":          "plugin",
+}
+
+// writeArtifact writes a generated file following the collision policy setting and
+// returns the path actually written
+func (a *App) writeArtifact(path string, data []byte, overwrite bool) (string, error) {
+	policy := artifact.PolicyOverwrite
+	if settings, err := a.config.GetAppSettings(); err == nil {
+		policy = settings.GetArtifactCollisionPolicy()
+	}
+
+	written, err := artifact.Write(path, data, policy, overwrite)
+	if err != nil {
+		return "", err
+	}
+	if written != path {
+		logger.App.Debug().Str("path", path).Str("written", written).Msg("Artifact exists, wrote a timestamped copy")
+	}
+	return written, nil
+}
+
+// ListGeneratedArtifacts lists the maps, GIFs, exports, screenshots and plugin outputs of a session, newest first
+func (a *App) ListGeneratedArtifacts(serverURL, sessionID string) ([]GeneratedArtifactInfo, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []GeneratedArtifactInfo{}
+	err = filepath.WalkDir(gameDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(gameDir, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+
+		if d.IsDir() {
+			// Only descend into the artifact folders
+			if path != gameDir && artifactDirs[parts[0]] == "" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		kind := artifactKind(parts)
+		if kind == "" {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		result = append(result, GeneratedArtifactInfo{
+			Kind:       kind,
+			Name:       filepath.ToSlash(rel),
+			Path:       path,
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list artifacts: %w", err)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ModifiedAt.After(result[j].ModifiedAt) })
+	return result, nil
+}
+
+// artifactKind classifies a file of the game directory given its path elements
+func artifactKind(parts []string) string {
+	name := strings.ToLower(parts[len(parts)-1])
+	if len(parts) == 1 {
+		switch {
+		case strings.HasSuffix(name, "-map.svg"):
+			return "map"
+		case strings.HasSuffix(name, ".gif"):
+			return "gif"
+		}
+		return ""
+	}
+	return artifactDirs[parts[0]]
+}
@@ -1,17 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/gen2brain/beeep"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
 
 	"github.com/neper-stars/astrum/api"
 	"github.com/neper-stars/astrum/api/async"
+	astrum "github.com/neper-stars/astrum/lib"
 	"github.com/neper-stars/astrum/lib/auth"
 	"github.com/neper-stars/astrum/lib/logger"
 	"github.com/neper-stars/astrum/lib/notification"
+	"github.com/neper-stars/astrum/lib/telemetry"
+	"github.com/neper-stars/astrum/lib/workqueue"
+	"github.com/neper-stars/astrum/model"
 )
 
 // =============================================================================
@@ -29,6 +37,24 @@ func (a *App) Connect(serverURL, username, password string) (*ConnectResult, err
 		return nil, fmt.Errorf("server not found: %s", serverURL)
 	}
 
+	// One Connect at a time per server, so two attempts don't race their managers
+	ctx, err := a.beginConnect(serverURL)
+	if err != nil {
+		return nil, err
+	}
+	defer a.endConnect(serverURL)
+
+	// Connecting again replaces the current connection
+	a.mu.RLock()
+	_, connected := a.authManagers[serverURL]
+	a.mu.RUnlock()
+	if connected {
+		if err := a.Disconnect(serverURL); err != nil {
+			return nil, err
+		}
+	}
+	a.setConnecting(serverURL, true)
+
 	// Create API client
 	client := api.NewClient(serverURL)
 
@@ -37,6 +63,7 @@ func (a *App) Connect(serverURL, username, password string) (*ConnectResult, err
 
 	// Create notification manager
 	notifMgr := notification.NewManager(serverURL)
+	notifMgr.SetHeartbeat(a.webSocketHeartbeat())
 
 	// Set up notification callbacks
 	a.setupNotificationCallbacks(notifMgr, serverURL)
@@ -71,7 +98,7 @@ func (a *App) Connect(serverURL, username, password string) (*ConnectResult, err
 		}
 
 		// Emit connection state change event
-		runtime.EventsEmit(a.ctx, "connection:changed", serverURL, connected)
+		a.emit("connection:changed", serverURL, connected)
 	})
 
 	// Wire auth token refresh to notification manager reconnect
@@ -82,9 +109,20 @@ func (a *App) Connect(serverURL, username, password string) (*ConnectResult, err
 	})
 
 	// Connect auth (this will trigger OnTokenRefreshed which connects notifications)
-	if err := authMgr.Connect(username, password); err != nil {
-		return nil, fmt.Errorf("connection failed: %w", err)
+	if err := authMgr.ConnectContext(ctx, username, password); err != nil {
+		if ctx.Err() != nil {
+			return nil, ErrConnectCanceled
+		}
+		a.telemetry.Error(telemetry.ErrorConnect)
+		return nil, fmt.Errorf("connection failed: %w", a.maintenanceError(serverURL, err))
+	}
+	if ctx.Err() != nil {
+		// Canceled once authenticated: drop the connection
+		notifMgr.Disconnect()
+		authMgr.Disconnect()
+		return nil, ErrConnectCanceled
 	}
+	a.telemetry.Count(telemetry.FeatureConnect)
 
 	// Start polling fallback
 	notifMgr.StartPolling()
@@ -104,6 +142,30 @@ func (a *App) Connect(serverURL, username, password string) (*ConnectResult, err
 	// Start monitoring for sessions where we are participating
 	go a.startMonitoringForServer(serverURL)
 
+	// Only receive the turn and order notifications of our own sessions
+	go a.syncSessionSubscriptions(serverURL)
+
+	// Catch up on turns generated while the app was closed
+	a.queueTurnReconciliation(serverURL)
+
+	// Apply the invitation expiry policy to invitations received while offline
+	go a.runInvitationPolicy(serverURL)
+
+	// The server may have been upgraded since it last lacked optional endpoints
+	a.mu.Lock()
+	delete(a.noAnnouncements, serverURL)
+	delete(a.noCapacity, serverURL)
+	delete(a.noDevices, serverURL)
+	delete(a.noPresence, serverURL)
+	delete(a.noDemote, serverURL)
+	delete(a.noMemberRemoval, serverURL)
+	delete(a.noLeaderboard, serverURL)
+	delete(a.noSchedule, serverURL)
+	a.mu.Unlock()
+	go a.refreshAnnouncement(serverURL)
+	go a.reportDevice(serverURL)
+	go a.sendHeartbeat(serverURL)
+
 	userInfo := authMgr.GetUserInfo()
 
 	// Fetch user profile to get isManager status
@@ -120,6 +182,8 @@ func (a *App) Connect(serverURL, username, password string) (*ConnectResult, err
 		Str("serialKey", userInfo.SerialKey).
 		Msg("Connect result with serial key")
 
+	go a.storeSerialKey(serverURL, userInfo.SerialKey)
+
 	return &ConnectResult{
 		Username:  userInfo.User.Nickname,
 		UserID:    userInfo.User.ID,
@@ -128,10 +192,80 @@ func (a *App) Connect(serverURL, username, password string) (*ConnectResult, err
 	}, nil
 }
 
+// ErrConnectInProgress is returned by Connect while another Connect to the same server runs
+var ErrConnectInProgress = errors.New("a connection to this server is already in progress")
+
+// ErrConnectCanceled is returned by Connect when CancelConnect aborted it
+var ErrConnectCanceled = errors.New("connection canceled")
+
+// beginConnect marks a Connect to a server as in progress, returning the
+// context CancelConnect cancels
+func (a *App) beginConnect(serverURL string) (context.Context, error) {
+	a.connectMu.Lock()
+	if _, ok := a.connecting[serverURL]; ok {
+		a.connectMu.Unlock()
+		return nil, ErrConnectInProgress
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.connecting[serverURL] = cancel
+	a.connectMu.Unlock()
+	return ctx, nil
+}
+
+// endConnect marks the Connect to a server as over
+func (a *App) endConnect(serverURL string) {
+	a.connectMu.Lock()
+	if cancel, ok := a.connecting[serverURL]; ok {
+		cancel()
+		delete(a.connecting, serverURL)
+	}
+	a.connectMu.Unlock()
+
+	a.setConnecting(serverURL, false)
+}
+
+// setConnecting updates the in-progress flag of a connection state and
+// emits "connection:connecting"
+func (a *App) setConnecting(serverURL string, connecting bool) {
+	a.mu.Lock()
+	state := a.connections[serverURL]
+	if state == nil {
+		state = &ConnectionState{}
+	} else {
+		copied := *state
+		state = &copied
+	}
+	state.Connecting = connecting
+	a.connections[serverURL] = state
+	shuttingDown := a.shuttingDown
+	a.mu.Unlock()
+
+	if !shuttingDown {
+		a.emit("connection:connecting", serverURL, connecting)
+	}
+}
+
+// CancelConnect aborts the Connect in progress to a server, which then fails
+// with ErrConnectCanceled. Returns false if no Connect was in progress.
+func (a *App) CancelConnect(serverURL string) bool {
+	a.connectMu.Lock()
+	cancel, ok := a.connecting[serverURL]
+	a.connectMu.Unlock()
+
+	if !ok {
+		return false
+	}
+	cancel()
+	logger.App.Info().Str("serverUrl", serverURL).Msg("Canceled connection attempt")
+	return true
+}
+
 // setupNotificationCallbacks configures callbacks for a notification manager
 func (a *App) setupNotificationCallbacks(notifMgr *notification.Manager, serverURL string) {
-	// Set up notification callback
-	notifMgr.SetOnNotification(func(n async.ResourceChange) {
+	// Set up notification callback - bursts of identical notifications arrive
+	// merged, count tells how many were received and is passed to the frontend
+	// as the last event argument
+	notifMgr.SetOnNotification(func(n async.ResourceChange, count int) {
 		a.mu.RLock()
 		shuttingDown := a.shuttingDown
 		a.mu.RUnlock()
@@ -158,25 +292,39 @@ func (a *App) setupNotificationCallbacks(notifMgr *notification.Manager, serverU
 
 		// For session_turn, include metadata (year)
 		if nType == api.NotificationTypeSessionTurn && n.Metadata != nil {
-			runtime.EventsEmit(a.ctx, eventName, serverURL, nID, n.Metadata)
+			a.emit(eventName, serverURL, nID, n.Metadata, count)
 			logger.App.Debug().
 				Str("event", eventName).
 				Str("serverUrl", serverURL).
 				Str("id", nID).
+				Int("count", count).
 				Interface("metadata", n.Metadata).
 				Msg("Notification received")
 
 			// Show desktop notification for new turns (action is "ready" for both game start and new turn generation)
 			if nAction == async.ResourceChangeActionReady {
-				go a.showTurnReadyNotification(serverURL, nID, n.Metadata)
+				// Turn handling goes ahead of queued background work
+				metadata := n.Metadata
+				a.work.Submit(workqueue.High, "turn-ready", func() {
+					a.showTurnReadyNotification(serverURL, nID, metadata)
+					// A new year resets everyone's submission
+					a.refreshOrdersStatus(serverURL, nID, true)
+				})
+				a.onTurnGenerated(serverURL, nID, notificationYear(n.Metadata))
+				go a.recordTimelineEvent(serverURL, nID, model.TimelineEvent{
+					Type: model.TimelineTurnGenerated,
+					Time: notificationTime(n.Timestamp),
+					Year: notificationYear(n.Metadata),
+				})
 			}
 		} else if nType == api.NotificationTypePendingRegistration && n.Metadata != nil {
 			// For pending_registration approval, include metadata (user_profile_id, nickname)
-			runtime.EventsEmit(a.ctx, eventName, serverURL, nID, n.Metadata)
+			a.emit(eventName, serverURL, nID, n.Metadata, count)
 			logger.App.Debug().
 				Str("event", eventName).
 				Str("serverUrl", serverURL).
 				Str("id", nID).
+				Int("count", count).
 				Interface("metadata", n.Metadata).
 				Msg("Notification received")
 
@@ -184,31 +332,86 @@ func (a *App) setupNotificationCallbacks(notifMgr *notification.Manager, serverU
 			if nAction == async.ResourceChangeActionApproved {
 				go a.showRegistrationApprovedNotification(serverURL, n.Metadata)
 			}
+		} else if nType == api.NotificationTypeSessionWaitlist && n.Metadata != nil {
+			// For session_waitlist, include metadata (user_profile_id)
+			a.emit(eventName, serverURL, nID, n.Metadata, count)
+			logger.App.Debug().
+				Str("event", eventName).
+				Str("serverUrl", serverURL).
+				Str("id", nID).
+				Int("count", count).
+				Interface("metadata", n.Metadata).
+				Msg("Waitlist notification received")
+
+			// Tell the user a slot opened for them
+			if nAction == api.NotificationActionSlotOpen {
+				go a.showWaitlistSlotNotification(serverURL, nID, n.Metadata)
+			}
+		} else if nType == api.NotificationTypePresence && n.Metadata != nil {
+			// For presence, the ID is the user and metadata tells whether they are online
+			a.emit(eventName, serverURL, nID, n.Metadata, count)
+			if online, ok := presenceOnline(n.Metadata); ok {
+				a.emit("presence:changed", serverURL, nID, online)
+			}
 		} else if nType == api.NotificationTypePlayerControl && n.Metadata != nil {
 			// For player_control, include metadata (session_id, player_order, ai_control_type)
-			runtime.EventsEmit(a.ctx, eventName, serverURL, nID, n.Metadata)
+			a.emit(eventName, serverURL, nID, n.Metadata, count)
 			logger.App.Debug().
 				Str("event", eventName).
 				Str("serverUrl", serverURL).
 				Str("id", nID).
+				Int("count", count).
 				Interface("metadata", n.Metadata).
 				Msg("Player control notification received")
 		} else {
-			runtime.EventsEmit(a.ctx, eventName, serverURL, nID)
+			a.emit(eventName, serverURL, nID, count)
 			logger.App.Debug().
 				Str("event", eventName).
 				Str("serverUrl", serverURL).
 				Str("id", nID).
+				Int("count", count).
 				Msg("Notification received")
 		}
 
 		// Handle session updates - check if session started and we should begin monitoring
 		if nType == api.NotificationTypeSession && nAction == async.ResourceChangeActionUpdated {
 			go a.checkAndStartMonitoring(serverURL, nID)
+			// Players may have been reordered - keep our race file in the right slot
+			go a.syncOwnRaceFile(serverURL, nID)
+			go a.checkGameFinished(serverURL, nID)
+		}
+
+		// Keep the session timeline - membership changes and rules (ruleset IDs are session IDs)
+		if nType == api.NotificationTypeSession &&
+			(nAction == async.ResourceChangeActionUpdated || nAction == async.ResourceChangeActionMemberLeft) {
+			go a.trackSessionMembers(serverURL, nID, notificationTime(n.Timestamp))
+		}
+		if nType == api.NotificationTypeRuleset &&
+			(nAction == async.ResourceChangeActionCreated || nAction == async.ResourceChangeActionUpdated) {
+			go a.recordTimelineEvent(serverURL, nID, model.TimelineEvent{
+				Type: model.TimelineRulesChanged,
+				Time: notificationTime(n.Timestamp),
+			})
+		}
+
+		// Handle order submissions - keep the live order status current
+		if nType == api.NotificationTypeOrderStatus {
+			go a.refreshOrdersStatus(serverURL, nID, false)
+		}
+
+		// Handle live rounds - keep the countdown and ready-check current
+		if nType == api.NotificationTypeLiveRound {
+			go a.refreshLiveRound(serverURL, nID, nAction == async.ResourceChangeActionCreated)
+		}
+
+		// Handle new invitations - auto-decline those coming from ignored users
+		if nType == api.NotificationTypeInvitation && nAction == async.ResourceChangeActionCreated {
+			go a.autoDeclineIgnoredInvitations(serverURL)
 		}
 
 		// Handle session deleted - archive the session directory
 		if nType == api.NotificationTypeSession && nAction == async.ResourceChangeActionDeleted {
+			a.setSessionSubscribed(serverURL, nID, false)
 			go a.archiveDeletedSession(serverURL, nID)
 		}
 	})
@@ -218,27 +421,17 @@ func (a *App) setupNotificationCallbacks(notifMgr *notification.Manager, serverU
 		a.mu.RLock()
 		shuttingDown := a.shuttingDown
 		a.mu.RUnlock()
-		if shuttingDown {
+		if shuttingDown || a.inMaintenance(serverURL) {
 			return
 		}
-		runtime.EventsEmit(a.ctx, "sessions:updated", serverURL)
+		a.emit("sessions:updated", serverURL)
 	})
 }
 
 // showTurnReadyNotification shows a desktop notification when a new turn is ready
 func (a *App) showTurnReadyNotification(serverURL, sessionID string, metadata interface{}) {
 	// Get the year from metadata
-	year := 0
-	if metaMap, ok := metadata.(map[string]interface{}); ok {
-		if yearVal, ok := metaMap["year"]; ok {
-			switch v := yearVal.(type) {
-			case float64:
-				year = int(v)
-			case int:
-				year = v
-			}
-		}
-	}
+	year := notificationYear(metadata)
 
 	// Get session name from the server
 	sessionName := sessionID // fallback to ID
@@ -355,6 +548,7 @@ func (a *App) Disconnect(serverURL string) error {
 	if authMgr != nil {
 		authMgr.Disconnect()
 	}
+	a.disconnectAltConnections(serverURL)
 
 	// Now clean up the maps
 	a.mu.Lock()
@@ -362,6 +556,8 @@ func (a *App) Disconnect(serverURL string) error {
 	delete(a.notificationManagers, serverURL)
 	delete(a.orderMonitors, serverURL)
 	delete(a.clients, serverURL)
+	delete(a.profileCaches, serverURL)
+	delete(a.otherDevices, serverURL)
 	a.connections[serverURL] = &ConnectionState{
 		Connected: false,
 	}
@@ -384,6 +580,10 @@ func (a *App) GetConnectionState(serverURL string) *ConnectionState {
 
 // GetCurrentAPIKey returns the API key for the currently connected user on a server
 func (a *App) GetCurrentAPIKey(serverURL string) (string, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return "", err
+	}
+
 	a.mu.RLock()
 	conn := a.connections[serverURL]
 	a.mu.RUnlock()
@@ -423,6 +623,10 @@ func (a *App) AutoConnect(serverURL string) (*ConnectResult, error) {
 
 	// Get the API key from keyring
 	apiKey, err := a.config.GetCredential(serverURL, defaultCred.NickName)
+	if errors.Is(err, astrum.ErrKeyringUnavailable) {
+		a.credentialsLocked(err)
+		return nil, fmt.Errorf("credentials are locked until the system keyring is available: %w", err)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve credentials: %w", err)
 	}
@@ -436,10 +640,106 @@ func (a *App) AutoConnect(serverURL string) (*ConnectResult, error) {
 	return a.Connect(serverURL, defaultCred.NickName, apiKey)
 }
 
+// =============================================================================
+// STARTUP CONNECTIONS
+// =============================================================================
+
+// Startup connection statuses
+const (
+	autoConnectConnecting = "connecting"
+	autoConnectConnected  = "connected"
+	autoConnectLocked     = "locked" // retried once the keyring is available
+	autoConnectFailed     = "failed"
+)
+
+// autoConnectServers connects in parallel the servers flagged to connect on
+// startup that have a saved credential, emitting "autoconnect:status" for each
+// status change and "autoconnect:done" once all attempts are over. Servers
+// whose credentials are locked in the keyring are connected once it is
+// available. With only set, just these servers are connected.
+func (a *App) autoConnectServers(only ...string) {
+	servers, err := a.config.GetServers()
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to get servers for startup connections")
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		if !server.GetAutoConnectOnStartup() || server.GetDefaultCredentialRef() == nil {
+			continue
+		}
+		if len(only) > 0 && !slices.Contains(only, server.URL) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			a.setAutoConnectStatus(server.URL, autoConnectConnecting, "")
+			if _, err := a.AutoConnect(server.URL); err != nil {
+				logger.App.Warn().Err(err).Str("serverUrl", server.URL).Msg("Startup connection failed")
+				status := autoConnectFailed
+				if errors.Is(err, astrum.ErrKeyringUnavailable) {
+					status = autoConnectLocked
+				}
+				a.setAutoConnectStatus(server.URL, status, err.Error())
+				return
+			}
+			a.setAutoConnectStatus(server.URL, autoConnectConnected, "")
+		}()
+	}
+	wg.Wait()
+
+	summary := a.GetAutoConnectStatus()
+	logger.App.Info().Int("servers", len(summary)).Msg("Startup connections completed")
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("autoconnect:done", summary)
+	}
+}
+
+// setAutoConnectStatus records and emits the startup connection status of a server
+func (a *App) setAutoConnectStatus(serverURL, status, errMsg string) {
+	info := &AutoConnectInfo{ServerURL: serverURL, Status: status, Error: errMsg}
+	a.autoConnectMu.Lock()
+	a.autoConnect[serverURL] = info
+	a.autoConnectMu.Unlock()
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("autoconnect:status", *info)
+	}
+}
+
+// GetAutoConnectStatus returns the startup connection status of the servers
+// connected on startup, sorted by URL
+func (a *App) GetAutoConnectStatus() []AutoConnectInfo {
+	a.autoConnectMu.Lock()
+	defer a.autoConnectMu.Unlock()
+
+	result := make([]AutoConnectInfo, 0, len(a.autoConnect))
+	for _, info := range a.autoConnect {
+		result = append(result, *info)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ServerURL < result[j].ServerURL
+	})
+	return result
+}
+
 // Register submits a registration request for a new user account on a server.
 // Returns the registration result which includes whether approval is needed.
 // The API key is automatically saved to the keyring.
 func (a *App) Register(serverURL, nickname, email, message string) (*RegistrationResultInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
 	client := api.NewClient(serverURL)
 	authMgr := auth.NewManager(client)
 
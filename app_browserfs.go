@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
 )
 
 // =============================================================================
@@ -153,7 +154,7 @@ func (a *App) FSWriteFile(sessionKey, path string, data []byte, mode int) error
 
 	// Ensure parent directory exists
 	dir := filepath.Dir(fullPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := safefile.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create parent directory: %w", err)
 	}
 
@@ -163,7 +164,7 @@ func (a *App) FSWriteFile(sessionKey, path string, data []byte, mode int) error
 		fileMode = 0644
 	}
 
-	if err := os.WriteFile(fullPath, data, fileMode); err != nil {
+	if err := safefile.WriteFile(fullPath, data, fileMode); err != nil {
 		return fmt.Errorf("failed to write file: %w", err)
 	}
 
@@ -370,7 +371,7 @@ func (a *App) FSListAll(sessionKey string) ([]FSFileEntry, error) {
 	}
 
 	// Ensure directory exists
-	if err := os.MkdirAll(gameDir, 0755); err != nil {
+	if err := safefile.MkdirAll(gameDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create game directory: %w", err)
 	}
 
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// CREDENTIAL CHECK
+// =============================================================================
+
+// credentialCheckTimeout bounds the authentication of a single credential
+const credentialCheckTimeout = 15 * time.Second
+
+// Outcomes of a credential check
+const (
+	credentialValid       = "valid"
+	credentialRejected    = "rejected"    // the server refused the API key: expired or revoked
+	credentialMissing     = "missing"     // the API key is not in the keyring anymore
+	credentialUnreachable = "unreachable" // the server could not tell, try again later
+	credentialError       = "error"
+)
+
+// ValidateAllCredentials authenticates every credential stored for every
+// server, without connecting: no session, WebSocket or monitoring is started
+// and the current connections are left alone. Expired or revoked API keys
+// show up as "rejected", so they can be replaced before a turn deadline.
+// Servers are checked concurrently, the credentials of a server one by one.
+func (a *App) ValidateAllCredentials() ([]CredentialCheckInfo, error) {
+	servers, err := a.config.GetServers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get servers: %w", err)
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results []CredentialCheckInfo
+	)
+	for _, server := range servers {
+		if len(server.CredentialRefs) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checks := a.checkServerCredentials(server.URL, server.Name, server.DefaultCredName, server.CredentialRefs)
+			mu.Lock()
+			results = append(results, checks...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].ServerName != results[j].ServerName {
+			return results[i].ServerName < results[j].ServerName
+		}
+		return results[i].Nickname < results[j].Nickname
+	})
+
+	failed := 0
+	for _, r := range results {
+		if r.Status != credentialValid {
+			failed++
+		}
+	}
+	logger.App.Info().Int("credentials", len(results)).Int("failed", failed).Msg("Validated stored credentials")
+
+	return results, nil
+}
+
+// checkServerCredentials authenticates the credentials of a server with a
+// client of their own
+func (a *App) checkServerCredentials(serverURL, serverName, defaultName string, refs []model.CredentialRef) []CredentialCheckInfo {
+	results := make([]CredentialCheckInfo, 0, len(refs))
+	for _, ref := range refs {
+		info := CredentialCheckInfo{
+			ServerURL:  serverURL,
+			ServerName: serverName,
+			Nickname:   ref.NickName,
+			IsDefault:  ref.IsDefault || ref.NickName == defaultName,
+		}
+
+		apiKey, err := a.config.GetCredential(serverURL, ref.NickName)
+		switch {
+		case err != nil:
+			info.Status = credentialError
+			info.Error = fmt.Sprintf("failed to read the keyring: %v", err)
+		case apiKey == "":
+			info.Status = credentialMissing
+			info.Error = "API key not found in the keyring"
+		default:
+			ctx, cancel := context.WithTimeout(a.ctx, credentialCheckTimeout)
+			_, err := api.NewClient(serverURL).Authenticate(ctx, ref.NickName, apiKey)
+			cancel()
+			info.Status = credentialStatus(err)
+			if err != nil {
+				info.Error = err.Error()
+			}
+		}
+
+		if info.Status != credentialValid {
+			logger.App.Warn().
+				Str("serverUrl", serverURL).
+				Str("nickname", ref.NickName).
+				Str("status", info.Status).
+				Str("error", info.Error).
+				Msg("Stored credential failed validation")
+		}
+		results = append(results, info)
+	}
+	return results
+}
+
+// credentialStatus classifies the outcome of an authentication
+func credentialStatus(err error) string {
+	if err == nil {
+		return credentialValid
+	}
+	if errors.Is(err, context.DeadlineExceeded) || api.IsTransient(err) {
+		return credentialUnreachable
+	}
+
+	code := 0
+	var apiErr *api.APIError
+	var statusErr *api.StatusError
+	if errors.As(err, &apiErr) {
+		code = apiErr.Code
+	} else if errors.As(err, &statusErr) {
+		code = statusErr.StatusCode
+	}
+	switch code {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return credentialRejected
+	}
+	return credentialError
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/neper-stars/astrum/api"
+)
+
+func TestCredentialStatus(t *testing.T) {
+	assert.Equal(t, credentialValid, credentialStatus(nil))
+	assert.Equal(t, credentialRejected, credentialStatus(&api.APIError{Code: 401, Message: "invalid api key"}))
+	assert.Equal(t, credentialRejected, credentialStatus(fmt.Errorf("authentication failed: %w", &api.StatusError{Op: "authentication", StatusCode: 403})))
+	assert.Equal(t, credentialUnreachable, credentialStatus(&api.StatusError{Op: "authentication", StatusCode: 502}))
+	assert.Equal(t, credentialUnreachable, credentialStatus(context.DeadlineExceeded))
+	assert.Equal(t, credentialError, credentialStatus(&api.APIError{Code: 400, Message: "bad request"}))
+	assert.Equal(t, credentialError, credentialStatus(errors.New("unexpected")))
+}
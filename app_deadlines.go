@@ -0,0 +1,274 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gen2brain/beeep"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/api/models"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// ORDER DEADLINES
+// =============================================================================
+
+// deadlineCheckInterval is how often the deadlines of our missing orders are checked
+const deadlineCheckInterval = 5 * time.Minute
+
+// defaultDeadlineCadence is the cadence assumed for schedules that don't tell theirs
+const defaultDeadlineCadence = 24 * time.Hour
+
+// deadlineStage is a level of a nearing deadline, reached when the time left
+// falls below a fraction of the cadence of the session
+type deadlineStage struct {
+	level    string
+	fraction float64
+}
+
+// deadlineStages are the levels of a nearing deadline, from the least to the
+// most urgent: 12, 6 and 2 hours before the deadline of a daily game
+var deadlineStages = []deadlineStage{
+	{"notice", 1.0 / 2},
+	{"warning", 1.0 / 4},
+	{"critical", 1.0 / 12},
+}
+
+// GetDeadlines returns the sessions of a server whose turn deadline nears
+// while our orders are not uploaded, the most urgent first. Deadlines come
+// from the turn schedule the host set, on servers that have one.
+func (a *App) GetDeadlines(serverURL string) []DeadlineInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := []DeadlineInfo{}
+	for key, info := range a.deadlines {
+		if strings.HasPrefix(key, serverURL+"|") {
+			result = append(result, *info)
+		}
+	}
+	slices.SortFunc(result, func(x, y DeadlineInfo) int { return int(x.RemainingSeconds - y.RemainingSeconds) })
+	return result
+}
+
+// checkDeadlinesForAll checks the deadlines of every connected server
+func (a *App) checkDeadlinesForAll() {
+	a.mu.RLock()
+	serverURLs := make([]string, 0, len(a.clients))
+	for url := range a.clients {
+		serverURLs = append(serverURLs, url)
+	}
+	a.mu.RUnlock()
+
+	for _, url := range serverURLs {
+		a.checkDeadlines(url)
+	}
+}
+
+// checkDeadlines follows the deadlines of the started sessions of a server
+// where our orders are not uploaded. Each session reaching a more urgent
+// level emits "session:deadline" (serverURL, sessionID, info) and shows a
+// desktop notification when enabled; a session whose orders came in emits it
+// with an empty level. "deadlines:count" (count) follows every change, for the
+// badge of the app.
+func (a *App) checkDeadlines(serverURL string) {
+	if a.inMaintenance(serverURL) {
+		return
+	}
+
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	unsupported := a.noSchedule[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk || unsupported {
+		return
+	}
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return
+	}
+
+	ctx := mgr.GetContext()
+	sessions, err := client.ListSessions(ctx)
+	if err != nil {
+		logger.App.Debug().Err(err).Str("serverUrl", serverURL).Msg("Failed to list sessions for deadlines")
+		return
+	}
+
+	now := time.Now()
+	for _, session := range sessions {
+		playerOrder := -1
+		for _, player := range session.Players {
+			if player.UserProfileID == userInfo.User.ID {
+				playerOrder = int(player.PlayerOrder)
+				break
+			}
+		}
+		if session.State != models.SessionStateStarted || playerOrder < 0 {
+			a.updateDeadline(serverURL, session.ID, nil)
+			continue
+		}
+
+		schedule, err := client.GetSessionSchedule(ctx, session.ID)
+		if errors.Is(err, api.ErrNotSupported) {
+			a.markNoSchedule(serverURL)
+			return
+		}
+		if err != nil {
+			logger.App.Debug().Err(err).Str("sessionId", session.ID).Msg("Failed to get session schedule")
+			continue
+		}
+		if schedule == nil {
+			a.updateDeadline(serverURL, session.ID, nil)
+			continue
+		}
+
+		status, err := a.GetCachedOrdersStatus(serverURL, session.ID)
+		if err != nil {
+			logger.App.Debug().Err(err).Str("sessionId", session.ID).Msg("Failed to get orders status for deadlines")
+			continue
+		}
+		submitted := slices.ContainsFunc(status.Players, func(p PlayerOrderStatusInfo) bool {
+			return p.PlayerOrder == playerOrder && p.Submitted
+		})
+
+		cadence := time.Duration(schedule.CadenceHours) * time.Hour
+		remaining := schedule.NextGenerationAt.Sub(now)
+		level := deadlineLevel(remaining, cadence)
+		if submitted || level == "" {
+			a.updateDeadline(serverURL, session.ID, nil)
+			continue
+		}
+		a.updateDeadline(serverURL, session.ID, &DeadlineInfo{
+			SessionID:        session.ID,
+			SessionName:      session.Name,
+			Year:             status.PendingYear,
+			DeadlineAt:       schedule.NextGenerationAt.Format(time.RFC3339),
+			RemainingSeconds: int64(remaining / time.Second),
+			CadenceHours:     schedule.CadenceHours,
+			Level:            level,
+		})
+	}
+}
+
+// updateDeadline records the deadline of a session, nil once it is no longer
+// at risk, and tells about escalations and risks gone
+func (a *App) updateDeadline(serverURL, sessionID string, info *DeadlineInfo) {
+	key := serverURL + "|" + sessionID
+
+	a.mu.Lock()
+	previous := a.deadlines[key]
+	if info != nil {
+		a.deadlines[key] = info
+	} else {
+		delete(a.deadlines, key)
+	}
+	count := len(a.deadlines)
+	shuttingDown := a.shuttingDown
+	a.mu.Unlock()
+
+	escalated := info != nil && (previous == nil || previous.Year != info.Year || deadlineRank(info.Level) > deadlineRank(previous.Level))
+	cleared := info == nil && previous != nil
+	if !escalated && !cleared {
+		return
+	}
+
+	event := info
+	if cleared {
+		gone := *previous
+		gone.Level = ""
+		event = &gone
+	}
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Str("level", event.Level).
+		Int64("remainingSeconds", event.RemainingSeconds).
+		Msg("Order deadline changed")
+	if shuttingDown {
+		return
+	}
+	a.emit("session:deadline", serverURL, sessionID, event)
+	a.emit("deadlines:count", count)
+
+	if escalated {
+		a.showDeadlineNotification(info)
+	}
+}
+
+// showDeadlineNotification shows a desktop notification for a nearing
+// deadline of missing orders, when enabled
+func (a *App) showDeadlineNotification(info *DeadlineInfo) {
+	settings, err := a.config.GetAppSettings()
+	if err != nil || !settings.GetDeadlineNotifications() {
+		return
+	}
+
+	title := "Orders Due Soon"
+	if info.Level == "critical" {
+		title = "Orders Due Now"
+	}
+	message := fmt.Sprintf("Your orders for year %d of %s are not uploaded and the deadline has passed", info.Year, info.SessionName)
+	if remaining := time.Duration(info.RemainingSeconds) * time.Second; remaining > 0 {
+		message = fmt.Sprintf("Your orders for year %d of %s are not uploaded, the turn is generated in %s",
+			info.Year, info.SessionName, formatRemaining(remaining))
+	}
+	if err := beeep.Notify(title, message, a.notificationIcon); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to show desktop notification")
+	}
+}
+
+// deadlineLevel returns the level of a deadline given the time left and the
+// cadence of the session, "" while it is far. A passed deadline is critical.
+func deadlineLevel(remaining, cadence time.Duration) string {
+	if cadence <= 0 {
+		cadence = defaultDeadlineCadence
+	}
+	level := ""
+	for _, stage := range deadlineStages {
+		if remaining <= time.Duration(float64(cadence)*stage.fraction) {
+			level = stage.level
+		}
+	}
+	return level
+}
+
+// deadlineRank orders deadline levels, 0 for none
+func deadlineRank(level string) int {
+	return slices.IndexFunc(deadlineStages, func(stage deadlineStage) bool { return stage.level == level }) + 1
+}
+
+// formatRemaining writes the time left before a deadline for people
+func formatRemaining(d time.Duration) string {
+	if d < time.Hour {
+		return plural(int(d.Minutes()), "1 minute", fmt.Sprintf("%d minutes", int(d.Minutes())))
+	}
+	hours := int(d.Round(time.Hour).Hours())
+	return plural(hours, "1 hour", fmt.Sprintf("%d hours", hours))
+}
+
+// applyDeadline marks a session at risk when its deadline nears while our
+// orders are not uploaded
+func (a *App) applyDeadline(serverURL string, info *SessionInfo) {
+	a.mu.RLock()
+	deadline := a.deadlines[serverURL+"|"+info.ID]
+	a.mu.RUnlock()
+
+	if deadline != nil {
+		info.AtRisk = true
+		info.DeadlineAt = deadline.DeadlineAt
+	}
+}
+
+func (a *App) markNoSchedule(serverURL string) {
+	a.mu.Lock()
+	a.noSchedule[serverURL] = true
+	a.mu.Unlock()
+	logger.App.Debug().Str("serverUrl", serverURL).Msg("Server has no turn schedules")
+}
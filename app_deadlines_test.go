@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadlineLevel(t *testing.T) {
+	day := 24 * time.Hour
+
+	assert.Equal(t, "", deadlineLevel(20*time.Hour, day))
+	assert.Equal(t, "notice", deadlineLevel(12*time.Hour, day))
+	assert.Equal(t, "warning", deadlineLevel(5*time.Hour, day))
+	assert.Equal(t, "critical", deadlineLevel(90*time.Minute, day))
+	assert.Equal(t, "critical", deadlineLevel(-time.Minute, day), "a passed deadline is critical")
+
+	// Faster games escalate later
+	assert.Equal(t, "", deadlineLevel(5*time.Hour, 8*time.Hour))
+	assert.Equal(t, "notice", deadlineLevel(10*time.Hour, 0), "unknown cadences are daily")
+}
+
+func TestDeadlineRank(t *testing.T) {
+	assert.Equal(t, 0, deadlineRank(""))
+	assert.Less(t, deadlineRank("notice"), deadlineRank("warning"))
+	assert.Less(t, deadlineRank("warning"), deadlineRank("critical"))
+}
+
+func TestFormatRemaining(t *testing.T) {
+	assert.Equal(t, "1 minute", formatRemaining(time.Minute+10*time.Second))
+	assert.Equal(t, "45 minutes", formatRemaining(45*time.Minute))
+	assert.Equal(t, "1 hour", formatRemaining(70*time.Minute))
+	assert.Equal(t, "6 hours", formatRemaining(5*time.Hour+40*time.Minute))
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// MULTI-MACHINE CONNECTIONS
+// =============================================================================
+
+// deviceReportInterval is how often this machine reports itself to the servers
+const deviceReportInterval = time.Minute
+
+// deviceActiveWindow is how long a machine counts as connected after its last report
+const deviceActiveWindow = 3 * deviceReportInterval
+
+// GetOtherDevices returns the other machines the user is connected to a
+// server from, as of the last report. Empty on servers without device tracking.
+func (a *App) GetOtherDevices(serverURL string) []DeviceInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return slices.Clone(a.otherDevices[serverURL])
+}
+
+// IsMonitoringHandedOff tells whether the order auto-upload of a server is
+// left to another machine
+func (a *App) IsMonitoringHandedOff(serverURL string) bool {
+	server, err := a.config.GetServer(serverURL)
+	return err == nil && server != nil && server.MonitoringHandedOff
+}
+
+// HandOffMonitoring stops the order auto-upload of a server on this machine,
+// leaving it to another machine of the user so both don't upload the same
+// orders. Orders can still be uploaded by hand.
+func (a *App) HandOffMonitoring(serverURL string) error {
+	if err := a.setMonitoringHandedOff(serverURL, true); err != nil {
+		return err
+	}
+	a.stopServerMonitoring(serverURL)
+	go a.reportDevice(serverURL)
+	a.emitMonitoringHandoff(serverURL, true)
+	return nil
+}
+
+// TakeOverMonitoring resumes the order auto-upload of a server on this
+// machine. Other machines of the user hand it off on their next report.
+func (a *App) TakeOverMonitoring(serverURL string) error {
+	if err := a.setMonitoringHandedOff(serverURL, false); err != nil {
+		return err
+	}
+	go func() {
+		a.reportDevice(serverURL)
+		a.startMonitoringForServer(serverURL)
+	}()
+	a.emitMonitoringHandoff(serverURL, false)
+	return nil
+}
+
+// setMonitoringHandedOff records whether this machine auto-uploads the
+// orders of a server. Taking over records the claim time.
+func (a *App) setMonitoringHandedOff(serverURL string, handedOff bool) error {
+	server, err := a.config.GetServer(serverURL)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return fmt.Errorf("server with URL %s not found", serverURL)
+	}
+
+	server.MonitoringHandedOff = handedOff
+	if !handedOff {
+		server.MonitoringClaimedAt = time.Now().UTC()
+	}
+	if err := a.config.UpdateServer(*server); err != nil {
+		return fmt.Errorf("failed to update server: %w", err)
+	}
+
+	logger.App.Info().Str("url", serverURL).Bool("handedOff", handedOff).Msg("Set order monitoring handoff")
+	return nil
+}
+
+// stopServerMonitoring stops watching the order files of all the sessions of a server
+func (a *App) stopServerMonitoring(serverURL string) {
+	a.mu.Lock()
+	orderMon := a.orderMonitors[serverURL]
+	delete(a.orderMonitors, serverURL)
+	a.mu.Unlock()
+
+	if orderMon != nil {
+		orderMon.Stop()
+	}
+}
+
+// emitMonitoringHandoff emits "monitoring:handoff" (serverURL, handedOff)
+func (a *App) emitMonitoringHandoff(serverURL string, handedOff bool) {
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("monitoring:handoff", serverURL, handedOff)
+	}
+}
+
+// reportDevicesForAll reports this machine to every connected server
+func (a *App) reportDevicesForAll() {
+	a.mu.RLock()
+	serverURLs := make([]string, 0, len(a.clients))
+	for url := range a.clients {
+		serverURLs = append(serverURLs, url)
+	}
+	a.mu.RUnlock()
+
+	for _, url := range serverURLs {
+		a.reportDevice(url)
+	}
+}
+
+// reportDevice tells a server this machine is connected and learns the other
+// machines of the user. "server:devices" (serverURL, devices) is emitted when
+// they changed. When another machine took the order auto-upload over since
+// this one did, this machine hands it off. Servers without device tracking
+// are remembered until the next connection.
+func (a *App) reportDevice(serverURL string) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	unsupported := a.noDevices[serverURL]
+	a.mu.RUnlock()
+	if !ok || !mgrOk || unsupported {
+		return
+	}
+
+	userInfo := mgr.GetUserInfo()
+	server, err := a.config.GetServer(serverURL)
+	if userInfo == nil || err != nil || server == nil {
+		return
+	}
+	deviceID, err := a.config.GetDeviceID()
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to get device ID")
+		return
+	}
+
+	machine, _ := os.Hostname()
+	devices, err := client.ReportDevice(mgr.GetContext(), userInfo.User.ID, &api.Device{
+		ID:         deviceID,
+		Machine:    machine,
+		Monitoring: !server.MonitoringHandedOff,
+		ClaimedAt:  server.MonitoringClaimedAt,
+		LastSeen:   time.Now().UTC(),
+	})
+	if errors.Is(err, api.ErrNotSupported) {
+		a.mu.Lock()
+		a.noDevices[serverURL] = true
+		a.mu.Unlock()
+		logger.App.Debug().Str("serverUrl", serverURL).Msg("Server has no device tracking")
+		return
+	}
+	if err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to report device")
+		return
+	}
+
+	others, claimedElsewhere := otherActiveDevices(devices, deviceID, server.MonitoringClaimedAt, time.Now())
+
+	a.mu.Lock()
+	changed := !slices.Equal(a.otherDevices[serverURL], others)
+	a.otherDevices[serverURL] = others
+	shuttingDown := a.shuttingDown
+	a.mu.Unlock()
+	if changed && !shuttingDown {
+		a.emit("server:devices", serverURL, others)
+	}
+
+	if claimedElsewhere && !server.MonitoringHandedOff {
+		logger.App.Info().Str("serverUrl", serverURL).Msg("Another machine took the order auto-upload over")
+		if err := a.HandOffMonitoring(serverURL); err != nil {
+			logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to hand off order monitoring")
+		}
+	}
+}
+
+// otherActiveDevices returns the machines other than deviceID seen recently,
+// and whether one of them took the order auto-upload over after claimedAt
+func otherActiveDevices(devices []api.Device, deviceID string, claimedAt, now time.Time) ([]DeviceInfo, bool) {
+	others := []DeviceInfo{}
+	claimedElsewhere := false
+	for _, d := range devices {
+		if d.ID == deviceID || now.Sub(d.LastSeen) > deviceActiveWindow {
+			continue
+		}
+		others = append(others, DeviceInfo{
+			Machine:    d.Machine,
+			Monitoring: d.Monitoring,
+			LastSeen:   d.LastSeen.Format(time.RFC3339),
+		})
+		if d.Monitoring && d.ClaimedAt.After(claimedAt) {
+			claimedElsewhere = true
+		}
+	}
+	return others, claimedElsewhere
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/neper-stars/astrum/api"
+)
+
+func TestOtherActiveDevices(t *testing.T) {
+	now := time.Now()
+	claimedAt := now.Add(-time.Hour)
+
+	devices := []api.Device{
+		{ID: "self", Machine: "desktop", Monitoring: true, ClaimedAt: claimedAt, LastSeen: now},
+		{ID: "laptop", Machine: "laptop", LastSeen: now.Add(-time.Minute)},
+		{ID: "old", Machine: "old-pc", Monitoring: true, ClaimedAt: now, LastSeen: now.Add(-time.Hour)},
+	}
+
+	others, claimedElsewhere := otherActiveDevices(devices, "self", claimedAt, now)
+	assert.False(t, claimedElsewhere, "inactive machines don't take the upload over")
+	if assert.Len(t, others, 1) {
+		assert.Equal(t, "laptop", others[0].Machine)
+		assert.False(t, others[0].Monitoring)
+	}
+
+	// The laptop takes the order upload over
+	devices[1].Monitoring = true
+	devices[1].ClaimedAt = now.Add(-time.Minute)
+	_, claimedElsewhere = otherActiveDevices(devices, "self", claimedAt, now)
+	assert.True(t, claimedElsewhere)
+
+	// This machine took it back since
+	_, claimedElsewhere = otherActiveDevices(devices, "self", now, now)
+	assert.False(t, claimedElsewhere)
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+
+	"github.com/rs/zerolog"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/platform"
+	"github.com/neper-stars/astrum/lib/safefile"
+	"github.com/neper-stars/astrum/lib/workqueue"
+)
+
+// =============================================================================
+// DIAGNOSTICS
+// =============================================================================
+
+// GetDiagnostics returns a snapshot of the background work queue and
+// connections, and how long the startup took
+func (a *App) GetDiagnostics() *DiagnosticsInfo {
+	stats := a.work.Stats()
+
+	info := &DiagnosticsInfo{
+		QueueDepth:       stats.Depth(),
+		QueuedByPriority: make(map[string]int),
+		Goroutines:       goruntime.NumGoroutine(),
+		Startup:          a.startupTiming(),
+	}
+	for _, p := range []workqueue.Priority{workqueue.High, workqueue.Normal, workqueue.Low} {
+		info.QueuedByPriority[p.String()] = stats.Queued[p]
+		info.QueueRunning += stats.Running[p]
+	}
+
+	a.mu.RLock()
+	for _, state := range a.connections {
+		if state.Connected {
+			info.Servers++
+		}
+	}
+	for _, notifMgr := range a.notificationManagers {
+		if notifMgr.IsConnected() {
+			info.WebSockets++
+		}
+		info.SilentDeaths += notifMgr.SilentDeaths()
+	}
+	a.mu.RUnlock()
+
+	return info
+}
+
+// environmentVariables are the variables worth reporting, as they change how
+// the WebView renders
+var environmentVariables = []string{
+	"XDG_CURRENT_DESKTOP",
+	"XDG_SESSION_TYPE",
+	"WAYLAND_DISPLAY",
+	"GDK_BACKEND",
+	"WEBKIT_DISABLE_COMPOSITING_MODE",
+	"WEBKIT_DISABLE_DMABUF_RENDERER",
+	"WEBVIEW2_ADDITIONAL_BROWSER_ARGUMENTS",
+	"LIBGL_ALWAYS_SOFTWARE",
+}
+
+// GetEnvironmentReport summarizes the platform and WebView for bug reports
+func (a *App) GetEnvironmentReport() *EnvironmentReportInfo {
+	webView, webViewVersion := platform.WebViewVersion()
+
+	report := &EnvironmentReportInfo{
+		OS:             goruntime.GOOS,
+		Arch:           goruntime.GOARCH,
+		GoVersion:      goruntime.Version(),
+		WebView:        webView,
+		WebViewVersion: webViewVersion,
+		BuildType:      runtime.Environment(a.ctx).BuildType,
+		SafeMode:       safeMode,
+		Profile:        a.profile,
+		Environment:    make(map[string]string),
+	}
+
+	for _, key := range environmentVariables {
+		if value, ok := os.LookupEnv(key); ok {
+			report.Environment[key] = value
+		}
+	}
+	return report
+}
+
+// =============================================================================
+// LOGGING
+// =============================================================================
+
+// logFileName is the rotating log file of a profile, in its logs directory
+const logFileName = "astrum.log"
+
+// openLogFile starts copying the log to the rotating file of the profile
+func (a *App) openLogFile() {
+	dir := filepath.Join(astrum.ProfilePath(a.profile), "logs")
+	if err := safefile.MkdirAll(dir, 0755); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to create log directory")
+		return
+	}
+	if err := logger.SetFile(filepath.Join(dir, logFileName)); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to open log file")
+	}
+}
+
+// applyLogOutput switches the log to the format and outputs of the settings
+func (a *App) applyLogOutput() {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return
+	}
+	if settings.GetLogFormat() == logger.FormatConsole && !settings.GetLogSyslog() {
+		return // as set up by logger.Init
+	}
+	if err := logger.SetOutput(settings.GetLogFormat(), settings.GetLogSyslog()); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to set log output")
+	}
+}
+
+// SetLogOutput sets the format of the log lines, "console" or "json" for log
+// pipelines, and whether they are copied to the system log (journald under
+// systemd). Applied right away.
+func (a *App) SetLogOutput(format string, syslog bool) (*AppSettingsInfo, error) {
+	if err := logger.SetOutput(format, syslog); err != nil {
+		return nil, err
+	}
+	if err := a.config.SetLogOutput(format, syslog); err != nil {
+		return nil, fmt.Errorf("failed to set log output: %w", err)
+	}
+
+	logger.App.Info().Str("format", format).Bool("syslog", syslog).Msg("Set log output")
+
+	return a.GetAppSettings()
+}
+
+// GetLogConfig returns the log level of each component and the log file
+func (a *App) GetLogConfig() *LogConfigInfo {
+	info := &LogConfigInfo{File: logger.File()}
+	for _, component := range logger.Components() {
+		level, _ := logger.Level(component)
+		info.Components = append(info.Components, LogComponentInfo{Name: component, Level: level.String()})
+	}
+	return info
+}
+
+// SetLogLevel changes the log level of a component ("all" for every one)
+// while the app runs, e.g. "debug" for the monitor only. The level lasts
+// until the app exits, ASTRUM_DEBUG=true still sets the startup level.
+func (a *App) SetLogLevel(component, level string) (*LogConfigInfo, error) {
+	parsed, err := zerolog.ParseLevel(level)
+	if err != nil || level == "" {
+		return nil, fmt.Errorf("invalid log level: %s", level)
+	}
+	if err := logger.SetLevel(component, parsed); err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Str("component", component).Str("level", parsed.String()).Msg("Set log level")
+	return a.GetLogConfig(), nil
+}
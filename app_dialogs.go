@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"github.com/neper-stars/astrum/lib/intel"
+)
+
+// =============================================================================
+// NATIVE FILE DIALOGS
+// =============================================================================
+
+// playerFilePattern returns the patterns of a per-player Stars! file type,
+// e.g. "*.r1;*.r2;...;*.r16" for race files
+func playerFilePattern(prefix string) string {
+	patterns := make([]string, 0, 16)
+	for i := 1; i <= 16; i++ {
+		patterns = append(patterns, fmt.Sprintf("*.%s%d", prefix, i))
+	}
+	return strings.Join(patterns, ";")
+}
+
+// dialogFilters returns the filters of a file kind: "race", "turn", "orders",
+// "history", "universe", "intel", "image", "gif", "settings" or "session". Any other kind
+// shows all files.
+func dialogFilters(kind string) []runtime.FileFilter {
+	var filters []runtime.FileFilter
+	switch kind {
+	case "race":
+		filters = append(filters, runtime.FileFilter{DisplayName: "Stars! Race Files (*.r1-*.r16)", Pattern: playerFilePattern("r")})
+	case "turn":
+		filters = append(filters, runtime.FileFilter{DisplayName: "Stars! Turn Files (*.m1-*.m16)", Pattern: playerFilePattern("m")})
+	case "orders":
+		filters = append(filters, runtime.FileFilter{DisplayName: "Stars! Order Files (*.x1-*.x16)", Pattern: playerFilePattern("x")})
+	case "history":
+		filters = append(filters, runtime.FileFilter{DisplayName: "Stars! History Files (*.h1-*.h16)", Pattern: playerFilePattern("h")})
+	case "universe":
+		filters = append(filters, runtime.FileFilter{DisplayName: "Stars! Universe Files (*.xy)", Pattern: "*.xy"})
+	case "intel":
+		filters = append(filters, runtime.FileFilter{DisplayName: "Intel Packets (*" + intel.FileExtension + ")", Pattern: "*" + intel.FileExtension})
+	case "image":
+		filters = append(filters, runtime.FileFilter{DisplayName: "Images (*.png, *.jpg)", Pattern: "*.png;*.jpg;*.jpeg"})
+	case "gif":
+		filters = append(filters, runtime.FileFilter{DisplayName: "Animated GIF (*.gif)", Pattern: "*.gif"})
+	case "settings":
+		filters = append(filters, runtime.FileFilter{DisplayName: "Settings (*.json)", Pattern: "*.json"})
+	case "session":
+		filters = append(filters, runtime.FileFilter{DisplayName: "Session Archives (*" + SessionArchiveExtension + ")", Pattern: "*" + SessionArchiveExtension})
+	}
+	return append(filters, runtime.FileFilter{DisplayName: "All Files", Pattern: "*"})
+}
+
+// OpenFileDialog shows a native file picker for a file kind (see dialogFilters)
+// and returns the selected path, or an empty string when cancelled
+func (a *App) OpenFileDialog(title, kind, defaultDir string) (string, error) {
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:            title,
+		DefaultDirectory: defaultDir,
+		Filters:          dialogFilters(kind),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open file dialog: %w", err)
+	}
+	return path, nil
+}
+
+// SaveFileDialog shows a native save dialog for a file kind (see dialogFilters)
+// and returns the chosen path, or an empty string when cancelled
+func (a *App) SaveFileDialog(title, kind, defaultDir, defaultName string) (string, error) {
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:            title,
+		DefaultDirectory: defaultDir,
+		DefaultFilename:  defaultName,
+		Filters:          dialogFilters(kind),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open save dialog: %w", err)
+	}
+	return path, nil
+}
+
+// SelectDirectory shows a native directory picker and returns the selected
+// path, or an empty string when cancelled
+func (a *App) SelectDirectory(title, defaultDir string) (string, error) {
+	path, err := runtime.OpenDirectoryDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:            title,
+		DefaultDirectory: defaultDir,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open directory dialog: %w", err)
+	}
+	return path, nil
+}
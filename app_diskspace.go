@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/platform"
+)
+
+// =============================================================================
+// DISK SPACE GUARD
+// =============================================================================
+
+const bytesPerMB = 1024 * 1024
+
+// DiskSpaceError is returned when writing would leave less free space than
+// configured, before anything was written
+type DiskSpaceError struct {
+	Path        string
+	Operation   string
+	AvailableMB int64
+	RequiredMB  int64
+}
+
+func (e *DiskSpaceError) Error() string {
+	return fmt.Sprintf("not enough disk space for %s in %s: %d MB free, %d MB required",
+		e.Operation, e.Path, e.AvailableMB, e.RequiredMB)
+}
+
+// checkDiskSpace makes sure writing size bytes under path leaves the
+// configured free space. Below it, the operation is refused with a
+// *DiskSpaceError or, when set to warn, goes ahead after a "disk:low" event.
+// Filesystems whose free space can't be read are not checked.
+func (a *App) checkDiskSpace(path, operation string, size int64) error {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil
+	}
+	minFreeMB := settings.GetMinFreeDiskSpaceMB()
+	if minFreeMB == 0 {
+		return nil
+	}
+
+	available, err := platform.FreeSpace(path)
+	if err != nil {
+		logger.App.Debug().Err(err).Str("path", path).Msg("Failed to read free disk space")
+		return nil
+	}
+
+	requiredMB := int64(minFreeMB) + (size+bytesPerMB-1)/bytesPerMB
+	availableMB := int64(available / bytesPerMB)
+	if availableMB >= requiredMB {
+		return nil
+	}
+
+	if settings.GetLowDiskSpaceAction() == astrum.DiskSpaceWarn {
+		logger.App.Warn().Str("path", path).Str("operation", operation).Int64("availableMb", availableMB).Int64("requiredMb", requiredMB).Msg("Low disk space")
+		a.mu.RLock()
+		shuttingDown := a.shuttingDown
+		a.mu.RUnlock()
+		if !shuttingDown {
+			a.emit("disk:low", LowDiskSpaceInfo{
+				Path:        path,
+				AvailableMB: availableMB,
+				RequiredMB:  requiredMB,
+				Operation:   operation,
+			})
+		}
+		return nil
+	}
+
+	return &DiskSpaceError{Path: path, Operation: operation, AvailableMB: availableMB, RequiredMB: requiredMB}
+}
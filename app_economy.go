@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/neper-stars/houston/blocks"
+	"github.com/neper-stars/houston/store"
+)
+
+// =============================================================================
+// ECONOMY PROJECTION
+// =============================================================================
+
+// maxProjectionYears bounds the length of an economy projection
+const maxProjectionYears = 100
+
+// projectedPlanet is the evolving state of a planet during a projection
+type projectedPlanet struct {
+	planet     store.PlanetEntity // copy, its population and installations change
+	maxPop     int64              // colonists
+	habitable  int                // %, negative when the planet is hostile
+	maxMines   int
+	maxFactory int
+	spent      int // resources carried toward the next installation
+}
+
+// ProjectEconomy projects the player's empire years forward from the local
+// turn file, using the race settings. Each year every populated planet grows,
+// produces resources, gives its research share and spends the build share of
+// the rest on factories, then mines, until the population can't operate more.
+//
+// This is an approximation: it ignores minerals, terraforming, new colonies
+// and anything else in the production queues.
+func (a *App) ProjectEconomy(serverURL, sessionID string, years int, assumptions EconomyAssumptionsInfo) ([]EconomyYearInfo, error) {
+	if years < 1 || years > maxProjectionYears {
+		return nil, fmt.Errorf("years must be between 1 and %d", maxProjectionYears)
+	}
+	if assumptions.BuildPercent < 0 || assumptions.BuildPercent > 100 {
+		return nil, fmt.Errorf("build percentage must be between 0 and 100")
+	}
+
+	gs, err := a.loadSessionGameStore(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	player, ok := gs.Player(turnOwner(gs))
+	if !ok || !player.HasFullData {
+		return nil, fmt.Errorf("no race data in the turn file")
+	}
+
+	researchPct, _ := researchPercentage(player)
+	if assumptions.ResearchPercent != nil {
+		researchPct = *assumptions.ResearchPercent
+	}
+	if researchPct < 0 || researchPct > 100 {
+		return nil, fmt.Errorf("research percentage must be between 0 and 100")
+	}
+
+	owned := gs.PlanetsByOwner(player.PlayerNumber)
+	sort.Slice(owned, func(i, j int) bool { return owned[i].PlanetNumber < owned[j].PlanetNumber })
+	var planets []*projectedPlanet
+	for _, planet := range owned {
+		if planet.Population == 0 {
+			continue
+		}
+		planets = append(planets, &projectedPlanet{
+			planet:     *planet,
+			maxPop:     int64(gs.MaxPopulation(planet, player)) * 100,
+			habitable:  gs.PctPlanetDesirability(planet, player),
+			maxMines:   planet.MaxMines(gs, player),
+			maxFactory: gs.MaxFactories(planet, player),
+		})
+	}
+
+	startYear := blocks.StarsBaseYear + int(gs.Turn)
+	result := make([]EconomyYearInfo, 0, years)
+	for y := 1; y <= years; y++ {
+		year := EconomyYearInfo{Year: startYear + y}
+		for _, p := range planets {
+			resources := gs.CResourcesAtPlanet(&p.planet, player)
+			research := 0
+			if !p.planet.NoResearch {
+				research = resources * researchPct / 100
+			}
+			p.build(player, (resources-research)*assumptions.BuildPercent/100)
+			mined := p.mine(player)
+			p.grow(player)
+
+			year.Population += p.planet.Population
+			year.Resources += resources
+			year.Research += research
+			year.Factories += p.planet.Factories
+			year.Mines += p.planet.Mines
+			year.Ironium += mined[0]
+			year.Boranium += mined[1]
+			year.Germanium += mined[2]
+		}
+		year.Planets = len(planets)
+		result = append(result, year)
+	}
+	return result, nil
+}
+
+// build spends resources on factories then mines, within what the population
+// can operate
+func (p *projectedPlanet) build(player *store.PlayerEntity, resources int) {
+	p.spent += resources
+	operable := func(perTenK int) int { return int(p.planet.Population/100) * perTenK / 100 }
+
+	factoryCap := min(p.maxFactory, operable(player.Production.FactoriesOperate))
+	if player.PRT == blocks.PRTAlternateReality {
+		factoryCap = 0
+	}
+	for p.planet.Factories < factoryCap && player.Production.FactoryCost > 0 && p.spent >= player.Production.FactoryCost {
+		p.planet.Factories++
+		p.spent -= player.Production.FactoryCost
+	}
+	if p.planet.Factories < factoryCap {
+		return
+	}
+
+	mineCap := min(p.maxMines, operable(player.Production.MinesOperate))
+	for p.planet.Mines < mineCap && player.Production.MineCost > 0 && p.spent >= player.Production.MineCost {
+		p.planet.Mines++
+		p.spent -= player.Production.MineCost
+	}
+	if p.planet.Mines >= mineCap {
+		// nothing left to build, the resources would go to the queue
+		p.spent = 0
+	}
+}
+
+// mine returns the kT of ironium, boranium and germanium mined in a year
+func (p *projectedPlanet) mine(player *store.PlayerEntity) [3]int64 {
+	mines := min(p.planet.Mines, int(p.planet.Population/100)*player.Production.MinesOperate/100)
+	perConc := func(conc int) int64 {
+		return int64(mines * player.Production.MineProduction / 10 * conc / 100)
+	}
+	return [3]int64{perConc(p.planet.IroniumConc), perConc(p.planet.BoraniumConc), perConc(p.planet.GermaniumConc)}
+}
+
+// grow applies a year of population growth: the race's growth rate scaled by
+// the planet's habitability, slowed past a quarter of the capacity. Hostile
+// planets lose a tenth of their negative habitability, overcrowded ones a
+// tenth of their excess.
+func (p *projectedPlanet) grow(player *store.PlayerEntity) {
+	pop := p.planet.Population
+	var delta int64
+	switch {
+	case p.habitable < 0:
+		delta = pop * int64(p.habitable) / 1000
+	case p.maxPop > 0 && pop >= p.maxPop:
+		delta = -(pop - p.maxPop) / 10
+	default:
+		growth := float64(pop) * float64(player.GrowthRate) / 100 * float64(p.habitable) / 100
+		if p.maxPop > 0 {
+			if ratio := float64(pop) / float64(p.maxPop); ratio > 0.25 {
+				growth *= 16.0 / 9.0 * (1 - ratio) * (1 - ratio)
+			}
+		}
+		delta = int64(growth)
+		if p.maxPop > 0 && pop+delta > p.maxPop {
+			delta = p.maxPop - pop
+		}
+	}
+	// Stars! counts colonists by hundreds
+	p.planet.Population = max(0, (pop+delta)/100*100)
+}
+
+// researchPercentage returns the share of resources the player gives to
+// research, if the turn file has it
+func researchPercentage(player *store.PlayerEntity) (int, bool) {
+	for _, block := range player.RawBlocks() {
+		if pb, ok := block.(blocks.PlayerBlock); ok {
+			return pb.ResearchPercentage, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"github.com/neper-stars/astrum/lib/eventbatch"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// FRONTEND EVENTS
+// =============================================================================
+
+// eventFlushInterval is how long events set to the latest policy are held
+const eventFlushInterval = 100 * time.Millisecond
+
+// defaultEventRules are the events sent in bursts during big syncs. Only the
+// last one per server, session... is delivered each flush. The others go
+// through right away.
+var defaultEventRules = map[string]eventbatch.Rule{
+	"sessions:updated":      {Policy: eventbatch.Latest, KeyArgs: 1}, // serverURL
+	"connection:connecting": {Policy: eventbatch.Latest, KeyArgs: 1}, // serverURL
+	"orders:status":         {Policy: eventbatch.Latest, KeyArgs: 2}, // serverURL, sessionID
+	"generation:status":     {Policy: eventbatch.Latest, KeyArgs: 2}, // serverURL, sessionID
+	"server:status":         {Policy: eventbatch.Latest, KeyArgs: 1}, // serverURL
+	"disk:low":              {Policy: eventbatch.Latest},
+}
+
+// newEventBatcher creates the batcher delivering the app events to the frontend
+func (a *App) newEventBatcher() *eventbatch.Batcher {
+	b := eventbatch.New(eventFlushInterval, func(name string, args ...any) {
+		runtime.EventsEmit(a.ctx, name, args...)
+	})
+	for name, rule := range defaultEventRules {
+		b.SetRule(name, rule)
+	}
+	return b
+}
+
+// emit sends an event to the frontend, following the policy of its type
+func (a *App) emit(name string, args ...any) {
+	a.events.Emit(name, args...)
+}
+
+// GetEventPolicies returns the event types whose delivery is not immediate,
+// or was set explicitly
+func (a *App) GetEventPolicies() []EventPolicyInfo {
+	rules := a.events.Rules()
+	result := make([]EventPolicyInfo, 0, len(rules))
+	for name, rule := range rules {
+		result = append(result, EventPolicyInfo{Event: name, Policy: rule.Policy.String(), KeyArgs: rule.KeyArgs})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Event < result[j].Event })
+	return result
+}
+
+// SetEventPolicy sets how events of a type are delivered while the app runs:
+// "immediate", or "latest" to only deliver the last event per key every
+// flush, the key being the first keyArgs arguments. An event name ending with
+// "*" sets every event starting with the rest, e.g. "notification:*".
+func (a *App) SetEventPolicy(event, policy string, keyArgs int) ([]EventPolicyInfo, error) {
+	p, err := eventbatch.ParsePolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	if event == "" || keyArgs < 0 {
+		return nil, fmt.Errorf("invalid event policy")
+	}
+
+	a.events.SetRule(event, eventbatch.Rule{Policy: p, KeyArgs: keyArgs})
+	logger.App.Info().Str("event", event).Str("policy", p.String()).Int("keyArgs", keyArgs).Msg("Set event policy")
+	return a.GetEventPolicies(), nil
+}
@@ -0,0 +1,493 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/neper-stars/houston/blocks"
+	"github.com/neper-stars/houston/data"
+	"github.com/neper-stars/houston/store"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/telemetry"
+)
+
+// =============================================================================
+// GAME DATA EXPORT
+// =============================================================================
+
+// exportColumn describes one column of an exported dataset
+type exportColumn struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// exportDataset is a table ready to be written as JSON or CSV
+type exportDataset struct {
+	Name        string                   `json:"dataset"`
+	Description string                   `json:"description"`
+	ExportedAt  time.Time                `json:"exportedAt"`
+	Columns     []exportColumn           `json:"schema"`
+	Rows        []map[string]interface{} `json:"rows"`
+}
+
+var turnFilePattern = regexp.MustCompile(`^game\.m\d+$`)
+
+// ExportGameData writes a dataset of the session to the exports/ folder of its game
+// directory as "json" or "csv", and returns the written file path.
+// Datasets are "orders" and "scores" (every player, every year), and "races",
+// "planets" and "fleets" (as seen in the local turn file).
+func (a *App) ExportGameData(serverURL, sessionID, what, format string) (string, error) {
+	if format != "json" && format != "csv" {
+		return "", fmt.Errorf("unsupported export format: %s", format)
+	}
+
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL // fallback to URL if server not found
+	if server != nil {
+		serverName = server.Name
+	}
+
+	gameDir, err := a.config.EnsureSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	var dataset *exportDataset
+	switch what {
+	case "orders":
+		dataset, err = a.exportOrders(serverURL, sessionID)
+	case "scores":
+		dataset, err = a.exportScores(serverURL, sessionID)
+	case "races", "planets", "fleets":
+		var gs *store.GameStore
+		gs, err = loadLocalGameStore(gameDir)
+		if err != nil {
+			return "", err
+		}
+		switch what {
+		case "races":
+			dataset = exportRaces(gs)
+		case "planets":
+			dataset = exportPlanets(gs)
+		default:
+			dataset = exportFleets(gs)
+		}
+	default:
+		return "", fmt.Errorf("unknown dataset: %s", what)
+	}
+	if err != nil {
+		return "", err
+	}
+	dataset.ExportedAt = time.Now()
+
+	exportDir := filepath.Join(gameDir, "exports")
+	if err := os.MkdirAll(exportDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create exports directory: %w", err)
+	}
+
+	path := filepath.Join(exportDir, fmt.Sprintf("%s-%s.%s", what, dataset.ExportedAt.Format("20060102-150405"), format))
+	if format == "json" {
+		path, err = a.writeExportJSON(path, dataset)
+	} else {
+		path, err = a.writeExportCSV(path, dataset)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Str("dataset", what).
+		Int("rows", len(dataset.Rows)).
+		Str("path", path).
+		Msg("Exported game data")
+
+	a.telemetry.Count(telemetry.FeatureExport)
+	return path, nil
+}
+
+// exportOrders builds the order submission history of a session
+func (a *App) exportOrders(serverURL, sessionID string) (*exportDataset, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := mgr.GetContext()
+	latestTurn, err := client.GetLatestTurn(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest turn: %w", err)
+	}
+
+	dataset := &exportDataset{
+		Name:        "orders",
+		Description: "Order submission status of every player for every year",
+		Columns: []exportColumn{
+			{Name: "year", Description: "Game year the orders were submitted for"},
+			{Name: "playerOrder", Description: "Player number (0-indexed)"},
+			{Name: "nickname", Description: "Player nickname"},
+			{Name: "isBot", Description: "Whether the player is an AI"},
+			{Name: "submitted", Description: "Whether orders were submitted for that year"},
+		},
+		Rows: []map[string]interface{}{},
+	}
+
+	for year := blocks.StarsBaseYear; year <= int(latestTurn.Year); year++ {
+		status, err := client.GetOrdersStatus(ctx, sessionID, year)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get orders status for %d: %w", year, err)
+		}
+		for _, p := range status {
+			dataset.Rows = append(dataset.Rows, map[string]interface{}{
+				"year":        year,
+				"playerOrder": p.PlayerOrder,
+				"nickname":    p.Nickname,
+				"isBot":       p.IsBot,
+				"submitted":   p.Submitted,
+			})
+		}
+	}
+
+	return dataset, nil
+}
+
+// exportScores builds the score history of a session from the turn files of every year
+func (a *App) exportScores(serverURL, sessionID string) (*exportDataset, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := mgr.GetContext()
+	latestTurn, err := client.GetLatestTurn(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest turn: %w", err)
+	}
+
+	dataset := &exportDataset{
+		Name:        "scores",
+		Description: "Player scores of every year, as reported in your turn files",
+		Columns: []exportColumn{
+			{Name: "year", Description: "Game year"},
+			{Name: "player", Description: "Race name (plural)"},
+			{Name: "rank", Description: "Rank of the player"},
+			{Name: "score", Description: "Total score"},
+			{Name: "resources", Description: "Resources available"},
+			{Name: "planets", Description: "Number of planets owned"},
+			{Name: "starbases", Description: "Number of starbases"},
+			{Name: "unarmedShips", Description: "Number of unarmed ships"},
+			{Name: "escortShips", Description: "Number of escort ships"},
+			{Name: "capitalShips", Description: "Number of capital ships"},
+			{Name: "techLevels", Description: "Sum of tech levels"},
+		},
+		Rows: []map[string]interface{}{},
+	}
+
+	// One year is parsed at a time, only its scores are kept
+	defer freeProcessingMemory()
+	for year := blocks.StarsBaseYear; year <= int(latestTurn.Year); year++ {
+		gs, err := fetchYearGameStore(ctx, client, sessionID, year)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, player := range sortedPlayers(gs) {
+			score := player.StoredScore
+			if score == nil {
+				continue
+			}
+			dataset.Rows = append(dataset.Rows, map[string]interface{}{
+				"year":         year,
+				"player":       player.NamePlural,
+				"rank":         score.Rank,
+				"score":        score.Score,
+				"resources":    score.Resources,
+				"planets":      score.Planets,
+				"starbases":    score.Starbases,
+				"unarmedShips": score.UnarmedShips,
+				"escortShips":  score.EscortShips,
+				"capitalShips": score.CapitalShips,
+				"techLevels":   score.TechLevels,
+			})
+		}
+	}
+
+	return dataset, nil
+}
+
+// exportRaces lists the races known from a turn
+func exportRaces(gs *store.GameStore) *exportDataset {
+	dataset := &exportDataset{
+		Name:        "races",
+		Description: "Races known from the latest turn file",
+		Columns: []exportColumn{
+			{Name: "playerNumber", Description: "Player number (0-indexed)"},
+			{Name: "singularName", Description: "Race name (singular)"},
+			{Name: "pluralName", Description: "Race name (plural)"},
+			{Name: "prt", Description: "Primary racial trait, empty when unknown"},
+			{Name: "planets", Description: "Number of planets known to be owned"},
+			{Name: "fleets", Description: "Number of fleets known"},
+		},
+		Rows: []map[string]interface{}{},
+	}
+
+	for _, player := range sortedPlayers(gs) {
+		prt := ""
+		if player.HasFullData {
+			if info := data.GetPRT(player.PRT); info != nil {
+				prt = info.Code
+			}
+		}
+		dataset.Rows = append(dataset.Rows, map[string]interface{}{
+			"playerNumber": player.PlayerNumber,
+			"singularName": player.NameSingular,
+			"pluralName":   player.NamePlural,
+			"prt":          prt,
+			"planets":      player.PlanetCount,
+			"fleets":       player.FleetCount,
+		})
+	}
+
+	return dataset
+}
+
+// exportPlanets lists the planets known from a turn
+func exportPlanets(gs *store.GameStore) *exportDataset {
+	dataset := &exportDataset{
+		Name:        "planets",
+		Description: "Planets as seen in the latest turn file",
+		Columns: []exportColumn{
+			{Name: "year", Description: "Game year of the turn"},
+			{Name: "planetNumber", Description: "Planet number"},
+			{Name: "name", Description: "Planet name"},
+			{Name: "x", Description: "X coordinate"},
+			{Name: "y", Description: "Y coordinate"},
+			{Name: "owner", Description: "Owner race name (plural), empty when unowned"},
+			{Name: "homeworld", Description: "Whether the planet is a homeworld"},
+			{Name: "starbase", Description: "Whether the planet has a starbase"},
+			{Name: "population", Description: "Population"},
+			{Name: "mines", Description: "Number of mines"},
+			{Name: "factories", Description: "Number of factories"},
+			{Name: "defenses", Description: "Number of defenses"},
+			{Name: "ironiumConc", Description: "Ironium concentration"},
+			{Name: "boraniumConc", Description: "Boranium concentration"},
+			{Name: "germaniumConc", Description: "Germanium concentration"},
+		},
+		Rows: []map[string]interface{}{},
+	}
+
+	year := blocks.StarsBaseYear + int(gs.Turn)
+	planets := gs.AllPlanets()
+	sort.Slice(planets, func(i, j int) bool { return planets[i].PlanetNumber < planets[j].PlanetNumber })
+
+	for _, planet := range planets {
+		dataset.Rows = append(dataset.Rows, map[string]interface{}{
+			"year":          year,
+			"planetNumber":  planet.PlanetNumber,
+			"name":          planet.Name,
+			"x":             planet.X,
+			"y":             planet.Y,
+			"owner":         playerName(gs, planet.Owner),
+			"homeworld":     planet.IsHomeworld,
+			"starbase":      planet.HasStarbase,
+			"population":    planet.Population,
+			"mines":         planet.Mines,
+			"factories":     planet.Factories,
+			"defenses":      planet.Defenses,
+			"ironiumConc":   planet.IroniumConc,
+			"boraniumConc":  planet.BoraniumConc,
+			"germaniumConc": planet.GermaniumConc,
+		})
+	}
+
+	return dataset
+}
+
+// exportFleets lists the fleets known from a turn
+func exportFleets(gs *store.GameStore) *exportDataset {
+	dataset := &exportDataset{
+		Name:        "fleets",
+		Description: "Fleets as seen in the latest turn file",
+		Columns: []exportColumn{
+			{Name: "year", Description: "Game year of the turn"},
+			{Name: "owner", Description: "Owner race name (plural)"},
+			{Name: "fleetNumber", Description: "Fleet number (0-indexed)"},
+			{Name: "name", Description: "Fleet name"},
+			{Name: "x", Description: "X coordinate"},
+			{Name: "y", Description: "Y coordinate"},
+			{Name: "warp", Description: "Warp speed"},
+			{Name: "ships", Description: "Total number of ships"},
+			{Name: "composition", Description: "Ships per design, separated by semicolons"},
+		},
+		Rows: []map[string]interface{}{},
+	}
+
+	year := blocks.StarsBaseYear + int(gs.Turn)
+	fleets := gs.AllFleets()
+	sort.Slice(fleets, func(i, j int) bool {
+		if fleets[i].Owner != fleets[j].Owner {
+			return fleets[i].Owner < fleets[j].Owner
+		}
+		return fleets[i].FleetNumber < fleets[j].FleetNumber
+	})
+
+	for _, fleet := range fleets {
+		if fleet.IsDead {
+			continue
+		}
+		dataset.Rows = append(dataset.Rows, map[string]interface{}{
+			"year":        year,
+			"owner":       playerName(gs, fleet.Owner),
+			"fleetNumber": fleet.FleetNumber,
+			"name":        fleet.Name(),
+			"x":           fleet.X,
+			"y":           fleet.Y,
+			"warp":        fleet.Warp,
+			"ships":       fleet.TotalShips(),
+			"composition": fleetComposition(gs, fleet),
+		})
+	}
+
+	return dataset
+}
+
+// fleetComposition formats the ships of a fleet as "3x Scout; 1x Destroyer"
+func fleetComposition(gs *store.GameStore, fleet *store.FleetEntity) string {
+	designs := fleet.GetDesigns(gs)
+	slots := make([]int, 0, len(designs))
+	for slot := range designs {
+		slots = append(slots, slot)
+	}
+	sort.Ints(slots)
+
+	parts := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		parts = append(parts, fmt.Sprintf("%dx %s", designs[slot].Count, designs[slot].Design.Name))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// playerName returns the plural race name of a player, or "" if unknown or unowned
+func playerName(gs *store.GameStore, playerNumber int) string {
+	if playerNumber < 0 {
+		return ""
+	}
+	if player, ok := gs.Player(playerNumber); ok {
+		return player.NamePlural
+	}
+	return ""
+}
+
+// sortedPlayers returns the players of a store ordered by player number
+func sortedPlayers(gs *store.GameStore) []*store.PlayerEntity {
+	players := gs.AllPlayers()
+	sort.Slice(players, func(i, j int) bool { return players[i].PlayerNumber < players[j].PlayerNumber })
+	return players
+}
+
+// newGameStore parses a universe and a turn file
+func newGameStore(universe, turn []byte) (*store.GameStore, error) {
+	release := acquireParse()
+	defer release()
+
+	gs := store.New()
+	if err := gs.AddFile("game.xy", universe); err != nil {
+		return nil, fmt.Errorf("failed to load universe file: %w", err)
+	}
+	if err := gs.AddFile("game.m1", turn); err != nil {
+		return nil, fmt.Errorf("failed to load turn file: %w", err)
+	}
+	return gs, nil
+}
+
+// loadLocalGameStore parses the turn files of a game directory
+func loadLocalGameStore(gameDir string) (*store.GameStore, error) {
+	universe, turn, err := readLocalTurnFiles(gameDir)
+	if err != nil {
+		return nil, err
+	}
+	return newGameStore(universe, turn)
+}
+
+// findTurnFile returns the path of the .mN turn file of a game directory
+func findTurnFile(gameDir string) (string, error) {
+	entries, err := os.ReadDir(gameDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read game directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() && turnFilePattern.MatchString(strings.ToLower(entry.Name())) {
+			return filepath.Join(gameDir, entry.Name()), nil
+		}
+	}
+
+	return "", fmt.Errorf("no turn file found in %s", gameDir)
+}
+
+// writeExportJSON writes the dataset with its schema embedded and returns the path written
+func (a *App) writeExportJSON(path string, dataset *exportDataset) (string, error) {
+	out, err := jsoniter.MarshalIndent(dataset, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode export: %w", err)
+	}
+	path, err = a.writeArtifact(path, out, false)
+	if err != nil {
+		return "", fmt.Errorf("failed to write export: %w", err)
+	}
+	return path, nil
+}
+
+// writeExportCSV writes the dataset as CSV, with the schema in a companion .schema.txt file
+// so the CSV itself stays clean for spreadsheets. Returns the path of the CSV written.
+func (a *App) writeExportCSV(path string, dataset *exportDataset) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	header := make([]string, len(dataset.Columns))
+	for i, col := range dataset.Columns {
+		header[i] = col.Name
+	}
+	if err := w.Write(header); err != nil {
+		return "", fmt.Errorf("failed to write export: %w", err)
+	}
+	for _, row := range dataset.Rows {
+		record := make([]string, len(dataset.Columns))
+		for i, col := range dataset.Columns {
+			record[i] = fmt.Sprint(row[col.Name])
+		}
+		if err := w.Write(record); err != nil {
+			return "", fmt.Errorf("failed to write export: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("failed to write export: %w", err)
+	}
+
+	path, err := a.writeArtifact(path, buf.Bytes(), false)
+	if err != nil {
+		return "", fmt.Errorf("failed to write export: %w", err)
+	}
+
+	// The schema follows the name of the CSV actually written
+	var schema strings.Builder
+	fmt.Fprintf(&schema, "%s: %s\n\n", dataset.Name, dataset.Description)
+	for _, col := range dataset.Columns {
+		fmt.Fprintf(&schema, "%s\t%s\n", col.Name, col.Description)
+	}
+	schemaPath := strings.TrimSuffix(path, filepath.Ext(path)) + ".schema.txt"
+	if _, err := a.writeArtifact(schemaPath, []byte(schema.String()), true); err != nil {
+		return "", fmt.Errorf("failed to write export schema: %w", err)
+	}
+
+	return path, nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/neper-stars/houston/blocks"
+	"github.com/neper-stars/houston/store"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// STARS! REPORT DUMPS
+// =============================================================================
+
+// ExportStarsDumps writes the known universe of the local turn as the report
+// dumps Stars! itself produces (File > Dump in the game), which community
+// tools such as Stars! Calculator and galaxy viewers read:
+//
+//	game.map  universe definition: planet number, X, Y, name
+//	game.pN   planet report of player N
+//	game.fN   fleet report of player N
+//
+// Files are tab-separated with CRLF line endings and are written to
+// exports/stars-<year>/. Columns the turn file does not carry are left empty.
+// Returns the written file paths.
+func (a *App) ExportStarsDumps(serverURL, sessionID string) ([]string, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	turnFile, err := findTurnFile(gameDir)
+	if err != nil {
+		return nil, err
+	}
+	playerOrder := strings.TrimPrefix(strings.ToLower(filepath.Base(turnFile)), "game.m")
+
+	gs, err := loadLocalGameStore(gameDir)
+	if err != nil {
+		return nil, err
+	}
+
+	year := blocks.StarsBaseYear + int(gs.Turn)
+	dumpDir := filepath.Join(gameDir, "exports", fmt.Sprintf("stars-%d", year))
+
+	files := []struct {
+		name string
+		rows [][]string
+	}{
+		{"game.map", starsMapDump(gs)},
+		{"game.p" + playerOrder, starsPlanetDump(gs)},
+		{"game.f" + playerOrder, starsFleetDump(gs)},
+	}
+
+	var paths []string
+	for _, f := range files {
+		written, err := a.writeArtifact(filepath.Join(dumpDir, f.name), formatStarsDump(f.rows), false)
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, written)
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Int("year", year).
+		Str("dir", dumpDir).
+		Msg("Exported Stars! report dumps")
+
+	return paths, nil
+}
+
+// starsMapDump builds the universe definition dump
+func starsMapDump(gs *store.GameStore) [][]string {
+	rows := [][]string{{"#", "X", "Y", "Name"}}
+	for _, planet := range sortedPlanets(gs) {
+		rows = append(rows, []string{
+			strconv.Itoa(planet.PlanetNumber + 1),
+			strconv.Itoa(planet.X),
+			strconv.Itoa(planet.Y),
+			planet.Name,
+		})
+	}
+	return rows
+}
+
+// starsPlanetDump builds the planet report dump
+func starsPlanetDump(gs *store.GameStore) [][]string {
+	rows := [][]string{{
+		"Planet Name", "Owner", "Starbase", "Report Age", "Population", "Value",
+		"Mines", "Factories", "Def %",
+		"Surface Ironium", "Surface Boranium", "Surface Germanium",
+		"Ironium MC", "Boranium MC", "Germanium MC",
+	}}
+	for _, planet := range sortedPlanets(gs) {
+		starbase := ""
+		if planet.HasStarbase {
+			starbase = "Yes"
+		}
+		rows = append(rows, []string{
+			planet.Name,
+			playerName(gs, planet.Owner),
+			starbase,
+			"", // report age is not in the turn file
+			strconv.FormatInt(planet.Population, 10),
+			"", // value depends on the viewing race
+			strconv.Itoa(planet.Mines),
+			strconv.Itoa(planet.Factories),
+			"", // defense coverage depends on defense tech
+			strconv.FormatInt(planet.Ironium, 10),
+			strconv.FormatInt(planet.Boranium, 10),
+			strconv.FormatInt(planet.Germanium, 10),
+			strconv.Itoa(planet.IroniumConc),
+			strconv.Itoa(planet.BoraniumConc),
+			strconv.Itoa(planet.GermaniumConc),
+		})
+	}
+	return rows
+}
+
+// starsFleetDump builds the fleet report dump
+func starsFleetDump(gs *store.GameStore) [][]string {
+	planetAt := make(map[[2]int]string)
+	for _, planet := range gs.AllPlanets() {
+		planetAt[[2]int{planet.X, planet.Y}] = planet.Name
+	}
+
+	rows := [][]string{{
+		"Fleet Name", "X", "Y", "Planet", "Destination", "Battle Plan", "Ship Cnt",
+		"Ironium", "Boranium", "Germanium", "Colonists", "Fuel", "Owner", "ETA", "Warp", "Mass",
+	}}
+
+	fleets := gs.AllFleets()
+	sort.Slice(fleets, func(i, j int) bool {
+		if fleets[i].Owner != fleets[j].Owner {
+			return fleets[i].Owner < fleets[j].Owner
+		}
+		return fleets[i].FleetNumber < fleets[j].FleetNumber
+	})
+
+	for _, fleet := range fleets {
+		if fleet.IsDead {
+			continue
+		}
+		cargo := fleet.GetCargo()
+		rows = append(rows, []string{
+			fleet.Name(),
+			strconv.Itoa(fleet.X),
+			strconv.Itoa(fleet.Y),
+			planetAt[[2]int{fleet.X, fleet.Y}],
+			"", // destination and battle plan are only known for own fleets
+			"",
+			strconv.Itoa(fleet.TotalShips()),
+			strconv.FormatInt(cargo.Ironium, 10),
+			strconv.FormatInt(cargo.Boranium, 10),
+			strconv.FormatInt(cargo.Germanium, 10),
+			strconv.FormatInt(cargo.Population, 10),
+			strconv.FormatInt(cargo.Fuel, 10),
+			playerName(gs, fleet.Owner),
+			"",
+			strconv.Itoa(fleet.Warp),
+			strconv.FormatInt(fleet.GetTotalMass(gs), 10),
+		})
+	}
+	return rows
+}
+
+// sortedPlanets returns the planets of a turn by planet number
+func sortedPlanets(gs *store.GameStore) []*store.PlanetEntity {
+	planets := gs.AllPlanets()
+	sort.Slice(planets, func(i, j int) bool { return planets[i].PlanetNumber < planets[j].PlanetNumber })
+	return planets
+}
+
+// formatStarsDump joins rows the way Stars! writes its dumps
+func formatStarsDump(rows [][]string) []byte {
+	var b strings.Builder
+	for _, row := range rows {
+		b.WriteString(strings.Join(row, "\t"))
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/neper-stars/houston/blocks"
+	"github.com/neper-stars/houston/data"
+	"github.com/neper-stars/houston/store"
+)
+
+// =============================================================================
+// FUEL CALCULATOR
+// =============================================================================
+
+// ListMyShipDesigns returns the player's ship designs from the local turn file
+// with the figures the fuel calculator needs
+func (a *App) ListMyShipDesigns(serverURL, sessionID string) ([]ShipDesignInfo, error) {
+	gs, err := a.loadSessionGameStore(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	designs := gs.ShipDesignsByOwner(turnOwner(gs))
+	sort.Slice(designs, func(i, j int) bool { return designs[i].DesignNumber < designs[j].DesignNumber })
+
+	result := make([]ShipDesignInfo, 0, len(designs))
+	for _, design := range designs {
+		result = append(result, shipDesignInfo(design))
+	}
+	return result, nil
+}
+
+// CalculateFuelUsage computes the fuel a fleet of shipCount ships of a design
+// burns to travel distance light-years at the given warp while carrying cargoKt
+// kT of cargo. The design is looked up in the player's local turn file and the
+// race's IFE trait is applied.
+func (a *App) CalculateFuelUsage(serverURL, sessionID string, designNumber, shipCount, distance, warp, cargoKt int) (*FuelUsageInfo, error) {
+	if warp < 1 || warp > 10 {
+		return nil, fmt.Errorf("warp must be between 1 and 10")
+	}
+	if shipCount < 1 || distance < 0 || cargoKt < 0 {
+		return nil, fmt.Errorf("ship count must be positive, distance and cargo can't be negative")
+	}
+
+	gs, err := a.loadSessionGameStore(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := turnOwner(gs)
+	var design *store.DesignEntity
+	for _, d := range gs.ShipDesignsByOwner(owner) {
+		if d.DesignNumber == designNumber {
+			design = d
+			break
+		}
+	}
+	if design == nil {
+		return nil, fmt.Errorf("design %d not found in the turn file", designNumber)
+	}
+
+	info := shipDesignInfo(design)
+	if !info.HasEngine {
+		return nil, fmt.Errorf("%s has no engine", design.Name)
+	}
+	if cargoKt > info.CargoCapacity*shipCount {
+		return nil, fmt.Errorf("%d kT of cargo exceeds the fleet's capacity of %d kT", cargoKt, info.CargoCapacity*shipCount)
+	}
+
+	ife := false
+	if player, ok := gs.Player(owner); ok {
+		ife = player.HasLRT(blocks.LRTImprovedFuelEfficiency)
+	}
+
+	mass := info.Mass*shipCount + cargoKt
+	fuel := fuelUsage(mass, distance, info.FuelTable[warp], ife)
+	capacity := info.FuelCapacity * shipCount
+
+	return &FuelUsageInfo{
+		Mass:         mass,
+		Fuel:         fuel,
+		FuelCapacity: capacity,
+		Enough:       fuel <= capacity,
+		Turns:        travelTurns(distance, warp),
+		Efficiency:   info.FuelTable[warp],
+		IFE:          ife,
+		Safe:         warp <= info.SafeWarp,
+	}, nil
+}
+
+// shipDesignInfo converts a Houston design to the calculator's view of it
+func shipDesignInfo(design *store.DesignEntity) ShipDesignInfo {
+	info := ShipDesignInfo{
+		DesignNumber:  design.DesignNumber,
+		Name:          design.Name,
+		FuelCapacity:  design.GetFuelCapacity(),
+		CargoCapacity: design.GetCargoCapacity(),
+		FuelTable:     []int{},
+	}
+	if hull := design.Hull(); hull != nil {
+		info.Hull = hull.Name
+	}
+	for _, block := range design.RawBlocks() {
+		if db, ok := block.(blocks.DesignBlock); ok {
+			info.Mass = db.Mass
+		}
+	}
+	if engine := design.GetEngine(); engine != nil {
+		info.HasEngine = true
+		info.Engine = engine.Name
+		info.SafeWarp = engine.SafeSpeed
+		info.FreeWarp = engine.FreeSpeed
+		info.FuelTable = append([]int{}, engine.FuelPerMg[:]...)
+	}
+	return info
+}
+
+// fuelUsage applies the Stars! fuel formula: mass times distance times the
+// engine efficiency at the chosen warp (100 = 100%), over 200, rounded up.
+// IFE races burn 15% less.
+func fuelUsage(massKt, distance, efficiency int, ife bool) int {
+	fuel := float64(massKt) * float64(distance) * float64(efficiency) / 100 / 200
+	if ife {
+		if lrt := data.GetLRTByBitmask(blocks.LRTImprovedFuelEfficiency); lrt != nil {
+			fuel *= 1 - lrt.FuelEfficiencyBonus
+		}
+	}
+	return int(math.Ceil(fuel))
+}
+
+// travelTurns returns the number of turns needed to cover distance light-years
+// at warp, fleets move warp² light-years per turn
+func travelTurns(distance, warp int) int {
+	if distance == 0 {
+		return 0
+	}
+	perTurn := warp * warp
+	return (distance + perTurn - 1) / perTurn
+}
@@ -13,21 +13,18 @@ import (
 
 // StartGame initializes the game for a session (generates first turn)
 func (a *App) StartGame(serverURL, sessionID string) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
-	_, err := client.InitializeGame(mgr.GetContext(), sessionID)
+	_, err = client.InitializeGame(mgr.GetContext(), sessionID)
 	if err != nil {
 		return fmt.Errorf("failed to start game: %w", err)
 	}
 
 	logger.App.Info().Str("sessionId", sessionID).Msg("Started game")
+	a.trackGeneration(serverURL, sessionID, 0)
 	return nil
 }
 
@@ -37,13 +34,9 @@ func (a *App) StartGame(serverURL, sessionID string) error {
 
 // ReorderPlayers updates the player order in a session (manager only)
 func (a *App) ReorderPlayers(serverURL, sessionID string, playerOrders []map[string]interface{}) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
 	// Convert from map to api.PlayerOrder
@@ -75,6 +68,9 @@ func (a *App) ReorderPlayers(serverURL, sessionID string, playerOrders []map[str
 		Str("sessionId", sessionID).
 		Int("updatedCount", len(updated)).
 		Msg("Reordered players")
+
+	go a.syncOwnRaceFile(serverURL, sessionID)
+
 	return nil
 }
 
@@ -201,41 +197,46 @@ func convertRulesInfoToRuleset(info *RulesInfo) *api.Ruleset {
 
 // GetRules returns the ruleset for a session
 func (a *App) GetRules(serverURL, sessionID string) (*RulesInfo, error) {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err == nil {
+		var rules *api.Ruleset
+		rules, err = client.GetRules(mgr.GetContext(), sessionID)
+		if err == nil {
+			info := convertRuleset(rules)
+			a.cacheServerData(serverURL, cacheKeyRules+sessionID, info)
+			return info, nil
+		}
+		err = fmt.Errorf("failed to get rules: %w", err)
 	}
 
-	rules, err := client.GetRules(mgr.GetContext(), sessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get rules: %w", err)
+	var cached RulesInfo
+	if useServerCache(err) && a.cachedServerData(serverURL, cacheKeyRules+sessionID, &cached) {
+		cached.Stale = true
+		return &cached, nil
 	}
-
-	return convertRuleset(rules), nil
+	return nil, err
 }
 
 // SetRules updates the ruleset for a session (manager only)
 func (a *App) SetRules(serverURL, sessionID string, rulesInfo *RulesInfo) (*RulesInfo, error) {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
+	update := a.beginRulesUpdate(serverURL, sessionID, rulesInfo)
+
 	ruleset := convertRulesInfoToRuleset(rulesInfo)
 	updated, err := client.CreateRules(mgr.GetContext(), sessionID, ruleset)
 	if err != nil {
-		return nil, fmt.Errorf("failed to set rules: %w", err)
+		err = fmt.Errorf("failed to set rules: %w", err)
+		update.rollback(err)
+		return nil, err
 	}
 
 	logger.App.Info().Str("sessionId", sessionID).Msg("Updated rules")
 
-	return convertRuleset(updated), nil
+	info := convertRuleset(updated)
+	update.confirm(info)
+	return info, nil
 }
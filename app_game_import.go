@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// =============================================================================
+// GAME IMPORT
+// =============================================================================
+
+// starsGameFilePattern matches the files of a Stars! game of any name:
+// universe, host, turn, order and player history files
+var starsGameFilePattern = regexp.MustCompile(`(?i)^(.+)\.(xy|hst|m\d+|x\d+|h\d+)$`)
+
+// Ways of bringing found games into the game directories
+const (
+	importModeCopy = "copy"
+	importModeMove = "move"
+	importModeLink = "link" // hard links, source and game directory must be on the same volume
+)
+
+// foundGame is a set of Stars! files sharing a directory and a base name
+type foundGame struct {
+	dir   string
+	base  string
+	files []string // names as found on disk
+}
+
+// ScanForGames looks for Stars! games in a directory tree, e.g. the folders of
+// another launcher or a manual layout: every universe (.xy) file with the host,
+// turn and order files of the same name next to it. The servers directory is
+// skipped, its games are already known.
+func (a *App) ScanForGames(root string) ([]FoundGameInfo, error) {
+	games, err := a.scanForGames(root)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]FoundGameInfo, 0, len(games))
+	for _, g := range games {
+		info := FoundGameInfo{Dir: g.dir, Name: g.base, Files: g.files}
+		for _, name := range g.files {
+			ext := strings.ToLower(filepath.Ext(name))
+			switch {
+			case ext == ".hst":
+				info.HasHostFile = true
+			case strings.HasPrefix(ext, ".m"):
+				info.Players++
+				if year, err := starsFileYear(filepath.Join(g.dir, name)); err == nil && year > info.Year {
+					info.Year = year
+				}
+			}
+		}
+		if info.HasHostFile {
+			if year, err := starsFileYear(filepath.Join(g.dir, g.base+".hst")); err == nil {
+				info.Year = year
+			}
+		}
+		result = append(result, info)
+	}
+
+	logger.App.Info().Str("root", root).Int("games", len(result)).Msg("Scanned for Stars! games")
+	return result, nil
+}
+
+// scanForGames walks a directory tree for Stars! games
+func (a *App) scanForGames(root string) ([]*foundGame, error) {
+	if root == "" {
+		return nil, fmt.Errorf("no directory to scan")
+	}
+	// Games already managed by Astrum are not imported again
+	managed := make(map[string]bool)
+	if serversDir, err := a.config.GetServersDir(); err == nil {
+		managed[serversDir] = true
+	}
+	if servers, err := a.config.GetServers(); err == nil {
+		for _, srv := range servers {
+			if dir, err := a.config.GetServerDir(srv.Name); err == nil {
+				managed[dir] = true
+			}
+		}
+	}
+
+	sets := make(map[string]*foundGame)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil // Unreadable folder, scan the rest
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || managed[path]) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		m := starsGameFilePattern.FindStringSubmatch(d.Name())
+		if m == nil {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		key := dir + "\x00" + strings.ToLower(m[1])
+		set, ok := sets[key]
+		if !ok {
+			set = &foundGame{dir: dir, base: m[1]}
+			sets[key] = set
+		}
+		set.files = append(set.files, d.Name())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan %s: %w", root, err)
+	}
+
+	var games []*foundGame
+	for _, set := range sets {
+		for _, name := range set.files {
+			if strings.EqualFold(filepath.Ext(name), ".xy") {
+				sort.Strings(set.files)
+				games = append(games, set)
+				break
+			}
+		}
+	}
+	sort.Slice(games, func(i, j int) bool {
+		if games[i].dir != games[j].dir {
+			return games[i].dir < games[j].dir
+		}
+		return games[i].base < games[j].base
+	})
+	return games, nil
+}
+
+// ImportFoundGame brings a game found by ScanForGames into Astrum's layout:
+// the game directory of a session of a configured server, or of a new local
+// game named name when serverURL is empty. Files are renamed to game.* as
+// Astrum expects, and copied, moved or hard linked depending on mode ("copy",
+// "move" or "link"). stars.exe is brought along when it is next to the game.
+// Existing files of the game directory are never overwritten.
+func (a *App) ImportFoundGame(dir, baseName, serverURL, sessionID, name, mode string) (*ImportedGameInfo, error) {
+	switch mode {
+	case importModeCopy, importModeMove, importModeLink:
+	default:
+		return nil, fmt.Errorf("unknown import mode %q", mode)
+	}
+
+	var files []string
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+	for _, entry := range entries {
+		m := starsGameFilePattern.FindStringSubmatch(entry.Name())
+		if m != nil && !entry.IsDir() && strings.EqualFold(m[1], baseName) {
+			files = append(files, entry.Name())
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no %s game files in %s", baseName, dir)
+	}
+
+	info := &ImportedGameInfo{ServerURL: serverURL, SessionID: sessionID}
+	var gameDir string
+	if serverURL == "" {
+		if name == "" {
+			name = baseName
+		}
+		game, localDir, err := a.createLocalGame(name)
+		if err != nil {
+			return nil, err
+		}
+		info.ServerURL, info.SessionID, gameDir = astrum.LocalGamesServer, game.ID, localDir
+	} else {
+		server, err := a.config.GetServer(serverURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get server: %w", err)
+		}
+		if server == nil {
+			return nil, fmt.Errorf("server %s is not configured", serverURL)
+		}
+		if sessionID == "" {
+			return nil, fmt.Errorf("no session to import the game into")
+		}
+		if gameDir, err = a.config.EnsureSessionGameDir(server.Name, sessionID); err != nil {
+			return nil, err
+		}
+	}
+	info.GameDir = gameDir
+
+	// Check everything first, a half imported game is worse than none
+	targets := make(map[string]string, len(files)+1)
+	for _, file := range files {
+		targets[file] = "game" + strings.ToLower(filepath.Ext(file))
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), "stars.exe") {
+			if _, err := os.Stat(filepath.Join(gameDir, "stars.exe")); os.IsNotExist(err) {
+				targets[entry.Name()] = "stars.exe"
+			}
+		}
+	}
+	for _, target := range targets {
+		if _, err := os.Stat(filepath.Join(gameDir, target)); err == nil {
+			return nil, fmt.Errorf("%s already exists in %s", target, gameDir)
+		}
+		if target != "stars.exe" {
+			info.Files = append(info.Files, target)
+		}
+	}
+	sort.Strings(info.Files)
+
+	for file, target := range targets {
+		src, dst := filepath.Join(dir, file), filepath.Join(gameDir, target)
+		if err := importGameFile(src, dst, mode); err != nil {
+			return nil, err
+		}
+		a.recordGameFile(gameDir, dst, fileOriginLocal)
+	}
+
+	logger.App.Info().
+		Str("dir", dir).
+		Str("game", baseName).
+		Str("serverUrl", info.ServerURL).
+		Str("sessionId", info.SessionID).
+		Str("mode", mode).
+		Int("files", len(info.Files)).
+		Msg("Imported Stars! game")
+	return info, nil
+}
+
+// importGameFile copies, moves or hard links a file
+func importGameFile(src, dst, mode string) error {
+	switch mode {
+	case importModeLink:
+		if err := os.Link(src, dst); err != nil {
+			return fmt.Errorf("failed to link %s: %w", filepath.Base(src), err)
+		}
+		return nil
+	case importModeMove:
+		if err := os.Rename(src, dst); err == nil {
+			return nil
+		}
+		// Another volume: copy, then remove the source
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", filepath.Base(src), err)
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := safefile.Create(dst, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Base(dst), err)
+	}
+	defer func() { _ = out.Close() }()
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to copy %s: %w", filepath.Base(src), err)
+	}
+	if err := out.Commit(); err != nil {
+		return fmt.Errorf("failed to save %s: %w", filepath.Base(dst), err)
+	}
+
+	if mode == importModeMove {
+		_ = in.Close()
+		if err := os.Remove(src); err != nil {
+			logger.App.Warn().Err(err).Str("file", src).Msg("Failed to remove imported file")
+		}
+	}
+	return nil
+}
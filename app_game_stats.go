@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neper-stars/houston/blocks"
+	"github.com/neper-stars/houston/data"
+	"github.com/neper-stars/houston/store"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/api/models"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// GAME STATISTICS AND LEADERBOARD
+// =============================================================================
+
+// SetGameStatsSharing turns the submission of our finished games to the
+// leaderboard of a server on or off. It is off until the user opts in: the
+// final scores, duration and racial traits of a game are then submitted the
+// first time the game is seen finished.
+func (a *App) SetGameStatsSharing(serverURL string, enabled bool) error {
+	server, err := a.config.GetServer(serverURL)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return fmt.Errorf("server with URL %s not found", serverURL)
+	}
+
+	server.ShareGameStats = enabled
+	if err := a.config.UpdateServer(*server); err != nil {
+		return fmt.Errorf("failed to update server: %w", err)
+	}
+
+	logger.App.Info().Str("url", serverURL).Bool("enabled", enabled).Msg("Set game statistics sharing")
+	return nil
+}
+
+// SubmitGameStats submits the end-of-game statistics of a finished session to
+// the server leaderboard and returns them, e.g. for a game finished before
+// sharing was turned on. They are read from our final turn file: the scores
+// of the other players are only known when the game rules make them public,
+// and only our own primary racial trait is known.
+func (a *App) SubmitGameStats(serverURL, sessionID string) (*GameStatsInfo, error) {
+	a.mu.RLock()
+	unsupported := a.noLeaderboard[serverURL]
+	a.mu.RUnlock()
+	if unsupported {
+		return nil, fmt.Errorf("failed to submit game statistics: this server has no leaderboard: %w", api.ErrNotSupported)
+	}
+
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	ctx := mgr.GetContext()
+
+	session, err := client.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.State != models.SessionStateArchived {
+		return nil, fmt.Errorf("the game is not finished")
+	}
+
+	latest, err := client.GetLatestTurn(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest turn: %w", err)
+	}
+	gs, err := fetchYearGameStore(ctx, client, sessionID, int(latest.Year))
+	if err != nil {
+		return nil, err
+	}
+	stats := gameStats(gs, int(latest.Year))
+
+	err = client.SubmitGameStats(ctx, sessionID, stats)
+	if errors.Is(err, api.ErrNotSupported) {
+		a.markNoLeaderboard(serverURL)
+		return nil, fmt.Errorf("failed to submit game statistics: this server has no leaderboard: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit game statistics: %w", err)
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Int("finalYear", stats.FinalYear).
+		Int("players", len(stats.Players)).
+		Msg("Submitted game statistics")
+
+	info := &GameStatsInfo{
+		SessionID: sessionID,
+		FinalYear: stats.FinalYear,
+		Years:     stats.Years,
+		Players:   []GameStatsPlayerInfo{},
+	}
+	for _, p := range stats.Players {
+		info.Players = append(info.Players, GameStatsPlayerInfo{
+			PlayerOrder: int(p.PlayerOrder),
+			Race:        p.Race,
+			PRT:         p.PRT,
+			Score:       p.Score,
+			Rank:        p.Rank,
+		})
+	}
+	return info, nil
+}
+
+// GetServerLeaderboard returns the server-wide leaderboard, best first.
+// Servers without a leaderboard return api.ErrNotSupported, remembered until
+// the next connection.
+func (a *App) GetServerLeaderboard(serverURL string) ([]LeaderboardEntryInfo, error) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	unsupported := a.noLeaderboard[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk {
+		return nil, fmt.Errorf("%w: %s", ErrNotConnected, serverURL)
+	}
+	if unsupported {
+		return nil, fmt.Errorf("this server has no leaderboard: %w", api.ErrNotSupported)
+	}
+
+	entries, err := client.GetLeaderboard(mgr.GetContext())
+	if errors.Is(err, api.ErrNotSupported) {
+		a.markNoLeaderboard(serverURL)
+		return nil, fmt.Errorf("this server has no leaderboard: %w", err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+
+	result := make([]LeaderboardEntryInfo, 0, len(entries))
+	for _, e := range entries {
+		info := LeaderboardEntryInfo{
+			Rank:        e.Rank,
+			Nickname:    e.Nickname,
+			Games:       e.Games,
+			Wins:        e.Wins,
+			BestScore:   e.BestScore,
+			FavoritePRT: e.FavoritePRT,
+		}
+		if !e.LastGameAt.IsZero() {
+			info.LastGameAt = e.LastGameAt.Format(time.RFC3339)
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// shareGameStats submits the statistics of a game just seen finished, when
+// the user opted in for its server
+func (a *App) shareGameStats(serverURL, sessionID string) {
+	server, err := a.config.GetServer(serverURL)
+	if err != nil || server == nil || !server.ShareGameStats {
+		return
+	}
+	if _, err := a.SubmitGameStats(serverURL, sessionID); err != nil && !errors.Is(err, api.ErrNotSupported) {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to share game statistics")
+	}
+}
+
+// gameStats builds the end-of-game statistics of the final turn of a game,
+// with the players whose score is known
+func gameStats(gs *store.GameStore, finalYear int) *api.GameStats {
+	stats := &api.GameStats{
+		FinalYear: finalYear,
+		Years:     finalYear - blocks.StarsBaseYear,
+		Players:   []api.GameStatsPlayer{},
+	}
+	for _, player := range sortedPlayers(gs) {
+		if player.StoredScore == nil {
+			continue
+		}
+		p := api.GameStatsPlayer{
+			PlayerOrder: int64(player.PlayerNumber),
+			Race:        player.NamePlural,
+			Score:       player.StoredScore.Score,
+			Rank:        player.StoredScore.Rank,
+		}
+		if player.HasFullData {
+			if info := data.GetPRT(player.PRT); info != nil {
+				p.PRT = info.Code
+			}
+		}
+		stats.Players = append(stats.Players, p)
+	}
+	return stats
+}
+
+func (a *App) markNoLeaderboard(serverURL string) {
+	a.mu.Lock()
+	a.noLeaderboard[serverURL] = true
+	a.mu.Unlock()
+	logger.App.Debug().Str("serverUrl", serverURL).Msg("Server has no leaderboard")
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// TURN GENERATION PROGRESS
+// =============================================================================
+
+// Turn generation states
+const (
+	generationQueued     = "queued"     // generation requested, not seen running yet
+	generationGenerating = "generating" // the server has not produced the new year yet
+	generationDone       = "done"
+	generationFailed     = "failed"
+)
+
+// The server has no generation status endpoint: the latest turn is polled
+// until a new year appears, a new turn notification usually arriving first
+const (
+	generationPollInterval = 5 * time.Second
+	generationTimeout      = 10 * time.Minute
+)
+
+// GetGenerationStatus returns the progress of the last turn generation
+// followed for a session, or nil. Changes are emitted as "generation:status"
+// (serverURL, sessionID, status).
+func (a *App) GetGenerationStatus(serverURL, sessionID string) *GenerationStatusInfo {
+	a.generationMu.Lock()
+	defer a.generationMu.Unlock()
+
+	info, ok := a.generations[serverURL+"|"+sessionID]
+	if !ok {
+		return nil
+	}
+	status := *info
+	return &status
+}
+
+// trackGeneration follows the generation of the year after fromYear, unless
+// it is already followed
+func (a *App) trackGeneration(serverURL, sessionID string, fromYear int) {
+	key := serverURL + "|" + sessionID
+
+	a.generationMu.Lock()
+	if info, ok := a.generations[key]; ok && info.FromYear == fromYear &&
+		(info.Status == generationQueued || info.Status == generationGenerating) {
+		a.generationMu.Unlock()
+		return
+	}
+	now := time.Now()
+	info := &GenerationStatusInfo{
+		ServerURL: serverURL,
+		SessionID: sessionID,
+		FromYear:  fromYear,
+		Status:    generationQueued,
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	a.generations[key] = info
+	a.generationMu.Unlock()
+
+	logger.App.Info().Str("sessionId", sessionID).Int("fromYear", fromYear).Msg("Following turn generation")
+	a.emitGenerationStatus(info)
+
+	go a.pollGeneration(serverURL, sessionID, fromYear)
+}
+
+// pollGeneration polls the latest turn until the generation is over
+func (a *App) pollGeneration(serverURL, sessionID string, fromYear int) {
+	deadline := time.Now().Add(generationTimeout)
+	ticker := time.NewTicker(generationPollInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !a.generationPending(serverURL, sessionID, fromYear) {
+			return
+		}
+
+		client, mgr, err := a.sessionConnection(serverURL, sessionID)
+		if err != nil {
+			a.setGenerationStatus(serverURL, sessionID, fromYear, generationFailed, 0, "disconnected from server")
+			return
+		}
+		// Before the first turn exists the latest turn is an error, keep waiting
+		turn, err := client.GetLatestTurn(mgr.GetContext(), sessionID)
+		if err == nil && int(turn.Year) > fromYear {
+			a.setGenerationStatus(serverURL, sessionID, fromYear, generationDone, int(turn.Year), "")
+			return
+		}
+
+		if time.Now().After(deadline) {
+			reason := fmt.Sprintf("no new year after %s", generationTimeout)
+			a.setGenerationStatus(serverURL, sessionID, fromYear, generationFailed, 0, reason)
+			return
+		}
+		a.setGenerationStatus(serverURL, sessionID, fromYear, generationGenerating, 0, "")
+	}
+}
+
+// generationPending tells whether the generation after fromYear is still followed and not over
+func (a *App) generationPending(serverURL, sessionID string, fromYear int) bool {
+	a.generationMu.Lock()
+	defer a.generationMu.Unlock()
+
+	info, ok := a.generations[serverURL+"|"+sessionID]
+	return ok && info.FromYear == fromYear &&
+		(info.Status == generationQueued || info.Status == generationGenerating)
+}
+
+// onTurnGenerated ends the followed generation of a session when its new turn is ready
+func (a *App) onTurnGenerated(serverURL, sessionID string, year int) {
+	a.generationMu.Lock()
+	info, ok := a.generations[serverURL+"|"+sessionID]
+	fromYear := 0
+	if ok {
+		fromYear = info.FromYear
+	}
+	a.generationMu.Unlock()
+
+	if ok && (year == 0 || year > fromYear) {
+		a.setGenerationStatus(serverURL, sessionID, fromYear, generationDone, year, "")
+	}
+}
+
+// setGenerationStatus updates a followed generation, if it is still pending,
+// and emits the change
+func (a *App) setGenerationStatus(serverURL, sessionID string, fromYear int, status string, year int, reason string) {
+	if !a.generationPending(serverURL, sessionID, fromYear) {
+		return
+	}
+
+	a.generationMu.Lock()
+	info := a.generations[serverURL+"|"+sessionID]
+	changed := info.Status != status
+	info.Status = status
+	info.Year = year
+	info.Reason = reason
+	info.UpdatedAt = time.Now()
+	snapshot := *info
+	a.generationMu.Unlock()
+
+	if !changed {
+		return
+	}
+	switch status {
+	case generationDone:
+		logger.App.Info().Str("sessionId", sessionID).Int("year", year).Dur("took", time.Since(snapshot.StartedAt)).Msg("Turn generation done")
+	case generationFailed:
+		logger.App.Warn().Str("sessionId", sessionID).Str("reason", reason).Msg("Turn generation failed")
+	}
+	a.emitGenerationStatus(&snapshot)
+}
+
+// emitGenerationStatus emits a generation status unless the app is shutting down
+func (a *App) emitGenerationStatus(info *GenerationStatusInfo) {
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("generation:status", info.ServerURL, info.SessionID, info)
+	}
+}
+
+// followHostedGeneration follows the generation of a session the user hosts
+// once every human player has submitted orders
+func (a *App) followHostedGeneration(serverURL, sessionID string, status *OrdersStatusInfo) {
+	if len(status.Players) == 0 {
+		return
+	}
+	for _, p := range status.Players {
+		if !p.Submitted && !p.IsBot {
+			return
+		}
+	}
+
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return
+	}
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return
+	}
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil || !containsString(session.Managers, userInfo.User.ID) {
+		return
+	}
+
+	a.trackGeneration(serverURL, sessionID, status.PendingYear)
+}
+
+// generationDiagnosticsNote tells the host where the diagnostics come from
+const generationDiagnosticsNote = "The server does not publish its turn generation log: " +
+	"these checks are made on the session files it holds."
+
+// GetGenerationErrors looks for the usual causes of a failed turn generation
+// in the session files (session hosts only): missing host or universe file,
+// and corrupt, stale or unsubmitted orders
+func (a *App) GetGenerationErrors(serverURL, sessionID string) (*GenerationDiagnosticsInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := client.GetSessionFiles(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session files: %w", err)
+	}
+
+	diag := &GenerationDiagnosticsInfo{
+		SessionID:  sessionID,
+		Year:       int(files.Year),
+		Generation: a.GetGenerationStatus(serverURL, sessionID),
+		Issues:     []GenerationIssueInfo{},
+		Note:       generationDiagnosticsNote,
+	}
+
+	if diag.Generation != nil && diag.Generation.Status == generationFailed {
+		diag.Issues = append(diag.Issues, GenerationIssueInfo{
+			Code:       "generation_timeout",
+			Message:    "Turn generation did not complete: " + diag.Generation.Reason,
+			Suggestion: "Check the issues below, then ask the server administrator whether the host is running",
+		})
+	}
+	diag.Issues = append(diag.Issues, gameFileIssues(files)...)
+
+	for i, order := range files.Orders {
+		if order.B64Data == "" {
+			continue
+		}
+		if issue := diagnoseOrder(order.B64Data, i+1, diag.Year); issue != nil {
+			diag.Issues = append(diag.Issues, *issue)
+		}
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int("year", diag.Year).Int("issues", len(diag.Issues)).Msg("Diagnosed turn generation")
+	return diag, nil
+}
+
+// diagnoseOrder checks an order file held by the server, returning nil if it looks fine
+func diagnoseOrder(b64Data string, playerNumber, year int) *GenerationIssueInfo {
+	resubmit := fmt.Sprintf("Ask player %d to open the turn in Stars!, save and submit the orders again", playerNumber)
+
+	data, err := base64.StdEncoding.DecodeString(b64Data)
+	if err != nil {
+		return &GenerationIssueInfo{
+			Code:         "order_corrupt",
+			PlayerNumber: playerNumber,
+			Message:      fmt.Sprintf("The orders of player %d are not valid base64", playerNumber),
+			Suggestion:   resubmit,
+		}
+	}
+	validator, err := astrum.NewOrderValidatorFromBytes(data)
+	if err != nil {
+		return &GenerationIssueInfo{
+			Code:         "order_corrupt",
+			PlayerNumber: playerNumber,
+			Message:      fmt.Sprintf("The orders of player %d can't be read: %v", playerNumber, err),
+			Suggestion:   resubmit,
+		}
+	}
+	if year != 0 && validator.Year() != year {
+		return &GenerationIssueInfo{
+			Code:         "order_wrong_year",
+			PlayerNumber: playerNumber,
+			Message:      fmt.Sprintf("The orders of player %d are for year %d, not %d", playerNumber, validator.Year(), year),
+			Suggestion:   resubmit,
+		}
+	}
+	if !validator.TurnIsSubmitted() {
+		return &GenerationIssueInfo{
+			Code:         "order_not_submitted",
+			PlayerNumber: playerNumber,
+			Message:      fmt.Sprintf("The orders of player %d were saved without submitting the turn", playerNumber),
+			Suggestion:   resubmit,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/neper-stars/astrum/lib/help"
+)
+
+// =============================================================================
+// IN-APP HELP
+// =============================================================================
+
+// GetHelpTopic returns a help topic, its body in Markdown
+func (a *App) GetHelpTopic(id string) (*help.Topic, error) {
+	return help.Get(id)
+}
+
+// ListHelpTopics returns all the help topics, without their body
+func (a *App) ListHelpTopics() ([]help.Topic, error) {
+	return help.List()
+}
+
+// SearchHelp returns the help topics containing all the words of query
+func (a *App) SearchHelp(query string) ([]help.SearchResult, error) {
+	return help.Search(query)
+}
+
+// GetHelpForScreen returns the help topic explaining a screen of the app,
+// e.g. "race-builder" or "settings-wine"
+func (a *App) GetHelpForScreen(screen string) (*help.Topic, error) {
+	return help.ForScreen(screen)
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/neper-stars/astrum/api/models"
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/hooks"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// LIFECYCLE HOOKS
+// =============================================================================
+
+// maxHookRuns is the number of hook runs kept for the activity feed
+const maxHookRuns = 50
+
+// GetHooks returns the configured hooks
+func (a *App) GetHooks() ([]HookInfo, error) {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app settings: %w", err)
+	}
+
+	result := make([]HookInfo, 0, len(settings.Hooks))
+	for _, h := range settings.Hooks {
+		result = append(result, HookInfo{
+			Event:          h.Event,
+			Command:        h.Command,
+			TimeoutSeconds: h.TimeoutSeconds,
+			Enabled:        h.Enabled,
+		})
+	}
+	return result, nil
+}
+
+// SetHook adds or replaces the hook of an event. An empty command removes it
+func (a *App) SetHook(hook HookInfo) ([]HookInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	if !hooks.IsEvent(hook.Event) {
+		return nil, fmt.Errorf("unknown hook event: %s", hook.Event)
+	}
+	if hook.TimeoutSeconds < 0 {
+		return nil, fmt.Errorf("hook timeout must not be negative: %d", hook.TimeoutSeconds)
+	}
+
+	if err := a.config.SetHook(astrum.HookEntry{
+		Event:          hook.Event,
+		Command:        hook.Command,
+		TimeoutSeconds: hook.TimeoutSeconds,
+		Enabled:        hook.Enabled,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set hook: %w", err)
+	}
+
+	logger.App.Info().Str("event", hook.Event).Str("command", hook.Command).Msg("Set hook")
+
+	return a.GetHooks()
+}
+
+// TestHook runs the hook of an event with sample values and returns the outcome
+func (a *App) TestHook(event string) (*HookRunInfo, error) {
+	hook, err := a.findHook(event)
+	if err != nil {
+		return nil, err
+	}
+	if hook == nil {
+		return nil, fmt.Errorf("no hook configured for %s", event)
+	}
+
+	run := a.runHook(*hook, "", "", map[string]string{"ASTRUM_TEST": "1"})
+	return &run, nil
+}
+
+// GetHookRuns returns the most recent hook runs, newest first
+func (a *App) GetHookRuns() []HookRunInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	result := make([]HookRunInfo, len(a.hookRuns))
+	for i, run := range a.hookRuns {
+		result[len(a.hookRuns)-1-i] = run
+	}
+	return result
+}
+
+// findHook returns the hook configured for an event, or nil
+func (a *App) findHook(event string) (*astrum.HookEntry, error) {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app settings: %w", err)
+	}
+	for _, h := range settings.Hooks {
+		if h.Event == event {
+			return &h, nil
+		}
+	}
+	return nil, nil
+}
+
+// fireHook runs the enabled hook of an event, if any. Meant to be called in a goroutine.
+func (a *App) fireHook(event, serverURL, sessionID string, vars map[string]string) {
+	hook, err := a.findHook(event)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("event", event).Msg("Failed to look up hook")
+		return
+	}
+	if hook == nil || !hook.Enabled {
+		return
+	}
+
+	a.runHook(*hook, serverURL, sessionID, vars)
+}
+
+// runHook executes a hook, records the run and emits "hook:finished"
+func (a *App) runHook(hook astrum.HookEntry, serverURL, sessionID string, vars map[string]string) HookRunInfo {
+	env := map[string]string{
+		"ASTRUM_EVENT":      hook.Event,
+		"ASTRUM_SERVER_URL": serverURL,
+		"ASTRUM_SESSION_ID": sessionID,
+	}
+	if serverURL != "" {
+		server, _ := a.config.GetServer(serverURL)
+		serverName := serverURL // fallback to URL if server not found
+		if server != nil {
+			serverName = server.Name
+		}
+		env["ASTRUM_SERVER_NAME"] = serverName
+		if sessionID != "" {
+			if gameDir, err := a.config.GetSessionGameDir(serverName, sessionID); err == nil {
+				env["ASTRUM_GAME_DIR"] = gameDir
+			}
+		}
+	}
+	for k, v := range vars {
+		env[k] = v
+	}
+
+	run := HookRunInfo{
+		Event:     hook.Event,
+		Command:   hook.Command,
+		ServerURL: serverURL,
+		SessionID: sessionID,
+		StartedAt: time.Now(),
+	}
+
+	result, err := hooks.Run(hook.Command, env, time.Duration(hook.TimeoutSeconds)*time.Second)
+	if err != nil {
+		run.Error = err.Error()
+		logger.App.Warn().Err(err).Str("event", hook.Event).Str("command", hook.Command).Msg("Hook failed to start")
+	} else {
+		run.DurationMs = result.Duration.Milliseconds()
+		run.ExitCode = result.ExitCode
+		run.TimedOut = result.TimedOut
+		run.Output = result.Output
+		logger.App.Info().
+			Str("event", hook.Event).
+			Str("command", hook.Command).
+			Int("exitCode", result.ExitCode).
+			Bool("timedOut", result.TimedOut).
+			Dur("duration", result.Duration).
+			Msg("Hook finished")
+	}
+
+	a.mu.Lock()
+	a.hookRuns = append(a.hookRuns, run)
+	if len(a.hookRuns) > maxHookRuns {
+		a.hookRuns = a.hookRuns[len(a.hookRuns)-maxHookRuns:]
+	}
+	shuttingDown := a.shuttingDown
+	a.mu.Unlock()
+
+	if !shuttingDown {
+		a.emit("hook:finished", run)
+	}
+
+	return run
+}
+
+// fireOrderUploadedHook runs the order-uploaded hook
+func (a *App) fireOrderUploadedHook(serverURL, sessionID string, year int) {
+	a.fireHook(hooks.EventOrderUploaded, serverURL, sessionID, map[string]string{
+		"ASTRUM_YEAR": strconv.Itoa(year),
+	})
+}
+
+// checkGameFinished runs the game-finished hook the first time a session is
+// seen archived, and shares its statistics when opted in
+func (a *App) checkGameFinished(serverURL, sessionID string) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	done := a.finishedGames[serverURL+"|"+sessionID]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk || done {
+		return
+	}
+
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil || session.State != models.SessionStateArchived {
+		return
+	}
+
+	a.mu.Lock()
+	if a.finishedGames[serverURL+"|"+sessionID] {
+		a.mu.Unlock()
+		return
+	}
+	a.finishedGames[serverURL+"|"+sessionID] = true
+	a.mu.Unlock()
+
+	a.fireHook(hooks.EventGameFinished, serverURL, sessionID, map[string]string{
+		"ASTRUM_SESSION_NAME": session.Name,
+	})
+	a.shareGameStats(serverURL, sessionID)
+}
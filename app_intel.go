@@ -0,0 +1,360 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neper-stars/houston/blocks"
+	"github.com/neper-stars/houston/store"
+
+	"github.com/neper-stars/astrum/lib/intel"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+	"github.com/neper-stars/astrum/lib/telemetry"
+)
+
+// =============================================================================
+// INTEL SHARING
+// =============================================================================
+
+// intelDirName is the game directory subfolder holding imported intel packets
+const intelDirName = "intel"
+
+// ExportIntelPacket writes the planets and fleets of the local turn that pass
+// the filter to a signed intel packet in exports/, and returns its path
+func (a *App) ExportIntelPacket(serverURL, sessionID string, filter IntelFilterOptions) (string, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	me, err := localPlayerNumber(gameDir)
+	if err != nil {
+		return "", err
+	}
+	gs, err := loadLocalGameStore(gameDir)
+	if err != nil {
+		return "", err
+	}
+
+	key, err := a.intelSigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	payload := buildIntelPayload(gs, me, filter)
+	data, err := intel.Encode(payload, key)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("intel-%d%s", payload.Year, intel.FileExtension)
+	path, err := a.writeArtifact(filepath.Join(gameDir, "exports", name), data, false)
+	if err != nil {
+		return "", err
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Int("planets", len(payload.Planets)).
+		Int("fleets", len(payload.Fleets)).
+		Str("path", path).
+		Msg("Exported intel packet")
+
+	a.telemetry.Count(telemetry.FeatureIntelExchange)
+	return path, nil
+}
+
+// ImportIntelPacket verifies an ally's intel packet and stores it with the
+// session, replacing an older packet of the same sender and year
+func (a *App) ImportIntelPacket(serverURL, sessionID, path string) (*IntelPacketInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read intel packet: %w", err)
+	}
+
+	payload, fingerprint, err := intel.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse intel of another game when the local turn tells which game this is
+	if gs, err := loadLocalGameStore(gameDir); err == nil && gs.GameID != 0 && payload.GameID != gs.GameID {
+		return nil, fmt.Errorf("intel packet is from another game")
+	}
+
+	dest := filepath.Join(gameDir, intelDirName, fmt.Sprintf("%s-%d%s", fingerprint, payload.Year, intel.FileExtension))
+	if err := safefile.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create intel directory: %w", err)
+	}
+	if err := safefile.WriteFile(dest, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to save intel packet: %w", err)
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Str("sender", payload.Sender).
+		Str("fingerprint", fingerprint).
+		Int("year", payload.Year).
+		Msg("Imported intel packet")
+
+	a.telemetry.Count(telemetry.FeatureIntelExchange)
+	return intelPacketInfo(payload, fingerprint, dest), nil
+}
+
+// GetImportedIntel lists the intel packets imported into a session, newest year first
+func (a *App) GetImportedIntel(serverURL, sessionID string) ([]IntelPacketInfo, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	packets, err := importedIntel(gameDir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]IntelPacketInfo, 0, len(packets))
+	for _, p := range packets {
+		result = append(result, *intelPacketInfo(p.payload, p.fingerprint, p.path))
+	}
+	return result, nil
+}
+
+// GetImportedIntelData returns the planets and fleets of the intel packets
+// imported into a session for a year (0 for every year), newest year first,
+// to merge them into the map of the session. GenerateMapData merges those of
+// the year of the map on its own.
+func (a *App) GetImportedIntelData(serverURL, sessionID string, year int) ([]IntelDataInfo, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	packets, err := importedIntel(gameDir, year)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]IntelDataInfo, 0, len(packets))
+	for _, p := range packets {
+		info := IntelDataInfo{
+			Packet:  *intelPacketInfo(p.payload, p.fingerprint, p.path),
+			Planets: make([]IntelPlanetInfo, 0, len(p.payload.Planets)),
+			Fleets:  make([]IntelFleetInfo, 0, len(p.payload.Fleets)),
+		}
+		for _, planet := range p.payload.Planets {
+			info.Planets = append(info.Planets, IntelPlanetInfo{
+				Number:      planet.Number,
+				Name:        planet.Name,
+				X:           planet.X,
+				Y:           planet.Y,
+				Owner:       planet.Owner,
+				Starbase:    planet.Starbase,
+				Population:  planet.Population,
+				Mines:       planet.Mines,
+				Factories:   planet.Factories,
+				Defenses:    planet.Defenses,
+				Ironium:     planet.Ironium,
+				Boranium:    planet.Boranium,
+				Germanium:   planet.Germanium,
+				Gravity:     planet.Gravity,
+				Temperature: planet.Temperature,
+				Radiation:   planet.Radiation,
+			})
+		}
+		for _, fleet := range p.payload.Fleets {
+			info.Fleets = append(info.Fleets, IntelFleetInfo{
+				Owner:  fleet.Owner,
+				Number: fleet.Number,
+				Name:   fleet.Name,
+				X:      fleet.X,
+				Y:      fleet.Y,
+				Ships:  fleet.Ships,
+				Warp:   fleet.Warp,
+				DeltaX: fleet.DeltaX,
+				DeltaY: fleet.DeltaY,
+			})
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// importedPacket is a verified intel packet stored with a session
+type importedPacket struct {
+	payload     *intel.Payload
+	fingerprint string
+	path        string
+}
+
+// importedIntel reads the intel packets imported into a game directory for a
+// year (0 for every year), newest year first. Invalid packets are skipped.
+func importedIntel(gameDir string, year int) ([]importedPacket, error) {
+	entries, err := os.ReadDir(filepath.Join(gameDir, intelDirName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read intel directory: %w", err)
+	}
+
+	var result []importedPacket
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), intel.FileExtension) {
+			continue
+		}
+		path := filepath.Join(gameDir, intelDirName, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		payload, fingerprint, err := intel.Decode(data)
+		if err != nil {
+			logger.App.Warn().Err(err).Str("path", path).Msg("Skipping invalid intel packet")
+			continue
+		}
+		if year != 0 && payload.Year != year {
+			continue
+		}
+		result = append(result, importedPacket{payload: payload, fingerprint: fingerprint, path: path})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].payload.Year != result[j].payload.Year {
+			return result[i].payload.Year > result[j].payload.Year
+		}
+		return result[i].payload.Sender < result[j].payload.Sender
+	})
+	return result, nil
+}
+
+// intelSigningKey returns the key signing exported packets, creating it on first use
+func (a *App) intelSigningKey() (ed25519.PrivateKey, error) {
+	stored, err := a.config.CredentialStore().GetIntelSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	if stored != "" {
+		key, err := hex.DecodeString(stored)
+		if err == nil && len(key) == ed25519.PrivateKeySize {
+			return ed25519.PrivateKey(key), nil
+		}
+		logger.App.Warn().Msg("Stored intel signing key is invalid, generating a new one")
+	}
+
+	key, err := intel.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.config.CredentialStore().SetIntelSigningKey(hex.EncodeToString(key)); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// buildIntelPayload selects the intel of a turn allowed by the filter
+func buildIntelPayload(gs *store.GameStore, me int, filter IntelFilterOptions) *intel.Payload {
+	payload := &intel.Payload{
+		GameID:     gs.GameID,
+		Year:       blocks.StarsBaseYear + int(gs.Turn),
+		Sender:     playerName(gs, me),
+		ExportedAt: time.Now(),
+	}
+
+	shared := func(owner int) bool {
+		if owner == me && !filter.IncludeOwn {
+			return false
+		}
+		return len(filter.Owners) == 0 || slices.Contains(filter.Owners, owner)
+	}
+
+	if filter.Planets {
+		for _, planet := range sortedPlanets(gs) {
+			if !shared(planet.Owner) {
+				continue
+			}
+			p := intel.Planet{
+				Number:   planet.PlanetNumber,
+				Name:     planet.Name,
+				X:        planet.X,
+				Y:        planet.Y,
+				Owner:    planet.Owner,
+				Starbase: planet.HasStarbase,
+			}
+			if filter.Installations {
+				p.Population = planet.Population
+				p.Mines = planet.Mines
+				p.Factories = planet.Factories
+				p.Defenses = planet.Defenses
+			}
+			if filter.Environment {
+				p.Ironium = planet.IroniumConc
+				p.Boranium = planet.BoraniumConc
+				p.Germanium = planet.GermaniumConc
+				p.Gravity = planet.Gravity
+				p.Temperature = planet.Temperature
+				p.Radiation = planet.Radiation
+			}
+			payload.Planets = append(payload.Planets, p)
+		}
+	}
+
+	if filter.Fleets {
+		for _, fleet := range gs.AllFleets() {
+			if fleet.IsDead || !shared(fleet.Owner) {
+				continue
+			}
+			payload.Fleets = append(payload.Fleets, intel.Fleet{
+				Owner:  fleet.Owner,
+				Number: fleet.FleetNumber,
+				Name:   fleet.Name(),
+				X:      fleet.X,
+				Y:      fleet.Y,
+				Ships:  fleet.TotalShips(),
+				Warp:   fleet.Warp,
+				DeltaX: fleet.DeltaX,
+				DeltaY: fleet.DeltaY,
+			})
+		}
+	}
+
+	return payload
+}
+
+// intelPacketInfo summarizes a packet for the frontend
+func intelPacketInfo(p *intel.Payload, fingerprint, path string) *IntelPacketInfo {
+	return &IntelPacketInfo{
+		Sender:      p.Sender,
+		Fingerprint: fingerprint,
+		Year:        p.Year,
+		ExportedAt:  p.ExportedAt.Format(time.RFC3339),
+		Planets:     len(p.Planets),
+		Fleets:      len(p.Fleets),
+		Path:        path,
+	}
+}
+
+// localPlayerNumber returns the 0-indexed player number of the local turn file (game.mN)
+func localPlayerNumber(gameDir string) (int, error) {
+	turnFile, err := findTurnFile(gameDir)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(filepath.Base(turnFile)), "game.m"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid turn file name: %s", turnFile)
+	}
+	return n - 1, nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"time"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// KEYRING AVAILABILITY
+// =============================================================================
+
+// Delays between two checks of a keyring that could not be read, growing
+// from keyringRetryMin to keyringRetryMax
+const (
+	keyringRetryMin = 2 * time.Second
+	keyringRetryMax = time.Minute
+)
+
+// GetKeyringStatus tells whether the stored credentials are locked: the
+// system keyring could not be read, e.g. its daemon was not running yet when
+// the app was started at login. Locked credentials are waited for, and the
+// startup connections they held are made once the keyring is available.
+func (a *App) GetKeyringStatus() *KeyringStatusInfo {
+	a.mu.RLock()
+	status := &KeyringStatusInfo{Locked: a.keyringLocked}
+	a.mu.RUnlock()
+	if !status.Locked {
+		return status
+	}
+
+	if err := a.config.CredentialStore().Available(); err != nil {
+		status.Error = err.Error()
+	}
+	for _, info := range a.GetAutoConnectStatus() {
+		if info.Status == autoConnectLocked {
+			status.Held = append(status.Held, info.ServerURL)
+		}
+	}
+	return status
+}
+
+// credentialsLocked records that the keyring could not be read and waits for
+// it in the background, once. Emits "keyring:locked".
+func (a *App) credentialsLocked(err error) {
+	a.mu.Lock()
+	waiting := a.keyringLocked
+	a.keyringLocked = true
+	shuttingDown := a.shuttingDown
+	a.mu.Unlock()
+	if waiting {
+		return
+	}
+
+	logger.App.Warn().Err(err).Msg("Keyring unavailable, credentials are locked")
+	if !shuttingDown {
+		a.emit("keyring:locked", err.Error())
+	}
+	go a.waitForKeyring()
+}
+
+// waitForKeyring checks the keyring until it answers, then emits
+// "keyring:unlocked" and makes the startup connections it held
+func (a *App) waitForKeyring() {
+	delay := keyringRetryMin
+	for {
+		time.Sleep(delay)
+
+		a.mu.RLock()
+		shuttingDown := a.shuttingDown
+		a.mu.RUnlock()
+		if shuttingDown {
+			return
+		}
+
+		err := a.config.CredentialStore().Available()
+		if err == nil {
+			break
+		}
+		logger.App.Debug().Err(err).Dur("retryIn", delay).Msg("Keyring still unavailable")
+		delay = min(delay*2, keyringRetryMax)
+	}
+
+	held := []string{}
+	for _, info := range a.GetAutoConnectStatus() {
+		if info.Status == autoConnectLocked {
+			held = append(held, info.ServerURL)
+		}
+	}
+
+	a.mu.Lock()
+	a.keyringLocked = false
+	shuttingDown := a.shuttingDown
+	a.mu.Unlock()
+
+	logger.App.Info().Int("servers", len(held)).Msg("Keyring available, credentials are unlocked")
+	if !shuttingDown {
+		a.emit("keyring:unlocked")
+	}
+
+	if len(held) > 0 {
+		a.autoConnectServers(held...)
+	}
+}
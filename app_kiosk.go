@@ -0,0 +1,150 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// GUEST MODE
+// =============================================================================
+
+// ErrGuestMode is returned by the destructive actions disabled in guest mode
+var ErrGuestMode = errors.New("not available in guest mode")
+
+// kioskMaxFailures is the number of wrong PINs accepted before unlocking is
+// refused for kioskLockout
+const (
+	kioskMaxFailures = 5
+	kioskLockout     = 30 * time.Second
+)
+
+// requireUnlocked returns ErrGuestMode when guest mode is on
+func (a *App) requireUnlocked() error {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	if settings.GetKioskLocked() {
+		return ErrGuestMode
+	}
+	return nil
+}
+
+// GetKioskStatus returns whether a guest mode PIN is set and guest mode is on
+func (a *App) GetKioskStatus() (*KioskStatusInfo, error) {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, err
+	}
+	return &KioskStatusInfo{
+		PinSet: settings.KioskPinHash != nil,
+		Locked: settings.GetKioskLocked(),
+	}, nil
+}
+
+// SetKioskPin sets the guest mode PIN (4 to 8 digits). currentPin must match
+// the PIN already set, if any. An empty newPin removes the PIN.
+func (a *App) SetKioskPin(currentPin, newPin string) (*KioskStatusInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, err
+	}
+	if settings.KioskPinHash != nil {
+		if err := a.checkKioskPin(*settings.KioskPinHash, currentPin); err != nil {
+			return nil, err
+		}
+	}
+
+	hash := ""
+	if newPin != "" {
+		if err := astrum.ValidateKioskPin(newPin); err != nil {
+			return nil, err
+		}
+		if hash, err = astrum.HashKioskPin(newPin); err != nil {
+			return nil, err
+		}
+	}
+	if err := a.config.SetKioskPin(hash); err != nil {
+		return nil, fmt.Errorf("failed to save PIN: %w", err)
+	}
+
+	logger.App.Info().Bool("pinSet", hash != "").Msg("Set guest mode PIN")
+	return a.kioskChanged()
+}
+
+// LockKiosk turns guest mode on, which requires a PIN to be set
+func (a *App) LockKiosk() (*KioskStatusInfo, error) {
+	if err := a.config.SetKioskLocked(true); err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Msg("Guest mode on")
+	return a.kioskChanged()
+}
+
+// UnlockKiosk turns guest mode off if the PIN matches
+func (a *App) UnlockKiosk(pin string) (*KioskStatusInfo, error) {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, err
+	}
+	if settings.KioskPinHash == nil || !settings.GetKioskLocked() {
+		return a.GetKioskStatus()
+	}
+	if err := a.checkKioskPin(*settings.KioskPinHash, pin); err != nil {
+		return nil, err
+	}
+
+	if err := a.config.SetKioskLocked(false); err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Msg("Guest mode off")
+	return a.kioskChanged()
+}
+
+// checkKioskPin checks a PIN, refusing any attempt for a while after too many wrong ones
+func (a *App) checkKioskPin(hash, pin string) error {
+	a.kioskMu.Lock()
+	defer a.kioskMu.Unlock()
+
+	if wait := time.Until(a.kioskRetryAt); wait > 0 {
+		return fmt.Errorf("too many wrong PINs, try again in %d seconds", int(wait.Seconds())+1)
+	}
+	if !astrum.CheckKioskPin(hash, pin) {
+		a.kioskFailures++
+		if a.kioskFailures >= kioskMaxFailures {
+			a.kioskFailures = 0
+			a.kioskRetryAt = time.Now().Add(kioskLockout)
+			logger.App.Warn().Msg("Too many wrong guest mode PINs")
+		}
+		return fmt.Errorf("wrong PIN")
+	}
+	a.kioskFailures = 0
+	return nil
+}
+
+// kioskChanged emits "kiosk:changed" (status) and returns the status
+func (a *App) kioskChanged() (*KioskStatusInfo, error) {
+	status, err := a.GetKioskStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("kiosk:changed", status)
+	}
+	return status, nil
+}
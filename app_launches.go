@@ -0,0 +1,207 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// LAUNCH HISTORY
+// =============================================================================
+
+// launchStderrTail bounds the error output of a Stars! run kept in the launch
+// history, Wine failures are reported in the last lines
+const launchStderrTail = 4096
+
+// GetLaunchHistory returns the recent Stars! launches of a session, most
+// recent first, with the end of the Wine error output of each run so that
+// "it crashed on startup" can be told apart from a Wine setup problem
+func (a *App) GetLaunchHistory(serverURL, sessionID string) ([]LaunchRecordInfo, error) {
+	history, err := a.config.GetLaunchHistory(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]LaunchRecordInfo, 0, len(history.Launches))
+	for i := len(history.Launches) - 1; i >= 0; i-- {
+		r := history.Launches[i]
+		info := LaunchRecordInfo{
+			StartedAt:  r.StartedAt,
+			Dir:        r.Dir,
+			File:       r.File,
+			Wine:       r.Wine,
+			WinePrefix: r.WinePrefix,
+			Env:        r.Env,
+			ExitCode:   r.ExitCode,
+			Error:      r.Error,
+			Stderr:     r.Stderr,
+		}
+		if !r.EndedAt.IsZero() {
+			info.DurationMs = r.EndedAt.Sub(r.StartedAt).Milliseconds()
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// superviseLaunch waits for a Stars! run to exit and adds it to the launch
+// history of its session
+func (a *App) superviseLaunch(serverURL, sessionID string, cmd *exec.Cmd, record model.LaunchRecord, stderr *tailBuffer) {
+	err := cmd.Wait()
+	record.EndedAt = time.Now()
+
+	a.runningMu.Lock()
+	delete(a.running, cmd)
+	a.runningMu.Unlock()
+
+	record.Stderr = stderr.String()
+	record.ExitCode = cmd.ProcessState.ExitCode()
+
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		record.Error = err.Error()
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Int("exitCode", record.ExitCode).
+		Dur("duration", record.EndedAt.Sub(record.StartedAt)).
+		Msg("Stars! exited")
+
+	a.recordLaunch(serverURL, sessionID, record)
+	a.pickUpOrders(serverURL, sessionID, record.StartedAt)
+}
+
+// pickUpOrders uploads the orders saved during a Stars! run as soon as it
+// exits, instead of waiting for the order file watcher timing. The outcome is
+// emitted as usual ("order:submitted" or "order:error").
+func (a *App) pickUpOrders(serverURL, sessionID string, since time.Time) {
+	a.mu.RLock()
+	orderMon := a.orderMonitors[serverURL]
+	a.mu.RUnlock()
+	if orderMon == nil {
+		return
+	}
+
+	if orderMon.ProcessNow(sessionID, since) {
+		logger.App.Info().Str("sessionId", sessionID).Msg("Picked up orders after Stars! exited")
+	}
+}
+
+// recordLaunch adds a run to the launch history of a session
+func (a *App) recordLaunch(serverURL, sessionID string, record model.LaunchRecord) {
+	a.launchMu.Lock()
+	defer a.launchMu.Unlock()
+
+	history, err := a.config.GetLaunchHistory(serverURL, sessionID)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to load launch history")
+		return
+	}
+	history.Add(record)
+	if err := a.config.SetLaunchHistory(serverURL, sessionID, history); err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to save launch history")
+	}
+}
+
+// runningLaunch is a Stars! process started by Astrum
+type runningLaunch struct {
+	serverURL  string
+	sessionID  string
+	winePrefix string // "" when run natively
+}
+
+// checkConcurrentLaunch applies the concurrent launch policy before starting
+// Stars! for a session: two instances sharing a Wine prefix or a game
+// directory can overwrite each other's files. Blocked launches return an
+// error naming the session already running, warnings emit
+// "launch:concurrent" (serverURL, sessionID, message).
+func (a *App) checkConcurrentLaunch(serverURL, sessionID, winePrefix string) error {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return fmt.Errorf("failed to get app settings: %w", err)
+	}
+	policy := settings.GetConcurrentLaunchPolicy()
+	if policy == astrum.ConcurrentLaunchAllow {
+		return nil
+	}
+
+	var conflict runningLaunch
+	found, samePrefix := false, false
+	a.runningMu.Lock()
+	for _, r := range a.running {
+		if r.serverURL == serverURL && r.sessionID == sessionID {
+			conflict, found, samePrefix = r, true, false
+			break
+		}
+		if winePrefix != "" && r.winePrefix == winePrefix {
+			conflict, found, samePrefix = r, true, true
+		}
+	}
+	a.runningMu.Unlock()
+	if !found {
+		return nil
+	}
+
+	name := conflict.sessionID
+	if gameDir, err := a.sessionGameDir(conflict.serverURL, conflict.sessionID); err == nil {
+		if n := a.sessionArchiveName(conflict.serverURL, conflict.sessionID, gameDir); n != "" {
+			name = n
+		}
+	}
+	message := fmt.Sprintf("Stars! is already running for this session (%s)", name)
+	if samePrefix {
+		message = fmt.Sprintf("Stars! is already running for %s in the same Wine prefix", name)
+	}
+
+	if policy == astrum.ConcurrentLaunchBlock {
+		return fmt.Errorf("%s, close it first", message)
+	}
+
+	logger.App.Warn().Str("sessionId", sessionID).Str("runningSessionId", conflict.sessionID).Bool("samePrefix", samePrefix).Msg("Launching Stars! while another instance runs")
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("launch:concurrent", serverURL, sessionID, message)
+	}
+	return nil
+}
+
+// tailBuffer is a writer keeping the last bytes written to it
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+	max int
+}
+
+// newTailBuffer returns a writer keeping the last max bytes written
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+// Write keeps the end of p, dropping the oldest bytes past the limit
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-t.max:]...)
+	}
+	return len(p), nil
+}
+
+// String returns the bytes kept
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gen2brain/beeep"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/api/models"
+	"github.com/neper-stars/astrum/lib/auth"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// LIVE ROUNDS
+// =============================================================================
+
+// maxLiveRoundMinutes is the longest countdown of a live round
+const maxLiveRoundMinutes = 4 * 60
+
+// StartLiveRound starts a countdown of the given minutes for all the players
+// of a started session to play the pending year together. Members get the
+// countdown and a ready-check through "liveround:updated" events. Managers only.
+func (a *App) StartLiveRound(serverURL, sessionID string, minutes int) (*LiveRoundInfo, error) {
+	if minutes < 1 || minutes > maxLiveRoundMinutes {
+		return nil, fmt.Errorf("a live round lasts between 1 and %d minutes", maxLiveRoundMinutes)
+	}
+
+	client, mgr, session, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.State != models.SessionStateStarted {
+		return nil, fmt.Errorf("the game has not started")
+	}
+
+	round, err := client.StartLiveRound(mgr.GetContext(), sessionID, time.Duration(minutes)*time.Minute)
+	if err != nil {
+		return nil, liveRoundError("failed to start live round", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int("year", round.Year).Int("minutes", minutes).Msg("Started live round")
+	return a.convertLiveRound(serverURL, client, mgr, round), nil
+}
+
+// EndLiveRound stops the live round of a session. Managers only.
+func (a *App) EndLiveRound(serverURL, sessionID string) error {
+	client, mgr, _, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := client.EndLiveRound(mgr.GetContext(), sessionID); err != nil {
+		return liveRoundError("failed to end live round", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Msg("Ended live round")
+	return nil
+}
+
+// GetLiveRound returns the live round of a session, nil when none is running
+// or the server has no live rounds
+func (a *App) GetLiveRound(serverURL, sessionID string) (*LiveRoundInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	round, err := client.GetLiveRound(mgr.GetContext(), sessionID)
+	if errors.Is(err, api.ErrNotSupported) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get live round: %w", err)
+	}
+	if round == nil {
+		return nil, nil
+	}
+	return a.convertLiveRound(serverURL, client, mgr, round), nil
+}
+
+// RespondLiveRound answers the ready-check of the live round of a session
+func (a *App) RespondLiveRound(serverURL, sessionID string, ready bool) (*LiveRoundInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	round, err := client.RespondLiveRound(mgr.GetContext(), sessionID, ready)
+	if err != nil {
+		return nil, liveRoundError("failed to answer the ready-check", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Bool("ready", ready).Msg("Answered live round ready-check")
+	return a.convertLiveRound(serverURL, client, mgr, round), nil
+}
+
+// refreshLiveRound fetches the live round of a session after a notification
+// and emits "liveround:updated" (serverURL, sessionID, round or nil). A
+// desktop notification tells the players a round started.
+func (a *App) refreshLiveRound(serverURL, sessionID string, started bool) {
+	round, err := a.GetLiveRound(serverURL, sessionID)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to refresh live round")
+		return
+	}
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if shuttingDown {
+		return
+	}
+	a.emit("liveround:updated", serverURL, sessionID, round)
+
+	if started && round != nil {
+		sessionName := sessionID
+		if info, err := a.GetSession(serverURL, sessionID); err == nil {
+			sessionName = info.Name
+		}
+		title := "Live Round Started"
+		message := fmt.Sprintf("%s started a live round of year %d in %s, %d minutes to play",
+			round.StartedBy, round.Year, sessionName, (round.RemainingSeconds+59)/60)
+		if err := beeep.Notify(title, message, a.notificationIcon); err != nil {
+			logger.App.Warn().Err(err).Msg("Failed to show desktop notification")
+		}
+	}
+}
+
+// liveRoundError wraps a failed live round request
+func liveRoundError(msg string, err error) error {
+	if errors.Is(err, api.ErrNotSupported) {
+		return fmt.Errorf("%s: this server does not support live rounds: %w", msg, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// convertLiveRound converts a live round to the frontend format
+func (a *App) convertLiveRound(serverURL string, client *api.Client, mgr *auth.Manager, round *api.LiveRound) *LiveRoundInfo {
+	startedBy := round.StartedBy
+	if profiles, err := a.getCachedUserProfiles(serverURL, client, mgr); err == nil {
+		for _, p := range profiles {
+			if p.ID == round.StartedBy {
+				startedBy = p.Nickname
+				break
+			}
+		}
+	}
+
+	info := &LiveRoundInfo{
+		ID:               round.ID,
+		Year:             round.Year,
+		StartedBy:        startedBy,
+		EndsAt:           round.EndsAt.Format(time.RFC3339),
+		RemainingSeconds: max(round.RemainingSeconds, 0),
+		Ready:            []string{},
+		NotReady:         []string{},
+	}
+	for userID, ready := range round.Responses {
+		if ready {
+			info.Ready = append(info.Ready, userID)
+		} else {
+			info.NotReady = append(info.NotReady, userID)
+		}
+	}
+	sort.Strings(info.Ready)
+	sort.Strings(info.NotReady)
+	return info
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/neper-stars/astrum/api"
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// LOCAL HTTP API
+// =============================================================================
+//
+// An optional HTTP API bound to 127.0.0.1 so power users can script Astrum.
+// Every request must carry "Authorization: Bearer <token>". The server URL of
+// the targeted connection is passed in the "server" query parameter.
+//
+//	GET  /v1/sessions?server=URL                 list sessions
+//	POST /v1/sessions/{id}/turn?server=URL       download the latest turn to the game directory
+//	POST /v1/sessions/{id}/orders?server=URL     submit the .xN file sent as request body
+//	GET  /v1/sessions/{id}/map?server=URL        render the latest local turn as SVG (optional preset=name)
+//	GET  /v1/sessions/{id}/feed?server=URL       Atom feed of turn generations and order submissions
+
+// maxOrderUploadSize bounds the size of an uploaded order file
+const maxOrderUploadSize = 4 << 20
+
+// maxLocalAPIMapSize bounds the width and height of a rendered map, in pixels
+const maxLocalAPIMapSize = 8192
+
+// SetLocalAPI enables or disables the localhost HTTP API and sets its port.
+// The setting is only saved once the API listens on the port.
+func (a *App) SetLocalAPI(enabled bool, port int) (*AppSettingsInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	a.stopLocalAPI()
+	if enabled {
+		if err := a.startLocalAPI(port); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := a.config.SetLocalAPI(enabled, port); err != nil {
+		a.stopLocalAPI()
+		return nil, fmt.Errorf("failed to set local API: %w", err)
+	}
+
+	logger.App.Info().Bool("enabled", enabled).Int("port", port).Msg("Set local API")
+
+	return a.GetAppSettings()
+}
+
+// GetLocalAPIToken returns the token protecting the local HTTP API, creating one if needed
+func (a *App) GetLocalAPIToken() (string, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return "", err
+	}
+	return a.localAPIToken()
+}
+
+// RegenerateLocalAPIToken replaces the local HTTP API token, invalidating the previous one
+func (a *App) RegenerateLocalAPIToken() (string, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return "", err
+	}
+	return a.regenerateLocalAPIToken()
+}
+
+// localAPIToken returns the local HTTP API token, creating one if needed
+func (a *App) localAPIToken() (string, error) {
+	token, err := a.config.CredentialStore().GetLocalAPIToken()
+	if err != nil {
+		return "", err
+	}
+	if token != "" {
+		return token, nil
+	}
+	return a.regenerateLocalAPIToken()
+}
+
+// regenerateLocalAPIToken stores a new local HTTP API token
+func (a *App) regenerateLocalAPIToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	if err := a.config.CredentialStore().SetLocalAPIToken(token); err != nil {
+		return "", err
+	}
+
+	logger.App.Info().Msg("Regenerated local API token")
+	return token, nil
+}
+
+// startLocalAPI starts listening on 127.0.0.1:port
+func (a *App) startLocalAPI(port int) error {
+	a.localAPIMu.Lock()
+	defer a.localAPIMu.Unlock()
+
+	if a.localAPI != nil {
+		return nil
+	}
+
+	// Make sure a token exists before accepting requests
+	if _, err := a.localAPIToken(); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", port, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/sessions", a.handleLocalAPISessions)
+	mux.HandleFunc("POST /v1/sessions/{id}/turn", a.handleLocalAPITurn)
+	mux.HandleFunc("POST /v1/sessions/{id}/orders", a.handleLocalAPIOrders)
+	mux.HandleFunc("GET /v1/sessions/{id}/map", a.handleLocalAPIMap)
+	mux.HandleFunc("GET /v1/sessions/{id}/feed", a.handleLocalAPIFeed)
+
+	srv := &http.Server{
+		Handler:           a.localAPIAuth(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	a.localAPI = srv
+
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.App.Error().Err(err).Msg("Local API stopped")
+		}
+	}()
+
+	logger.App.Info().Int("port", port).Msg("Local API started")
+	return nil
+}
+
+// stopLocalAPI stops the local HTTP API if it is running
+func (a *App) stopLocalAPI() {
+	a.localAPIMu.Lock()
+	srv := a.localAPI
+	a.localAPI = nil
+	a.localAPIMu.Unlock()
+
+	if srv == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to stop local API")
+		return
+	}
+	logger.App.Info().Msg("Local API stopped")
+}
+
+// localAPIAuth rejects requests without the expected bearer token
+func (a *App) localAPIAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected, err := a.config.CredentialStore().GetLocalAPIToken()
+		if err != nil || expected == "" {
+			writeLocalAPIError(w, http.StatusServiceUnavailable, fmt.Errorf("local API token unavailable"))
+			return
+		}
+
+		given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			writeLocalAPIError(w, http.StatusUnauthorized, fmt.Errorf("missing bearer token"))
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(given), []byte(expected)) != 1 {
+			writeLocalAPIError(w, http.StatusUnauthorized, fmt.Errorf("invalid token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// localAPISessionID returns the session ID of the request path, or answers
+// 400 when it is not a plain path element: it names the game directory, so
+// an escaped ".." would reach outside of it
+func localAPISessionID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	sessionID := r.PathValue("id")
+	if !isPathElement(sessionID) {
+		writeLocalAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid session ID: %q", sessionID))
+		return "", false
+	}
+	return sessionID, true
+}
+
+// handleLocalAPISessions lists the sessions of a server
+func (a *App) handleLocalAPISessions(w http.ResponseWriter, r *http.Request) {
+	sessions, err := a.GetSessions(r.URL.Query().Get("server"))
+	if err != nil {
+		writeLocalAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeLocalAPIJSON(w, sessions)
+}
+
+// handleLocalAPITurn downloads the latest turn of a session to its game directory
+func (a *App) handleLocalAPITurn(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := localAPISessionID(w, r)
+	if !ok {
+		return
+	}
+	turn, err := a.GetLatestTurn(r.URL.Query().Get("server"), sessionID)
+	if err != nil {
+		writeLocalAPIError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeLocalAPIJSON(w, map[string]interface{}{
+		"sessionId": turn.SessionID,
+		"year":      turn.Year,
+	})
+}
+
+// handleLocalAPIOrders submits the order file sent as request body
+func (a *App) handleLocalAPIOrders(w http.ResponseWriter, r *http.Request) {
+	serverURL := r.URL.Query().Get("server")
+	sessionID, ok := localAPISessionID(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxOrderUploadSize))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeLocalAPIError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("order file is larger than %d bytes", tooLarge.Limit))
+		return
+	} else if err != nil {
+		writeLocalAPIError(w, http.StatusBadRequest, fmt.Errorf("failed to read order file: %w", err))
+		return
+	}
+
+	year, err := orderFileYear(data)
+	if err != nil {
+		writeLocalAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk {
+		writeLocalAPIError(w, http.StatusBadRequest, fmt.Errorf("not connected to server: %s", serverURL))
+		return
+	}
+
+	order := &api.Order{
+		B64Data: base64.StdEncoding.EncodeToString(data),
+	}
+	archived := a.archiveOrder(serverURL, sessionID, year, data)
+	err = client.SubmitTurn(mgr.GetContext(), sessionID, year, order)
+	archived.uploaded(err)
+	if err != nil {
+		writeLocalAPIError(w, http.StatusBadGateway, fmt.Errorf("failed to submit turn: %w", err))
+		return
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int("year", year).Msg("Submitted orders from local API")
+	go a.onOrderUploaded(serverURL, sessionID, year)
+
+	writeLocalAPIJSON(w, map[string]interface{}{
+		"sessionId": sessionID,
+		"year":      year,
+	})
+}
+
+// handleLocalAPIMap renders the turn files of the game directory as SVG
+func (a *App) handleLocalAPIMap(w http.ResponseWriter, r *http.Request) {
+	serverURL := r.URL.Query().Get("server")
+	sessionID, ok := localAPISessionID(w, r)
+	if !ok {
+		return
+	}
+
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL // fallback to URL if server not found
+	if server != nil {
+		serverName = server.Name
+	}
+
+	gameDir, err := a.config.GetSessionGameDir(serverName, sessionID)
+	if err != nil {
+		writeLocalAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	universe, turn, err := readLocalTurnFiles(gameDir)
+	if err != nil {
+		writeLocalAPIError(w, http.StatusNotFound, err)
+		return
+	}
+
+	options, err := a.resolveMapOptions(serverURL, sessionID, r.URL.Query().Get("preset"))
+	if err != nil {
+		writeLocalAPIError(w, http.StatusBadRequest, err)
+		return
+	}
+	for param, size := range map[string]*int{"width": &options.Width, "height": &options.Height} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 || n > maxLocalAPIMapSize {
+			writeLocalAPIError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: must be between 1 and %d", param, maxLocalAPIMapSize))
+			return
+		}
+		*size = n
+	}
+
+	svg, err := a.GenerateMap(MapGenerateRequest{
+		ServerURL:   serverURL,
+		SessionID:   sessionID,
+		Options:     options,
+		UniverseB64: base64.StdEncoding.EncodeToString(universe),
+		TurnB64:     base64.StdEncoding.EncodeToString(turn),
+	})
+	if err != nil {
+		writeLocalAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	_, _ = io.WriteString(w, svg)
+}
+
+// handleLocalAPIFeed serves the Atom feed of a session
+func (a *App) handleLocalAPIFeed(w http.ResponseWriter, r *http.Request) {
+	sessionID, ok := localAPISessionID(w, r)
+	if !ok {
+		return
+	}
+	data, err := a.sessionFeed(r.URL.Query().Get("server"), sessionID)
+	if err != nil {
+		writeLocalAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/atom+xml")
+	_, _ = w.Write(data)
+}
+
+// orderFileYear validates an order file and returns the year it was submitted for
+func orderFileYear(data []byte) (int, error) {
+	tmp, err := os.CreateTemp("", "astrum-order-*.x")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	tmp.Close()
+
+	validator, err := astrum.NewOrderValidator(tmp.Name())
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse order file: %w", err)
+	}
+	if !validator.TurnIsSubmitted() {
+		return 0, fmt.Errorf("turn not submitted")
+	}
+
+	return validator.Year(), nil
+}
+
+// readLocalTurnFiles reads the universe and turn files of a game directory
+func readLocalTurnFiles(gameDir string) (universe, turn []byte, err error) {
+	universe, err = os.ReadFile(filepath.Join(gameDir, "game.xy"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read universe file: %w", err)
+	}
+
+	turnFile, err := findTurnFile(gameDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	turn, err = os.ReadFile(turnFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read turn file: %w", err)
+	}
+
+	return universe, turn, nil
+}
+
+// writeLocalAPIJSON writes a JSON response
+func writeLocalAPIJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := jsoniter.NewEncoder(w).Encode(v); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to write local API response")
+	}
+}
+
+// writeLocalAPIError writes a JSON error response
+func writeLocalAPIError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = jsoniter.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+	"github.com/neper-stars/astrum/lib/safefile"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// LOCAL GAMES
+// =============================================================================
+
+// Local games are handled as the sessions of a server named
+// astrum.LocalGamesServer: bindings taking a server URL and a session ID (game
+// directory, maps, artifacts, archives, timeline...) work with it and the game
+// ID. Those needing the server (downloads, submissions) do not.
+
+// localGameFilePattern matches the Stars! files of a game: universe, host,
+// turn and order files
+var localGameFilePattern = regexp.MustCompile(`^game\.(xy|hst|m\d+|x\d+)$`)
+
+// ListLocalGames returns the games played without a server
+func (a *App) ListLocalGames() ([]LocalGameInfo, error) {
+	games, err := a.config.GetLocalGames()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]LocalGameInfo, 0, len(games))
+	for _, game := range games {
+		info, err := a.localGameInfo(game)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *info)
+	}
+	return result, nil
+}
+
+// CreateLocalGame adds a game played without a server and creates its game
+// directory, named after the game. The game itself is created with stars.exe
+// in that directory, or its files copied there.
+func (a *App) CreateLocalGame(name string) (*LocalGameInfo, error) {
+	game, _, err := a.createLocalGame(name)
+	if err != nil {
+		return nil, err
+	}
+	return a.localGameInfo(*game)
+}
+
+// createLocalGame adds a local game and returns it with its game directory
+func (a *App) createLocalGame(name string) (*model.LocalGame, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, "", fmt.Errorf("game name is required")
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", fmt.Errorf("failed to generate game ID: %w", err)
+	}
+	game := model.LocalGame{
+		ID:        hex.EncodeToString(id),
+		Name:      name,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := a.config.SetLocalGame(game); err != nil {
+		return nil, "", err
+	}
+
+	if _, err := a.config.AssignSessionDirAlias(astrum.LocalGamesServer, game.ID, game.Name); err != nil {
+		logger.App.Warn().Err(err).Str("gameId", game.ID).Msg("Failed to name local game directory")
+	}
+	gameDir, err := a.config.EnsureSessionGameDir(astrum.LocalGamesServer, game.ID)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	logger.App.Info().Str("gameId", game.ID).Str("name", game.Name).Str("gameDir", gameDir).Msg("Created local game")
+	return &game, gameDir, nil
+}
+
+// RenameLocalGame changes the name of a local game, its directory keeps its name
+func (a *App) RenameLocalGame(gameID, name string) (*LocalGameInfo, error) {
+	game, err := a.getLocalGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	game.Name = strings.TrimSpace(name)
+	if err := a.config.SetLocalGame(*game); err != nil {
+		return nil, err
+	}
+	return a.localGameInfo(*game)
+}
+
+// DeleteLocalGame removes a local game and its game directory, moved to the
+// trash unless hard delete is enabled
+func (a *App) DeleteLocalGame(gameID string) error {
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
+
+	game, err := a.getLocalGame(gameID)
+	if err != nil {
+		return err
+	}
+	gameDir, err := a.config.GetSessionGameDir(astrum.LocalGamesServer, game.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	if _, err := os.Stat(gameDir); err == nil {
+		if err := a.config.DeletePath(gameDir); err != nil {
+			return fmt.Errorf("failed to delete game directory: %w", err)
+		}
+	}
+	if err := a.config.DeleteLocalGame(game.ID); err != nil {
+		return err
+	}
+
+	logger.App.Info().Str("gameId", game.ID).Str("name", game.Name).Msg("Deleted local game")
+	return nil
+}
+
+// LaunchLocalGame opens a local game in Stars!: the turn of a player
+// (1-indexed), or the host file when playerNumber is 0. A past year is opened
+// from the turn history, 0 for the current year.
+func (a *App) LaunchLocalGame(gameID string, playerNumber, year int) error {
+	game, err := a.getLocalGame(gameID)
+	if err != nil {
+		return err
+	}
+	gameDir, err := a.config.GetSessionGameDir(astrum.LocalGamesServer, game.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get game directory: %w", err)
+	}
+	starsExePath := monitor.ResolveFile(gameDir, "stars.exe")
+	if _, err := os.Stat(starsExePath); os.IsNotExist(err) {
+		return fmt.Errorf("stars.exe not found in game directory")
+	}
+
+	runDir := gameDir
+	if year != 0 {
+		runDir = filepath.Join(gameDir, historicYearsDirName, strconv.Itoa(year))
+	}
+	fileName := "game.hst"
+	if playerNumber > 0 {
+		fileName = fmt.Sprintf("game.m%d", playerNumber)
+	}
+	filePath := monitor.ResolveFile(runDir, fileName)
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return fmt.Errorf("%s not found in %s", fileName, runDir)
+	}
+
+	return a.startStars(astrum.LocalGamesServer, astrum.LocalGamesServer, game.ID, runDir, starsExePath, filepath.Base(filePath))
+}
+
+// GetLocalGameTurn returns the universe and the turn of a player (1-indexed)
+// of a local game, for the map viewer. A past year is read from the turn
+// history, 0 for the current year.
+func (a *App) GetLocalGameTurn(gameID string, playerNumber, year int) (*TurnFilesInfo, error) {
+	game, err := a.getLocalGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	gameDir, err := a.config.GetSessionGameDir(astrum.LocalGamesServer, game.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	dir := gameDir
+	if year != 0 {
+		dir = filepath.Join(gameDir, historicYearsDirName, strconv.Itoa(year))
+	}
+	universe, err := os.ReadFile(monitor.ResolveFile(dir, "game.xy"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read universe file: %w", err)
+	}
+	turnPath := monitor.ResolveFile(dir, fmt.Sprintf("game.m%d", playerNumber))
+	turn, err := os.ReadFile(turnPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read turn file: %w", err)
+	}
+	if year == 0 {
+		if year, err = starsFileYear(turnPath); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TurnFilesInfo{
+		SessionID: game.ID,
+		Year:      year,
+		Universe:  base64.StdEncoding.EncodeToString(universe),
+		Turn:      base64.StdEncoding.EncodeToString(turn),
+	}, nil
+}
+
+// GenerateLocalGameTurn generates the next year of a local game with stars.exe
+// from the order files in its game directory. The current year is backed up
+// first, and the new one is added to the turn history.
+func (a *App) GenerateLocalGameTurn(gameID string) (*LocalGameInfo, error) {
+	game, err := a.getLocalGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	gameDir, err := a.config.GetSessionGameDir(astrum.LocalGamesServer, game.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game directory: %w", err)
+	}
+	starsExePath := monitor.ResolveFile(gameDir, "stars.exe")
+	if _, err := os.Stat(starsExePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("stars.exe not found in game directory")
+	}
+
+	year, err := starsFileYear(monitor.ResolveFile(gameDir, "game.hst"))
+	if err != nil {
+		return nil, err
+	}
+	// The first year of the game was never added to the history
+	if _, err := os.Stat(filepath.Join(gameDir, historicYearsDirName, strconv.Itoa(year))); os.IsNotExist(err) {
+		if err := archiveLocalYear(gameDir, year); err != nil {
+			logger.App.Warn().Err(err).Str("gameId", game.ID).Int("year", year).Msg("Failed to add year to turn history")
+		}
+	}
+	if _, err := a.backupLocalGame(game.ID, gameDir, year); err != nil {
+		return nil, err
+	}
+
+	players := len(localGameTurnFiles(gameDir))
+	turn, err := a.generateLocalTurn(astrum.LocalGamesServer, astrum.LocalGamesServer, gameDir, starsExePath, players)
+	if err != nil {
+		return nil, err
+	}
+	if err := archiveLocalYear(gameDir, turn.Year); err != nil {
+		logger.App.Warn().Err(err).Str("gameId", game.ID).Int("year", turn.Year).Msg("Failed to add year to turn history")
+	}
+
+	a.recordTimelineEvent(astrum.LocalGamesServer, game.ID, model.TimelineEvent{
+		Type: model.TimelineTurnGenerated,
+		Time: time.Now(),
+		Year: turn.Year,
+	})
+	if players == 1 {
+		a.autoGenerateMap(astrum.LocalGamesServer, game.ID, gameDir, monitor.ResolveFile(gameDir, "game.m1"))
+	}
+
+	logger.App.Info().Str("gameId", game.ID).Int("year", turn.Year).Int("players", players).Msg("Generated local game turn")
+	return a.localGameInfo(*game)
+}
+
+// BackupLocalGame saves the Stars! files of the current year of a local game
+// to <year>-backup.zip in its game directory. Returns the backup path.
+func (a *App) BackupLocalGame(gameID string) (string, error) {
+	game, err := a.getLocalGame(gameID)
+	if err != nil {
+		return "", err
+	}
+	gameDir, err := a.config.GetSessionGameDir(astrum.LocalGamesServer, game.ID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get game directory: %w", err)
+	}
+	year, err := starsFileYear(monitor.ResolveFile(gameDir, "game.hst"))
+	if err != nil {
+		return "", err
+	}
+	return a.backupLocalGame(game.ID, gameDir, year)
+}
+
+// backupLocalGame zips the Stars! files of a local game directory, laid out
+// like the backups downloaded from servers
+func (a *App) backupLocalGame(gameID, gameDir string, year int) (string, error) {
+	entries, err := os.ReadDir(gameDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read game directory: %w", err)
+	}
+
+	zipPath := filepath.Join(gameDir, fmt.Sprintf("%d-backup.zip", year))
+	zipFile, err := safefile.Create(zipPath, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create zip file: %w", err)
+	}
+	defer func() { _ = zipFile.Close() }()
+
+	zipWriter := zip.NewWriter(zipFile)
+	defer func() { _ = zipWriter.Close() }()
+
+	subFolder := fmt.Sprintf("backup/%d/", year)
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		if entry.IsDir() || !localGameFilePattern.MatchString(name) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(gameDir, entry.Name()))
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		w, err := zipWriter.Create(subFolder + name)
+		if err != nil {
+			return "", fmt.Errorf("failed to create %s entry in zip: %w", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return "", fmt.Errorf("failed to write %s to zip: %w", name, err)
+		}
+	}
+
+	// Only a complete backup replaces the file
+	if err := zipWriter.Close(); err != nil {
+		return "", fmt.Errorf("failed to finish zip file: %w", err)
+	}
+	if err := zipFile.Commit(); err != nil {
+		return "", fmt.Errorf("failed to save zip file: %w", err)
+	}
+
+	logger.App.Info().Str("gameId", gameID).Int("year", year).Str("zipPath", zipPath).Msg("Backed up local game")
+
+	a.recordTimelineEvent(astrum.LocalGamesServer, gameID, model.TimelineEvent{
+		Type:   model.TimelineBackup,
+		Time:   time.Now(),
+		Year:   year,
+		Detail: zipPath,
+	})
+	return zipPath, nil
+}
+
+// archiveLocalYear copies the universe and turn files of a local game
+// directory to its turn history, laid out like the historic years opened
+// from servers
+func archiveLocalYear(gameDir string, year int) error {
+	yearDir := filepath.Join(gameDir, historicYearsDirName, strconv.Itoa(year))
+	if err := safefile.MkdirAll(yearDir, 0755); err != nil {
+		return fmt.Errorf("failed to create year directory: %w", err)
+	}
+
+	for _, name := range append([]string{"game.xy"}, localGameTurnFiles(gameDir)...) {
+		data, err := os.ReadFile(monitor.ResolveFile(gameDir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if err := safefile.WriteFile(filepath.Join(yearDir, name), data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// localGameTurnFiles returns the names of the turn files of a directory,
+// lower-cased
+func localGameTurnFiles(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		name := strings.ToLower(entry.Name())
+		if !entry.IsDir() && turnFilePattern.MatchString(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// getLocalGame returns a local game, or an error if it does not exist
+func (a *App) getLocalGame(gameID string) (*model.LocalGame, error) {
+	game, err := a.config.GetLocalGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game == nil {
+		return nil, fmt.Errorf("local game %s not found", gameID)
+	}
+	return game, nil
+}
+
+// localGameInfo describes a local game from its game directory
+func (a *App) localGameInfo(game model.LocalGame) (*LocalGameInfo, error) {
+	gameDir, err := a.config.GetSessionGameDir(astrum.LocalGamesServer, game.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	info := &LocalGameInfo{
+		ServerURL: astrum.LocalGamesServer,
+		ID:        game.ID,
+		Name:      game.Name,
+		GameDir:   gameDir,
+		CreatedAt: game.CreatedAt,
+		Players:   len(localGameTurnFiles(gameDir)),
+	}
+	if year, err := starsFileYear(monitor.ResolveFile(gameDir, "game.hst")); err == nil {
+		info.Year = year
+	}
+	if _, err := os.Stat(monitor.ResolveFile(gameDir, "stars.exe")); err == nil {
+		info.HasStarsExe = true
+	}
+
+	info.HistoryYears = historicYears(gameDir)
+	return info, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// =============================================================================
+// LOCAL TURN GENERATION
+// =============================================================================
+
+// localHostDirName is the game directory subfolder the host files are staged
+// in, away from the player's own turn and orders
+const localHostDirName = "host"
+
+// localHostTimeout bounds a turn generation, stars.exe stuck on a dialog
+// would otherwise never exit
+const localHostTimeout = 5 * time.Minute
+
+// GenerateTurnLocally generates the next year of a session with the local
+// stars.exe, for when the server cannot: the host file, universe and orders
+// are fetched from the server, stars.exe runs in host mode on them and the
+// generated year is pushed back. Managers only. When the server cannot take
+// generated turns, the files stay in the host folder of the game directory and
+// Uploaded is false. Emits "turn:hosted" (serverURL, sessionID, year) once
+// uploaded.
+func (a *App) GenerateTurnLocally(serverURL, sessionID string) (*LocalTurnGenerationInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	ctx := mgr.GetContext()
+
+	files, err := client.GetSessionFiles(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get host files (only managers can host): %w", err)
+	}
+	if files.HostFile == "" || files.Universe == "" {
+		return nil, fmt.Errorf("server has no host file for this session")
+	}
+
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL
+	if server != nil {
+		serverName = server.Name
+	}
+	gameDir, err := a.config.EnsureSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game directory: %w", err)
+	}
+	starsExePath := monitor.ResolveFile(gameDir, "stars.exe")
+	if _, err := os.Stat(starsExePath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("stars.exe not found in game directory")
+	}
+
+	// Start from a clean directory, orders of a previous year would be
+	// processed again
+	hostDir := filepath.Join(gameDir, localHostDirName)
+	if err := os.RemoveAll(hostDir); err != nil {
+		return nil, fmt.Errorf("failed to clear host directory: %w", err)
+	}
+	if err := safefile.MkdirAll(hostDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create host directory: %w", err)
+	}
+
+	staged := map[string]string{"game.hst": files.HostFile, "game.xy": files.Universe}
+	for i, order := range files.Orders {
+		if order.B64Data != "" {
+			staged[fmt.Sprintf("game.x%d", i+1)] = order.B64Data
+		}
+	}
+	for name, b64 := range staged {
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		if err := safefile.WriteFile(filepath.Join(hostDir, name), data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	turn, err := a.generateLocalTurn(serverURL, serverName, hostDir, starsExePath, len(files.Turns))
+	if err != nil {
+		return nil, err
+	}
+	info := &LocalTurnGenerationInfo{
+		Year:    turn.Year,
+		Players: len(turn.Turns),
+		Orders:  len(staged) - 2,
+		HostDir: hostDir,
+	}
+
+	if err := client.UploadGeneratedTurn(ctx, sessionID, turn); err != nil {
+		if errors.Is(err, api.ErrNotSupported) {
+			logger.App.Warn().Str("serverUrl", serverURL).Str("dir", hostDir).Msg("Server does not take generated turns, files kept in the host folder")
+			return info, nil
+		}
+		return nil, fmt.Errorf("failed to upload generated turn: %w", err)
+	}
+	info.Uploaded = true
+
+	logger.App.Info().Str("sessionId", sessionID).Int("year", turn.Year).Int("orders", info.Orders).Msg("Uploaded locally generated turn")
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("turn:hosted", serverURL, sessionID, turn.Year)
+	}
+	return info, nil
+}
+
+// generateLocalTurn runs stars.exe in host mode on the game.hst of hostDir,
+// with the universe and order files staged next to it, and collects the
+// generated year with the turn files of the given number of players
+func (a *App) generateLocalTurn(serverURL, serverName, hostDir, starsExePath string, players int) (*api.GeneratedTurn, error) {
+	if players == 0 {
+		return nil, fmt.Errorf("no players to generate turns for")
+	}
+	hstPath := monitor.ResolveFile(hostDir, "game.hst")
+	before, err := starsFileYear(hstPath)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, _, err := a.starsCommand(serverURL, serverName, hostDir, starsExePath, "-g", "game.hst")
+	if err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Str("dir", hostDir).Int("year", before).Int("players", players).Msg("Generating turn with local stars.exe")
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to launch Stars!: %w", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, fmt.Errorf("stars.exe failed to generate the turn: %w", err)
+		}
+	case <-time.After(localHostTimeout):
+		_ = cmd.Process.Kill()
+		<-done
+		return nil, fmt.Errorf("stars.exe did not generate the turn within %s", localHostTimeout)
+	}
+
+	year, err := starsFileYear(hstPath)
+	if err != nil {
+		return nil, err
+	}
+	if year <= before {
+		return nil, fmt.Errorf("stars.exe did not generate a new year (still %d)", year)
+	}
+
+	turn := &api.GeneratedTurn{Year: year}
+	for name, dst := range map[string]*string{"game.hst": &turn.HostFile, "game.xy": &turn.Universe} {
+		data, err := os.ReadFile(monitor.ResolveFile(hostDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		*dst = base64.StdEncoding.EncodeToString(data)
+	}
+	for i := 1; i <= players; i++ {
+		name := fmt.Sprintf("game.m%d", i)
+		data, err := os.ReadFile(monitor.ResolveFile(hostDir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		turn.Turns = append(turn.Turns, base64.StdEncoding.EncodeToString(data))
+	}
+	return turn, nil
+}
+
+// starsFileYear returns the year of a Stars! host, universe or turn file
+func starsFileYear(path string) (int, error) {
+	header, err := starsFileHeader(path)
+	if err != nil {
+		return 0, err
+	}
+	return header.Year(), nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/neper-stars/astrum/lib/filehash"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// =============================================================================
+// GAME DIRECTORY MANIFEST
+// =============================================================================
+
+// gameManifestName is the file of a game directory listing the files Astrum
+// wrote there, see VerifyGameDir
+const gameManifestName = "manifest.json"
+
+// Origins of the files of a game directory
+const (
+	fileOriginServer    = "server"    // downloaded: universe, turn, race, stars.exe, backups
+	fileOriginLocal     = "local"     // written by Stars!: the submitted orders
+	fileOriginGenerated = "generated" // rendered by Astrum: maps
+)
+
+// Verification states of a file, see VerifyGameDir
+const (
+	fileStatusMissing  = "missing"
+	fileStatusModified = "modified"
+	fileStatusExtra    = "extra"
+)
+
+// gameManifest is the content of manifest.json
+type gameManifest struct {
+	Files map[string]gameManifestEntry `json:"files"` // slash-separated path relative to the game directory -> entry
+}
+
+// gameManifestEntry is a file as Astrum last wrote or uploaded it
+type gameManifestEntry struct {
+	Origin    string    `json:"origin"`
+	SHA256    string    `json:"sha256"`
+	Size      int64     `json:"size"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// recordGameFile records a file of a game directory in its manifest with the
+// hash of its current content. Failures are logged only: the manifest is a
+// troubleshooting aid, never a reason to fail a download.
+func (a *App) recordGameFile(gameDir, path, origin string) {
+	rel, err := filepath.Rel(gameDir, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	hash, err := filehash.ComputeFileHash(path)
+	if err != nil {
+		logger.App.Debug().Err(err).Str("path", path).Msg("Failed to hash file for the manifest")
+		return
+	}
+
+	a.manifestMu.Lock()
+	defer a.manifestMu.Unlock()
+
+	manifest := readGameManifest(gameDir)
+	manifest.Files[filepath.ToSlash(rel)] = gameManifestEntry{
+		Origin:    origin,
+		SHA256:    hash,
+		Size:      info.Size(),
+		UpdatedAt: time.Now().UTC(),
+	}
+	data, err := jsoniter.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = safefile.WriteFile(filepath.Join(gameDir, gameManifestName), data, 0644)
+	}
+	if err != nil {
+		logger.App.Warn().Err(err).Str("gameDir", gameDir).Msg("Failed to update game directory manifest")
+	}
+}
+
+// recordUploadedOrder records the order file of the game directory of a
+// session once uploaded, if it still holds the uploaded orders (orders may
+// come from a watched directory)
+func (a *App) recordUploadedOrder(serverURL, sessionID, orderHash string) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return
+	}
+	player, err := localPlayerNumber(gameDir)
+	if err != nil {
+		return
+	}
+	path := monitor.ResolveFile(gameDir, fmt.Sprintf("game.x%d", player+1))
+	if hash, err := filehash.ComputeFileHash(path); err == nil && hash == orderHash {
+		a.recordGameFile(gameDir, path, fileOriginLocal)
+	}
+}
+
+// isTurnFileName returns whether a manifest path is a universe or turn file
+func isTurnFileName(rel string) bool {
+	name := strings.ToLower(rel)
+	return name == "game.xy" || turnFilePattern.MatchString(name)
+}
+
+// readGameManifest returns the manifest of a game directory, empty when there
+// is none or it can't be read
+func readGameManifest(gameDir string) *gameManifest {
+	manifest := &gameManifest{}
+	if data, err := os.ReadFile(filepath.Join(gameDir, gameManifestName)); err == nil {
+		if err := jsoniter.Unmarshal(data, manifest); err != nil {
+			logger.App.Warn().Err(err).Str("gameDir", gameDir).Msg("Ignoring unreadable game directory manifest")
+		}
+	}
+	if manifest.Files == nil {
+		manifest.Files = make(map[string]gameManifestEntry)
+	}
+	return manifest
+}
+
+// VerifyGameDir compares the game directory of a session with its manifest,
+// so support can tell local corruption (missing or modified files) from
+// server issues. Extra files are those Astrum did not write, outside the
+// folders of generated files. Modified local orders usually mean Stars!
+// saved new orders after the last upload.
+func (a *App) VerifyGameDir(serverURL, sessionID string) (*GameDirVerificationInfo, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(gameDir); err != nil {
+		return nil, fmt.Errorf("failed to read game directory: %w", err)
+	}
+
+	a.manifestMu.Lock()
+	manifest := readGameManifest(gameDir)
+	a.manifestMu.Unlock()
+
+	info := &GameDirVerificationInfo{
+		GameDir: gameDir,
+		Tracked: len(manifest.Files),
+		Files:   []GameFileStatusInfo{},
+	}
+
+	for rel, entry := range manifest.Files {
+		path := filepath.Join(gameDir, filepath.FromSlash(rel))
+		status := GameFileStatusInfo{Path: rel, Origin: entry.Origin, RecordedAt: entry.UpdatedAt}
+		hash, err := filehash.ComputeFileHash(path)
+		switch {
+		case os.IsNotExist(err):
+			status.Status = fileStatusMissing
+		case err != nil:
+			return nil, fmt.Errorf("failed to hash %s: %w", rel, err)
+		case hash != entry.SHA256:
+			status.Status = fileStatusModified
+		default:
+			continue
+		}
+		info.Files = append(info.Files, status)
+	}
+
+	err = filepath.WalkDir(gameDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(gameDir, path)
+		if rel == "." {
+			return nil
+		}
+		if d.IsDir() {
+			if strings.HasPrefix(d.Name(), ".") || rel == historicYearsDirName || rel == localHostDirName || artifactDirs[rel] != "" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if strings.HasPrefix(d.Name(), ".") || rel == gameManifestName {
+			return nil
+		}
+		if _, ok := manifest.Files[rel]; !ok {
+			info.Files = append(info.Files, GameFileStatusInfo{Path: rel, Status: fileStatusExtra})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read game directory: %w", err)
+	}
+
+	// Missing turn files are downloaded again right away
+	for _, f := range info.Files {
+		if f.Status == fileStatusMissing && f.Origin == fileOriginServer && isTurnFileName(f.Path) {
+			recovered, err := a.recoverTurnFiles(serverURL, sessionID)
+			if err != nil {
+				logger.App.Error().Err(err).Str("sessionId", sessionID).Msg("Failed to recover turn files")
+			}
+			info.Recovered = recovered
+			break
+		}
+	}
+
+	sort.Slice(info.Files, func(i, j int) bool { return info.Files[i].Path < info.Files[j].Path })
+	// Extra files alone are no corruption
+	info.Intact = true
+	for _, f := range info.Files {
+		if f.Status != fileStatusExtra {
+			info.Intact = false
+		}
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Int("tracked", info.Tracked).
+		Int("findings", len(info.Files)).
+		Bool("intact", info.Intact).
+		Msg("Verified game directory")
+	return info, nil
+}
@@ -9,10 +9,15 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/maptheme"
+	"github.com/neper-stars/astrum/lib/telemetry"
+	"github.com/neper-stars/astrum/model"
 	"github.com/neper-stars/houston/lib/tools/maprenderer"
+	"github.com/neper-stars/houston/store"
 )
 
 // =============================================================================
@@ -40,40 +45,226 @@ func (a *App) GenerateMap(request MapGenerateRequest) (string, error) {
 	}
 
 	// Create renderer and load files
+	renderer, err := newMapRenderer(xyBytes, turnBytes)
+	if err != nil {
+		a.telemetry.Error(telemetry.ErrorMap)
+		return "", err
+	}
+
+	// Resolve the options from the requested preset or the session default
+	if request.Preset != "" || request.Options == (MapOptions{}) {
+		options, err := a.resolveMapOptions(request.ServerURL, request.SessionID, request.Preset)
+		if err != nil {
+			return "", err
+		}
+		request.Options = options
+	}
+
+	// Generate SVG
+	svg, err := a.applyMapTheme(renderer.RenderSVG(renderOptions(request.Options)), request.ServerURL, request.SessionID, request.Options)
+	if err != nil {
+		return "", err
+	}
+
+	a.telemetry.Count(telemetry.FeatureMap)
+	logger.App.Debug().
+		Int("svgLength", len(svg)).
+		Msg("Map generated successfully")
+
+	return svg, nil
+}
+
+// newMapRenderer creates a renderer loaded with a universe and a turn file
+func newMapRenderer(xyBytes, turnBytes []byte) (*maprenderer.Renderer, error) {
+	release := acquireParse()
+	defer release()
+
 	renderer := maprenderer.New()
 
 	// Load the xy file first
 	if err := renderer.LoadBytes("game.xy", xyBytes); err != nil {
-		return "", fmt.Errorf("failed to load universe file: %w", err)
+		return nil, fmt.Errorf("failed to load universe file: %w", err)
 	}
 
 	// Load the turn file
 	if err := renderer.LoadBytes("game.m1", turnBytes); err != nil {
-		return "", fmt.Errorf("failed to load turn file: %w", err)
-	}
-
-	// Convert MapOptions to RenderOptions
-	opts := &maprenderer.RenderOptions{
-		Width:               request.Options.Width,
-		Height:              request.Options.Height,
-		ShowNames:           request.Options.ShowNames,
-		ShowFleets:          request.Options.ShowFleets,
-		ShowFleetPaths:      request.Options.ShowFleetPaths,
-		ShowMines:           request.Options.ShowMines,
-		ShowWormholes:       request.Options.ShowWormholes,
-		ShowLegend:          request.Options.ShowLegend,
-		ShowScannerCoverage: request.Options.ShowScannerCoverage,
+		return nil, fmt.Errorf("failed to load turn file: %w", err)
+	}
+
+	return renderer, nil
+}
+
+// renderOptions converts MapOptions to RenderOptions
+func renderOptions(o MapOptions) *maprenderer.RenderOptions {
+	return &maprenderer.RenderOptions{
+		Width:               o.Width,
+		Height:              o.Height,
+		ShowNames:           o.ShowNames,
+		ShowFleets:          o.ShowFleets,
+		ShowFleetPaths:      o.ShowFleetPaths,
+		ShowMines:           o.ShowMines,
+		ShowWormholes:       o.ShowWormholes,
+		ShowLegend:          o.ShowLegend,
+		ShowScannerCoverage: o.ShowScannerCoverage,
 		Padding:             20,
 	}
+}
 
-	// Generate SVG
-	svg := renderer.RenderSVG(opts)
+// GetMapThemes returns the built-in map themes
+func (a *App) GetMapThemes() []MapThemeInfo {
+	names := make([]string, 0, len(maptheme.Builtin))
+	for name := range maptheme.Builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]MapThemeInfo, len(names))
+	for i, name := range names {
+		t := maptheme.Builtin[name]
+		result[i] = MapThemeInfo{
+			Name:       name,
+			Background: t.Background,
+			Foreground: t.Foreground,
+			Year:       t.Year,
+			Empire:     t.Empire,
+		}
+	}
+	return result
+}
 
-	logger.App.Debug().
-		Int("svgLength", len(svg)).
-		Msg("Map generated successfully")
+// applyMapTheme recolors a rendered SVG with the theme of the options and the
+// session's empire colors
+// Only SVG output is themed, PNG and GIF keep the renderer's colors
+func (a *App) applyMapTheme(svg, serverURL, sessionID string, o MapOptions) (string, error) {
+	theme, err := a.mapTheme(serverURL, sessionID, o)
+	if err != nil {
+		return "", err
+	}
+	return maptheme.Apply(svg, theme)
+}
 
-	return svg, nil
+// mapTheme resolves the theme of the options, with the empire colors of the
+// session registry on top of the theme palette
+func (a *App) mapTheme(serverURL, sessionID string, o MapOptions) (maptheme.Theme, error) {
+	var theme maptheme.Theme
+	switch {
+	case o.CustomTheme != nil:
+		theme = maptheme.Theme{
+			Background: o.CustomTheme.Background,
+			Foreground: o.CustomTheme.Foreground,
+			Year:       o.CustomTheme.Year,
+			Empire:     o.CustomTheme.Empire,
+		}
+	case o.Theme == "" || o.Theme == "classic":
+		// The renderer's own colors, nothing to rewrite
+	default:
+		t, ok := maptheme.Builtin[o.Theme]
+		if !ok {
+			return theme, fmt.Errorf("unknown map theme: %s", o.Theme)
+		}
+		theme = t
+	}
+
+	settings, err := a.config.GetSessionMapSettings(serverURL, sessionID)
+	if err != nil {
+		return theme, err
+	}
+	if len(settings.EmpireColors) > 0 {
+		empire := make([]string, maptheme.Players)
+		copy(empire, theme.Empire)
+		for player, color := range settings.EmpireColors {
+			if player >= 0 && player < len(empire) {
+				empire[player] = color
+			}
+		}
+		theme.Empire = empire
+	}
+	return theme, nil
+}
+
+// =============================================================================
+// EMPIRE COLORS
+// =============================================================================
+
+// GetEmpireColors returns the color of every player of a session, as drawn on
+// maps with the given theme ("" uses the session's default preset theme).
+// Score charts and reports use these so colors match the maps.
+func (a *App) GetEmpireColors(serverURL, sessionID, theme string) ([]EmpireColorInfo, error) {
+	options, err := a.resolveMapOptions(serverURL, sessionID, "")
+	if err != nil {
+		return nil, err
+	}
+	if theme != "" {
+		options.Theme = theme
+		options.CustomTheme = nil
+	}
+
+	resolved, err := a.mapTheme(serverURL, sessionID, options)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := a.config.GetSessionMapSettings(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Name the players of the latest local turn, or list every palette slot
+	var players []*store.PlayerEntity
+	if gameDir, err := a.sessionGameDir(serverURL, sessionID); err == nil {
+		if gs, err := loadLocalGameStore(gameDir); err == nil {
+			players = sortedPlayers(gs)
+		}
+	}
+
+	var result []EmpireColorInfo
+	if len(players) > 0 {
+		for _, p := range players {
+			_, custom := settings.EmpireColors[p.PlayerNumber]
+			result = append(result, EmpireColorInfo{
+				PlayerNumber: p.PlayerNumber,
+				Name:         p.NamePlural,
+				Color:        resolved.EmpireColor(p.PlayerNumber),
+				Custom:       custom,
+			})
+		}
+		return result, nil
+	}
+
+	for i := 0; i < maptheme.Players; i++ {
+		_, custom := settings.EmpireColors[i]
+		result = append(result, EmpireColorInfo{
+			PlayerNumber: i,
+			Color:        resolved.EmpireColor(i),
+			Custom:       custom,
+		})
+	}
+	return result, nil
+}
+
+// SetEmpireColor pins the color of a player in a session ("" resets it to the theme color)
+func (a *App) SetEmpireColor(serverURL, sessionID string, playerNumber int, color string) error {
+	if playerNumber < 0 || playerNumber >= maptheme.Players {
+		return fmt.Errorf("invalid player number: %d", playerNumber)
+	}
+	if color != "" {
+		if err := maptheme.Validate(color); err != nil {
+			return err
+		}
+	}
+
+	settings, err := a.config.GetSessionMapSettings(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
+	if color == "" {
+		delete(settings.EmpireColors, playerNumber)
+	} else {
+		if settings.EmpireColors == nil {
+			settings.EmpireColors = make(map[int]string)
+		}
+		settings.EmpireColors[playerNumber] = color
+	}
+	return a.config.SetSessionMapSettings(serverURL, sessionID, settings)
 }
 
 // SaveMap saves an SVG map to the session's game directory
@@ -109,7 +300,8 @@ func (a *App) SaveMap(request MapSaveRequest) error {
 	filePath := filepath.Join(gameDir, filename)
 
 	// Write SVG to file
-	if err := os.WriteFile(filePath, []byte(request.SVGContent), 0644); err != nil {
+	filePath, err = a.writeArtifact(filePath, []byte(request.SVGContent), request.Overwrite)
+	if err != nil {
 		return fmt.Errorf("failed to save map: %w", err)
 	}
 
@@ -137,12 +329,251 @@ func sanitizeFilename(name string) string {
 	return name
 }
 
+// =============================================================================
+// MAP PRESETS
+// =============================================================================
+
+// defaultMapOptions are used when neither a preset nor a session default is set
+var defaultMapOptions = MapOptions{
+	Width:      1024,
+	Height:     1024,
+	ShowNames:  true,
+	ShowFleets: true,
+	ShowLegend: true,
+}
+
+// GetMapPresets returns the saved map presets
+func (a *App) GetMapPresets() ([]MapPresetInfo, error) {
+	presets, err := a.config.GetMapPresets()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]MapPresetInfo, len(presets))
+	for i, p := range presets {
+		result[i] = mapPresetToInfo(p)
+	}
+	return result, nil
+}
+
+// SaveMapPreset adds or replaces a map preset
+func (a *App) SaveMapPreset(preset MapPresetInfo) ([]MapPresetInfo, error) {
+	o := preset.Options
+	if err := a.config.SetMapPreset(model.MapPreset{
+		Name:                preset.Name,
+		Width:               o.Width,
+		Height:              o.Height,
+		ShowNames:           o.ShowNames,
+		ShowFleets:          o.ShowFleets,
+		ShowFleetPaths:      o.ShowFleetPaths,
+		ShowMines:           o.ShowMines,
+		ShowWormholes:       o.ShowWormholes,
+		ShowLegend:          o.ShowLegend,
+		ShowScannerCoverage: o.ShowScannerCoverage,
+		Theme:               o.Theme,
+	}); err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Str("preset", preset.Name).Msg("Saved map preset")
+	return a.GetMapPresets()
+}
+
+// DeleteMapPreset removes a map preset
+func (a *App) DeleteMapPreset(name string) ([]MapPresetInfo, error) {
+	if err := a.config.DeleteMapPreset(name); err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Str("preset", name).Msg("Deleted map preset")
+	return a.GetMapPresets()
+}
+
+// GetSessionMapPreset returns the default map preset of a session ("" when none)
+func (a *App) GetSessionMapPreset(serverURL, sessionID string) (string, error) {
+	settings, err := a.config.GetSessionMapSettings(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+	return settings.DefaultPreset, nil
+}
+
+// SetSessionMapPreset sets the default map preset of a session ("" resets to the built-in options)
+func (a *App) SetSessionMapPreset(serverURL, sessionID, presetName string) error {
+	if presetName != "" {
+		preset, err := a.config.GetMapPreset(presetName)
+		if err != nil {
+			return err
+		}
+		if preset == nil {
+			return fmt.Errorf("map preset not found: %s", presetName)
+		}
+	}
+
+	settings, err := a.config.GetSessionMapSettings(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
+	settings.DefaultPreset = presetName
+	return a.config.SetSessionMapSettings(serverURL, sessionID, settings)
+}
+
+// GetSessionMapSettings returns the map preferences of a session
+func (a *App) GetSessionMapSettings(serverURL, sessionID string) (*SessionMapSettingsInfo, error) {
+	settings, err := a.config.GetSessionMapSettings(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &SessionMapSettingsInfo{
+		DefaultPreset: settings.DefaultPreset,
+		AutoGenerate:  settings.AutoGenerate,
+		AutoPNG:       settings.AutoPNG,
+	}, nil
+}
+
+// SetSessionAutoMap enables or disables the automatic map of every new turn,
+// optionally saving a PNG next to the SVG
+func (a *App) SetSessionAutoMap(serverURL, sessionID string, enabled, withPNG bool) (*SessionMapSettingsInfo, error) {
+	settings, err := a.config.GetSessionMapSettings(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	settings.AutoGenerate = enabled
+	settings.AutoPNG = withPNG
+	if err := a.config.SetSessionMapSettings(serverURL, sessionID, settings); err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Bool("enabled", enabled).Bool("png", withPNG).Msg("Set automatic map")
+
+	return a.GetSessionMapSettings(serverURL, sessionID)
+}
+
+// autoGenerateMap renders the session's default map of a freshly downloaded turn into
+// maps/<year>.svg (and .png if requested) and emits "map:generated"
+func (a *App) autoGenerateMap(serverURL, sessionID, gameDir, turnPath string) {
+	settings, err := a.config.GetSessionMapSettings(serverURL, sessionID)
+	if err != nil || !settings.AutoGenerate {
+		return
+	}
+
+	options, err := a.resolveMapOptions(serverURL, sessionID, "")
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to resolve automatic map options")
+		return
+	}
+
+	universe, err := os.ReadFile(filepath.Join(gameDir, "game.xy"))
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to read universe file for automatic map")
+		return
+	}
+	turn, err := os.ReadFile(turnPath)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to read turn file for automatic map")
+		return
+	}
+
+	renderer, err := newMapRenderer(universe, turn)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to load turn for automatic map")
+		return
+	}
+	year := renderer.Year()
+	opts := renderOptions(options)
+
+	svg, err := a.applyMapTheme(renderer.RenderSVG(opts), serverURL, sessionID, options)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to apply automatic map theme")
+		return
+	}
+
+	svgPath, err := a.writeArtifact(filepath.Join(gameDir, "maps", fmt.Sprintf("%d.svg", year)), []byte(svg), false)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to save automatic map")
+		return
+	}
+
+	pngPath := ""
+	if settings.AutoPNG {
+		var buf bytes.Buffer
+		if err := renderer.WritePNG(&buf, opts); err != nil {
+			logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to render automatic map PNG")
+		} else if pngPath, err = a.writeArtifact(filepath.Join(gameDir, "maps", fmt.Sprintf("%d.png", year)), buf.Bytes(), false); err != nil {
+			logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to save automatic map PNG")
+		}
+	}
+
+	a.recordGameFile(gameDir, svgPath, fileOriginGenerated)
+	if pngPath != "" {
+		a.recordGameFile(gameDir, pngPath, fileOriginGenerated)
+	}
+	logger.App.Info().Str("sessionId", sessionID).Int("year", year).Str("path", svgPath).Msg("Generated automatic map")
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("map:generated", serverURL, sessionID, year, svgPath, pngPath)
+	}
+}
+
+// resolveMapOptions returns the options of the named preset, or of the session's
+// default preset when name is empty, falling back to defaultMapOptions
+func (a *App) resolveMapOptions(serverURL, sessionID, name string) (MapOptions, error) {
+	if name == "" {
+		settings, err := a.config.GetSessionMapSettings(serverURL, sessionID)
+		if err != nil {
+			return MapOptions{}, err
+		}
+		name = settings.DefaultPreset
+	}
+	if name == "" {
+		return defaultMapOptions, nil
+	}
+
+	preset, err := a.config.GetMapPreset(name)
+	if err != nil {
+		return MapOptions{}, err
+	}
+	if preset == nil {
+		return MapOptions{}, fmt.Errorf("map preset not found: %s", name)
+	}
+	return mapPresetToInfo(*preset).Options, nil
+}
+
+// mapPresetToInfo converts a stored preset to its frontend representation
+func mapPresetToInfo(p model.MapPreset) MapPresetInfo {
+	return MapPresetInfo{
+		Name: p.Name,
+		Options: MapOptions{
+			Width:               p.Width,
+			Height:              p.Height,
+			ShowNames:           p.ShowNames,
+			ShowFleets:          p.ShowFleets,
+			ShowFleetPaths:      p.ShowFleetPaths,
+			ShowMines:           p.ShowMines,
+			ShowWormholes:       p.ShowWormholes,
+			ShowLegend:          p.ShowLegend,
+			ShowScannerCoverage: p.ShowScannerCoverage,
+			Theme:               p.Theme,
+		},
+	}
+}
+
 // =============================================================================
 // ANIMATED GIF GENERATION
 // =============================================================================
 
-// GenerateAnimatedMap generates an animated GIF map from session history
+// GenerateAnimatedMap generates an animated GIF map from session history.
+// StartOperation runs it with progress and cancellation.
 func (a *App) GenerateAnimatedMap(request AnimatedMapRequest) (string, error) {
+	return a.generateAnimatedMap(nil, request)
+}
+
+// generateAnimatedMap renders the animated GIF map of a session, reporting
+// its progress to op, and returns it base64 encoded
+func (a *App) generateAnimatedMap(op *operation, request AnimatedMapRequest) (string, error) {
 	logger.App.Debug().
 		Str("serverUrl", request.ServerURL).
 		Str("sessionId", request.SessionID).
@@ -160,7 +591,10 @@ func (a *App) GenerateAnimatedMap(request AnimatedMapRequest) (string, error) {
 	}
 
 	// Download the historic backup ZIP from the server
-	zipData, err := client.DownloadHistoricBackup(mgr.GetContext(), request.SessionID)
+	ctx, stop := op.bind(mgr.GetContext())
+	defer stop()
+	op.progress(0, 0, "Downloading the game history")
+	zipData, err := client.DownloadHistoricBackup(ctx, request.SessionID)
 	if err != nil {
 		return "", fmt.Errorf("failed to download historic backup: %w", err)
 	}
@@ -169,6 +603,11 @@ func (a *App) GenerateAnimatedMap(request AnimatedMapRequest) (string, error) {
 		Int("zipSize", len(zipData)).
 		Msg("Downloaded historic backup for animation")
 
+	// Every year of the game is held at once, hold a parse slot throughout
+	release := acquireParse()
+	defer release()
+	defer freeProcessingMemory()
+
 	// Create animator
 	animator := maprenderer.NewAnimator()
 
@@ -178,8 +617,15 @@ func (a *App) GenerateAnimatedMap(request AnimatedMapRequest) (string, error) {
 		return "", fmt.Errorf("failed to read backup zip: %w", err)
 	}
 
+	// One step per file of the zip and one for the rendering, after the download
+	total := len(zipReader.File) + 2
 	fileCount := 0
-	for _, file := range zipReader.File {
+	for i, file := range zipReader.File {
+		if err := op.err(); err != nil {
+			return "", err
+		}
+		op.progress(i+1, total, "Loading the game history")
+
 		// Only load .xy (universe) and .m* (turn) files
 		name := strings.ToLower(file.Name)
 		baseName := filepath.Base(name)
@@ -245,19 +691,7 @@ func (a *App) GenerateAnimatedMap(request AnimatedMapRequest) (string, error) {
 		Msg("Frames sorted by year")
 
 	// Set render options
-	opts := &maprenderer.RenderOptions{
-		Width:               request.Options.Width,
-		Height:              request.Options.Height,
-		ShowNames:           request.Options.ShowNames,
-		ShowFleets:          request.Options.ShowFleets,
-		ShowFleetPaths:      request.Options.ShowFleetPaths,
-		ShowMines:           request.Options.ShowMines,
-		ShowWormholes:       request.Options.ShowWormholes,
-		ShowLegend:          request.Options.ShowLegend,
-		ShowScannerCoverage: request.Options.ShowScannerCoverage,
-		Padding:             20,
-	}
-	animator.SetOptions(opts)
+	animator.SetOptions(renderOptions(request.Options))
 
 	// Use default GIF palette
 	animator.SetPalette(maprenderer.DefaultGIFPalette())
@@ -268,14 +702,21 @@ func (a *App) GenerateAnimatedMap(request AnimatedMapRequest) (string, error) {
 		delayMs = 500 // Default to 500ms if invalid
 	}
 
+	if err := op.err(); err != nil {
+		return "", err
+	}
+	op.progress(total-1, total, fmt.Sprintf("Rendering %d frames", animator.FrameCount()))
 	gifBytes, err := animator.RenderGIFBytes(delayMs)
 	if err != nil {
+		a.telemetry.Error(telemetry.ErrorMap)
 		return "", fmt.Errorf("failed to render GIF: %w", err)
 	}
+	a.telemetry.Count(telemetry.FeatureAnimatedMap)
 
 	// Encode to base64
 	gifB64 := base64.StdEncoding.EncodeToString(gifBytes)
 
+	op.progress(total, total, "Animated map rendered")
 	logger.App.Info().
 		Int("gifSize", len(gifBytes)).
 		Int("frameCount", animator.FrameCount()).
@@ -330,7 +771,8 @@ func (a *App) SaveGif(request GifSaveRequest) error {
 	filePath := filepath.Join(gameDir, filename)
 
 	// Write GIF to file
-	if err := os.WriteFile(filePath, gifData, 0644); err != nil {
+	filePath, err = a.writeArtifact(filePath, gifData, request.Overwrite)
+	if err != nil {
 		return fmt.Errorf("failed to save GIF: %w", err)
 	}
 
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/houston/store"
+)
+
+// =============================================================================
+// MAP DATA (hit-testing metadata)
+// =============================================================================
+
+// GenerateMapData returns the objects drawn on the map of a turn with their
+// screen positions, so the frontend can hit-test the SVG of GenerateMap for the
+// same request (same options and size). Planets and fleets seen by allies
+// the same year, from the intel packets imported into the session, are
+// merged in and tell the sender they come from.
+func (a *App) GenerateMapData(request MapGenerateRequest) (*MapDataInfo, error) {
+	logger.App.Debug().
+		Str("serverUrl", request.ServerURL).
+		Str("sessionId", request.SessionID).
+		Int("year", request.Year).
+		Msg("Generating map data")
+
+	xyBytes, err := base64.StdEncoding.DecodeString(request.UniverseB64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode universe file: %w", err)
+	}
+	turnBytes, err := base64.StdEncoding.DecodeString(request.TurnB64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode turn file: %w", err)
+	}
+
+	gs, err := newGameStore(xyBytes, turnBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Same option resolution as GenerateMap so both describe the same picture
+	if request.Preset != "" || request.Options == (MapOptions{}) {
+		options, err := a.resolveMapOptions(request.ServerURL, request.SessionID, request.Preset)
+		if err != nil {
+			return nil, err
+		}
+		request.Options = options
+	}
+
+	layout := newMapLayout(gs, request.Options.Width, request.Options.Height, renderOptions(request.Options).Padding)
+
+	data := &MapDataInfo{
+		Width:   request.Options.Width,
+		Height:  request.Options.Height,
+		Year:    2400 + int(gs.Turn),
+		Scale:   layout.scale,
+		Planets: []MapPlanetData{},
+		Fleets:  []MapFleetData{},
+	}
+
+	for _, planet := range gs.AllPlanets() {
+		px, py := layout.transform(planet.X, planet.Y)
+		// Same radius as the renderer, owned planets are drawn larger
+		radius := 2.0
+		if planet.Owner >= 0 {
+			radius = 3.0
+		}
+		data.Planets = append(data.Planets, MapPlanetData{
+			ID:          planet.PlanetNumber,
+			Name:        planet.Name,
+			Owner:       planet.Owner,
+			OwnerName:   playerName(gs, planet.Owner),
+			X:           planet.X,
+			Y:           planet.Y,
+			PX:          px,
+			PY:          py,
+			Radius:      radius,
+			HasStarbase: planet.HasStarbase,
+		})
+	}
+	sort.Slice(data.Planets, func(i, j int) bool { return data.Planets[i].ID < data.Planets[j].ID })
+
+	if request.Options.ShowFleets {
+		for _, fleet := range gs.AllFleets() {
+			px, py := layout.transform(fleet.X, fleet.Y)
+			data.Fleets = append(data.Fleets, MapFleetData{
+				ID:          fmt.Sprintf("%d:%d", fleet.Owner, fleet.FleetNumber),
+				Owner:       fleet.Owner,
+				OwnerName:   playerName(gs, fleet.Owner),
+				FleetNumber: fleet.FleetNumber,
+				Name:        fleet.Name(),
+				Ships:       fleet.TotalShips(),
+				Warp:        fleet.Warp,
+				X:           fleet.X,
+				Y:           fleet.Y,
+				PX:          px,
+				PY:          py,
+				Radius:      4, // size of the renderer's fleet triangle
+			})
+		}
+	}
+
+	// Merge what allies saw the same year, from their imported intel packets
+	if request.SessionID != "" {
+		if gameDir, err := a.sessionGameDir(request.ServerURL, request.SessionID); err == nil {
+			packets, err := importedIntel(gameDir, data.Year)
+			if err != nil {
+				logger.App.Warn().Err(err).Str("sessionId", request.SessionID).Msg("Failed to read imported intel")
+			}
+			overlayIntel(data, gs, layout, packets, request.Options.ShowFleets)
+		}
+	}
+
+	sort.Slice(data.Fleets, func(i, j int) bool {
+		if data.Fleets[i].Owner != data.Fleets[j].Owner {
+			return data.Fleets[i].Owner < data.Fleets[j].Owner
+		}
+		return data.Fleets[i].FleetNumber < data.Fleets[j].FleetNumber
+	})
+
+	return data, nil
+}
+
+// overlayIntel merges imported intel into map data: planets we know no owner
+// of take the owner an ally saw, and fleets we don't see are added. Packets
+// are taken newest first, the first one telling about an object wins.
+func overlayIntel(data *MapDataInfo, gs *store.GameStore, layout *mapLayout, packets []importedPacket, fleets bool) {
+	planets := make(map[int]*MapPlanetData, len(data.Planets))
+	for i := range data.Planets {
+		planets[data.Planets[i].ID] = &data.Planets[i]
+	}
+	seen := make(map[string]bool, len(data.Fleets))
+	for _, fleet := range data.Fleets {
+		seen[fleet.ID] = true
+	}
+
+	for _, p := range packets {
+		for _, planet := range p.payload.Planets {
+			known := planets[planet.Number]
+			if known == nil || known.Owner >= 0 || known.Intel != "" || planet.Owner < 0 {
+				continue
+			}
+			known.Owner = planet.Owner
+			known.OwnerName = playerName(gs, planet.Owner)
+			known.HasStarbase = planet.Starbase
+			known.Radius = 3.0 // owned planets are drawn larger
+			known.Intel = p.payload.Sender
+		}
+
+		if !fleets {
+			continue
+		}
+		for _, fleet := range p.payload.Fleets {
+			id := fmt.Sprintf("%d:%d", fleet.Owner, fleet.Number)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			px, py := layout.transform(fleet.X, fleet.Y)
+			data.Fleets = append(data.Fleets, MapFleetData{
+				ID:          id,
+				Owner:       fleet.Owner,
+				OwnerName:   playerName(gs, fleet.Owner),
+				FleetNumber: fleet.Number,
+				Name:        fleet.Name,
+				Ships:       fleet.Ships,
+				Warp:        fleet.Warp,
+				X:           fleet.X,
+				Y:           fleet.Y,
+				PX:          px,
+				PY:          py,
+				Radius:      4,
+				Intel:       p.payload.Sender,
+			})
+		}
+	}
+}
+
+// mapLayout maps game coordinates to map pixels the way the houston renderer
+// does: the bounds of every object are fitted into the image, centered, with
+// the Y axis flipped
+type mapLayout struct {
+	minX, maxY int
+	scale      float64
+	offsetX    float64
+	offsetY    float64
+}
+
+// newMapLayout computes the layout of a map of the given size
+func newMapLayout(gs *store.GameStore, width, height, padding int) *mapLayout {
+	minX, maxX := math.MaxInt32, math.MinInt32
+	minY, maxY := math.MaxInt32, math.MinInt32
+	update := func(x, y int) {
+		minX, maxX = min(minX, x), max(maxX, x)
+		minY, maxY = min(minY, y), max(maxY, y)
+	}
+	for _, planet := range gs.AllPlanets() {
+		update(planet.X, planet.Y)
+	}
+	for _, fleet := range gs.AllFleets() {
+		update(fleet.X, fleet.Y)
+	}
+	for _, mf := range gs.Minefields() {
+		update(mf.X, mf.Y)
+	}
+	for _, wh := range gs.Wormholes() {
+		update(wh.X, wh.Y)
+	}
+
+	if minX == math.MaxInt32 {
+		return &mapLayout{scale: 1}
+	}
+
+	rangeX := math.Max(float64(maxX-minX), 1)
+	rangeY := math.Max(float64(maxY-minY), 1)
+
+	availWidth := float64(width) - 2*float64(padding)
+	availHeight := float64(height) - 2*float64(padding)
+	scale := math.Min(availWidth/rangeX, availHeight/rangeY)
+
+	return &mapLayout{
+		minX:    minX,
+		maxY:    maxY,
+		scale:   scale,
+		offsetX: float64(padding) + (availWidth-rangeX*scale)/2,
+		offsetY: float64(padding) + (availHeight-rangeY*scale)/2,
+	}
+}
+
+// transform converts game coordinates to map pixels
+func (l *mapLayout) transform(x, y int) (float64, float64) {
+	return l.offsetX + float64(x-l.minX)*l.scale, l.offsetY + float64(l.maxY-y)*l.scale
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// MAP TILES
+// =============================================================================
+
+const (
+	// MapTileSize is the width and height of a map tile in pixels
+	MapTileSize = 256
+
+	// MaxMapTileZoom is the deepest zoom level, where the map is 16x16 tiles
+	MaxMapTileZoom = 4
+)
+
+// GetMapTile returns a PNG tile (base64) of a year's map, rendered with the
+// session's default map options. At zoom z the whole map is 2^z by 2^z tiles
+// of MapTileSize pixels; x and y count from the top-left corner.
+//
+// A zoom level is rendered once and all its tiles are cached under
+// tiles/<year>/ in the game directory, so panning only reads files.
+func (a *App) GetMapTile(serverURL, sessionID string, year, z, x, y int) (string, error) {
+	if z < 0 || z > MaxMapTileZoom {
+		return "", fmt.Errorf("invalid zoom level %d: must be between 0 and %d", z, MaxMapTileZoom)
+	}
+	if x < 0 || y < 0 || x >= 1<<z || y >= 1<<z {
+		return "", fmt.Errorf("tile %d/%d out of range at zoom %d", x, y, z)
+	}
+
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	options, err := a.resolveMapOptions(serverURL, sessionID, "")
+	if err != nil {
+		return "", err
+	}
+
+	levelDir := filepath.Join(gameDir, "tiles", strconv.Itoa(year), mapTileKey(options), strconv.Itoa(z))
+	tilePath := filepath.Join(levelDir, fmt.Sprintf("%d-%d.png", x, y))
+
+	if data, err := os.ReadFile(tilePath); err == nil {
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
+
+	// One level at a time: concurrent requests for the same level wait for
+	// the first render and then hit the cache
+	a.mapTilesMu.Lock()
+	defer a.mapTilesMu.Unlock()
+
+	if data, err := os.ReadFile(tilePath); err == nil {
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
+
+	if err := a.renderMapTiles(serverURL, sessionID, year, z, options, levelDir); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(tilePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read map tile: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// renderMapTiles renders a zoom level of a year's map and writes all its tiles
+func (a *App) renderMapTiles(serverURL, sessionID string, year, z int, options MapOptions, levelDir string) error {
+	turnFiles, err := a.GetTurn(serverURL, sessionID, year, false)
+	if err != nil {
+		return err
+	}
+	universe, err := base64.StdEncoding.DecodeString(turnFiles.Universe)
+	if err != nil {
+		return fmt.Errorf("failed to decode universe file: %w", err)
+	}
+	turn, err := base64.StdEncoding.DecodeString(turnFiles.Turn)
+	if err != nil {
+		return fmt.Errorf("failed to decode turn file: %w", err)
+	}
+
+	renderer, err := newMapRenderer(universe, turn)
+	if err != nil {
+		return err
+	}
+
+	tiles := 1 << z
+	opts := renderOptions(options)
+	opts.Width = MapTileSize * tiles
+	opts.Height = MapTileSize * tiles
+
+	// Same rasterization as WritePNG: anti-aliased SVG, basic renderer as fallback
+	img, err := renderer.RenderSVGToImage(opts)
+	if err != nil {
+		img = renderer.Render(opts)
+	}
+
+	if err := os.MkdirAll(levelDir, 0755); err != nil {
+		return fmt.Errorf("failed to create tile directory: %w", err)
+	}
+
+	for ty := 0; ty < tiles; ty++ {
+		for tx := 0; tx < tiles; tx++ {
+			rect := image.Rect(tx*MapTileSize, ty*MapTileSize, (tx+1)*MapTileSize, (ty+1)*MapTileSize)
+
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, img.SubImage(rect)); err != nil {
+				return fmt.Errorf("failed to encode map tile: %w", err)
+			}
+			// Tiles are a cache read back from their path: always replaced, never timestamped
+			if _, err := a.writeArtifact(filepath.Join(levelDir, fmt.Sprintf("%d-%d.png", tx, ty)), buf.Bytes(), true); err != nil {
+				return fmt.Errorf("failed to write map tile: %w", err)
+			}
+		}
+	}
+
+	logger.App.Debug().
+		Str("sessionId", sessionID).
+		Int("year", year).
+		Int("zoom", z).
+		Int("tiles", tiles*tiles).
+		Msg("Rendered map tiles")
+
+	return nil
+}
+
+// mapTileKey identifies the options a tile set was rendered with, so changing
+// the session's map options does not serve stale tiles
+func mapTileKey(o MapOptions) string {
+	h := fnv.New32a()
+	_, _ = fmt.Fprintf(h, "%t|%t|%d|%t|%t|%t|%t",
+		o.ShowNames, o.ShowFleets, o.ShowFleetPaths, o.ShowMines, o.ShowWormholes, o.ShowLegend, o.ShowScannerCoverage)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
@@ -5,8 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"time"
 
 	"github.com/neper-stars/astrum/api"
 	"github.com/neper-stars/astrum/api/models"
@@ -54,15 +53,32 @@ func (a *App) startMonitoringForServer(serverURL string) {
 		return
 	}
 
+	// Give our session directories readable names, before they are watched
+	for _, session := range sessions {
+		if !isSessionMember(&session, userInfo.User.ID) {
+			continue
+		}
+		a.assignSessionDirAlias(serverURL, serverName, session.ID, session.Name)
+	}
+
 	// Find sessions where we are participating (started and we were ready)
 	for _, session := range sessions {
 		if session.State != models.SessionStateStarted {
 			continue
 		}
 
+		// The session may be played with another credential than the server's
+		userID := userInfo.User.ID
+		if _, sessionMgr, err := a.sessionConnection(serverURL, session.ID); err != nil {
+			logger.Monitor.Warn().Err(err).Str("sessionID", session.ID).Msg("Cannot monitor session: session credential unavailable")
+			continue
+		} else if sessionUser := sessionMgr.GetUserInfo(); sessionUser != nil {
+			userID = sessionUser.User.ID
+		}
+
 		// Find our player entry and check if we were ready
 		for playerIdx, player := range session.Players {
-			if player.UserProfileID == userInfo.User.ID && player.Ready {
+			if player.UserProfileID == userID && player.Ready {
 				// We are participating in this session - start monitoring
 				a.startMonitoringSession(serverURL, serverName, session.ID, playerIdx)
 				break
@@ -73,6 +89,17 @@ func (a *App) startMonitoringForServer(serverURL string) {
 
 // startMonitoringSession starts monitoring a single session for order files
 func (a *App) startMonitoringSession(serverURL, serverName, sessionID string, playerOrder int) {
+	// Another machine of the user uploads the orders
+	if a.IsMonitoringHandedOff(serverURL) {
+		logger.Monitor.Debug().Str("sessionID", sessionID).Msg("Order monitoring handed off to another machine")
+		return
+	}
+	// Order files are not watched after a crash loop
+	if a.inSafeStart() {
+		logger.Monitor.Debug().Str("sessionID", sessionID).Msg("Order monitoring disabled in safe mode")
+		return
+	}
+
 	// Get or create monitor manager for this server
 	a.mu.Lock()
 	orderMon, exists := a.orderMonitors[serverURL]
@@ -91,15 +118,27 @@ func (a *App) startMonitoringSession(serverURL, serverName, sessionID string, pl
 			}
 
 			if success {
-				runtime.EventsEmit(a.ctx, "order:submitted", serverURL, sessID, year)
+				a.emit("order:submitted", serverURL, sessID, year, a.registerOrderUndo(serverURL, sessID, year))
+				go a.onOrderUploaded(serverURL, sessID, year)
 			} else {
 				errMsg := ""
 				if err != nil {
 					errMsg = err.Error()
 				}
-				runtime.EventsEmit(a.ctx, "order:error", serverURL, sessID, year, errMsg)
+				a.emit("order:error", serverURL, sessID, year, errMsg)
 			}
 		})
+		orderMon.SetOnOrderQuarantined(func(sessID, quarantinePath string, err error) {
+			a.mu.RLock()
+			shuttingDown := a.shuttingDown
+			a.mu.RUnlock()
+			if !shuttingDown {
+				a.emit("order:quarantined", serverURL, sessID, quarantinePath, err.Error())
+			}
+		})
+		orderMon.SetOnTurnFileRemoved(func(sessID, filePath string) {
+			a.recoverRemovedTurnFile(serverURL, sessID, filePath)
+		})
 		a.orderMonitors[serverURL] = orderMon
 	}
 	a.mu.Unlock()
@@ -122,6 +161,18 @@ func (a *App) startMonitoringSession(serverURL, serverName, sessionID string, pl
 		PlayerOrder: playerOrder,
 		GameDir:     gameDir,
 	}
+	if settings, err := a.config.GetAppSettings(); err == nil {
+		session.Debounce = time.Duration(settings.GetOrderDebounceMs()) * time.Millisecond
+		session.Settle = time.Duration(settings.GetOrderSettleMs()) * time.Millisecond
+	}
+	if extraDirs, err := a.config.GetSessionWatchDirs(serverURL, sessionID); err == nil {
+		session.ExtraDirs = extraDirs
+	} else {
+		logger.Monitor.Warn().
+			Err(err).
+			Str("sessionID", sessionID).
+			Msg("Failed to get extra order directories")
+	}
 
 	// Start watching
 	if err := orderMon.Watch(session); err != nil {
@@ -131,15 +182,23 @@ func (a *App) startMonitoringSession(serverURL, serverName, sessionID string, pl
 			Msg("Failed to start monitoring session")
 	}
 
+	// Races made with the Stars! race wizard are usually saved next to the game
+	a.watchRaceDir(gameDir, watchedRaceDir{serverURL: serverURL, sessionID: sessionID})
+
 	// Check for pending order files on startup
 	go a.rescanAndUploadPendingOrders(serverURL, sessionID, gameDir, playerOrder)
+
+	// Desktop widgets list every game we play, looked at or not
+	if a.statusFilePath() != "" {
+		go a.refreshOrdersStatus(serverURL, sessionID, false)
+	}
 }
 
 // rescanAndUploadPendingOrders checks for local order files that need to be uploaded on connect
 func (a *App) rescanAndUploadPendingOrders(serverURL, sessionID, gameDir string, playerOrder int) {
 	// Build the order file path: game.xN where N = playerOrder + 1 (1-indexed)
 	orderFileName := fmt.Sprintf("game.x%d", playerOrder+1)
-	orderPath := filepath.Join(gameDir, orderFileName)
+	orderPath := monitor.ResolveFile(gameDir, orderFileName)
 
 	// Check if file exists
 	data, err := os.ReadFile(orderPath)
@@ -217,8 +276,9 @@ func (a *App) rescanAndUploadPendingOrders(serverURL, sessionID, gameDir string,
 	shuttingDown := a.shuttingDown
 	a.mu.RUnlock()
 	if !shuttingDown {
-		runtime.EventsEmit(a.ctx, "order:submitted", serverURL, sessionID, orderYear)
+		a.emit("order:submitted", serverURL, sessionID, orderYear, a.registerOrderUndo(serverURL, sessionID, orderYear))
 	}
+	go a.onOrderUploaded(serverURL, sessionID, orderYear)
 }
 
 // createOrderHandler creates a handler function that validates order files
@@ -227,7 +287,7 @@ func (a *App) createOrderHandler(serverURL string) monitor.OrderFileHandler {
 		// Validate the order file
 		validator, err := astrum.NewOrderValidator(filePath)
 		if err != nil {
-			return 0, nil, fmt.Errorf("failed to parse order file: %w", err)
+			return 0, nil, fmt.Errorf("%w: %w", monitor.ErrInvalidOrderFile, err)
 		}
 
 		// Check if turn is submitted
@@ -250,12 +310,8 @@ func (a *App) createOrderHandler(serverURL string) monitor.OrderFileHandler {
 
 // checkAndStartMonitoring checks if a session has started and we should begin monitoring
 func (a *App) checkAndStartMonitoring(serverURL, sessionID string) {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	authMgr, authOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !authOk {
+	client, authMgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
 		return
 	}
 
@@ -332,19 +388,15 @@ func (a *App) createSubmitHandler(serverURL string) monitor.SubmitHandler {
 			shuttingDown := a.shuttingDown
 			a.mu.RUnlock()
 			if !shuttingDown {
-				runtime.EventsEmit(a.ctx, "order:conflict", srvURL, sessionID, year)
+				a.emit("order:conflict", srvURL, sessionID, year)
 			}
 			return fmt.Errorf("order conflict: file modified after upload for year %d", year)
 		}
 
 		// No stored hash for this year - this is a new order, proceed with upload
-		a.mu.RLock()
-		client, ok := a.clients[srvURL]
-		authMgr, authOk := a.authManagers[srvURL]
-		a.mu.RUnlock()
-
-		if !ok || !authOk {
-			return fmt.Errorf("not connected to server: %s", srvURL)
+		client, authMgr, err := a.sessionConnection(srvURL, sessionID)
+		if err != nil {
+			return err
 		}
 
 		// Get the latest turn year from the server to validate
@@ -354,15 +406,18 @@ func (a *App) createSubmitHandler(serverURL string) monitor.SubmitHandler {
 		}
 
 		// Check if the order year matches the server year
-		if year != int(latestTurn.Year) {
-			return fmt.Errorf("order year %d does not match server year %d", year, latestTurn.Year)
+		if err := a.checkOrderYear(srvURL, sessionID, client, year, int(latestTurn.Year)); err != nil {
+			return err
 		}
 
 		// Submit the order
 		order := &api.Order{
 			B64Data: base64.StdEncoding.EncodeToString(data),
 		}
-		if err := client.SubmitTurn(authMgr.GetContext(), sessionID, year, order); err != nil {
+		archived := a.archiveOrder(srvURL, sessionID, year, data)
+		err = client.SubmitTurn(authMgr.GetContext(), sessionID, year, order)
+		archived.uploaded(err)
+		if err != nil {
 			return fmt.Errorf("failed to submit turn: %w", err)
 		}
 
@@ -374,7 +429,56 @@ func (a *App) createSubmitHandler(serverURL string) monitor.SubmitHandler {
 				Int("year", year).
 				Msg("Failed to track uploaded order hash")
 		}
+		go a.publishSyncToken(srvURL, sessionID, year, currentHash)
+		a.recordUploadedOrder(srvURL, sessionID, currentHash)
 
 		return nil
 	}
 }
+
+// GetSessionWatchDirs returns the extra directories watched for the order
+// files of a session
+func (a *App) GetSessionWatchDirs(serverURL, sessionID string) ([]string, error) {
+	return a.config.GetSessionWatchDirs(serverURL, sessionID)
+}
+
+// SetSessionWatchDirs sets the extra directories watched for the order files
+// of a session, for players saving their orders elsewhere (e.g. a synced
+// folder). Orders saved there are copied to the game directory and uploaded.
+// A watched session is restarted with the new directories.
+func (a *App) SetSessionWatchDirs(serverURL, sessionID string, dirs []string) error {
+	cleaned := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("watch directory must be an absolute path: %s", dir)
+		}
+		dir = filepath.Clean(dir)
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			return fmt.Errorf("watch directory does not exist: %s", dir)
+		}
+		if !containsString(cleaned, dir) {
+			cleaned = append(cleaned, dir)
+		}
+	}
+
+	if err := a.config.SetSessionWatchDirs(serverURL, sessionID, cleaned); err != nil {
+		return err
+	}
+
+	a.mu.RLock()
+	orderMon, ok := a.orderMonitors[serverURL]
+	a.mu.RUnlock()
+	if ok {
+		if session, watched := orderMon.Session(sessionID); watched {
+			orderMon.Unwatch(sessionID)
+			a.startMonitoringSession(serverURL, session.ServerName, sessionID, session.PlayerOrder)
+		}
+	}
+
+	logger.Monitor.Info().Str("sessionID", sessionID).Strs("dirs", cleaned).Msg("Set extra order directories")
+	return nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/neper-stars/houston/blocks"
+	"github.com/neper-stars/houston/store"
+)
+
+// =============================================================================
+// NEWSLETTER
+// =============================================================================
+
+// notableShipLoss is the number of warships a player must lose in a year for
+// the newsletter to report a battle
+const notableShipLoss = 5
+
+// newsletter is the public information of a session year, before formatting
+type newsletter struct {
+	Title        string
+	Year         int
+	PlayerCount  int
+	PlanetCount  int
+	PublicScores bool
+	Scores       []newsletterScore
+	Battles      []string
+}
+
+// newsletterScore is a line of the newsletter scoreboard
+type newsletterScore struct {
+	Rank    int
+	Player  string
+	Score   int
+	Delta   int
+	Planets int
+}
+
+// GenerateNewsletter composes a summary of a year of a session that the host
+// can post for every player, as "markdown" or "html". It only holds public
+// information: the scoreboard and the battles deduced from warship losses
+// appear only when player scores are public in the game rules.
+func (a *App) GenerateNewsletter(serverURL, sessionID string, year int, format string) (string, error) {
+	if format != "markdown" && format != "html" {
+		return "", fmt.Errorf("unsupported newsletter format: %s", format)
+	}
+
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+	ctx := mgr.GetContext()
+
+	session, err := client.GetSession(ctx, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get session: %w", err)
+	}
+	rules, err := a.GetRules(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	gs, err := fetchYearGameStore(ctx, client, sessionID, year)
+	if err != nil {
+		return "", err
+	}
+
+	n := &newsletter{
+		Title:        session.Name,
+		Year:         year,
+		PlayerCount:  len(session.Players),
+		PlanetCount:  len(gs.AllPlanets()),
+		PublicScores: rules.PublicPlayerScores,
+	}
+
+	if n.PublicScores {
+		// The first year has nothing to compare with
+		var previous *store.GameStore
+		if year > blocks.StarsBaseYear {
+			if previous, err = fetchYearGameStore(ctx, client, sessionID, year-1); err != nil {
+				return "", err
+			}
+		}
+		n.Scores, n.Battles = newsletterScores(previous, gs)
+	}
+
+	if format == "html" {
+		return formatNewsletterHTML(n), nil
+	}
+	return formatNewsletterMarkdown(n), nil
+}
+
+// newsletterScores builds the scoreboard of a year, best rank first, and the
+// battles deduced from the warships players lost since the previous year
+func newsletterScores(previous, gs *store.GameStore) ([]newsletterScore, []string) {
+	scores := []newsletterScore{}
+	battles := []string{}
+	for _, player := range sortedPlayers(gs) {
+		score := player.StoredScore
+		if score == nil {
+			continue
+		}
+		line := newsletterScore{Rank: score.Rank, Player: player.NamePlural, Score: score.Score, Planets: score.Planets}
+
+		if previous != nil {
+			if before, ok := previous.Player(player.PlayerNumber); ok && before.StoredScore != nil {
+				line.Delta = score.Score - before.StoredScore.Score
+				lost := warships(before.StoredScore) - warships(score)
+				if lost >= notableShipLoss {
+					battles = append(battles, fmt.Sprintf("The %s lost %d warships", player.NamePlural, lost))
+				}
+			}
+		}
+		scores = append(scores, line)
+	}
+	sort.SliceStable(scores, func(i, j int) bool { return scores[i].Rank < scores[j].Rank })
+	return scores, battles
+}
+
+// warships returns the number of armed ships of a score
+func warships(s *store.StoredScore) int {
+	return s.EscortShips + s.CapitalShips
+}
+
+// formatNewsletterMarkdown formats a newsletter as Markdown
+func formatNewsletterMarkdown(n *newsletter) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s — %d\n\n", n.Title, n.Year)
+	fmt.Fprintf(&b, "%d players share a universe of %d planets.\n", n.PlayerCount, n.PlanetCount)
+
+	if !n.PublicScores {
+		b.WriteString("\n_Player scores are not public in this game._\n")
+		return b.String()
+	}
+
+	if len(n.Scores) > 0 {
+		b.WriteString("\n## Scoreboard\n\n| Rank | Player | Score | Change | Planets |\n|---:|---|---:|---:|---:|\n")
+		for _, s := range n.Scores {
+			fmt.Fprintf(&b, "| %d | %s | %d | %+d | %d |\n", s.Rank, s.Player, s.Score, s.Delta, s.Planets)
+		}
+	}
+
+	if len(n.Battles) > 0 {
+		b.WriteString("\n## Battles\n\n")
+		for _, battle := range n.Battles {
+			fmt.Fprintf(&b, "- %s\n", battle)
+		}
+	}
+
+	return b.String()
+}
+
+// formatNewsletterHTML formats a newsletter as an HTML fragment
+func formatNewsletterHTML(n *newsletter) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<h1>%s — %d</h1>\n", html.EscapeString(n.Title), n.Year)
+	fmt.Fprintf(&b, "<p>%d players share a universe of %d planets.</p>\n", n.PlayerCount, n.PlanetCount)
+
+	if !n.PublicScores {
+		b.WriteString("<p><em>Player scores are not public in this game.</em></p>\n")
+		return b.String()
+	}
+
+	if len(n.Scores) > 0 {
+		b.WriteString("<h2>Scoreboard</h2>\n<table>\n<tr><th>Rank</th><th>Player</th><th>Score</th><th>Change</th><th>Planets</th></tr>\n")
+		for _, s := range n.Scores {
+			fmt.Fprintf(&b, "<tr><td>%d</td><td>%s</td><td>%d</td><td>%+d</td><td>%d</td></tr>\n",
+				s.Rank, html.EscapeString(s.Player), s.Score, s.Delta, s.Planets)
+		}
+		b.WriteString("</table>\n")
+	}
+
+	if len(n.Battles) > 0 {
+		b.WriteString("<h2>Battles</h2>\n<ul>\n")
+		for _, battle := range n.Battles {
+			fmt.Fprintf(&b, "<li>%s</li>\n", html.EscapeString(battle))
+		}
+		b.WriteString("</ul>\n")
+	}
+
+	return b.String()
+}
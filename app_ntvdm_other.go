@@ -2,6 +2,10 @@
 
 package main
 
+import (
+	"fmt"
+)
+
 // CheckNtvdmSupport is a no-op on non-Windows platforms
 // NTVDM is a Windows-only feature for running 16-bit applications
 func (a *App) CheckNtvdmSupport() (*NtvdmCheckResult, error) {
@@ -11,3 +15,8 @@ func (a *App) CheckNtvdmSupport() (*NtvdmCheckResult, error) {
 		Message:   "NTVDM check is only applicable on Windows. Use Wine on this platform.",
 	}, nil
 }
+
+// InstallOtvdm is not available on non-Windows platforms
+func (a *App) InstallOtvdm() (*NtvdmCheckResult, error) {
+	return nil, fmt.Errorf("OTVDM is only used on Windows, use Wine on this platform")
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// LONG OPERATIONS
+// =============================================================================
+
+// Kinds of long operations, see StartOperation
+const (
+	operationSessionBackup  = "session_backup"  // DownloadSessionBackup, result is the zip path
+	operationHistoricBackup = "historic_backup" // DownloadHistoricBackup, result is the zip path
+	operationTurnDownload   = "turn_download"   // every missing year of a session, result is a summary
+	operationAnimatedMap    = "animated_map"    // GenerateAnimatedMap, result is the base64 GIF
+)
+
+// Statuses of a long operation
+const (
+	operationRunning   = "running"
+	operationDone      = "done"
+	operationFailed    = "failed"
+	operationCancelled = "cancelled"
+)
+
+// operationRetention is how long a finished operation waits for its result
+// to be fetched before being forgotten
+const operationRetention = 10 * time.Minute
+
+// operation is a long operation started from the frontend. Its state is
+// guarded by App.opsMu.
+type operation struct {
+	app        *App
+	ctx        context.Context
+	cancel     context.CancelFunc
+	info       OperationInfo
+	result     string
+	finishedAt time.Time
+}
+
+// StartOperation starts a long operation in the background and returns its
+// ID. Its progress is emitted as "operation:progress" (opID, info) and its end
+// as "operation:done" (opID, info), with the status "done", "failed" or
+// "cancelled". The result of a completed operation is fetched with
+// GetOperationResult. Kinds are "session_backup", "historic_backup",
+// "turn_download" (every year of the session missing locally) and
+// "animated_map", which needs request.AnimatedMap.
+func (a *App) StartOperation(request OperationRequest) (string, error) {
+	var run func(op *operation) (string, error)
+	switch request.Kind {
+	case operationSessionBackup:
+		run = func(op *operation) (string, error) {
+			return a.downloadSessionBackup(op, request.ServerURL, request.SessionID)
+		}
+	case operationHistoricBackup:
+		run = func(op *operation) (string, error) {
+			return a.downloadHistoricBackup(op, request.ServerURL, request.SessionID)
+		}
+	case operationTurnDownload:
+		run = func(op *operation) (string, error) {
+			return a.downloadSessionTurns(op, request.ServerURL, request.SessionID)
+		}
+	case operationAnimatedMap:
+		if request.AnimatedMap == nil {
+			return "", fmt.Errorf("an animated map operation needs its map request")
+		}
+		mapRequest := *request.AnimatedMap
+		mapRequest.ServerURL, mapRequest.SessionID = request.ServerURL, request.SessionID
+		run = func(op *operation) (string, error) {
+			return a.generateAnimatedMap(op, mapRequest)
+		}
+	default:
+		return "", fmt.Errorf("unknown operation kind %q", request.Kind)
+	}
+
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate operation ID: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(a.ctx)
+	op := &operation{
+		app:    a,
+		ctx:    ctx,
+		cancel: cancel,
+		info: OperationInfo{
+			ID:        hex.EncodeToString(buf),
+			Kind:      request.Kind,
+			ServerURL: request.ServerURL,
+			SessionID: request.SessionID,
+			Status:    operationRunning,
+			StartedAt: time.Now().Format(time.RFC3339),
+		},
+	}
+
+	now := time.Now()
+	a.opsMu.Lock()
+	for id, other := range a.operations {
+		if !other.finishedAt.IsZero() && now.Sub(other.finishedAt) > operationRetention {
+			delete(a.operations, id)
+		}
+	}
+	a.operations[op.info.ID] = op
+	a.opsMu.Unlock()
+
+	logger.App.Info().
+		Str("opId", op.info.ID).
+		Str("kind", request.Kind).
+		Str("sessionId", request.SessionID).
+		Msg("Started operation")
+
+	go func() {
+		result, err := run(op)
+		op.finish(result, err)
+	}()
+	return op.info.ID, nil
+}
+
+// CancelOperation cancels a running operation, which then ends with the
+// status "cancelled". Returns false if no such operation is running.
+func (a *App) CancelOperation(opID string) bool {
+	a.opsMu.Lock()
+	op, ok := a.operations[opID]
+	running := ok && op.info.Status == operationRunning
+	a.opsMu.Unlock()
+
+	if !running {
+		return false
+	}
+	op.cancel()
+	logger.App.Info().Str("opId", opID).Msg("Canceled operation")
+	return true
+}
+
+// ListOperations returns the running operations and the finished ones whose
+// result was not fetched yet, e.g. to show them again after a reload
+func (a *App) ListOperations() []OperationInfo {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+
+	ops := []OperationInfo{}
+	for _, op := range a.operations {
+		ops = append(ops, op.info)
+	}
+	return ops
+}
+
+// GetOperationResult returns the result of a completed operation, which is
+// then forgotten: a zip path for backups, a summary for turn downloads and
+// the base64 GIF for animated maps. A failed operation returns its error.
+func (a *App) GetOperationResult(opID string) (string, error) {
+	a.opsMu.Lock()
+	defer a.opsMu.Unlock()
+
+	op, ok := a.operations[opID]
+	if !ok {
+		return "", fmt.Errorf("unknown operation %s", opID)
+	}
+	switch op.info.Status {
+	case operationRunning:
+		return "", fmt.Errorf("the operation is still running")
+	case operationCancelled:
+		delete(a.operations, opID)
+		return "", fmt.Errorf("the operation was cancelled")
+	case operationFailed:
+		delete(a.operations, opID)
+		return "", errors.New(op.info.Error)
+	}
+	delete(a.operations, opID)
+	return op.result, nil
+}
+
+// progress records the steps done so far, total being 0 while unknown, and
+// emits "operation:progress". Does nothing for work not started as an
+// operation, so the same code serves the plain bindings.
+func (op *operation) progress(done, total int, message string) {
+	if op == nil {
+		return
+	}
+
+	op.app.opsMu.Lock()
+	op.info.Done, op.info.Total, op.info.Message = done, total, message
+	info := op.info
+	op.app.opsMu.Unlock()
+
+	op.app.mu.RLock()
+	shuttingDown := op.app.shuttingDown
+	op.app.mu.RUnlock()
+	if !shuttingDown {
+		op.app.emit("operation:progress", info.ID, info)
+	}
+}
+
+// err returns the error of a cancelled operation, nil otherwise or for work
+// not started as an operation
+func (op *operation) err() error {
+	if op == nil {
+		return nil
+	}
+	return op.ctx.Err()
+}
+
+// bind returns a context of parent that is also cancelled with the operation
+func (op *operation) bind(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	if op == nil {
+		return ctx, cancel
+	}
+	stop := context.AfterFunc(op.ctx, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
+
+// finish records the outcome of an operation and emits "operation:done"
+func (op *operation) finish(result string, err error) {
+	op.app.opsMu.Lock()
+	switch {
+	case err != nil && op.ctx.Err() != nil:
+		op.info.Status = operationCancelled
+	case err != nil:
+		op.info.Status = operationFailed
+		op.info.Error = err.Error()
+	default:
+		op.info.Status = operationDone
+		op.result = result
+	}
+	op.finishedAt = time.Now()
+	info := op.info
+	op.app.opsMu.Unlock()
+	op.cancel()
+
+	logger.App.Info().
+		Str("opId", info.ID).
+		Str("kind", info.Kind).
+		Str("status", info.Status).
+		Err(err).
+		Msg("Operation finished")
+
+	op.app.mu.RLock()
+	shuttingDown := op.app.shuttingDown
+	op.app.mu.RUnlock()
+	if !shuttingDown {
+		op.app.emit("operation:done", info.ID, info)
+	}
+}
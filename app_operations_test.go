@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOperationTestApp() *App {
+	// Shutting down, so that nothing is emitted without a frontend
+	return &App{
+		ctx:          context.Background(),
+		operations:   make(map[string]*operation),
+		shuttingDown: true,
+	}
+}
+
+func TestStartOperation_UnknownKind(t *testing.T) {
+	a := newOperationTestApp()
+
+	_, err := a.StartOperation(OperationRequest{Kind: "defragment"})
+	assert.EqualError(t, err, `unknown operation kind "defragment"`)
+
+	_, err = a.StartOperation(OperationRequest{Kind: operationAnimatedMap})
+	assert.Error(t, err, "an animated map needs its map request")
+}
+
+func TestStartOperation_Failed(t *testing.T) {
+	a := newOperationTestApp()
+
+	opID, err := a.StartOperation(OperationRequest{
+		Kind:      operationSessionBackup,
+		ServerURL: "https://test.server.com",
+		SessionID: "session-123",
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		ops := a.ListOperations()
+		return len(ops) == 1 && ops[0].Status != operationRunning
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, operationFailed, a.ListOperations()[0].Status)
+	assert.False(t, a.CancelOperation(opID), "a finished operation cannot be cancelled")
+
+	_, err = a.GetOperationResult(opID)
+	assert.ErrorContains(t, err, ErrNotConnected.Error())
+
+	// The result is only given once
+	_, err = a.GetOperationResult(opID)
+	assert.EqualError(t, err, "unknown operation "+opID)
+}
+
+func TestOperation_Cancelled(t *testing.T) {
+	a := newOperationTestApp()
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &operation{app: a, ctx: ctx, cancel: cancel, info: OperationInfo{ID: "op-1", Status: operationRunning}}
+	a.operations["op-1"] = op
+
+	assert.True(t, a.CancelOperation("op-1"))
+	assert.ErrorIs(t, op.err(), context.Canceled)
+	op.finish("", op.err())
+
+	assert.Equal(t, operationCancelled, a.ListOperations()[0].Status)
+	_, err := a.GetOperationResult("op-1")
+	assert.EqualError(t, err, "the operation was cancelled")
+}
+
+func TestOperation_Nil(t *testing.T) {
+	var op *operation
+
+	// Work not started as an operation reports nothing and is never cancelled
+	op.progress(1, 2, "Downloading")
+	assert.NoError(t, op.err())
+
+	ctx, stop := op.bind(context.Background())
+	defer stop()
+	assert.NoError(t, ctx.Err())
+}
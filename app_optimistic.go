@@ -0,0 +1,109 @@
+package main
+
+import (
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// OPTIMISTIC UPDATES
+// =============================================================================
+
+// optimisticUpdate is a change echoed to the cached server data before the
+// server confirms it. The frontend gets <topic>:optimistic with the echoed
+// state right away, then <topic>:confirmed with the server state, or
+// <topic>:rollback with the previous state and the error.
+type optimisticUpdate[T any] struct {
+	a         *App
+	serverURL string
+	sessionID string
+	topic     string // "session" or "rules"
+	key       string // server cache key
+	previous  *T     // nil when nothing was cached, so nothing was echoed
+	echoed    *T
+}
+
+// beginOptimistic applies a change to a copy of the cached state and echoes
+// it. Without cached state there is nothing to echo and the update is a no-op
+// until confirmed.
+func beginOptimistic[T any](a *App, serverURL, sessionID, topic, key string, cached *T, apply func(*T)) *optimisticUpdate[T] {
+	u := &optimisticUpdate[T]{a: a, serverURL: serverURL, sessionID: sessionID, topic: topic, key: key}
+	if cached == nil {
+		return u
+	}
+
+	// Work on a copy, the cached state must survive for the rollback
+	data, err := jsoniter.Marshal(cached)
+	if err != nil {
+		return u
+	}
+	echoed := new(T)
+	if err := jsoniter.Unmarshal(data, echoed); err != nil {
+		return u
+	}
+	apply(echoed)
+
+	u.previous = cached
+	u.echoed = echoed
+	a.cacheServerData(serverURL, key, echoed)
+	a.emitOptimistic(topic+":optimistic", serverURL, sessionID, echoed)
+	return u
+}
+
+// confirm replaces the echoed state with the state confirmed by the server
+func (u *optimisticUpdate[T]) confirm(state *T) {
+	u.a.cacheServerData(u.serverURL, u.key, state)
+	u.a.emitOptimistic(u.topic+":confirmed", u.serverURL, u.sessionID, state)
+}
+
+// confirmEchoed confirms the echoed state, for calls whose response does not
+// carry the updated state
+func (u *optimisticUpdate[T]) confirmEchoed() {
+	if u.echoed != nil {
+		u.confirm(u.echoed)
+	}
+}
+
+// rollback restores the state cached before the echo after the server
+// refused the change. Failures before any echo are left to the caller.
+func (u *optimisticUpdate[T]) rollback(err error) {
+	if u.previous == nil {
+		return
+	}
+	logger.App.Info().Err(err).Str("serverUrl", u.serverURL).Str("sessionId", u.sessionID).Str("topic", u.topic).Msg("Rolling back optimistic update")
+	u.a.cacheServerData(u.serverURL, u.key, u.previous)
+	u.a.emitOptimistic(u.topic+":rollback", u.serverURL, u.sessionID, u.previous, err.Error())
+}
+
+// emitOptimistic emits an optimistic update event unless the app is shutting down
+func (a *App) emitOptimistic(event string, data ...any) {
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit(event, data...)
+	}
+}
+
+// beginSessionUpdate echoes a change to the cached state of a session
+func (a *App) beginSessionUpdate(serverURL, sessionID string, apply func(*SessionInfo)) *optimisticUpdate[SessionInfo] {
+	cached, _ := a.cachedSession(serverURL, sessionID)
+	if cached != nil {
+		cached.Stale = false
+	}
+	return beginOptimistic(a, serverURL, sessionID, "session", cacheKeySession+sessionID, cached, apply)
+}
+
+// beginRulesUpdate echoes new rules in place of the cached rules of a session
+func (a *App) beginRulesUpdate(serverURL, sessionID string, rules *RulesInfo) *optimisticUpdate[RulesInfo] {
+	var cached *RulesInfo
+	var previous RulesInfo
+	if a.cachedServerData(serverURL, cacheKeyRules+sessionID, &previous) {
+		cached = &previous
+	}
+	return beginOptimistic(a, serverURL, sessionID, "rules", cacheKeyRules+sessionID, cached, func(r *RulesInfo) {
+		*r = *rules
+		r.Stale = false
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/filehash"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// =============================================================================
+// ORDER HISTORY
+// =============================================================================
+
+// orderHistoryDirName is the folder of the game directory keeping a copy of
+// every uploaded order file, to settle "I did submit!" disputes
+const orderHistoryDirName = "orders-history"
+
+// Upload states of an archived order
+const (
+	orderStatusPending  = "pending"
+	orderStatusUploaded = "uploaded"
+	orderStatusFailed   = "failed"
+)
+
+// orderReceipt is saved next to an archived order file, as <version>.json
+type orderReceipt struct {
+	ServerURL  string     `json:"serverUrl"`
+	SessionID  string     `json:"sessionId"`
+	Year       int        `json:"year"`
+	Player     int        `json:"player"`
+	Hash       string     `json:"hash"`
+	Size       int        `json:"size"`
+	ArchivedAt time.Time  `json:"archivedAt"`
+	Status     string     `json:"status"`
+	UploadedAt *time.Time `json:"uploadedAt,omitempty"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// archivedOrder is an order file archived before its upload, whose receipt
+// is completed once the upload is done
+type archivedOrder struct {
+	path    string
+	receipt orderReceipt
+}
+
+// archiveOrder copies an order file about to be uploaded to the order history
+// of its session. Archiving is best effort: failures are logged and nil is
+// returned, which the upload must not wait on.
+func (a *App) archiveOrder(serverURL, sessionID string, year int, data []byte) *archivedOrder {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err == nil {
+		err = safefile.MkdirAll(filepath.Join(gameDir, orderHistoryDirName), 0755)
+	}
+	if err != nil {
+		logger.Monitor.Warn().Err(err).Str("sessionID", sessionID).Int("year", year).Msg("Failed to archive order file")
+		return nil
+	}
+
+	player := 0
+	if validator, err := astrum.NewOrderValidatorFromBytes(data); err == nil {
+		player = validator.PlayerIndex() + 1
+	}
+
+	now := time.Now()
+	version := fmt.Sprintf("%d-%s.x%d", year, now.Format("20060102-150405"), player)
+	archived := &archivedOrder{
+		path: filepath.Join(gameDir, orderHistoryDirName, version),
+		receipt: orderReceipt{
+			ServerURL:  serverURL,
+			SessionID:  sessionID,
+			Year:       year,
+			Player:     player,
+			Hash:       filehash.ComputeHash(data),
+			Size:       len(data),
+			ArchivedAt: now,
+			Status:     orderStatusPending,
+		},
+	}
+
+	if err := safefile.WriteFile(archived.path, data, 0644); err != nil {
+		logger.Monitor.Warn().Err(err).Str("sessionID", sessionID).Int("year", year).Msg("Failed to archive order file")
+		return nil
+	}
+	archived.saveReceipt()
+	return archived
+}
+
+// uploaded records the outcome of the upload of an archived order
+func (o *archivedOrder) uploaded(err error) {
+	if o == nil {
+		return
+	}
+	if err != nil {
+		o.receipt.Status = orderStatusFailed
+		o.receipt.Error = err.Error()
+	} else {
+		now := time.Now()
+		o.receipt.Status = orderStatusUploaded
+		o.receipt.UploadedAt = &now
+	}
+	o.saveReceipt()
+}
+
+// saveReceipt writes the receipt of an archived order next to it
+func (o *archivedOrder) saveReceipt() {
+	data, err := jsoniter.MarshalIndent(o.receipt, "", "  ")
+	if err == nil {
+		err = safefile.WriteFile(o.path+".json", data, 0644)
+	}
+	if err != nil {
+		logger.Monitor.Warn().Err(err).Str("path", o.path).Msg("Failed to save order receipt")
+	}
+}
+
+// ListOrderHistory returns the order files uploaded for a session, newest first
+func (a *App) ListOrderHistory(serverURL, sessionID string) ([]OrderVersionInfo, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	historyDir := filepath.Join(gameDir, orderHistoryDirName)
+
+	entries, err := os.ReadDir(historyDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []OrderVersionInfo{}, nil
+		}
+		return nil, fmt.Errorf("failed to read order history: %w", err)
+	}
+
+	result := []OrderVersionInfo{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(historyDir, name))
+		if err != nil {
+			continue
+		}
+		var receipt orderReceipt
+		if err := jsoniter.Unmarshal(data, &receipt); err != nil {
+			logger.App.Warn().Err(err).Str("file", name).Msg("Failed to read order receipt")
+			continue
+		}
+
+		info := OrderVersionInfo{
+			Version:    strings.TrimSuffix(name, ".json"),
+			Year:       receipt.Year,
+			Player:     receipt.Player,
+			Hash:       receipt.Hash,
+			Size:       receipt.Size,
+			ArchivedAt: receipt.ArchivedAt.Format(time.RFC3339),
+			Status:     receipt.Status,
+			Error:      receipt.Error,
+		}
+		if receipt.UploadedAt != nil {
+			info.UploadedAt = receipt.UploadedAt.Format(time.RFC3339)
+		}
+		result = append(result, info)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ArchivedAt > result[j].ArchivedAt
+	})
+	return result, nil
+}
+
+// DownloadOrderVersion returns an archived order file of a session, base64 encoded
+func (a *App) DownloadOrderVersion(serverURL, sessionID, version string) (string, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	// Only the base name is used, the file must be in the order history
+	data, err := os.ReadFile(filepath.Join(gameDir, orderHistoryDirName, filepath.Base(version)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read archived order: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
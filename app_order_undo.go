@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// ORDER UNDO
+// =============================================================================
+
+// orderUndoWindow is how long an automatic order upload can be undone
+const orderUndoWindow = 30 * time.Second
+
+// pendingUndo is an order upload that can still be undone
+type pendingUndo struct {
+	serverURL string
+	sessionID string
+	year      int
+	expiresAt time.Time
+}
+
+// registerOrderUndo makes an order upload undoable for orderUndoWindow and
+// returns its undo token, "" if none could be made
+func (a *App) registerOrderUndo(serverURL, sessionID string, year int) string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to generate order undo token")
+		return ""
+	}
+	token := hex.EncodeToString(buf)
+
+	now := time.Now()
+	a.undoMu.Lock()
+	defer a.undoMu.Unlock()
+	for t, u := range a.undos {
+		if now.After(u.expiresAt) {
+			delete(a.undos, t)
+		}
+	}
+	a.undos[token] = pendingUndo{serverURL: serverURL, sessionID: sessionID, year: year, expiresAt: now.Add(orderUndoWindow)}
+	return token
+}
+
+// UndoSubmission retracts an order upload while its undo window is open, with
+// the token of its "order:submitted" event, on servers allowing it. The order
+// file is uploaded again when next saved. Emits "order:retracted" (serverURL,
+// sessionID, year).
+func (a *App) UndoSubmission(token string) error {
+	a.undoMu.Lock()
+	undo, ok := a.undos[token]
+	delete(a.undos, token)
+	a.undoMu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("unknown undo token")
+	}
+	if time.Now().After(undo.expiresAt) {
+		return fmt.Errorf("the upload can no longer be undone")
+	}
+
+	client, mgr, err := a.sessionConnection(undo.serverURL, undo.sessionID)
+	if err != nil {
+		return err
+	}
+	if err := client.RetractTurn(mgr.GetContext(), undo.sessionID, undo.year); err != nil {
+		if errors.Is(err, api.ErrNotSupported) {
+			return fmt.Errorf("this server does not allow retracting orders")
+		}
+		return fmt.Errorf("failed to retract orders: %w", err)
+	}
+
+	// Without the uploaded hash, the next save of the order file is uploaded
+	if err := a.fileHashTracker.ForgetFile(undo.serverURL, undo.sessionID, fmt.Sprintf("order:%d", undo.year)); err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", undo.sessionID).Msg("Failed to forget uploaded order hash")
+	}
+
+	logger.App.Info().Str("sessionId", undo.sessionID).Int("year", undo.year).Msg("Retracted order upload")
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("order:retracted", undo.serverURL, undo.sessionID, undo.year)
+	}
+	return nil
+}
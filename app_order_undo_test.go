@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+func TestMain(m *testing.M) {
+	// Initialize logger for tests
+	logger.Init(false)
+	os.Exit(m.Run())
+}
+
+func newUndoTestApp() *App {
+	return &App{undos: make(map[string]pendingUndo)}
+}
+
+func TestUndoSubmission_InsideWindow(t *testing.T) {
+	a := newUndoTestApp()
+
+	token := a.registerOrderUndo("https://test.server.com", "session-123", 2400)
+	require.NotEmpty(t, token)
+
+	// The undo is accepted and goes on to retract on the server, which fails
+	// here as no server is connected
+	err := a.UndoSubmission(token)
+	assert.ErrorIs(t, err, ErrNotConnected)
+
+	// A token can only be used once
+	err = a.UndoSubmission(token)
+	assert.EqualError(t, err, "unknown undo token")
+}
+
+func TestUndoSubmission_AfterWindow(t *testing.T) {
+	a := newUndoTestApp()
+
+	token := a.registerOrderUndo("https://test.server.com", "session-123", 2400)
+	require.NotEmpty(t, token)
+
+	// Move the upload out of its undo window
+	undo := a.undos[token]
+	undo.expiresAt = time.Now().Add(-time.Second)
+	a.undos[token] = undo
+
+	err := a.UndoSubmission(token)
+	assert.EqualError(t, err, "the upload can no longer be undone")
+	assert.NotContains(t, a.undos, token)
+}
+
+func TestUndoSubmission_UnknownToken(t *testing.T) {
+	a := newUndoTestApp()
+
+	err := a.UndoSubmission("not-a-token")
+	assert.EqualError(t, err, "unknown undo token")
+}
+
+func TestRegisterOrderUndo_DropsExpired(t *testing.T) {
+	a := newUndoTestApp()
+	a.undos["old"] = pendingUndo{expiresAt: time.Now().Add(-orderUndoWindow)}
+
+	token := a.registerOrderUndo("https://test.server.com", "session-123", 2400)
+	require.NotEmpty(t, token)
+
+	assert.NotContains(t, a.undos, "old")
+	assert.WithinDuration(t, time.Now().Add(orderUndoWindow), a.undos[token].expiresAt, time.Second)
+}
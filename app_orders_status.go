@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/model"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// =============================================================================
+// LIVE ORDER STATUS
+// =============================================================================
+
+// GetCachedOrdersStatus returns the last known order status of a session, fetching
+// it on first use. The cache follows order_status and new turn notifications, and
+// every change is emitted as "orders:status" (serverURL, sessionID, status).
+func (a *App) GetCachedOrdersStatus(serverURL, sessionID string) (*OrdersStatusInfo, error) {
+	a.mu.RLock()
+	info, ok := a.ordersStatus[serverURL+"|"+sessionID]
+	a.mu.RUnlock()
+
+	if ok {
+		return info, nil
+	}
+	return a.GetOrdersStatus(serverURL, sessionID)
+}
+
+// refreshOrdersStatus refetches the order status of a session and emits it.
+// With onlyCached, sessions nobody looked at yet are left alone.
+func (a *App) refreshOrdersStatus(serverURL, sessionID string, onlyCached bool) {
+	a.mu.RLock()
+	_, cached := a.ordersStatus[serverURL+"|"+sessionID]
+	a.mu.RUnlock()
+
+	if onlyCached && !cached {
+		return
+	}
+
+	a.mu.RLock()
+	previous := a.ordersStatus[serverURL+"|"+sessionID]
+	a.mu.RUnlock()
+
+	info, err := a.GetOrdersStatus(serverURL, sessionID)
+	if err != nil {
+		logger.App.Debug().Err(err).Str("sessionId", sessionID).Msg("Failed to refresh order status")
+		return
+	}
+	a.recordPlayerSubmissions(serverURL, sessionID, previous, info)
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("orders:status", serverURL, sessionID, info)
+	}
+
+	a.followHostedGeneration(serverURL, sessionID, info)
+}
+
+// recordPlayerSubmissions adds the players whose orders came in since the
+// previous status of the same year to the session timeline. Our own uploads
+// are already there.
+func (a *App) recordPlayerSubmissions(serverURL, sessionID string, previous, current *OrdersStatusInfo) {
+	if previous == nil || previous.PendingYear != current.PendingYear {
+		return
+	}
+	before := make(map[int]bool, len(previous.Players))
+	for _, p := range previous.Players {
+		before[p.PlayerOrder] = p.Submitted
+	}
+	_, own, isPlayer := a.ownPlayerOrder(serverURL, sessionID)
+
+	for _, p := range current.Players {
+		if !p.Submitted || before[p.PlayerOrder] || (isPlayer && p.PlayerOrder == own) {
+			continue
+		}
+		a.recordTimelineEvent(serverURL, sessionID, model.TimelineEvent{
+			Type:   model.TimelinePlayerOrders,
+			Time:   time.Now(),
+			Year:   current.PendingYear,
+			Detail: p.Nickname,
+		})
+	}
+}
+
+// ownPlayerOrder returns the last known state of a session and our 0-indexed
+// player in it, and whether we play in it. No server call is made.
+func (a *App) ownPlayerOrder(serverURL, sessionID string) (*SessionInfo, int, bool) {
+	a.mu.RLock()
+	mgr, ok := a.authManagers[serverURL]
+	a.mu.RUnlock()
+	if !ok {
+		return nil, 0, false
+	}
+	if nickname, err := a.config.GetSessionCredential(serverURL, sessionID); err == nil && nickname != "" {
+		a.altConnMu.Lock()
+		conn, ok := a.altConnections[altConnectionKey(serverURL, nickname)]
+		a.altConnMu.Unlock()
+		if ok {
+			mgr = conn.mgr
+		}
+	}
+	userInfo := mgr.GetUserInfo()
+	session, ok := a.cachedSession(serverURL, sessionID)
+	if userInfo == nil || !ok {
+		return nil, 0, false
+	}
+
+	for _, p := range session.Players {
+		if p.UserProfileID == userInfo.User.ID {
+			return session, p.PlayerOrder, true
+		}
+	}
+	return session, 0, false
+}
+
+// NudgePlayer prepares a reminder for a player who has not submitted orders yet
+// (session hosts only). The server has no reminder or chat endpoint, so the
+// reminder is copied to the clipboard for the host to post in the game's chat;
+// the text is also returned.
+func (a *App) NudgePlayer(serverURL, sessionID string, playerOrder int) (string, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return "", fmt.Errorf("no user info available")
+	}
+
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get session: %w", err)
+	}
+	if !containsString(session.Managers, userInfo.User.ID) {
+		return "", fmt.Errorf("only session hosts can nudge players")
+	}
+
+	status, err := a.GetOrdersStatus(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	var player *PlayerOrderStatusInfo
+	for i := range status.Players {
+		if status.Players[i].PlayerOrder == playerOrder {
+			player = &status.Players[i]
+			break
+		}
+	}
+	if player == nil {
+		return "", fmt.Errorf("player %d not found in session", playerOrder)
+	}
+	if player.IsBot {
+		return "", fmt.Errorf("%s is an AI player", player.Nickname)
+	}
+	if player.Submitted {
+		return "", fmt.Errorf("%s already submitted orders for %d", player.Nickname, status.PendingYear)
+	}
+
+	text := fmt.Sprintf("@%s reminder: your orders for %s (year %d) are still pending, the turn is waiting on you!",
+		player.Nickname, session.Name, status.PendingYear)
+
+	if err := runtime.ClipboardSetText(a.ctx, text); err != nil {
+		return "", fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Int("playerOrder", playerOrder).
+		Msg("Prepared player reminder")
+
+	return text, nil
+}
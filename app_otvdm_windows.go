@@ -0,0 +1,224 @@
+//go:build windows
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// otvdmReleaseURL is the GitHub API endpoint of the latest winevdm release.
+// Releases are used rather than the CI builds recommended in the help text
+// because only releases come with a published checksum.
+const otvdmReleaseURL = "https://api.github.com/repos/otya128/winevdm/releases/latest"
+
+// otvdmInstallTimeout bounds the whole download and install
+const otvdmInstallTimeout = 5 * time.Minute
+
+// otvdmRelease is the part of a GitHub release we use
+type otvdmRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name        string `json:"name"`
+		DownloadURL string `json:"browser_download_url"`
+		Digest      string `json:"digest"` // "sha256:<hex>"
+	} `json:"assets"`
+}
+
+// InstallOtvdm downloads the latest winevdm release, verifies its checksum,
+// registers it (asking for administrator rights) and checks 16-bit support again
+func (a *App) InstallOtvdm() (*NtvdmCheckResult, error) {
+	if found, _ := checkOtvdmRegistry(); found {
+		return a.CheckNtvdmSupport()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), otvdmInstallTimeout)
+	defer cancel()
+
+	release, err := fetchOtvdmRelease(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var downloadURL, checksum, assetName string
+	for _, asset := range release.Assets {
+		if strings.HasPrefix(asset.Name, "otvdm-") && strings.HasSuffix(asset.Name, ".zip") {
+			downloadURL, assetName = asset.DownloadURL, asset.Name
+			checksum, _ = strings.CutPrefix(asset.Digest, "sha256:")
+			break
+		}
+	}
+	if downloadURL == "" {
+		return nil, fmt.Errorf("no winevdm download found in release %s", release.TagName)
+	}
+	if checksum == "" {
+		return nil, fmt.Errorf("winevdm release %s has no published checksum, install it manually", release.TagName)
+	}
+
+	logger.App.Info().Str("release", release.TagName).Str("asset", assetName).Msg("Downloading winevdm")
+
+	data, err := downloadOtvdm(ctx, downloadURL)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), checksum) {
+		return nil, fmt.Errorf("winevdm download checksum mismatch, not installing it")
+	}
+
+	// Kept installed from the config directory: the registration points there
+	installDir := filepath.Join(astrum.ConfigPath(), "otvdm", release.TagName)
+	if err := extractOtvdm(data, installDir); err != nil {
+		return nil, err
+	}
+
+	inf, err := findOtvdmInf(installDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registering writes to HKLM: run the INF install elevated and wait for it
+	script := fmt.Sprintf(
+		`Start-Process -FilePath rundll32.exe -ArgumentList 'setupapi.dll,InstallHinfSection DefaultInstall 128 "%s"' -Verb RunAs -Wait`,
+		strings.ReplaceAll(inf, "'", "''"))
+	cmd := exec.CommandContext(ctx, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to register winevdm: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	logger.App.Info().Str("path", installDir).Msg("Installed winevdm")
+	return a.CheckNtvdmSupport()
+}
+
+// fetchOtvdmRelease gets the latest winevdm release description
+func fetchOtvdmRelease(ctx context.Context) (*otvdmRelease, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, otvdmReleaseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get winevdm release: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get winevdm release: %s", resp.Status)
+	}
+
+	var release otvdmRelease
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return nil, fmt.Errorf("failed to read winevdm release: %w", err)
+	}
+	return &release, nil
+}
+
+// downloadOtvdm downloads the winevdm archive
+func downloadOtvdm(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download winevdm: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download winevdm: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download winevdm: %w", err)
+	}
+	return data, nil
+}
+
+// extractOtvdm extracts the winevdm archive into dir
+func extractOtvdm(data []byte, dir string) error {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("failed to open winevdm archive: %w", err)
+	}
+
+	for _, f := range zr.File {
+		dest := filepath.Join(dir, filepath.FromSlash(f.Name))
+		// Refuse entries escaping the install directory
+		if !strings.HasPrefix(dest, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid path in winevdm archive: %s", f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(dest, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := extractZipFile(f, dest); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// extractZipFile writes a file of an archive to dest
+func extractZipFile(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// findOtvdmInf returns the path of the winevdm install.inf, which may be in
+// a subdirectory of the archive
+func findOtvdmInf(dir string) (string, error) {
+	var inf string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(d.Name(), "install.inf") {
+			inf = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to look for install.inf: %w", err)
+	}
+	if inf == "" {
+		return "", fmt.Errorf("install.inf not found in the winevdm archive")
+	}
+	return inf, nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/neper-stars/astrum/api"
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// HOST ORDER UPLOAD
+// =============================================================================
+
+// UploadOrderForPlayer submits the order file of another player of a session,
+// for when their client is broken and they sent the .xN file to the host.
+// Managers only. fileOrB64 is the path of the order file or its base64
+// content. The file must hold the submitted orders of the player at
+// playerOrder (0-indexed) for the pending year. Returns that year.
+func (a *App) UploadOrderForPlayer(serverURL, sessionID string, playerOrder int, fileOrB64 string) (int, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return 0, fmt.Errorf("no user info available")
+	}
+
+	ctx := mgr.GetContext()
+	session, err := client.GetSession(ctx, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get session: %w", err)
+	}
+	if !slices.Contains(session.Managers, userInfo.User.ID) {
+		return 0, fmt.Errorf("only session hosts can upload orders for a player")
+	}
+
+	var player *api.SessionPlayer
+	for _, p := range session.Players {
+		if int(p.PlayerOrder) == playerOrder {
+			player = p
+			break
+		}
+	}
+	if player == nil {
+		return 0, fmt.Errorf("no player %d in this session", playerOrder+1)
+	}
+	if player.IsBot {
+		return 0, fmt.Errorf("player %d is played by the AI", playerOrder+1)
+	}
+
+	data, err := readOrderInput(fileOrB64)
+	if err != nil {
+		return 0, err
+	}
+	validator, err := astrum.NewOrderValidatorFromBytes(data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse order file: %w", err)
+	}
+	if !validator.TurnIsSubmitted() {
+		return 0, fmt.Errorf("turn not submitted in this order file")
+	}
+	if validator.PlayerIndex() != playerOrder {
+		return 0, fmt.Errorf("order file is for player %d, not player %d", validator.PlayerIndex()+1, playerOrder+1)
+	}
+
+	latestTurn, err := client.GetLatestTurn(ctx, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest turn from server: %w", err)
+	}
+	year := validator.Year()
+	if err := a.checkOrderYear(serverURL, sessionID, client, year, int(latestTurn.Year)); err != nil {
+		return 0, err
+	}
+
+	order := &api.Order{B64Data: base64.StdEncoding.EncodeToString(data)}
+	if err := client.SubmitTurnForPlayer(ctx, sessionID, year, playerOrder, order); err != nil {
+		if errors.Is(err, api.ErrNotSupported) {
+			return 0, fmt.Errorf("this server does not allow hosts to upload orders for a player")
+		}
+		return 0, fmt.Errorf("failed to submit orders: %w", err)
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Int("year", year).
+		Int("playerOrder", playerOrder).
+		Msg("Uploaded orders for player")
+
+	go a.refreshOrdersStatus(serverURL, sessionID, false)
+	return year, nil
+}
+
+// readOrderInput returns the content of an order file given its path or its
+// base64 content
+func readOrderInput(fileOrB64 string) ([]byte, error) {
+	if info, err := os.Stat(fileOrB64); err == nil && info.Mode().IsRegular() {
+		data, err := os.ReadFile(fileOrB64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read order file: %w", err)
+		}
+		return data, nil
+	}
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(fileOrB64))
+	if err != nil {
+		return nil, fmt.Errorf("not an order file path nor base64 data")
+	}
+	return data, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	hs "github.com/neper-stars/houston"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/plugins"
+)
+
+// =============================================================================
+// TURN POST-PROCESSING PLUGINS
+// =============================================================================
+
+// ListPlugins returns the plugins found in the plugins directory
+func (a *App) ListPlugins() ([]PluginInfo, error) {
+	found, err := plugins.Discover(astrum.PluginsPath())
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app settings: %w", err)
+	}
+
+	result := make([]PluginInfo, 0, len(found))
+	for _, p := range found {
+		result = append(result, PluginInfo{
+			Name:    p.Name,
+			Path:    p.Path,
+			Enabled: containsString(settings.EnabledPlugins, p.Name),
+		})
+	}
+	return result, nil
+}
+
+// GetPluginsDir returns the plugins directory, creating it if needed
+func (a *App) GetPluginsDir() (string, error) {
+	dir := astrum.PluginsPath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create plugins directory: %w", err)
+	}
+	return dir, nil
+}
+
+// SetPluginEnabled enables or disables a plugin
+func (a *App) SetPluginEnabled(name string, enabled bool) ([]PluginInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	if err := a.config.SetPluginEnabled(name, enabled); err != nil {
+		return nil, fmt.Errorf("failed to set plugin: %w", err)
+	}
+
+	logger.App.Info().Str("plugin", name).Bool("enabled", enabled).Msg("Set plugin")
+
+	return a.ListPlugins()
+}
+
+// RunPlugins runs the enabled plugins on the local turn of a session
+func (a *App) RunPlugins(serverURL, sessionID string) ([]PluginRunInfo, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	turnFile, err := findTurnFile(gameDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.runPlugins(serverURL, sessionID, gameDir, turnFile)
+}
+
+// ListPluginArtifacts lists the files produced by plugins for a session
+func (a *App) ListPluginArtifacts(serverURL, sessionID string) ([]PluginArtifactInfo, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	root := filepath.Join(gameDir, "plugins")
+	files, err := plugins.ListArtifacts(root)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]PluginArtifactInfo, 0, len(files))
+	for _, rel := range files {
+		// Artifacts live in <plugin>/<year>/<name>
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) < 3 {
+			continue
+		}
+		year, _ := strconv.Atoi(parts[1])
+		result = append(result, PluginArtifactInfo{
+			Plugin: parts[0],
+			Year:   year,
+			Name:   filepath.Join(parts[2:]...),
+			Path:   filepath.Join(root, rel),
+		})
+	}
+	return result, nil
+}
+
+// runPluginsAfterDownload runs the enabled plugins on a freshly downloaded turn
+func (a *App) runPluginsAfterDownload(serverURL, sessionID, gameDir, turnFile string) {
+	runs, err := a.runPlugins(serverURL, sessionID, gameDir, turnFile)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to run plugins")
+		return
+	}
+	if len(runs) == 0 {
+		return
+	}
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("plugins:finished", serverURL, sessionID, runs)
+	}
+}
+
+// runPlugins runs every enabled plugin on a turn file of gameDir
+func (a *App) runPlugins(serverURL, sessionID, gameDir, turnFile string) ([]PluginRunInfo, error) {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app settings: %w", err)
+	}
+	if len(settings.EnabledPlugins) == 0 {
+		return nil, nil
+	}
+
+	found, err := plugins.Discover(astrum.PluginsPath())
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := os.ReadFile(turnFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read turn file: %w", err)
+	}
+	header, err := hs.FileData(raw).FileHeader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse turn file: %w", err)
+	}
+	year := header.Year()
+
+	var runs []PluginRunInfo
+	for _, p := range found {
+		if !containsString(settings.EnabledPlugins, p.Name) {
+			continue
+		}
+
+		outputDir := filepath.Join(gameDir, "plugins", p.Name, strconv.Itoa(year))
+		info := PluginRunInfo{Plugin: p.Name, Year: year, Artifacts: []string{}}
+
+		run, err := plugins.Execute(p, plugins.Input{
+			UniverseFile: filepath.Join(gameDir, "game.xy"),
+			TurnFile:     turnFile,
+			Year:         year,
+			OutputDir:    outputDir,
+		})
+		if err != nil {
+			info.Error = err.Error()
+		} else {
+			info.ExitCode = run.Result.ExitCode
+			info.TimedOut = run.Result.TimedOut
+			info.Output = run.Result.Output
+			for _, artifact := range run.Artifacts {
+				info.Artifacts = append(info.Artifacts, filepath.Join(p.Name, strconv.Itoa(year), artifact))
+			}
+		}
+
+		logger.App.Info().
+			Str("sessionId", sessionID).
+			Str("plugin", p.Name).
+			Int("year", year).
+			Int("artifacts", len(info.Artifacts)).
+			Str("error", info.Error).
+			Msg("Ran plugin")
+
+		runs = append(runs, info)
+	}
+
+	return runs, nil
+}
+
+// sessionGameDir returns the game directory of a session
+func (a *App) sessionGameDir(serverURL, sessionID string) (string, error) {
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL // fallback to URL if server not found
+	if server != nil {
+		serverName = server.Name
+	}
+
+	gameDir, err := a.config.GetSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get game directory: %w", err)
+	}
+	return gameDir, nil
+}
@@ -0,0 +1,709 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	"slices"
+	"strings"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/api/models"
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// JOIN PREFLIGHT
+// =============================================================================
+
+// Display names for the ruleset enums, indexed by their API value
+var (
+	universeSizeNames     = []string{"Tiny", "Small", "Medium", "Large", "Huge"}
+	densityNames          = []string{"Sparse", "Normal", "Dense", "Packed"}
+	startingDistanceNames = []string{"Close", "Moderate", "Farther", "Distant"}
+)
+
+// lowHabitableFraction is the share of habitable planets under which a race is
+// flagged as a risky fit for sparse or spread-out universes
+const lowHabitableFraction = 0.05
+
+// enumName returns the display name of an enum value, or its number if unknown
+func enumName(names []string, value int64) string {
+	if value >= 0 && int(value) < len(names) {
+		return names[value]
+	}
+	return fmt.Sprintf("%d", value)
+}
+
+// GetJoinPreflight gathers what is worth knowing before joining a session: hosts,
+// player counts, turn cadence, universe settings, notable rules and, when raceID
+// is not empty, how well that race fits the universe.
+func (a *App) GetJoinPreflight(serverURL, sessionID, raceID string) (*JoinPreflightInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	info := &JoinPreflightInfo{
+		SessionID:      session.ID,
+		SessionName:    session.Name,
+		State:          session.State,
+		Hosts:          []string{},
+		MemberCount:    len(session.Members),
+		PlayerCount:    len(session.Players),
+		RulesIsSet:     session.RulesIsSet,
+		RuleHighlights: []string{},
+		Warnings:       []string{},
+	}
+
+	for _, p := range session.Players {
+		if p.Ready {
+			info.ReadyCount++
+		}
+	}
+
+	if session.State == models.SessionStateStarted {
+		info.Warnings = append(info.Warnings, "The game has already started")
+	}
+
+	if userInfo := mgr.GetUserInfo(); userInfo != nil && !slices.Contains(session.Members, userInfo.User.ID) {
+		capacity, err := a.fetchSessionCapacity(serverURL, client, mgr, sessionID)
+		if err != nil {
+			logger.App.Warn().Err(err).Msg("GetJoinPreflight: failed to get session capacity")
+		}
+		if sessionIsFull(capacity, len(session.Members), userInfo.User.Nickname) {
+			info.Warnings = append(info.Warnings, "The session is full")
+		}
+	}
+
+	// Resolve host nicknames
+	profiles, err := a.getCachedUserProfiles(serverURL, client, mgr)
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("GetJoinPreflight: failed to get user profiles")
+	}
+	nicknames := make(map[string]string, len(profiles))
+	for _, p := range profiles {
+		nicknames[p.ID] = p.Nickname
+	}
+	for _, id := range session.Managers {
+		if name, ok := nicknames[id]; ok {
+			info.Hosts = append(info.Hosts, name)
+		} else {
+			info.Hosts = append(info.Hosts, id)
+		}
+	}
+
+	// Turn cadence, unknown (0) when the host set no schedule or the server has none
+	schedule, err := client.GetSessionSchedule(mgr.GetContext(), sessionID)
+	if err != nil && !errors.Is(err, api.ErrNotSupported) {
+		logger.App.Warn().Err(err).Msg("GetJoinPreflight: failed to get session schedule")
+	}
+	if schedule != nil {
+		info.CadenceHours = schedule.CadenceHours
+	}
+
+	var rules *api.Ruleset
+	if session.RulesIsSet {
+		rules, err = client.GetRules(mgr.GetContext(), sessionID)
+		if err != nil {
+			logger.App.Warn().Err(err).Msg("GetJoinPreflight: failed to get rules")
+		}
+	} else {
+		info.Warnings = append(info.Warnings, "The host has not set the rules yet")
+	}
+
+	if rules != nil {
+		info.UniverseSize = enumName(universeSizeNames, rules.UniverseSize)
+		info.Density = enumName(densityNames, rules.Density)
+		info.StartingDistance = enumName(startingDistanceNames, rules.StartingDistance)
+		info.RuleHighlights = ruleHighlights(rules)
+	}
+
+	if raceID != "" {
+		fit, err := a.raceFit(serverURL, raceID)
+		if err != nil {
+			return nil, err
+		}
+		info.Race = fit
+
+		if !fit.IsValid {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("Race %s is not valid", fit.Name))
+		}
+		if fit.Points < 0 {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("Race %s has negative advantage points", fit.Name))
+		}
+		if rules != nil && fit.HabitableFraction < lowHabitableFraction &&
+			(rules.Density == 0 || rules.StartingDistance >= 2) {
+			info.Warnings = append(info.Warnings, fmt.Sprintf(
+				"Race %s can only live on about %.0f%% of planets, which is tight in a %s universe with %s starting distance",
+				fit.Name, fit.HabitableFraction*100, info.Density, info.StartingDistance))
+		}
+	}
+
+	return info, nil
+}
+
+// ruleHighlights lists the non-default game options and victory conditions of a ruleset
+func ruleHighlights(r *api.Ruleset) []string {
+	highlights := []string{}
+	options := []struct {
+		enabled bool
+		label   string
+	}{
+		{r.MaximumMinerals, "Maximum minerals"},
+		{r.SlowerTechAdvances, "Slower tech advances"},
+		{r.AcceleratedBbsPlay, "Accelerated BBS play"},
+		{r.NoRandomEvents, "No random events"},
+		{r.ComputerPlayersFormAlliances, "Computer players form alliances"},
+		{r.PublicPlayerScores, "Public player scores"},
+		{r.GalaxyClumping, "Galaxy clumping"},
+	}
+	for _, o := range options {
+		if o.enabled {
+			highlights = append(highlights, o.label)
+		}
+	}
+
+	info := convertRuleset(r)
+	victory := []struct {
+		enabled bool
+		label   string
+	}{
+		{info.VcOwnsPercentOfPlanets, fmt.Sprintf("Victory: own %d%% of planets", info.VcOwnsPercentOfPlanetsValue)},
+		{info.VcAttainTechInFields, fmt.Sprintf("Victory: tech %d in %d fields", info.VcAttainTechInFieldsTechValue, info.VcAttainTechInFieldsFieldsValue)},
+		{info.VcExceedScoreOf, fmt.Sprintf("Victory: score over %d", info.VcExceedScoreOfValue)},
+		{info.VcExceedNextPlayerScoreBy, fmt.Sprintf("Victory: lead by %d%%", info.VcExceedNextPlayerScoreByValue)},
+		{info.VcHasProductionCapacityOf, fmt.Sprintf("Victory: production of %dk", info.VcHasProductionCapacityOfValue)},
+		{info.VcOwnsCapitalShips, fmt.Sprintf("Victory: own %d capital ships", info.VcOwnsCapitalShipsValue)},
+		{info.VcHaveHighestScoreAfterYears, fmt.Sprintf("Victory: highest score after %d years", info.VcHaveHighestScoreAfterYearsValue)},
+	}
+	for _, v := range victory {
+		if v.enabled {
+			highlights = append(highlights, v.label)
+		}
+	}
+
+	return highlights
+}
+
+// raceFit loads one of our races and evaluates its points and habitability
+func (a *App) raceFit(serverURL, raceID string) (*RaceFitInfo, error) {
+	config, err := a.LoadRaceFileConfig(serverURL, raceID)
+	if err != nil {
+		return nil, err
+	}
+
+	validation := a.ValidateRaceConfig(config)
+
+	return &RaceFitInfo{
+		RaceID:            raceID,
+		Name:              config.PluralName,
+		Points:            validation.Points,
+		IsValid:           validation.IsValid,
+		HabitableFraction: habitableFraction(config),
+		Habitability:      validation.Habitability,
+	}, nil
+}
+
+// habitableFraction estimates the share of planets within a race's habitability
+// ranges, assuming planet environments are evenly spread over the 0-100 scale
+func habitableFraction(config RaceConfig) float64 {
+	axis := func(immune bool, center, width int) float64 {
+		if immune {
+			return 1
+		}
+		span := clamp(center+width, 0, 100) - clamp(center-width, 0, 100)
+		return float64(span) / 100
+	}
+
+	return axis(config.GravityImmune, config.GravityCenter, config.GravityWidth) *
+		axis(config.TemperatureImmune, config.TemperatureCenter, config.TemperatureWidth) *
+		axis(config.RadiationImmune, config.RadiationCenter, config.RadiationWidth)
+}
+
+// =============================================================================
+// READY CHECKLIST
+// =============================================================================
+
+// racePasswordCheck checks that the race file is password protected. It only
+// fails when the house rules of the session require a password, servers
+// without house rules require none.
+func (a *App) racePasswordCheck(ctx context.Context, client *api.Client, sessionID string, raceSelected bool, raceData string) ReadyCheckInfo {
+	check := ReadyCheckInfo{ID: "race_password", Label: "Race password set if required"}
+	if !raceSelected {
+		check.Skipped = true
+		return check
+	}
+	pb, err := racePlayerBlock(raceData)
+	if err != nil {
+		check.Skipped = true
+		return check
+	}
+	if pb.HasPassword() {
+		check.Passed = true
+		return check
+	}
+
+	rules, err := client.GetSessionHouseRules(ctx, sessionID)
+	switch {
+	case errors.Is(err, api.ErrNotSupported):
+		check.Passed = true
+	case err != nil:
+		check.Skipped = true
+		check.Message = fmt.Sprintf("House rules cannot be read: %v", err)
+	case rules.RequireRacePassword:
+		check.Message = "The house rules of this session require a race password - set one in the race file"
+	default:
+		check.Passed = true
+	}
+	return check
+}
+
+// readyCheckError returns the error of a checklist with failed checks, listing their messages
+func readyCheckError(checklist *ReadyChecklistInfo) error {
+	var failed []string
+	for _, c := range checklist.Checks {
+		if !c.Passed && !c.Skipped {
+			failed = append(failed, c.Message)
+		}
+	}
+	return fmt.Errorf("not ready: %s", strings.Join(failed, "; "))
+}
+
+// GetReadyChecklist runs the checks required before marking ourselves ready in a session:
+// race selected and valid, race points not negative, race password set when the
+// house rules require one, game directory writable and, when Wine is used, Wine
+// installation validated.
+func (a *App) GetReadyChecklist(serverURL, sessionID string) (*ReadyChecklistInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	checklist := &ReadyChecklistInfo{Checks: []ReadyCheckInfo{}}
+	add := func(check ReadyCheckInfo) {
+		checklist.Checks = append(checklist.Checks, check)
+	}
+
+	// Race selected
+	race, err := client.GetSessionPlayerRace(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get player race: %w", err)
+	}
+	raceSelected := race.ID != "" && race.Data != ""
+	check := ReadyCheckInfo{ID: "race_selected", Label: "Race selected", Passed: raceSelected}
+	if !raceSelected {
+		check.Message = "No race selected - please select a race first"
+	}
+	add(check)
+
+	// Race valid and points
+	validCheck := ReadyCheckInfo{ID: "race_valid", Label: "Race file is valid"}
+	pointsCheck := ReadyCheckInfo{ID: "race_points", Label: "Race advantage points are not negative"}
+	if !raceSelected {
+		validCheck.Skipped = true
+		pointsCheck.Skipped = true
+	} else if config, err := raceConfigFromData(race.Data); err != nil {
+		validCheck.Message = fmt.Sprintf("Race file cannot be read: %v", err)
+		pointsCheck.Skipped = true
+	} else {
+		validation := a.ValidateRaceConfig(config)
+		validCheck.Passed = validation.IsValid
+		if !validation.IsValid {
+			messages := make([]string, len(validation.Errors))
+			for i, e := range validation.Errors {
+				messages[i] = e.Message
+			}
+			validCheck.Message = fmt.Sprintf("Race %s is invalid: %s", config.PluralName, strings.Join(messages, ", "))
+		}
+		pointsCheck.Passed = validation.Points >= 0
+		if !pointsCheck.Passed {
+			pointsCheck.Message = fmt.Sprintf("Race %s has %d advantage points left", config.PluralName, validation.Points)
+		}
+	}
+	add(validCheck)
+	add(pointsCheck)
+
+	// Race password, when the house rules of the session require one
+	add(a.racePasswordCheck(mgr.GetContext(), client, sessionID, raceSelected, race.Data))
+
+	// Game directory writable
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL
+	if server != nil {
+		serverName = server.Name
+	}
+	dirCheck := ReadyCheckInfo{ID: "game_dir_writable", Label: "Game directory is writable"}
+	if gameDir, err := a.config.EnsureSessionGameDir(serverName, sessionID); err != nil {
+		dirCheck.Message = fmt.Sprintf("Game directory cannot be created: %v", err)
+	} else if f, err := os.CreateTemp(gameDir, ".astrum-write-check-*"); err != nil {
+		dirCheck.Message = fmt.Sprintf("Game directory is not writable: %v", err)
+	} else {
+		name := f.Name()
+		_ = f.Close()
+		_ = os.Remove(name)
+		dirCheck.Passed = true
+	}
+	add(dirCheck)
+
+	// Wine validated (only when Stars! cannot run natively or in the browser)
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app settings: %w", err)
+	}
+	wineCheck := ReadyCheckInfo{ID: "wine_validated", Label: "Wine installation validated"}
+	switch {
+	case settings.GetUseWine():
+		wineCheck.Passed = settings.GetValidWineInstall()
+		if !wineCheck.Passed {
+			wineCheck.Message = "Wine installation not validated, run 'Check Wine Installation' in Settings"
+		}
+	case goruntime.GOOS == "windows" || settings.GetEnableBrowserStars():
+		wineCheck.Skipped = true
+	default:
+		wineCheck.Message = fmt.Sprintf("Wine is required to run Stars! on %s, enable it in Settings", goruntime.GOOS)
+	}
+	add(wineCheck)
+
+	checklist.Ready = true
+	for _, c := range checklist.Checks {
+		if !c.Passed && !c.Skipped {
+			checklist.Ready = false
+			break
+		}
+	}
+
+	return checklist, nil
+}
+
+// =============================================================================
+// TURN GENERATION DRY-RUN
+// =============================================================================
+
+// maxStarsPlayers is the maximum number of players in a Stars! game
+const maxStarsPlayers = 16
+
+// ValidateTurnGeneration lets a host check that a session can be started or that
+// the next turn can be generated, returning every blocker found.
+// Before the game starts, rules and player readiness are checked. Once started, the
+// orders status of the pending year is checked with the server, and our own order
+// file is checked locally for year mismatches.
+func (a *App) ValidateTurnGeneration(serverURL, sessionID string) (*TurnGenerationCheckInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return nil, fmt.Errorf("no user info available")
+	}
+
+	ctx := mgr.GetContext()
+	session, err := client.GetSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	result := &TurnGenerationCheckInfo{
+		SessionID: sessionID,
+		Blockers:  []TurnGenerationBlockerInfo{},
+	}
+	block := func(b TurnGenerationBlockerInfo) {
+		result.Blockers = append(result.Blockers, b)
+	}
+
+	switch session.State {
+	case models.SessionStatePending:
+		result.Stage = "start"
+		a.validateGameStart(serverURL, session, block)
+	case models.SessionStateStarted:
+		result.Stage = "turn"
+		year, err := a.validateTurnOrders(serverURL, session, userInfo.User.ID, block)
+		if err != nil {
+			return nil, err
+		}
+		result.Year = year
+	default:
+		block(TurnGenerationBlockerInfo{
+			Code:    "session_state",
+			Message: fmt.Sprintf("Session is %s", session.State),
+		})
+	}
+
+	result.CanGenerate = len(result.Blockers) == 0
+	return result, nil
+}
+
+// validateGameStart reports what prevents a pending session from being started
+func (a *App) validateGameStart(serverURL string, session *api.Session, block func(TurnGenerationBlockerInfo)) {
+	a.mu.RLock()
+	client := a.clients[serverURL]
+	mgr := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	nicknames := make(map[string]string)
+	if profiles, err := a.getCachedUserProfiles(serverURL, client, mgr); err == nil {
+		for _, p := range profiles {
+			nicknames[p.ID] = p.Nickname
+		}
+	}
+
+	if !session.RulesIsSet {
+		block(TurnGenerationBlockerInfo{Code: "rules_not_set", Message: "Rules have not been set"})
+	}
+
+	if len(session.Players) == 0 {
+		block(TurnGenerationBlockerInfo{Code: "no_players", Message: "No player has selected a race"})
+	}
+	if len(session.Players) > maxStarsPlayers {
+		block(TurnGenerationBlockerInfo{
+			Code:    "too_many_players",
+			Message: fmt.Sprintf("Stars! supports at most %d players, session has %d", maxStarsPlayers, len(session.Players)),
+		})
+	}
+
+	// Members who never picked a race
+	hasRace := make(map[string]bool, len(session.Players))
+	for _, p := range session.Players {
+		hasRace[p.UserProfileID] = true
+	}
+	for _, id := range session.Members {
+		if !hasRace[id] {
+			block(TurnGenerationBlockerInfo{
+				Code:     "no_race",
+				Nickname: nicknames[id],
+				Message:  fmt.Sprintf("%s has not selected a race", displayName(nicknames, id)),
+			})
+		}
+	}
+
+	for _, p := range session.Players {
+		if p.IsBot || p.Ready {
+			continue
+		}
+		block(TurnGenerationBlockerInfo{
+			Code:        "not_ready",
+			PlayerOrder: int(p.PlayerOrder) + 1,
+			Nickname:    nicknames[p.UserProfileID],
+			Message:     fmt.Sprintf("Player %d (%s) is not ready", p.PlayerOrder+1, displayName(nicknames, p.UserProfileID)),
+		})
+	}
+}
+
+// validateTurnOrders reports missing orders for the pending year of a started session
+// and checks our local order file. Returns the pending year.
+func (a *App) validateTurnOrders(serverURL string, session *api.Session, myID string, block func(TurnGenerationBlockerInfo)) (int, error) {
+	a.mu.RLock()
+	client := a.clients[serverURL]
+	mgr := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	ctx := mgr.GetContext()
+	latestTurn, err := client.GetLatestTurn(ctx, session.ID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest turn: %w", err)
+	}
+	year := int(latestTurn.Year)
+
+	status, err := client.GetOrdersStatus(ctx, session.ID, year)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get orders status: %w", err)
+	}
+
+	for _, p := range status {
+		if p.IsBot || p.Submitted {
+			continue
+		}
+		block(TurnGenerationBlockerInfo{
+			Code:        "order_missing",
+			PlayerOrder: p.PlayerOrder + 1,
+			Nickname:    p.Nickname,
+			Message:     fmt.Sprintf("Player %d (%s) has not submitted orders for %d", p.PlayerOrder+1, p.Nickname, year),
+		})
+	}
+
+	// Check our own order file locally
+	playerNumber := findPlayerNumber(session, myID)
+	if playerNumber == 0 {
+		return year, nil
+	}
+
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL
+	if server != nil {
+		serverName = server.Name
+	}
+	gameDir, err := a.config.GetSessionGameDir(serverName, session.ID)
+	if err != nil {
+		return year, nil
+	}
+
+	orderPath := filepath.Join(gameDir, fmt.Sprintf("game.x%d", playerNumber))
+	if _, err := os.Stat(orderPath); err != nil {
+		return year, nil
+	}
+
+	validator, err := astrum.NewOrderValidator(orderPath)
+	if err != nil {
+		block(TurnGenerationBlockerInfo{
+			Code:        "order_invalid",
+			PlayerOrder: playerNumber,
+			Message:     fmt.Sprintf("Player %d order file cannot be read: %v", playerNumber, err),
+		})
+		return year, nil
+	}
+	if validator.Year() != year {
+		block(TurnGenerationBlockerInfo{
+			Code:        "order_year_mismatch",
+			PlayerOrder: playerNumber,
+			Message:     fmt.Sprintf("Player %d order file is for %d, pending year is %d", playerNumber, validator.Year(), year),
+		})
+	}
+
+	return year, nil
+}
+
+// displayName returns a nickname if known, the raw ID otherwise
+func displayName(nicknames map[string]string, id string) string {
+	if name, ok := nicknames[id]; ok && name != "" {
+		return name
+	}
+	return id
+}
+
+// =============================================================================
+// FIRST TURN BOOTSTRAP
+// =============================================================================
+
+// RunFirstTurnBootstrap walks a new player through getting their first turn ready:
+// it checks the game started, downloads the latest turn, verifies the race file,
+// makes sure stars.exe is present and Wine is usable. Each step is emitted as a
+// "bootstrap:step" event as it completes so the UI can follow along.
+// Steps after a failed step are still attempted when they do not depend on it.
+func (a *App) RunFirstTurnBootstrap(serverURL, sessionID string) (*FirstTurnStatusInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return nil, fmt.Errorf("no user info available")
+	}
+
+	status := &FirstTurnStatusInfo{SessionID: sessionID, Steps: []BootstrapStepInfo{}}
+	step := func(s BootstrapStepInfo) {
+		status.Steps = append(status.Steps, s)
+		a.mu.RLock()
+		shuttingDown := a.shuttingDown
+		a.mu.RUnlock()
+		if !shuttingDown {
+			a.emit("bootstrap:step", serverURL, sessionID, s)
+		}
+	}
+
+	// Game started
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.State != models.SessionStateStarted {
+		step(BootstrapStepInfo{
+			ID:      "game_started",
+			Label:   "Game started",
+			Message: "The host has not started the game yet",
+		})
+		return status, nil
+	}
+	step(BootstrapStepInfo{ID: "game_started", Label: "Game started", Done: true})
+
+	// Turn downloaded (GetLatestTurn saves the files into the game directory)
+	turnStep := BootstrapStepInfo{ID: "turn_downloaded", Label: "Turn downloaded"}
+	if turn, err := a.GetLatestTurn(serverURL, sessionID); err != nil {
+		turnStep.Message = err.Error()
+	} else {
+		status.Year = turn.Year
+		turnStep.Done = true
+		turnStep.Message = fmt.Sprintf("Year %d", turn.Year)
+	}
+	step(turnStep)
+
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL
+	if server != nil {
+		serverName = server.Name
+	}
+	gameDir, err := a.config.EnsureSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	// Race file present for our slot
+	raceStep := BootstrapStepInfo{ID: "race_file", Label: "Race file in place"}
+	playerNumber := findPlayerNumber(session, userInfo.User.ID)
+	if playerNumber == 0 {
+		raceStep.Message = "You are not a player in this session"
+	} else if _, err := os.Stat(filepath.Join(gameDir, raceFileName(playerNumber))); err != nil {
+		raceStep.Message = fmt.Sprintf("%s is missing from the game directory", raceFileName(playerNumber))
+	} else {
+		raceStep.Done = true
+	}
+	step(raceStep)
+
+	// stars.exe present, downloaded right away if missing
+	exeStep := BootstrapStepInfo{ID: "stars_exe", Label: "Stars! available"}
+	starsPath := filepath.Join(gameDir, "stars.exe")
+	if _, err := os.Stat(starsPath); err != nil {
+		a.downloadStarsExeToDir(serverURL, sessionID, gameDir)
+	}
+	if _, err := os.Stat(starsPath); err != nil {
+		exeStep.Message = "stars.exe could not be downloaded"
+	} else {
+		exeStep.Done = true
+	}
+	step(exeStep)
+
+	// Wine usable when needed
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app settings: %w", err)
+	}
+	wineStep := BootstrapStepInfo{ID: "wine", Label: "Wine ready"}
+	switch {
+	case (goruntime.GOOS == "windows" || settings.GetEnableBrowserStars()) && !settings.GetUseWine():
+		wineStep.Skipped = true
+	case !settings.GetUseWine():
+		wineStep.Message = fmt.Sprintf("Wine is required to run Stars! on %s, enable it in Settings", goruntime.GOOS)
+	case !settings.GetValidWineInstall():
+		wineStep.Message = "Wine installation not validated, run 'Check Wine Installation' in Settings"
+	default:
+		wineStep.Done = true
+	}
+	step(wineStep)
+
+	status.ReadyToPlay = true
+	for _, s := range status.Steps {
+		if !s.Done && !s.Skipped {
+			status.ReadyToPlay = false
+			break
+		}
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Int("year", status.Year).
+		Bool("readyToPlay", status.ReadyToPlay).
+		Msg("First turn bootstrap done")
+
+	return status, nil
+}
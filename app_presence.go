@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/auth"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// PRESENCE
+// =============================================================================
+
+// presenceInterval is how often the presence heartbeat is sent while connected
+const presenceInterval = deviceReportInterval
+
+// sendHeartbeatsForAll sends the presence heartbeat to every connected server
+func (a *App) sendHeartbeatsForAll() {
+	a.mu.RLock()
+	serverURLs := make([]string, 0, len(a.clients))
+	for url := range a.clients {
+		serverURLs = append(serverURLs, url)
+	}
+	a.mu.RUnlock()
+
+	for _, url := range serverURLs {
+		a.sendHeartbeat(url)
+	}
+}
+
+// sendHeartbeat tells a server the user is online, so session members see
+// who is around. Servers without presence are remembered until the next
+// connection.
+func (a *App) sendHeartbeat(serverURL string) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	unsupported := a.noPresence[serverURL]
+	a.mu.RUnlock()
+	if !ok || !mgrOk || unsupported {
+		return
+	}
+
+	err := client.SendHeartbeat(mgr.GetContext())
+	if errors.Is(err, api.ErrNotSupported) {
+		a.markNoPresence(serverURL)
+		return
+	}
+	if err != nil {
+		logger.App.Debug().Err(err).Str("serverUrl", serverURL).Msg("Failed to send presence heartbeat")
+	}
+}
+
+// fetchSessionPresence returns the members of a session connected to the
+// server. Returns nil when the server has no presence.
+func (a *App) fetchSessionPresence(serverURL string, client *api.Client, mgr *auth.Manager, sessionID string) ([]string, error) {
+	a.mu.RLock()
+	unsupported := a.noPresence[serverURL]
+	a.mu.RUnlock()
+	if unsupported {
+		return nil, nil
+	}
+
+	presence, err := client.GetSessionPresence(mgr.GetContext(), sessionID)
+	if errors.Is(err, api.ErrNotSupported) {
+		a.markNoPresence(serverURL)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	online := []string{}
+	for _, p := range presence {
+		if p.Online {
+			online = append(online, p.UserProfileID)
+		}
+	}
+	return online, nil
+}
+
+func (a *App) markNoPresence(serverURL string) {
+	a.mu.Lock()
+	a.noPresence[serverURL] = true
+	a.mu.Unlock()
+	logger.App.Debug().Str("serverUrl", serverURL).Msg("Server has no presence")
+}
+
+// presenceOnline reads whether the user of a presence notification is online
+func presenceOnline(metadata interface{}) (bool, bool) {
+	metaMap, ok := metadata.(map[string]interface{})
+	if !ok {
+		return false, false
+	}
+	online, ok := metaMap["online"].(bool)
+	return online, ok
+}
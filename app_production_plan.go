@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/neper-stars/houston/blocks"
+	"github.com/neper-stars/houston/store"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// PRODUCTION PLANNER
+// =============================================================================
+
+// maxProductionCount is the largest count a production queue item can hold
+const maxProductionCount = 1023
+
+// standardProductionItems names the standard production items
+var standardProductionItems = map[int]string{
+	blocks.ProductionItemAutoMines:        "Auto Mines",
+	blocks.ProductionItemAutoFactories:    "Auto Factories",
+	blocks.ProductionItemAutoDefenses:     "Auto Defenses",
+	blocks.ProductionItemAutoAlchemy:      "Auto Alchemy",
+	blocks.ProductionItemAutoMinTerraform: "Auto Min Terraform",
+	blocks.ProductionItemAutoMaxTerraform: "Auto Max Terraform",
+	blocks.ProductionItemAutoPackets:      "Auto Packets",
+	blocks.ProductionItemFactory:          "Factory",
+	blocks.ProductionItemMine:             "Mine",
+	blocks.ProductionItemDefense:          "Defense",
+	blocks.ProductionItemMineralAlchemy:   "Mineral Alchemy",
+	blocks.ProductionItemPacketIronium:    "Ironium Packet",
+	blocks.ProductionItemPacketBoranium:   "Boranium Packet",
+	blocks.ProductionItemPacketGermanium:  "Germanium Packet",
+	blocks.ProductionItemPacketMixed:      "Mixed Packet",
+	blocks.ProductionItemScanner:          "Planetary Scanner",
+}
+
+// GetProductionTemplates returns the saved production queue templates
+func (a *App) GetProductionTemplates() ([]ProductionTemplateInfo, error) {
+	templates, err := a.config.GetProductionTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ProductionTemplateInfo, len(templates))
+	for i, t := range templates {
+		result[i] = ProductionTemplateInfo{Name: t.Name, Items: productionItemsToInfo(t.Items)}
+	}
+	return result, nil
+}
+
+// SaveProductionTemplate adds or replaces a production queue template
+func (a *App) SaveProductionTemplate(template ProductionTemplateInfo) ([]ProductionTemplateInfo, error) {
+	items, err := productionItemsFromInfo(template.Items)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.config.SetProductionTemplate(model.ProductionTemplate{Name: template.Name, Items: items}); err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Str("template", template.Name).Msg("Saved production template")
+	return a.GetProductionTemplates()
+}
+
+// DeleteProductionTemplate removes a production queue template. Plans using
+// it are reported as drifting until they are changed.
+func (a *App) DeleteProductionTemplate(name string) ([]ProductionTemplateInfo, error) {
+	if err := a.config.DeleteProductionTemplate(name); err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Str("template", name).Msg("Deleted production template")
+	return a.GetProductionTemplates()
+}
+
+// GetProductionPlans returns the per-planet production plans of a session
+func (a *App) GetProductionPlans(serverURL, sessionID string) ([]PlanetProductionPlanInfo, error) {
+	plans, err := a.config.GetProductionPlans(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]PlanetProductionPlanInfo, len(plans))
+	for i, p := range plans {
+		result[i] = PlanetProductionPlanInfo{
+			PlanetNumber: p.PlanetNumber,
+			Template:     p.Template,
+			Items:        productionItemsToInfo(p.Items),
+		}
+	}
+	return result, nil
+}
+
+// SetPlanetProductionPlan sets the plan of a planet, either a template or its
+// own items. A plan with neither removes the planet's plan.
+func (a *App) SetPlanetProductionPlan(serverURL, sessionID string, plan PlanetProductionPlanInfo) ([]PlanetProductionPlanInfo, error) {
+	if plan.Template != "" && len(plan.Items) > 0 {
+		return nil, fmt.Errorf("a plan uses either a template or its own items")
+	}
+	items, err := productionItemsFromInfo(plan.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	plans, err := a.config.GetProductionPlans(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([]model.PlanetProductionPlan, 0, len(plans)+1)
+	for _, p := range plans {
+		if p.PlanetNumber != plan.PlanetNumber {
+			kept = append(kept, p)
+		}
+	}
+	if plan.Template != "" || len(items) > 0 {
+		kept = append(kept, model.PlanetProductionPlan{
+			PlanetNumber: plan.PlanetNumber,
+			Template:     plan.Template,
+			Items:        items,
+		})
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].PlanetNumber < kept[j].PlanetNumber })
+
+	if err := a.config.SetProductionPlans(serverURL, sessionID, kept); err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int("planet", plan.PlanetNumber).Str("template", plan.Template).Msg("Set production plan")
+	return a.GetProductionPlans(serverURL, sessionID)
+}
+
+// GetProductionDriftReport compares the production plans of a session with
+// the queues of the local turn file
+func (a *App) GetProductionDriftReport(serverURL, sessionID string) (*ProductionDriftReportInfo, error) {
+	gs, err := a.loadSessionGameStore(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return a.productionDriftReport(serverURL, sessionID, gs)
+}
+
+// checkProductionDrift runs the drift report on a freshly downloaded turn and
+// emits "production:drift" when a planet strays from its plan
+func (a *App) checkProductionDrift(serverURL, sessionID, gameDir, turnPath string) {
+	plans, err := a.config.GetProductionPlans(serverURL, sessionID)
+	if err != nil || len(plans) == 0 {
+		return
+	}
+
+	universe, err := os.ReadFile(filepath.Join(gameDir, "game.xy"))
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to read universe file for production drift")
+		return
+	}
+	turn, err := os.ReadFile(turnPath)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to read turn file for production drift")
+		return
+	}
+	gs, err := newGameStore(universe, turn)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to load turn for production drift")
+		return
+	}
+
+	report, err := a.productionDriftReport(serverURL, sessionID, gs)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to build production drift report")
+		return
+	}
+	drifting := 0
+	for _, p := range report.Planets {
+		if !p.InSync {
+			drifting++
+		}
+	}
+	if drifting == 0 {
+		return
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int("year", report.Year).Int("planets", drifting).Msg("Production queues drift from plan")
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("production:drift", serverURL, sessionID, report)
+	}
+}
+
+// productionDriftReport compares each planned planet's queue with its plan
+func (a *App) productionDriftReport(serverURL, sessionID string, gs *store.GameStore) (*ProductionDriftReportInfo, error) {
+	plans, err := a.config.GetProductionPlans(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := a.config.GetProductionTemplates()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string][]model.ProductionItem, len(templates))
+	for _, t := range templates {
+		byName[t.Name] = t.Items
+	}
+
+	owner := turnOwner(gs)
+	report := &ProductionDriftReportInfo{Year: blocks.StarsBaseYear + int(gs.Turn), Planets: []PlanetDriftInfo{}}
+	for _, plan := range plans {
+		drift := PlanetDriftInfo{PlanetNumber: plan.PlanetNumber, Template: plan.Template, Items: []ProductionDriftItemInfo{}}
+		if planet, ok := gs.Planet(plan.PlanetNumber); ok {
+			drift.Name = planet.Name
+		}
+
+		planned := plan.Items
+		if plan.Template != "" {
+			items, ok := byName[plan.Template]
+			if !ok {
+				drift.Problem = fmt.Sprintf("template %q not found", plan.Template)
+				report.Planets = append(report.Planets, drift)
+				continue
+			}
+			planned = items
+		}
+
+		var queued []model.ProductionItem
+		if queue, ok := gs.ProductionQueue(plan.PlanetNumber); ok {
+			for _, item := range queue.Items {
+				kind := model.ProductionItemStandard
+				if item.IsShipDesign() {
+					kind = model.ProductionItemDesign
+				}
+				queued = append(queued, model.ProductionItem{Kind: kind, ID: item.ItemId, Count: item.Count})
+			}
+		}
+
+		drift.Items = diffProductionItems(gs, owner, planned, queued)
+		drift.InSync = len(drift.Items) == 0
+		report.Planets = append(report.Planets, drift)
+	}
+	return report, nil
+}
+
+// diffProductionItems lists the items whose planned and queued counts differ
+func diffProductionItems(gs *store.GameStore, owner int, planned, queued []model.ProductionItem) []ProductionDriftItemInfo {
+	type key struct {
+		kind string
+		id   int
+	}
+	counts := map[key][2]int{}
+	var order []key
+	add := func(items []model.ProductionItem, side int) {
+		for _, item := range items {
+			k := key{item.Kind, item.ID}
+			c, seen := counts[k]
+			if !seen {
+				order = append(order, k)
+			}
+			c[side] += item.Count
+			counts[k] = c
+		}
+	}
+	add(planned, 0)
+	add(queued, 1)
+
+	result := []ProductionDriftItemInfo{}
+	for _, k := range order {
+		c := counts[k]
+		if c[0] == c[1] {
+			continue
+		}
+		result = append(result, ProductionDriftItemInfo{
+			Kind:    k.kind,
+			ID:      k.id,
+			Name:    productionItemName(gs, owner, k.kind, k.id),
+			Planned: c[0],
+			Queued:  c[1],
+		})
+	}
+	return result
+}
+
+// productionItemName returns the display name of a production item
+func productionItemName(gs *store.GameStore, owner int, kind string, id int) string {
+	if kind == model.ProductionItemDesign {
+		if design, ok := gs.Design(owner, id); ok {
+			return design.Name
+		}
+		return fmt.Sprintf("Design #%d", id+1)
+	}
+	if name, ok := standardProductionItems[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("Item #%d", id)
+}
+
+// productionItemsFromInfo validates the items of a template or plan
+func productionItemsFromInfo(items []ProductionItemInfo) ([]model.ProductionItem, error) {
+	result := make([]model.ProductionItem, 0, len(items))
+	for _, item := range items {
+		switch item.Kind {
+		case model.ProductionItemStandard:
+			if _, ok := standardProductionItems[item.ID]; !ok {
+				return nil, fmt.Errorf("unknown production item: %d", item.ID)
+			}
+		case model.ProductionItemDesign:
+			if item.ID < 0 || item.ID > 15 {
+				return nil, fmt.Errorf("design number must be between 0 and 15")
+			}
+		default:
+			return nil, fmt.Errorf("unknown production item kind: %s", item.Kind)
+		}
+		if item.Count < 1 || item.Count > maxProductionCount {
+			return nil, fmt.Errorf("count must be between 1 and %d", maxProductionCount)
+		}
+		result = append(result, model.ProductionItem{Kind: item.Kind, ID: item.ID, Count: item.Count})
+	}
+	return result, nil
+}
+
+func productionItemsToInfo(items []model.ProductionItem) []ProductionItemInfo {
+	result := make([]ProductionItemInfo, len(items))
+	for i, item := range items {
+		result[i] = ProductionItemInfo{Kind: item.Kind, ID: item.ID, Count: item.Count}
+	}
+	return result
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// CONFIGURATION PROFILES
+// =============================================================================
+
+// GetProfiles returns the active configuration profile and the existing ones
+func (a *App) GetProfiles() *ProfilesInfo {
+	return &ProfilesInfo{
+		Active:   a.profile,
+		Profiles: astrum.ListProfiles(),
+	}
+}
+
+// GetInstanceWarnings returns what to expect from this instance running next
+// to another one using the same servers directory, allowed with
+// --allow-multiple. Empty when this instance has the directory to itself.
+func (a *App) GetInstanceWarnings() []string {
+	if a.instanceWarnings == nil {
+		return []string{}
+	}
+	return a.instanceWarnings
+}
+
+// SwitchProfile restarts Astrum with another configuration profile, created
+// if it doesn't exist yet. The profile is remembered for the next launches.
+func (a *App) SwitchProfile(name string) error {
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
+
+	if err := astrum.ValidateProfileName(name); err != nil {
+		return err
+	}
+	if name == a.profile {
+		return nil
+	}
+
+	if err := astrum.SetLastProfile(name); err != nil {
+		return err
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find the Astrum executable: %w", err)
+	}
+	// The new instance starts while this one shuts down: it can, as each
+	// profile has its own database
+	args := []string{"--profile", name}
+	if dir := astrum.ConfigDirOverride(); dir != "" {
+		args = append(args, "--config-dir", dir)
+	}
+	if allowMultiple {
+		args = append(args, "--allow-multiple")
+	}
+	cmd := exec.Command(exe, args...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start Astrum with profile %s: %w", name, err)
+	}
+	_ = cmd.Process.Release()
+
+	logger.App.Info().Str("from", a.profile).Str("to", name).Msg("Switching profile")
+
+	runtime.Quit(a.ctx)
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"path/filepath"
+	goruntime "runtime"
+	runtimepprof "runtime/pprof"
+	"strconv"
+	"time"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// =============================================================================
+// PROFILING
+// =============================================================================
+
+// defaultPprofPort is the port of the profiling endpoints in debug mode,
+// changed with ASTRUM_PPROF_PORT
+const defaultPprofPort = 6060
+
+// maxCPUProfileSeconds bounds the duration of a CPU profile
+const maxCPUProfileSeconds = 120
+
+// startProfilingServer serves the net/http/pprof endpoints on
+// 127.0.0.1:port/debug/pprof/, for maintainers in debug mode only
+func startProfilingServer(port int) {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		logger.Logger.Warn().Err(err).Int("port", port).Msg("Failed to start profiling endpoints")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := srv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Logger.Error().Err(err).Msg("Profiling endpoints stopped")
+		}
+	}()
+
+	logger.Logger.Info().Int("port", port).Msg("Profiling endpoints started")
+}
+
+// pprofPort returns the port of the profiling endpoints
+func pprofPort() int {
+	if port, err := strconv.Atoi(os.Getenv("ASTRUM_PPROF_PORT")); err == nil && port > 0 {
+		return port
+	}
+	return defaultPprofPort
+}
+
+// CaptureProfile writes a profile of the app into the profiles directory of
+// the configuration and returns its path, for users to attach to performance
+// reports. kind is "cpu" (recorded for seconds), "heap", "allocs",
+// "goroutine", "block" or "mutex".
+func (a *App) CaptureProfile(kind string, seconds int) (string, error) {
+	var profile *runtimepprof.Profile
+	if kind == "cpu" {
+		if seconds <= 0 || seconds > maxCPUProfileSeconds {
+			return "", fmt.Errorf("CPU profile duration must be between 1 and %d seconds", maxCPUProfileSeconds)
+		}
+	} else {
+		if profile = runtimepprof.Lookup(kind); profile == nil {
+			return "", fmt.Errorf("unknown profile: %s", kind)
+		}
+	}
+
+	dir := filepath.Join(astrum.ProfilePath(a.profile), "profiles")
+	if err := safefile.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create profiles directory: %w", err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s-%s.pprof", kind, time.Now().Format("20060102-150405")))
+
+	file, err := safefile.Create(path, 0644)
+	if err != nil {
+		return "", fmt.Errorf("failed to create profile: %w", err)
+	}
+	defer file.Close()
+
+	if kind == "cpu" {
+		if err := runtimepprof.StartCPUProfile(file); err != nil {
+			return "", fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		time.Sleep(time.Duration(seconds) * time.Second)
+		runtimepprof.StopCPUProfile()
+	} else {
+		if kind == "heap" {
+			goruntime.GC() // up to date live objects
+		}
+		if err := profile.WriteTo(file, 0); err != nil {
+			return "", fmt.Errorf("failed to write profile: %w", err)
+		}
+	}
+
+	if err := file.Commit(); err != nil {
+		return "", fmt.Errorf("failed to write profile: %w", err)
+	}
+
+	logger.App.Info().Str("kind", kind).Str("path", path).Msg("Captured profile")
+	return path, nil
+}
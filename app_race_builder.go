@@ -203,37 +203,46 @@ func (a *App) LoadRaceFileConfig(serverURL, raceID string) (RaceConfig, error) {
 		return RaceConfig{}, fmt.Errorf("failed to download race: %w", err)
 	}
 
+	return raceConfigFromData(raceData)
+}
+
+// raceConfigFromData parses base64 encoded race file data into RaceConfig
+func raceConfigFromData(raceData string) (RaceConfig, error) {
+	pb, err := racePlayerBlock(raceData)
+	if err != nil {
+		return RaceConfig{}, err
+	}
+
+	// Convert PlayerBlock to RaceConfig
+	return playerBlockToConfig(pb), nil
+}
+
+// racePlayerBlock returns the player block of base64 encoded race file data
+func racePlayerBlock(raceData string) (*hs.PlayerBlock, error) {
 	// Decode base64
 	rawData, err := base64.StdEncoding.DecodeString(raceData)
 	if err != nil {
-		return RaceConfig{}, fmt.Errorf("failed to decode race data: %w", err)
+		return nil, fmt.Errorf("failed to decode race data: %w", err)
 	}
 
 	// Parse the race file using Houston
 	fd := hs.FileData(rawData)
 	blockList, err := fd.BlockList()
 	if err != nil {
-		return RaceConfig{}, fmt.Errorf("failed to parse race file: %w", err)
+		return nil, fmt.Errorf("failed to parse race file: %w", err)
 	}
 
 	// Find the PlayerBlock
-	var pb *hs.PlayerBlock
 	for _, b := range blockList {
 		if b.BlockTypeID() == hs.PlayerBlockType {
 			playerBlock, ok := b.(hs.PlayerBlock)
 			if ok && playerBlock.Valid {
-				pb = &playerBlock
-				break
+				return &playerBlock, nil
 			}
 		}
 	}
 
-	if pb == nil {
-		return RaceConfig{}, fmt.Errorf("no valid player block found in race file")
-	}
-
-	// Convert PlayerBlock to RaceConfig
-	return playerBlockToConfig(pb), nil
+	return nil, fmt.Errorf("no valid player block found in race file")
 }
 
 // playerBlockToConfig converts a Houston PlayerBlock to RaceConfig
@@ -302,7 +311,8 @@ func playerBlockToConfig(pb *hs.PlayerBlock) RaceConfig {
 
 // BuildAndSaveRace creates a race file and uploads it to the server.
 // If sessionId is provided, also sets it as the player's race for that session.
-func (a *App) BuildAndSaveRace(serverURL string, config RaceConfig, sessionID string) (*RaceInfo, error) {
+// With reuseExisting, an identical race already on the server is used instead.
+func (a *App) BuildAndSaveRace(serverURL string, config RaceConfig, sessionID string, reuseExisting bool) (*RaceInfo, error) {
 	// Validate first
 	result := a.ValidateRaceConfig(config)
 	if !result.IsValid {
@@ -323,7 +333,7 @@ func (a *App) BuildAndSaveRace(serverURL string, config RaceConfig, sessionID st
 
 	// Upload to server
 	raceData := base64.StdEncoding.EncodeToString(raceBytes)
-	raceInfo, err := a.UploadRace(serverURL, raceData)
+	raceInfo, err := a.UploadRace(serverURL, raceData, reuseExisting)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// RACE CLEANUP
+// =============================================================================
+
+// FindUnusedRaces returns the races of the current user that are not the race
+// of any of their sessions, archived ones included, as candidates for DeleteRaces
+func (a *App) FindUnusedRaces(serverURL string) ([]RaceInfo, error) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk {
+		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return nil, fmt.Errorf("no user info available")
+	}
+	ctx := mgr.GetContext()
+
+	races, err := client.ListRaces(ctx, userInfo.User.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get races: %w", err)
+	}
+	sessions, err := client.ListSessionsIncludeArchived(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	// A session whose race can't be read fails the whole search, rather than
+	// flagging a race that may be in use
+	used := make(map[string]bool)
+	for i := range sessions {
+		if findPlayerNumber(&sessions[i], userInfo.User.ID) == 0 {
+			continue
+		}
+		race, err := client.GetSessionPlayerRace(ctx, sessions[i].ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get race of session %s: %w", sessions[i].Name, err)
+		}
+		if race.ID != "" {
+			used[race.ID] = true
+		}
+	}
+
+	unused := []RaceInfo{}
+	for _, r := range races {
+		if !used[r.ID] {
+			unused = append(unused, RaceInfo{
+				ID:           r.ID,
+				UserID:       r.UserID,
+				NameSingular: r.NameSingular,
+				NamePlural:   r.NamePlural,
+			})
+		}
+	}
+
+	logger.App.Info().Str("serverUrl", serverURL).Int("races", len(races)).Int("unused", len(unused)).Msg("Found unused races")
+	return unused, nil
+}
+
+// DeleteRaces deletes several races of the current user, going on after a
+// failure so one bad race doesn't block the cleanup
+func (a *App) DeleteRaces(serverURL string, raceIDs []string) (*RaceDeletionInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	result := &RaceDeletionInfo{Deleted: []string{}, Failed: map[string]string{}}
+	for _, raceID := range raceIDs {
+		if err := a.DeleteRace(serverURL, raceID); err != nil {
+			result.Failed[raceID] = err.Error()
+			continue
+		}
+		result.Deleted = append(result.Deleted, raceID)
+	}
+
+	logger.App.Info().Str("serverUrl", serverURL).Int("deleted", len(result.Deleted)).Int("failed", len(result.Failed)).Msg("Deleted races")
+	return result, nil
+}
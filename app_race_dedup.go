@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/auth"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// RACE DEDUPLICATION
+// =============================================================================
+
+// cacheKeyRaceHashes caches the content hashes of a user's server races, by
+// race ID, so duplicates are found without downloading every race each time
+const cacheKeyRaceHashes = "race_hashes/"
+
+// raceHash returns the hash of a race file given as base64
+func raceHash(raceData string) string {
+	data, err := base64.StdEncoding.DecodeString(raceData)
+	if err != nil {
+		data = []byte(raceData)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// FindDuplicateRace returns the server race with the same content as a race
+// file (base64 encoded), or nil, so the UI can offer to reuse it instead of
+// uploading a copy
+func (a *App) FindDuplicateRace(serverURL, raceData string) (*RaceInfo, error) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk {
+		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	}
+
+	return a.findDuplicateRace(client, mgr, serverURL, raceData)
+}
+
+// findDuplicateRace looks for a server race with the content of raceData,
+// refreshing the race hash cache on the way
+func (a *App) findDuplicateRace(client *api.Client, mgr *auth.Manager, serverURL, raceData string) (*RaceInfo, error) {
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return nil, fmt.Errorf("no user info available")
+	}
+	userID := userInfo.User.ID
+
+	races, err := client.ListRaces(mgr.GetContext(), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get races: %w", err)
+	}
+
+	cached := map[string]string{}
+	a.cachedServerData(serverURL, cacheKeyRaceHashes+userID, &cached)
+
+	// Only races not seen before are downloaded; deleted ones are dropped
+	hashes := make(map[string]string, len(races))
+	for _, r := range races {
+		if hash, ok := cached[r.ID]; ok {
+			hashes[r.ID] = hash
+			continue
+		}
+		data := r.Data
+		if data == "" {
+			race, err := client.GetRace(mgr.GetContext(), userID, r.ID)
+			if err != nil {
+				logger.App.Warn().Err(err).Str("raceId", r.ID).Msg("Failed to download race for deduplication")
+				continue
+			}
+			data = race.Data
+		}
+		hashes[r.ID] = raceHash(data)
+	}
+	a.cacheServerData(serverURL, cacheKeyRaceHashes+userID, hashes)
+
+	hash := raceHash(raceData)
+	for _, r := range races {
+		if hashes[r.ID] == hash {
+			return &RaceInfo{
+				ID:           r.ID,
+				UserID:       r.UserID,
+				NameSingular: r.NameSingular,
+				NamePlural:   r.NamePlural,
+			}, nil
+		}
+	}
+	return nil, nil
+}
+
+// setRaceHash records the hash of a race in the cache, or forgets the race
+// when raceData is empty
+func (a *App) setRaceHash(serverURL, userID, raceID, raceData string) {
+	hashes := map[string]string{}
+	if !a.cachedServerData(serverURL, cacheKeyRaceHashes+userID, &hashes) {
+		// Nothing cached yet, the next lookup hashes every race anyway
+		return
+	}
+	if raceData == "" {
+		delete(hashes, raceID)
+	} else {
+		hashes[raceID] = raceHash(raceData)
+	}
+	a.cacheServerData(serverURL, cacheKeyRaceHashes+userID, hashes)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+)
+
+// =============================================================================
+// RACE DETECTION
+// =============================================================================
+
+// gameRaceFilePattern matches the game.rN files Astrum writes into game
+// directories itself when a player becomes ready
+var gameRaceFilePattern = regexp.MustCompile(`(?i)^game\.r[0-9]+$`)
+
+// watchedRaceDir is the session whose game directory is watched for races,
+// empty for the workshop folder
+type watchedRaceDir struct {
+	serverURL string
+	sessionID string
+}
+
+// startRaceWatcher starts watching the race workshop folder; game directories
+// are added as their sessions are monitored
+func (a *App) startRaceWatcher() {
+	watcher, err := monitor.NewRaceWatcher(a.onRaceFile)
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to start race watcher")
+		return
+	}
+
+	a.mu.Lock()
+	a.raceWatcher = watcher
+	a.mu.Unlock()
+
+	if dir, err := a.config.GetRaceWorkshopDir(); err == nil && dir != "" {
+		a.watchRaceDir(dir, watchedRaceDir{})
+	}
+}
+
+// watchRaceDir watches a directory for race files created with the Stars! race wizard
+func (a *App) watchRaceDir(dir string, owner watchedRaceDir) {
+	a.mu.Lock()
+	watcher := a.raceWatcher
+	if watcher != nil {
+		a.raceDirs[dir] = owner
+	}
+	a.mu.Unlock()
+
+	if watcher == nil {
+		return
+	}
+	if err := watcher.Add(dir); err != nil {
+		logger.App.Warn().Err(err).Str("dir", dir).Msg("Failed to watch directory for races")
+	}
+}
+
+// unwatchRaceDir stops watching a directory for race files
+func (a *App) unwatchRaceDir(dir string) {
+	a.mu.Lock()
+	watcher := a.raceWatcher
+	delete(a.raceDirs, dir)
+	a.mu.Unlock()
+
+	if watcher != nil {
+		watcher.Remove(dir)
+	}
+}
+
+// onRaceFile parses and validates a new race file and offers it for upload
+func (a *App) onRaceFile(dir, filePath string) {
+	a.mu.RLock()
+	owner, ok := a.raceDirs[dir]
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+
+	if !ok || shuttingDown {
+		return
+	}
+	// Game directories hold the race files copied by SetPlayerReady
+	if owner.sessionID != "" && gameRaceFilePattern.MatchString(filepath.Base(filePath)) {
+		return
+	}
+
+	info, err := a.inspectRaceFile(filePath)
+	if err != nil {
+		logger.App.Debug().Err(err).Str("path", filePath).Msg("Ignoring unreadable race file")
+		return
+	}
+	info.ServerURL = owner.serverURL
+	info.SessionID = owner.sessionID
+
+	logger.App.Info().Str("path", filePath).Bool("valid", info.Valid).Msg("Detected new race file")
+	a.emit("race:detected", info)
+}
+
+// inspectRaceFile parses and validates a race file
+func (a *App) inspectRaceFile(filePath string) (*DetectedRaceInfo, error) {
+	rawData, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read race file: %w", err)
+	}
+	config, err := raceConfigFromData(base64.StdEncoding.EncodeToString(rawData))
+	if err != nil {
+		return nil, err
+	}
+
+	result := a.ValidateRaceConfig(config)
+	info := &DetectedRaceInfo{
+		Path:         filePath,
+		NameSingular: config.SingularName,
+		NamePlural:   config.PluralName,
+		Valid:        result.IsValid,
+		Errors:       []string{},
+	}
+	for _, e := range result.Errors {
+		info.Errors = append(info.Errors, e.Message)
+	}
+	return info, nil
+}
+
+// UploadRaceFile uploads a race file offered by a race:detected event,
+// reusing an identical server race if there is one
+func (a *App) UploadRaceFile(serverURL, filePath string) (*RaceInfo, error) {
+	if !monitor.IsRaceFile(filePath) {
+		return nil, fmt.Errorf("%s is not a race file", filepath.Base(filePath))
+	}
+
+	info, err := a.inspectRaceFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Valid {
+		return nil, fmt.Errorf("invalid race: %v", info.Errors)
+	}
+
+	rawData, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read race file: %w", err)
+	}
+	return a.UploadRace(serverURL, base64.StdEncoding.EncodeToString(rawData), true)
+}
+
+// SetRaceWorkshopDir sets the folder watched for races made with the Stars!
+// race wizard, "" to stop watching one
+func (a *App) SetRaceWorkshopDir(dir string) (*AppSettingsInfo, error) {
+	previous, err := a.config.GetRaceWorkshopDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get race workshop folder: %w", err)
+	}
+	if dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("not a directory: %s", dir)
+		}
+	}
+	if err := a.config.SetRaceWorkshopDir(dir); err != nil {
+		return nil, fmt.Errorf("failed to set race workshop folder: %w", err)
+	}
+
+	if previous != "" {
+		a.unwatchRaceDir(previous)
+	}
+	if dir != "" {
+		a.watchRaceDir(dir, watchedRaceDir{})
+	}
+
+	logger.App.Info().Str("path", dir).Msg("Set race workshop folder")
+	return a.GetAppSettings()
+}
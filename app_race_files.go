@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// =============================================================================
+// RACE FILES
+// =============================================================================
+
+// raceFilePattern matches Stars! race file names (.r1 to .r16)
+var raceFilePattern = regexp.MustCompile(`(?i)\.r([1-9]|1[0-6])$`)
+
+// SaveRaceToDisk downloads a race and writes it to targetDir as <race name>.r1,
+// the name Stars! gives races saved from its race wizard. Returns the file path.
+func (a *App) SaveRaceToDisk(serverURL, raceID, targetDir string) (string, error) {
+	if targetDir == "" {
+		return "", fmt.Errorf("no target directory")
+	}
+
+	raceData, err := a.DownloadRace(serverURL, raceID)
+	if err != nil {
+		return "", err
+	}
+	config, err := raceConfigFromData(raceData)
+	if err != nil {
+		return "", err
+	}
+	rawData, err := base64.StdEncoding.DecodeString(raceData)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode race data: %w", err)
+	}
+
+	name := sanitizeFilename(config.SingularName)
+	if name == "" {
+		name = "race"
+	}
+	path := filepath.Join(targetDir, name+".r1")
+	if err := safefile.WriteFile(path, rawData, 0644); err != nil {
+		return "", fmt.Errorf("failed to write race file: %w", err)
+	}
+
+	logger.App.Info().Str("path", path).Str("raceId", raceID).Msg("Saved race to disk")
+	return path, nil
+}
+
+// SaveRaceToSessionDir writes a race into the game directory of a session as
+// game.rN, N being the current user's player number. Returns the file path.
+func (a *App) SaveRaceToSessionDir(serverURL, sessionID, raceID string) (string, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return "", fmt.Errorf("no user info available")
+	}
+
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get session: %w", err)
+	}
+	playerNumber := findPlayerNumber(session, userInfo.User.ID)
+	if playerNumber == 0 {
+		return "", fmt.Errorf("current user is not a player in this session")
+	}
+
+	raceData, err := a.DownloadRace(serverURL, raceID)
+	if err != nil {
+		return "", err
+	}
+	rawData, err := base64.StdEncoding.DecodeString(raceData)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode race data: %w", err)
+	}
+
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL // fallback to URL if server not found
+	if server != nil {
+		serverName = server.Name
+	}
+	gameDir, err := a.config.EnsureSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	path := filepath.Join(gameDir, raceFileName(playerNumber))
+	if err := safefile.WriteFile(path, rawData, 0644); err != nil {
+		return "", fmt.Errorf("failed to write race file: %w", err)
+	}
+
+	logger.App.Info().Str("path", path).Str("raceId", raceID).Msg("Saved race to session directory")
+	return path, nil
+}
+
+// ListGameDirRaceFiles returns the names of the race files in the game
+// directory of a session, e.g. races created with the Stars! race wizard
+func (a *App) ListGameDirRaceFiles(serverURL, sessionID string) ([]string, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(gameDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read game directory: %w", err)
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && raceFilePattern.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ImportRaceFromGameDir uploads a race file from the game directory of a
+// session, reusing an identical server race if there is one
+func (a *App) ImportRaceFromGameDir(serverURL, sessionID, fileName string) (*RaceInfo, error) {
+	if !raceFilePattern.MatchString(fileName) {
+		return nil, fmt.Errorf("%s is not a race file", fileName)
+	}
+
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the base name is used, the file must be in the game directory
+	rawData, err := os.ReadFile(filepath.Join(gameDir, filepath.Base(fileName)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read race file: %w", err)
+	}
+
+	raceData := base64.StdEncoding.EncodeToString(rawData)
+	if _, err := raceConfigFromData(raceData); err != nil {
+		return nil, err
+	}
+
+	raceInfo, err := a.UploadRace(serverURL, raceData, true)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Str("file", fileName).Str("raceId", raceInfo.ID).Msg("Imported race from game directory")
+	return raceInfo, nil
+}
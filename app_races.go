@@ -1,13 +1,18 @@
 package main
 
 import (
+	"bytes"
 	"encoding/base64"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/api/models"
 	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
 )
 
 // =============================================================================
@@ -48,8 +53,10 @@ func (a *App) GetMyRaces(serverURL string) ([]RaceInfo, error) {
 	return result, nil
 }
 
-// UploadRace uploads a new race file (data is base64 encoded)
-func (a *App) UploadRace(serverURL, raceData string) (*RaceInfo, error) {
+// UploadRace uploads a new race file (data is base64 encoded). With
+// reuseExisting, a server race with the same content is returned instead of
+// creating a duplicate; FindDuplicateRace tells beforehand whether there is one.
+func (a *App) UploadRace(serverURL, raceData string, reuseExisting bool) (*RaceInfo, error) {
 	a.mu.RLock()
 	client, ok := a.clients[serverURL]
 	mgr, mgrOk := a.authManagers[serverURL]
@@ -64,6 +71,17 @@ func (a *App) UploadRace(serverURL, raceData string) (*RaceInfo, error) {
 		return nil, fmt.Errorf("no user info available")
 	}
 
+	if reuseExisting {
+		existing, err := a.findDuplicateRace(client, mgr, serverURL, raceData)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			logger.App.Info().Str("name", existing.NameSingular).Str("id", existing.ID).Msg("Reusing identical race instead of uploading")
+			return existing, nil
+		}
+	}
+
 	race := &api.Race{
 		Data: raceData,
 	}
@@ -74,6 +92,7 @@ func (a *App) UploadRace(serverURL, raceData string) (*RaceInfo, error) {
 	}
 
 	logger.App.Info().Str("name", created.NameSingular).Str("id", created.ID).Msg("Uploaded race")
+	a.setRaceHash(serverURL, userInfo.User.ID, created.ID, raceData)
 
 	return &RaceInfo{
 		ID:           created.ID,
@@ -109,6 +128,10 @@ func (a *App) DownloadRace(serverURL, raceID string) (string, error) {
 
 // DeleteRace deletes a race from the user's profile
 func (a *App) DeleteRace(serverURL, raceID string) error {
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
+
 	a.mu.RLock()
 	client, ok := a.clients[serverURL]
 	mgr, mgrOk := a.authManagers[serverURL]
@@ -126,6 +149,7 @@ func (a *App) DeleteRace(serverURL, raceID string) error {
 	if err := client.DeleteRace(mgr.GetContext(), userInfo.User.ID, raceID); err != nil {
 		return fmt.Errorf("failed to delete race: %w", err)
 	}
+	a.setRaceHash(serverURL, userInfo.User.ID, raceID, "")
 
 	logger.App.Info().Str("id", raceID).Msg("Deleted race")
 
@@ -133,21 +157,40 @@ func (a *App) DeleteRace(serverURL, raceID string) error {
 }
 
 // SetSessionRace sets the race for the current user in a session
+// If the player is already ready they are un-ready first; changing race after the game started is refused
 func (a *App) SetSessionRace(serverURL, sessionID, raceID string) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
 
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.State != models.SessionStatePending {
+		return fmt.Errorf("the game has already started, the race can no longer be changed")
+	}
+
+	// Changing race while ready would leave the previous race file in the game
+	// directory: un-ready first, the player has to become ready again with the new race
+	if userInfo := mgr.GetUserInfo(); userInfo != nil {
+		for _, player := range session.Players {
+			if player.UserProfileID == userInfo.User.ID && player.Ready {
+				if err := a.SetPlayerReady(serverURL, sessionID, false); err != nil {
+					return fmt.Errorf("failed to un-ready before race change: %w", err)
+				}
+				logger.App.Warn().Str("sessionId", sessionID).Msg("Race changed while ready, player is no longer ready")
+				break
+			}
+		}
 	}
 
 	playerRace := &api.SessionPlayerRace{
 		RaceID: raceID,
 	}
 
-	_, err := client.SetSessionPlayerRace(mgr.GetContext(), sessionID, playerRace)
+	_, err = client.SetSessionPlayerRace(mgr.GetContext(), sessionID, playerRace)
 	if err != nil {
 		return fmt.Errorf("failed to set session race: %w", err)
 	}
@@ -159,26 +202,35 @@ func (a *App) SetSessionRace(serverURL, sessionID, raceID string) error {
 
 // GetSessionPlayerRace gets the current user's race for a session
 func (a *App) GetSessionPlayerRace(serverURL, sessionID string) (*RaceInfo, error) {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
+	info, err := a.fetchSessionPlayerRace(serverURL, sessionID)
+	if err == nil {
+		a.cacheServerData(serverURL, cacheKeyPlayerRace+sessionID, info)
+	} else if !useServerCache(err) || !a.cachedServerData(serverURL, cacheKeyPlayerRace+sessionID, &info) {
+		return nil, err
+	} else {
+		info.Stale = true
+	}
 
-	if !ok || !mgrOk {
-		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	// Return nil if no race is set (empty ID)
+	if info.ID == "" {
+		return nil, nil
 	}
+	return &info, nil
+}
 
-	race, err := client.GetSessionPlayerRace(mgr.GetContext(), sessionID)
+// fetchSessionPlayerRace gets the current user's race for a session from the server
+func (a *App) fetchSessionPlayerRace(serverURL, sessionID string) (RaceInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get session player race: %w", err)
+		return RaceInfo{}, err
 	}
 
-	// Return nil if no race is set (empty ID)
-	if race.ID == "" {
-		return nil, nil
+	race, err := client.GetSessionPlayerRace(mgr.GetContext(), sessionID)
+	if err != nil {
+		return RaceInfo{}, fmt.Errorf("failed to get session player race: %w", err)
 	}
 
-	return &RaceInfo{
+	return RaceInfo{
 		ID:           race.ID,
 		UserID:       race.UserID,
 		NameSingular: race.NameSingular,
@@ -187,15 +239,15 @@ func (a *App) GetSessionPlayerRace(serverURL, sessionID string) (*RaceInfo, erro
 }
 
 // SetPlayerReady sets the ready state for the current player in a session
-// When setting ready=true, it also copies the race file to the game directory
-func (a *App) SetPlayerReady(serverURL, sessionID string, ready bool) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+// When setting ready=true, it first runs the ready checklist: failed checks emit
+// "ready:checklist" (serverURL, sessionID, checklist) and return an error listing
+// them. Otherwise the race file is copied to the game directory.
+// When setting ready=false, the copied race file is removed so a slot change before
+// the game starts cannot leave a stale game.rN behind. Un-ready is refused once the game started.
+func (a *App) SetPlayerReady(serverURL, sessionID string, ready bool) (err error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
 	ctx := mgr.GetContext()
@@ -204,13 +256,37 @@ func (a *App) SetPlayerReady(serverURL, sessionID string, ready bool) error {
 		return fmt.Errorf("no user info available")
 	}
 
+	// Show the new ready state right away, the checks below can take a while
+	update := a.beginSessionUpdate(serverURL, sessionID, func(s *SessionInfo) {
+		for i := range s.Players {
+			if s.Players[i].UserProfileID == userInfo.User.ID {
+				s.Players[i].Ready = ready
+			}
+		}
+	})
+	defer func() {
+		if err != nil {
+			update.rollback(err)
+		}
+	}()
+
+	// Get the server name for calculating game directory
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL // fallback to URL if server not found
+	if server != nil {
+		serverName = server.Name
+	}
+
 	// If setting ready=true, copy the race file to the game directory first
+	var unreadySession *api.Session
 	if ready {
-		// Get the server name for calculating game directory
-		server, _ := a.config.GetServer(serverURL)
-		serverName := serverURL // fallback to URL if server not found
-		if server != nil {
-			serverName = server.Name
+		checklist, err := a.GetReadyChecklist(serverURL, sessionID)
+		if err != nil {
+			return err
+		}
+		if !checklist.Ready {
+			a.emit("ready:checklist", serverURL, sessionID, checklist)
+			return readyCheckError(checklist)
 		}
 
 		// Get the game directory (calculated from servers dir)
@@ -242,52 +318,197 @@ func (a *App) SetPlayerReady(serverURL, sessionID string, ready bool) error {
 		}
 
 		// Find the current player in the session to get their player order
-		playerOrder := 0
-		for _, player := range session.Players {
-			if player.UserProfileID == userInfo.User.ID {
-				playerOrder = int(player.PlayerOrder) + 1 // PlayerOrder is 0-indexed, Stars! uses 1-indexed
-				break
-			}
-		}
+		playerOrder := findPlayerNumber(session, userInfo.User.ID)
 		if playerOrder == 0 {
 			return fmt.Errorf("current user is not a player in this session")
 		}
 
 		// Build the race file path (player order determines the file number)
 		// Stars! race files are named like: game.r1, game.r2, etc.
-		raceFileName := fmt.Sprintf("game.r%d", playerOrder)
-		raceFilePath := filepath.Join(gameDir, raceFileName)
+		raceFilePath := filepath.Join(gameDir, raceFileName(playerOrder))
 
 		// Write the race file
-		if err := os.WriteFile(raceFilePath, raceData, 0644); err != nil {
+		if err := safefile.WriteFile(raceFilePath, raceData, 0644); err != nil {
 			return fmt.Errorf("failed to write race file: %w", err)
 		}
 
 		logger.App.Info().Str("path", raceFilePath).Msg("Copied race file")
+
+		// Remove copies of our race left in other slots by a previous ready
+		removeStaleRaceCopies(gameDir, playerOrder, raceData)
+	} else {
+		session, err := client.GetSession(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("failed to get session: %w", err)
+		}
+		if session.State != models.SessionStatePending {
+			return fmt.Errorf("the game has already started, ready state can no longer be changed")
+		}
+		unreadySession = session
 	}
 
 	// Now set the ready state on the server
-	_, err := client.SetPlayerReady(ctx, sessionID, ready)
+	_, err = client.SetPlayerReady(ctx, sessionID, ready)
 	if err != nil {
 		return fmt.Errorf("failed to set player ready state: %w", err)
 	}
 
+	// The race file goes once the server no longer counts us ready
+	if unreadySession != nil {
+		a.removeOwnRaceFile(serverName, unreadySession, userInfo.User.ID)
+	}
+
 	logger.App.Info().Bool("ready", ready).Str("sessionId", sessionID).Msg("Set player ready state")
+	update.confirmEchoed()
 
 	return nil
 }
 
+// findPlayerNumber returns the 1-indexed Stars! player number of a user in a session, or 0
+func findPlayerNumber(session *api.Session, userProfileID string) int {
+	for _, player := range session.Players {
+		if player.UserProfileID == userProfileID {
+			return int(player.PlayerOrder) + 1 // PlayerOrder is 0-indexed, Stars! uses 1-indexed
+		}
+	}
+	return 0
+}
+
+// raceFileName returns the Stars! race file name for a player number (game.r1, game.r2, ...)
+func raceFileName(playerNumber int) string {
+	return fmt.Sprintf("game.r%d", playerNumber)
+}
+
+// removeOwnRaceFile removes the race file copied for the current user when they became ready
+func (a *App) removeOwnRaceFile(serverName string, session *api.Session, userProfileID string) {
+	playerOrder := findPlayerNumber(session, userProfileID)
+	if playerOrder == 0 {
+		return
+	}
+
+	gameDir, err := a.config.GetSessionGameDir(serverName, session.ID)
+	if err != nil {
+		return
+	}
+
+	raceFilePath := filepath.Join(gameDir, raceFileName(playerOrder))
+	if err := os.Remove(raceFilePath); err != nil {
+		if !os.IsNotExist(err) {
+			logger.App.Warn().Err(err).Str("path", raceFilePath).Msg("Failed to remove race file")
+		}
+		return
+	}
+
+	logger.App.Info().Str("path", raceFilePath).Msg("Removed race file after un-ready")
+}
+
+// removeStaleRaceCopies deletes race files in other slots holding the same race data,
+// left over when our player number changed between two ready toggles. The
+// race file of our own slot is kept whatever the case of its name.
+func removeStaleRaceCopies(gameDir string, playerNumber int, raceData []byte) {
+	entries, err := os.ReadDir(gameDir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		slot, ok := strings.CutPrefix(strings.ToLower(entry.Name()), "game.r")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		if n, err := strconv.Atoi(slot); err != nil || n == playerNumber {
+			continue
+		}
+		path := filepath.Join(gameDir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil || !bytes.Equal(data, raceData) {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			logger.App.Warn().Err(err).Str("path", path).Msg("Failed to remove stale race file")
+			continue
+		}
+		logger.App.Info().Str("path", path).Msg("Removed stale race file from previous slot")
+	}
+}
+
+// syncOwnRaceFile keeps our race file in the right slot before the game starts.
+// When the host reorders players, our player number changes: the race file is
+// regenerated under the new game.rN name and copies left in old slots are removed.
+func (a *App) syncOwnRaceFile(serverURL, sessionID string) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return
+	}
+
+	ctx := mgr.GetContext()
+	session, err := client.GetSession(ctx, sessionID)
+	if err != nil || session.State != models.SessionStatePending {
+		return
+	}
+
+	ready := false
+	for _, player := range session.Players {
+		if player.UserProfileID == userInfo.User.ID {
+			ready = player.Ready
+			break
+		}
+	}
+	if !ready {
+		return
+	}
+
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL
+	if server != nil {
+		serverName = server.Name
+	}
+
+	gameDir, err := a.config.GetSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(gameDir); err != nil {
+		return
+	}
+
+	race, err := client.GetSessionPlayerRace(ctx, sessionID)
+	if err != nil || race.Data == "" {
+		return
+	}
+	raceData, err := base64.StdEncoding.DecodeString(race.Data)
+	if err != nil {
+		return
+	}
+
+	playerNumber := findPlayerNumber(session, userInfo.User.ID)
+	raceFilePath := filepath.Join(gameDir, raceFileName(playerNumber))
+	if existing, err := os.ReadFile(raceFilePath); err != nil || !bytes.Equal(existing, raceData) {
+		if err := safefile.WriteFile(raceFilePath, raceData, 0644); err != nil {
+			logger.App.Warn().Err(err).Str("path", raceFilePath).Msg("Failed to regenerate race file")
+			return
+		}
+		logger.App.Info().
+			Str("sessionId", sessionID).
+			Str("path", raceFilePath).
+			Msg("Regenerated race file for new player slot")
+	}
+
+	removeStaleRaceCopies(gameDir, playerNumber, raceData)
+}
+
 // AddBotPlayer adds a bot player to a session
 // Only session managers or global managers can add bots
 // raceID must be 0-6 (bot race types), botLevel must be 0-4 (difficulty)
 func (a *App) AddBotPlayer(serverURL, sessionID string, raceID string, botLevel int) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
 	botLevelInt64 := int64(botLevel)
@@ -297,7 +518,7 @@ func (a *App) AddBotPlayer(serverURL, sessionID string, raceID string, botLevel
 		BotLevel: &botLevelInt64,
 	}
 
-	_, err := client.SetSessionPlayerRace(mgr.GetContext(), sessionID, playerRace)
+	_, err = client.SetSessionPlayerRace(mgr.GetContext(), sessionID, playerRace)
 	if err != nil {
 		return fmt.Errorf("failed to add bot player: %w", err)
 	}
@@ -315,16 +536,12 @@ func (a *App) AddBotPlayer(serverURL, sessionID string, raceID string, botLevel
 // Only session managers or global managers can remove bots
 // playerRaceID is the ID of the player race mapping (userProfileId for bots)
 func (a *App) RemoveBotPlayer(serverURL, sessionID, playerRaceID string) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
-	err := client.DeleteSessionPlayerRace(mgr.GetContext(), sessionID, playerRaceID)
+	err = client.DeleteSessionPlayerRace(mgr.GetContext(), sessionID, playerRaceID)
 	if err != nil {
 		return fmt.Errorf("failed to remove bot player: %w", err)
 	}
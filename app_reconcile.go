@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/neper-stars/houston/blocks"
+
+	"github.com/neper-stars/astrum/api/models"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+	"github.com/neper-stars/astrum/lib/workqueue"
+)
+
+// =============================================================================
+// MISSED TURNS RECONCILIATION
+// =============================================================================
+
+// turnsDirName is the game directory subfolder keeping the turn files of every
+// year, as turns/<year>/game.xy and turns/<year>/game.mN
+const turnsDirName = "turns"
+
+// queueTurnReconciliation schedules the reconciliation of a server's games as
+// background work, behind turn handling and user actions
+func (a *App) queueTurnReconciliation(serverURL string) {
+	a.work.Submit(workqueue.Low, "reconcile-turns", func() {
+		a.reconcileMissedTurns(serverURL)
+	})
+}
+
+// reconcileMissedTurns downloads every year generated in our started games
+// that is missing locally, e.g. turns generated while the app was closed.
+// The latest year also goes to the game directory, ready to be played.
+// When anything was downloaded, "turns:reconciled" (serverURL, summary) is emitted.
+func (a *App) reconcileMissedTurns(serverURL string) {
+	if a.inMaintenance(serverURL) {
+		// Run again once the maintenance is over, see refreshServerStatus
+		logger.App.Debug().Str("serverUrl", serverURL).Msg("Server under maintenance, turn reconciliation deferred")
+		return
+	}
+
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk {
+		return
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return
+	}
+
+	sessions, err := client.ListSessions(mgr.GetContext())
+	if err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to list sessions for turn reconciliation")
+		return
+	}
+
+	summary := TurnReconcileInfo{Sessions: []string{}}
+	for _, session := range sessions {
+		if session.State != models.SessionStateStarted {
+			continue
+		}
+
+		playerOrder := -1
+		for _, player := range session.Players {
+			if player.UserProfileID == userInfo.User.ID {
+				playerOrder = int(player.PlayerOrder)
+				break
+			}
+		}
+		if playerOrder < 0 {
+			continue
+		}
+
+		downloaded, err := a.reconcileSessionTurns(nil, serverURL, session.ID, playerOrder)
+		if err != nil {
+			logger.App.Warn().Err(err).Str("sessionId", session.ID).Msg("Failed to reconcile missed turns")
+		}
+		if downloaded > 0 {
+			summary.Turns += downloaded
+			summary.Games++
+			summary.Sessions = append(summary.Sessions, session.ID)
+		}
+	}
+
+	if summary.Turns == 0 {
+		return
+	}
+
+	summary.Message = fmt.Sprintf("%d new %s downloaded across %d %s",
+		summary.Turns, plural(summary.Turns, "turn", "turns"),
+		summary.Games, plural(summary.Games, "game", "games"))
+
+	logger.App.Info().
+		Str("serverUrl", serverURL).
+		Int("turns", summary.Turns).
+		Int("games", summary.Games).
+		Msg("Reconciled missed turns")
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("turns:reconciled", serverURL, summary)
+	}
+}
+
+// reconcileSessionTurns downloads the years of a session missing from its turns
+// folder, reporting its progress to op, and returns how many were downloaded.
+// playerOrder is 0-indexed.
+func (a *App) reconcileSessionTurns(op *operation, serverURL, sessionID string, playerOrder int) (int, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, stop := op.bind(mgr.GetContext())
+	defer stop()
+	op.progress(0, 0, "Looking for missing years")
+	latest, err := client.GetLatestTurn(ctx, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest turn: %w", err)
+	}
+	if latest.Turn == nil {
+		return 0, fmt.Errorf("latest turn has no files")
+	}
+	latestYear := int(latest.Year)
+
+	have := make(map[int]bool)
+	for _, year := range localTurnYears(gameDir) {
+		have[year] = true
+	}
+	if have[latestYear] {
+		return 0, nil
+	}
+
+	// Years are about the same size, the latest one tells how much room they need
+	missing := 0
+	for year := blocks.StarsBaseYear; year <= latestYear; year++ {
+		if !have[year] {
+			missing++
+		}
+	}
+	yearSize := int64(len(latest.Turn.Universe)+len(latest.Turn.Turn)) * 3 / 4
+	if err := a.checkDiskSpace(gameDir, "turn download", yearSize*int64(missing)); err != nil {
+		return 0, err
+	}
+
+	downloaded := 0
+	for year := blocks.StarsBaseYear; year <= latestYear; year++ {
+		if have[year] {
+			continue
+		}
+		if err := op.err(); err != nil {
+			return downloaded, err
+		}
+		op.progress(downloaded, missing, fmt.Sprintf("Downloading %d", year))
+
+		files := latest
+		if year != latestYear {
+			if files, err = client.GetTurn(ctx, sessionID, year); err != nil {
+				return downloaded, fmt.Errorf("failed to get turn files for %d: %w", year, err)
+			}
+		}
+		if files.Turn == nil {
+			continue
+		}
+
+		if err := archiveTurn(gameDir, year, playerOrder, files.Turn.Universe, files.Turn.Turn); err != nil {
+			return downloaded, err
+		}
+		downloaded++
+	}
+
+	// The latest year is the one to play
+	if err := a.saveTurnFiles(serverURL, sessionID, latest.Turn.Universe, latest.Turn.Turn); err != nil {
+		return downloaded, err
+	}
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Int("downloaded", downloaded).
+		Int("latestYear", latestYear).
+		Msg("Downloaded missed turns")
+
+	op.progress(missing, missing, fmt.Sprintf("%d %s downloaded", downloaded, plural(downloaded, "year", "years")))
+	return downloaded, nil
+}
+
+// downloadSessionTurns downloads every year of a session we play in that is
+// missing locally, reporting its progress to op, and returns a summary
+func (a *App) downloadSessionTurns(op *operation, serverURL, sessionID string) (string, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return "", fmt.Errorf("not authenticated on %s", serverURL)
+	}
+
+	ctx, stop := op.bind(mgr.GetContext())
+	defer stop()
+	session, err := client.GetSession(ctx, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get session: %w", err)
+	}
+	if session.State != models.SessionStateStarted {
+		return "", fmt.Errorf("the game has not started yet")
+	}
+
+	playerOrder := -1
+	for _, player := range session.Players {
+		if player.UserProfileID == userInfo.User.ID {
+			playerOrder = int(player.PlayerOrder)
+			break
+		}
+	}
+	if playerOrder < 0 {
+		return "", fmt.Errorf("you are not a player of this session")
+	}
+
+	downloaded, err := a.reconcileSessionTurns(op, serverURL, sessionID, playerOrder)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %s downloaded", downloaded, plural(downloaded, "turn", "turns")), nil
+}
+
+// archiveTurn saves the base64 encoded turn files of a year in the turns folder
+func archiveTurn(gameDir string, year, playerOrder int, universe, turn string) error {
+	universeData, err := base64.StdEncoding.DecodeString(universe)
+	if err != nil {
+		return fmt.Errorf("failed to decode universe data: %w", err)
+	}
+	turnData, err := base64.StdEncoding.DecodeString(turn)
+	if err != nil {
+		return fmt.Errorf("failed to decode turn data: %w", err)
+	}
+
+	dir := filepath.Join(gameDir, turnsDirName, strconv.Itoa(year))
+	if err := safefile.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create turns directory: %w", err)
+	}
+	if err := safefile.WriteFile(filepath.Join(dir, "game.xy"), universeData, 0644); err != nil {
+		return fmt.Errorf("failed to save universe file: %w", err)
+	}
+	turnName := fmt.Sprintf("game.m%d", playerOrder+1)
+	if err := safefile.WriteFile(filepath.Join(dir, turnName), turnData, 0644); err != nil {
+		return fmt.Errorf("failed to save turn file: %w", err)
+	}
+	return nil
+}
+
+// localTurnYears returns the years kept in the turns folder, oldest first
+func localTurnYears(gameDir string) []int {
+	entries, err := os.ReadDir(filepath.Join(gameDir, turnsDirName))
+	if err != nil {
+		return nil
+	}
+
+	var years []int
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if year, err := strconv.Atoi(entry.Name()); err == nil {
+			years = append(years, year)
+		}
+	}
+	sort.Ints(years)
+	return years
+}
+
+// plural picks the singular or plural form of a word for a count
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
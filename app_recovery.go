@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+)
+
+// =============================================================================
+// TURN FILE RECOVERY
+// =============================================================================
+
+// turnFileRecoveryDelay is how long a removed turn file is given to come back
+// before it is re-downloaded: files replaced atomically (by Astrum or a sync
+// tool) are briefly seen as removed on some platforms
+const turnFileRecoveryDelay = 2 * time.Second
+
+// recoveryMu serializes recoveries, a file removal and a launch may both
+// notice the same missing files
+var recoveryMu sync.Mutex
+
+// missingTurnFiles returns the essential files of a game directory that are
+// not there: the universe file and the turn file of the player (1-indexed)
+func missingTurnFiles(gameDir string, playerNumber int) []string {
+	var missing []string
+	for _, name := range []string{"game.xy", fmt.Sprintf("game.m%d", playerNumber)} {
+		if _, err := os.Stat(monitor.ResolveFile(gameDir, name)); os.IsNotExist(err) {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// recoverTurnFiles re-downloads the universe and turn files of the current
+// year of a session when they vanished from the game directory (user cleanup,
+// antivirus), and emits "turn:recovered" (serverURL, sessionID, year, files).
+// Returns the files recovered, none when nothing was missing.
+func (a *App) recoverTurnFiles(serverURL, sessionID string) ([]string, error) {
+	recoveryMu.Lock()
+	defer recoveryMu.Unlock()
+
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return nil, fmt.Errorf("no user info available")
+	}
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	playerNumber := findPlayerNumber(session, userInfo.User.ID)
+	if playerNumber == 0 {
+		return nil, nil // Not playing, no turn file expected
+	}
+	missing := missingTurnFiles(gameDir, playerNumber)
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	logger.App.Warn().Str("sessionId", sessionID).Strs("files", missing).Msg("Turn files missing, downloading them again")
+
+	// The stored hashes would make the download skip writing identical files
+	for _, name := range missing {
+		if err := a.fileHashTracker.ForgetFile(serverURL, sessionID, filepath.Join(gameDir, name)); err != nil {
+			logger.App.Warn().Err(err).Str("file", name).Msg("Failed to forget file hash")
+		}
+	}
+
+	latest, err := client.GetLatestTurn(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest turn files: %w", err)
+	}
+	if err := a.saveTurnFiles(serverURL, sessionID, latest.Turn.Universe, latest.Turn.Turn); err != nil {
+		return nil, fmt.Errorf("failed to restore turn files: %w", err)
+	}
+	if still := missingTurnFiles(gameDir, playerNumber); len(still) > 0 {
+		return nil, fmt.Errorf("server has no %v for this session", still)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int64("year", latest.Year).Strs("files", missing).Msg("Recovered turn files")
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("turn:recovered", serverURL, sessionID, int(latest.Year), missing)
+	}
+	return missing, nil
+}
+
+// recoverRemovedTurnFile recovers the turn files of a session after the file
+// watcher saw one removed, unless it came back meanwhile
+func (a *App) recoverRemovedTurnFile(serverURL, sessionID, filePath string) {
+	time.Sleep(turnFileRecoveryDelay)
+	if _, err := os.Stat(filePath); err == nil {
+		return
+	}
+	if _, err := a.recoverTurnFiles(serverURL, sessionID); err != nil {
+		logger.App.Error().Err(err).Str("sessionId", sessionID).Msg("Failed to recover turn files")
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// APPLICATION DATA RESET
+// =============================================================================
+
+// What ResetApplicationData wipes
+const (
+	resetSettings   = "settings"   // app settings back to their defaults
+	resetHashes     = "hashes"     // file hashes, game files are rewritten once
+	resetServers    = "servers"    // servers and their keyring credentials
+	resetEverything = "everything" // all of the above, the local data and the other keyring secrets
+)
+
+// configExportDir is the directory of the profile holding the configuration
+// exported before each reset
+const configExportDir = "config-exports"
+
+// GetResetConfirmation returns the text to type to confirm a reset of the given scope
+func (a *App) GetResetConfirmation(scope string) string {
+	return resetConfirmation(scope)
+}
+
+// resetConfirmation is the text confirming a reset of scope
+func resetConfirmation(scope string) string {
+	return "reset " + scope
+}
+
+// ResetApplicationData wipes the selected data for a clean slate: the
+// settings only, the file hashes only, all servers, or everything including
+// the keyring secrets. Game directories stay on disk. Confirmation must be
+// the text of GetResetConfirmation, typed by the user. The current settings and
+// servers are exported to the profile directory first, and nothing is reset
+// if that fails. Failures once wiping began are listed in the summary.
+func (a *App) ResetApplicationData(opts ResetOptions) (*ResetSummaryInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	switch opts.Scope {
+	case resetSettings, resetHashes, resetServers, resetEverything:
+	default:
+		return nil, fmt.Errorf("unknown reset scope: %s", opts.Scope)
+	}
+	if want := resetConfirmation(opts.Scope); opts.Confirmation != want {
+		return nil, fmt.Errorf("type %q to confirm the reset", want)
+	}
+
+	exportPath, err := a.exportConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to export the current configuration, nothing was reset: %w", err)
+	}
+
+	summary := &ResetSummaryInfo{
+		Scope:      opts.Scope,
+		ExportedTo: exportPath,
+		Steps:      []string{fmt.Sprintf("Exported the configuration to %s", exportPath)},
+		Errors:     []string{},
+	}
+	step := func(done string, err error) {
+		if err != nil {
+			summary.Errors = append(summary.Errors, err.Error())
+			return
+		}
+		summary.Steps = append(summary.Steps, done)
+	}
+
+	if opts.Scope == resetServers || opts.Scope == resetEverything {
+		a.removeAllServers(summary)
+	}
+	if opts.Scope == resetHashes || opts.Scope == resetEverything {
+		step("Forgot the file hashes", a.fileHashTracker.Clear())
+	}
+	if opts.Scope == resetEverything {
+		a.stopLocalAPI()
+		step("Deleted the local API token", a.config.CredentialStore().DeleteLocalAPIToken())
+		step("Deleted the intel signing key", a.config.CredentialStore().DeleteIntelSigningKey())
+		step("Cleared the local data", a.config.ClearData())
+	}
+	if opts.Scope == resetSettings || opts.Scope == resetEverything {
+		step("Reset the settings to their defaults", a.config.ResetAppSettings())
+		summary.RestartRequired = true
+	}
+	if opts.Scope == resetServers || opts.Scope == resetEverything {
+		step("Added the default server", a.EnsureDefaultServer())
+	}
+
+	logger.App.Warn().
+		Str("scope", opts.Scope).
+		Str("exportedTo", exportPath).
+		Int("errors", len(summary.Errors)).
+		Msg("Reset application data")
+	return summary, nil
+}
+
+// removeAllServers removes every server and its keyring credentials, leaving
+// the game directories on disk
+func (a *App) removeAllServers(summary *ResetSummaryInfo) {
+	servers, err := a.config.GetServers()
+	if err != nil {
+		summary.Errors = append(summary.Errors, fmt.Sprintf("failed to get servers: %v", err))
+		return
+	}
+
+	for _, server := range servers {
+		removal, err := a.RemoveServerWithCleanup(server.URL, RemoveServerOptions{DeleteCredentials: true, GameDirs: gameDirsKeep})
+		if err != nil {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("failed to remove %s: %v", server.Name, err))
+			continue
+		}
+		summary.Steps = append(summary.Steps, fmt.Sprintf("Removed %s and its credentials", server.Name))
+		summary.Errors = append(summary.Errors, removal.Errors...)
+	}
+}
+
+// exportConfig writes the current settings and servers to the export
+// directory of the profile and returns the file path. It is private to the
+// user: the shared directory permissions of safefile do not apply.
+func (a *App) exportConfig() (string, error) {
+	data, err := a.config.ExportConfig()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(astrum.ProfilePath(a.profile), configExportDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create export directory: %w", err)
+	}
+	path := filepath.Join(dir, "config-"+time.Now().Format("20060102-150405")+".json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write config export: %w", err)
+	}
+	return path, nil
+}
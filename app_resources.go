@@ -0,0 +1,75 @@
+package main
+
+import (
+	goruntime "runtime"
+	"runtime/debug"
+	"sync/atomic"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// PROCESSING BUDGET
+// =============================================================================
+
+// maxConcurrentParses is how many turns are parsed or rendered at once. A
+// turn of a huge game takes tens of MB once parsed, so bulk jobs (every year
+// of a session, animated maps) wait for a slot instead of piling up.
+const maxConcurrentParses = 2
+
+var (
+	parseSlots   = make(chan struct{}, maxConcurrentParses)
+	activeParses atomic.Int32
+)
+
+// acquireParse waits for a parse slot, the returned func releasing it
+func acquireParse() (release func()) {
+	parseSlots <- struct{}{}
+	activeParses.Add(1)
+	return func() {
+		activeParses.Add(-1)
+		<-parseSlots
+	}
+}
+
+// freeProcessingMemory hands the memory of a finished bulk job back to the OS
+// instead of keeping the heap at its peak size
+func freeProcessingMemory() {
+	debug.FreeOSMemory()
+}
+
+// GetResourceUsage returns the memory and goroutines used by the app, for
+// performance reports
+func (a *App) GetResourceUsage() *ResourceUsageInfo {
+	var stats goruntime.MemStats
+	goruntime.ReadMemStats(&stats)
+
+	return &ResourceUsageInfo{
+		HeapAlloc:    stats.HeapAlloc,
+		HeapInuse:    stats.HeapInuse,
+		HeapReleased: stats.HeapReleased,
+		Sys:          stats.Sys,
+		NumGC:        stats.NumGC,
+		Goroutines:   goruntime.NumGoroutine(),
+		ActiveParses: int(activeParses.Load()),
+		MaxParses:    maxConcurrentParses,
+	}
+}
+
+// FreeMemory drops the in-memory caches, refetched when next needed, and
+// hands the unused memory back to the OS
+func (a *App) FreeMemory() *ResourceUsageInfo {
+	a.mu.Lock()
+	a.profileCaches = make(map[string]*userProfileCache)
+	a.mu.Unlock()
+
+	before := a.GetResourceUsage()
+	freeProcessingMemory()
+	after := a.GetResourceUsage()
+
+	logger.App.Info().
+		Uint64("heapBefore", before.HeapInuse).
+		Uint64("heapAfter", after.HeapInuse).
+		Msg("Freed memory")
+	return after
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/neper-stars/houston/blocks"
+	"github.com/neper-stars/houston/data"
+	"github.com/neper-stars/houston/store"
+)
+
+// =============================================================================
+// ROUTE MEASUREMENT
+// =============================================================================
+
+// ifeFuelFactor is the fuel usage of races with Improved Fuel Efficiency
+const ifeFuelFactor = 0.85
+
+// MeasureRoute measures a path of map points (game coordinates) flown at a warp
+// speed: light-year distances, the year each point is reached and, when asked,
+// the fuel burned. A fleet moves warp² light-years per year and keeps moving
+// past waypoints within a year, so arrival years are counted on the
+// cumulative distance.
+//
+// Fuel uses the Stars! formula mass × engine fuel table × distance / 200000
+// (mg, rounded up per leg). The mass and engine come either from a fleet of
+// the latest local turn or from the request. For fleets mixing designs the
+// least efficient engine is used, which slightly overestimates usage.
+func (a *App) MeasureRoute(request RouteMeasureRequest) (*RouteMeasureInfo, error) {
+	if len(request.Points) < 2 {
+		return nil, fmt.Errorf("a route needs at least two points")
+	}
+	if request.Warp < 1 || request.Warp > 10 {
+		return nil, fmt.Errorf("invalid warp speed %d: must be between 1 and 10", request.Warp)
+	}
+
+	var fuel *RouteFuelInfo
+	var fuelTable int
+	if request.Fuel {
+		var err error
+		fuel, fuelTable, err = a.routeFuel(request)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	speed := float64(request.Warp * request.Warp)
+	result := &RouteMeasureInfo{
+		Warp: request.Warp,
+		Legs: make([]RouteLegInfo, 0, len(request.Points)-1),
+		Fuel: fuel,
+	}
+
+	for i := 1; i < len(request.Points); i++ {
+		from, to := request.Points[i-1], request.Points[i]
+		distance := math.Hypot(float64(to.X-from.X), float64(to.Y-from.Y))
+		result.TotalDistance += distance
+
+		leg := RouteLegInfo{
+			From:        from,
+			To:          to,
+			Distance:    distance,
+			ArrivalYear: int(math.Ceil(result.TotalDistance / speed)),
+		}
+		if fuel != nil {
+			leg.Fuel = legFuel(fuel.Mass, fuelTable, distance, fuel.IFE)
+			fuel.Used += leg.Fuel
+		}
+		result.Legs = append(result.Legs, leg)
+	}
+
+	result.TotalYears = int(math.Ceil(result.TotalDistance / speed))
+	if fuel != nil && fuel.Available >= 0 {
+		fuel.Enough = fuel.Used <= fuel.Available
+	}
+
+	return result, nil
+}
+
+// routeFuel resolves the mass, engine and available fuel of a route request
+func (a *App) routeFuel(request RouteMeasureRequest) (*RouteFuelInfo, int, error) {
+	if request.Fleet == nil {
+		engine := findEngine(request.Engine)
+		if engine == nil {
+			return nil, 0, fmt.Errorf("unknown engine: %s", request.Engine)
+		}
+		if request.Mass <= 0 {
+			return nil, 0, fmt.Errorf("a mass is required to compute fuel usage")
+		}
+		return &RouteFuelInfo{
+			Engine:    engine.Name,
+			Mass:      request.Mass,
+			IFE:       request.IFE,
+			Available: -1,
+		}, engine.FuelPerMg[request.Warp], nil
+	}
+
+	gameDir, err := a.sessionGameDir(request.ServerURL, request.SessionID)
+	if err != nil {
+		return nil, 0, err
+	}
+	gs, err := loadLocalGameStore(gameDir)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	fleet, ok := gs.Fleet(request.Fleet.Owner, request.Fleet.FleetNumber)
+	if !ok {
+		return nil, 0, fmt.Errorf("fleet %d of player %d not found", request.Fleet.FleetNumber, request.Fleet.Owner)
+	}
+
+	engine := leastEfficientEngine(gs, fleet, request.Warp)
+	if engine == nil {
+		return nil, 0, fmt.Errorf("the engines of %s are unknown", fleet.Name())
+	}
+
+	ife := false
+	if player, ok := gs.Player(fleet.Owner); ok && player.HasFullData {
+		ife = player.HasLRT(blocks.LRTImprovedFuelEfficiency)
+	}
+
+	return &RouteFuelInfo{
+		Engine:    engine.Name,
+		Mass:      fleet.GetTotalMass(gs),
+		IFE:       ife,
+		Available: fleet.GetCargo().Fuel,
+	}, engine.FuelPerMg[request.Warp], nil
+}
+
+// leastEfficientEngine returns the engine of the fleet burning the most fuel at a warp
+func leastEfficientEngine(gs *store.GameStore, fleet *store.FleetEntity, warp int) *data.Engine {
+	var worst *data.Engine
+	for _, info := range fleet.GetDesigns(gs) {
+		if info.Design == nil {
+			continue
+		}
+		engine := info.Design.GetEngine()
+		if engine != nil && (worst == nil || engine.FuelPerMg[warp] > worst.FuelPerMg[warp]) {
+			worst = engine
+		}
+	}
+	return worst
+}
+
+// findEngine looks an engine up by name, ignoring case
+func findEngine(name string) *data.Engine {
+	for _, engine := range data.Engines {
+		if strings.EqualFold(engine.Name, name) {
+			return engine
+		}
+	}
+	return nil
+}
+
+// legFuel is the fuel in mg burned by a mass flying a distance
+func legFuel(mass int64, fuelTable int, distance float64, ife bool) int64 {
+	fuel := float64(mass) * float64(fuelTable) * distance / 200000
+	if ife {
+		fuel *= ifeFuelFactor
+	}
+	return int64(math.Ceil(fuel))
+}
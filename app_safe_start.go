@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// =============================================================================
+// SAFE START
+// =============================================================================
+
+// startupMarkerFile counts the startups of a profile that did not get through,
+// in its directory. It is written when the app starts and removed once the
+// window stayed up for startupGracePeriod, or when the app exits.
+const startupMarkerFile = ".astrum-startup"
+
+// crashLoopThreshold is how many startups in a row must fail before the next
+// one is a safe start
+const crashLoopThreshold = 3
+
+// startupGracePeriod is how long the window must stay up for a startup to count as successful
+const startupGracePeriod = 30 * time.Second
+
+// GetSafeModeReason tells why the app started in safe mode after crashing
+// during its last startups, empty after a normal start. A safe start makes no
+// connection on startup and watches no order files: the UI offers resetting
+// the settings that may cause the crashes, then restarting. Servers can still
+// be connected by hand.
+func (a *App) GetSafeModeReason() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.safeStartReason
+}
+
+// inSafeStart returns whether the app started in safe mode after crashing
+func (a *App) inSafeStart() bool {
+	return a.GetSafeModeReason() != ""
+}
+
+// beginStartup counts this startup in the marker of the profile, and turns
+// safe start on when the previous ones did not get through
+func (a *App) beginStartup() {
+	path := filepath.Join(astrum.ProfilePath(a.profile), startupMarkerFile)
+	failed, err := countStartup(path)
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to update startup marker")
+	}
+	if failed < crashLoopThreshold {
+		return
+	}
+
+	reason := fmt.Sprintf("Astrum did not start properly the last %d times, so it started without connecting "+
+		"to servers nor watching order files. Resetting the settings may fix the crashes.", failed)
+	a.mu.Lock()
+	a.safeStartReason = reason
+	a.mu.Unlock()
+	logger.App.Warn().Int("failedStartups", failed).Msg("Safe start: auto-connect and order monitoring disabled")
+}
+
+// confirmStartup removes the startup marker once the window stayed up long
+// enough, or right away when the app exits
+func (a *App) confirmStartup() {
+	path := filepath.Join(astrum.ProfilePath(a.profile), startupMarkerFile)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.App.Warn().Err(err).Msg("Failed to remove startup marker")
+	}
+}
+
+// countStartup increments the startup marker at path and returns how many
+// startups failed before this one
+func countStartup(path string) (int, error) {
+	failed := 0
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+	if err == nil {
+		// A damaged marker counts as no failed startup
+		failed, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+		failed = max(failed, 0)
+	}
+
+	return failed, safefile.WriteFile(path, []byte(strconv.Itoa(failed+1)), 0644)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountStartup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), startupMarkerFile)
+
+	for want := 0; want < 3; want++ {
+		failed, err := countStartup(path)
+		require.NoError(t, err)
+		assert.Equal(t, want, failed)
+	}
+
+	// A removed marker means the last startup got through
+	require.NoError(t, os.Remove(path))
+	failed, err := countStartup(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, failed)
+}
+
+func TestCountStartupDamagedMarker(t *testing.T) {
+	path := filepath.Join(t.TempDir(), startupMarkerFile)
+	require.NoError(t, os.WriteFile(path, []byte("garbage"), 0644))
+
+	failed, err := countStartup(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, failed)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(data))
+}
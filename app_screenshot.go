@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	goruntime "runtime"
+	"strings"
+	"time"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// SCREENSHOTS
+// =============================================================================
+
+// screenshotsDirName is the game directory subfolder holding captured screenshots
+const screenshotsDirName = "screenshots"
+
+// starsWindowTitle is the title of the Stars! main window, natively or under Wine
+const starsWindowTitle = "Stars!"
+
+// windowsScreenshotScript captures the Stars! window with .NET. The output path
+// is a PowerShell single-quoted literal, formatted in with psQuote.
+const windowsScreenshotScript = `Add-Type -AssemblyName System.Drawing
+Add-Type @"
+using System;
+using System.Runtime.InteropServices;
+public struct StarsRect { public int Left, Top, Right, Bottom; }
+public static class StarsWindow {
+    [DllImport("user32.dll")] public static extern bool GetWindowRect(IntPtr hWnd, out StarsRect rect);
+}
+"@
+$p = Get-Process | Where-Object { $_.MainWindowTitle -like 'Stars!*' } | Select-Object -First 1
+if (-not $p) { Write-Error 'Stars! window not found, is the game running?'; exit 1 }
+$r = New-Object StarsRect
+[void][StarsWindow]::GetWindowRect($p.MainWindowHandle, [ref]$r)
+$bmp = New-Object System.Drawing.Bitmap ($r.Right - $r.Left), ($r.Bottom - $r.Top)
+$g = [System.Drawing.Graphics]::FromImage($bmp)
+$g.CopyFromScreen($r.Left, $r.Top, 0, 0, $bmp.Size)
+$bmp.Save(%s, [System.Drawing.Imaging.ImageFormat]::Png)`
+
+// CaptureStarsScreenshot takes a screenshot of the Stars! window and saves it as
+// a PNG in the session's screenshots folder. Returns the file path so it can be
+// attached to reports.
+// Uses screencapture on macOS (whole screen), PowerShell on Windows and import
+// (ImageMagick) elsewhere, with xdotool to find the Wine window when installed.
+func (a *App) CaptureStarsScreenshot(serverURL, sessionID string) (string, error) {
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL
+	if server != nil {
+		serverName = server.Name
+	}
+
+	gameDir, err := a.config.EnsureSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	dir := filepath.Join(gameDir, screenshotsDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create screenshots directory: %w", err)
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("screenshot-%s.png", time.Now().Format("20060102-150405")))
+
+	cmd, err := screenshotCommand(path)
+	if err != nil {
+		return "", err
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to capture screenshot: %w: %s", err, output)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("screenshot was not written: %w", err)
+	}
+
+	logger.App.Info().Str("path", path).Str("sessionId", sessionID).Msg("Captured screenshot")
+	return path, nil
+}
+
+// screenshotCommand returns the platform command capturing the Stars! window to path
+func screenshotCommand(path string) (*exec.Cmd, error) {
+	switch goruntime.GOOS {
+	case "darwin":
+		return exec.Command("screencapture", "-x", path), nil
+	case "windows":
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+			fmt.Sprintf(windowsScreenshotScript, psQuote(path))), nil
+	default: // linux and others, Stars! runs under Wine
+		if _, err := exec.LookPath("import"); err != nil {
+			return nil, fmt.Errorf("no screenshot tool found, install ImageMagick")
+		}
+		window, err := starsWindowID()
+		if err != nil {
+			return nil, err
+		}
+		return exec.Command("import", "-window", window, path), nil
+	}
+}
+
+// starsWindowID returns the X11 window of Stars! for import -window: its ID
+// found with xdotool, or its title for import to look up without xdotool
+func starsWindowID() (string, error) {
+	if _, err := exec.LookPath("xdotool"); err != nil {
+		return starsWindowTitle, nil
+	}
+
+	output, err := exec.Command("xdotool", "search", "--onlyvisible", "--name", "^"+regexp.QuoteMeta(starsWindowTitle)).Output()
+	ids := strings.Fields(string(output))
+	if err != nil || len(ids) == 0 {
+		return "", fmt.Errorf("Stars! window not found, is the game running?")
+	}
+	return ids[0], nil
+}
+
+// psQuote returns s as a PowerShell single-quoted string literal
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// ListSessionScreenshots returns the paths of the screenshots captured for a session, oldest first
+func (a *App) ListSessionScreenshots(serverURL, sessionID string) ([]string, error) {
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL
+	if server != nil {
+		serverName = server.Name
+	}
+
+	gameDir, err := a.config.GetSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(gameDir, screenshotsDirName, "*.png"))
+	if err != nil {
+		return nil, err
+	}
+	if matches == nil {
+		matches = []string{}
+	}
+
+	return matches, nil
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	goruntime "runtime"
+	"sync"
+	"time"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// SELF-TEST
+// =============================================================================
+
+// Self-test check outcomes
+const (
+	selfTestPass = "pass"
+	selfTestFail = "fail"
+	selfTestSkip = "skip" // not applicable, e.g. websocket of a disconnected server
+)
+
+// selfTestNetworkTimeout bounds each network check
+const selfTestNetworkTimeout = 10 * time.Second
+
+// RunSelfTest exercises the local stack (database, keyring, servers directory,
+// Wine or OTVDM) and the network path to each server, and returns a pass/fail
+// report to attach to support requests
+func (a *App) RunSelfTest() *SelfTestReportInfo {
+	report := &SelfTestReportInfo{
+		StartedAt: time.Now().Format(time.RFC3339),
+		Passed:    true,
+		Checks:    []SelfTestCheckInfo{},
+	}
+
+	report.add(runSelfTestCheck("database", "", func() (string, error) {
+		return "read, write and delete succeeded", a.config.CheckDatabase()
+	}))
+	report.add(runSelfTestCheck("keyring", "", func() (string, error) {
+		return "set, get and delete succeeded", a.config.CredentialStore().Check()
+	}))
+	report.add(runSelfTestCheck("serversDir", "", func() (string, error) {
+		dir, err := a.config.CheckServersDir()
+		return dir + " is writable", err
+	}))
+	report.add(a.selfTestLauncher())
+
+	servers, err := a.config.GetServers()
+	if err != nil {
+		report.add(SelfTestCheckInfo{Name: "servers", Status: selfTestFail, Message: err.Error()})
+	}
+
+	// Servers are checked concurrently, as unreachable ones wait for the timeout
+	results := make([][]SelfTestCheckInfo, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = []SelfTestCheckInfo{
+				runSelfTestCheck("reachability", server.URL, func() (string, error) {
+					return selfTestReachability(server.URL)
+				}),
+				a.selfTestWebSocket(server.URL),
+			}
+		}()
+	}
+	wg.Wait()
+	for _, checks := range results {
+		for _, check := range checks {
+			report.add(check)
+		}
+	}
+
+	logger.App.Info().Bool("passed", report.Passed).Int("checks", len(report.Checks)).Msg("Self-test completed")
+	return report
+}
+
+// add appends a check to the report, failing the report if the check failed
+func (r *SelfTestReportInfo) add(check SelfTestCheckInfo) {
+	r.Checks = append(r.Checks, check)
+	if check.Status == selfTestFail {
+		r.Passed = false
+	}
+}
+
+// runSelfTestCheck times a check, which passes when fn returns no error
+func runSelfTestCheck(name, target string, fn func() (string, error)) SelfTestCheckInfo {
+	start := time.Now()
+	message, err := fn()
+	check := SelfTestCheckInfo{
+		Name:       name,
+		Target:     target,
+		Status:     selfTestPass,
+		Message:    message,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		check.Status = selfTestFail
+		check.Message = err.Error()
+	}
+	return check
+}
+
+// selfTestLauncher checks what runs stars.exe: OTVDM on Windows, Wine elsewhere
+func (a *App) selfTestLauncher() SelfTestCheckInfo {
+	if goruntime.GOOS == "windows" {
+		return runSelfTestCheck("otvdm", "", func() (string, error) {
+			result, err := a.CheckNtvdmSupport()
+			if err != nil {
+				return "", err
+			}
+			if !result.Available {
+				return "", fmt.Errorf("%s", result.Message)
+			}
+			return result.Message, nil
+		})
+	}
+
+	useWine, err := a.config.GetUseWine()
+	if err != nil {
+		return SelfTestCheckInfo{Name: "wine", Status: selfTestFail, Message: err.Error()}
+	}
+	if !useWine {
+		return SelfTestCheckInfo{Name: "wine", Status: selfTestSkip, Message: "Wine is disabled in settings"}
+	}
+	return runSelfTestCheck("wine", "", func() (string, error) {
+		result, err := a.CheckWine32Support()
+		if err != nil {
+			return "", err
+		}
+		if !result.Valid {
+			return "", fmt.Errorf("%s", result.Message)
+		}
+		return result.Message, nil
+	})
+}
+
+// selfTestReachability checks that a server answers HTTP requests. Any
+// response counts, as the server root may not be an API endpoint.
+func selfTestReachability(serverURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), selfTestNetworkTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serverURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid server URL: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("server is unreachable: %w", err)
+	}
+	_ = resp.Body.Close()
+	return fmt.Sprintf("server answered with HTTP %d", resp.StatusCode), nil
+}
+
+// selfTestWebSocket opens and closes a separate notification connection to a
+// connected server, leaving the one in use untouched
+func (a *App) selfTestWebSocket(serverURL string) SelfTestCheckInfo {
+	a.mu.RLock()
+	mgr, ok := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgr.IsConnected() {
+		return SelfTestCheckInfo{
+			Name:    "websocket",
+			Target:  serverURL,
+			Status:  selfTestSkip,
+			Message: "not connected to server",
+		}
+	}
+
+	return runSelfTestCheck("websocket", serverURL, func() (string, error) {
+		client := api.NewNotificationClient(serverURL)
+		if err := client.Connect(mgr.GetToken()); err != nil {
+			return "", fmt.Errorf("websocket handshake failed: %w", err)
+		}
+		client.Close()
+		return "handshake succeeded", nil
+	})
+}
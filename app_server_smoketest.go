@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"time"
+
+	hs "github.com/neper-stars/houston"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// SERVER SMOKE TEST
+// =============================================================================
+
+// smokeTestTimeout bounds a whole smoke test run, turn generation included
+const smokeTestTimeout = 5 * time.Minute
+
+// smokeTestBots is how many AI players join the smoke test game
+const smokeTestBots = 1
+
+// SetSmokeTestAccount sets the stored credential the smoke test of a server
+// plays with, "" for none. It must be an account of its own: the test creates
+// and deletes a session with it, and the default credential is refused so the
+// games of the user are never at risk.
+func (a *App) SetSmokeTestAccount(serverURL, nickname string) error {
+	server, err := a.config.GetServer(serverURL)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return fmt.Errorf("server with URL %s not found", serverURL)
+	}
+
+	if nickname != "" {
+		known := slices.ContainsFunc(server.CredentialRefs, func(ref model.CredentialRef) bool {
+			return ref.NickName == nickname
+		})
+		if !known {
+			return fmt.Errorf("no stored credential for %s on this server", nickname)
+		}
+		if defaultCred := server.GetDefaultCredentialRef(); defaultCred != nil && defaultCred.NickName == nickname {
+			return fmt.Errorf("the smoke test needs an account of its own, not the default one")
+		}
+	}
+
+	server.SmokeTestCredName = nickname
+	if err := a.config.UpdateServer(*server); err != nil {
+		return fmt.Errorf("failed to update server: %w", err)
+	}
+
+	logger.App.Info().Str("url", serverURL).Str("nickname", nickname).Msg("Set smoke test account")
+	return nil
+}
+
+// RunServerSmokeTest plays a whole game cycle on a server with its smoke test
+// account, for server admins to validate a deployment: authenticate, create a
+// session, set rules, join with the first race of the account, add an AI
+// player, start the game, download the turn, submit empty orders and delete
+// the session. The test uses a client of its own, leaving the connections of
+// the app alone. Steps after a failed one are skipped, except the deletion of
+// the session once created. Each step is emitted as "smoketest:step"
+// (serverURL, step) as it completes.
+func (a *App) RunServerSmokeTest(serverURL string) (*SelfTestReportInfo, error) {
+	server, err := a.config.GetServer(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server with URL %s not found", serverURL)
+	}
+	nickname := server.SmokeTestCredName
+	if nickname == "" {
+		return nil, fmt.Errorf("no smoke test account set for this server")
+	}
+	apiKey, err := a.config.GetCredential(serverURL, nickname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the keyring: %w", err)
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("API key of %s not found in the keyring", nickname)
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, smokeTestTimeout)
+	defer cancel()
+
+	report := &SelfTestReportInfo{
+		StartedAt: time.Now().Format(time.RFC3339),
+		Passed:    true,
+		Checks:    []SelfTestCheckInfo{},
+	}
+	add := func(check SelfTestCheckInfo) {
+		report.add(check)
+		a.mu.RLock()
+		shuttingDown := a.shuttingDown
+		a.mu.RUnlock()
+		if !shuttingDown {
+			a.emit("smoketest:step", serverURL, check)
+		}
+	}
+	step := func(name string, fn func() (string, error)) {
+		if !report.Passed {
+			add(SelfTestCheckInfo{Name: name, Target: serverURL, Status: selfTestSkip, Message: "Skipped after a failed step"})
+			return
+		}
+		add(runSelfTestCheck(name, serverURL, fn))
+	}
+
+	client := api.NewClient(serverURL)
+	var (
+		userID    string
+		sessionID string
+		year      int
+		turn      *api.TurnFiles
+	)
+
+	step("authenticate", func() (string, error) {
+		if _, err := client.Authenticate(ctx, nickname, apiKey); err != nil {
+			return "", err
+		}
+		client.SetCredentials(nickname, apiKey)
+		userInfo, err := client.GetUserInfo(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get user info: %w", err)
+		}
+		userID = userInfo.User.ID
+		return fmt.Sprintf("Authenticated as %s", nickname), nil
+	})
+
+	step("create_session", func() (string, error) {
+		session, err := client.CreateSession(ctx, &api.Session{
+			Name:    fmt.Sprintf("Astrum smoke test %s", time.Now().Format("20060102-150405")),
+			Private: true,
+		})
+		if err != nil {
+			return "", err
+		}
+		sessionID = session.ID
+		return fmt.Sprintf("Created session %s", session.ID), nil
+	})
+
+	step("set_rules", func() (string, error) {
+		// The smallest and fastest universe
+		_, err := client.CreateRules(ctx, sessionID, &api.Ruleset{
+			VcWinnerMustMeetxOfTheAbove:                    1,
+			VcAtLeastxYearsMustPassBeforeaWinnerIsDeclared: 50,
+		})
+		if err != nil {
+			return "", err
+		}
+		return "Rules set", nil
+	})
+
+	step("join_race", func() (string, error) {
+		races, err := client.ListRaces(ctx, userID)
+		if err != nil {
+			return "", fmt.Errorf("failed to list races: %w", err)
+		}
+		if len(races) == 0 {
+			return "", fmt.Errorf("the smoke test account has no race, upload one first")
+		}
+		if _, err := client.SetSessionPlayerRace(ctx, sessionID, &api.SessionPlayerRace{RaceID: races[0].ID}); err != nil {
+			return "", fmt.Errorf("failed to select race: %w", err)
+		}
+		if _, err := client.SetPlayerReady(ctx, sessionID, true); err != nil {
+			return "", fmt.Errorf("failed to set ready: %w", err)
+		}
+		return fmt.Sprintf("Joined with %s", races[0].NamePlural), nil
+	})
+
+	step("add_bots", func() (string, error) {
+		level := int64(1)
+		for range smokeTestBots {
+			bot := &api.SessionPlayerRace{RaceID: "0", IsBot: true, BotLevel: &level}
+			if _, err := client.SetSessionPlayerRace(ctx, sessionID, bot); err != nil {
+				return "", err
+			}
+		}
+		return fmt.Sprintf("Added %d AI player(s)", smokeTestBots), nil
+	})
+
+	step("start", func() (string, error) {
+		if _, err := client.InitializeGame(ctx, sessionID); err != nil {
+			return "", err
+		}
+		return "Game started", nil
+	})
+
+	step("download_turn", func() (string, error) {
+		latest, err := client.GetLatestTurn(ctx, sessionID)
+		if err != nil {
+			return "", err
+		}
+		if latest.Turn == nil || latest.Turn.Turn == "" {
+			return "", fmt.Errorf("the server sent no turn file")
+		}
+		turn, year = latest, int(latest.Year)
+		return fmt.Sprintf("Downloaded the turn of year %d", year), nil
+	})
+
+	step("submit_order", func() (string, error) {
+		data, err := smokeTestOrders(turn)
+		if err != nil {
+			return "", err
+		}
+		order := &api.Order{B64Data: base64.StdEncoding.EncodeToString(data)}
+		if err := client.SubmitTurn(ctx, sessionID, year, order); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Submitted empty orders for year %d", year), nil
+	})
+
+	// The session goes away whatever failed before
+	if sessionID != "" {
+		add(runSelfTestCheck("delete_session", serverURL, func() (string, error) {
+			if err := client.DeleteSession(ctx, sessionID); err != nil {
+				return "", err
+			}
+			return "Session deleted", nil
+		}))
+	} else {
+		add(SelfTestCheckInfo{Name: "delete_session", Target: serverURL, Status: selfTestSkip, Message: "No session was created"})
+	}
+
+	logger.App.Info().
+		Str("url", serverURL).
+		Bool("passed", report.Passed).
+		Int("steps", len(report.Checks)).
+		Msg("Server smoke test completed")
+	return report, nil
+}
+
+// smokeTestOrders builds submitted orders without any command from a turn
+func smokeTestOrders(turn *api.TurnFiles) ([]byte, error) {
+	universe, err := base64.StdEncoding.DecodeString(turn.Turn.Universe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode universe data: %w", err)
+	}
+	turnData, err := base64.StdEncoding.DecodeString(turn.Turn.Turn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode turn data: %w", err)
+	}
+	header, err := hs.FileData(turnData).FileHeader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse turn file: %w", err)
+	}
+
+	gs, err := newGameStore(universe, turnData)
+	if err != nil {
+		return nil, err
+	}
+	data, err := gs.GenerateXFile(header.PlayerIndex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate orders: %w", err)
+	}
+	return data, nil
+}
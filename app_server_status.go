@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// SERVER STATUS
+// =============================================================================
+
+// serverStatusInterval is how often the status of known servers is checked
+const serverStatusInterval = 5 * time.Minute
+
+// serverStatusTimeout bounds a status request, the server may be unreachable
+const serverStatusTimeout = 10 * time.Second
+
+// GetServerStatus checks the health of a server and its planned maintenance
+// windows. It works without being connected, so the frontend can tell why a
+// server is unreachable. Servers without a status endpoint are reported as
+// not supported.
+func (a *App) GetServerStatus(serverURL string) (*ServerStatusInfo, error) {
+	return a.refreshServerStatus(serverURL)
+}
+
+// refreshServerStatus fetches the status of a server and records it. While a
+// maintenance is in progress, the connection failures of the server are only
+// logged at debug level. "server:status" (serverURL, status) is emitted when
+// the status changed, and the missed turns are reconciled once a maintenance
+// is over.
+func (a *App) refreshServerStatus(serverURL string) (*ServerStatusInfo, error) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	a.mu.RUnlock()
+	if !ok {
+		client = api.NewClient(serverURL)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), serverStatusTimeout)
+	defer cancel()
+
+	status, err := client.GetServerStatus(ctx)
+	if err != nil && !errors.Is(err, api.ErrNotSupported) {
+		return nil, fmt.Errorf("failed to get server status: %w", err)
+	}
+	info := serverStatusInfo(status, time.Now())
+
+	a.mu.Lock()
+	previous := a.serverStatuses[serverURL]
+	a.serverStatuses[serverURL] = info
+	notifMgr := a.notificationManagers[serverURL]
+	_, connected := a.clients[serverURL]
+	shuttingDown := a.shuttingDown
+	a.mu.Unlock()
+
+	if notifMgr != nil {
+		notifMgr.SetQuiet(info.InMaintenance)
+	}
+	if previous != nil && sameServerStatus(previous, info) {
+		return info, nil
+	}
+
+	if info.InMaintenance {
+		logger.App.Info().Str("serverUrl", serverURL).Str("message", info.Message).Msg("Server under maintenance")
+	} else if previous != nil && previous.InMaintenance {
+		logger.App.Info().Str("serverUrl", serverURL).Msg("Server maintenance over")
+		if connected {
+			// Turns may have been generated while the syncs were deferred
+			a.queueTurnReconciliation(serverURL)
+		}
+	}
+
+	if !shuttingDown {
+		a.emit("server:status", serverURL, info)
+	}
+	return info, nil
+}
+
+// refreshServerStatusForAll refreshes the status of every server connected or
+// attempted since startup
+func (a *App) refreshServerStatusForAll() {
+	a.mu.RLock()
+	serverURLs := make([]string, 0, len(a.connections))
+	for url := range a.connections {
+		serverURLs = append(serverURLs, url)
+	}
+	a.mu.RUnlock()
+	sort.Strings(serverURLs)
+
+	for _, url := range serverURLs {
+		if _, err := a.refreshServerStatus(url); err != nil {
+			logger.App.Debug().Err(err).Str("serverUrl", url).Msg("Failed to refresh server status")
+		}
+	}
+}
+
+// inMaintenance tells whether a server was last known to be under maintenance.
+// Non-urgent syncs are deferred meanwhile.
+func (a *App) inMaintenance(serverURL string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	status := a.serverStatuses[serverURL]
+	return status != nil && status.InMaintenance
+}
+
+// serverStatusInfo converts a server status, status is nil when the server has
+// no status endpoint. Only the current and upcoming maintenance windows are kept.
+func serverStatusInfo(status *api.ServerStatus, now time.Time) *ServerStatusInfo {
+	if status == nil {
+		return &ServerStatusInfo{Status: api.ServerStatusOK}
+	}
+
+	info := &ServerStatusInfo{
+		Status:    status.Status,
+		Message:   status.Message,
+		Supported: true,
+	}
+	info.InMaintenance = status.Status == api.ServerStatusMaintenance || status.ActiveMaintenance(now) != nil
+	for _, w := range status.Maintenance {
+		if !w.End.After(now) {
+			continue
+		}
+		info.Maintenance = append(info.Maintenance, MaintenanceWindowInfo{
+			Start:  w.Start,
+			End:    w.End,
+			Reason: w.Reason,
+			Active: !now.Before(w.Start),
+		})
+	}
+	sort.Slice(info.Maintenance, func(i, j int) bool { return info.Maintenance[i].Start.Before(info.Maintenance[j].Start) })
+	return info
+}
+
+// sameServerStatus tells whether two statuses would show the same to the user
+func sameServerStatus(x, y *ServerStatusInfo) bool {
+	if x.Status != y.Status || x.Message != y.Message || x.InMaintenance != y.InMaintenance ||
+		x.Supported != y.Supported || len(x.Maintenance) != len(y.Maintenance) {
+		return false
+	}
+	for i, w := range x.Maintenance {
+		v := y.Maintenance[i]
+		if !w.Start.Equal(v.Start) || !w.End.Equal(v.End) || w.Reason != v.Reason || w.Active != v.Active {
+			return false
+		}
+	}
+	return true
+}
+
+// maintenanceError explains a failed connection when the server is under
+// maintenance, and returns err unchanged otherwise
+func (a *App) maintenanceError(serverURL string, err error) error {
+	status, statusErr := a.refreshServerStatus(serverURL)
+	if statusErr != nil || !status.InMaintenance {
+		return err
+	}
+	for _, w := range status.Maintenance {
+		if w.Active {
+			return fmt.Errorf("server under maintenance until %s: %w", w.End.Local().Format("Jan 2 15:04"), err)
+		}
+	}
+	return fmt.Errorf("server under maintenance: %w", err)
+}
@@ -9,6 +9,8 @@ import (
 
 	astrum "github.com/neper-stars/astrum/lib"
 	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+	"github.com/neper-stars/astrum/lib/safefile"
 	"github.com/neper-stars/astrum/model"
 )
 
@@ -77,7 +79,7 @@ func (a *App) IsDefaultServer(serverURL string) bool {
 
 // AddDefaultServer adds the default Neper server
 func (a *App) AddDefaultServer() (*ServerInfo, error) {
-	return a.AddServer(DefaultServerName, DefaultServerURL)
+	return a.AddServer(DefaultServerName, DefaultServerURL, false)
 }
 
 // =============================================================================
@@ -101,30 +103,70 @@ func (a *App) GetServers() ([]ServerInfo, error) {
 
 	result := make([]ServerInfo, len(servers))
 	for i, srv := range servers {
-		defaultCred := srv.GetDefaultCredentialRef()
-		result[i] = ServerInfo{
-			URL:            srv.URL,
-			Name:           srv.Name,
-			IconURL:        srv.IconURL,
-			HasCredentials: len(srv.CredentialRefs) > 0,
-			IsConnected:    a.connections[srv.URL] != nil && a.connections[srv.URL].Connected,
-			Order:          srv.Order,
-		}
-		if defaultCred != nil {
-			result[i].DefaultUsername = defaultCred.NickName
-		}
+		result[i] = a.serverInfoLocked(&srv)
 	}
 
 	return result, nil
 }
 
-// AddServer adds a new server
-func (a *App) AddServer(name, url string) (*ServerInfo, error) {
+// ErrServerDuplicate is returned when adding a server already configured under another URL form
+var ErrServerDuplicate = errors.New("duplicate server")
+
+// serverInfo converts a configured server for the frontend
+func (a *App) serverInfo(srv *model.Server) *ServerInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	info := a.serverInfoLocked(srv)
+	return &info
+}
+
+// serverInfoLocked is serverInfo for callers holding a.mu
+func (a *App) serverInfoLocked(srv *model.Server) ServerInfo {
+	info := ServerInfo{
+		URL:            srv.URL,
+		Name:           srv.Name,
+		IconURL:        srv.IconURL,
+		HasCredentials: len(srv.CredentialRefs) > 0,
+		IsConnected:    a.connections[srv.URL] != nil && a.connections[srv.URL].Connected,
+		Order:          srv.Order,
+		AutoConnect:    srv.GetAutoConnectOnStartup(),
+		GamesDir:       srv.GamesDir,
+		SmokeTestUser:  srv.SmokeTestCredName,
+		ShareGameStats: srv.ShareGameStats,
+	}
+	if defaultCred := srv.GetDefaultCredentialRef(); defaultCred != nil {
+		info.DefaultUsername = defaultCred.NickName
+	}
+	return info
+}
+
+// AddServer adds a new server. A URL pointing to an already configured server
+// (same host and path, whatever the scheme, case or trailing slash) is refused,
+// unless merge is true: the existing entry is then returned unchanged, keeping
+// its credentials and game directories.
+func (a *App) AddServer(name, url string, merge bool) (*ServerInfo, error) {
 	// Validate server name
 	if err := a.config.ValidateServerName(name); err != nil {
 		return nil, fmt.Errorf("invalid server name: the name must contain valid characters")
 	}
 
+	url, err := astrum.NormalizeServerURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicate, err := a.config.FindDuplicateServer(url, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check server URL: %w", err)
+	}
+	if duplicate != nil {
+		if !merge {
+			return nil, fmt.Errorf("server '%s' already uses this URL (%s): %w", duplicate.Name, duplicate.URL, ErrServerDuplicate)
+		}
+		logger.App.Info().Str("url", url).Str("existing", duplicate.URL).Msg("Merged duplicate server into existing entry")
+		return a.serverInfo(duplicate), nil
+	}
+
 	// Check for server name collision (sanitized names must be unique for directories/prefixes)
 	conflictingName, err := a.config.CheckServerNameCollision(name, "")
 	if err != nil {
@@ -167,11 +209,18 @@ func (a *App) AddServer(name, url string) (*ServerInfo, error) {
 		HasCredentials: false,
 		IsConnected:    false,
 		Order:          newOrder,
+		AutoConnect:    server.GetAutoConnectOnStartup(),
 	}, nil
 }
 
-// UpdateServer updates an existing server
+// UpdateServer updates an existing server, connected or not. A new name moves
+// the server directory, wine prefix and the data keyed by them; the order file
+// monitors are paused meanwhile and a failed step rolls the migration back.
 func (a *App) UpdateServer(oldURL, name, newURL string) error {
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
+
 	// Validate server name
 	if err := a.config.ValidateServerName(name); err != nil {
 		return fmt.Errorf("invalid server name: the name must contain valid characters")
@@ -186,20 +235,21 @@ func (a *App) UpdateServer(oldURL, name, newURL string) error {
 		return fmt.Errorf("server with URL %s not found", oldURL)
 	}
 
+	newURL, err = astrum.NormalizeServerURL(newURL)
+	if err != nil {
+		return err
+	}
+	duplicate, err := a.config.FindDuplicateServer(newURL, oldURL)
+	if err != nil {
+		return fmt.Errorf("failed to check server URL: %w", err)
+	}
+	if duplicate != nil {
+		return fmt.Errorf("server '%s' already uses this URL (%s): %w", duplicate.Name, duplicate.URL, ErrServerDuplicate)
+	}
+
 	// Check if name is changing
 	nameChanging := server.Name != name
-
-	// Prevent renaming a connected server to avoid file conflicts
-	if nameChanging {
-		a.mu.RLock()
-		conn := a.connections[oldURL]
-		isConnected := conn != nil && conn.Connected
-		a.mu.RUnlock()
-
-		if isConnected {
-			return fmt.Errorf("cannot rename a connected server - please disconnect first")
-		}
-	}
+	oldName := server.Name
 
 	// Check for server name collision if name is changing
 	if nameChanging {
@@ -210,20 +260,85 @@ func (a *App) UpdateServer(oldURL, name, newURL string) error {
 			}
 			return fmt.Errorf("failed to check server name: %w", err)
 		}
+	}
+
+	// Order file watchers hold paths and the URL of the server: stop them
+	// during the migration and watch the sessions again once it is over
+	paused := a.pauseOrderMonitors(oldURL)
+	resumeURL, resumeName := oldURL, oldName
+	defer func() { a.resumeOrderMonitors(resumeURL, resumeName, paused) }()
+
+	// Migrate the directories and the keys built from the server name,
+	// undoing the steps already done if one fails
+	var undo []func()
+	rollback := func() {
+		for i := len(undo) - 1; i >= 0; i-- {
+			undo[i]()
+		}
+	}
+
+	if nameChanging {
+		// Resolved once, the server is only found under its old name until saved
+		root, err := a.config.GetServerGamesRoot(oldName)
+		if err != nil {
+			return fmt.Errorf("failed to get server games directory: %w", err)
+		}
 
-		// Rename server directory if it exists
-		if err := a.renameServerDirectory(server.Name, name); err != nil {
+		if err := a.renameServerDirectory(root, oldName, name); err != nil {
 			return fmt.Errorf("failed to rename server directory: %w", err)
 		}
+		undo = append(undo, func() {
+			if err := a.renameServerDirectory(root, name, oldName); err != nil {
+				logger.App.Error().Err(err).Msg("Failed to restore server directory")
+			}
+		})
 
-		// Rename wine prefix directory if it exists
-		if err := a.renameWinePrefixDirectory(server.Name, name); err != nil {
-			// Log warning but don't fail - wine prefix might not exist
-			logger.App.Warn().Err(err).Msg("Failed to rename wine prefix directory")
+		if err := a.renameWinePrefixDirectory(oldName, name); err != nil {
+			rollback()
+			return fmt.Errorf("failed to rename wine prefix directory: %w", err)
 		}
+		undo = append(undo, func() {
+			if err := a.renameWinePrefixDirectory(name, oldName); err != nil {
+				logger.App.Error().Err(err).Msg("Failed to restore wine prefix directory")
+			}
+		})
+
+		if err := a.config.MoveSessionDirAliases(oldName, name); err != nil {
+			rollback()
+			return fmt.Errorf("failed to migrate session directory names: %w", err)
+		}
+		undo = append(undo, func() {
+			if err := a.config.MoveSessionDirAliases(name, oldName); err != nil {
+				logger.App.Error().Err(err).Msg("Failed to restore session directory names")
+			}
+		})
+
+		oldDir := filepath.Join(root, a.config.SanitizeServerName(oldName))
+		newDir := filepath.Join(root, a.config.SanitizeServerName(name))
+		if err := a.fileHashTracker.MoveServerDir(oldURL, oldDir, newDir); err != nil {
+			rollback()
+			return fmt.Errorf("failed to migrate file hashes: %w", err)
+		}
+		undo = append(undo, func() {
+			if err := a.fileHashTracker.MoveServerDir(oldURL, newDir, oldDir); err != nil {
+				logger.App.Error().Err(err).Msg("Failed to restore file hashes")
+			}
+		})
+	}
+
+	// Update server metadata (saved under the new URL, replacing the old entry)
+	updated := *server
+	updated.Name = name
+	updated.URL = newURL
+
+	// Saved with the data keyed by the URL in one transaction
+	if err := a.config.MoveServer(oldURL, updated); err != nil {
+		rollback()
+		return fmt.Errorf("failed to update server: %w", err)
 	}
+	resumeURL, resumeName = newURL, name
 
-	// If URL changed, we need to migrate credentials and remove old server
+	// If URL changed, we need to migrate what lives outside the database
 	if oldURL != newURL {
 		// Migrate credentials to new URL in keyring
 		for _, cred := range server.CredentialRefs {
@@ -236,22 +351,23 @@ func (a *App) UpdateServer(oldURL, name, newURL string) error {
 			}
 		}
 
-		// Remove old server entry
-		if err := a.config.RemoveServer(oldURL); err != nil {
-			logger.App.Warn().Err(err).Msg("Failed to remove old server entry")
+		if serial, err := a.config.CredentialStore().GetSerialKey(oldURL); err == nil && serial != "" {
+			_ = a.config.CredentialStore().SetSerialKey(newURL, serial)
+			_ = a.config.CredentialStore().DeleteSerialKey(oldURL)
 		}
-	}
-
-	// Update server metadata
-	server.Name = name
-	server.URL = newURL
 
-	if err := a.config.AddServer(*server); err != nil {
-		return fmt.Errorf("failed to update server: %w", err)
+		// Keep the hashes of downloaded files and uploaded orders, or everything
+		// would be downloaded again and every order would look new
+		if err := a.fileHashTracker.MigrateServer(oldURL, newURL); err != nil {
+			logger.App.Warn().Err(err).Msg("Failed to migrate file hashes")
+		}
 	}
 
 	// If URL changed and we had a connection, update the maps
 	if oldURL != newURL {
+		// Session credentials connect again under the new URL when next used
+		a.disconnectAltConnections(oldURL)
+
 		a.mu.Lock()
 		if client, ok := a.clients[oldURL]; ok {
 			a.clients[newURL] = client
@@ -276,10 +392,55 @@ func (a *App) UpdateServer(oldURL, name, newURL string) error {
 	return nil
 }
 
-// RemoveServer removes a server
+// RemoveServer removes a server and its credentials, leaving its game
+// directories and wine prefix on disk
 func (a *App) RemoveServer(url string) error {
-	if err := a.config.RemoveServer(url); err != nil {
-		return fmt.Errorf("failed to remove server: %w", err)
+	_, err := a.RemoveServerWithCleanup(url, RemoveServerOptions{DeleteCredentials: true, GameDirs: gameDirsKeep})
+	return err
+}
+
+// What to do with the game directories of a removed server
+const (
+	gameDirsKeep    = "keep"
+	gameDirsArchive = "archive"
+	gameDirsDelete  = "delete"
+)
+
+// RemoveServerWithCleanup removes a server and the selected leftovers: keyring
+// credentials, game directories (archived or deleted) and wine prefix. With
+// DryRun, nothing is changed and the summary tells what would be done.
+// Cleanup failures don't undo the removal, they are listed in the summary.
+func (a *App) RemoveServerWithCleanup(url string, opts RemoveServerOptions) (*ServerRemovalInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	switch opts.GameDirs {
+	case "":
+		opts.GameDirs = gameDirsKeep
+	case gameDirsKeep, gameDirsArchive, gameDirsDelete:
+	default:
+		return nil, fmt.Errorf("unknown game directories action: %s", opts.GameDirs)
+	}
+
+	server, err := a.config.GetServer(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server with URL %s not found", url)
+	}
+
+	summary, err := a.planServerRemoval(server, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.DryRun {
+		return summary, nil
+	}
+
+	if err := a.config.RemoveServer(url, opts.DeleteCredentials); err != nil {
+		return nil, fmt.Errorf("failed to remove server: %w", err)
 	}
 
 	// Clean up connections
@@ -299,8 +460,9 @@ func (a *App) RemoveServer(url string) error {
 	delete(a.clients, url)
 	delete(a.connections, url)
 	a.mu.Unlock()
+	a.disconnectAltConnections(url)
 
-	// Clean up file hashes for all sessions on this server (files are left on disk)
+	// Clean up file hashes for all sessions on this server
 	if err := a.fileHashTracker.ForgetServer(url); err != nil {
 		logger.App.Warn().
 			Err(err).
@@ -308,7 +470,219 @@ func (a *App) RemoveServer(url string) error {
 			Msg("Failed to clean up file hashes after removing server")
 	}
 
-	logger.App.Info().Str("url", url).Msg("Removed server")
+	if err := a.config.DeleteIgnoreList(url); err != nil {
+		logger.App.Warn().Err(err).Str("serverURL", url).Msg("Failed to delete ignore list after removing server")
+	}
+	if err := a.config.DeleteInvitationTracking(url); err != nil {
+		logger.App.Warn().Err(err).Str("serverURL", url).Msg("Failed to delete invitation tracking after removing server")
+	}
+	if err := a.config.DeleteAnnouncementRead(url); err != nil {
+		logger.App.Warn().Err(err).Str("serverURL", url).Msg("Failed to delete announcement read state after removing server")
+	}
+	if err := a.config.DeleteServerLaunchEnv(url); err != nil {
+		logger.App.Warn().Err(err).Str("serverURL", url).Msg("Failed to delete launch environments after removing server")
+	}
+	if err := a.config.DeleteServerLaunchHistory(url); err != nil {
+		logger.App.Warn().Err(err).Str("serverURL", url).Msg("Failed to delete launch history after removing server")
+	}
+	if err := a.config.DeleteServerSessionCredentials(url); err != nil {
+		logger.App.Warn().Err(err).Str("serverURL", url).Msg("Failed to delete session credentials after removing server")
+	}
+	if err := a.config.DeleteServerSessionWatchDirs(url); err != nil {
+		logger.App.Warn().Err(err).Str("serverURL", url).Msg("Failed to delete session watch directories after removing server")
+	}
+	if err := a.config.DeleteServerSessionTimelines(url); err != nil {
+		logger.App.Warn().Err(err).Str("serverURL", url).Msg("Failed to delete session timelines after removing server")
+	}
+	if err := a.config.DeleteServerSessionMapSettings(url); err != nil {
+		logger.App.Warn().Err(err).Str("serverURL", url).Msg("Failed to delete session map settings after removing server")
+	}
+	if err := a.config.DeleteServerProductionPlans(url); err != nil {
+		logger.App.Warn().Err(err).Str("serverURL", url).Msg("Failed to delete production plans after removing server")
+	}
+
+	// The order file monitors are stopped: the directories can go. The server
+	// directory was resolved while the server still existed.
+	switch {
+	case summary.GameDir == "":
+	case opts.GameDirs == gameDirsArchive:
+		archived, err := a.config.ArchiveServerDir(server.Name, summary.GameDir)
+		if err != nil {
+			summary.Errors = append(summary.Errors, err.Error())
+		}
+		summary.ArchivedTo = archived
+	case opts.GameDirs == gameDirsDelete:
+		if err := a.config.DeleteServerDir(server.Name, summary.GameDir); err != nil {
+			summary.Errors = append(summary.Errors, err.Error())
+		}
+	}
+
+	if summary.WinePrefix != "" {
+		if err := a.config.DeletePath(summary.WinePrefix); err != nil {
+			summary.Errors = append(summary.Errors, fmt.Sprintf("failed to delete wine prefix: %v", err))
+		}
+	}
+
+	logger.App.Info().
+		Str("url", url).
+		Bool("credentials", opts.DeleteCredentials).
+		Str("gameDirs", opts.GameDirs).
+		Bool("winePrefix", opts.DeleteWinePrefix).
+		Int("errors", len(summary.Errors)).
+		Msg("Removed server")
+	return summary, nil
+}
+
+// planServerRemoval lists what removing a server with opts does
+func (a *App) planServerRemoval(server *model.Server, opts RemoveServerOptions) (*ServerRemovalInfo, error) {
+	summary := &ServerRemovalInfo{
+		DryRun:      opts.DryRun,
+		ServerURL:   server.URL,
+		ServerName:  server.Name,
+		Credentials: []string{},
+		GameDirs:    opts.GameDirs,
+		Steps:       []string{fmt.Sprintf("Remove server '%s' (%s)", server.Name, server.URL)},
+		Errors:      []string{},
+	}
+
+	if opts.DeleteCredentials {
+		for _, cred := range server.CredentialRefs {
+			summary.Credentials = append(summary.Credentials, cred.NickName)
+		}
+		summary.Steps = append(summary.Steps, fmt.Sprintf("Delete %d saved credential(s) and the serial key from the system keyring", len(summary.Credentials)))
+	}
+
+	serverDir, err := a.config.GetServerDir(server.Name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(serverDir); err == nil {
+		summary.GameDir = serverDir
+		sessions, err := a.config.ListSessionDirs(server.Name)
+		if err != nil {
+			return nil, err
+		}
+		summary.Sessions = len(sessions)
+		switch opts.GameDirs {
+		case gameDirsArchive:
+			summary.Steps = append(summary.Steps, fmt.Sprintf("Archive %s (%d session(s)) to %s", serverDir, len(sessions), astrum.OldServersDir))
+		case gameDirsDelete:
+			summary.Steps = append(summary.Steps, a.deleteStep(fmt.Sprintf("%s (%d session(s))", serverDir, len(sessions))))
+		}
+	}
+
+	if opts.DeleteWinePrefix {
+		prefix, err := a.config.GetServerWinePrefix(server.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(prefix); err == nil {
+			summary.WinePrefix = prefix
+			summary.Steps = append(summary.Steps, a.deleteStep("wine prefix "+prefix))
+		}
+	}
+
+	return summary, nil
+}
+
+// SetServerAutoConnect sets whether a server is connected when the app starts
+func (a *App) SetServerAutoConnect(serverURL string, enabled bool) error {
+	server, err := a.config.GetServer(serverURL)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return fmt.Errorf("server with URL %s not found", serverURL)
+	}
+
+	server.AutoConnectOnStartup = &enabled
+	if err := a.config.UpdateServer(*server); err != nil {
+		return fmt.Errorf("failed to update server: %w", err)
+	}
+
+	logger.App.Info().Str("url", serverURL).Bool("enabled", enabled).Msg("Set server auto-connect")
+	return nil
+}
+
+// SetServerGamesDir keeps the game directories of a server under dir instead
+// of the servers directory, "" going back to the servers directory. The
+// server directory is moved there, copied when dir is on another disk, and
+// its sessions are watched again at their new place.
+func (a *App) SetServerGamesDir(serverURL, dir string) error {
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
+
+	server, err := a.config.GetServer(serverURL)
+	if err != nil {
+		return fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return fmt.Errorf("server with URL %s not found", serverURL)
+	}
+
+	serversDir, err := a.config.GetServersDir()
+	if err != nil {
+		return fmt.Errorf("failed to get servers directory: %w", err)
+	}
+	if dir != "" {
+		if !filepath.IsAbs(dir) {
+			return fmt.Errorf("games directory must be an absolute path: %s", dir)
+		}
+		dir = filepath.Clean(dir)
+		if dir == filepath.Clean(serversDir) {
+			dir = ""
+		}
+	}
+	if dir == server.GamesDir {
+		return nil
+	}
+
+	oldDir, err := a.config.GetServerDir(server.Name)
+	if err != nil {
+		return err
+	}
+	newRoot := dir
+	if newRoot == "" {
+		newRoot = serversDir
+	}
+	newDir := filepath.Join(newRoot, a.config.SanitizeServerName(server.Name))
+
+	// Order file watchers hold paths: stop them during the move
+	paused := a.pauseOrderMonitors(serverURL)
+	defer func() { a.resumeOrderMonitors(serverURL, server.Name, paused) }()
+
+	moved := false
+	if _, err := os.Stat(oldDir); err == nil {
+		if err := safefile.MoveDir(oldDir, newDir); err != nil {
+			return fmt.Errorf("failed to move server directory: %w", err)
+		}
+		moved = true
+	}
+	moveBack := func() {
+		if !moved {
+			return
+		}
+		if err := safefile.MoveDir(newDir, oldDir); err != nil {
+			logger.App.Error().Err(err).Str("from", newDir).Str("to", oldDir).Msg("Failed to restore server directory")
+		}
+	}
+
+	if err := a.fileHashTracker.MoveServerDir(serverURL, oldDir, newDir); err != nil {
+		moveBack()
+		return fmt.Errorf("failed to migrate file hashes: %w", err)
+	}
+
+	server.GamesDir = dir
+	if err := a.config.UpdateServer(*server); err != nil {
+		if err := a.fileHashTracker.MoveServerDir(serverURL, newDir, oldDir); err != nil {
+			logger.App.Error().Err(err).Msg("Failed to restore file hashes")
+		}
+		moveBack()
+		return fmt.Errorf("failed to update server: %w", err)
+	}
+
+	logger.App.Info().Str("url", serverURL).Str("from", oldDir).Str("to", newDir).Msg("Moved server games directory")
 	return nil
 }
 
@@ -333,16 +707,32 @@ func (a *App) ReorderServers(serverOrders []ServerOrder) error {
 	return nil
 }
 
-// renameServerDirectory renames the server directory when a server name changes.
-// If the old directory doesn't exist, this is a no-op.
-// If the new directory already exists, this returns an error.
-func (a *App) renameServerDirectory(oldName, newName string) error {
-	serversDir, err := a.config.GetServersDir()
-	if err != nil {
-		return fmt.Errorf("failed to get servers directory: %w", err)
+// pauseOrderMonitors stops the order file monitor of a server and returns the
+// sessions it watched
+func (a *App) pauseOrderMonitors(serverURL string) []monitor.WatchedSession {
+	a.mu.Lock()
+	orderMon, ok := a.orderMonitors[serverURL]
+	delete(a.orderMonitors, serverURL)
+	a.mu.Unlock()
+
+	if !ok {
+		return nil
 	}
+	return orderMon.StopAll()
+}
 
-	if serversDir == "" {
+// resumeOrderMonitors watches paused sessions again, at their current location
+func (a *App) resumeOrderMonitors(serverURL, serverName string, sessions []monitor.WatchedSession) {
+	for _, session := range sessions {
+		a.startMonitoringSession(serverURL, serverName, session.SessionID, session.PlayerOrder)
+	}
+}
+
+// renameServerDirectory renames the server directory in root when a server name changes.
+// If the old directory doesn't exist, this is a no-op.
+// If the new directory already exists, this returns an error.
+func (a *App) renameServerDirectory(root, oldName, newName string) error {
+	if root == "" {
 		return fmt.Errorf("servers directory is not configured")
 	}
 
@@ -354,8 +744,8 @@ func (a *App) renameServerDirectory(oldName, newName string) error {
 		return nil
 	}
 
-	oldDir := filepath.Join(serversDir, oldSanitized)
-	newDir := filepath.Join(serversDir, newSanitized)
+	oldDir := filepath.Join(root, oldSanitized)
+	newDir := filepath.Join(root, newSanitized)
 
 	// Check if old directory exists
 	if _, err := os.Stat(oldDir); os.IsNotExist(err) {
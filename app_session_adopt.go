@@ -0,0 +1,196 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	hs "github.com/neper-stars/houston"
+	"github.com/neper-stars/houston/blocks"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/api/models"
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+)
+
+// =============================================================================
+// SESSION DIRECTORY ADOPTION
+// =============================================================================
+
+// adoptSessionDir links a directory created by hand in the server directory,
+// e.g. a game folder copied from another machine, to the session it is named
+// after. The session must be one we play in and have no game yet, and the
+// directory must hold the universe of that session, and our turn file if any. Its files are registered as known and the session is monitored.
+// Returns whether the directory was adopted, instead of being archived as an
+// orphan. Emits "session:adopted" (serverURL, sessionID, gameDir).
+func (a *App) adoptSessionDir(serverURL, serverName, dirName string, sessions []api.Session) bool {
+	serverDir, err := a.config.GetServerDir(serverName)
+	if err != nil {
+		return false
+	}
+	dir := filepath.Join(serverDir, dirName)
+	if _, err := os.Stat(monitor.ResolveFile(dir, "game.xy")); err != nil {
+		return false // Not a game, or an alias of a deleted session
+	}
+
+	for i := range sessions {
+		session := &sessions[i]
+		if !astrum.SessionDirMatches(dirName, session.Name, session.ID) {
+			continue
+		}
+		if a.config.HasSessionGame(serverName, session.ID) {
+			continue // Set up already, the copy is not ours to take
+		}
+
+		player, err := a.checkAdoptedSessionDir(serverURL, session, dir)
+		if err != nil {
+			logger.App.Warn().
+				Err(err).
+				Str("sessionId", session.ID).
+				Str("path", dir).
+				Msg("Session directory not adopted")
+			continue
+		}
+
+		// The game directory created while waiting for the first turn goes away
+		oldDir, err := a.config.GetSessionGameDir(serverName, session.ID)
+		if err != nil {
+			return false
+		}
+		a.mu.RLock()
+		orderMon, monExists := a.orderMonitors[serverURL]
+		a.mu.RUnlock()
+		if monExists {
+			orderMon.Unwatch(session.ID)
+		}
+
+		monitored := session.State == models.SessionStateStarted && player.Ready
+
+		gameDir, err := a.config.AdoptSessionDir(serverName, session.ID, dirName)
+		if err != nil {
+			logger.App.Warn().Err(err).Str("sessionId", session.ID).Msg("Failed to adopt session directory")
+			if monitored {
+				a.startMonitoringSession(serverURL, serverName, session.ID, int(player.PlayerOrder))
+			}
+			return false
+		}
+		if err := a.fileHashTracker.MoveSessionDir(serverURL, session.ID, oldDir, gameDir); err != nil {
+			logger.App.Warn().Err(err).Str("sessionId", session.ID).Msg("Failed to move file hashes to adopted session directory")
+		}
+		a.registerAdoptedFiles(serverURL, session.ID, gameDir, int(player.PlayerOrder))
+
+		logger.App.Info().
+			Str("sessionId", session.ID).
+			Str("path", gameDir).
+			Msg("Adopted session directory")
+
+		if monitored {
+			a.startMonitoringSession(serverURL, serverName, session.ID, int(player.PlayerOrder))
+		}
+		a.queueTurnReconciliation(serverURL)
+
+		a.mu.RLock()
+		shuttingDown := a.shuttingDown
+		a.mu.RUnlock()
+		if !shuttingDown {
+			a.emit("session:adopted", serverURL, session.ID, gameDir)
+		}
+		return true
+	}
+	return false
+}
+
+// checkAdoptedSessionDir checks that a directory holds a game of a session:
+// its universe comes from the session's game and its turn file, when there is
+// one, belongs to our player. Returns our player in the session.
+func (a *App) checkAdoptedSessionDir(serverURL string, session *api.Session, dir string) (*api.SessionPlayer, error) {
+	client, mgr, err := a.sessionConnection(serverURL, session.ID)
+	if err != nil {
+		return nil, err
+	}
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return nil, fmt.Errorf("no user info available")
+	}
+
+	var player *api.SessionPlayer
+	for _, p := range session.Players {
+		if p.UserProfileID == userInfo.User.ID {
+			player = p
+			break
+		}
+	}
+	if player == nil {
+		return nil, fmt.Errorf("not a player of this session")
+	}
+
+	latest, err := client.GetLatestTurn(mgr.GetContext(), session.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest turn from server: %w", err)
+	}
+	if latest.Turn == nil {
+		return nil, fmt.Errorf("no turn generated yet")
+	}
+	universe, err := base64.StdEncoding.DecodeString(latest.Turn.Universe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode universe data: %w", err)
+	}
+	serverHeader, err := hs.FileData(universe).FileHeader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server universe: %w", err)
+	}
+
+	localHeader, err := starsFileHeader(monitor.ResolveFile(dir, "game.xy"))
+	if err != nil {
+		return nil, err
+	}
+	if localHeader.GameID != serverHeader.GameID {
+		return nil, fmt.Errorf("universe is not the one of this session")
+	}
+
+	turnPath := monitor.ResolveFile(dir, fmt.Sprintf("game.m%d", player.PlayerOrder+1))
+	if _, err := os.Stat(turnPath); err != nil {
+		return player, nil // Downloaded by the reconciliation
+	}
+	turnHeader, err := starsFileHeader(turnPath)
+	if err != nil {
+		return nil, err
+	}
+	if turnHeader.GameID != serverHeader.GameID {
+		return nil, fmt.Errorf("turn file is not from this session")
+	}
+	if turnHeader.PlayerIndex() != int(player.PlayerOrder) {
+		return nil, fmt.Errorf("turn file is for player %d, not player %d", turnHeader.PlayerIndex()+1, player.PlayerOrder+1)
+	}
+	if turnHeader.Year() > int(latest.Year) {
+		return nil, fmt.Errorf("turn file year %d is ahead of server year %d", turnHeader.Year(), latest.Year)
+	}
+	return player, nil
+}
+
+// registerAdoptedFiles records the hashes of the turn files of an adopted
+// directory, as if they had been downloaded, so they are not written again
+func (a *App) registerAdoptedFiles(serverURL, sessionID, gameDir string, playerOrder int) {
+	for _, name := range []string{"game.xy", fmt.Sprintf("game.m%d", playerOrder+1)} {
+		path := monitor.ResolveFile(gameDir, name)
+		if _, _, err := a.fileHashTracker.SyncFileHash(serverURL, sessionID, path); err != nil {
+			logger.App.Warn().Err(err).Str("path", path).Msg("Failed to register file of adopted session directory")
+		}
+	}
+}
+
+// starsFileHeader reads the header of a Stars! file
+func starsFileHeader(path string) (*blocks.FileHeader, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+	}
+	header, err := hs.FileData(raw).FileHeader()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return header, nil
+}
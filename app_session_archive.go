@@ -0,0 +1,418 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// SESSION ARCHIVES
+// =============================================================================
+
+// SessionArchiveExtension is the file extension of session archives, which are
+// zip files
+const SessionArchiveExtension = ".astrum.zip"
+
+// sessionArchiveVersion is the format version of session archives
+const sessionArchiveVersion = 1
+
+// Entries of a session archive: the manifest and the game directory files
+const (
+	sessionArchiveManifestFile = "session.json"
+	sessionArchiveGamePrefix   = "game/"
+)
+
+// sessionArchiveGameFile matches the names of the Stars! game files kept in
+// session archives: game.xy, game.hst and the numbered .r, .m, .x and .h
+// files, including the order copies of the order history
+var sessionArchiveGameFile = regexp.MustCompile(`(?i)^[a-z0-9_-]+\.(xy|hst|[rmxh][0-9]{1,2})$`)
+
+// Subfolders of the game directory kept in session archives besides the
+// game files, with the extensions of the files kept from them
+var sessionArchiveExtras = map[string][]string{
+	"maps": {".svg", ".png"}, // maps generated after each turn
+	".":    {".md"},          // local notes, e.g. handoff notes
+}
+
+// isSessionArchiveFile returns whether a file of the game directory, given
+// its slash separated relative path, belongs in a session archive: game
+// files, the receipts of the order history, maps and notes. Anything else,
+// executables first, is never restored from an archive.
+func isSessionArchiveFile(rel string) bool {
+	name := path.Base(rel)
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	if sessionArchiveGameFile.MatchString(name) {
+		return true
+	}
+	dir := path.Dir(rel)
+	if dir == orderHistoryDirName {
+		return strings.HasSuffix(name, ".json")
+	}
+	for _, ext := range sessionArchiveExtras[dir] {
+		if strings.EqualFold(path.Ext(name), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// sessionArchive is the manifest of a session archive: the session it belongs
+// to and its local data kept outside the game directory
+type sessionArchive struct {
+	Version         int                          `json:"version"`
+	ServerURL       string                       `json:"serverUrl"`
+	SessionID       string                       `json:"sessionId"`
+	SessionName     string                       `json:"sessionName,omitempty"`
+	ExportedAt      time.Time                    `json:"exportedAt"`
+	Timeline        *model.SessionTimeline       `json:"timeline,omitempty"`
+	MapSettings     *model.SessionMapSettings    `json:"mapSettings,omitempty"`
+	ProductionPlans []model.PlanetProductionPlan `json:"productionPlans,omitempty"`
+	OrderHashes     map[string]string            `json:"orderHashes,omitempty"` // order:<year> -> hash of the uploaded order
+}
+
+// ExportSessionArchive bundles a session into a single file at archivePath: the game
+// files of the game directory (turns, orders, order history), its maps and
+// notes, and the session
+// data kept in the configuration (timeline, map settings, production plans,
+// uploaded orders). stars.exe is left out, it is downloaded again on import.
+// The archive can be restored on another machine with ImportSessionArchive.
+func (a *App) ExportSessionArchive(serverURL, sessionID, archivePath string) (*SessionArchiveInfo, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(gameDir); err != nil {
+		return nil, fmt.Errorf("no game directory for session %s: %w", sessionID, err)
+	}
+
+	manifest, err := a.sessionArchiveManifest(serverURL, sessionID, gameDir)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := safefile.Create(archivePath, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session archive: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	zw := zip.NewWriter(file)
+	data, err := jsoniter.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal session archive manifest: %w", err)
+	}
+	w, err := zw.Create(sessionArchiveManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manifest entry in archive: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, fmt.Errorf("failed to write manifest to archive: %w", err)
+	}
+
+	files, err := addDirToZip(zw, gameDir, sessionArchiveGamePrefix, isSessionArchiveFile)
+	if err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write session archive: %w", err)
+	}
+	if err := file.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to write session archive: %w", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int("files", files).Str("path", archivePath).Msg("Exported session archive")
+	return sessionArchiveInfo(manifest, gameDir, files), nil
+}
+
+// ImportSessionArchive restores a session archive made by ExportSessionArchive
+// and links it back to its server session. serverURL overrides the server of
+// the archive, e.g. when the server is reached at another address from this
+// machine, and must be a configured server. Only game files, maps and notes
+// are restored, replacing those already in the game directory.
+func (a *App) ImportSessionArchive(path, serverURL string) (*SessionArchiveInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open session archive: %w", err)
+	}
+	defer func() { _ = zr.Close() }()
+
+	manifest, err := readSessionArchiveManifest(&zr.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if serverURL == "" {
+		serverURL = manifest.ServerURL
+	}
+	server, err := a.config.GetServer(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get server: %w", err)
+	}
+	if server == nil {
+		return nil, fmt.Errorf("server %s is not configured, add it before importing the session", serverURL)
+	}
+	sessionID := manifest.SessionID
+
+	// Name the directory like a session joined on this machine
+	if manifest.SessionName != "" {
+		a.assignSessionDirAlias(serverURL, server.Name, sessionID, manifest.SessionName)
+	}
+	gameDir, err := a.config.EnsureSessionGameDir(server.Name, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*zip.File
+	var size int64
+	for _, f := range zr.File {
+		rel, ok := strings.CutPrefix(f.Name, sessionArchiveGamePrefix)
+		if !ok || rel == "" || f.FileInfo().IsDir() {
+			continue
+		}
+		if !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return nil, fmt.Errorf("invalid file %s in session archive", f.Name)
+		}
+		if !f.Mode().IsRegular() || !isSessionArchiveFile(rel) {
+			logger.App.Warn().Str("file", f.Name).Msg("Skipped file of session archive, not a game file, map or note")
+			continue
+		}
+		entries = append(entries, f)
+		size += int64(f.UncompressedSize64)
+	}
+	if err := a.checkDiskSpace(gameDir, "session import", size); err != nil {
+		return nil, err
+	}
+
+	for _, f := range entries {
+		rel := strings.TrimPrefix(f.Name, sessionArchiveGamePrefix)
+		if err := restoreArchiveFile(f, filepath.Join(gameDir, filepath.FromSlash(rel))); err != nil {
+			return nil, err
+		}
+	}
+	files := len(entries)
+
+	if err := a.restoreSessionArchiveData(serverURL, manifest); err != nil {
+		return nil, err
+	}
+
+	// Catch up with the server: stars.exe and the turns generated since the export
+	a.mu.RLock()
+	_, connected := a.clients[serverURL]
+	a.mu.RUnlock()
+	if connected {
+		a.ensureStarsExeInDir(serverURL, sessionID, gameDir)
+		a.queueTurnReconciliation(serverURL)
+	}
+
+	logger.App.Info().Str("serverUrl", serverURL).Str("sessionId", sessionID).Int("files", files).Str("path", path).Msg("Imported session archive")
+	info := sessionArchiveInfo(manifest, gameDir, files)
+	info.ServerURL = serverURL
+	return info, nil
+}
+
+// sessionArchiveManifest collects the configuration data of a session
+func (a *App) sessionArchiveManifest(serverURL, sessionID, gameDir string) (*sessionArchive, error) {
+	manifest := &sessionArchive{
+		Version:     sessionArchiveVersion,
+		ServerURL:   serverURL,
+		SessionID:   sessionID,
+		SessionName: a.sessionArchiveName(serverURL, sessionID, gameDir),
+		ExportedAt:  time.Now().UTC(),
+		OrderHashes: make(map[string]string),
+	}
+
+	var err error
+	if manifest.Timeline, err = a.config.GetSessionTimeline(serverURL, sessionID); err != nil {
+		return nil, err
+	}
+	if manifest.MapSettings, err = a.config.GetSessionMapSettings(serverURL, sessionID); err != nil {
+		return nil, err
+	}
+	if manifest.ProductionPlans, err = a.config.GetProductionPlans(serverURL, sessionID); err != nil {
+		return nil, err
+	}
+	// Paths are specific to this machine, only the uploaded orders are kept
+	for key, hash := range a.fileHashTracker.SessionHashes(serverURL, sessionID) {
+		if strings.HasPrefix(key, "order:") {
+			manifest.OrderHashes[key] = hash
+		}
+	}
+	return manifest, nil
+}
+
+// sessionArchiveName returns the name of a session from the server, or from
+// its directory alias when offline
+func (a *App) sessionArchiveName(serverURL, sessionID, gameDir string) string {
+	if session, err := a.GetSession(serverURL, sessionID); err == nil && session != nil {
+		return session.Name
+	}
+	base := filepath.Base(gameDir)
+	if name, _, ok := strings.Cut(base, " ("); ok {
+		return strings.ReplaceAll(name, "_", " ")
+	}
+	return ""
+}
+
+// restoreSessionArchiveData stores the configuration data of an imported session
+func (a *App) restoreSessionArchiveData(serverURL string, manifest *sessionArchive) error {
+	sessionID := manifest.SessionID
+	if manifest.Timeline != nil {
+		if err := a.config.SetSessionTimeline(serverURL, sessionID, manifest.Timeline); err != nil {
+			return err
+		}
+	}
+	if manifest.MapSettings != nil {
+		if err := a.config.SetSessionMapSettings(serverURL, sessionID, manifest.MapSettings); err != nil {
+			return err
+		}
+	}
+	if err := a.config.SetProductionPlans(serverURL, sessionID, manifest.ProductionPlans); err != nil {
+		return err
+	}
+	// Orders uploaded from the other machine must not be uploaded again
+	for key, hash := range manifest.OrderHashes {
+		if !strings.HasPrefix(key, "order:") {
+			continue
+		}
+		if err := a.fileHashTracker.SetHash(serverURL, sessionID, key, hash); err != nil {
+			return fmt.Errorf("failed to restore uploaded orders: %w", err)
+		}
+	}
+	return nil
+}
+
+// readSessionArchiveManifest reads and checks the manifest of a session archive
+func readSessionArchiveManifest(zr *zip.Reader) (*sessionArchive, error) {
+	f, err := zr.Open(sessionArchiveManifestFile)
+	if err != nil {
+		return nil, fmt.Errorf("not a session archive: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var manifest sessionArchive
+	if err := jsoniter.NewDecoder(f).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("failed to read session archive manifest: %w", err)
+	}
+	if manifest.Version > sessionArchiveVersion {
+		return nil, fmt.Errorf("session archive made by a newer version of the app")
+	}
+	if manifest.ServerURL == "" || manifest.SessionID == "" {
+		return nil, fmt.Errorf("session archive has no session")
+	}
+	// The session ID names the game directory
+	if !isPathElement(manifest.SessionID) {
+		return nil, fmt.Errorf("invalid session ID %q in session archive", manifest.SessionID)
+	}
+	return &manifest, nil
+}
+
+// isPathElement returns whether name is a single element of a path, usable
+// as a file or directory name without leaving its parent
+func isPathElement(name string) bool {
+	return filepath.IsLocal(name) && filepath.Base(name) == name && name != "." && name != ".."
+}
+
+// addDirToZip adds the files of dir accepted by keep (given their slash
+// separated path relative to dir) to a zip under prefix, and returns how many
+// were added
+func addDirToZip(zw *zip.Writer, dir, prefix string, keep func(rel string) bool) (int, error) {
+	files := 0
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !keep(rel) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = path.Join(prefix, rel)
+		header.Method = zip.Deflate
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = src.Close() }()
+		if _, err := io.Copy(w, src); err != nil {
+			return err
+		}
+		files++
+		return nil
+	})
+	if err != nil {
+		return files, fmt.Errorf("failed to add %s to archive: %w", dir, err)
+	}
+	return files, nil
+}
+
+// restoreArchiveFile writes a zip entry to dest, creating its directory
+func restoreArchiveFile(f *zip.File, dest string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s from archive: %w", f.Name, err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Errorf("failed to read %s from archive: %w", f.Name, err)
+	}
+	if err := safefile.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := safefile.WriteFile(dest, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return nil
+}
+
+// sessionArchiveInfo describes an exported or imported session archive
+func sessionArchiveInfo(manifest *sessionArchive, gameDir string, files int) *SessionArchiveInfo {
+	return &SessionArchiveInfo{
+		ServerURL:   manifest.ServerURL,
+		SessionID:   manifest.SessionID,
+		SessionName: manifest.SessionName,
+		ExportedAt:  manifest.ExportedAt,
+		GameDir:     gameDir,
+		Files:       files,
+	}
+}
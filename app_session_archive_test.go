@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSessionArchiveFile(t *testing.T) {
+	kept := []string{
+		"game.xy",
+		"game.m1",
+		"game.x12",
+		"game.hst",
+		orderHistoryDirName + "/2405.json",
+		"maps/2405.svg",
+		"maps/2405.png",
+		"handoff-20261016-120000.md",
+	}
+	for _, rel := range kept {
+		assert.True(t, isSessionArchiveFile(rel), rel)
+	}
+
+	dropped := []string{
+		"stars.exe",
+		"maps/run.exe",
+		"maps/sub/2405.svg",
+		"notes/todo.md",
+		".hidden.md",
+		orderHistoryDirName + "/.tmp.json",
+		"exports/intel-2405.astrum-intel",
+	}
+	for _, rel := range dropped {
+		assert.False(t, isSessionArchiveFile(rel), rel)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// OFFLINE SESSION CACHE
+// =============================================================================
+
+// ErrNotConnected is returned by server calls made while disconnected
+var ErrNotConnected = errors.New("not connected to server")
+
+// Server cache keys; session-scoped ones are suffixed with the session ID
+const (
+	cacheKeySessions     = "sessions"
+	cacheKeySession      = "session/"
+	cacheKeyRules        = "rules/"
+	cacheKeyOrdersStatus = "orders_status/"
+	cacheKeyPlayerRace   = "player_race/"
+)
+
+// useServerCache tells whether a failed server call should be answered from
+// the cache: the server is disconnected or unreachable for now
+func useServerCache(err error) bool {
+	return errors.Is(err, ErrNotConnected) || api.IsTransient(err)
+}
+
+// cacheServerData saves the last known server data under key. Failures are
+// only logged, the cache is best effort.
+func (a *App) cacheServerData(serverURL, key string, v any) {
+	data, err := jsoniter.Marshal(v)
+	if err == nil {
+		err = a.config.SetServerCache(serverURL, key, data)
+	}
+	if err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Str("key", key).Msg("Failed to cache server data")
+	}
+}
+
+// cachedServerData loads the data cached under key into v, and tells whether
+// there was any
+func (a *App) cachedServerData(serverURL, key string, v any) bool {
+	data, cachedAt, err := a.config.GetServerCache(serverURL, key)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Str("key", key).Msg("Failed to read server cache")
+		return false
+	}
+	if data == nil {
+		return false
+	}
+	if err := jsoniter.Unmarshal(data, v); err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Str("key", key).Msg("Failed to decode server cache")
+		return false
+	}
+	logger.App.Debug().Str("serverUrl", serverURL).Str("key", key).Time("cachedAt", cachedAt).Msg("Serving cached server data")
+	return true
+}
+
+// cachedSessions returns the last known sessions of a server, flagged as stale
+func (a *App) cachedSessions(serverURL string) ([]SessionInfo, bool) {
+	var sessions []SessionInfo
+	if !a.cachedServerData(serverURL, cacheKeySessions, &sessions) {
+		return nil, false
+	}
+	for i := range sessions {
+		sessions[i].Stale = true
+	}
+	return sessions, true
+}
+
+// cachedSession returns the last known state of a session, flagged as stale.
+// Sessions only seen in the session list are served from that list.
+func (a *App) cachedSession(serverURL, sessionID string) (*SessionInfo, bool) {
+	var session SessionInfo
+	if a.cachedServerData(serverURL, cacheKeySession+sessionID, &session) {
+		session.Stale = true
+		return &session, true
+	}
+	sessions, ok := a.cachedSessions(serverURL)
+	if !ok {
+		return nil, false
+	}
+	for i := range sessions {
+		if sessions[i].ID == sessionID {
+			return &sessions[i], true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/auth"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// SESSION CAPACITY
+// =============================================================================
+
+// SetMaxPlayers limits how many players can join a session, 0 for no limit.
+// Managers only. Slots reserved with ReserveSlot count against the limit.
+func (a *App) SetMaxPlayers(serverURL, sessionID string, maxPlayers int) (*SessionInfo, error) {
+	if maxPlayers < 0 || maxPlayers > maxStarsPlayers {
+		return nil, fmt.Errorf("max players must be between 0 and %d", maxStarsPlayers)
+	}
+
+	client, mgr, session, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if maxPlayers > 0 && maxPlayers < len(session.Members) {
+		return nil, fmt.Errorf("session already has %d members", len(session.Members))
+	}
+
+	capacity, err := client.SetMaxPlayers(mgr.GetContext(), sessionID, maxPlayers)
+	if err != nil {
+		return nil, a.capacityError(serverURL, "failed to set max players", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int("maxPlayers", maxPlayers).Msg("Set session max players")
+	return a.sessionWithCapacity(serverURL, sessionID, session, capacity), nil
+}
+
+// ReserveSlot keeps a player slot of a session for a nickname, so a full
+// public session still has room for that player once they register.
+// Managers only.
+func (a *App) ReserveSlot(serverURL, sessionID, nickname string) (*SessionInfo, error) {
+	nickname = strings.TrimSpace(nickname)
+	if nickname == "" {
+		return nil, fmt.Errorf("nickname is required")
+	}
+
+	client, mgr, session, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := client.ReserveSlot(mgr.GetContext(), sessionID, nickname)
+	if err != nil {
+		return nil, a.capacityError(serverURL, "failed to reserve slot", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Str("nickname", nickname).Msg("Reserved session slot")
+	return a.sessionWithCapacity(serverURL, sessionID, session, capacity), nil
+}
+
+// ReleaseSlot frees the slot reserved for a nickname. Managers only.
+func (a *App) ReleaseSlot(serverURL, sessionID, nickname string) (*SessionInfo, error) {
+	client, mgr, session, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	capacity, err := client.ReleaseSlot(mgr.GetContext(), sessionID, nickname)
+	if err != nil {
+		return nil, a.capacityError(serverURL, "failed to release slot", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Str("nickname", nickname).Msg("Released session slot")
+	return a.sessionWithCapacity(serverURL, sessionID, session, capacity), nil
+}
+
+// managedSession fetches a session the current user manages
+func (a *App) managedSession(serverURL, sessionID string) (*api.Client, *auth.Manager, *api.Session, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return nil, nil, nil, fmt.Errorf("no user info available")
+	}
+
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if !slices.Contains(session.Managers, userInfo.User.ID) {
+		return nil, nil, nil, fmt.Errorf("only session hosts can manage the session")
+	}
+	return client, mgr, session, nil
+}
+
+// fetchSessionCapacity retrieves the capacity of a session. Returns nil when
+// the server has no capacity management, which is remembered until the next
+// connection.
+func (a *App) fetchSessionCapacity(serverURL string, client *api.Client, mgr *auth.Manager, sessionID string) (*api.SessionCapacity, error) {
+	a.mu.RLock()
+	unsupported := a.noCapacity[serverURL]
+	a.mu.RUnlock()
+	if unsupported {
+		return nil, nil
+	}
+
+	capacity, err := client.GetSessionCapacity(mgr.GetContext(), sessionID)
+	if errors.Is(err, api.ErrNotSupported) {
+		a.markNoCapacity(serverURL)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return capacity, nil
+}
+
+// capacityError wraps a failed capacity update, remembering servers without
+// capacity management
+func (a *App) capacityError(serverURL, msg string, err error) error {
+	if errors.Is(err, api.ErrNotSupported) {
+		a.markNoCapacity(serverURL)
+		return fmt.Errorf("%s: this server does not support player slots: %w", msg, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+func (a *App) markNoCapacity(serverURL string) {
+	a.mu.Lock()
+	a.noCapacity[serverURL] = true
+	a.mu.Unlock()
+	logger.App.Debug().Str("serverUrl", serverURL).Msg("Server has no session capacity")
+}
+
+// sessionWithCapacity converts a session with its updated capacity and
+// refreshes the cached copy
+func (a *App) sessionWithCapacity(serverURL, sessionID string, session *api.Session, capacity *api.SessionCapacity) *SessionInfo {
+	info := a.convertSession(serverURL, sessionID, session)
+	applySessionCapacity(info, capacity)
+	a.cacheServerData(serverURL, cacheKeySession+sessionID, info)
+	return info
+}
+
+// applySessionCapacity copies the capacity of a session into its info
+func applySessionCapacity(info *SessionInfo, capacity *api.SessionCapacity) {
+	if capacity == nil {
+		return
+	}
+	info.MaxPlayers = capacity.MaxPlayers
+	info.ReservedSlots = make([]string, 0, len(capacity.Reservations))
+	for _, r := range capacity.Reservations {
+		info.ReservedSlots = append(info.ReservedSlots, r.Nickname)
+	}
+}
+
+// sessionIsFull reports whether a session has no slot left for nickname.
+// Reserved slots are kept for their nickname only.
+func sessionIsFull(capacity *api.SessionCapacity, memberCount int, nickname string) bool {
+	if capacity == nil || capacity.MaxPlayers <= 0 {
+		return false
+	}
+	reserved := 0
+	for _, r := range capacity.Reservations {
+		if strings.EqualFold(r.Nickname, nickname) {
+			return false
+		}
+		reserved++
+	}
+	return memberCount+reserved >= capacity.MaxPlayers
+}
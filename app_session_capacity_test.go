@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/neper-stars/astrum/api"
+)
+
+func TestSessionIsFull(t *testing.T) {
+	capacity := &api.SessionCapacity{
+		MaxPlayers:   4,
+		Reservations: []api.SlotReservation{{Nickname: "Alice"}},
+	}
+
+	tests := []struct {
+		name     string
+		capacity *api.SessionCapacity
+		members  int
+		nickname string
+		want     bool
+	}{
+		{"unknown capacity", nil, 10, "bob", false},
+		{"unlimited", &api.SessionCapacity{}, 10, "bob", false},
+		{"room left", capacity, 2, "bob", false},
+		{"last slot reserved", capacity, 3, "bob", true},
+		{"reservation holder", capacity, 3, "alice", false},
+		{"over filled", capacity, 5, "bob", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sessionIsFull(tt.capacity, tt.members, tt.nickname))
+		})
+	}
+}
+
+func TestApplySessionCapacity(t *testing.T) {
+	info := &SessionInfo{}
+	applySessionCapacity(info, nil)
+	assert.Zero(t, info.MaxPlayers)
+	assert.Nil(t, info.ReservedSlots)
+
+	applySessionCapacity(info, &api.SessionCapacity{
+		MaxPlayers:   6,
+		Reservations: []api.SlotReservation{{Nickname: "alice"}, {Nickname: "bob"}},
+	})
+	assert.Equal(t, 6, info.MaxPlayers)
+	assert.Equal(t, []string{"alice", "bob"}, info.ReservedSlots)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/auth"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// SESSION CREDENTIALS
+// =============================================================================
+
+// altConnection is a connection to a server with a credential other than the
+// one connected by Connect, used by the sessions played with that credential.
+// It has no notification channel: notifications come from the main connection.
+type altConnection struct {
+	client *api.Client
+	mgr    *auth.Manager
+}
+
+// altConnectionKey returns the key of a connection in altConnections
+func altConnectionKey(serverURL, nickname string) string {
+	return serverURL + "|" + nickname
+}
+
+// GetSessionCredential returns the nickname of the credential a session is
+// played with, or "" when it uses the server's default credential
+func (a *App) GetSessionCredential(serverURL, sessionID string) (string, error) {
+	return a.config.GetSessionCredential(serverURL, sessionID)
+}
+
+// SetSessionCredential plays a session with one of the saved credentials of
+// the server, e.g. a secondary account holding another slot. An empty
+// nickname goes back to the server's default credential.
+func (a *App) SetSessionCredential(serverURL, sessionID, nickname string) error {
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
+
+	if nickname != "" {
+		server, err := a.config.GetServer(serverURL)
+		if err != nil {
+			return fmt.Errorf("failed to get server: %w", err)
+		}
+		if server == nil {
+			return fmt.Errorf("server with URL %s not found", serverURL)
+		}
+		found := false
+		for _, cred := range server.CredentialRefs {
+			found = found || cred.NickName == nickname
+		}
+		if !found {
+			return fmt.Errorf("no saved credential for %s on this server", nickname)
+		}
+	}
+
+	if err := a.config.SetSessionCredential(serverURL, sessionID, nickname); err != nil {
+		return err
+	}
+
+	logger.App.Info().Str("serverUrl", serverURL).Str("sessionId", sessionID).Str("nickname", nickname).Msg("Set session credential")
+	return nil
+}
+
+// sessionConnection returns the client and auth manager to use for a session:
+// those of the session credential when it has one, connecting it on first
+// use, the server connection otherwise. The server must be connected.
+func (a *App) sessionConnection(serverURL, sessionID string) (*api.Client, *auth.Manager, error) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotConnected, serverURL)
+	}
+
+	nickname, err := a.config.GetSessionCredential(serverURL, sessionID)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to get session credential, using the server connection")
+		return client, mgr, nil
+	}
+	if nickname == "" {
+		return client, mgr, nil
+	}
+	if userInfo := mgr.GetUserInfo(); userInfo != nil && userInfo.User.Nickname == nickname {
+		return client, mgr, nil
+	}
+
+	conn, err := a.altConnection(serverURL, nickname)
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn.client, conn.mgr, nil
+}
+
+// altConnection returns the connection of a session credential, connecting it if needed
+func (a *App) altConnection(serverURL, nickname string) (*altConnection, error) {
+	// Held while connecting, so concurrent calls don't connect twice
+	a.altConnMu.Lock()
+	defer a.altConnMu.Unlock()
+
+	key := altConnectionKey(serverURL, nickname)
+	if conn, ok := a.altConnections[key]; ok && conn.mgr.IsConnected() {
+		return conn, nil
+	}
+
+	apiKey, err := a.config.GetCredential(serverURL, nickname)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve credentials: %w", err)
+	}
+	if apiKey == "" {
+		return nil, fmt.Errorf("credentials not found in keyring for %s", nickname)
+	}
+
+	client := api.NewClient(serverURL)
+	mgr := auth.NewManager(client)
+	if err := mgr.Connect(nickname, apiKey); err != nil {
+		return nil, fmt.Errorf("failed to connect as %s: %w", nickname, err)
+	}
+
+	conn := &altConnection{client: client, mgr: mgr}
+	a.altConnections[key] = conn
+	logger.App.Info().Str("serverUrl", serverURL).Str("nickname", nickname).Msg("Connected session credential")
+	return conn, nil
+}
+
+// disconnectAltConnections disconnects the session credentials of a server,
+// or of every server when serverURL is empty
+func (a *App) disconnectAltConnections(serverURL string) {
+	a.altConnMu.Lock()
+	defer a.altConnMu.Unlock()
+
+	for key, conn := range a.altConnections {
+		if serverURL == "" || strings.HasPrefix(key, serverURL+"|") {
+			conn.mgr.Disconnect()
+			delete(a.altConnections, key)
+		}
+	}
+}
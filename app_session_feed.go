@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/neper-stars/astrum/lib/feed"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// SESSION FEEDS
+// =============================================================================
+
+// sessionFeedFile is the name of the Atom feed written in the game directory
+const sessionFeedFile = "feed.atom"
+
+// sessionFeedEvents are the timeline events making up a session feed: turns
+// generated and orders submitted
+var sessionFeedEvents = map[string]bool{
+	model.TimelineTurnGenerated: true,
+	model.TimelineOrderSent:     true,
+	model.TimelinePlayerOrders:  true,
+}
+
+// SetSessionFeeds enables or disables the Atom feed written in each game
+// directory, so a game can be followed from a feed reader. The feeds of the
+// sessions are written as their events come in.
+func (a *App) SetSessionFeeds(enabled bool) (*AppSettingsInfo, error) {
+	if err := a.config.SetSessionFeeds(enabled); err != nil {
+		return nil, fmt.Errorf("failed to set session feeds: %w", err)
+	}
+
+	logger.App.Info().Bool("enabled", enabled).Msg("Set session feeds")
+
+	return a.GetAppSettings()
+}
+
+// GetSessionFeed returns the Atom feed of a session: its turn generations
+// and order submissions, most recent first. The feed is also served by the
+// local HTTP API.
+func (a *App) GetSessionFeed(serverURL, sessionID string) (string, error) {
+	data, err := a.sessionFeed(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// GetSessionFeedPath returns the path of the Atom feed file of a session,
+// written when session feeds are enabled
+func (a *App) GetSessionFeedPath(serverURL, sessionID string) (string, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(gameDir, sessionFeedFile), nil
+}
+
+// writeSessionFeed rewrites the feed file of a session if feeds are enabled
+// and the session has a game directory. Failures are only logged.
+func (a *App) writeSessionFeed(serverURL, sessionID string) {
+	settings, err := a.config.GetAppSettings()
+	if err != nil || !settings.GetSessionFeeds() {
+		return
+	}
+
+	path, err := a.GetSessionFeedPath(serverURL, sessionID)
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		return
+	}
+	data, err := a.sessionFeed(serverURL, sessionID)
+	if err == nil {
+		err = safefile.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to write session feed")
+	}
+}
+
+// sessionFeed renders the Atom feed of a session from its timeline
+func (a *App) sessionFeed(serverURL, sessionID string) ([]byte, error) {
+	events, err := a.GetSessionTimeline(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	name := sessionID
+	if session, ok := a.cachedSession(serverURL, sessionID); ok && session.Name != "" {
+		name = session.Name
+	}
+
+	feedID := strings.TrimSuffix(serverURL, "/") + "/sessions/" + sessionID
+	f := &feed.Feed{
+		ID:     feedID,
+		Title:  name,
+		Author: "Astrum",
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		e := events[i]
+		if !sessionFeedEvents[e.Type] {
+			continue
+		}
+		at, err := time.Parse(time.RFC3339, e.Time)
+		if err != nil {
+			continue
+		}
+		f.Entries = append(f.Entries, feed.Entry{
+			ID:      fmt.Sprintf("%s#%s-%d-%d-%s", feedID, e.Type, e.Year, at.Unix(), url.PathEscape(e.Detail)),
+			Title:   fmt.Sprintf("%s: %s", name, e.Title),
+			Updated: at,
+		})
+	}
+	return f.Marshal(time.Now())
+}
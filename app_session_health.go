@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/api/models"
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// SESSION HEALTH
+// =============================================================================
+
+// CheckSessionHealth verifies that the server holds a complete set of files
+// for the current year of a started game (session managers only): the host
+// file, a turn for every player and orders matching the year, so a game about
+// to get stuck can be fixed beforehand
+func (a *App) CheckSessionHealth(serverURL, sessionID string) (*SessionHealthInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return nil, fmt.Errorf("no user info available")
+	}
+
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	if !containsString(session.Managers, userInfo.User.ID) {
+		return nil, fmt.Errorf("only session managers can check the session health")
+	}
+	if session.State != models.SessionStateStarted {
+		return nil, fmt.Errorf("the game has not started")
+	}
+
+	files, err := client.GetSessionFiles(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session files: %w", err)
+	}
+
+	health := &SessionHealthInfo{
+		SessionID: sessionID,
+		Year:      int(files.Year),
+		Issues:    []GenerationIssueInfo{},
+	}
+
+	health.Issues = append(health.Issues, gameFileIssues(files)...)
+
+	players := len(session.Players)
+	for i := range players {
+		var b64Data string
+		if i < len(files.Turns) {
+			b64Data = files.Turns[i].B64Data
+		}
+		if issue := checkTurnFile(b64Data, i+1, health.Year); issue != nil {
+			health.Issues = append(health.Issues, *issue)
+		}
+	}
+
+	for i, order := range files.Orders {
+		if order.B64Data == "" {
+			continue
+		}
+		if i >= players {
+			health.Issues = append(health.Issues, GenerationIssueInfo{
+				Code:         "order_unknown_player",
+				PlayerNumber: i + 1,
+				Message:      fmt.Sprintf("The server holds orders for player %d, the game has %d players", i+1, players),
+				Suggestion:   "Ask the server administrator to remove the stray orders",
+			})
+			continue
+		}
+		if issue := diagnoseOrder(order.B64Data, i+1, health.Year); issue != nil {
+			health.Issues = append(health.Issues, *issue)
+		}
+	}
+
+	health.Healthy = len(health.Issues) == 0
+	logger.App.Info().Str("sessionId", sessionID).Int("year", health.Year).Int("issues", len(health.Issues)).Msg("Checked session health")
+	return health, nil
+}
+
+// gameFileIssues reports the game-wide files missing from the session files
+func gameFileIssues(files *api.SessionFiles) []GenerationIssueInfo {
+	var issues []GenerationIssueInfo
+	if files.HostFile == "" {
+		issues = append(issues, GenerationIssueInfo{
+			Code:       "host_file_missing",
+			Message:    "The server has no host file (game.hst) for this game",
+			Suggestion: "Restore the host file from a session backup, or ask the server administrator to restore it",
+		})
+	}
+	if files.Universe == "" {
+		issues = append(issues, GenerationIssueInfo{
+			Code:       "universe_missing",
+			Message:    "The server has no universe file (game.xy) for this game",
+			Suggestion: "Restore the universe file from a session backup, or ask the server administrator to restore it",
+		})
+	}
+	return issues
+}
+
+// checkTurnFile checks the turn file of a player held by the server,
+// returning nil if it looks fine
+func checkTurnFile(b64Data string, playerNumber, year int) *GenerationIssueInfo {
+	regenerate := "Restore the turn from a session backup, or ask the server administrator to regenerate it"
+
+	if b64Data == "" {
+		return &GenerationIssueInfo{
+			Code:         "turn_missing",
+			PlayerNumber: playerNumber,
+			Message:      fmt.Sprintf("The server has no turn file (game.m%d) for player %d", playerNumber, playerNumber),
+			Suggestion:   regenerate,
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(b64Data)
+	if err == nil {
+		var validator *astrum.OrderValidator
+		validator, err = astrum.NewOrderValidatorFromBytes(data)
+		if err == nil {
+			if year != 0 && validator.Year() != year {
+				return &GenerationIssueInfo{
+					Code:         "turn_wrong_year",
+					PlayerNumber: playerNumber,
+					Message:      fmt.Sprintf("The turn of player %d is for year %d, not %d", playerNumber, validator.Year(), year),
+					Suggestion:   regenerate,
+				}
+			}
+			return nil
+		}
+	}
+	return &GenerationIssueInfo{
+		Code:         "turn_corrupt",
+		PlayerNumber: playerNumber,
+		Message:      fmt.Sprintf("The turn of player %d can't be read: %v", playerNumber, err),
+		Suggestion:   regenerate,
+	}
+}
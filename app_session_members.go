@@ -0,0 +1,159 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/auth"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// SESSION MEMBERSHIP MANAGEMENT
+// =============================================================================
+
+// GetMemberManagement tells which membership changes the server supports, as
+// far as known: both are assumed until the server refuses them, which is
+// remembered until the next connection
+func (a *App) GetMemberManagement(serverURL string) *MemberManagementInfo {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return &MemberManagementInfo{
+		CanDemote: !a.noDemote[serverURL],
+		CanRemove: !a.noMemberRemoval[serverURL],
+	}
+}
+
+// DemoteManager makes a manager of a session a plain member (manager only).
+// A session keeps at least one manager. The change is echoed to the cached
+// session ("session:optimistic"), then confirmed or rolled back, and emits
+// "session:manager-demoted" (serverURL, sessionID, memberID) once done.
+func (a *App) DemoteManager(serverURL, sessionID, memberID string) error {
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
+
+	client, mgr, session, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(session.Managers, memberID) {
+		return fmt.Errorf("this member is not a manager of the session")
+	}
+	if len(session.Managers) == 1 {
+		return fmt.Errorf("a session needs at least one manager, promote another member first")
+	}
+
+	update := a.beginSessionUpdate(serverURL, sessionID, func(s *SessionInfo) {
+		s.Managers = slices.DeleteFunc(s.Managers, func(id string) bool { return id == memberID })
+	})
+
+	if err := a.demoteMember(serverURL, client, mgr, sessionID, memberID); err != nil {
+		update.rollback(err)
+		return err
+	}
+	update.confirmEchoed()
+
+	logger.App.Info().Str("sessionId", sessionID).Str("memberId", memberID).Msg("Demoted manager to member")
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("session:manager-demoted", serverURL, sessionID, memberID)
+	}
+	return nil
+}
+
+// RemoveMember removes a member from a session (manager only), freeing their
+// player slot. Leaving a session ourselves goes through QuitSession. The
+// change is echoed to the cached session ("session:optimistic"), then
+// confirmed or rolled back, and emits "session:member-removed" (serverURL,
+// sessionID, memberID) once done.
+func (a *App) RemoveMember(serverURL, sessionID, memberID string) error {
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
+
+	a.mu.RLock()
+	unsupported := a.noMemberRemoval[serverURL]
+	a.mu.RUnlock()
+	if unsupported {
+		return fmt.Errorf("failed to remove member: this server cannot remove members: %w", api.ErrNotSupported)
+	}
+
+	client, mgr, session, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
+	if memberID == mgr.GetUserInfo().User.ID {
+		return fmt.Errorf("use QuitSession to leave the session")
+	}
+	if !slices.Contains(session.Members, memberID) && !slices.Contains(session.Managers, memberID) {
+		return fmt.Errorf("this user is not a member of the session")
+	}
+
+	update := a.beginSessionUpdate(serverURL, sessionID, func(s *SessionInfo) {
+		isMember := func(id string) bool { return id == memberID }
+		s.Members = slices.DeleteFunc(s.Members, isMember)
+		s.Managers = slices.DeleteFunc(s.Managers, isMember)
+		s.Players = slices.DeleteFunc(s.Players, func(p SessionPlayerInfo) bool { return p.UserProfileID == memberID })
+	})
+
+	err = client.RemoveMember(mgr.GetContext(), sessionID, memberID)
+	if errors.Is(err, api.ErrNotSupported) {
+		a.markNoMemberRemoval(serverURL)
+		err = fmt.Errorf("failed to remove member: this server cannot remove members: %w", err)
+	} else if err != nil {
+		err = fmt.Errorf("failed to remove member: %w", err)
+	}
+	if err != nil {
+		update.rollback(err)
+		return err
+	}
+	update.confirmEchoed()
+
+	logger.App.Info().Str("sessionId", sessionID).Str("memberId", memberID).Msg("Removed member from session")
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("session:member-removed", serverURL, sessionID, memberID)
+	}
+	return nil
+}
+
+// demoteMember demotes a manager, remembering servers that cannot demote
+func (a *App) demoteMember(serverURL string, client *api.Client, mgr *auth.Manager, sessionID, memberID string) error {
+	a.mu.RLock()
+	unsupported := a.noDemote[serverURL]
+	a.mu.RUnlock()
+	if unsupported {
+		return fmt.Errorf("failed to demote manager: this server cannot demote managers: %w", api.ErrNotSupported)
+	}
+
+	err := client.DemoteMember(mgr.GetContext(), sessionID, memberID)
+	if errors.Is(err, api.ErrNotSupported) {
+		a.markNoDemote(serverURL)
+		return fmt.Errorf("failed to demote manager: this server cannot demote managers: %w", err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to demote manager: %w", err)
+	}
+	return nil
+}
+
+func (a *App) markNoDemote(serverURL string) {
+	a.mu.Lock()
+	a.noDemote[serverURL] = true
+	a.mu.Unlock()
+	logger.App.Debug().Str("serverUrl", serverURL).Msg("Server cannot demote managers")
+}
+
+func (a *App) markNoMemberRemoval(serverURL string) {
+	a.mu.Lock()
+	a.noMemberRemoval[serverURL] = true
+	a.mu.Unlock()
+	logger.App.Debug().Str("serverUrl", serverURL).Msg("Server cannot remove members")
+}
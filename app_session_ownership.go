@@ -0,0 +1,194 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/api/models"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// SESSION OWNERSHIP TRANSFER
+// =============================================================================
+
+// What becomes of the current host once a session is handed over
+const (
+	handoverStay   = "stay"   // remain a manager next to the new host
+	handoverDemote = "demote" // become a plain member, where the server can demote
+	handoverQuit   = "quit"   // leave the session
+)
+
+// TransferSessionOwnership hands a session over to another member: the member
+// is promoted to manager, then the current host stays a manager, is demoted
+// to a plain member or quits the session, depending on mode ("stay", "demote"
+// or "quit"). Servers that cannot demote managers leave the current host a
+// manager, with a warning. Handoff notes for the new host (players, year and
+// the given notes) are written in the game directory and returned, ready to be
+// sent. Emits "session:transferred" (serverURL, sessionID, info).
+func (a *App) TransferSessionOwnership(serverURL, sessionID, newManagerID, mode, notes string) (*OwnershipTransferInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+	switch mode {
+	case handoverStay, handoverDemote, handoverQuit:
+	default:
+		return nil, fmt.Errorf("unknown handover mode %q", mode)
+	}
+
+	client, mgr, session, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	ctx := mgr.GetContext()
+	userID := mgr.GetUserInfo().User.ID
+	if newManagerID == userID {
+		return nil, fmt.Errorf("the session is already yours")
+	}
+	if !slices.Contains(session.Members, newManagerID) && !slices.Contains(session.Managers, newManagerID) {
+		return nil, fmt.Errorf("the new host must be a member of the session")
+	}
+
+	nicknames := make(map[string]string)
+	if profiles, err := a.getCachedUserProfiles(serverURL, client, mgr); err == nil {
+		for _, p := range profiles {
+			nicknames[p.ID] = p.Nickname
+		}
+	}
+	nickname := func(id string) string {
+		if n, ok := nicknames[id]; ok {
+			return n
+		}
+		return id
+	}
+
+	info := &OwnershipTransferInfo{
+		SessionID:    sessionID,
+		NewManagerID: newManagerID,
+		NewManager:   nickname(newManagerID),
+		Role:         "manager",
+		Warnings:     []string{},
+	}
+
+	if !slices.Contains(session.Managers, newManagerID) {
+		if err := client.PromoteMember(ctx, sessionID, newManagerID); err != nil {
+			return nil, fmt.Errorf("failed to promote %s: %w", info.NewManager, err)
+		}
+		info.Promoted = true
+	}
+
+	// Written while we can still read the session
+	info.Notes = handoffNotes(session, nickname, nickname(userID), info.NewManager, a.sessionYear(serverURL, sessionID), notes, time.Now())
+	if path, err := a.writeHandoffNotes(serverURL, sessionID, info.Notes); err != nil {
+		info.Warnings = append(info.Warnings, fmt.Sprintf("Handoff notes not saved: %v", err))
+	} else {
+		info.NotesPath = path
+	}
+
+	switch mode {
+	case handoverDemote:
+		err := a.demoteMember(serverURL, client, mgr, sessionID, userID)
+		switch {
+		case errors.Is(err, api.ErrNotSupported):
+			info.Warnings = append(info.Warnings, "This server cannot demote managers, you remain a manager of the session")
+		case err != nil:
+			info.Warnings = append(info.Warnings, fmt.Sprintf("Could not step down: %v", err))
+		default:
+			info.Role = "member"
+		}
+	case handoverQuit:
+		if err := a.QuitSession(serverURL, sessionID); err != nil {
+			info.Warnings = append(info.Warnings, fmt.Sprintf("Failed to leave the session: %v", err))
+		} else {
+			info.Role = "none"
+		}
+	}
+
+	a.recordTimelineEvent(serverURL, sessionID, model.TimelineEvent{
+		Type:   model.TimelineHostChanged,
+		Time:   time.Now(),
+		Detail: info.NewManager,
+	})
+
+	logger.App.Info().
+		Str("sessionId", sessionID).
+		Str("newManagerId", newManagerID).
+		Str("mode", mode).
+		Str("role", info.Role).
+		Msg("Transferred session ownership")
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("session:transferred", serverURL, sessionID, info)
+	}
+	return info, nil
+}
+
+// sessionYear returns the last known pending year of a session, 0 if unknown
+func (a *App) sessionYear(serverURL, sessionID string) int {
+	a.mu.RLock()
+	status := a.ordersStatus[serverURL+"|"+sessionID]
+	a.mu.RUnlock()
+	if status != nil {
+		return status.PendingYear
+	}
+	return 0
+}
+
+// writeHandoffNotes saves handoff notes in the game directory of a session
+func (a *App) writeHandoffNotes(serverURL, sessionID, notes string) (string, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(gameDir); err != nil {
+		return "", fmt.Errorf("no game directory: %w", err)
+	}
+	path := filepath.Join(gameDir, fmt.Sprintf("handoff-%s.md", time.Now().Format("20060102-150405")))
+	if err := safefile.WriteFile(path, []byte(notes), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// handoffNotes writes what a new host needs to take a session over, as Markdown
+func handoffNotes(session *api.Session, nickname func(string) string, from, to string, year int, notes string, at time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Handoff of %s\n\n", session.Name)
+	fmt.Fprintf(&b, "Handed over by %s to %s on %s.\n\n", from, to, at.Format("2006-01-02"))
+	fmt.Fprintf(&b, "- Session: %s\n", session.ID)
+	fmt.Fprintf(&b, "- State: %s\n", session.State)
+	if session.State == models.SessionStateStarted && year > 0 {
+		fmt.Fprintf(&b, "- Pending year: %d\n", year)
+	}
+
+	if len(session.Players) > 0 {
+		b.WriteString("\n## Players\n\n")
+		players := slices.Clone(session.Players)
+		slices.SortFunc(players, func(x, y *api.SessionPlayer) int { return int(x.PlayerOrder - y.PlayerOrder) })
+		for _, p := range players {
+			if p.IsBot {
+				fmt.Fprintf(&b, "%d. AI (%s)\n", p.PlayerOrder+1, p.BotRaceName)
+			} else {
+				fmt.Fprintf(&b, "%d. %s\n", p.PlayerOrder+1, nickname(p.UserProfileID))
+			}
+		}
+	}
+
+	if notes = strings.TrimSpace(notes); notes != "" {
+		b.WriteString("\n## Notes\n\n")
+		b.WriteString(notes)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gen2brain/beeep"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/auth"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// SESSION WAITING LIST
+// =============================================================================
+
+// JoinWaitlist puts the current user on the waiting list of a full session.
+// The server offers the next free slot to the first user of the list, which
+// shows a desktop notification. Returns the position on the list.
+func (a *App) JoinWaitlist(serverURL, sessionID string) (int, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	entry, err := client.JoinWaitlist(mgr.GetContext(), sessionID)
+	if err != nil {
+		return 0, waitlistError("failed to join waiting list", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int("position", entry.Position).Msg("Joined session waiting list")
+	return entry.Position, nil
+}
+
+// LeaveWaitlist takes the current user off the waiting list of a session
+func (a *App) LeaveWaitlist(serverURL, sessionID string) error {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return fmt.Errorf("no user info available")
+	}
+
+	if err := client.RemoveWaitlistEntry(mgr.GetContext(), sessionID, userInfo.User.ID); err != nil {
+		return waitlistError("failed to leave waiting list", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Msg("Left session waiting list")
+	return nil
+}
+
+// GetWaitlist returns the waiting list of a session, first in line first.
+// Managers only.
+func (a *App) GetWaitlist(serverURL, sessionID string) ([]WaitlistEntryInfo, error) {
+	client, mgr, _, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := client.GetWaitlist(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, waitlistError("failed to get waiting list", err)
+	}
+	return a.convertWaitlist(serverURL, client, mgr, entries), nil
+}
+
+// MoveWaitlistEntry moves a user to a position of the waiting list, 1 being
+// the next user offered a slot. Managers only.
+func (a *App) MoveWaitlistEntry(serverURL, sessionID, userProfileID string, position int) ([]WaitlistEntryInfo, error) {
+	if position < 1 {
+		return nil, fmt.Errorf("position must be at least 1")
+	}
+
+	client, mgr, _, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := client.MoveWaitlistEntry(mgr.GetContext(), sessionID, userProfileID, position)
+	if err != nil {
+		return nil, waitlistError("failed to move waiting list entry", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Str("userProfileId", userProfileID).Int("position", position).Msg("Moved waiting list entry")
+	return a.convertWaitlist(serverURL, client, mgr, entries), nil
+}
+
+// RemoveFromWaitlist takes a user off the waiting list of a session. Managers only.
+func (a *App) RemoveFromWaitlist(serverURL, sessionID, userProfileID string) error {
+	client, mgr, _, err := a.managedSession(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if err := client.RemoveWaitlistEntry(mgr.GetContext(), sessionID, userProfileID); err != nil {
+		return waitlistError("failed to remove waiting list entry", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Str("userProfileId", userProfileID).Msg("Removed waiting list entry")
+	return nil
+}
+
+// waitlistError wraps a failed waiting list request
+func waitlistError(msg string, err error) error {
+	if errors.Is(err, api.ErrNotSupported) {
+		return fmt.Errorf("%s: this server does not support waiting lists: %w", msg, err)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// convertWaitlist converts waiting list entries to the frontend format
+func (a *App) convertWaitlist(serverURL string, client *api.Client, mgr *auth.Manager, entries []api.WaitlistEntry) []WaitlistEntryInfo {
+	nicknames := make(map[string]string)
+	if profiles, err := a.getCachedUserProfiles(serverURL, client, mgr); err == nil {
+		for _, p := range profiles {
+			nicknames[p.ID] = p.Nickname
+		}
+	}
+
+	result := make([]WaitlistEntryInfo, len(entries))
+	for i, e := range entries {
+		nickname := nicknames[e.UserProfileID]
+		if nickname == "" {
+			nickname = e.UserProfileID
+		}
+		result[i] = WaitlistEntryInfo{
+			UserProfileID: e.UserProfileID,
+			Nickname:      nickname,
+			Position:      e.Position,
+			JoinedAt:      e.JoinedAt.Format(time.RFC3339),
+			Notified:      !e.NotifiedAt.IsZero(),
+		}
+	}
+	return result
+}
+
+// showWaitlistSlotNotification shows a desktop notification when a slot of a
+// session opened for the current user
+func (a *App) showWaitlistSlotNotification(serverURL, sessionID string, metadata interface{}) {
+	metaMap, ok := metadata.(map[string]interface{})
+	if !ok {
+		return
+	}
+	userID, _ := metaMap["user_profile_id"].(string)
+
+	a.mu.RLock()
+	client := a.clients[serverURL]
+	mgr := a.authManagers[serverURL]
+	a.mu.RUnlock()
+	if client == nil || mgr == nil {
+		return
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil || userID == "" || userID != userInfo.User.ID {
+		return
+	}
+
+	sessionName := sessionID
+	if session, err := client.GetSession(mgr.GetContext(), sessionID); err == nil {
+		sessionName = session.Name
+	}
+
+	title := "Slot Available"
+	message := fmt.Sprintf("A slot opened for you in %s", sessionName)
+	if err := beeep.Notify(title, message, a.notificationIcon); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to show desktop notification")
+	} else {
+		logger.App.Debug().
+			Str("serverUrl", serverURL).
+			Str("sessionId", sessionID).
+			Msg("Desktop notification shown for waiting list slot")
+	}
+}
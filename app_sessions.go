@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 
 	"github.com/neper-stars/astrum/api"
@@ -41,11 +42,17 @@ func (a *App) GetSessions(serverURL string) ([]SessionInfo, error) {
 	a.mu.RUnlock()
 
 	if !ok || !mgrOk {
-		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+		if cached, ok := a.cachedSessions(serverURL); ok {
+			return cached, nil
+		}
+		return nil, fmt.Errorf("%w: %s", ErrNotConnected, serverURL)
 	}
 
 	sessions, err := client.ListSessions(mgr.GetContext())
 	if err != nil {
+		if cached, ok := a.cachedSessions(serverURL); ok && useServerCache(err) {
+			return cached, nil
+		}
 		return nil, fmt.Errorf("failed to get sessions: %w", err)
 	}
 
@@ -65,10 +72,14 @@ func (a *App) GetSessions(serverURL string) ([]SessionInfo, error) {
 			Players:           convertPlayers(s.Players),
 			PendingInvitation: s.PendingInvitation,
 		}
+		a.applyDeadline(serverURL, &result[i])
 	}
 
-	// Archive any local session directories that no longer exist on the server
-	go a.archiveOrphanedSessions(serverURL, serverSessionIDs)
+	a.cacheServerData(serverURL, cacheKeySessions, result)
+
+	// Archive any local session directories that no longer exist on the server,
+	// adopting those made by hand for our sessions
+	go a.archiveOrphanedSessions(serverURL, serverSessionIDs, sessions)
 
 	return result, nil
 }
@@ -109,19 +120,39 @@ func (a *App) GetSessionsIncludeArchived(serverURL string) ([]SessionInfo, error
 
 // GetSession returns a specific session
 func (a *App) GetSession(serverURL, sessionID string) (*SessionInfo, error) {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err == nil {
+		var session *api.Session
+		session, err = client.GetSession(mgr.GetContext(), sessionID)
+		if err == nil {
+			info := a.convertSession(serverURL, sessionID, session)
+			if capacity, capErr := a.fetchSessionCapacity(serverURL, client, mgr, sessionID); capErr != nil {
+				logger.App.Debug().Err(capErr).Str("sessionId", sessionID).Msg("Failed to get session capacity")
+			} else {
+				applySessionCapacity(info, capacity)
+			}
+			if online, presenceErr := a.fetchSessionPresence(serverURL, client, mgr, sessionID); presenceErr != nil {
+				logger.App.Debug().Err(presenceErr).Str("sessionId", sessionID).Msg("Failed to get session presence")
+			} else {
+				info.Online = online
+			}
+			a.applyDeadline(serverURL, info)
+			a.cacheServerData(serverURL, cacheKeySession+sessionID, info)
+			return info, nil
+		}
+		err = fmt.Errorf("failed to get session: %w", err)
 	}
 
-	session, err := client.GetSession(mgr.GetContext(), sessionID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get session: %w", err)
+	if useServerCache(err) {
+		if cached, ok := a.cachedSession(serverURL, sessionID); ok {
+			return cached, nil
+		}
 	}
+	return nil, err
+}
+
+// convertSession converts a session fetched by GetSession to the frontend format
+func (a *App) convertSession(serverURL, sessionID string, session *api.Session) *SessionInfo {
 
 	logger.App.Debug().
 		Str("serverUrl", serverURL).
@@ -150,7 +181,7 @@ func (a *App) GetSession(serverURL, sessionID string) (*SessionInfo, error) {
 		RulesIsSet:        session.RulesIsSet,
 		Players:           convertPlayers(session.Players),
 		PendingInvitation: session.PendingInvitation,
-	}, nil
+	}
 }
 
 // CreateSession creates a new session
@@ -175,6 +206,7 @@ func (a *App) CreateSession(serverURL, name string, isPublic bool) (*SessionInfo
 	}
 
 	logger.App.Info().Str("name", created.Name).Str("id", created.ID).Msg("Created session")
+	a.setSessionSubscribed(serverURL, created.ID, true)
 
 	// Create the game directory for this session and download stars.exe if enabled
 	server, _ := a.config.GetServer(serverURL)
@@ -182,7 +214,7 @@ func (a *App) CreateSession(serverURL, name string, isPublic bool) (*SessionInfo
 	if server != nil {
 		serverName = server.Name
 	}
-	a.setupSessionGameDir(serverURL, serverName, created.ID)
+	a.setupSessionGameDir(serverURL, serverName, created.ID, created.Name)
 
 	return &SessionInfo{
 		ID:                created.ID,
@@ -199,21 +231,27 @@ func (a *App) CreateSession(serverURL, name string, isPublic bool) (*SessionInfo
 
 // JoinSession joins an existing session
 func (a *App) JoinSession(serverURL, sessionID string) (*SessionInfo, error) {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
+	update := a.beginSessionUpdate(serverURL, sessionID, func(s *SessionInfo) {
+		if userInfo := mgr.GetUserInfo(); userInfo != nil && !slices.Contains(s.Members, userInfo.User.ID) {
+			s.Members = append(s.Members, userInfo.User.ID)
+		}
+		s.PendingInvitation = false
+	})
+
 	session, err := client.JoinSession(mgr.GetContext(), sessionID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to join session: %w", err)
+		err = fmt.Errorf("failed to join session: %w", err)
+		update.rollback(err)
+		return nil, err
 	}
 
 	logger.App.Info().Str("name", session.Name).Str("id", session.ID).Msg("Joined session")
+	a.setSessionSubscribed(serverURL, session.ID, true)
 
 	// Create the game directory for this session and download stars.exe if enabled
 	server, _ := a.config.GetServer(serverURL)
@@ -221,9 +259,9 @@ func (a *App) JoinSession(serverURL, sessionID string) (*SessionInfo, error) {
 	if server != nil {
 		serverName = server.Name
 	}
-	a.setupSessionGameDir(serverURL, serverName, session.ID)
+	a.setupSessionGameDir(serverURL, serverName, session.ID, session.Name)
 
-	return &SessionInfo{
+	info := &SessionInfo{
 		ID:                session.ID,
 		Name:              session.Name,
 		IsPublic:          !session.Private,
@@ -233,18 +271,21 @@ func (a *App) JoinSession(serverURL, sessionID string) (*SessionInfo, error) {
 		RulesIsSet:        session.RulesIsSet,
 		Players:           convertPlayers(session.Players),
 		PendingInvitation: session.PendingInvitation,
-	}, nil
+	}
+	update.confirm(info)
+
+	return info, nil
 }
 
 // DeleteSession deletes a session (manager only)
 func (a *App) DeleteSession(serverURL, sessionID string) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
 
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
 	if err := client.DeleteSession(mgr.GetContext(), sessionID); err != nil {
@@ -252,18 +293,19 @@ func (a *App) DeleteSession(serverURL, sessionID string) error {
 	}
 
 	logger.App.Info().Str("id", sessionID).Msg("Deleted session")
+	a.setSessionSubscribed(serverURL, sessionID, false)
 	return nil
 }
 
 // QuitSession removes the current user from a session
 func (a *App) QuitSession(serverURL, sessionID string) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
 
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
 	if err := client.QuitSession(mgr.GetContext(), sessionID); err != nil {
@@ -287,18 +329,15 @@ func (a *App) QuitSession(serverURL, sessionID string) error {
 	}
 
 	logger.App.Info().Str("id", sessionID).Msg("Quit session")
+	a.setSessionSubscribed(serverURL, sessionID, false)
 	return nil
 }
 
 // PromoteMember promotes a member to manager in a session
 func (a *App) PromoteMember(serverURL, sessionID, memberID string) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
 	if err := client.PromoteMember(mgr.GetContext(), sessionID, memberID); err != nil {
@@ -311,13 +350,9 @@ func (a *App) PromoteMember(serverURL, sessionID, memberID string) error {
 
 // ArchiveSession archives a finished session (manager only)
 func (a *App) ArchiveSession(serverURL, sessionID string) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
 	if err := client.ArchiveSession(mgr.GetContext(), sessionID); err != nil {
@@ -362,7 +397,8 @@ func (a *App) archiveDeletedSession(serverURL, sessionID string) {
 
 // archiveOrphanedSessions checks for local session directories that don't exist on the server
 // and moves them to ZZ_OLD_SESSIONS. This is called after fetching sessions from the server.
-func (a *App) archiveOrphanedSessions(serverURL string, serverSessionIDs map[string]bool) {
+// Directories named after one of the sessions, e.g. copied by hand, are adopted instead.
+func (a *App) archiveOrphanedSessions(serverURL string, serverSessionIDs map[string]bool, sessions []api.Session) {
 	// Get server name from URL
 	server, err := a.config.GetServer(serverURL)
 	if err != nil || server == nil {
@@ -382,6 +418,9 @@ func (a *App) archiveOrphanedSessions(serverURL string, serverSessionIDs map[str
 	// Check each local directory against server sessions
 	for _, localSessionID := range localSessionDirs {
 		if !serverSessionIDs[localSessionID] {
+			if a.adoptSessionDir(serverURL, server.Name, localSessionID, sessions) {
+				continue
+			}
 			// This session doesn't exist on server - archive it
 			archivedPath, err := a.config.ArchiveSessionDir(server.Name, localSessionID)
 			if err != nil {
@@ -407,13 +446,9 @@ func (a *App) archiveOrphanedSessions(serverURL string, serverSessionIDs map[str
 
 // GetPlayerControlStatus returns the control status for all players in a session (manager only)
 func (a *App) GetPlayerControlStatus(serverURL, sessionID string) ([]PlayerControlStatusInfo, error) {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
 	list, err := client.GetPlayerControl(mgr.GetContext(), sessionID)
@@ -438,13 +473,9 @@ func (a *App) GetPlayerControlStatus(serverURL, sessionID string) ([]PlayerContr
 
 // SwitchPlayerToAI switches a human player to AI control (manager only)
 func (a *App) SwitchPlayerToAI(serverURL, sessionID string, playerOrder int, aiType string) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
 	if err := client.SwitchPlayerToAI(mgr.GetContext(), sessionID, playerOrder, aiType); err != nil {
@@ -463,13 +494,9 @@ func (a *App) SwitchPlayerToAI(serverURL, sessionID string, playerOrder int, aiT
 
 // SwitchPlayerToHuman switches an AI-controlled player back to human control (manager only)
 func (a *App) SwitchPlayerToHuman(serverURL, sessionID string, playerOrder int) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
 	if err := client.SwitchPlayerToHuman(mgr.GetContext(), sessionID, playerOrder); err != nil {
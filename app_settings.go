@@ -11,7 +11,11 @@ import (
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 
+	"github.com/neper-stars/astrum/api"
+	astrum "github.com/neper-stars/astrum/lib"
 	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/platform"
+	"github.com/neper-stars/astrum/lib/safefile"
 	"github.com/neper-stars/neper/lib/wine"
 )
 
@@ -34,6 +38,49 @@ func (a *App) GetAppSettings() (*AppSettingsInfo, error) {
 		WinePrefixesDir:    settings.GetWinePrefixesDir(),
 		ValidWineInstall:   settings.GetValidWineInstall(),
 		EnableBrowserStars: settings.GetEnableBrowserStars(),
+
+		InvitationExpiryDays: settings.GetInvitationExpiryDays(),
+		InvitationDigest:     settings.GetInvitationDigest(),
+
+		LocalAPIEnabled: settings.GetLocalAPIEnabled(),
+		LocalAPIPort:    settings.GetLocalAPIPort(),
+
+		StatusFileEnabled: settings.GetStatusFileEnabled(),
+		StatusFilePath:    settings.GetStatusFilePath(),
+
+		SessionFeeds: settings.GetSessionFeeds(),
+
+		ArtifactCollisionPolicy: settings.GetArtifactCollisionPolicy(),
+
+		SubmissionConfirmation: settings.GetSubmissionConfirmation(),
+		SubmissionDigest:       settings.GetSubmissionDigest(),
+		ReportTurnReads:        settings.GetReportTurnReads(),
+		DeadlineNotifications:  settings.GetDeadlineNotifications(),
+
+		WebSocketPingInterval: settings.GetWebSocketPingInterval(),
+		WebSocketPongWait:     settings.GetWebSocketPongWait(),
+		WebSocketAdaptive:     settings.GetWebSocketAdaptive(),
+
+		RaceWorkshopDir: settings.GetRaceWorkshopDir(),
+
+		FileMode:  settings.GetFileMode(),
+		DirMode:   settings.GetDirMode(),
+		FileGroup: settings.GetFileGroup(),
+
+		MinFreeDiskSpaceMB: settings.GetMinFreeDiskSpaceMB(),
+		LowDiskSpaceAction: settings.GetLowDiskSpaceAction(),
+
+		OrderDebounceMs: settings.GetOrderDebounceMs(),
+		OrderSettleMs:   settings.GetOrderSettleMs(),
+
+		LogFormat: settings.GetLogFormat(),
+		LogSyslog: settings.GetLogSyslog(),
+
+		HardDelete: settings.GetHardDelete(),
+
+		HistoryRetentionYears: settings.GetHistoryRetentionYears(),
+
+		ConcurrentLaunchPolicy: settings.GetConcurrentLaunchPolicy(),
 	}, nil
 }
 
@@ -188,6 +235,259 @@ func (a *App) SetEnableBrowserStars(enabled bool) (*AppSettingsInfo, error) {
 	return a.GetAppSettings()
 }
 
+// SetInvitationExpiryDays sets after how many days pending invitations are declined (0 disables)
+func (a *App) SetInvitationExpiryDays(days int) (*AppSettingsInfo, error) {
+	if err := a.config.SetInvitationExpiryDays(days); err != nil {
+		return nil, fmt.Errorf("failed to set invitation expiry: %w", err)
+	}
+
+	logger.App.Info().Int("days", days).Msg("Set invitation expiry")
+
+	go a.runInvitationPolicyForAll()
+
+	return a.GetAppSettings()
+}
+
+// SetInvitationDigest enables or disables the daily pending invitations reminder
+func (a *App) SetInvitationDigest(enabled bool) (*AppSettingsInfo, error) {
+	if err := a.config.SetInvitationDigest(enabled); err != nil {
+		return nil, fmt.Errorf("failed to set invitation digest: %w", err)
+	}
+
+	logger.App.Info().Bool("enabled", enabled).Msg("Set invitation digest")
+
+	return a.GetAppSettings()
+}
+
+// SetSubmissionNotifications enables or disables the notification after each order
+// upload and the weekly digest of uploaded orders
+func (a *App) SetSubmissionNotifications(confirmation, digest bool) (*AppSettingsInfo, error) {
+	if err := a.config.SetSubmissionNotifications(confirmation, digest); err != nil {
+		return nil, fmt.Errorf("failed to set submission notifications: %w", err)
+	}
+
+	logger.App.Info().Bool("confirmation", confirmation).Bool("digest", digest).Msg("Set submission notifications")
+
+	return a.GetAppSettings()
+}
+
+// SetHardDelete sets whether the directories Astrum deletes are removed for
+// good instead of going to the trash
+func (a *App) SetHardDelete(enabled bool) (*AppSettingsInfo, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return nil, err
+	}
+
+	if err := a.config.SetHardDelete(enabled); err != nil {
+		return nil, fmt.Errorf("failed to set hard delete: %w", err)
+	}
+
+	logger.App.Info().Bool("enabled", enabled).Msg("Set hard delete")
+
+	return a.GetAppSettings()
+}
+
+// SetHistoryRetentionYears sets how many past years downloaded for review are
+// kept per session, 0 keeping them all. Sessions past the new retention are
+// pruned in the background.
+func (a *App) SetHistoryRetentionYears(years int) (*AppSettingsInfo, error) {
+	if err := a.config.SetHistoryRetentionYears(years); err != nil {
+		return nil, fmt.Errorf("failed to set history retention: %w", err)
+	}
+
+	logger.App.Info().Int("years", years).Msg("Set history retention")
+	go a.pruneAllHistoricYears()
+
+	return a.GetAppSettings()
+}
+
+// SetConcurrentLaunchPolicy sets what launching Stars! does when it already
+// runs for the same session or Wine prefix: "allow", "warn" or "block"
+func (a *App) SetConcurrentLaunchPolicy(policy string) (*AppSettingsInfo, error) {
+	if err := a.config.SetConcurrentLaunchPolicy(policy); err != nil {
+		return nil, fmt.Errorf("failed to set concurrent launch policy: %w", err)
+	}
+
+	logger.App.Info().Str("policy", policy).Msg("Set concurrent launch policy")
+
+	return a.GetAppSettings()
+}
+
+// SetReportTurnReads enables or disables telling the session hosts when a turn is opened
+func (a *App) SetReportTurnReads(enabled bool) (*AppSettingsInfo, error) {
+	if err := a.config.SetReportTurnReads(enabled); err != nil {
+		return nil, fmt.Errorf("failed to set turn read reporting: %w", err)
+	}
+
+	logger.App.Info().Bool("enabled", enabled).Msg("Set turn read reporting")
+
+	return a.GetAppSettings()
+}
+
+// SetDeadlineNotifications enables or disables the desktop notifications of
+// nearing deadlines of missing orders. The "session:deadline" events are
+// emitted either way.
+func (a *App) SetDeadlineNotifications(enabled bool) (*AppSettingsInfo, error) {
+	if err := a.config.SetDeadlineNotifications(enabled); err != nil {
+		return nil, fmt.Errorf("failed to set deadline notifications: %w", err)
+	}
+
+	logger.App.Info().Bool("enabled", enabled).Msg("Set deadline notifications")
+
+	return a.GetAppSettings()
+}
+
+// SetWebSocketHeartbeat sets the notification connection keep-alive (in seconds).
+// Connected servers pick the new settings up on their next reconnection.
+func (a *App) SetWebSocketHeartbeat(pingInterval, pongWait int, adaptive bool) (*AppSettingsInfo, error) {
+	if err := a.config.SetWebSocketHeartbeat(pingInterval, pongWait, adaptive); err != nil {
+		return nil, fmt.Errorf("failed to set WebSocket heartbeat: %w", err)
+	}
+
+	heartbeat := a.webSocketHeartbeat()
+	a.mu.RLock()
+	for _, notifMgr := range a.notificationManagers {
+		notifMgr.SetHeartbeat(heartbeat)
+	}
+	a.mu.RUnlock()
+
+	logger.App.Info().
+		Int("pingInterval", pingInterval).
+		Int("pongWait", pongWait).
+		Bool("adaptive", adaptive).
+		Msg("Set WebSocket heartbeat")
+
+	return a.GetAppSettings()
+}
+
+// webSocketHeartbeat returns the notification connection keep-alive from the settings
+func (a *App) webSocketHeartbeat() api.HeartbeatConfig {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return api.DefaultHeartbeatConfig()
+	}
+	return api.HeartbeatConfig{
+		PingInterval: time.Duration(settings.GetWebSocketPingInterval()) * time.Second,
+		PongWait:     time.Duration(settings.GetWebSocketPongWait()) * time.Second,
+		Adaptive:     settings.GetWebSocketAdaptive(),
+	}
+}
+
+// SetArtifactCollisionPolicy sets what to do when a generated file (map, GIF...) already exists
+func (a *App) SetArtifactCollisionPolicy(policy string) (*AppSettingsInfo, error) {
+	if err := a.config.SetArtifactCollisionPolicy(policy); err != nil {
+		return nil, fmt.Errorf("failed to set collision policy: %w", err)
+	}
+
+	logger.App.Info().Str("policy", policy).Msg("Set artifact collision policy")
+
+	return a.GetAppSettings()
+}
+
+// SetFilePermissions sets the octal modes ("0664", "0775") and the group of
+// the game files and directories Astrum creates, e.g. when the servers
+// directory is shared over Samba. "" keeps the default.
+func (a *App) SetFilePermissions(fileMode, dirMode, group string) (*AppSettingsInfo, error) {
+	perms := safefile.Permissions{Group: group}
+	var err error
+	if perms.FileMode, err = astrum.ParseFileMode(fileMode); err != nil {
+		return nil, err
+	}
+	if perms.DirMode, err = astrum.ParseFileMode(dirMode); err != nil {
+		return nil, err
+	}
+	if err := safefile.SetPermissions(perms); err != nil {
+		return nil, err
+	}
+	if err := a.config.SetFilePermissions(fileMode, dirMode, group); err != nil {
+		return nil, fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	logger.App.Info().Str("fileMode", fileMode).Str("dirMode", dirMode).Str("group", group).Msg("Set file permissions")
+
+	return a.GetAppSettings()
+}
+
+// applyFilePermissions applies the saved file permissions on startup
+func (a *App) applyFilePermissions() {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return
+	}
+	perms, err := settings.FilePermissions()
+	if err == nil {
+		err = safefile.SetPermissions(perms)
+	}
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to apply file permissions, using defaults")
+	}
+}
+
+// SetDiskSpaceGuard sets the free space (MB, 0 to disable) downloads, backups
+// and Wine prefix creation keep, and whether they "refuse" or "warn" below it
+func (a *App) SetDiskSpaceGuard(minFreeMB int, action string) (*AppSettingsInfo, error) {
+	if err := a.config.SetDiskSpaceGuard(minFreeMB, action); err != nil {
+		return nil, fmt.Errorf("failed to set disk space guard: %w", err)
+	}
+
+	logger.App.Info().Int("minFreeMb", minFreeMB).Str("action", action).Msg("Set disk space guard")
+
+	return a.GetAppSettings()
+}
+
+// SetOrderWatchTiming sets how long (ms) the order file watcher waits after
+// the last write, and how long an order file must stay unchanged before it is
+// read (0 to disable). Watched sessions are restarted with the new timing.
+func (a *App) SetOrderWatchTiming(debounceMs, settleMs int) (*AppSettingsInfo, error) {
+	if err := a.config.SetOrderWatchTiming(debounceMs, settleMs); err != nil {
+		return nil, fmt.Errorf("failed to set order watch timing: %w", err)
+	}
+
+	a.mu.RLock()
+	serverURLs := make([]string, 0, len(a.orderMonitors))
+	for serverURL := range a.orderMonitors {
+		serverURLs = append(serverURLs, serverURL)
+	}
+	a.mu.RUnlock()
+
+	for _, serverURL := range serverURLs {
+		sessions := a.pauseOrderMonitors(serverURL)
+		if len(sessions) > 0 {
+			a.resumeOrderMonitors(serverURL, sessions[0].ServerName, sessions)
+		}
+	}
+
+	logger.App.Info().Int("debounceMs", debounceMs).Int("settleMs", settleMs).Msg("Set order watch timing")
+
+	return a.GetAppSettings()
+}
+
+// GetConfigAuditLog returns the most recent configuration changes (settings,
+// servers and credential references), newest first. A limit of 0 returns all of them.
+func (a *App) GetConfigAuditLog(limit int) ([]ConfigAuditEntryInfo, error) {
+	entries, err := a.config.GetConfigAuditLog(limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]ConfigAuditEntryInfo, 0, len(entries))
+	for _, e := range entries {
+		changes := make([]ConfigChangeInfo, 0, len(e.Changes))
+		for _, c := range e.Changes {
+			changes = append(changes, ConfigChangeInfo{Field: c.Field, Old: c.Old, New: c.New})
+		}
+		result = append(result, ConfigAuditEntryInfo{
+			Time:    e.Time.Format(time.RFC3339),
+			Kind:    e.Kind,
+			Action:  e.Action,
+			Target:  e.Target,
+			Source:  e.Source,
+			Changes: changes,
+		})
+	}
+	return result, nil
+}
+
 // ensureWinePrefixesDir ensures the wine prefixes directory exists
 func (a *App) ensureWinePrefixesDir() error {
 	prefixesDir, err := a.config.GetWinePrefixesDir()
@@ -203,6 +503,9 @@ func (a *App) ensureWinePrefixesDir() error {
 	return nil
 }
 
+// winePrefixSize is about the size of a new Wine prefix
+const winePrefixSize = 300 * bytesPerMB
+
 // ensureServerWinePrefix ensures the wine prefix for a specific server exists, creating it if necessary
 func (a *App) ensureServerWinePrefix(serverName string) (string, error) {
 	prefixPath, err := a.config.GetServerWinePrefix(serverName)
@@ -210,6 +513,13 @@ func (a *App) ensureServerWinePrefix(serverName string) (string, error) {
 		return "", err
 	}
 
+	// A new Wine prefix takes a few hundred MB
+	if _, err := os.Stat(prefixPath); os.IsNotExist(err) {
+		if err := a.checkDiskSpace(prefixPath, "Wine prefix creation", winePrefixSize); err != nil {
+			return "", err
+		}
+	}
+
 	prefix, err := wine.NewPrefix(logger.App, wine.PrefixOptions{
 		PrefixPath: prefixPath,
 	})
@@ -229,14 +539,14 @@ func (a *App) ensureServerWinePrefix(serverName string) (string, error) {
 // This performs an actual test by creating a temporary wine prefix and running a simple command
 func (a *App) CheckWine32Support() (*WineCheckResult, error) {
 	// 1. Check if wine command exists
-	winePath, err := exec.LookPath("wine")
+	winePath, err := platform.FindWine()
 	if err != nil {
 		if err := a.config.SetValidWineInstall(false); err != nil {
 			logger.App.Warn().Err(err).Msg("Failed to save wine validation status")
 		}
 		return &WineCheckResult{
 			Valid:   false,
-			Message: "Wine binary not found. Please install Wine (e.g., 'apt install wine' or 'dnf install wine').",
+			Message: "Wine binary not found. " + platform.WineInstallHint,
 		}, nil
 	}
 
@@ -288,11 +598,11 @@ func (a *App) CheckWine32Support() (*WineCheckResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	cmd := exec.CommandContext(ctx, "wine", "cmd", "/c", "echo", "test")
-	cmd.Env = append(os.Environ(),
-		"WINEPREFIX="+testPrefixDir,
-		"WINEARCH=win32",
-	)
+	cmd := exec.CommandContext(ctx, winePath, "cmd", "/c", "echo", "test")
+	cmd.Env = append(os.Environ(), "WINEPREFIX="+testPrefixDir)
+	if platform.WineArch != "" {
+		cmd.Env = append(cmd.Env, "WINEARCH="+platform.WineArch)
+	}
 
 	output, err := cmd.CombinedOutput()
 	outputStr := string(output)
@@ -345,42 +655,19 @@ func (a *App) CheckWine32Support() (*WineCheckResult, error) {
 
 // scanAndDownloadStarsExe scans all game directories and downloads stars.exe where missing
 func (a *App) scanAndDownloadStarsExe() {
-	serversDir, err := a.config.GetServersDir()
-	if err != nil {
-		logger.App.Warn().Err(err).Msg("Failed to get servers directory for stars.exe scan")
-		return
-	}
-
-	// Get all configured servers to map server names to URLs
 	servers, err := a.config.GetServers()
 	if err != nil {
 		logger.App.Warn().Err(err).Msg("Failed to get servers for stars.exe scan")
 		return
 	}
 
-	// Build a map of sanitized server names to server URLs
-	serverNameToURL := make(map[string]string)
+	// Each server directory may be in its own games directory
 	for _, srv := range servers {
-		sanitizedName := a.config.SanitizeServerName(srv.Name)
-		serverNameToURL[sanitizedName] = srv.URL
-	}
-
-	// Scan the servers directory for server subdirectories
-	serverDirs, err := os.ReadDir(serversDir)
-	if err != nil {
-		logger.App.Warn().Err(err).Msg("Failed to read servers directory for stars.exe scan")
-		return
-	}
-
-	for _, serverDir := range serverDirs {
-		if !serverDir.IsDir() {
-			continue
-		}
-
-		serverName := serverDir.Name()
-		serverURL, ok := serverNameToURL[serverName]
-		if !ok {
-			logger.App.Debug().Str("dir", serverName).Msg("Skipping unknown server directory")
+		serverName := a.config.SanitizeServerName(srv.Name)
+		serverURL := srv.URL
+		serverPath, err := a.config.GetServerDir(srv.Name)
+		if err != nil {
+			logger.App.Warn().Err(err).Str("server", serverName).Msg("Failed to get server directory")
 			continue
 		}
 
@@ -395,8 +682,10 @@ func (a *App) scanAndDownloadStarsExe() {
 		}
 
 		// Scan for session directories that need stars.exe
-		serverPath := filepath.Join(serversDir, serverName)
 		sessionDirs, err := os.ReadDir(serverPath)
+		if os.IsNotExist(err) {
+			continue
+		}
 		if err != nil {
 			logger.App.Warn().Err(err).Str("server", serverName).Msg("Failed to read server directory")
 			continue
@@ -405,7 +694,7 @@ func (a *App) scanAndDownloadStarsExe() {
 		// Collect directories that need stars.exe
 		var dirsNeedingStars []string
 		for _, sessionDir := range sessionDirs {
-			if !sessionDir.IsDir() {
+			if !sessionDir.IsDir() || sessionDir.Name() == astrum.OldSessionsDir {
 				continue
 			}
 
@@ -442,15 +731,18 @@ func (a *App) scanAndDownloadStarsExe() {
 		// Copy to all directories that need it
 		for _, gameDir := range dirsNeedingStars {
 			starsPath := filepath.Join(gameDir, "stars.exe")
-			if err := os.WriteFile(starsPath, data, 0755); err != nil {
+			if err := safefile.WriteFile(starsPath, data, 0755); err != nil {
 				logger.App.Warn().Err(err).Str("path", starsPath).Msg("Failed to write stars.exe")
 				continue
 			}
+			if err := platform.PrepareExecutable(starsPath); err != nil {
+				logger.App.Warn().Err(err).Str("path", starsPath).Msg("Failed to prepare stars.exe")
+			}
 			logger.App.Debug().Str("path", starsPath).Msg("Copied stars.exe")
 
-			// Extract sessionID from directory name and emit event
-			sessionID := filepath.Base(gameDir)
-			runtime.EventsEmit(a.ctx, "starsExe:downloaded", serverURL, sessionID)
+			// Resolve the sessionID from the directory name and emit event
+			sessionID := a.config.SessionIDForDir(serverName, filepath.Base(gameDir))
+			a.emit("starsExe:downloaded", serverURL, sessionID)
 		}
 	}
 
@@ -463,7 +755,10 @@ func (a *App) scanAndDownloadStarsExe() {
 
 // setupSessionGameDir creates the game directory for a session and
 // optionally downloads stars.exe if auto-download is enabled
-func (a *App) setupSessionGameDir(serverURL, serverName, sessionID string) {
+func (a *App) setupSessionGameDir(serverURL, serverName, sessionID, sessionName string) {
+	// Give the directory a readable name before anything is written to it
+	a.assignSessionDirAlias(serverURL, serverName, sessionID, sessionName)
+
 	gameDir, err := a.config.EnsureSessionGameDir(serverName, sessionID)
 	if err != nil {
 		logger.App.Warn().Err(err).Msg("Failed to create game directory")
@@ -475,6 +770,29 @@ func (a *App) setupSessionGameDir(serverURL, serverName, sessionID string) {
 	a.ensureStarsExeInDir(serverURL, sessionID, gameDir)
 }
 
+// assignSessionDirAlias gives a session directory its readable name, moving the
+// hashes of files already tracked in it
+func (a *App) assignSessionDirAlias(serverURL, serverName, sessionID, sessionName string) {
+	oldDir, err := a.config.GetSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return
+	}
+
+	newDir, err := a.config.AssignSessionDirAlias(serverName, sessionID, sessionName)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to assign session directory name")
+		return
+	}
+	if newDir == oldDir {
+		return
+	}
+
+	if err := a.fileHashTracker.MoveSessionDir(serverURL, sessionID, oldDir, newDir); err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to move file hashes to renamed session directory")
+	}
+	logger.App.Info().Str("sessionId", sessionID).Str("path", newDir).Msg("Named session directory")
+}
+
 // ensureStarsExeInDir checks if stars.exe should be downloaded and triggers download if needed
 func (a *App) ensureStarsExeInDir(serverURL, sessionID, gameDir string) {
 	// Check if auto-download is enabled
@@ -519,13 +837,17 @@ func (a *App) downloadStarsExeToDir(serverURL, sessionID, gameDir string) {
 	}
 
 	starsPath := filepath.Join(gameDir, "stars.exe")
-	if err := os.WriteFile(starsPath, data, 0755); err != nil {
+	if err := safefile.WriteFile(starsPath, data, 0755); err != nil {
 		logger.App.Warn().Err(err).Str("path", starsPath).Msg("Failed to save stars.exe")
 		return
 	}
+	if err := platform.PrepareExecutable(starsPath); err != nil {
+		logger.App.Warn().Err(err).Str("path", starsPath).Msg("Failed to prepare stars.exe")
+	}
 
+	a.recordGameFile(gameDir, starsPath, fileOriginServer)
 	logger.App.Info().Str("path", starsPath).Int("size", len(data)).Msg("Downloaded stars.exe")
 
 	// Notify frontend that stars.exe is now available for this session
-	runtime.EventsEmit(a.ctx, "starsExe:downloaded", serverURL, sessionID)
+	a.emit("starsExe:downloaded", serverURL, sessionID)
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/neper-stars/houston/blocks"
+	"github.com/neper-stars/houston/store"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// =============================================================================
+// SHAREABLE SNIPPETS
+// =============================================================================
+
+// FormatShareSnippet turns planet positions or a fleet composition into a short
+// text snippet suited for pasting in chat (Discord markdown)
+func (a *App) FormatShareSnippet(request ShareSnippetRequest) (string, error) {
+	var b strings.Builder
+
+	switch request.Kind {
+	case "planet", "planets":
+		if len(request.Planets) == 0 {
+			return "", fmt.Errorf("no planet to share")
+		}
+		for i, p := range request.Planets {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(formatPlanetSnippet(p))
+		}
+	case "fleet":
+		if request.Fleet == nil {
+			return "", fmt.Errorf("no fleet to share")
+		}
+		b.WriteString(formatFleetSnippet(*request.Fleet))
+	default:
+		return "", fmt.Errorf("unknown snippet kind: %s", request.Kind)
+	}
+
+	if request.Year > 0 {
+		fmt.Fprintf(&b, "\n_(as of %d)_", request.Year)
+	}
+
+	return b.String(), nil
+}
+
+// CopyShareSnippet formats a snippet and copies it to the clipboard, returning the copied text
+func (a *App) CopyShareSnippet(request ShareSnippetRequest) (string, error) {
+	text, err := a.FormatShareSnippet(request)
+	if err != nil {
+		return "", err
+	}
+
+	if err := a.CopyToClipboard(text); err != nil {
+		return "", err
+	}
+
+	return text, nil
+}
+
+// CopyToClipboard copies text to the clipboard, for snippets the frontend
+// formats or edits itself
+func (a *App) CopyToClipboard(text string) error {
+	if err := runtime.ClipboardSetText(a.ctx, text); err != nil {
+		return fmt.Errorf("failed to copy to clipboard: %w", err)
+	}
+	return nil
+}
+
+// CopyPlanetSnippet copies the position of a planet of the local turn file to the clipboard
+func (a *App) CopyPlanetSnippet(serverURL, sessionID, planetName string) (string, error) {
+	gs, err := a.loadSessionGameStore(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	planet, ok := gs.PlanetByName(planetName)
+	if !ok {
+		return "", fmt.Errorf("planet not found: %s", planetName)
+	}
+
+	return a.CopyShareSnippet(ShareSnippetRequest{
+		Kind: "planet",
+		Year: blocks.StarsBaseYear + int(gs.Turn),
+		Planets: []SharePlanetInfo{{
+			Name:  planet.Name,
+			X:     planet.X,
+			Y:     planet.Y,
+			Owner: playerName(gs, planet.Owner),
+		}},
+	})
+}
+
+// CopyFleetSnippet copies the composition of a fleet of the local turn file to the clipboard
+func (a *App) CopyFleetSnippet(serverURL, sessionID string, owner, fleetNumber int) (string, error) {
+	gs, err := a.loadSessionGameStore(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	fleet, ok := gs.Fleet(owner, fleetNumber)
+	if !ok {
+		return "", fmt.Errorf("fleet not found: %d/%d", owner, fleetNumber)
+	}
+
+	info := &ShareFleetInfo{
+		Name:  fleet.Name(),
+		Owner: playerName(gs, fleet.Owner),
+		X:     fleet.X,
+		Y:     fleet.Y,
+	}
+	designs := fleet.GetDesigns(gs)
+	for slot := 0; slot < 16; slot++ {
+		if d, ok := designs[slot]; ok {
+			info.Ships = append(info.Ships, ShareShipInfo{Design: d.Design.Name, Count: d.Count})
+		}
+	}
+
+	return a.CopyShareSnippet(ShareSnippetRequest{
+		Kind:  "fleet",
+		Year:  blocks.StarsBaseYear + int(gs.Turn),
+		Fleet: info,
+	})
+}
+
+// loadSessionGameStore parses the turn files of a session's game directory
+func (a *App) loadSessionGameStore(serverURL, sessionID string) (*store.GameStore, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return loadLocalGameStore(gameDir)
+}
+
+// formatPlanetSnippet formats a planet as "**Name** (x, y) - owner"
+func formatPlanetSnippet(p SharePlanetInfo) string {
+	s := fmt.Sprintf("**%s** (%d, %d)", p.Name, p.X, p.Y)
+	if p.Owner != "" {
+		s += " - " + p.Owner
+	}
+	return s
+}
+
+// formatFleetSnippet formats a fleet as a header line followed by one line per design
+func formatFleetSnippet(f ShareFleetInfo) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "**%s**", f.Name)
+	if f.Owner != "" {
+		fmt.Fprintf(&b, " (%s)", f.Owner)
+	}
+	fmt.Fprintf(&b, " at (%d, %d)", f.X, f.Y)
+	if f.Destination != "" {
+		fmt.Fprintf(&b, " heading to %s", f.Destination)
+	}
+
+	total := 0
+	for _, ship := range f.Ships {
+		fmt.Fprintf(&b, "\n- %dx %s", ship.Count, ship.Design)
+		total += ship.Count
+	}
+	if len(f.Ships) > 1 {
+		fmt.Fprintf(&b, "\n%d ships", total)
+	}
+
+	return b.String()
+}
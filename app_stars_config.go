@@ -0,0 +1,349 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	goruntime "runtime"
+	"sort"
+	"strings"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/starsini"
+)
+
+// =============================================================================
+// STARS! SERIAL KEY
+// =============================================================================
+
+// Stars! reads its registration from stars.ini in the Windows directory. Each
+// server has its own Wine prefix, so each prefix gets the serial of its server.
+const (
+	starsIniName          = "stars.ini"
+	starsIniSerialSection = "Registration"
+	starsIniSerialKey     = "Serial"
+)
+
+// storeSerialKey persists the serial key received on connect and applies it to
+// the server's Wine prefix when Wine is used
+func (a *App) storeSerialKey(serverURL, serial string) {
+	if serial == "" {
+		return
+	}
+
+	creds := a.config.CredentialStore()
+	if stored, err := creds.GetSerialKey(serverURL); err == nil && stored == serial {
+		return
+	}
+
+	if err := creds.SetSerialKey(serverURL, serial); err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to store serial key")
+		return
+	}
+	logger.App.Info().Str("serverUrl", serverURL).Msg("Stored Stars! serial key")
+
+	if err := a.applySerialKey(serverURL); err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to apply serial key")
+	}
+}
+
+// applySerialKey writes the stored serial key into the stars.ini of the server's Wine prefix.
+// Does nothing when Wine is not used: a native Windows install keeps its own registration.
+func (a *App) applySerialKey(serverURL string) error {
+	useWine, err := a.config.GetUseWine()
+	if err != nil || !useWine {
+		return err
+	}
+
+	serial, err := a.config.CredentialStore().GetSerialKey(serverURL)
+	if err != nil || serial == "" {
+		return err
+	}
+
+	path, err := a.starsIniPath(serverURL)
+	if err != nil {
+		return err
+	}
+
+	ini, err := starsini.Load(path)
+	if err != nil {
+		return err
+	}
+	if current, _ := ini.Get(starsIniSerialSection, starsIniSerialKey); current == serial {
+		return nil
+	}
+	ini.Set(starsIniSerialSection, starsIniSerialKey, serial)
+	if err := ini.Save(); err != nil {
+		return err
+	}
+
+	logger.App.Info().Str("path", ini.Path()).Msg("Applied Stars! serial key to wine prefix")
+	return nil
+}
+
+// GetSerialKey returns the Stars! serial key stored for a server, or "" if none
+func (a *App) GetSerialKey(serverURL string) (string, error) {
+	serial, err := a.config.CredentialStore().GetSerialKey(serverURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to get serial key: %w", err)
+	}
+	return serial, nil
+}
+
+// CopySerialKeyToClipboard copies the Stars! serial key of a server to the clipboard
+func (a *App) CopySerialKeyToClipboard(serverURL string) error {
+	serial, err := a.GetSerialKey(serverURL)
+	if err != nil {
+		return err
+	}
+	if serial == "" {
+		return fmt.Errorf("no serial key stored for this server")
+	}
+
+	if err := runtime.ClipboardSetText(a.ctx, serial); err != nil {
+		return fmt.Errorf("failed to copy serial key: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// STARS.INI SETTINGS
+// =============================================================================
+
+// starsIniPath returns the stars.ini used when launching Stars! for a server:
+// in the server's Wine prefix when Wine is used, in the Windows directory otherwise
+func (a *App) starsIniPath(serverURL string) (string, error) {
+	useWine, err := a.config.GetUseWine()
+	if err != nil {
+		return "", fmt.Errorf("failed to get wine setting: %w", err)
+	}
+
+	if !useWine {
+		if goruntime.GOOS != "windows" {
+			return "", fmt.Errorf("wine is required to run Stars! on %s, enable it in Settings", goruntime.GOOS)
+		}
+		windowsDir := os.Getenv("WINDIR")
+		if windowsDir == "" {
+			windowsDir = `C:\Windows`
+		}
+		return filepath.Join(windowsDir, starsIniName), nil
+	}
+
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL
+	if server != nil {
+		serverName = server.Name
+	}
+
+	prefixPath, err := a.ensureServerWinePrefix(serverName)
+	if err != nil {
+		return "", err
+	}
+
+	windowsDir := filepath.Join(prefixPath, "drive_c", "windows")
+	if err := os.MkdirAll(windowsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create windows directory: %w", err)
+	}
+
+	return filepath.Join(windowsDir, starsIniName), nil
+}
+
+// GetStarsIni returns the values of the stars.ini used for a server
+func (a *App) GetStarsIni(serverURL string) ([]StarsIniEntryInfo, error) {
+	path, err := a.starsIniPath(serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	ini, err := starsini.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := ini.Entries()
+	result := make([]StarsIniEntryInfo, len(entries))
+	for i, e := range entries {
+		result[i] = StarsIniEntryInfo{
+			Section: e.Section,
+			Key:     e.Key,
+			Value:   e.Value,
+		}
+	}
+
+	return result, nil
+}
+
+// SetStarsIniValue sets a value in the stars.ini used for a server (an empty value removes the key)
+func (a *App) SetStarsIniValue(serverURL, section, key, value string) error {
+	if section == "" || key == "" {
+		return fmt.Errorf("section and key are required")
+	}
+
+	path, err := a.starsIniPath(serverURL)
+	if err != nil {
+		return err
+	}
+
+	ini, err := starsini.Load(path)
+	if err != nil {
+		return err
+	}
+
+	if value == "" {
+		ini.Delete(section, key)
+	} else {
+		ini.Set(section, key, value)
+	}
+
+	if err := ini.Save(); err != nil {
+		return err
+	}
+
+	logger.App.Info().Str("path", path).Str("section", section).Str("key", key).Msg("Updated stars.ini")
+	return nil
+}
+
+// GetStarsIniDefaults returns the stars.ini values applied when missing before launching Stars!
+func (a *App) GetStarsIniDefaults() ([]StarsIniEntryInfo, error) {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app settings: %w", err)
+	}
+
+	result := make([]StarsIniEntryInfo, len(settings.StarsIniDefaults))
+	for i, d := range settings.StarsIniDefaults {
+		result[i] = StarsIniEntryInfo{
+			Section: d.Section,
+			Key:     d.Key,
+			Value:   d.Value,
+		}
+	}
+
+	return result, nil
+}
+
+// SetStarsIniDefault adds or updates a stars.ini default (an empty value removes it)
+func (a *App) SetStarsIniDefault(section, key, value string) ([]StarsIniEntryInfo, error) {
+	if section == "" || key == "" {
+		return nil, fmt.Errorf("section and key are required")
+	}
+
+	if err := a.config.SetStarsIniDefault(astrum.StarsIniEntry{
+		Section: section,
+		Key:     key,
+		Value:   value,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to set stars.ini default: %w", err)
+	}
+
+	return a.GetStarsIniDefaults()
+}
+
+// applyStarsIniDefaults writes the configured defaults into the stars.ini of a
+// server, leaving values already present (set by Stars! or by the user) untouched
+func (a *App) applyStarsIniDefaults(serverURL string) error {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	if len(settings.StarsIniDefaults) == 0 {
+		return nil
+	}
+
+	path, err := a.starsIniPath(serverURL)
+	if err != nil {
+		return err
+	}
+
+	ini, err := starsini.Load(path)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for _, d := range settings.StarsIniDefaults {
+		if _, ok := ini.Get(d.Section, d.Key); ok {
+			continue
+		}
+		ini.Set(d.Section, d.Key, d.Value)
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	if err := ini.Save(); err != nil {
+		return err
+	}
+
+	logger.App.Info().Str("path", path).Msg("Applied stars.ini defaults")
+	return nil
+}
+
+// =============================================================================
+// LAUNCH ENVIRONMENT
+// =============================================================================
+
+// GetSessionLaunchEnv returns the environment variables set when launching
+// Stars! for a session, by name
+func (a *App) GetSessionLaunchEnv(serverURL, sessionID string) (map[string]string, error) {
+	return a.config.GetSessionLaunchEnv(serverURL, sessionID)
+}
+
+// SetSessionLaunchEnv sets the environment variables set when launching
+// Stars! for a session, e.g. LC_ALL, WINEDLLOVERRIDES or WINEDEBUG to work
+// around Wine quirks of a machine. They override the Astrum environment and
+// the Wine prefix variables. An empty map removes them all.
+func (a *App) SetSessionLaunchEnv(serverURL, sessionID string, env map[string]string) error {
+	cleaned := make(map[string]string, len(env))
+	for name, value := range env {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, "=\x00") || strings.ContainsRune(value, 0) {
+			return fmt.Errorf("invalid environment variable %q", name)
+		}
+		cleaned[name] = value
+	}
+
+	if err := a.config.SetSessionLaunchEnv(serverURL, sessionID, cleaned); err != nil {
+		return err
+	}
+	logger.App.Info().Str("sessionId", sessionID).Int("variables", len(cleaned)).Msg("Set session launch environment")
+	return nil
+}
+
+// applyLaunchEnv adds the launch environment variables of a session to the
+// environment of a Stars! command, overriding the inherited ones. Returns
+// them as NAME=value.
+func (a *App) applyLaunchEnv(cmd *exec.Cmd, serverURL, sessionID string) []string {
+	env, err := a.config.GetSessionLaunchEnv(serverURL, sessionID)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to get session launch environment")
+		return nil
+	}
+	if len(env) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	applied := make([]string, 0, len(names))
+	for _, name := range names {
+		applied = append(applied, name+"="+env[name])
+	}
+
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	// The last value of a duplicated variable is the one used
+	cmd.Env = append(cmd.Env, applied...)
+	logger.App.Debug().Str("sessionId", sessionID).Strs("variables", names).Msg("Applied session launch environment")
+	return applied
+}
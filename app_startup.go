@@ -0,0 +1,67 @@
+package main
+
+import (
+	"time"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// STARTUP TIMING
+// =============================================================================
+
+// processStart is when the process started, as package variables are
+// initialized before main
+var processStart = time.Now()
+
+// tracePhase records a startup phase begun at start and returns now, the
+// start of the next phase. Deferred phases run after the window is shown.
+func (a *App) tracePhase(name string, start time.Time, deferred bool) time.Time {
+	now := time.Now()
+	a.startupMu.Lock()
+	a.startupPhases = append(a.startupPhases, StartupPhaseInfo{
+		Name:       name,
+		StartMs:    start.Sub(processStart).Milliseconds(),
+		DurationMs: now.Sub(start).Milliseconds(),
+		Deferred:   deferred,
+	})
+	a.startupMu.Unlock()
+	return now
+}
+
+// windowShown records when the frontend first loaded
+func (a *App) windowShown() {
+	elapsed := time.Since(processStart)
+	a.startupMu.Lock()
+	a.windowShownMs = elapsed.Milliseconds()
+	a.startupMu.Unlock()
+	logger.App.Info().Dur("elapsed", elapsed).Msg("Window shown")
+}
+
+// deferredStartup does the startup work not needed to show the window: the
+// file hashes and the keyring are only read once the frontend is up
+func (a *App) deferredStartup() {
+	start := time.Now()
+	if err := a.fileHashTracker.Load(); err != nil {
+		logger.App.Error().Err(err).Msg("Failed to load file hashes, files will be rewritten once")
+	}
+	start = a.tracePhase("file hashes", start, true)
+
+	// Reads the local API token from the keyring
+	if settings, err := a.config.GetAppSettings(); err == nil && settings.GetLocalAPIEnabled() {
+		if err := a.startLocalAPI(settings.GetLocalAPIPort()); err != nil {
+			logger.App.Warn().Err(err).Msg("Failed to start local API")
+		}
+		a.tracePhase("local API", start, true)
+	}
+}
+
+// startupTiming returns the startup phases recorded so far
+func (a *App) startupTiming() *StartupTimingInfo {
+	a.startupMu.Lock()
+	defer a.startupMu.Unlock()
+	return &StartupTimingInfo{
+		WindowShownMs: a.windowShownMs,
+		Phases:        append([]StartupPhaseInfo(nil), a.startupPhases...),
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// =============================================================================
+// STATUS FILE FOR DESKTOP WIDGETS
+// =============================================================================
+
+// statusFileName is the name of the status file in the profile directory,
+// unless another path is set
+const statusFileName = "status.json"
+
+// statusFileVersion is the format version of the status file
+const statusFileVersion = 1
+
+// Order states of a session in the status file
+const (
+	statusFileWaiting   = "waiting"   // our orders for the pending year are not uploaded
+	statusFileSubmitted = "submitted" // our orders are uploaded, the turn waits on others
+)
+
+// statusFile is the content of the status file: a small JSON document for
+// Polybar, Rainmeter and the like, rewritten atomically on every order status
+// change so readers never see a partial file
+type statusFile struct {
+	Version   int                 `json:"version"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+	Waiting   int                 `json:"waiting"` // sessions waiting for our orders
+	Sessions  []statusFileSession `json:"sessions"`
+}
+
+// statusFileSession is the order state of a session we play in
+type statusFileSession struct {
+	ServerURL   string `json:"serverUrl"`
+	ServerName  string `json:"serverName"`
+	SessionID   string `json:"sessionId"`
+	SessionName string `json:"sessionName"`
+	Year        int    `json:"year"`
+	State       string `json:"state"`     // statusFileWaiting or statusFileSubmitted
+	Submitted   int    `json:"submitted"` // players whose orders are in, AI players included
+	Players     int    `json:"players"`
+	Stale       bool   `json:"stale,omitempty"` // known from before the server went unreachable
+}
+
+// SetStatusFile enables or disables the status file for desktop widgets and
+// sets its path, "" for status.json in the profile directory. The file is
+// written at once when enabled, and removed when disabled or moved.
+func (a *App) SetStatusFile(enabled bool, path string) (*AppSettingsInfo, error) {
+	previous := a.statusFilePath()
+
+	if err := a.config.SetStatusFile(enabled, path); err != nil {
+		return nil, fmt.Errorf("failed to set status file: %w", err)
+	}
+
+	if previous != "" && (!enabled || previous != a.statusFilePath()) {
+		if err := os.Remove(previous); err != nil && !os.IsNotExist(err) {
+			logger.App.Warn().Err(err).Str("path", previous).Msg("Failed to remove previous status file")
+		}
+	}
+	if enabled {
+		a.writeStatusFile()
+	}
+
+	logger.App.Info().Bool("enabled", enabled).Str("path", path).Msg("Set status file")
+
+	return a.GetAppSettings()
+}
+
+// statusFilePath returns the path of the status file, or "" when it is disabled
+func (a *App) statusFilePath() string {
+	settings, err := a.config.GetAppSettings()
+	if err != nil || !settings.GetStatusFileEnabled() {
+		return ""
+	}
+	if path := settings.GetStatusFilePath(); path != "" {
+		return path
+	}
+	return filepath.Join(astrum.ProfilePath(a.profile), statusFileName)
+}
+
+// writeStatusFile rewrites the status file from the known order statuses, if
+// it is enabled. Failures are only logged, widgets are best effort.
+func (a *App) writeStatusFile() {
+	path := a.statusFilePath()
+	if path == "" {
+		return
+	}
+
+	a.statusFileMu.Lock()
+	defer a.statusFileMu.Unlock()
+
+	status := a.buildStatusFile()
+	data, err := jsoniter.MarshalIndent(status, "", "  ")
+	if err == nil {
+		err = safefile.MkdirAll(filepath.Dir(path), 0755)
+	}
+	if err == nil {
+		err = safefile.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		logger.App.Warn().Err(err).Str("path", path).Msg("Failed to write status file")
+	}
+}
+
+// buildStatusFile summarizes the known order statuses of the sessions we play in
+func (a *App) buildStatusFile() *statusFile {
+	a.mu.RLock()
+	known := make(map[string]*OrdersStatusInfo, len(a.ordersStatus))
+	for key, info := range a.ordersStatus {
+		known[key] = info
+	}
+	a.mu.RUnlock()
+
+	status := &statusFile{
+		Version:   statusFileVersion,
+		UpdatedAt: time.Now().UTC(),
+		Sessions:  []statusFileSession{},
+	}
+	for key, info := range known {
+		serverURL, sessionID, _ := strings.Cut(key, "|")
+		entry, ok := a.statusFileSession(serverURL, sessionID, info)
+		if !ok {
+			continue
+		}
+		if entry.State == statusFileWaiting {
+			status.Waiting++
+		}
+		status.Sessions = append(status.Sessions, entry)
+	}
+	sort.Slice(status.Sessions, func(i, j int) bool {
+		if status.Sessions[i].ServerName != status.Sessions[j].ServerName {
+			return status.Sessions[i].ServerName < status.Sessions[j].ServerName
+		}
+		return status.Sessions[i].SessionName < status.Sessions[j].SessionName
+	})
+	return status
+}
+
+// statusFileSession describes the order state of a session, and tells whether
+// we play in it
+func (a *App) statusFileSession(serverURL, sessionID string, info *OrdersStatusInfo) (statusFileSession, bool) {
+	entry := statusFileSession{
+		ServerURL: serverURL,
+		SessionID: sessionID,
+		Year:      info.PendingYear,
+		Players:   len(info.Players),
+		Stale:     info.Stale,
+	}
+
+	session, playerOrder, ok := a.ownPlayerOrder(serverURL, sessionID)
+	if !ok {
+		return entry, false
+	}
+	entry.SessionName = session.Name
+	if server, err := a.config.GetServer(serverURL); err == nil && server != nil {
+		entry.ServerName = server.Name
+	}
+
+	entry.State = statusFileWaiting
+	for _, p := range info.Players {
+		if !p.Submitted {
+			continue
+		}
+		entry.Submitted++
+		if p.PlayerOrder == playerOrder {
+			entry.State = statusFileSubmitted
+		}
+	}
+	return entry, true
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gen2brain/beeep"
+
+	"github.com/neper-stars/astrum/lib/hooks"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/telemetry"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// SUBMISSION CONFIRMATIONS
+// =============================================================================
+
+// submissionDigestInterval is the delay between two submission digests
+const submissionDigestInterval = 7 * 24 * time.Hour
+
+// submissionLogRetention is how long uploads are kept in the submission log
+const submissionLogRetention = 90 * 24 * time.Hour
+
+// onOrderUploaded runs everything following a successful order upload: the
+// order-uploaded hook, the submission log and the optional confirmation
+func (a *App) onOrderUploaded(serverURL, sessionID string, year int) {
+	a.telemetry.Count(telemetry.FeatureOrdersUpload)
+	a.fireOrderUploadedHook(serverURL, sessionID, year)
+
+	record := model.SubmissionRecord{
+		ServerURL:  serverURL,
+		SessionID:  sessionID,
+		Year:       year,
+		UploadedAt: time.Now(),
+	}
+
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	a.mu.RUnlock()
+	if ok && mgrOk {
+		if session, err := client.GetSession(mgr.GetContext(), sessionID); err == nil {
+			record.SessionName = session.Name
+		}
+	}
+
+	log, err := a.config.GetSubmissionLog()
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to load submission log")
+	} else {
+		log.Records = append(log.Records, record)
+		log.Prune(record.UploadedAt, submissionLogRetention)
+		if err := a.config.SetSubmissionLog(log); err != nil {
+			logger.App.Warn().Err(err).Msg("Failed to save submission log")
+		}
+	}
+	go a.writeSessionFeed(serverURL, sessionID)
+
+	settings, err := a.config.GetAppSettings()
+	if err != nil || !settings.GetSubmissionConfirmation() {
+		return
+	}
+
+	title := "Orders Submitted"
+	message := fmt.Sprintf("%s: orders for %d uploaded at %s", submissionName(record), year, record.UploadedAt.Format("15:04"))
+	if err := beeep.Notify(title, message, a.notificationIcon); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to show desktop notification")
+	}
+}
+
+// GetSubmissionLog returns the order uploads of the last 90 days, newest first
+func (a *App) GetSubmissionLog() ([]SubmissionRecordInfo, error) {
+	log, err := a.config.GetSubmissionLog()
+	if err != nil {
+		return nil, err
+	}
+
+	records := log.Records
+	sort.Slice(records, func(i, j int) bool { return records[i].UploadedAt.After(records[j].UploadedAt) })
+
+	result := make([]SubmissionRecordInfo, 0, len(records))
+	for _, r := range records {
+		result = append(result, SubmissionRecordInfo{
+			ServerURL:   r.ServerURL,
+			SessionID:   r.SessionID,
+			SessionName: r.SessionName,
+			Year:        r.Year,
+			UploadedAt:  r.UploadedAt.Format(time.RFC3339),
+		})
+	}
+	return result, nil
+}
+
+// runSubmissionDigest sends the weekly summary of uploaded orders as a desktop
+// notification and through the submission-digest hook, when enabled
+func (a *App) runSubmissionDigest() {
+	settings, err := a.config.GetAppSettings()
+	if err != nil || !settings.GetSubmissionDigest() {
+		return
+	}
+
+	log, err := a.config.GetSubmissionLog()
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to load submission log")
+		return
+	}
+
+	now := time.Now()
+	if log.LastDigest.IsZero() {
+		// First run: start counting the week from now
+		log.LastDigest = now
+	} else if now.Sub(log.LastDigest) < submissionDigestInterval {
+		return
+	} else {
+		var week []model.SubmissionRecord
+		for _, r := range log.Records {
+			if r.UploadedAt.After(log.LastDigest) {
+				week = append(week, r)
+			}
+		}
+		if len(week) > 0 {
+			a.sendSubmissionDigest(week)
+		}
+		log.LastDigest = now
+	}
+
+	if err := a.config.SetSubmissionLog(log); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to save submission log")
+	}
+}
+
+// sendSubmissionDigest notifies the uploads of the past week
+func (a *App) sendSubmissionDigest(records []model.SubmissionRecord) {
+	// Years uploaded per session, in upload order
+	var names []string
+	years := make(map[string][]string)
+	for _, r := range records {
+		name := submissionName(r)
+		if _, ok := years[name]; !ok {
+			names = append(names, name)
+		}
+		years[name] = append(years[name], strconv.Itoa(r.Year))
+	}
+
+	lines := make([]string, len(names))
+	for i, name := range names {
+		lines[i] = fmt.Sprintf("%s: %s", name, strings.Join(years[name], ", "))
+	}
+	digest := strings.Join(lines, "\n")
+
+	title := "Weekly Orders Digest"
+	message := fmt.Sprintf("%d orders uploaded this week\n%s", len(records), digest)
+	if err := beeep.Notify(title, message, a.notificationIcon); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to show desktop notification")
+	}
+
+	a.fireHook(hooks.EventSubmissionDigest, "", "", map[string]string{
+		"ASTRUM_SUBMISSIONS": strconv.Itoa(len(records)),
+		"ASTRUM_DIGEST":      digest,
+	})
+
+	logger.App.Info().Int("submissions", len(records)).Msg("Sent submission digest")
+}
+
+// submissionName is the session name of a record, or its ID when unknown
+func submissionName(r model.SubmissionRecord) string {
+	if r.SessionName != "" {
+		return r.SessionName
+	}
+	return r.SessionID
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// NOTIFICATION SUBSCRIPTIONS
+// =============================================================================
+
+// syncSessionSubscriptions limits the per-session notifications of a server to
+// the sessions we are a member or manager of
+func (a *App) syncSessionSubscriptions(serverURL string) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	notifMgr, notifOk := a.notificationManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk || !notifOk {
+		return
+	}
+
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return
+	}
+
+	sessions, err := client.ListSessions(mgr.GetContext())
+	if err != nil {
+		// Keep receiving everything rather than missing our own sessions
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to list sessions for notification subscriptions")
+		return
+	}
+
+	var ids []string
+	for _, session := range sessions {
+		if isSessionMember(&session, userInfo.User.ID) {
+			ids = append(ids, session.ID)
+		}
+	}
+	notifMgr.SetSessionSubscriptions(ids)
+
+	logger.App.Debug().
+		Str("serverUrl", serverURL).
+		Int("sessions", len(ids)).
+		Msg("Updated notification subscriptions")
+}
+
+// setSessionSubscribed subscribes to or unsubscribes from the notifications of a session
+func (a *App) setSessionSubscribed(serverURL, sessionID string, subscribed bool) {
+	a.mu.RLock()
+	notifMgr, ok := a.notificationManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok {
+		return
+	}
+	if subscribed {
+		notifMgr.Subscribe(sessionID)
+	} else {
+		notifMgr.Unsubscribe(sessionID)
+	}
+}
+
+// isSessionMember returns whether a user is a member or manager of a session
+func isSessionMember(session *api.Session, userID string) bool {
+	return containsString(session.Members, userID) || containsString(session.Managers, userID)
+}
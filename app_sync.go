@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/neper-stars/houston/blocks"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// MULTI-MACHINE SYNC
+// =============================================================================
+
+// CheckSessionSync tells whether the local copy of a session is behind the
+// server, e.g. when the same game is also played from another computer: the
+// server has a newer turn, or the orders of the current year were submitted
+// from elsewhere. Servers storing sync tokens tell which order file they
+// acknowledged; others only tell whether orders were submitted at all.
+func (a *App) CheckSessionSync(serverURL, sessionID string) (*SessionSyncInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := a.fetchOrdersStatus(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	info := &SessionSyncInfo{ServerYear: status.PendingYear}
+
+	if gs, err := loadLocalGameStore(gameDir); err == nil {
+		info.LocalYear = blocks.StarsBaseYear + int(gs.Turn)
+	}
+	info.TurnStale = info.LocalYear < info.ServerYear
+
+	player, err := localPlayerNumber(gameDir)
+	if err != nil {
+		// No local turn at all: everything is to be pulled
+		return info, nil
+	}
+	for _, p := range status.Players {
+		if p.PlayerOrder == player {
+			info.OrdersSubmitted = p.Submitted
+		}
+	}
+
+	localHash := a.fileHashTracker.GetHash(serverURL, sessionID, fmt.Sprintf("order:%d", info.ServerYear))
+	token, err := client.GetSyncToken(mgr.GetContext(), sessionID)
+	switch {
+	case errors.Is(err, api.ErrNotSupported):
+		// Submitted orders this machine did not upload came from elsewhere
+		info.OrdersElsewhere = info.OrdersSubmitted && localHash == ""
+	case err != nil:
+		return nil, fmt.Errorf("failed to get sync token: %w", err)
+	default:
+		info.Supported = true
+		if token != nil && token.Year == info.ServerYear && token.OrderHash != localHash {
+			info.OrdersElsewhere = true
+			info.SubmittedFrom = token.Machine
+			info.SubmittedAt = &token.UpdatedAt
+		}
+	}
+	info.Stale = info.TurnStale || info.OrdersElsewhere
+	return info, nil
+}
+
+// syncBeforeOrders pulls the latest turn of a session when the local one is
+// stale, before the user plays it. When the orders of the year were submitted
+// from another machine, "session:sync" (serverURL, sessionID, sync) is emitted
+// so the user knows new orders would replace them. Only a failed pull is an
+// error, the game can still be played when the check itself fails.
+func (a *App) syncBeforeOrders(serverURL, sessionID string) error {
+	info, err := a.CheckSessionSync(serverURL, sessionID)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to check session sync")
+		return nil
+	}
+
+	if info.TurnStale {
+		logger.App.Info().
+			Str("sessionId", sessionID).
+			Int("localYear", info.LocalYear).
+			Int("serverYear", info.ServerYear).
+			Msg("Local turn is stale, pulling the latest one")
+		if _, err := a.GetLatestTurn(serverURL, sessionID); err != nil {
+			return err
+		}
+	}
+
+	if info.OrdersElsewhere {
+		logger.App.Warn().
+			Str("sessionId", sessionID).
+			Int("year", info.ServerYear).
+			Str("machine", info.SubmittedFrom).
+			Msg("Orders of the year were submitted from another machine")
+
+		a.mu.RLock()
+		shuttingDown := a.shuttingDown
+		a.mu.RUnlock()
+		if !shuttingDown {
+			a.emit("session:sync", serverURL, sessionID, info)
+		}
+	}
+	return nil
+}
+
+// publishSyncToken tells the server which order file it acknowledged, for the
+// other machines of the user. Servers without sync tokens are ignored.
+func (a *App) publishSyncToken(serverURL, sessionID string, year int, orderHash string) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return
+	}
+
+	machine, _ := os.Hostname()
+	token := &api.SyncToken{
+		Year:      year,
+		OrderHash: orderHash,
+		Machine:   machine,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := client.SetSyncToken(mgr.GetContext(), sessionID, token); err != nil {
+		if errors.Is(err, api.ErrNotSupported) {
+			logger.App.Debug().Str("serverUrl", serverURL).Msg("Server has no sync tokens")
+			return
+		}
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Int("year", year).Msg("Failed to publish sync token")
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/neper-stars/houston/data"
+)
+
+// =============================================================================
+// TECH TREE
+// =============================================================================
+
+// GetTechTree returns every component and hull known to Houston with its cost,
+// tech requirements and stats. Houston has no cost or requirements for hulls,
+// those are left at zero. When a session is given, the player's current
+// tech levels are read from the local turn file and each component is flagged
+// as available or not, hulls are left unflagged.
+func (a *App) GetTechTree(serverURL, sessionID string) (*TechTreeInfo, error) {
+	type source struct {
+		category data.ItemCategory
+		item     any
+	}
+	var sources []source
+	collect := func(category data.ItemCategory, item any) {
+		sources = append(sources, source{category, item})
+	}
+	for _, item := range data.Scanners {
+		collect(data.CategoryScanner, item)
+	}
+	for _, item := range data.PlanetaryScanners {
+		collect(data.CategoryPlanetary, item)
+	}
+	for _, item := range data.PlanetaryDefenses {
+		collect(data.CategoryPlanetary, item)
+	}
+	for _, item := range data.Engines {
+		collect(data.CategoryEngine, item)
+	}
+	for _, item := range data.Shields {
+		collect(data.CategoryShield, item)
+	}
+	for _, item := range data.Armors {
+		collect(data.CategoryArmor, item)
+	}
+	for _, item := range data.BeamWeapons {
+		collect(data.CategoryBeamWeapon, item)
+	}
+	for _, item := range data.Torpedoes {
+		collect(data.CategoryTorpedo, item)
+	}
+	for _, item := range data.Electricals {
+		collect(data.CategoryElectrical, item)
+	}
+	for _, item := range data.Mechanicals {
+		collect(data.CategoryMechanical, item)
+	}
+	for _, item := range data.MineLayers {
+		collect(data.CategoryMineLayer, item)
+	}
+	for _, item := range data.MiningRobots {
+		collect(data.CategoryMiningRobo, item)
+	}
+	for _, item := range data.Bombs {
+		collect(data.CategoryBomb, item)
+	}
+	for _, item := range data.Orbitals {
+		collect(data.CategoryOrbital, item)
+	}
+	for _, item := range data.Terraformers {
+		collect(data.CategoryTerraform, item)
+	}
+	for _, hull := range data.Hulls {
+		if hull.IsStarbase {
+			collect(data.CategoryStarbase, hull)
+		} else {
+			collect(data.CategoryShipHull, hull)
+		}
+	}
+
+	items := make([]TechItemInfo, 0, len(sources))
+	for _, src := range sources {
+		info, err := techItemInfo(src.category, src.item)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build tech tree: %w", err)
+		}
+		items = append(items, info)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Category != items[j].Category {
+			return items[i].Category < items[j].Category
+		}
+		return items[i].ID < items[j].ID
+	})
+
+	tree := &TechTreeInfo{Items: items}
+	if sessionID == "" {
+		return tree, nil
+	}
+
+	gs, err := a.loadSessionGameStore(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	player, ok := gs.Player(turnOwner(gs))
+	if !ok {
+		return nil, fmt.Errorf("no player data in the turn file")
+	}
+	levels := data.TechRequirements{
+		Energy:       player.Tech.Energy,
+		Weapons:      player.Tech.Weapons,
+		Propulsion:   player.Tech.Propulsion,
+		Construction: player.Tech.Construction,
+		Electronics:  player.Tech.Electronics,
+		Biotech:      player.Tech.Biotech,
+	}
+	levelsInfo := techLevelsInfo(levels)
+	tree.Levels = &levelsInfo
+	hullCategories := []string{data.CategoryNames[data.CategoryShipHull], data.CategoryNames[data.CategoryStarbase]}
+	for i := range tree.Items {
+		if containsString(hullCategories, tree.Items[i].Category) {
+			continue
+		}
+		available := techRequirements(tree.Items[i].Requirements).CanBuildWith(levels)
+		tree.Items[i].Available = &available
+	}
+	return tree, nil
+}
+
+// techItemInfo flattens one of Houston's item structs. The fields shared by
+// all items are lifted out, whatever is left is the item's stats.
+func techItemInfo(category data.ItemCategory, item any) (TechItemInfo, error) {
+	raw, err := json.Marshal(item)
+	if err != nil {
+		return TechItemInfo{}, err
+	}
+
+	var common struct {
+		ID   int
+		Name string
+		Mass int
+		Tech data.TechRequirements
+		Cost data.Cost
+	}
+	if err := json.Unmarshal(raw, &common); err != nil {
+		return TechItemInfo{}, err
+	}
+	stats := map[string]any{}
+	if err := json.Unmarshal(raw, &stats); err != nil {
+		return TechItemInfo{}, err
+	}
+	for _, key := range []string{"ID", "Name", "Mass", "Tech", "Cost"} {
+		delete(stats, key)
+	}
+
+	return TechItemInfo{
+		Category:     data.CategoryNames[category],
+		ID:           common.ID,
+		Name:         common.Name,
+		Mass:         common.Mass,
+		Requirements: techLevelsInfo(common.Tech),
+		Cost: TechCostInfo{
+			Resources: common.Cost.Resources,
+			Ironium:   common.Cost.Ironium,
+			Boranium:  common.Cost.Boranium,
+			Germanium: common.Cost.Germanium,
+		},
+		Stats: stats,
+	}, nil
+}
+
+func techLevelsInfo(t data.TechRequirements) TechLevelsInfo {
+	return TechLevelsInfo{
+		Energy:       t.Energy,
+		Weapons:      t.Weapons,
+		Propulsion:   t.Propulsion,
+		Construction: t.Construction,
+		Electronics:  t.Electronics,
+		Biotech:      t.Biotech,
+	}
+}
+
+func techRequirements(t TechLevelsInfo) data.TechRequirements {
+	return data.TechRequirements{
+		Energy:       t.Energy,
+		Weapons:      t.Weapons,
+		Propulsion:   t.Propulsion,
+		Construction: t.Construction,
+		Electronics:  t.Electronics,
+		Biotech:      t.Biotech,
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/telemetry"
+)
+
+// =============================================================================
+// OPT-IN TELEMETRY
+// =============================================================================
+
+// telemetryInterval is the minimum delay between two usage reports
+const telemetryInterval = 24 * time.Hour
+
+// GetTelemetryPreview returns the telemetry settings and exactly the report
+// that would be sent now, so it can be reviewed before enabling telemetry
+func (a *App) GetTelemetryPreview() (*TelemetryPreviewInfo, error) {
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	report := a.telemetry.Report(settings.GetTelemetryID())
+	payload, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal telemetry report: %w", err)
+	}
+
+	info := &TelemetryPreviewInfo{
+		Enabled:  settings.GetTelemetryEnabled(),
+		Endpoint: settings.GetTelemetryEndpoint(),
+		Payload:  string(payload),
+	}
+	if lastSent := a.telemetry.LastSent(); !lastSent.IsZero() {
+		info.LastSent = lastSent.Format(time.RFC3339)
+	}
+	return info, nil
+}
+
+// SetTelemetry enables or disables the usage reports sent to endpoint
+func (a *App) SetTelemetry(enabled bool, endpoint string) (*TelemetryPreviewInfo, error) {
+	if err := a.config.SetTelemetry(enabled, endpoint); err != nil {
+		return nil, err
+	}
+
+	logger.App.Info().Bool("enabled", enabled).Str("endpoint", endpoint).Msg("Set telemetry")
+	return a.GetTelemetryPreview()
+}
+
+// sendTelemetryIfDue sends the usage report when telemetry is enabled and
+// the last one is older than telemetryInterval
+func (a *App) sendTelemetryIfDue() {
+	settings, err := a.config.GetAppSettings()
+	if err != nil || !settings.GetTelemetryEnabled() || settings.GetTelemetryEndpoint() == "" {
+		return
+	}
+	if time.Since(a.telemetry.LastSent()) < telemetryInterval {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report := a.telemetry.Report(settings.GetTelemetryID())
+	if err := telemetry.Send(ctx, settings.GetTelemetryEndpoint(), report); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to send telemetry report")
+		return
+	}
+	a.telemetry.Sent(report, time.Now())
+	a.saveTelemetryState()
+
+	logger.App.Debug().Msg("Sent telemetry report")
+}
+
+// restoreTelemetryState loads the usage counts not sent yet
+func (a *App) restoreTelemetryState() {
+	state, err := a.config.GetTelemetryState()
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to load telemetry state")
+		return
+	}
+	a.telemetry.Restore(*state)
+}
+
+// saveTelemetryState persists the usage counts not sent yet
+func (a *App) saveTelemetryState() {
+	if err := a.config.SetTelemetryState(a.telemetry.State()); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to save telemetry state")
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// SESSION TIMELINE
+// =============================================================================
+
+// GetSessionTimeline returns the events of a session in chronological order:
+// generated turns, order submissions of the other players, player arrivals
+// and departures and rule changes seen in notifications, plus my order
+// uploads and backups. Events are recorded from
+// the moment Astrum sees them, earlier history is not reconstructed.
+func (a *App) GetSessionTimeline(serverURL, sessionID string) ([]TimelineEventInfo, error) {
+	timeline, err := a.config.GetSessionTimeline(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]TimelineEventInfo, 0, len(timeline.Events))
+	for _, e := range timeline.Events {
+		result = append(result, timelineEventInfo(e))
+	}
+
+	// My uploads are already kept in the submission log
+	log, err := a.config.GetSubmissionLog()
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range log.Records {
+		if r.ServerURL != serverURL || r.SessionID != sessionID {
+			continue
+		}
+		result = append(result, timelineEventInfo(model.TimelineEvent{
+			Type: model.TimelineOrderSent,
+			Time: r.UploadedAt,
+			Year: r.Year,
+		}))
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Time < result[j].Time })
+	return result, nil
+}
+
+// recordTimelineEvent adds an event to the timeline of a session
+func (a *App) recordTimelineEvent(serverURL, sessionID string, e model.TimelineEvent) {
+	a.timelineMu.Lock()
+	defer a.timelineMu.Unlock()
+
+	timeline, err := a.config.GetSessionTimeline(serverURL, sessionID)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to load session timeline")
+		return
+	}
+	timeline.Add(e)
+	if err := a.config.SetSessionTimeline(serverURL, sessionID, timeline); err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to save session timeline")
+		return
+	}
+	go a.writeSessionFeed(serverURL, sessionID)
+}
+
+// trackSessionMembers records the players who joined or left a session since
+// its last update. The first call only takes a snapshot of the members.
+func (a *App) trackSessionMembers(serverURL, sessionID string, at time.Time) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk {
+		return
+	}
+
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil {
+		return
+	}
+
+	// Follow membership changes made by others (added or removed by a host)
+	if userInfo := mgr.GetUserInfo(); userInfo != nil {
+		a.setSessionSubscribed(serverURL, sessionID, isSessionMember(session, userInfo.User.ID))
+	}
+
+	current := make(map[string]bool)
+	for _, id := range append(append([]string{}, session.Members...), session.Managers...) {
+		current[id] = true
+	}
+
+	a.timelineMu.Lock()
+	defer a.timelineMu.Unlock()
+
+	timeline, err := a.config.GetSessionTimeline(serverURL, sessionID)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to load session timeline")
+		return
+	}
+
+	if timeline.Members != nil {
+		previous := make(map[string]bool, len(timeline.Members))
+		for _, id := range timeline.Members {
+			previous[id] = true
+		}
+
+		nicknames := make(map[string]string)
+		if profiles, err := a.getCachedUserProfiles(serverURL, client, mgr); err == nil {
+			for _, p := range profiles {
+				nicknames[p.ID] = p.Nickname
+			}
+		}
+		name := func(id string) string {
+			if n, ok := nicknames[id]; ok {
+				return n
+			}
+			return id
+		}
+
+		for id := range current {
+			if !previous[id] {
+				timeline.Add(model.TimelineEvent{Type: model.TimelinePlayerJoined, Time: at, Detail: name(id)})
+			}
+		}
+		for id := range previous {
+			if !current[id] {
+				timeline.Add(model.TimelineEvent{Type: model.TimelinePlayerLeft, Time: at, Detail: name(id)})
+			}
+		}
+	}
+
+	timeline.Members = make([]string, 0, len(current))
+	for id := range current {
+		timeline.Members = append(timeline.Members, id)
+	}
+	sort.Strings(timeline.Members)
+
+	if err := a.config.SetSessionTimeline(serverURL, sessionID, timeline); err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to save session timeline")
+	}
+}
+
+// notificationTime returns when a notification happened, now if it has no timestamp
+func notificationTime(timestamp *int64) time.Time {
+	if timestamp == nil || *timestamp == 0 {
+		return time.Now()
+	}
+	return time.Unix(*timestamp, 0)
+}
+
+// notificationYear returns the year carried by a notification's metadata, 0 if none
+func notificationYear(metadata interface{}) int {
+	if metaMap, ok := metadata.(map[string]interface{}); ok {
+		switch v := metaMap["year"].(type) {
+		case float64:
+			return int(v)
+		case int:
+			return v
+		}
+	}
+	return 0
+}
+
+// timelineEventInfo converts a timeline event for the frontend
+func timelineEventInfo(e model.TimelineEvent) TimelineEventInfo {
+	info := TimelineEventInfo{
+		Type:   e.Type,
+		Time:   e.Time.UTC().Format(time.RFC3339),
+		Year:   e.Year,
+		Detail: e.Detail,
+	}
+	switch e.Type {
+	case model.TimelineTurnGenerated:
+		info.Title = fmt.Sprintf("Turn %d generated", e.Year)
+	case model.TimelineOrderSent:
+		info.Title = fmt.Sprintf("Orders for %d submitted", e.Year)
+	case model.TimelinePlayerOrders:
+		info.Title = fmt.Sprintf("%s submitted orders for %d", e.Detail, e.Year)
+	case model.TimelinePlayerJoined:
+		info.Title = fmt.Sprintf("%s joined", e.Detail)
+	case model.TimelinePlayerLeft:
+		info.Title = fmt.Sprintf("%s left", e.Detail)
+	case model.TimelineRulesChanged:
+		info.Title = "Rules changed"
+	case model.TimelineBackup:
+		info.Title = "Backup downloaded"
+	case model.TimelineHostChanged:
+		info.Title = fmt.Sprintf("Session handed over to %s", e.Detail)
+	default:
+		info.Title = e.Type
+	}
+	return info
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/trash"
+)
+
+// =============================================================================
+// TRASH
+// =============================================================================
+
+// RestoreFromTrash moves a directory or file Astrum deleted back from the
+// trash to its path, e.g. the game directory of a removed server. Only
+// supported with the XDG trash of Linux desktops.
+func (a *App) RestoreFromTrash(path string) error {
+	if err := trash.Restore(path); err != nil {
+		if errors.Is(err, trash.ErrNotFound) {
+			return fmt.Errorf("%s is not in the trash", path)
+		}
+		return fmt.Errorf("failed to restore from the trash: %w", err)
+	}
+
+	logger.App.Info().Str("path", path).Msg("Restored from the trash")
+	return nil
+}
+
+// deleteStep describes the deletion of what in a removal plan, which goes to
+// the trash unless hard deletes are set
+func (a *App) deleteStep(what string) string {
+	if settings, err := a.config.GetAppSettings(); err == nil && settings.GetHardDelete() {
+		return "Delete " + what
+	}
+	return "Move " + what + " to the trash"
+}
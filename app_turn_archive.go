@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// =============================================================================
+// TURN ARCHIVE
+// =============================================================================
+
+// historicYearsDirName is the folder of the game directory where past years
+// are staged for review, one subdirectory per year
+const historicYearsDirName = "history"
+
+// OpenHistoricYear launches Stars! on a past year of a session to review it.
+// The universe and turn files of that year are staged in history/<year> of
+// the game directory, so the current game files are left untouched and orders
+// saved while reviewing are neither watched nor uploaded.
+func (a *App) OpenHistoricYear(serverURL, sessionID string, year int) error {
+	staged, err := a.stageHistoricYear(serverURL, sessionID, year)
+	if err != nil {
+		return err
+	}
+	starsExePath := monitor.ResolveFile(staged.gameDir, "stars.exe")
+	if _, err := os.Stat(starsExePath); os.IsNotExist(err) {
+		return fmt.Errorf("stars.exe not found in game directory")
+	}
+
+	return a.startStars(serverURL, staged.serverName, sessionID, staged.dir, starsExePath, staged.turnFile)
+}
+
+// FetchHistoricYear downloads a past year of a session again into
+// history/<year> of the game directory, e.g. after it was pruned, without
+// opening it. Returns the year directory.
+func (a *App) FetchHistoricYear(serverURL, sessionID string, year int) (string, error) {
+	staged, err := a.stageHistoricYear(serverURL, sessionID, year)
+	if err != nil {
+		return "", err
+	}
+	return staged.dir, nil
+}
+
+// GetHistoricYears returns the past years of a session staged in its game
+// directory, oldest first
+func (a *App) GetHistoricYears(serverURL, sessionID string) ([]int, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return historicYears(gameDir), nil
+}
+
+// stagedYear is a past year staged for review
+type stagedYear struct {
+	serverName string
+	gameDir    string
+	dir        string // history/<year> of the game directory
+	turnFile   string // turn file of the player in dir
+}
+
+// stageHistoricYear downloads the universe and turn files of a past year of a
+// session into history/<year> of the game directory, then prunes the years
+// past the history retention
+func (a *App) stageHistoricYear(serverURL, sessionID string, year int) (*stagedYear, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return nil, fmt.Errorf("no user info available")
+	}
+
+	session, err := client.GetSession(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	playerNumber := findPlayerNumber(session, userInfo.User.ID)
+	if playerNumber == 0 {
+		return nil, fmt.Errorf("you are not a player in this session")
+	}
+
+	turn, err := a.GetTurn(serverURL, sessionID, year, false)
+	if err != nil {
+		return nil, err
+	}
+	if turn.Turn == "" {
+		return nil, fmt.Errorf("no turn file for year %d", year)
+	}
+
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL
+	if server != nil {
+		serverName = server.Name
+	}
+	gameDir, err := a.config.EnsureSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	// Start from a clean directory, left over orders of a previous review
+	// would be loaded along with the turn
+	yearDir := filepath.Join(gameDir, historicYearsDirName, strconv.Itoa(year))
+	if err := os.RemoveAll(yearDir); err != nil {
+		return nil, fmt.Errorf("failed to clear year directory: %w", err)
+	}
+	if err := safefile.MkdirAll(yearDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create year directory: %w", err)
+	}
+
+	turnFileName := fmt.Sprintf("game.m%d", playerNumber)
+	files := map[string]string{"game.xy": turn.Universe, turnFileName: turn.Turn}
+	for name, b64 := range files {
+		if b64 == "" {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(b64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		if err := safefile.WriteFile(filepath.Join(yearDir, name), data, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int("year", year).Str("dir", yearDir).Msg("Staged historic year")
+
+	if settings, err := a.config.GetAppSettings(); err == nil {
+		a.pruneHistoricYears(gameDir, settings.GetHistoryRetentionYears(), year)
+	}
+	return &stagedYear{serverName: serverName, gameDir: gameDir, dir: yearDir, turnFile: turnFileName}, nil
+}
+
+// historicYears returns the years staged in history/ of a game directory,
+// oldest first
+func historicYears(gameDir string) []int {
+	entries, _ := os.ReadDir(filepath.Join(gameDir, historicYearsDirName))
+	var years []int
+	for _, entry := range entries {
+		if year, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			years = append(years, year)
+		}
+	}
+	sort.Ints(years)
+	return years
+}
+
+// pruneHistoricYears removes the oldest staged years of a game directory past
+// the keep most recent ones, 0 keeping them all. The year being reviewed is
+// kept whatever its age. They can be fetched again from the server, and go
+// to the trash unless hard deletes are set.
+func (a *App) pruneHistoricYears(gameDir string, keep, reviewed int) {
+	if keep <= 0 {
+		return
+	}
+	years := historicYears(gameDir)
+	for i := 0; i < len(years)-keep; i++ {
+		if years[i] == reviewed {
+			continue
+		}
+		if err := a.config.DeletePath(filepath.Join(gameDir, historicYearsDirName, strconv.Itoa(years[i]))); err != nil {
+			logger.App.Warn().Err(err).Str("gameDir", gameDir).Int("year", years[i]).Msg("Failed to prune historic year")
+			continue
+		}
+		logger.App.Debug().Str("gameDir", gameDir).Int("year", years[i]).Msg("Pruned historic year")
+	}
+}
+
+// pruneAllHistoricYears applies the history retention to the sessions of all
+// servers. Local games are left alone, their history cannot be fetched again.
+func (a *App) pruneAllHistoricYears() {
+	settings, err := a.config.GetAppSettings()
+	if err != nil || settings.GetHistoryRetentionYears() <= 0 {
+		return
+	}
+	servers, err := a.config.GetServers()
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to get servers to prune historic years")
+		return
+	}
+
+	for _, server := range servers {
+		sessionIDs, err := a.config.ListSessionDirs(server.Name)
+		if err != nil {
+			continue
+		}
+		for _, sessionID := range sessionIDs {
+			gameDir, err := a.config.GetSessionGameDir(server.Name, sessionID)
+			if err != nil {
+				continue
+			}
+			a.pruneHistoricYears(gameDir, settings.GetHistoryRetentionYears(), 0)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/neper-stars/astrum/api/models"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// TURN CYCLE
+// =============================================================================
+
+// GetNextActionableSession returns the next started session, across all
+// connected servers, where the user has not submitted orders for the current
+// year yet. Sessions are taken in server then name order, starting after the
+// one AdvanceTurnCycle last opened, so repeated calls go round the queue.
+// It returns nil when no session awaits orders.
+func (a *App) GetNextActionableSession() (*ActionableSessionInfo, error) {
+	candidates := a.actionableSessions()
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	a.mu.RLock()
+	last := a.turnCycleLast
+	a.mu.RUnlock()
+
+	for i, c := range candidates {
+		if c.ServerURL+"|"+c.SessionID == last {
+			next := candidates[(i+1)%len(candidates)]
+			return &next, nil
+		}
+	}
+	return &candidates[0], nil
+}
+
+// AdvanceTurnCycle downloads the latest turn of the next session awaiting
+// orders and launches Stars! on it. It returns the session it opened, or nil
+// when no session awaits orders.
+func (a *App) AdvanceTurnCycle() (*ActionableSessionInfo, error) {
+	next, err := a.GetNextActionableSession()
+	if err != nil || next == nil {
+		return nil, err
+	}
+
+	if _, err := a.GetLatestTurn(next.ServerURL, next.SessionID); err != nil {
+		return nil, err
+	}
+	if err := a.LaunchStars(next.ServerURL, next.SessionID); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.turnCycleLast = next.ServerURL + "|" + next.SessionID
+	a.mu.Unlock()
+
+	logger.App.Info().Str("serverUrl", next.ServerURL).Str("sessionId", next.SessionID).Int("year", next.Year).Msg("Advanced turn cycle")
+	return next, nil
+}
+
+// actionableSessions lists the sessions awaiting the user's orders. Servers
+// or sessions that can't be reached are skipped.
+func (a *App) actionableSessions() []ActionableSessionInfo {
+	a.mu.RLock()
+	serverURLs := make([]string, 0, len(a.connections))
+	for url, state := range a.connections {
+		if state.Connected {
+			serverURLs = append(serverURLs, url)
+		}
+	}
+	a.mu.RUnlock()
+	sort.Strings(serverURLs)
+
+	var result []ActionableSessionInfo
+	for _, serverURL := range serverURLs {
+		sessions, err := a.GetSessions(serverURL)
+		if err != nil {
+			logger.App.Debug().Err(err).Str("serverUrl", serverURL).Msg("Failed to list sessions for turn cycle")
+			continue
+		}
+		sort.Slice(sessions, func(i, j int) bool { return sessions[i].Name < sessions[j].Name })
+
+		for _, session := range sessions {
+			if session.State != models.SessionStateStarted {
+				continue
+			}
+			if info, ok := a.awaitingOrders(serverURL, session); ok {
+				result = append(result, info)
+			}
+		}
+	}
+	return result
+}
+
+// awaitingOrders tells whether the user plays a session and has not submitted
+// orders for its current year
+func (a *App) awaitingOrders(serverURL string, session SessionInfo) (ActionableSessionInfo, bool) {
+	_, mgr, err := a.sessionConnection(serverURL, session.ID)
+	if err != nil {
+		return ActionableSessionInfo{}, false
+	}
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return ActionableSessionInfo{}, false
+	}
+
+	playerOrder := -1
+	for _, p := range session.Players {
+		if p.UserProfileID == userInfo.User.ID {
+			playerOrder = p.PlayerOrder
+			break
+		}
+	}
+	if playerOrder < 0 {
+		return ActionableSessionInfo{}, false
+	}
+
+	status, err := a.GetOrdersStatus(serverURL, session.ID)
+	if err != nil || status.Stale {
+		return ActionableSessionInfo{}, false
+	}
+	for _, p := range status.Players {
+		if p.PlayerOrder == playerOrder && !p.Submitted {
+			return ActionableSessionInfo{
+				ServerURL:   serverURL,
+				SessionID:   session.ID,
+				SessionName: session.Name,
+				Year:        status.PendingYear,
+			}, true
+		}
+	}
+	return ActionableSessionInfo{}, false
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/neper-stars/houston/store"
+)
+
+// =============================================================================
+// TURN HINTS
+// =============================================================================
+
+// Kinds of turn hints
+const (
+	hintIdleFleet     = "idle-fleet"
+	hintEmptyQueue    = "empty-queue"
+	hintNoResearch    = "no-research"
+	hintOverpopulated = "overpopulated"
+)
+
+// AnalyzeMyTurn looks for common beginner mistakes in the local turn file of
+// a session: fleets without orders, planets with nothing to build, no research
+// budget and planets over their population capacity. The checks are simple
+// rules, a hint is not necessarily a mistake.
+func (a *App) AnalyzeMyTurn(serverURL, sessionID string) ([]TurnHintInfo, error) {
+	gs, err := a.loadSessionGameStore(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := turnOwner(gs)
+	player, ok := gs.Player(owner)
+	if !ok {
+		return nil, fmt.Errorf("no player data in the turn file")
+	}
+
+	hints := []TurnHintInfo{}
+	hints = append(hints, researchHints(player)...)
+	hints = append(hints, planetHints(gs, player)...)
+	hints = append(hints, fleetHints(gs, owner)...)
+	return hints, nil
+}
+
+// researchHints warns when no resources go to research
+func researchHints(player *store.PlayerEntity) []TurnHintInfo {
+	if pct, ok := researchPercentage(player); ok && pct == 0 {
+		return []TurnHintInfo{{
+			Kind:    hintNoResearch,
+			Message: "Your research budget is 0%, your tech levels won't improve",
+		}}
+	}
+	return nil
+}
+
+// planetHints warns about the player's planets with an empty production queue
+// or more colonists than they can hold
+func planetHints(gs *store.GameStore, player *store.PlayerEntity) []TurnHintInfo {
+	planets := gs.PlanetsByOwner(player.PlayerNumber)
+	sort.Slice(planets, func(i, j int) bool { return planets[i].PlanetNumber < planets[j].PlanetNumber })
+
+	hints := []TurnHintInfo{}
+	for _, planet := range planets {
+		if queue, ok := gs.ProductionQueue(planet.PlanetNumber); !ok || queue.QueueLength() == 0 {
+			hints = append(hints, TurnHintInfo{
+				Kind:         hintEmptyQueue,
+				Message:      fmt.Sprintf("%s has nothing in its production queue", planet.Name),
+				PlanetNumber: planet.PlanetNumber,
+			})
+		}
+		// Houston counts the capacity in hundreds of colonists
+		if maxPop := int64(gs.MaxPopulation(planet, player)) * 100; maxPop > 0 && planet.Population > maxPop {
+			hints = append(hints, TurnHintInfo{
+				Kind:         hintOverpopulated,
+				Message:      fmt.Sprintf("%s has %d colonists for a capacity of %d, the excess will die off", planet.Name, planet.Population, maxPop),
+				PlanetNumber: planet.PlanetNumber,
+			})
+		}
+	}
+	return hints
+}
+
+// fleetHints warns about the player's fleets without any waypoint beyond
+// their position
+func fleetHints(gs *store.GameStore, owner int) []TurnHintInfo {
+	fleets := gs.FleetsByOwner(owner)
+	sort.Slice(fleets, func(i, j int) bool { return fleets[i].FleetNumber < fleets[j].FleetNumber })
+
+	hints := []TurnHintInfo{}
+	for _, fleet := range fleets {
+		if fleet.IsDead || fleet.WaypointCount > 1 {
+			continue
+		}
+		hints = append(hints, TurnHintInfo{
+			Kind:        hintIdleFleet,
+			Message:     fmt.Sprintf("%s has no orders", fleet.Name()),
+			FleetNumber: fleet.FleetNumber,
+		})
+	}
+	return hints
+}
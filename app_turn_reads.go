@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/neper-stars/houston/blocks"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// =============================================================================
+// TURN READ RECEIPTS
+// =============================================================================
+
+// Turn read states of a player, see GetTurnReadStatus
+const (
+	turnReadNotOpened = "not-opened"
+	turnReadOpened    = "opened"
+	turnReadSubmitted = "submitted"
+)
+
+// GetTurnReadStatus returns the orders status of the current year of a session
+// along with which players opened their turn (session hosts only), telling
+// apart players who haven't looked yet from those who looked but haven't
+// submitted. Servers without read receipts only report submissions.
+func (a *App) GetTurnReadStatus(serverURL, sessionID string) (*TurnReadStatusInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := a.GetOrdersStatus(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	info := &TurnReadStatusInfo{
+		SessionID: sessionID,
+		Year:      status.PendingYear,
+		Players:   make([]PlayerTurnReadInfo, 0, len(status.Players)),
+		Supported: true,
+	}
+
+	reads, err := client.GetTurnReads(mgr.GetContext(), sessionID, status.PendingYear)
+	if errors.Is(err, api.ErrNotSupported) {
+		info.Supported = false
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get turn reads: %w", err)
+	}
+	readBy := make(map[int]api.TurnRead, len(reads))
+	for _, r := range reads {
+		readBy[r.PlayerOrder] = r
+	}
+
+	for _, p := range status.Players {
+		player := PlayerTurnReadInfo{
+			PlayerOrder: p.PlayerOrder,
+			Nickname:    p.Nickname,
+			IsBot:       p.IsBot,
+			Submitted:   p.Submitted,
+			State:       turnReadNotOpened,
+		}
+		if r, ok := readBy[p.PlayerOrder]; ok {
+			readAt := r.ReadAt
+			player.OpenedAt = &readAt
+			player.State = turnReadOpened
+		}
+		if p.Submitted {
+			player.State = turnReadSubmitted
+		}
+		info.Players = append(info.Players, player)
+	}
+	return info, nil
+}
+
+// reportTurnOpened tells the server the user opened the local turn of a
+// session, unless disabled in the settings. Servers without read receipts are
+// ignored.
+func (a *App) reportTurnOpened(serverURL, sessionID, gameDir string) {
+	settings, err := a.config.GetAppSettings()
+	if err != nil || !settings.GetReportTurnReads() {
+		return
+	}
+
+	gs, err := loadLocalGameStore(gameDir)
+	if err != nil {
+		logger.App.Debug().Err(err).Str("sessionId", sessionID).Msg("Failed to read the opened turn year")
+		return
+	}
+	year := blocks.StarsBaseYear + int(gs.Turn)
+
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return
+	}
+	if err := client.MarkTurnRead(mgr.GetContext(), sessionID, year); err != nil {
+		if errors.Is(err, api.ErrNotSupported) {
+			logger.App.Debug().Str("serverUrl", serverURL).Msg("Server has no turn read receipts")
+			return
+		}
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Int("year", year).Msg("Failed to report opened turn")
+	}
+}
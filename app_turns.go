@@ -8,8 +8,16 @@ import (
 	"os/exec"
 	"path/filepath"
 	goruntime "runtime"
+	"time"
 
+	"github.com/neper-stars/astrum/lib/hooks"
 	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+	"github.com/neper-stars/astrum/lib/platform"
+	"github.com/neper-stars/astrum/lib/safefile"
+	"github.com/neper-stars/astrum/lib/telemetry"
+	"github.com/neper-stars/astrum/lib/workqueue"
+	"github.com/neper-stars/astrum/model"
 	"github.com/neper-stars/neper/lib/wine"
 )
 
@@ -34,13 +42,9 @@ func (a *App) saveTurnFiles(serverURL, sessionID, universe, turn string) error {
 	}
 
 	// Get player order to determine the .mN file number
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
 	}
 
 	ctx := mgr.GetContext()
@@ -91,6 +95,7 @@ func (a *App) saveTurnFiles(serverURL, sessionID, universe, turn string) error {
 		if err != nil {
 			return fmt.Errorf("failed to write universe file: %w", err)
 		}
+		a.recordGameFile(gameDir, universePath, fileOriginServer)
 		if written {
 			logger.App.Debug().
 				Str("sessionID", sessionID).
@@ -112,6 +117,7 @@ func (a *App) saveTurnFiles(serverURL, sessionID, universe, turn string) error {
 		if err != nil {
 			return fmt.Errorf("failed to write turn file: %w", err)
 		}
+		a.recordGameFile(gameDir, turnPath, fileOriginServer)
 		if written {
 			logger.App.Debug().
 				Str("sessionID", sessionID).
@@ -119,6 +125,18 @@ func (a *App) saveTurnFiles(serverURL, sessionID, universe, turn string) error {
 				Int("playerOrder", playerOrder).
 				Int("size", len(turnData)).
 				Msg("Saved turn file")
+			go a.fireHook(hooks.EventTurnDownloaded, serverURL, sessionID, map[string]string{
+				"ASTRUM_FILE": turnPath,
+			})
+			a.work.Submit(workqueue.Normal, "plugins", func() {
+				a.runPluginsAfterDownload(serverURL, sessionID, gameDir, turnPath)
+			})
+			a.work.Submit(workqueue.Low, "auto-map", func() {
+				a.autoGenerateMap(serverURL, sessionID, gameDir, turnPath)
+			})
+			a.work.Submit(workqueue.Low, "production-drift", func() {
+				a.checkProductionDrift(serverURL, sessionID, gameDir, turnPath)
+			})
 		}
 	}
 
@@ -135,9 +153,10 @@ func (a *App) saveTurnFiles(serverURL, sessionID, universe, turn string) error {
 			if err != nil {
 				logger.App.Warn().Err(err).Msg("Failed to decode race data")
 			} else {
-				if err := os.WriteFile(raceFilePath, raceData, 0644); err != nil {
+				if err := safefile.WriteFile(raceFilePath, raceData, 0644); err != nil {
 					logger.App.Warn().Err(err).Str("path", raceFilePath).Msg("Failed to write race file")
 				} else {
+					a.recordGameFile(gameDir, raceFilePath, fileOriginServer)
 					logger.App.Debug().
 						Str("sessionID", sessionID).
 						Str("path", raceFilePath).
@@ -152,24 +171,22 @@ func (a *App) saveTurnFiles(serverURL, sessionID, universe, turn string) error {
 	// Ensure stars.exe is downloaded if auto-download is enabled
 	a.ensureStarsExeInDir(serverURL, sessionID, gameDir)
 
+	a.telemetry.Count(telemetry.FeatureTurnDownload)
 	return nil
 }
 
 // GetTurn retrieves turn files for a specific year in a session
 // If saveToGameDir is true, it also saves the files to the game directory
 func (a *App) GetTurn(serverURL, sessionID string, year int, saveToGameDir bool) (*TurnFilesInfo, error) {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
 	ctx := mgr.GetContext()
 	turnFiles, err := client.GetTurn(ctx, sessionID, year)
 	if err != nil {
+		a.telemetry.Error(telemetry.ErrorTurnDownload)
 		return nil, fmt.Errorf("failed to get turn files: %w", err)
 	}
 
@@ -194,17 +211,14 @@ func (a *App) GetTurn(serverURL, sessionID string, year int, saveToGameDir bool)
 // GetLatestTurn retrieves the latest turn files for a session
 // It also auto-saves the files to the game directory
 func (a *App) GetLatestTurn(serverURL, sessionID string) (*TurnFilesInfo, error) {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
-
-	if !ok || !mgrOk {
-		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
 	turnFiles, err := client.GetLatestTurn(mgr.GetContext(), sessionID)
 	if err != nil {
+		a.telemetry.Error(telemetry.ErrorTurnDownload)
 		return nil, fmt.Errorf("failed to get latest turn files: %w", err)
 	}
 
@@ -225,21 +239,28 @@ func (a *App) GetLatestTurn(serverURL, sessionID string) (*TurnFilesInfo, error)
 }
 
 // DownloadSessionBackup downloads all session files and creates a backup zip (manager only)
-// The zip is saved to the game directory as <year>-backup.zip with files in backup/<year>/ subfolder
+// The zip is saved to the game directory as <year>-backup.zip with files in backup/<year>/ subfolder.
+// StartOperation runs it with progress and cancellation.
 func (a *App) DownloadSessionBackup(serverURL, sessionID string) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
+	_, err := a.downloadSessionBackup(nil, serverURL, sessionID)
+	return err
+}
 
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+// downloadSessionBackup creates the backup zip of a session, reporting its
+// progress to op, and returns the zip path
+func (a *App) downloadSessionBackup(op *operation, serverURL, sessionID string) (string, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return "", err
 	}
+	ctx, stop := op.bind(mgr.GetContext())
+	defer stop()
 
 	// Get session files from API
-	files, err := client.GetSessionFiles(mgr.GetContext(), sessionID)
+	op.progress(0, 0, "Downloading the session files")
+	files, err := client.GetSessionFiles(ctx, sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to get session files: %w", err)
+		return "", fmt.Errorf("failed to get session files: %w", err)
 	}
 
 	// Get the server name for calculating game directory
@@ -252,14 +273,26 @@ func (a *App) DownloadSessionBackup(serverURL, sessionID string) error {
 	// Get game directory
 	gameDir, err := a.config.EnsureSessionGameDir(serverName, sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to get game directory: %w", err)
+		return "", fmt.Errorf("failed to get game directory: %w", err)
+	}
+
+	// Base64 takes 4 bytes for 3, the zip is at most that size
+	size := len(files.Universe) + len(files.HostFile)
+	for _, turn := range files.Turns {
+		size += len(turn.B64Data)
+	}
+	for _, order := range files.Orders {
+		size += len(order.B64Data)
+	}
+	if err := a.checkDiskSpace(gameDir, "backup", int64(size)*3/4); err != nil {
+		return "", err
 	}
 
 	// Create the zip file
 	zipPath := filepath.Join(gameDir, fmt.Sprintf("%d-backup.zip", files.Year))
-	zipFile, err := os.Create(zipPath)
+	zipFile, err := safefile.Create(zipPath, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to create zip file: %w", err)
+		return "", fmt.Errorf("failed to create zip file: %w", err)
 	}
 	defer func() { _ = zipFile.Close() }()
 
@@ -268,18 +301,46 @@ func (a *App) DownloadSessionBackup(serverURL, sessionID string) error {
 
 	subFolder := fmt.Sprintf("backup/%d/", files.Year)
 
+	// One step per file written, after the download
+	total := 1
+	for _, data := range []string{files.Universe, files.HostFile} {
+		if data != "" {
+			total++
+		}
+	}
+	for _, turn := range files.Turns {
+		if turn.B64Data != "" {
+			total++
+		}
+	}
+	for _, order := range files.Orders {
+		if order.B64Data != "" {
+			total++
+		}
+	}
+	done := 1
+	written := func(name string) error {
+		done++
+		op.progress(done, total, fmt.Sprintf("Saved %s", name))
+		return op.err()
+	}
+	op.progress(done, total, "Session files downloaded")
+
 	// Add universe file (.xy)
 	if files.Universe != "" {
 		data, err := base64.StdEncoding.DecodeString(files.Universe)
 		if err != nil {
-			return fmt.Errorf("failed to decode universe file: %w", err)
+			return "", fmt.Errorf("failed to decode universe file: %w", err)
 		}
 		w, err := zipWriter.Create(subFolder + "game.xy")
 		if err != nil {
-			return fmt.Errorf("failed to create universe entry in zip: %w", err)
+			return "", fmt.Errorf("failed to create universe entry in zip: %w", err)
 		}
 		if _, err := w.Write(data); err != nil {
-			return fmt.Errorf("failed to write universe to zip: %w", err)
+			return "", fmt.Errorf("failed to write universe to zip: %w", err)
+		}
+		if err := written("game.xy"); err != nil {
+			return "", err
 		}
 	}
 
@@ -287,14 +348,17 @@ func (a *App) DownloadSessionBackup(serverURL, sessionID string) error {
 	if files.HostFile != "" {
 		data, err := base64.StdEncoding.DecodeString(files.HostFile)
 		if err != nil {
-			return fmt.Errorf("failed to decode host file: %w", err)
+			return "", fmt.Errorf("failed to decode host file: %w", err)
 		}
 		w, err := zipWriter.Create(subFolder + "game.hst")
 		if err != nil {
-			return fmt.Errorf("failed to create host entry in zip: %w", err)
+			return "", fmt.Errorf("failed to create host entry in zip: %w", err)
 		}
 		if _, err := w.Write(data); err != nil {
-			return fmt.Errorf("failed to write host to zip: %w", err)
+			return "", fmt.Errorf("failed to write host to zip: %w", err)
+		}
+		if err := written("game.hst"); err != nil {
+			return "", err
 		}
 	}
 
@@ -305,15 +369,18 @@ func (a *App) DownloadSessionBackup(serverURL, sessionID string) error {
 		}
 		data, err := base64.StdEncoding.DecodeString(turn.B64Data)
 		if err != nil {
-			return fmt.Errorf("failed to decode turn file %d: %w", i+1, err)
+			return "", fmt.Errorf("failed to decode turn file %d: %w", i+1, err)
 		}
 		filename := fmt.Sprintf("game.m%d", i+1)
 		w, err := zipWriter.Create(subFolder + filename)
 		if err != nil {
-			return fmt.Errorf("failed to create turn entry in zip: %w", err)
+			return "", fmt.Errorf("failed to create turn entry in zip: %w", err)
 		}
 		if _, err := w.Write(data); err != nil {
-			return fmt.Errorf("failed to write turn to zip: %w", err)
+			return "", fmt.Errorf("failed to write turn to zip: %w", err)
+		}
+		if err := written(filename); err != nil {
+			return "", err
 		}
 	}
 
@@ -324,44 +391,71 @@ func (a *App) DownloadSessionBackup(serverURL, sessionID string) error {
 		}
 		data, err := base64.StdEncoding.DecodeString(order.B64Data)
 		if err != nil {
-			return fmt.Errorf("failed to decode order file %d: %w", i+1, err)
+			return "", fmt.Errorf("failed to decode order file %d: %w", i+1, err)
 		}
 		filename := fmt.Sprintf("game.x%d", i+1)
 		w, err := zipWriter.Create(subFolder + filename)
 		if err != nil {
-			return fmt.Errorf("failed to create order entry in zip: %w", err)
+			return "", fmt.Errorf("failed to create order entry in zip: %w", err)
 		}
 		if _, err := w.Write(data); err != nil {
-			return fmt.Errorf("failed to write order to zip: %w", err)
+			return "", fmt.Errorf("failed to write order to zip: %w", err)
+		}
+		if err := written(filename); err != nil {
+			return "", err
 		}
 	}
 
+	// Only a complete backup replaces the file
+	if err := zipWriter.Close(); err != nil {
+		return "", fmt.Errorf("failed to finish zip file: %w", err)
+	}
+	if err := zipFile.Commit(); err != nil {
+		return "", fmt.Errorf("failed to save zip file: %w", err)
+	}
+	a.recordGameFile(gameDir, zipPath, fileOriginServer)
+
 	logger.App.Info().
 		Str("sessionId", sessionID).
 		Int64("year", files.Year).
 		Str("zipPath", zipPath).
 		Msg("Downloaded session backup")
 
-	return nil
+	a.recordTimelineEvent(serverURL, sessionID, model.TimelineEvent{
+		Type:   model.TimelineBackup,
+		Time:   time.Now(),
+		Year:   int(files.Year),
+		Detail: zipPath,
+	})
+
+	return zipPath, nil
 }
 
 // DownloadHistoricBackup downloads all historic session files as a zip from the server
-// The zip is saved to the game directory as historic-backup.zip
+// The zip is saved to the game directory as historic-backup.zip.
+// StartOperation runs it with progress and cancellation.
 func (a *App) DownloadHistoricBackup(serverURL, sessionID string) error {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
+	_, err := a.downloadHistoricBackup(nil, serverURL, sessionID)
+	return err
+}
 
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server: %s", serverURL)
+// downloadHistoricBackup saves the historic backup zip of a session,
+// reporting its progress to op, and returns the zip path
+func (a *App) downloadHistoricBackup(op *operation, serverURL, sessionID string) (string, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return "", err
 	}
+	ctx, stop := op.bind(mgr.GetContext())
+	defer stop()
 
-	// Download the backup zip from the server
-	zipData, err := client.DownloadHistoricBackup(mgr.GetContext(), sessionID)
+	// Download the backup zip from the server, its size is not known beforehand
+	op.progress(0, 0, "Downloading the historic backup")
+	zipData, err := client.DownloadHistoricBackup(ctx, sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to download historic backup: %w", err)
+		return "", fmt.Errorf("failed to download historic backup: %w", err)
 	}
+	op.progress(1, 2, "Saving the historic backup")
 
 	// Get the server name for calculating game directory
 	server, _ := a.config.GetServer(serverURL)
@@ -373,14 +467,15 @@ func (a *App) DownloadHistoricBackup(serverURL, sessionID string) error {
 	// Get game directory
 	gameDir, err := a.config.EnsureSessionGameDir(serverName, sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to get game directory: %w", err)
+		return "", fmt.Errorf("failed to get game directory: %w", err)
 	}
 
 	// Save the zip file
 	zipPath := filepath.Join(gameDir, "historic-backup.zip")
-	if err := os.WriteFile(zipPath, zipData, 0644); err != nil {
-		return fmt.Errorf("failed to save historic backup: %w", err)
+	if err := safefile.WriteFile(zipPath, zipData, 0644); err != nil {
+		return "", fmt.Errorf("failed to save historic backup: %w", err)
 	}
+	a.recordGameFile(gameDir, zipPath, fileOriginServer)
 
 	logger.App.Info().
 		Str("sessionId", sessionID).
@@ -388,19 +483,38 @@ func (a *App) DownloadHistoricBackup(serverURL, sessionID string) error {
 		Int("size", len(zipData)).
 		Msg("Downloaded historic backup")
 
-	return nil
+	a.recordTimelineEvent(serverURL, sessionID, model.TimelineEvent{
+		Type:   model.TimelineBackup,
+		Time:   time.Now(),
+		Detail: zipPath,
+	})
+
+	op.progress(2, 2, "Historic backup saved")
+	return zipPath, nil
 }
 
 // GetOrdersStatus retrieves order submission status for all players for the current turn
 // Orders are submitted for the current year (latestYear), and will be used to generate the next year
 func (a *App) GetOrdersStatus(serverURL, sessionID string) (*OrdersStatusInfo, error) {
-	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
-	a.mu.RUnlock()
+	info, err := a.fetchOrdersStatus(serverURL, sessionID)
+	if err == nil {
+		a.cacheServerData(serverURL, cacheKeyOrdersStatus+sessionID, info)
+		return info, nil
+	}
 
-	if !ok || !mgrOk {
-		return nil, fmt.Errorf("not connected to server: %s", serverURL)
+	var cached OrdersStatusInfo
+	if useServerCache(err) && a.cachedServerData(serverURL, cacheKeyOrdersStatus+sessionID, &cached) {
+		cached.Stale = true
+		return &cached, nil
+	}
+	return nil, err
+}
+
+// fetchOrdersStatus retrieves the orders status of the current turn from the server
+func (a *App) fetchOrdersStatus(serverURL, sessionID string) (*OrdersStatusInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get the latest turn to determine the current year
@@ -429,11 +543,19 @@ func (a *App) GetOrdersStatus(serverURL, sessionID string) (*OrdersStatusInfo, e
 		}
 	}
 
-	return &OrdersStatusInfo{
+	info := &OrdersStatusInfo{
 		SessionID:   sessionID,
 		PendingYear: currentYear,
 		Players:     players,
-	}, nil
+	}
+
+	a.mu.Lock()
+	a.ordersStatus[serverURL+"|"+sessionID] = info
+	a.mu.Unlock()
+
+	go a.writeStatusFile()
+
+	return info, nil
 }
 
 // OpenGameDir opens the game directory for a session in the system file explorer
@@ -452,17 +574,7 @@ func (a *App) OpenGameDir(serverURL, sessionID string) error {
 	}
 
 	// Open the directory in the system file explorer
-	var cmd *exec.Cmd
-	switch goruntime.GOOS {
-	case "darwin":
-		cmd = exec.Command("open", gameDir)
-	case "windows":
-		cmd = exec.Command("explorer", gameDir)
-	default: // linux and others
-		cmd = exec.Command("xdg-open", gameDir)
-	}
-
-	if err := cmd.Start(); err != nil {
+	if err := platform.OpenPath(gameDir); err != nil {
 		return fmt.Errorf("failed to open directory: %w", err)
 	}
 
@@ -470,6 +582,56 @@ func (a *App) OpenGameDir(serverURL, sessionID string) error {
 	return nil
 }
 
+// RevealTurnFile opens the system file explorer on the game directory of a
+// session with the turn file selected
+func (a *App) RevealTurnFile(serverURL, sessionID string) error {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
+	turnPath, err := findTurnFile(gameDir)
+	if err != nil {
+		return err
+	}
+
+	if err := platform.RevealPath(turnPath); err != nil {
+		return fmt.Errorf("failed to reveal turn file: %w", err)
+	}
+	logger.App.Info().Str("path", turnPath).Msg("Revealed turn file")
+	return nil
+}
+
+// RevealBackup opens the system file explorer on the game directory of a
+// session with the most recent backup selected, see DownloadSessionBackup and
+// DownloadHistoricBackup
+func (a *App) RevealBackup(serverURL, sessionID string) error {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
+	backups, err := filepath.Glob(filepath.Join(gameDir, "*-backup.zip"))
+	if err != nil {
+		return fmt.Errorf("failed to find backups: %w", err)
+	}
+
+	var latest string
+	var latestTime time.Time
+	for _, backup := range backups {
+		if info, err := os.Stat(backup); err == nil && info.ModTime().After(latestTime) {
+			latest, latestTime = backup, info.ModTime()
+		}
+	}
+	if latest == "" {
+		return fmt.Errorf("no backup found in %s", gameDir)
+	}
+
+	if err := platform.RevealPath(latest); err != nil {
+		return fmt.Errorf("failed to reveal backup: %w", err)
+	}
+	logger.App.Info().Str("path", latest).Msg("Revealed backup")
+	return nil
+}
+
 // HasStarsExe checks if stars.exe exists in the game directory for a session
 func (a *App) HasStarsExe(serverURL, sessionID string) bool {
 	a.mu.RLock()
@@ -492,7 +654,7 @@ func (a *App) HasStarsExe(serverURL, sessionID string) bool {
 		return false
 	}
 
-	starsPath := filepath.Join(gameDir, "stars.exe")
+	starsPath := monitor.ResolveFile(gameDir, "stars.exe")
 	_, err = os.Stat(starsPath)
 	return err == nil
 }
@@ -502,17 +664,21 @@ func (a *App) HasStarsExe(serverURL, sessionID string) bool {
 // If useWine is enabled in settings, it uses wine to run stars.exe
 func (a *App) LaunchStars(serverURL, sessionID string) error {
 	a.mu.RLock()
-	client, ok := a.clients[serverURL]
-	mgr, mgrOk := a.authManagers[serverURL]
 	conn := a.connections[serverURL]
 	a.mu.RUnlock()
 
-	if !ok || !mgrOk {
-		return fmt.Errorf("not connected to server")
-	}
 	if conn == nil || !conn.Connected {
 		return fmt.Errorf("not connected to server")
 	}
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return err
+	}
+
+	// The game may have moved on from another machine
+	if err := a.syncBeforeOrders(serverURL, sessionID); err != nil {
+		return err
+	}
 
 	// Get current user info
 	userInfo := mgr.GetUserInfo()
@@ -552,84 +718,144 @@ func (a *App) LaunchStars(serverURL, sessionID string) error {
 		return fmt.Errorf("failed to get game directory: %w", err)
 	}
 
+	// Download again turn files deleted since
+	if _, err := a.recoverTurnFiles(serverURL, sessionID); err != nil {
+		return err
+	}
+
 	// Build the turn file path
 	turnFileName := fmt.Sprintf("game.m%d", playerOrder)
-	turnFilePath := filepath.Join(gameDir, turnFileName)
+	turnFilePath := monitor.ResolveFile(gameDir, turnFileName)
 
 	// Check if turn file exists
 	if _, err := os.Stat(turnFilePath); os.IsNotExist(err) {
 		return fmt.Errorf("turn file not found: %s (download the turn first)", turnFileName)
 	}
+	// Launch Stars! with the file name as found on disk, it may be upper case
+	turnFileName = filepath.Base(turnFilePath)
 
 	// Check if stars.exe exists
-	starsExePath := filepath.Join(gameDir, "stars.exe")
+	starsExePath := monitor.ResolveFile(gameDir, "stars.exe")
 	if _, err := os.Stat(starsExePath); os.IsNotExist(err) {
 		return fmt.Errorf("stars.exe not found in game directory")
 	}
 
-	// Check if we should use Wine
-	useWine, err := a.config.GetUseWine()
+	if err := a.startStars(serverURL, serverName, sessionID, gameDir, starsExePath, turnFileName); err != nil {
+		return err
+	}
+	go a.reportTurnOpened(serverURL, sessionID, gameDir)
+	return nil
+}
+
+// startStars starts stars.exe on a turn file of runDir, with Wine when
+// enabled, without waiting for it to exit. The run is added to the launch
+// history of the session once Stars! exits.
+func (a *App) startStars(serverURL, serverName, sessionID, runDir, starsExePath, turnFileName string) error {
+	cmd, useWine, err := a.starsCommand(serverURL, serverName, runDir, starsExePath, turnFileName)
 	if err != nil {
-		return fmt.Errorf("failed to get wine setting: %w", err)
+		return err
+	}
+	record := model.LaunchRecord{
+		StartedAt: time.Now(),
+		Dir:       runDir,
+		File:      turnFileName,
+		Wine:      useWine,
+		Env:       a.applyLaunchEnv(cmd, serverURL, sessionID),
+		ExitCode:  -1,
 	}
+	if useWine {
+		record.WinePrefix, _ = a.config.GetServerWinePrefix(serverName)
+	}
+	if err := a.checkConcurrentLaunch(serverURL, sessionID, record.WinePrefix); err != nil {
+		return err
+	}
+	stderr := newTailBuffer(launchStderrTail)
+	cmd.Stderr = stderr
+
+	logger.App.Info().
+		Str("sessionID", sessionID).
+		Str("gameDir", runDir).
+		Str("turnFile", turnFileName).
+		Str("serverName", serverName).
+		Bool("wine", useWine).
+		Msg("Launching Stars!")
 
-	var cmd *exec.Cmd
+	// Start the process (don't wait for it to complete)
+	if err := cmd.Start(); err != nil {
+		a.telemetry.Error(telemetry.ErrorLaunch)
+		record.Error = err.Error()
+		a.recordLaunch(serverURL, sessionID, record)
+		return fmt.Errorf("failed to launch Stars!: %w", err)
+	}
 	if useWine {
-		// Check if wine installation has been validated
-		validWine, err := a.config.GetValidWineInstall()
-		if err != nil {
-			return fmt.Errorf("failed to get wine validation status: %w", err)
-		}
-		if !validWine {
-			return fmt.Errorf("wine installation not validated, please run 'Check Wine Installation' in Settings first")
-		}
+		a.telemetry.Count(telemetry.FeatureLaunchWine)
+	} else {
+		a.telemetry.Count(telemetry.FeatureLaunchNative)
+	}
 
-		// Get per-server wine prefix and ensure it exists
-		winePrefix, err := a.ensureServerWinePrefix(serverName)
-		if err != nil {
-			return fmt.Errorf("failed to ensure server wine prefix: %w", err)
-		}
+	a.runningMu.Lock()
+	a.running[cmd] = runningLaunch{serverURL: serverURL, sessionID: sessionID, winePrefix: record.WinePrefix}
+	a.runningMu.Unlock()
 
-		// Create the wine prefix manager for environment
-		prefix, err := wine.NewPrefix(logger.App, wine.PrefixOptions{
-			PrefixPath: winePrefix,
-		})
-		if err != nil {
-			return fmt.Errorf("failed to create wine prefix manager: %w", err)
+	go a.superviseLaunch(serverURL, sessionID, cmd, record, stderr)
+	return nil
+}
+
+// starsCommand builds the command running stars.exe with args in runDir, with
+// the per-server Wine prefix when Wine is enabled. Returns whether Wine is used.
+func (a *App) starsCommand(serverURL, serverName, runDir, starsExePath string, args ...string) (*exec.Cmd, bool, error) {
+	useWine, err := a.config.GetUseWine()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get wine setting: %w", err)
+	}
+
+	if !useWine {
+		// On Windows, run directly
+		if goruntime.GOOS != "windows" {
+			return nil, false, fmt.Errorf("wine is required to run Stars! on %s, enable it in Settings", goruntime.GOOS)
+		}
+		if err := a.applyStarsIniDefaults(serverURL); err != nil {
+			logger.App.Warn().Err(err).Msg("Failed to apply stars.ini defaults before launch")
 		}
 
-		// Launch with wine
-		cmd = exec.Command("wine", starsExePath, turnFileName)
-		cmd.Dir = gameDir
-		cmd.Env = append(os.Environ(), prefix.Env()...)
+		cmd := exec.Command(starsExePath, args...)
+		cmd.Dir = runDir
+		return cmd, false, nil
+	}
 
-		logger.App.Info().
-			Str("sessionID", sessionID).
-			Str("gameDir", gameDir).
-			Str("turnFile", turnFileName).
-			Str("winePrefix", winePrefix).
-			Str("serverName", serverName).
-			Msg("Launching Stars! with Wine using per-server prefix")
-	} else {
-		// On Windows, launch directly
-		if goruntime.GOOS == "windows" {
-			cmd = exec.Command(starsExePath, turnFileName)
-			cmd.Dir = gameDir
+	// Check if wine installation has been validated
+	validWine, err := a.config.GetValidWineInstall()
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to get wine validation status: %w", err)
+	}
+	if !validWine {
+		return nil, true, fmt.Errorf("wine installation not validated, please run 'Check Wine Installation' in Settings first")
+	}
 
-			logger.App.Info().
-				Str("sessionID", sessionID).
-				Str("gameDir", gameDir).
-				Str("turnFile", turnFileName).
-				Msg("Launching Stars! directly")
-		} else {
-			return fmt.Errorf("wine is required to run Stars! on %s, enable it in Settings", goruntime.GOOS)
-		}
+	// Get per-server wine prefix and ensure it exists
+	winePrefix, err := a.ensureServerWinePrefix(serverName)
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to ensure server wine prefix: %w", err)
 	}
 
-	// Start the process (don't wait for it to complete)
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to launch Stars!: %w", err)
+	// Make sure the prefix is registered with this server's serial key
+	if err := a.applySerialKey(serverURL); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to apply serial key before launch")
+	}
+	if err := a.applyStarsIniDefaults(serverURL); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to apply stars.ini defaults before launch")
 	}
 
-	return nil
+	// Create the wine prefix manager for environment
+	prefix, err := wine.NewPrefix(logger.App, wine.PrefixOptions{
+		PrefixPath: winePrefix,
+	})
+	if err != nil {
+		return nil, true, fmt.Errorf("failed to create wine prefix manager: %w", err)
+	}
+
+	cmd := exec.Command(platform.WineCommand(), append([]string{starsExePath}, args...)...)
+	cmd.Dir = runDir
+	cmd.Env = append(os.Environ(), prefix.Env()...)
+	return cmd, true, nil
 }
@@ -8,11 +8,12 @@ import "time"
 
 // ConnectionState tracks the state of a server connection
 type ConnectionState struct {
-	Connected bool      `json:"connected"`
-	Username  string    `json:"username"`
-	UserID    string    `json:"userId"`
-	Error     string    `json:"error,omitempty"`
-	Since     time.Time `json:"since,omitempty"`
+	Connected  bool      `json:"connected"`
+	Connecting bool      `json:"connecting"` // A Connect is in progress
+	Username   string    `json:"username"`
+	UserID     string    `json:"userId"`
+	Error      string    `json:"error,omitempty"`
+	Since      time.Time `json:"since,omitempty"`
 }
 
 // =============================================================================
@@ -28,6 +29,10 @@ type ServerInfo struct {
 	DefaultUsername string `json:"defaultUsername,omitempty"`
 	IsConnected     bool   `json:"isConnected"`
 	Order           int    `json:"order"`
+	AutoConnect     bool   `json:"autoConnect"`             // Connected when the app starts
+	GamesDir        string `json:"gamesDir,omitempty"`      // Holds the server directory instead of the servers directory
+	SmokeTestUser   string `json:"smokeTestUser,omitempty"` // Credential used by RunServerSmokeTest
+	ShareGameStats  bool   `json:"shareGameStats"`          // Finished games are submitted to the server leaderboard
 }
 
 // ServerOrder is used for reordering servers
@@ -55,6 +60,16 @@ type RegistrationResultInfo struct {
 	Pending  bool   `json:"pending"` // True if user needs admin approval
 }
 
+// CredentialCheckInfo is the outcome of checking a stored credential against its server
+type CredentialCheckInfo struct {
+	ServerURL  string `json:"serverUrl"`
+	ServerName string `json:"serverName"`
+	Nickname   string `json:"nickname"`
+	IsDefault  bool   `json:"isDefault"`
+	Status     string `json:"status"` // "valid", "rejected", "missing", "unreachable" or "error"
+	Error      string `json:"error,omitempty"`
+}
+
 // =============================================================================
 // SESSION TYPES
 // =============================================================================
@@ -70,6 +85,12 @@ type SessionInfo struct {
 	RulesIsSet        bool                `json:"rulesIsSet"`
 	Players           []SessionPlayerInfo `json:"players"`
 	PendingInvitation bool                `json:"pending_invitation"`
+	Stale             bool                `json:"stale,omitempty"`         // served from the offline cache
+	MaxPlayers        int                 `json:"maxPlayers,omitempty"`    // 0 when unlimited or unknown
+	ReservedSlots     []string            `json:"reservedSlots,omitempty"` // Nicknames with a reserved slot
+	Online            []string            `json:"online,omitempty"`        // Members connected to the server, when it tells
+	AtRisk            bool                `json:"atRisk,omitempty"`        // The deadline nears and our orders are not uploaded
+	DeadlineAt        string              `json:"deadlineAt,omitempty"`    // Deadline of an at risk session, RFC 3339
 }
 
 // SessionPlayerInfo is the JSON-friendly representation of a session player
@@ -82,6 +103,114 @@ type SessionPlayerInfo struct {
 	BotRaceName   *string `json:"botRaceName,omitempty"`
 }
 
+// LiveRoundInfo is the JSON-friendly representation of a live round, a
+// countdown for all the players of a session to play the same year together
+type LiveRoundInfo struct {
+	ID               string   `json:"id"`
+	Year             int      `json:"year"`
+	StartedBy        string   `json:"startedBy"` // Nickname of the host
+	EndsAt           string   `json:"endsAt"`
+	RemainingSeconds int      `json:"remainingSeconds"`
+	Ready            []string `json:"ready"`    // User profile IDs of the players ready
+	NotReady         []string `json:"notReady"` // User profile IDs of the players not ready
+}
+
+// DeviceInfo is another machine the user is connected from
+type DeviceInfo struct {
+	Machine    string `json:"machine"`
+	Monitoring bool   `json:"monitoring"` // The machine auto-uploads order files
+	LastSeen   string `json:"lastSeen"`
+}
+
+// WaitlistEntryInfo is the JSON-friendly representation of a user waiting
+// for a slot in a full session
+type WaitlistEntryInfo struct {
+	UserProfileID string `json:"userProfileId"`
+	Nickname      string `json:"nickname"`
+	Position      int    `json:"position"`
+	JoinedAt      string `json:"joinedAt"`
+	Notified      bool   `json:"notified"` // A slot was offered to the user
+}
+
+// JoinPreflightInfo summarizes a session before joining it
+type JoinPreflightInfo struct {
+	SessionID        string       `json:"sessionId"`
+	SessionName      string       `json:"sessionName"`
+	State            string       `json:"state"`
+	Hosts            []string     `json:"hosts"` // Nicknames of the session managers
+	MemberCount      int          `json:"memberCount"`
+	PlayerCount      int          `json:"playerCount"` // Players who already picked a race
+	ReadyCount       int          `json:"readyCount"`
+	CadenceHours     int          `json:"cadenceHours"` // Hours between turns, 0 if the session has no turn schedule
+	RulesIsSet       bool         `json:"rulesIsSet"`
+	UniverseSize     string       `json:"universeSize,omitempty"`
+	Density          string       `json:"density,omitempty"`
+	StartingDistance string       `json:"startingDistance,omitempty"`
+	RuleHighlights   []string     `json:"ruleHighlights"`
+	Race             *RaceFitInfo `json:"race,omitempty"` // nil if no race was given
+	Warnings         []string     `json:"warnings"`
+}
+
+// RaceFitInfo reports whether a race is a reasonable fit for a session
+type RaceFitInfo struct {
+	RaceID            string                  `json:"raceId"`
+	Name              string                  `json:"name"`
+	Points            int                     `json:"points"`
+	IsValid           bool                    `json:"isValid"`
+	HabitableFraction float64                 `json:"habitableFraction"` // Rough share of planets in the hab range (0-1)
+	Habitability      HabitabilityDisplayInfo `json:"habitability"`
+}
+
+// ReadyCheckInfo is the outcome of a single ready-state check
+type ReadyCheckInfo struct {
+	ID      string `json:"id"` // e.g. "race_selected", "game_dir_writable"
+	Label   string `json:"label"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped"` // True if the check does not apply or cannot be verified
+	Message string `json:"message,omitempty"`
+}
+
+// ReadyChecklistInfo is the list of checks run before marking a player ready
+type ReadyChecklistInfo struct {
+	Ready  bool             `json:"ready"` // True if no check failed
+	Checks []ReadyCheckInfo `json:"checks"`
+}
+
+// FirstTurnStatusInfo is the outcome of the first-turn bootstrap of a session
+type FirstTurnStatusInfo struct {
+	SessionID   string              `json:"sessionId"`
+	Year        int                 `json:"year,omitempty"`
+	ReadyToPlay bool                `json:"readyToPlay"` // True if Stars! can be opened on the turn
+	Steps       []BootstrapStepInfo `json:"steps"`
+}
+
+// BootstrapStepInfo is a single step of the first-turn bootstrap
+type BootstrapStepInfo struct {
+	ID      string `json:"id"` // "game_started", "turn_downloaded", "race_file", "stars_exe", "wine"
+	Label   string `json:"label"`
+	Done    bool   `json:"done"`
+	Skipped bool   `json:"skipped"`
+	Message string `json:"message,omitempty"`
+}
+
+// OwnershipTransferInfo is the outcome of handing a session over to a new host
+type OwnershipTransferInfo struct {
+	SessionID    string   `json:"sessionId"`
+	NewManagerID string   `json:"newManagerId"`
+	NewManager   string   `json:"newManager"` // nickname
+	Promoted     bool     `json:"promoted"`   // false when the new host already was a manager
+	Role         string   `json:"role"`       // what we are left with: "manager", "member" or "none"
+	Notes        string   `json:"notes"`      // handoff notes, Markdown
+	NotesPath    string   `json:"notesPath,omitempty"`
+	Warnings     []string `json:"warnings"`
+}
+
+// MemberManagementInfo tells which membership changes a server supports
+type MemberManagementInfo struct {
+	CanDemote bool `json:"canDemote"`
+	CanRemove bool `json:"canRemove"`
+}
+
 // =============================================================================
 // USER TYPES
 // =============================================================================
@@ -96,6 +225,24 @@ type UserProfileInfo struct {
 	Message   string `json:"message,omitempty"` // Registration message (for pending users)
 }
 
+// ServerMemberInfo is a user profile enriched with the sessions we share with them
+type ServerMemberInfo struct {
+	ID             string              `json:"id"`
+	Nickname       string              `json:"nickname"`
+	State          string              `json:"state"`
+	IsManager      bool                `json:"isManager"`
+	IsMe           bool                `json:"isMe"`
+	SharedSessions []SharedSessionInfo `json:"sharedSessions"`
+}
+
+// SharedSessionInfo is a minimal view of a session both we and another member belong to
+type SharedSessionInfo struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	IsManager bool   `json:"isManager"` // True if the other member manages this session
+}
+
 // InvitationInfo is the JSON-friendly representation of an invitation
 type InvitationInfo struct {
 	ID              string `json:"id"`
@@ -107,6 +254,24 @@ type InvitationInfo struct {
 	InviteeNickname string `json:"inviteeNickname,omitempty"` // For sent invitations
 }
 
+// IgnoredUserInfo is the JSON-friendly representation of an ignore list entry
+type IgnoredUserInfo struct {
+	UserProfileID string    `json:"userProfileId"`
+	Nickname      string    `json:"nickname"`
+	Mode          string    `json:"mode"` // "hide" or "decline"
+	AddedAt       time.Time `json:"addedAt"`
+}
+
+// AutoDeclinedInvitationInfo is an invitation that was declined because of the ignore list
+type AutoDeclinedInvitationInfo struct {
+	InvitationID    string    `json:"invitationId"`
+	SessionID       string    `json:"sessionId"`
+	SessionName     string    `json:"sessionName"`
+	InviterID       string    `json:"inviterId"`
+	InviterNickname string    `json:"inviterNickname"`
+	DeclinedAt      time.Time `json:"declinedAt"`
+}
+
 // =============================================================================
 // RACE TYPES
 // =============================================================================
@@ -117,6 +282,7 @@ type RaceInfo struct {
 	UserID       string `json:"userId"`
 	NameSingular string `json:"nameSingular"`
 	NamePlural   string `json:"namePlural"`
+	Stale        bool   `json:"stale,omitempty"` // served from the offline cache
 }
 
 // =============================================================================
@@ -160,6 +326,9 @@ type RulesInfo struct {
 	// Victory Condition Meta
 	VcWinnerMustMeet       int `json:"vcWinnerMustMeet"`
 	VcMinYearsBeforeWinner int `json:"vcMinYearsBeforeWinner"`
+
+	// Stale is set when the rules are served from the offline cache
+	Stale bool `json:"stale,omitempty"`
 }
 
 // =============================================================================
@@ -179,6 +348,7 @@ type OrdersStatusInfo struct {
 	SessionID   string                  `json:"sessionId"`
 	PendingYear int                     `json:"pendingYear"`
 	Players     []PlayerOrderStatusInfo `json:"players"`
+	Stale       bool                    `json:"stale,omitempty"` // served from the offline cache
 }
 
 // PlayerOrderStatusInfo represents order submission status for a single player
@@ -189,6 +359,23 @@ type PlayerOrderStatusInfo struct {
 	Submitted   bool   `json:"submitted"`
 }
 
+// TurnGenerationCheckInfo is the result of a host dry-run before starting the game or generating a turn
+type TurnGenerationCheckInfo struct {
+	SessionID   string                      `json:"sessionId"`
+	Stage       string                      `json:"stage"` // "start" before InitializeGame, "turn" once started
+	Year        int                         `json:"year,omitempty"`
+	CanGenerate bool                        `json:"canGenerate"`
+	Blockers    []TurnGenerationBlockerInfo `json:"blockers"`
+}
+
+// TurnGenerationBlockerInfo is a single reason preventing generation
+type TurnGenerationBlockerInfo struct {
+	Code        string `json:"code"`                  // e.g. "not_ready", "order_missing", "order_year_mismatch"
+	PlayerOrder int    `json:"playerOrder,omitempty"` // 1-indexed Stars! player number, 0 if not player specific
+	Nickname    string `json:"nickname,omitempty"`
+	Message     string `json:"message"`
+}
+
 // PlayerControlStatusInfo is the JSON-friendly representation of player control status
 type PlayerControlStatusInfo struct {
 	PlayerOrder   int     `json:"playerOrder"`
@@ -199,6 +386,38 @@ type PlayerControlStatusInfo struct {
 	ControlStatus string  `json:"controlStatus"`           // "human" or "ai"
 }
 
+// ShareSnippetRequest describes game data to turn into a shareable text snippet
+type ShareSnippetRequest struct {
+	Kind    string            `json:"kind"` // "planet", "planets" or "fleet"
+	Year    int               `json:"year,omitempty"`
+	Planets []SharePlanetInfo `json:"planets,omitempty"`
+	Fleet   *ShareFleetInfo   `json:"fleet,omitempty"`
+}
+
+// SharePlanetInfo is a planet position to share
+type SharePlanetInfo struct {
+	Name  string `json:"name"`
+	X     int    `json:"x"`
+	Y     int    `json:"y"`
+	Owner string `json:"owner,omitempty"`
+}
+
+// ShareFleetInfo is a fleet composition to share
+type ShareFleetInfo struct {
+	Name        string          `json:"name"`
+	Owner       string          `json:"owner,omitempty"`
+	X           int             `json:"x"`
+	Y           int             `json:"y"`
+	Destination string          `json:"destination,omitempty"`
+	Ships       []ShareShipInfo `json:"ships"`
+}
+
+// ShareShipInfo is a ship design and count within a shared fleet
+type ShareShipInfo struct {
+	Design string `json:"design"`
+	Count  int    `json:"count"`
+}
+
 // =============================================================================
 // SETTINGS TYPES
 // =============================================================================
@@ -212,6 +431,113 @@ type AppSettingsInfo struct {
 	WinePrefixesDir    string `json:"winePrefixesDir"`
 	ValidWineInstall   bool   `json:"validWineInstall"`
 	EnableBrowserStars bool   `json:"enableBrowserStars"`
+
+	InvitationExpiryDays int  `json:"invitationExpiryDays"` // 0 = never auto-decline
+	InvitationDigest     bool `json:"invitationDigest"`
+
+	LocalAPIEnabled bool `json:"localApiEnabled"`
+	LocalAPIPort    int  `json:"localApiPort"`
+
+	StatusFileEnabled bool   `json:"statusFileEnabled"`
+	StatusFilePath    string `json:"statusFilePath"` // "" = status.json in the profile directory
+
+	SessionFeeds bool `json:"sessionFeeds"`
+
+	ArtifactCollisionPolicy string `json:"artifactCollisionPolicy"` // "overwrite", "timestamp" or "prompt"
+
+	SubmissionConfirmation bool `json:"submissionConfirmation"`
+	SubmissionDigest       bool `json:"submissionDigest"`
+	ReportTurnReads        bool `json:"reportTurnReads"`
+	DeadlineNotifications  bool `json:"deadlineNotifications"`
+
+	WebSocketPingInterval int  `json:"webSocketPingInterval"` // seconds, 0 = no client pings
+	WebSocketPongWait     int  `json:"webSocketPongWait"`     // seconds
+	WebSocketAdaptive     bool `json:"webSocketAdaptive"`
+
+	RaceWorkshopDir string `json:"raceWorkshopDir"` // "" = no workshop folder
+
+	FileMode  string `json:"fileMode"`  // octal, "" = as created
+	DirMode   string `json:"dirMode"`   // octal, "" = as created
+	FileGroup string `json:"fileGroup"` // "" = the user's group
+
+	MinFreeDiskSpaceMB int    `json:"minFreeDiskSpaceMb"` // 0 = not checked
+	LowDiskSpaceAction string `json:"lowDiskSpaceAction"` // "refuse" or "warn"
+
+	OrderDebounceMs int `json:"orderDebounceMs"`
+	OrderSettleMs   int `json:"orderSettleMs"` // 0 = no write-settle check
+
+	LogFormat string `json:"logFormat"` // "console" or "json"
+	LogSyslog bool   `json:"logSyslog"`
+
+	HardDelete bool `json:"hardDelete"` // false = deleted directories go to the trash
+
+	HistoryRetentionYears int `json:"historyRetentionYears"` // past years kept per session, 0 = all
+
+	ConcurrentLaunchPolicy string `json:"concurrentLaunchPolicy"` // "allow", "warn" or "block"
+}
+
+// HookInfo is a command run when a lifecycle event occurs
+type HookInfo struct {
+	Event          string `json:"event"` // "turn-downloaded", "order-uploaded", "game-finished" or "submission-digest"
+	Command        string `json:"command"`
+	TimeoutSeconds int    `json:"timeoutSeconds"`
+	Enabled        bool   `json:"enabled"`
+}
+
+// HookRunInfo is the outcome of a hook run
+type HookRunInfo struct {
+	Event      string    `json:"event"`
+	Command    string    `json:"command"`
+	ServerURL  string    `json:"serverUrl,omitempty"`
+	SessionID  string    `json:"sessionId,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"`
+	ExitCode   int       `json:"exitCode"`
+	TimedOut   bool      `json:"timedOut"`
+	Output     string    `json:"output"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// GeneratedArtifactInfo is a file generated by Astrum in a game directory
+type GeneratedArtifactInfo struct {
+	Kind       string    `json:"kind"` // "map", "gif", "export", "screenshot" or "plugin"
+	Name       string    `json:"name"` // path relative to the game directory
+	Path       string    `json:"path"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modifiedAt"`
+}
+
+// PluginInfo is a turn post-processing plugin found in the plugins directory
+type PluginInfo struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Enabled bool   `json:"enabled"`
+}
+
+// PluginRunInfo is the outcome of a plugin run on a turn
+type PluginRunInfo struct {
+	Plugin    string   `json:"plugin"`
+	Year      int      `json:"year"`
+	ExitCode  int      `json:"exitCode"`
+	TimedOut  bool     `json:"timedOut"`
+	Output    string   `json:"output"`
+	Error     string   `json:"error,omitempty"`
+	Artifacts []string `json:"artifacts"` // paths relative to the session's plugins/ folder
+}
+
+// PluginArtifactInfo is a file produced by a plugin
+type PluginArtifactInfo struct {
+	Plugin string `json:"plugin"`
+	Year   int    `json:"year"`
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+}
+
+// StarsIniEntryInfo is a single value of a Stars! stars.ini file
+type StarsIniEntryInfo struct {
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	Value   string `json:"value"`
 }
 
 // WineCheckResult represents the result of a Wine 32-bit support check
@@ -243,6 +569,26 @@ type MapOptions struct {
 	ShowWormholes       bool `json:"showWormholes"`
 	ShowLegend          bool `json:"showLegend"`
 	ShowScannerCoverage bool `json:"showScannerCoverage"`
+
+	Theme       string        `json:"theme,omitempty"`       // Built-in theme name ("classic", "dark", "light", "colorblind")
+	CustomTheme *MapThemeInfo `json:"customTheme,omitempty"` // Overrides Theme when set
+}
+
+// EmpireColorInfo is the map color of a player in a session
+type EmpireColorInfo struct {
+	PlayerNumber int    `json:"playerNumber"`
+	Name         string `json:"name,omitempty"`
+	Color        string `json:"color"`  // "#rrggbb"
+	Custom       bool   `json:"custom"` // pinned in the session registry rather than from the theme
+}
+
+// MapThemeInfo is a set of map colors given as "#rrggbb"
+type MapThemeInfo struct {
+	Name       string   `json:"name,omitempty"`
+	Background string   `json:"background"`
+	Foreground string   `json:"foreground"`
+	Year       string   `json:"year"`
+	Empire     []string `json:"empire"` // Player colors by player number, empty keeps the classic palette
 }
 
 // MapGenerateRequest contains the data needed to generate a map
@@ -251,8 +597,64 @@ type MapGenerateRequest struct {
 	SessionID   string     `json:"sessionId"`
 	Year        int        `json:"year"`
 	Options     MapOptions `json:"options"`
-	UniverseB64 string     `json:"universeB64"` // Base64 encoded .xy file
-	TurnB64     string     `json:"turnB64"`     // Base64 encoded .mN file
+	Preset      string     `json:"preset,omitempty"` // Named preset overriding Options
+	UniverseB64 string     `json:"universeB64"`      // Base64 encoded .xy file
+	TurnB64     string     `json:"turnB64"`          // Base64 encoded .mN file
+}
+
+// MapDataInfo describes the objects drawn on a map, in map pixels
+type MapDataInfo struct {
+	Width   int             `json:"width"`
+	Height  int             `json:"height"`
+	Year    int             `json:"year"`
+	Scale   float64         `json:"scale"` // Pixels per light-year
+	Planets []MapPlanetData `json:"planets"`
+	Fleets  []MapFleetData  `json:"fleets"` // Empty unless the options show fleets
+}
+
+// MapPlanetData is a planet drawn on the map
+type MapPlanetData struct {
+	ID          int     `json:"id"` // Planet number
+	Name        string  `json:"name"`
+	Owner       int     `json:"owner"` // -1 when unowned
+	OwnerName   string  `json:"ownerName,omitempty"`
+	X           int     `json:"x"` // Game coordinates
+	Y           int     `json:"y"`
+	PX          float64 `json:"px"` // Map pixels
+	PY          float64 `json:"py"`
+	Radius      float64 `json:"radius"` // Drawn radius in pixels
+	HasStarbase bool    `json:"hasStarbase"`
+	Intel       string  `json:"intel,omitempty"` // Sender of the imported intel the owner comes from, empty when seen by us
+}
+
+// MapFleetData is a fleet drawn on the map
+type MapFleetData struct {
+	ID          string  `json:"id"` // "owner:fleetNumber"
+	Owner       int     `json:"owner"`
+	OwnerName   string  `json:"ownerName,omitempty"`
+	FleetNumber int     `json:"fleetNumber"`
+	Name        string  `json:"name"`
+	Ships       int     `json:"ships"`
+	Warp        int     `json:"warp"`
+	X           int     `json:"x"` // Game coordinates
+	Y           int     `json:"y"`
+	PX          float64 `json:"px"` // Map pixels
+	PY          float64 `json:"py"`
+	Radius      float64 `json:"radius"`          // Hit radius in pixels
+	Intel       string  `json:"intel,omitempty"` // Sender of the imported intel showing the fleet, empty when seen by us
+}
+
+// MapPresetInfo is a named set of map options
+type MapPresetInfo struct {
+	Name    string     `json:"name"`
+	Options MapOptions `json:"options"`
+}
+
+// SessionMapSettingsInfo holds the map preferences of a session
+type SessionMapSettingsInfo struct {
+	DefaultPreset string `json:"defaultPreset"`
+	AutoGenerate  bool   `json:"autoGenerate"`
+	AutoPNG       bool   `json:"autoPng"`
 }
 
 // MapSaveRequest contains the data needed to save a map
@@ -263,6 +665,7 @@ type MapSaveRequest struct {
 	RaceName     string `json:"raceName"`
 	PlayerNumber int    `json:"playerNumber"`
 	SVGContent   string `json:"svgContent"`
+	Overwrite    bool   `json:"overwrite"` // replace an existing file regardless of the collision policy
 }
 
 // AnimatedMapRequest contains the data needed to generate an animated GIF map
@@ -273,6 +676,28 @@ type AnimatedMapRequest struct {
 	Delay     int        `json:"delay"` // Frame delay in milliseconds
 }
 
+// OperationRequest describes a long operation to start, see StartOperation
+type OperationRequest struct {
+	Kind        string              `json:"kind"` // "session_backup", "historic_backup", "turn_download" or "animated_map"
+	ServerURL   string              `json:"serverUrl"`
+	SessionID   string              `json:"sessionId"`
+	AnimatedMap *AnimatedMapRequest `json:"animatedMap,omitempty"` // Map to render, for "animated_map"
+}
+
+// OperationInfo is the state of a long operation
+type OperationInfo struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	ServerURL string `json:"serverUrl"`
+	SessionID string `json:"sessionId"`
+	Status    string `json:"status"`          // "running", "done", "failed" or "cancelled"
+	Done      int    `json:"done"`            // Steps done
+	Total     int    `json:"total"`           // Steps to do, 0 while unknown
+	Message   string `json:"message"`         // What is being done, e.g. "Downloading 2403"
+	Error     string `json:"error,omitempty"` // Why the operation failed
+	StartedAt string `json:"startedAt"`       // RFC 3339
+}
+
 // GifSaveRequest contains the data needed to save a GIF
 type GifSaveRequest struct {
 	ServerURL    string `json:"serverUrl"`
@@ -280,4 +705,817 @@ type GifSaveRequest struct {
 	RaceName     string `json:"raceName"`
 	PlayerNumber int    `json:"playerNumber"`
 	GifContent   string `json:"gifContent"` // Base64 encoded GIF
+	Overwrite    bool   `json:"overwrite"`  // replace an existing file regardless of the collision policy
+}
+
+// RoutePoint is a point of a measured route, in game coordinates (light-years)
+type RoutePoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// RouteFleetRef identifies a fleet of the latest local turn
+type RouteFleetRef struct {
+	Owner       int `json:"owner"`
+	FleetNumber int `json:"fleetNumber"`
+}
+
+// RouteMeasureRequest is a route to measure at a warp speed
+type RouteMeasureRequest struct {
+	ServerURL string         `json:"serverUrl"`
+	SessionID string         `json:"sessionId"`
+	Points    []RoutePoint   `json:"points"`
+	Warp      int            `json:"warp"`
+	Fuel      bool           `json:"fuel"`             // Compute fuel usage
+	Fleet     *RouteFleetRef `json:"fleet,omitempty"`  // Fleet giving mass, engine and fuel on board
+	Engine    string         `json:"engine,omitempty"` // Engine name when no fleet is given
+	Mass      int64          `json:"mass,omitempty"`   // Mass in kT when no fleet is given
+	IFE       bool           `json:"ife,omitempty"`    // Improved Fuel Efficiency when no fleet is given
+}
+
+// RouteLegInfo is one leg of a measured route
+type RouteLegInfo struct {
+	From        RoutePoint `json:"from"`
+	To          RoutePoint `json:"to"`
+	Distance    float64    `json:"distance"`       // Light-years
+	ArrivalYear int        `json:"arrivalYear"`    // Years from departure to reach To
+	Fuel        int64      `json:"fuel,omitempty"` // mg burned on this leg
+}
+
+// RouteFuelInfo is the fuel usage of a measured route
+type RouteFuelInfo struct {
+	Engine    string `json:"engine"`
+	Mass      int64  `json:"mass"` // kT
+	IFE       bool   `json:"ife"`
+	Used      int64  `json:"used"`      // mg
+	Available int64  `json:"available"` // mg on board, -1 when unknown
+	Enough    bool   `json:"enough"`
+}
+
+// RouteMeasureInfo is the result of MeasureRoute
+type RouteMeasureInfo struct {
+	Warp          int            `json:"warp"`
+	Legs          []RouteLegInfo `json:"legs"`
+	TotalDistance float64        `json:"totalDistance"` // Light-years
+	TotalYears    int            `json:"totalYears"`
+	Fuel          *RouteFuelInfo `json:"fuel,omitempty"`
+}
+
+// IntelFilterOptions selects what an exported intel packet shares
+type IntelFilterOptions struct {
+	Planets       bool  `json:"planets"`       // Share planets
+	Fleets        bool  `json:"fleets"`        // Share fleets
+	IncludeOwn    bool  `json:"includeOwn"`    // Share my own planets and fleets
+	Installations bool  `json:"installations"` // Share population, mines, factories and defenses
+	Environment   bool  `json:"environment"`   // Share mineral concentrations and habitability
+	Owners        []int `json:"owners"`        // Only share objects of these player numbers (-1 = unowned), empty shares all
+}
+
+// IntelPacketInfo summarizes an intel packet
+type IntelPacketInfo struct {
+	Sender      string `json:"sender"`
+	Fingerprint string `json:"fingerprint"` // Identifies the sender's signing key
+	Year        int    `json:"year"`
+	ExportedAt  string `json:"exportedAt"`
+	Planets     int    `json:"planets"`
+	Fleets      int    `json:"fleets"`
+	Path        string `json:"path"`
+}
+
+// IntelDataInfo is the content of an imported intel packet
+type IntelDataInfo struct {
+	Packet  IntelPacketInfo   `json:"packet"`
+	Planets []IntelPlanetInfo `json:"planets"`
+	Fleets  []IntelFleetInfo  `json:"fleets"`
+}
+
+// IntelPlanetInfo is a planet as known by the sender of an intel packet.
+// Installations and environment are 0 when not shared.
+type IntelPlanetInfo struct {
+	Number      int    `json:"number"`
+	Name        string `json:"name"`
+	X           int    `json:"x"`
+	Y           int    `json:"y"`
+	Owner       int    `json:"owner"` // -1 when unowned
+	Starbase    bool   `json:"starbase"`
+	Population  int64  `json:"population"`
+	Mines       int    `json:"mines"`
+	Factories   int    `json:"factories"`
+	Defenses    int    `json:"defenses"`
+	Ironium     int    `json:"ironium"` // Concentrations
+	Boranium    int    `json:"boranium"`
+	Germanium   int    `json:"germanium"`
+	Gravity     int    `json:"gravity"`
+	Temperature int    `json:"temperature"`
+	Radiation   int    `json:"radiation"`
+}
+
+// IntelFleetInfo is a fleet as known by the sender of an intel packet
+type IntelFleetInfo struct {
+	Owner  int    `json:"owner"`
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Ships  int    `json:"ships"`
+	Warp   int    `json:"warp"`
+	DeltaX int    `json:"deltaX"`
+	DeltaY int    `json:"deltaY"`
+}
+
+// SubmissionRecordInfo is an order upload of the submission log
+type SubmissionRecordInfo struct {
+	ServerURL   string `json:"serverUrl"`
+	SessionID   string `json:"sessionId"`
+	SessionName string `json:"sessionName"`
+	Year        int    `json:"year"`
+	UploadedAt  string `json:"uploadedAt"` // RFC 3339
+}
+
+// TimelineEventInfo is an event of a session timeline
+type TimelineEventInfo struct {
+	Type   string `json:"type"` // "turn-generated", "order-submitted", "player-joined", "player-left", "rules-changed" or "backup"
+	Time   string `json:"time"` // RFC 3339, UTC
+	Year   int    `json:"year,omitempty"`
+	Title  string `json:"title"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// DiagnosticsInfo is a snapshot of the app internals for troubleshooting
+type DiagnosticsInfo struct {
+	QueueDepth       int                `json:"queueDepth"`       // Background tasks waiting to run
+	QueuedByPriority map[string]int     `json:"queuedByPriority"` // "high", "normal" and "low" -> waiting tasks
+	QueueRunning     int                `json:"queueRunning"`     // Background tasks running
+	Goroutines       int                `json:"goroutines"`
+	Servers          int                `json:"servers"`      // Connected servers
+	WebSockets       int                `json:"webSockets"`   // Connected notification channels
+	SilentDeaths     int                `json:"silentDeaths"` // Notification channels found silently dead and reconnected, since connected
+	Startup          *StartupTimingInfo `json:"startup"`
+}
+
+// TurnReconcileInfo summarizes the turns downloaded after being missed
+type TurnReconcileInfo struct {
+	Turns    int      `json:"turns"`    // Years downloaded
+	Games    int      `json:"games"`    // Sessions that had missed turns
+	Sessions []string `json:"sessions"` // IDs of those sessions
+	Message  string   `json:"message"`  // e.g. "3 new turns downloaded across 2 games"
+}
+
+// ConfigChangeInfo is a field changed by a configuration mutation
+type ConfigChangeInfo struct {
+	Field string `json:"field"`
+	Old   string `json:"old"` // JSON encoded, empty when unset
+	New   string `json:"new"` // JSON encoded, empty when unset
+}
+
+// ConfigAuditEntryInfo is an entry of the configuration audit log
+type ConfigAuditEntryInfo struct {
+	Time    string             `json:"time"`   // RFC 3339
+	Kind    string             `json:"kind"`   // "settings", "server" or "credentials"
+	Action  string             `json:"action"` // "add", "update", "move" or "remove"
+	Target  string             `json:"target"` // Server URL, empty for app settings
+	Source  string             `json:"source"` // Function that made the change, e.g. "main.(*App).SetUseWine"
+	Changes []ConfigChangeInfo `json:"changes"`
+}
+
+// ProfilesInfo lists the configuration profiles
+type ProfilesInfo struct {
+	Active   string   `json:"active"`
+	Profiles []string `json:"profiles"` // "default" first, then by name
+}
+
+// KioskStatusInfo is the guest mode state
+type KioskStatusInfo struct {
+	PinSet bool `json:"pinSet"`
+	Locked bool `json:"locked"` // Destructive actions are disabled
+}
+
+// SystemAccessibilityInfo is the accessibility preferences of the operating system
+type SystemAccessibilityInfo struct {
+	ReducedMotion bool `json:"reducedMotion"` // Animations should be avoided
+	HighContrast  bool `json:"highContrast"`  // A high contrast theme is in use
+}
+
+// EnvironmentReportInfo describes the platform and WebView for bug reports
+type EnvironmentReportInfo struct {
+	OS             string            `json:"os"`
+	Arch           string            `json:"arch"`
+	GoVersion      string            `json:"goVersion"`
+	BuildType      string            `json:"buildType"`      // "production", "debug" or "dev"
+	WebView        string            `json:"webView"`        // "WebKitGTK", "WebView2" or "WKWebView"
+	WebViewVersion string            `json:"webViewVersion"` // Empty when it couldn't be read
+	SafeMode       bool              `json:"safeMode"`       // GPU acceleration disabled
+	Profile        string            `json:"profile"`
+	Environment    map[string]string `json:"environment"` // Set variables affecting rendering
+}
+
+// TelemetryPreviewInfo is the telemetry settings and the report that would be sent
+type TelemetryPreviewInfo struct {
+	Enabled  bool   `json:"enabled"`
+	Endpoint string `json:"endpoint"`
+	Payload  string `json:"payload"`  // JSON report, exactly as it would be sent
+	LastSent string `json:"lastSent"` // RFC 3339, empty if never sent
+}
+
+// SelfTestCheckInfo is the outcome of one self-test check
+type SelfTestCheckInfo struct {
+	Name       string `json:"name"`             // e.g. "database", "keyring", "wine", "reachability"
+	Target     string `json:"target,omitempty"` // Server URL for per-server checks
+	Status     string `json:"status"`           // "pass", "fail" or "skip"
+	Message    string `json:"message"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// UploadFindingInfo is one check of DiagnoseUploadFailure
+type UploadFindingInfo struct {
+	Check      string `json:"check"`  // e.g. "connection", "order_file", "year", "conflict", "server"
+	Status     string `json:"status"` // "pass", "fail" or "skip"
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"` // How to fix a failed check
+}
+
+// UploadDiagnosisInfo is the result of DiagnoseUploadFailure
+type UploadDiagnosisInfo struct {
+	SessionID string              `json:"sessionId"`
+	Year      int                 `json:"year"`
+	Cause     string              `json:"cause,omitempty"` // Check of the first failure, the likely cause
+	Findings  []UploadFindingInfo `json:"findings"`
+}
+
+// SelfTestReportInfo is the result of RunSelfTest
+type SelfTestReportInfo struct {
+	StartedAt string              `json:"startedAt"` // RFC 3339
+	Passed    bool                `json:"passed"`    // No check failed
+	Checks    []SelfTestCheckInfo `json:"checks"`
+}
+
+// RemoveServerOptions selects the cleanups done when removing a server
+type RemoveServerOptions struct {
+	DeleteCredentials bool   `json:"deleteCredentials"` // Delete keyring credentials and serial key
+	GameDirs          string `json:"gameDirs"`          // "keep" (default), "archive" or "delete"
+	DeleteWinePrefix  bool   `json:"deleteWinePrefix"`
+	DryRun            bool   `json:"dryRun"` // Only report what would be done
+}
+
+// ServerRemovalInfo summarizes a server removal, done or planned
+type ServerRemovalInfo struct {
+	DryRun      bool     `json:"dryRun"`
+	ServerURL   string   `json:"serverUrl"`
+	ServerName  string   `json:"serverName"`
+	Credentials []string `json:"credentials"` // Usernames whose credentials are deleted
+	GameDirs    string   `json:"gameDirs"`    // "keep", "archive" or "delete"
+	GameDir     string   `json:"gameDir"`     // Server directory, empty if it doesn't exist
+	Sessions    int      `json:"sessions"`    // Session directories in GameDir
+	ArchivedTo  string   `json:"archivedTo"`  // Archive path, once archived
+	WinePrefix  string   `json:"winePrefix"`  // Wine prefix deleted, empty if none
+	Steps       []string `json:"steps"`       // Human-readable actions, in order
+	Errors      []string `json:"errors"`      // Cleanups that failed after the server was removed
+}
+
+// ResetOptions selects what ResetApplicationData wipes
+type ResetOptions struct {
+	Scope        string `json:"scope"`        // "settings", "hashes", "servers" or "everything"
+	Confirmation string `json:"confirmation"` // Typed by the user, see GetResetConfirmation
+}
+
+// ResetSummaryInfo summarizes a reset of the application data
+type ResetSummaryInfo struct {
+	Scope           string   `json:"scope"`
+	ExportedTo      string   `json:"exportedTo"`      // Configuration exported before the reset
+	Steps           []string `json:"steps"`           // Human-readable actions, in order
+	Errors          []string `json:"errors"`          // Wipes that failed
+	RestartRequired bool     `json:"restartRequired"` // The reset settings apply from the next start
+}
+
+// AutoConnectInfo is the startup connection status of a server
+type AutoConnectInfo struct {
+	ServerURL string `json:"serverUrl"`
+	Status    string `json:"status"`          // "connecting", "connected", "locked" or "failed"
+	Error     string `json:"error,omitempty"` // Why the connection failed
+}
+
+// KeyringStatusInfo tells whether the stored credentials can be read
+type KeyringStatusInfo struct {
+	Locked bool     `json:"locked"`          // the keyring could not be read, it is waited for
+	Held   []string `json:"held,omitempty"`  // URLs of the startup connections waiting for it
+	Error  string   `json:"error,omitempty"` // why the keyring could not be read
+}
+
+// RaceDeletionInfo is the outcome of a bulk race deletion
+type RaceDeletionInfo struct {
+	Deleted []string          `json:"deleted"` // IDs of the deleted races
+	Failed  map[string]string `json:"failed"`  // Error by race ID
+}
+
+// DetectedRaceInfo is a race file found in a watched folder, offered for upload
+type DetectedRaceInfo struct {
+	Path         string   `json:"path"`
+	ServerURL    string   `json:"serverUrl,omitempty"` // server of the game directory, empty for the workshop folder
+	SessionID    string   `json:"sessionId,omitempty"`
+	NameSingular string   `json:"nameSingular"`
+	NamePlural   string   `json:"namePlural"`
+	Valid        bool     `json:"valid"`
+	Errors       []string `json:"errors"` // why the race can't be used, when not valid
+}
+
+// GenerationStatusInfo is the progress of a turn generation followed for the host
+type GenerationStatusInfo struct {
+	ServerURL string    `json:"serverUrl"`
+	SessionID string    `json:"sessionId"`
+	FromYear  int       `json:"fromYear"`         // latest year before generation, 0 when starting the game
+	Year      int       `json:"year,omitempty"`   // generated year, once done
+	Status    string    `json:"status"`           // "queued", "generating", "done" or "failed"
+	Reason    string    `json:"reason,omitempty"` // why generation failed
+	StartedAt time.Time `json:"startedAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// GenerationIssueInfo is a likely cause of a failed turn generation
+type GenerationIssueInfo struct {
+	Code         string `json:"code"`                   // e.g. "host_file_missing", "order_corrupt"
+	PlayerNumber int    `json:"playerNumber,omitempty"` // 1-indexed player the issue is about, 0 for the game
+	Message      string `json:"message"`
+	Suggestion   string `json:"suggestion"` // what the host can do about it
+}
+
+// GenerationDiagnosticsInfo explains why the turn generation of a session failed
+type GenerationDiagnosticsInfo struct {
+	SessionID  string                `json:"sessionId"`
+	Year       int                   `json:"year"`
+	Generation *GenerationStatusInfo `json:"generation,omitempty"` // last followed generation, if any
+	Issues     []GenerationIssueInfo `json:"issues"`
+	Note       string                `json:"note"`
+}
+
+// SessionHealthInfo lists the discrepancies found in the files of a running game
+type SessionHealthInfo struct {
+	SessionID string                `json:"sessionId"`
+	Year      int                   `json:"year"`
+	Healthy   bool                  `json:"healthy"`
+	Issues    []GenerationIssueInfo `json:"issues"`
+}
+
+// LowDiskSpaceInfo is emitted as "disk:low" when a write goes ahead with little space left
+type LowDiskSpaceInfo struct {
+	Path        string `json:"path"`
+	AvailableMB int64  `json:"availableMb"`
+	RequiredMB  int64  `json:"requiredMb"`
+	Operation   string `json:"operation"` // e.g. "backup", "turn download"
+}
+
+// OrderVersionInfo is an order file archived when it was uploaded
+type OrderVersionInfo struct {
+	Version    string `json:"version"` // archived file name, e.g. "2405-20261016-150405.x3"
+	Year       int    `json:"year"`
+	Player     int    `json:"player"` // 1-indexed
+	Hash       string `json:"hash"`   // SHA-256
+	Size       int    `json:"size"`
+	ArchivedAt string `json:"archivedAt"`           // RFC 3339
+	Status     string `json:"status"`               // "pending", "uploaded" or "failed"
+	UploadedAt string `json:"uploadedAt,omitempty"` // RFC 3339
+	Error      string `json:"error,omitempty"`
+}
+
+// YearDiffInfo is what changed in a session between two years, as seen in
+// the player's turn files
+type YearDiffInfo struct {
+	SessionID string                  `json:"sessionId"`
+	YearA     int                     `json:"yearA"`
+	YearB     int                     `json:"yearB"`
+	Players   []PlayerYearDeltaInfo   `json:"players"`
+	Planets   []PlanetOwnerChangeInfo `json:"planets"`
+	Fleets    []FleetChangeInfo       `json:"fleets"`
+	Tech      []TechChangeInfo        `json:"tech"`
+}
+
+// PlayerYearDeltaInfo is the score of a player in both years of a diff
+type PlayerYearDeltaInfo struct {
+	Player         string `json:"player"` // race name (plural)
+	ScoreA         int    `json:"scoreA"`
+	ScoreB         int    `json:"scoreB"`
+	ScoreDelta     int    `json:"scoreDelta"`
+	RankA          int    `json:"rankA"`
+	RankB          int    `json:"rankB"`
+	PlanetsDelta   int    `json:"planetsDelta"`
+	ResourcesDelta int64  `json:"resourcesDelta"`
+	ShipsDelta     int    `json:"shipsDelta"`
+}
+
+// PlanetOwnerChangeInfo is a planet that changed hands between two years
+type PlanetOwnerChangeInfo struct {
+	PlanetNumber int    `json:"planetNumber"`
+	Name         string `json:"name"`
+	From         string `json:"from"` // "" = unowned
+	To           string `json:"to"`   // "" = unowned
+}
+
+// FleetChangeInfo is a fleet that appeared or disappeared between two years
+type FleetChangeInfo struct {
+	Owner       string `json:"owner"`
+	FleetNumber int    `json:"fleetNumber"`
+	Name        string `json:"name"`
+	Ships       int    `json:"ships"`
+	Change      string `json:"change"` // "built" or "destroyed"
+}
+
+// TechChangeInfo is a tech field of a player whose level changed between two years
+type TechChangeInfo struct {
+	Player string `json:"player"`
+	Field  string `json:"field"` // "energy", "weapons", "propulsion", "construction", "electronics" or "biotech"
+	From   int    `json:"from"`
+	To     int    `json:"to"`
+}
+
+// TurnHintInfo is a likely mistake found in the player's turn
+type TurnHintInfo struct {
+	Kind         string `json:"kind"` // "idle-fleet", "empty-queue", "no-research" or "overpopulated"
+	Message      string `json:"message"`
+	PlanetNumber int    `json:"planetNumber,omitempty"`
+	FleetNumber  int    `json:"fleetNumber,omitempty"` // 0-indexed, check the kind before reading it
+}
+
+// ShipDesignInfo is a ship design with the figures used by the fuel calculator
+type ShipDesignInfo struct {
+	DesignNumber  int    `json:"designNumber"`
+	Name          string `json:"name"`
+	Hull          string `json:"hull"`
+	Mass          int    `json:"mass"` // kT, empty
+	FuelCapacity  int    `json:"fuelCapacity"`
+	CargoCapacity int    `json:"cargoCapacity"`
+	HasEngine     bool   `json:"hasEngine"`
+	Engine        string `json:"engine"`
+	SafeWarp      int    `json:"safeWarp"`
+	FreeWarp      int    `json:"freeWarp"`  // fastest warp that burns no fuel
+	FuelTable     []int  `json:"fuelTable"` // efficiency by warp 0-10, 100 = 100%
+}
+
+// FuelUsageInfo is the result of a fuel calculation for a fleet
+type FuelUsageInfo struct {
+	Mass         int  `json:"mass"` // kT, ships and cargo
+	Fuel         int  `json:"fuel"` // mg
+	FuelCapacity int  `json:"fuelCapacity"`
+	Enough       bool `json:"enough"`
+	Turns        int  `json:"turns"`
+	Efficiency   int  `json:"efficiency"`
+	IFE          bool `json:"ife"`
+	Safe         bool `json:"safe"` // false when the engine takes damage at this warp
+}
+
+// TechTreeInfo is the component and hull catalog, with the player's tech
+// levels when it was requested for a session
+type TechTreeInfo struct {
+	Items  []TechItemInfo  `json:"items"`
+	Levels *TechLevelsInfo `json:"levels,omitempty"`
+}
+
+// TechItemInfo is a component or hull of the tech tree
+type TechItemInfo struct {
+	Category     string         `json:"category"`
+	ID           int            `json:"id"`
+	Name         string         `json:"name"`
+	Mass         int            `json:"mass"`
+	Requirements TechLevelsInfo `json:"requirements"`
+	Cost         TechCostInfo   `json:"cost"`
+	Stats        map[string]any `json:"stats"`               // item specific, keyed by Houston field name
+	Available    *bool          `json:"available,omitempty"` // nil when no session was given
+}
+
+// TechLevelsInfo holds a level for each of the six tech fields
+type TechLevelsInfo struct {
+	Energy       int `json:"energy"`
+	Weapons      int `json:"weapons"`
+	Propulsion   int `json:"propulsion"`
+	Construction int `json:"construction"`
+	Electronics  int `json:"electronics"`
+	Biotech      int `json:"biotech"`
+}
+
+// TechCostInfo is the build cost of a component
+type TechCostInfo struct {
+	Resources int `json:"resources"`
+	Ironium   int `json:"ironium"`
+	Boranium  int `json:"boranium"`
+	Germanium int `json:"germanium"`
+}
+
+// ProductionItemInfo is an entry of a planned production queue
+type ProductionItemInfo struct {
+	Kind  string `json:"kind"` // "standard" or "design"
+	ID    int    `json:"id"`   // standard item ID or design number (0-15)
+	Count int    `json:"count"`
+}
+
+// ProductionTemplateInfo is a named, reusable production queue
+type ProductionTemplateInfo struct {
+	Name  string               `json:"name"`
+	Items []ProductionItemInfo `json:"items"`
+}
+
+// PlanetProductionPlanInfo is the queue planned for a planet, either a
+// template or its own items
+type PlanetProductionPlanInfo struct {
+	PlanetNumber int                  `json:"planetNumber"`
+	Template     string               `json:"template,omitempty"`
+	Items        []ProductionItemInfo `json:"items,omitempty"`
+}
+
+// ProductionDriftReportInfo compares the production plans of a session with
+// the queues of a turn
+type ProductionDriftReportInfo struct {
+	Year    int               `json:"year"`
+	Planets []PlanetDriftInfo `json:"planets"`
+}
+
+// PlanetDriftInfo is the comparison of a planet's plan with its queue
+type PlanetDriftInfo struct {
+	PlanetNumber int                       `json:"planetNumber"`
+	Name         string                    `json:"name"`
+	Template     string                    `json:"template,omitempty"`
+	InSync       bool                      `json:"inSync"`
+	Problem      string                    `json:"problem,omitempty"` // set when the plan can't be compared
+	Items        []ProductionDriftItemInfo `json:"items"`
+}
+
+// ProductionDriftItemInfo is an item queued in a different count than planned
+type ProductionDriftItemInfo struct {
+	Kind    string `json:"kind"`
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Planned int    `json:"planned"` // 0 = queued but not planned
+	Queued  int    `json:"queued"`  // 0 = planned but missing from the queue
+}
+
+// EconomyAssumptionsInfo are the player's choices for an economy projection
+type EconomyAssumptionsInfo struct {
+	ResearchPercent *int `json:"researchPercent,omitempty"` // nil keeps the turn's research budget
+	BuildPercent    int  `json:"buildPercent"`              // share of the other resources spent on factories and mines
+}
+
+// EconomyYearInfo is the projected state of the empire at the end of a year
+type EconomyYearInfo struct {
+	Year       int   `json:"year"`
+	Planets    int   `json:"planets"`
+	Population int64 `json:"population"`
+	Resources  int   `json:"resources"`
+	Research   int   `json:"research"`
+	Factories  int   `json:"factories"`
+	Mines      int   `json:"mines"`
+	Ironium    int64 `json:"ironium"` // kT mined during the year
+	Boranium   int64 `json:"boranium"`
+	Germanium  int64 `json:"germanium"`
+}
+
+// ActionableSessionInfo is a session awaiting the user's orders
+type ActionableSessionInfo struct {
+	ServerURL   string `json:"serverUrl"`
+	SessionID   string `json:"sessionId"`
+	SessionName string `json:"sessionName"`
+	Year        int    `json:"year"` // year the orders are for
+}
+
+// ServerAnnouncementInfo is a message of the server admins
+type ServerAnnouncementInfo struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Severity  string    `json:"severity,omitempty"` // "info", "warning" or "critical"
+	UpdatedAt time.Time `json:"updatedAt"`
+	Read      bool      `json:"read"`
+	Stale     bool      `json:"stale,omitempty"` // served from the offline cache
+}
+
+// ServerStatusInfo is the health of a server and its planned maintenance windows
+type ServerStatusInfo struct {
+	Status        string                  `json:"status"` // "ok", "degraded" or "maintenance"
+	Message       string                  `json:"message,omitempty"`
+	InMaintenance bool                    `json:"inMaintenance"`         // syncs are deferred meanwhile
+	Maintenance   []MaintenanceWindowInfo `json:"maintenance,omitempty"` // current and upcoming windows
+	Supported     bool                    `json:"supported"`             // false when the server has no status endpoint
+}
+
+// MaintenanceWindowInfo is a period a server is planned to be unavailable
+type MaintenanceWindowInfo struct {
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Reason string    `json:"reason,omitempty"`
+	Active bool      `json:"active"`
+}
+
+// SessionArchiveInfo describes an exported or imported session archive
+type SessionArchiveInfo struct {
+	ServerURL   string    `json:"serverUrl"`
+	SessionID   string    `json:"sessionId"`
+	SessionName string    `json:"sessionName,omitempty"`
+	ExportedAt  time.Time `json:"exportedAt"`
+	GameDir     string    `json:"gameDir"`
+	Files       int       `json:"files"` // game directory files in the archive
+}
+
+// SessionSyncInfo tells whether the local copy of a session is behind the server
+type SessionSyncInfo struct {
+	LocalYear       int        `json:"localYear"` // 0 without a local turn
+	ServerYear      int        `json:"serverYear"`
+	TurnStale       bool       `json:"turnStale"`       // the server has a newer turn
+	OrdersSubmitted bool       `json:"ordersSubmitted"` // the user's orders of the server year were received
+	OrdersElsewhere bool       `json:"ordersElsewhere"` // ...from another machine
+	SubmittedFrom   string     `json:"submittedFrom,omitempty"`
+	SubmittedAt     *time.Time `json:"submittedAt,omitempty"`
+	Stale           bool       `json:"stale"`
+	Supported       bool       `json:"supported"` // false when the server has no sync tokens
+}
+
+// TurnYearCheckInfo reports local years of a game the server cannot explain,
+// and a local clock far from the server's
+type TurnYearCheckInfo struct {
+	SessionID        string              `json:"sessionId"`
+	ServerYear       int                 `json:"serverYear"`
+	TurnYear         int                 `json:"turnYear,omitempty"`         // 0 without a local turn
+	OrderYear        int                 `json:"orderYear,omitempty"`        // 0 without a local order file
+	ClockSkewSeconds int64               `json:"clockSkewSeconds,omitempty"` // server clock minus local clock
+	OK               bool                `json:"ok"`
+	Issues           []TurnYearIssueInfo `json:"issues"`
+}
+
+// TurnYearIssueInfo is an impossible situation found by the turn year check
+type TurnYearIssueInfo struct {
+	Code       string `json:"code"` // "turn_ahead", "order_ahead" or "clock_skew"
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion"`
+}
+
+// DeadlineInfo is a nearing turn deadline of a session where our orders are
+// not uploaded, from the turn schedule set by the host
+type DeadlineInfo struct {
+	SessionID        string `json:"sessionId"`
+	SessionName      string `json:"sessionName"`
+	Year             int    `json:"year"` // Pending year
+	DeadlineAt       string `json:"deadlineAt"`
+	RemainingSeconds int64  `json:"remainingSeconds"` // Negative once the deadline passed
+	CadenceHours     int    `json:"cadenceHours"`     // 0 when the schedule doesn't tell
+	Level            string `json:"level"`            // "notice", "warning" or "critical", "" once the orders are uploaded
+}
+
+// GameStatsInfo are the end-of-game statistics submitted to a server leaderboard
+type GameStatsInfo struct {
+	SessionID string                `json:"sessionId"`
+	FinalYear int                   `json:"finalYear"`
+	Years     int                   `json:"years"`   // Years played
+	Players   []GameStatsPlayerInfo `json:"players"` // Players whose score is known
+}
+
+// GameStatsPlayerInfo is the final standing of a player
+type GameStatsPlayerInfo struct {
+	PlayerOrder int    `json:"playerOrder"`   // 0-indexed
+	Race        string `json:"race"`          // Plural name
+	PRT         string `json:"prt,omitempty"` // Primary racial trait code, empty when unknown
+	Score       int    `json:"score"`
+	Rank        int    `json:"rank"` // 0 when unknown
+}
+
+// LeaderboardEntryInfo is a line of a server leaderboard
+type LeaderboardEntryInfo struct {
+	Rank        int    `json:"rank"`
+	Nickname    string `json:"nickname"`
+	Games       int    `json:"games"`
+	Wins        int    `json:"wins"`
+	BestScore   int    `json:"bestScore"`
+	FavoritePRT string `json:"favoritePrt,omitempty"`
+	LastGameAt  string `json:"lastGameAt,omitempty"` // RFC 3339
+}
+
+// TurnReadStatusInfo is the orders status of a year with which players opened their turn
+type TurnReadStatusInfo struct {
+	SessionID string               `json:"sessionId"`
+	Year      int                  `json:"year"`
+	Players   []PlayerTurnReadInfo `json:"players"`
+	Supported bool                 `json:"supported"` // false when the server has no read receipts
+}
+
+// PlayerTurnReadInfo tells whether a player opened their turn and submitted orders
+type PlayerTurnReadInfo struct {
+	PlayerOrder int        `json:"playerOrder"`
+	Nickname    string     `json:"nickname"`
+	IsBot       bool       `json:"isBot"`
+	Submitted   bool       `json:"submitted"`
+	OpenedAt    *time.Time `json:"openedAt,omitempty"`
+	State       string     `json:"state"` // "not-opened", "opened" or "submitted"
+}
+
+// LogConfigInfo is the log level of each component and the log file
+type LogConfigInfo struct {
+	Components []LogComponentInfo `json:"components"`
+	File       string             `json:"file"` // "" when the log goes to the console only
+}
+
+// LogComponentInfo is the log level of a component
+type LogComponentInfo struct {
+	Name  string `json:"name"`  // "main", "app", "monitor", "websocket"...
+	Level string `json:"level"` // "trace", "debug", "info", "warn", "error"...
+}
+
+// EventPolicyInfo is how events of a type are delivered to the frontend
+type EventPolicyInfo struct {
+	Event   string `json:"event"`   // event name, or prefix ending with "*"
+	Policy  string `json:"policy"`  // "immediate" or "latest"
+	KeyArgs int    `json:"keyArgs"` // leading arguments telling events apart with "latest"
+}
+
+// ResourceUsageInfo is the memory and goroutines used by the app, sizes in bytes
+type ResourceUsageInfo struct {
+	HeapAlloc    uint64 `json:"heapAlloc"`    // Allocated heap objects
+	HeapInuse    uint64 `json:"heapInuse"`    // Heap spans in use
+	HeapReleased uint64 `json:"heapReleased"` // Heap memory returned to the OS
+	Sys          uint64 `json:"sys"`          // Memory obtained from the OS
+	NumGC        uint32 `json:"numGC"`        // Garbage collections since startup
+	Goroutines   int    `json:"goroutines"`
+	ActiveParses int    `json:"activeParses"` // Turns being parsed or rendered
+	MaxParses    int    `json:"maxParses"`    // Turns parsed or rendered at once at most
+}
+
+// GameDirVerificationInfo compares a game directory with its manifest
+type GameDirVerificationInfo struct {
+	GameDir string               `json:"gameDir"`
+	Tracked int                  `json:"tracked"` // Files listed in the manifest
+	Intact  bool                 `json:"intact"`  // No tracked file is missing or modified
+	Files   []GameFileStatusInfo `json:"files"`   // Files differing from the manifest, by path
+
+	Recovered []string `json:"recovered"` // Missing turn files downloaded again, see "turn:recovered"
+}
+
+// GameFileStatusInfo is a file of a game directory differing from the manifest
+type GameFileStatusInfo struct {
+	Path       string    `json:"path"`       // Relative to the game directory, slash-separated
+	Status     string    `json:"status"`     // "missing", "modified" or "extra"
+	Origin     string    `json:"origin"`     // "server", "local" or "generated", "" for extra files
+	RecordedAt time.Time `json:"recordedAt"` // When the manifest entry was written, zero for extra files
+}
+
+// StartupTimingInfo is how long the last startup took, by phase, times in
+// milliseconds since the process started
+type StartupTimingInfo struct {
+	WindowShownMs int64              `json:"windowShownMs"` // 0 until the frontend loaded
+	Phases        []StartupPhaseInfo `json:"phases"`        // In the order they ended
+}
+
+// StartupPhaseInfo is a startup phase
+type StartupPhaseInfo struct {
+	Name       string `json:"name"`
+	StartMs    int64  `json:"startMs"`
+	DurationMs int64  `json:"durationMs"`
+	Deferred   bool   `json:"deferred"` // Run after the window was shown
+}
+
+// LocalTurnGenerationInfo is a year generated with the local stars.exe
+type LocalTurnGenerationInfo struct {
+	Year     int    `json:"year"`     // Year of the generated turn files
+	Players  int    `json:"players"`  // Turn files generated
+	Orders   int    `json:"orders"`   // Order files the year was generated from
+	HostDir  string `json:"hostDir"`  // Where the host files were staged and generated
+	Uploaded bool   `json:"uploaded"` // False when the server does not take generated turns
+}
+
+// LocalGameInfo is a game played without a server. ServerURL and ID can be
+// passed as server URL and session ID to the session bindings working offline.
+type LocalGameInfo struct {
+	ServerURL    string    `json:"serverUrl"`
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	GameDir      string    `json:"gameDir"`
+	CreatedAt    time.Time `json:"createdAt"`
+	Year         int       `json:"year"`         // Year of the host file, 0 until the game was created
+	Players      int       `json:"players"`      // Turn files in the game directory
+	HasStarsExe  bool      `json:"hasStarsExe"`  // stars.exe is in the game directory
+	HistoryYears []int     `json:"historyYears"` // Years in the turn history, oldest first
+}
+
+// FoundGameInfo is a Stars! game found by ScanForGames
+type FoundGameInfo struct {
+	Dir         string   `json:"dir"`
+	Name        string   `json:"name"`  // Base name of the files, e.g. "game" for game.xy
+	Files       []string `json:"files"` // As found on disk
+	HasHostFile bool     `json:"hasHostFile"`
+	Players     int      `json:"players"` // Turn files found
+	Year        int      `json:"year"`    // Of the host file, or the latest turn file, 0 if unreadable
+}
+
+// ImportedGameInfo is a game brought into Astrum's layout by ImportFoundGame
+type ImportedGameInfo struct {
+	ServerURL string   `json:"serverUrl"` // astrum.LocalGamesServer for a new local game
+	SessionID string   `json:"sessionId"` // The local game ID for a new local game
+	GameDir   string   `json:"gameDir"`
+	Files     []string `json:"files"` // Game files as named in the game directory
+}
+
+// LaunchRecordInfo is a run of Stars! for a session
+type LaunchRecordInfo struct {
+	StartedAt  time.Time `json:"startedAt"`
+	DurationMs int64     `json:"durationMs"` // 0 if Stars! did not start
+	Dir        string    `json:"dir"`
+	File       string    `json:"file"`
+	Wine       bool      `json:"wine"`
+	WinePrefix string    `json:"winePrefix,omitempty"`
+	Env        []string  `json:"env"`      // NAME=value set for the session
+	ExitCode   int       `json:"exitCode"` // -1 if Stars! did not start or was killed
+	Error      string    `json:"error,omitempty"`
+	Stderr     string    `json:"stderr"` // End of the error output
 }
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/filehash"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+)
+
+// =============================================================================
+// UPLOAD TROUBLESHOOTING
+// =============================================================================
+
+// DiagnoseUploadFailure finds out why the orders of a year were not uploaded
+// by testing each likely cause in turn: the connection, the order file, the
+// year, a conflict with an earlier upload and the answer of the server. The
+// findings are returned in that order, the first failure being the likely
+// cause, each with a suggested fix.
+func (a *App) DiagnoseUploadFailure(serverURL, sessionID string, year int) (*UploadDiagnosisInfo, error) {
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	diagnosis := &UploadDiagnosisInfo{
+		SessionID: sessionID,
+		Year:      year,
+		Findings:  []UploadFindingInfo{},
+	}
+
+	// Connection
+	client, mgr, connErr := a.sessionConnection(serverURL, sessionID)
+	if connErr != nil {
+		diagnosis.add(uploadFinding("connection", connErr,
+			"Connect to the server, pending orders are uploaded once connected"))
+	} else {
+		diagnosis.add(UploadFindingInfo{Check: "connection", Status: selfTestPass, Message: "Connected to the server"})
+	}
+	connected := connErr == nil
+
+	if a.IsMonitoringHandedOff(serverURL) {
+		diagnosis.add(UploadFindingInfo{
+			Check:      "monitoring",
+			Status:     selfTestFail,
+			Message:    "Automatic upload is handed off to another machine",
+			Suggestion: "Take the automatic upload over on this machine, or upload from the other one",
+		})
+	} else {
+		diagnosis.add(UploadFindingInfo{Check: "monitoring", Status: selfTestPass, Message: "Automatic upload is on for this machine"})
+	}
+
+	// Player number, from the server or else from the local turn file
+	player := 0
+	if connected {
+		if session, err := client.GetSession(mgr.GetContext(), sessionID); err == nil {
+			if userInfo := mgr.GetUserInfo(); userInfo != nil {
+				player = findPlayerNumber(session, userInfo.User.ID)
+			}
+		}
+	}
+	if player == 0 {
+		if n, err := localPlayerNumber(gameDir); err == nil {
+			player = n + 1
+		}
+	}
+	if player == 0 {
+		diagnosis.add(UploadFindingInfo{
+			Check:      "player",
+			Status:     selfTestFail,
+			Message:    "You have no player number in this session",
+			Suggestion: "Download the turn of the session, or check you joined it with this account",
+		})
+		return diagnosis.done(sessionID), nil
+	}
+
+	// Order file
+	orderPath := monitor.ResolveFile(gameDir, fmt.Sprintf("game.x%d", player))
+	storedHash := a.fileHashTracker.GetHash(serverURL, sessionID, fmt.Sprintf("order:%d", year))
+	findings, validator := orderFileFindings(orderPath, year, storedHash)
+	diagnosis.Findings = append(diagnosis.Findings, findings...)
+
+	// Server
+	if !connected {
+		diagnosis.add(UploadFindingInfo{Check: "server", Status: selfTestSkip, Message: "Not connected"})
+		return diagnosis.done(sessionID), nil
+	}
+	if validator != nil {
+		latestTurn, err := client.GetLatestTurn(mgr.GetContext(), sessionID)
+		switch {
+		case err != nil:
+			diagnosis.add(uploadFinding("server_year", fmt.Errorf("failed to get the latest turn: %w", err),
+				"Try again later, the server may be unavailable"))
+		case int(latestTurn.Year) > validator.Year():
+			diagnosis.add(UploadFindingInfo{
+				Check:      "server_year",
+				Status:     selfTestFail,
+				Message:    fmt.Sprintf("The server is at year %d, the order file is for year %d", latestTurn.Year, validator.Year()),
+				Suggestion: "Download the latest turn and play it",
+			})
+		case int(latestTurn.Year) < validator.Year():
+			diagnosis.add(UploadFindingInfo{
+				Check:      "server_year",
+				Status:     selfTestFail,
+				Message:    fmt.Sprintf("The server is at year %d, the order file is for year %d", latestTurn.Year, validator.Year()),
+				Suggestion: "Check the game folder holds this session, the order file is from another game",
+			})
+		default:
+			diagnosis.add(UploadFindingInfo{Check: "server_year", Status: selfTestPass, Message: fmt.Sprintf("The server is at year %d", latestTurn.Year)})
+		}
+	}
+	if skew, known := client.ClockSkew(); known {
+		diagnosis.add(clockFinding(skew))
+	}
+	diagnosis.add(a.lastUploadFinding(serverURL, sessionID, year, player))
+
+	return diagnosis.done(sessionID), nil
+}
+
+// orderFileFindings checks an order file can be uploaded for a year, and
+// returns its parsed content when it could be read
+func orderFileFindings(orderPath string, year int, storedHash string) ([]UploadFindingInfo, *astrum.OrderValidator) {
+	name := filepath.Base(orderPath)
+	data, err := os.ReadFile(orderPath)
+	if os.IsNotExist(err) {
+		return []UploadFindingInfo{{
+			Check:      "order_file",
+			Status:     selfTestFail,
+			Message:    fmt.Sprintf("There is no %s in %s", name, filepath.Dir(orderPath)),
+			Suggestion: "Play the turn in Stars! and submit it, from the game folder of the session",
+		}}, nil
+	}
+	if err != nil {
+		return []UploadFindingInfo{uploadFinding("order_file", err,
+			"Check the permissions of the file and that its disk is available")}, nil
+	}
+
+	validator, err := astrum.NewOrderValidatorFromBytes(data)
+	if err != nil {
+		return []UploadFindingInfo{uploadFinding("order_file", fmt.Errorf("%s cannot be read: %w", name, err),
+			"Open the turn in Stars! and submit it again, the file may be truncated")}, nil
+	}
+
+	findings := []UploadFindingInfo{{
+		Check:   "order_file",
+		Status:  selfTestPass,
+		Message: fmt.Sprintf("%s read, %d bytes", name, len(data)),
+	}}
+
+	if validator.TurnIsSubmitted() {
+		findings = append(findings, UploadFindingInfo{Check: "submitted", Status: selfTestPass, Message: "The turn is submitted"})
+	} else {
+		findings = append(findings, UploadFindingInfo{
+			Check:      "submitted",
+			Status:     selfTestFail,
+			Message:    "The turn was saved but not submitted",
+			Suggestion: "Submit the turn in Stars!, only submitted turns are uploaded",
+		})
+	}
+
+	if validator.Year() == year {
+		findings = append(findings, UploadFindingInfo{Check: "year", Status: selfTestPass, Message: fmt.Sprintf("The order file is for year %d", year)})
+	} else {
+		findings = append(findings, UploadFindingInfo{
+			Check:      "year",
+			Status:     selfTestFail,
+			Message:    fmt.Sprintf("The order file is for year %d, not %d", validator.Year(), year),
+			Suggestion: fmt.Sprintf("Play the turn of year %d", year),
+		})
+	}
+
+	switch currentHash := filehash.ComputeHash(data); {
+	case storedHash == "":
+		findings = append(findings, UploadFindingInfo{Check: "conflict", Status: selfTestPass, Message: "No order file was uploaded for this year yet"})
+	case storedHash == currentHash:
+		findings = append(findings, UploadFindingInfo{Check: "conflict", Status: selfTestPass, Message: "This order file was already uploaded"})
+	default:
+		findings = append(findings, UploadFindingInfo{
+			Check:      "conflict",
+			Status:     selfTestFail,
+			Message:    "The order file changed after it was uploaded for this year",
+			Suggestion: "Undo the upload to send new orders, or restore the uploaded version from the order history",
+		})
+	}
+	return findings, validator
+}
+
+// lastUploadFinding reports how the server answered the last upload of a year
+func (a *App) lastUploadFinding(serverURL, sessionID string, year, player int) UploadFindingInfo {
+	if status, err := a.fetchOrdersStatus(serverURL, sessionID); err == nil && status.PendingYear == year {
+		for _, p := range status.Players {
+			if p.PlayerOrder == player-1 && p.Submitted {
+				return UploadFindingInfo{Check: "server", Status: selfTestPass, Message: fmt.Sprintf("The server has your orders for year %d", year)}
+			}
+		}
+	}
+
+	history, err := a.ListOrderHistory(serverURL, sessionID)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("sessionId", sessionID).Msg("Failed to read order history")
+	}
+	for _, version := range history {
+		if version.Year != year {
+			continue
+		}
+		if version.Status == orderStatusFailed {
+			return UploadFindingInfo{
+				Check:      "server",
+				Status:     selfTestFail,
+				Message:    fmt.Sprintf("The server rejected the last upload: %s", version.Error),
+				Suggestion: "Fix the cause above, then upload again from the session",
+			}
+		}
+		break
+	}
+	return UploadFindingInfo{
+		Check:      "server",
+		Status:     selfTestFail,
+		Message:    fmt.Sprintf("The server has no orders from you for year %d", year),
+		Suggestion: "Upload the orders from the session",
+	}
+}
+
+// clockFinding checks the local clock against the server clock
+func clockFinding(skew time.Duration) UploadFindingInfo {
+	if issues := turnYearIssues(0, 0, 0, skew, true); len(issues) > 0 {
+		return UploadFindingInfo{Check: "clock", Status: selfTestFail, Message: issues[0].Message, Suggestion: issues[0].Suggestion}
+	}
+	return UploadFindingInfo{Check: "clock", Status: selfTestPass, Message: "The system clock agrees with the server"}
+}
+
+// uploadFinding is a failed check from an error
+func uploadFinding(check string, err error, suggestion string) UploadFindingInfo {
+	return UploadFindingInfo{Check: check, Status: selfTestFail, Message: err.Error(), Suggestion: suggestion}
+}
+
+// add appends a finding to the diagnosis
+func (d *UploadDiagnosisInfo) add(finding UploadFindingInfo) {
+	d.Findings = append(d.Findings, finding)
+}
+
+// done sets the likely cause, the first failed check, and logs the diagnosis
+func (d *UploadDiagnosisInfo) done(sessionID string) *UploadDiagnosisInfo {
+	for _, f := range d.Findings {
+		if f.Status == selfTestFail {
+			d.Cause = f.Check
+			break
+		}
+	}
+	logger.App.Info().Str("sessionId", sessionID).Int("year", d.Year).Str("cause", d.Cause).Msg("Diagnosed order upload")
+	return d
+}
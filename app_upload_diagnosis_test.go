@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderFileFindings_Missing(t *testing.T) {
+	findings, validator := orderFileFindings(filepath.Join(t.TempDir(), "game.x1"), 2400, "")
+
+	assert.Nil(t, validator)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "order_file", findings[0].Check)
+	assert.Equal(t, selfTestFail, findings[0].Status)
+	assert.Contains(t, findings[0].Message, "There is no game.x1")
+	assert.NotEmpty(t, findings[0].Suggestion)
+}
+
+func TestOrderFileFindings_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.x1")
+	require.NoError(t, os.WriteFile(path, []byte("not an order file"), 0644))
+
+	findings, validator := orderFileFindings(path, 2400, "")
+
+	assert.Nil(t, validator)
+	require.Len(t, findings, 1)
+	assert.Equal(t, selfTestFail, findings[0].Status)
+	assert.Contains(t, findings[0].Message, "game.x1 cannot be read")
+}
+
+func TestUploadDiagnosisCause(t *testing.T) {
+	d := &UploadDiagnosisInfo{Year: 2400}
+	d.add(UploadFindingInfo{Check: "connection", Status: selfTestPass})
+	d.add(UploadFindingInfo{Check: "year", Status: selfTestFail})
+	d.add(UploadFindingInfo{Check: "server", Status: selfTestFail})
+
+	assert.Equal(t, "year", d.done("session-123").Cause)
+}
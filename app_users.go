@@ -2,9 +2,16 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gen2brain/beeep"
 
 	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/auth"
 	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/model"
 )
 
 // =============================================================================
@@ -80,6 +87,10 @@ func (a *App) CreateUserProfile(serverURL, nickname, email string) (*UserProfile
 
 // DeleteUserProfile deletes a user profile (admin only)
 func (a *App) DeleteUserProfile(serverURL, userID string) error {
+	if err := a.requireUnlocked(); err != nil {
+		return err
+	}
+
 	a.mu.RLock()
 	client, ok := a.clients[serverURL]
 	mgr, mgrOk := a.authManagers[serverURL]
@@ -104,6 +115,10 @@ func (a *App) DeleteUserProfile(serverURL, userID string) error {
 // ResetUserApikey resets the API key for a user (admin only)
 // Returns the new API key
 func (a *App) ResetUserApikey(serverURL, userID string) (string, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return "", err
+	}
+
 	a.mu.RLock()
 	client, ok := a.clients[serverURL]
 	mgr, mgrOk := a.authManagers[serverURL]
@@ -124,6 +139,10 @@ func (a *App) ResetUserApikey(serverURL, userID string) (string, error) {
 
 // ChangeMyApikey resets the current user's API key, updates stored credentials, and re-authenticates
 func (a *App) ChangeMyApikey(serverURL string) (string, error) {
+	if err := a.requireUnlocked(); err != nil {
+		return "", err
+	}
+
 	a.mu.RLock()
 	client, ok := a.clients[serverURL]
 	mgr, mgrOk := a.authManagers[serverURL]
@@ -172,11 +191,49 @@ func (a *App) ChangeMyApikey(serverURL string) (string, error) {
 }
 
 // =============================================================================
-// INVITATIONS
+// SERVER MEMBERS DIRECTORY
 // =============================================================================
 
-// InviteUser creates an invitation for a user to join a session
-func (a *App) InviteUser(serverURL, sessionID, userProfileID string) (*InvitationInfo, error) {
+// userProfileCacheTTL is how long a fetched user profile list is reused
+// before GetServerMembers goes back to the server
+const userProfileCacheTTL = 5 * time.Minute
+
+// userProfileCache holds the last user profile list fetched from a server
+type userProfileCache struct {
+	profiles  []api.UserProfile
+	fetchedAt time.Time
+}
+
+// getCachedUserProfiles returns the user profiles for a server, using the
+// cached list when it is fresh enough
+func (a *App) getCachedUserProfiles(serverURL string, client *api.Client, mgr *auth.Manager) ([]api.UserProfile, error) {
+	a.mu.RLock()
+	cache := a.profileCaches[serverURL]
+	a.mu.RUnlock()
+
+	if cache != nil && time.Since(cache.fetchedAt) < userProfileCacheTTL {
+		return cache.profiles, nil
+	}
+
+	profiles, err := client.ListUserProfiles(mgr.GetContext())
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.profileCaches[serverURL] = &userProfileCache{
+		profiles:  profiles,
+		fetchedAt: time.Now(),
+	}
+	a.mu.Unlock()
+
+	return profiles, nil
+}
+
+// GetServerMembers returns the user profiles of a server, optionally filtered by a
+// case-insensitive nickname search, along with the sessions we share with each member.
+// Profiles are cached for a few minutes; use RefreshServerMembers to force a refetch.
+func (a *App) GetServerMembers(serverURL, query string) ([]ServerMemberInfo, error) {
 	a.mu.RLock()
 	client, ok := a.clients[serverURL]
 	mgr, mgrOk := a.authManagers[serverURL]
@@ -186,6 +243,99 @@ func (a *App) InviteUser(serverURL, sessionID, userProfileID string) (*Invitatio
 		return nil, fmt.Errorf("not connected to server: %s", serverURL)
 	}
 
+	userInfo := mgr.GetUserInfo()
+	if userInfo == nil {
+		return nil, fmt.Errorf("no user info available")
+	}
+	myID := userInfo.User.ID
+
+	profiles, err := a.getCachedUserProfiles(serverURL, client, mgr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profiles: %w", err)
+	}
+
+	sessions, err := client.ListSessions(mgr.GetContext())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	// Index the sessions we belong to by member ID
+	shared := make(map[string][]SharedSessionInfo)
+	for _, s := range sessions {
+		if !containsString(s.Members, myID) && !containsString(s.Managers, myID) {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, id := range append(append([]string{}, s.Members...), s.Managers...) {
+			if id == myID || seen[id] {
+				continue
+			}
+			seen[id] = true
+			shared[id] = append(shared[id], SharedSessionInfo{
+				ID:        s.ID,
+				Name:      s.Name,
+				State:     s.State,
+				IsManager: containsString(s.Managers, id),
+			})
+		}
+	}
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	result := make([]ServerMemberInfo, 0, len(profiles))
+	for _, p := range profiles {
+		if query != "" && !strings.Contains(strings.ToLower(p.Nickname), query) {
+			continue
+		}
+		sharedSessions := shared[p.ID]
+		if sharedSessions == nil {
+			sharedSessions = []SharedSessionInfo{}
+		}
+		result = append(result, ServerMemberInfo{
+			ID:             p.ID,
+			Nickname:       p.Nickname,
+			State:          p.State,
+			IsManager:      p.IsManager,
+			IsMe:           p.ID == myID,
+			SharedSessions: sharedSessions,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Nickname) < strings.ToLower(result[j].Nickname)
+	})
+
+	return result, nil
+}
+
+// RefreshServerMembers drops the cached user profiles for a server so the next
+// GetServerMembers call fetches them again
+func (a *App) RefreshServerMembers(serverURL string) {
+	a.mu.Lock()
+	delete(a.profileCaches, serverURL)
+	a.mu.Unlock()
+}
+
+// containsString reports whether s is present in list
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// INVITATIONS
+// =============================================================================
+
+// InviteUser creates an invitation for a user to join a session
+func (a *App) InviteUser(serverURL, sessionID, userProfileID string) (*InvitationInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
 	invitation := &api.Invitation{
 		SessionID:     sessionID,
 		UserProfileID: userProfileID,
@@ -229,6 +379,8 @@ func (a *App) GetInvitations(serverURL string) ([]InvitationInfo, error) {
 
 	logger.App.Debug().Str("serverUrl", serverURL).Int("count", len(invitations)).Msg("GetInvitations: fetched invitations")
 
+	invitations = a.filterIgnoredInvitations(serverURL, invitations)
+
 	result := make([]InvitationInfo, len(invitations))
 	for i, inv := range invitations {
 		result[i] = InvitationInfo{
@@ -299,6 +451,7 @@ func (a *App) AcceptInvitation(serverURL, invitationID string) (*SessionInfo, er
 	}
 
 	logger.App.Info().Str("name", session.Name).Str("id", session.ID).Msg("Accepted invitation, joined session")
+	a.setSessionSubscribed(serverURL, session.ID, true)
 
 	return &SessionInfo{
 		ID:                session.ID,
@@ -333,6 +486,414 @@ func (a *App) DeclineInvitation(serverURL, invitationID string) error {
 	return nil
 }
 
+// =============================================================================
+// INVITATION IGNORE LIST
+// =============================================================================
+
+// GetIgnoreList returns the users whose invitations are hidden or auto-declined on a server
+func (a *App) GetIgnoreList(serverURL string) ([]IgnoredUserInfo, error) {
+	list, err := a.config.GetIgnoreList(serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]IgnoredUserInfo, len(list.Users))
+	for i, u := range list.Users {
+		result[i] = IgnoredUserInfo{
+			UserProfileID: u.UserProfileID,
+			Nickname:      u.Nickname,
+			Mode:          string(u.Mode),
+			AddedAt:       u.AddedAt,
+		}
+	}
+
+	return result, nil
+}
+
+// AddToIgnoreList adds a user to the ignore list of a server, or updates its mode
+// mode is "hide" (invitations are hidden locally) or "decline" (invitations are declined on the server)
+func (a *App) AddToIgnoreList(serverURL, userProfileID, nickname, mode string) error {
+	ignoreMode := model.IgnoreMode(mode)
+	if ignoreMode != model.IgnoreModeHide && ignoreMode != model.IgnoreModeDecline {
+		return fmt.Errorf("invalid ignore mode: %s", mode)
+	}
+	if userProfileID == "" {
+		return fmt.Errorf("user profile ID is required")
+	}
+
+	err := a.updateIgnoreList(serverURL, func(list *model.IgnoreList) bool {
+		list.AddOrUpdate(model.IgnoredUser{
+			UserProfileID: userProfileID,
+			Nickname:      nickname,
+			Mode:          ignoreMode,
+			AddedAt:       time.Now(),
+		})
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.App.Info().Str("userProfileId", userProfileID).Str("mode", mode).Msg("Added user to ignore list")
+
+	// Apply right away to invitations already waiting
+	if ignoreMode == model.IgnoreModeDecline {
+		go a.autoDeclineIgnoredInvitations(serverURL)
+	}
+
+	return nil
+}
+
+// RemoveFromIgnoreList removes a user from the ignore list of a server
+func (a *App) RemoveFromIgnoreList(serverURL, userProfileID string) error {
+	removed := false
+	err := a.updateIgnoreList(serverURL, func(list *model.IgnoreList) bool {
+		removed = list.Remove(userProfileID)
+		return removed
+	})
+	if err != nil || !removed {
+		return err
+	}
+
+	logger.App.Info().Str("userProfileId", userProfileID).Msg("Removed user from ignore list")
+	return nil
+}
+
+// GetAutoDeclinedInvitations returns the audit trail of invitations declined because of the ignore list
+// Most recent entries come first
+func (a *App) GetAutoDeclinedInvitations(serverURL string) ([]AutoDeclinedInvitationInfo, error) {
+	list, err := a.config.GetIgnoreList(serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]AutoDeclinedInvitationInfo, 0, len(list.AutoDeclined))
+	for i := len(list.AutoDeclined) - 1; i >= 0; i-- {
+		d := list.AutoDeclined[i]
+		result = append(result, AutoDeclinedInvitationInfo{
+			InvitationID:    d.InvitationID,
+			SessionID:       d.SessionID,
+			SessionName:     d.SessionName,
+			InviterID:       d.InviterID,
+			InviterNickname: d.InviterNickname,
+			DeclinedAt:      d.DeclinedAt,
+		})
+	}
+
+	return result, nil
+}
+
+// ClearAutoDeclinedInvitations empties the audit trail of auto-declined invitations
+func (a *App) ClearAutoDeclinedInvitations(serverURL string) error {
+	return a.updateIgnoreList(serverURL, func(list *model.IgnoreList) bool {
+		list.AutoDeclined = nil
+		return true
+	})
+}
+
+// updateIgnoreList applies a change to the ignore list of a server, saving it
+// when update returns true. Updates are serialized so none is lost.
+func (a *App) updateIgnoreList(serverURL string, update func(list *model.IgnoreList) bool) error {
+	a.ignoreMu.Lock()
+	defer a.ignoreMu.Unlock()
+
+	list, err := a.config.GetIgnoreList(serverURL)
+	if err != nil {
+		return err
+	}
+	if !update(list) {
+		return nil
+	}
+	return a.config.SetIgnoreList(serverURL, list)
+}
+
+// filterIgnoredInvitations removes invitations sent by ignored users
+func (a *App) filterIgnoredInvitations(serverURL string, invitations []api.Invitation) []api.Invitation {
+	list, err := a.config.GetIgnoreList(serverURL)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to load ignore list")
+		return invitations
+	}
+	if len(list.Users) == 0 {
+		return invitations
+	}
+
+	kept := make([]api.Invitation, 0, len(invitations))
+	for _, inv := range invitations {
+		if list.Find(inv.InviterID) == nil {
+			kept = append(kept, inv)
+		}
+	}
+	return kept
+}
+
+// declineIgnoredInvitations removes invitations sent by ignored users, like
+// filterIgnoredInvitations, declining on the server those from users in
+// "decline" mode and recording them in the audit trail
+func (a *App) declineIgnoredInvitations(serverURL string, client *api.Client, mgr *auth.Manager, invitations []api.Invitation) []api.Invitation {
+	list, err := a.config.GetIgnoreList(serverURL)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to load ignore list")
+		return invitations
+	}
+	if len(list.Users) == 0 {
+		return invitations
+	}
+
+	kept := make([]api.Invitation, 0, len(invitations))
+	var declined []model.AutoDeclinedInvitation
+	for _, inv := range invitations {
+		ignored := list.Find(inv.InviterID)
+		if ignored == nil {
+			kept = append(kept, inv)
+			continue
+		}
+
+		if ignored.Mode != model.IgnoreModeDecline {
+			continue
+		}
+
+		if err := client.DeclineInvitation(mgr.GetContext(), inv.ID); err != nil {
+			logger.App.Warn().Err(err).Str("id", inv.ID).Msg("Failed to auto-decline invitation")
+			continue
+		}
+
+		declined = append(declined, model.AutoDeclinedInvitation{
+			InvitationID:    inv.ID,
+			SessionID:       inv.SessionID,
+			SessionName:     inv.SessionName,
+			InviterID:       inv.InviterID,
+			InviterNickname: inv.InviterNickname,
+			DeclinedAt:      time.Now(),
+		})
+
+		logger.App.Info().
+			Str("id", inv.ID).
+			Str("inviter", inv.InviterNickname).
+			Msg("Auto-declined invitation from ignored user")
+	}
+
+	if len(declined) > 0 {
+		err := a.updateIgnoreList(serverURL, func(list *model.IgnoreList) bool {
+			for _, d := range declined {
+				list.RecordAutoDeclined(d)
+			}
+			return true
+		})
+		if err != nil {
+			logger.App.Warn().Err(err).Msg("Failed to save auto-declined invitations")
+		}
+	}
+
+	return kept
+}
+
+// autoDeclineIgnoredInvitations fetches pending invitations and declines those
+// coming from users ignored in "decline" mode
+func (a *App) autoDeclineIgnoredInvitations(serverURL string) {
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk {
+		return
+	}
+
+	invitations, err := client.ListInvitations(mgr.GetContext())
+	if err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to list invitations for ignore list")
+		return
+	}
+
+	kept := a.declineIgnoredInvitations(serverURL, client, mgr, invitations)
+	if len(kept) != len(invitations) {
+		a.mu.RLock()
+		shuttingDown := a.shuttingDown
+		a.mu.RUnlock()
+		if !shuttingDown {
+			a.emit("invitations:filtered", serverURL)
+		}
+	}
+}
+
+// =============================================================================
+// INVITATION EXPIRY POLICY
+// =============================================================================
+
+// invitationPolicyInterval is how often pending invitations are checked for expiry
+const invitationPolicyInterval = time.Hour
+
+// invitationDigestInterval is the minimum delay between two pending invitations reminders
+const invitationDigestInterval = 24 * time.Hour
+
+// invitationPolicyLoop periodically applies the invitation policy to all connected servers,
+// sends the weekly submission digest and the telemetry report when they are due,
+// checks the server announcements and statuses and the deadlines of our missing
+// orders, and reports this machine and the user's presence to the servers
+func (a *App) invitationPolicyLoop() {
+	ticker := time.NewTicker(invitationPolicyInterval)
+	defer ticker.Stop()
+	announcements := time.NewTicker(announcementInterval)
+	defer announcements.Stop()
+	statuses := time.NewTicker(serverStatusInterval)
+	defer statuses.Stop()
+	devices := time.NewTicker(deviceReportInterval)
+	defer devices.Stop()
+	presence := time.NewTicker(presenceInterval)
+	defer presence.Stop()
+	deadlines := time.NewTicker(deadlineCheckInterval)
+	defer deadlines.Stop()
+
+	for {
+		select {
+		case <-a.stopInvitationPolicy:
+			return
+		case <-ticker.C:
+			a.runInvitationPolicyForAll()
+			a.runSubmissionDigest()
+			a.sendTelemetryIfDue()
+		case <-announcements.C:
+			a.refreshAnnouncementsForAll()
+		case <-statuses.C:
+			a.refreshServerStatusForAll()
+		case <-devices.C:
+			a.reportDevicesForAll()
+		case <-presence.C:
+			a.sendHeartbeatsForAll()
+		case <-deadlines.C:
+			a.checkDeadlinesForAll()
+		}
+	}
+}
+
+// runInvitationPolicyForAll applies the invitation policy to every connected server
+func (a *App) runInvitationPolicyForAll() {
+	a.mu.RLock()
+	serverURLs := make([]string, 0, len(a.clients))
+	for url := range a.clients {
+		serverURLs = append(serverURLs, url)
+	}
+	a.mu.RUnlock()
+
+	for _, url := range serverURLs {
+		a.runInvitationPolicy(url)
+	}
+}
+
+// runInvitationPolicy declines pending invitations older than the configured expiry
+// and shows the daily pending invitations reminder when enabled
+func (a *App) runInvitationPolicy(serverURL string) {
+	if a.inMaintenance(serverURL) {
+		logger.App.Debug().Str("serverUrl", serverURL).Msg("Server under maintenance, invitation policy deferred")
+		return
+	}
+
+	a.mu.RLock()
+	client, ok := a.clients[serverURL]
+	mgr, mgrOk := a.authManagers[serverURL]
+	a.mu.RUnlock()
+
+	if !ok || !mgrOk {
+		return
+	}
+
+	settings, err := a.config.GetAppSettings()
+	if err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to load settings for invitation policy")
+		return
+	}
+
+	invitations, err := client.ListInvitations(mgr.GetContext())
+	if err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to list invitations for invitation policy")
+		return
+	}
+	invitations = a.declineIgnoredInvitations(serverURL, client, mgr, invitations)
+
+	tracking, err := a.config.GetInvitationTracking(serverURL)
+	if err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to load invitation tracking")
+		return
+	}
+
+	now := time.Now()
+	ids := make([]string, len(invitations))
+	for i, inv := range invitations {
+		ids[i] = inv.ID
+	}
+	tracking.Sync(ids, now)
+
+	// Decline invitations that have been pending for too long
+	pending := invitations
+	if days := settings.GetInvitationExpiryDays(); days > 0 {
+		maxAge := time.Duration(days) * 24 * time.Hour
+		pending = make([]api.Invitation, 0, len(invitations))
+		for _, inv := range invitations {
+			if now.Sub(tracking.FirstSeen[inv.ID]) < maxAge {
+				pending = append(pending, inv)
+				continue
+			}
+
+			if err := client.DeclineInvitation(mgr.GetContext(), inv.ID); err != nil {
+				logger.App.Warn().Err(err).Str("id", inv.ID).Msg("Failed to decline expired invitation")
+				pending = append(pending, inv)
+				continue
+			}
+
+			delete(tracking.FirstSeen, inv.ID)
+			logger.App.Info().
+				Str("id", inv.ID).
+				Str("session", inv.SessionName).
+				Int("days", days).
+				Msg("Declined expired invitation")
+		}
+
+		if len(pending) != len(invitations) {
+			a.mu.RLock()
+			shuttingDown := a.shuttingDown
+			a.mu.RUnlock()
+			if !shuttingDown {
+				a.emit("invitations:expired", serverURL, len(invitations)-len(pending))
+			}
+		}
+	}
+
+	// Remind about pending invitations at most once a day
+	if settings.GetInvitationDigest() && len(pending) > 0 && now.Sub(tracking.LastDigest) >= invitationDigestInterval {
+		a.showPendingInvitationsNotification(serverURL, len(pending))
+		tracking.LastDigest = now
+	}
+
+	if err := a.config.SetInvitationTracking(serverURL, tracking); err != nil {
+		logger.App.Warn().Err(err).Str("serverUrl", serverURL).Msg("Failed to save invitation tracking")
+	}
+}
+
+// showPendingInvitationsNotification shows a desktop notification about pending invitations
+func (a *App) showPendingInvitationsNotification(serverURL string, count int) {
+	server, _ := a.config.GetServer(serverURL)
+	serverName := serverURL
+	if server != nil {
+		serverName = server.Name
+	}
+
+	title := "Pending Invitations"
+	message := fmt.Sprintf("You have %d pending invitations on %s", count, serverName)
+	if count == 1 {
+		message = fmt.Sprintf("You have a pending invitation on %s", serverName)
+	}
+
+	if err := beeep.Notify(title, message, a.notificationIcon); err != nil {
+		logger.App.Warn().Err(err).Msg("Failed to show desktop notification")
+	} else {
+		logger.App.Debug().
+			Str("serverUrl", serverURL).
+			Int("count", count).
+			Msg("Desktop notification shown for pending invitations")
+	}
+}
+
 // =============================================================================
 // PENDING REGISTRATIONS
 // =============================================================================
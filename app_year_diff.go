@@ -0,0 +1,285 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/neper-stars/houston/store"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// =============================================================================
+// YEAR DIFF
+// =============================================================================
+
+// fetchYearGameStore downloads and parses the universe and turn files of a year
+func fetchYearGameStore(ctx context.Context, client *api.Client, sessionID string, year int) (*store.GameStore, error) {
+	turnFiles, err := client.GetTurn(ctx, sessionID, year)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get turn files for %d: %w", year, err)
+	}
+
+	universe, err := base64.StdEncoding.DecodeString(turnFiles.Turn.Universe)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode universe file: %w", err)
+	}
+	turn, err := base64.StdEncoding.DecodeString(turnFiles.Turn.Turn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode turn file: %w", err)
+	}
+
+	gs, err := newGameStore(universe, turn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse turn files for %d: %w", year, err)
+	}
+	return gs, nil
+}
+
+// DiffYears compares two years of a session from the player's turn files:
+// score changes of every player, planets changing hands, fleets built and
+// destroyed, and tech level changes
+func (a *App) DiffYears(serverURL, sessionID string, yearA, yearB int) (*YearDiffInfo, error) {
+	if yearA == yearB {
+		return nil, fmt.Errorf("cannot compare a year with itself")
+	}
+
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	ctx := mgr.GetContext()
+
+	gsA, err := fetchYearGameStore(ctx, client, sessionID, yearA)
+	if err != nil {
+		return nil, err
+	}
+	gsB, err := fetchYearGameStore(ctx, client, sessionID, yearB)
+	if err != nil {
+		return nil, err
+	}
+
+	return &YearDiffInfo{
+		SessionID: sessionID,
+		YearA:     yearA,
+		YearB:     yearB,
+		Players:   diffPlayerScores(gsA, gsB),
+		Planets:   diffPlanetOwners(gsA, gsB),
+		Fleets:    diffFleets(gsA, gsB),
+		Tech:      diffTech(gsA, gsB),
+	}, nil
+}
+
+// ExportYearDiff writes the comparison of two years of a session as Markdown
+// to the exports/ folder of its game directory, and returns the written file path
+func (a *App) ExportYearDiff(serverURL, sessionID string, yearA, yearB int) (string, error) {
+	diff, err := a.DiffYears(serverURL, sessionID, yearA, yearB)
+	if err != nil {
+		return "", err
+	}
+
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return "", err
+	}
+	exportDir := filepath.Join(gameDir, "exports")
+	if err := safefile.MkdirAll(exportDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create exports directory: %w", err)
+	}
+
+	path := filepath.Join(exportDir, fmt.Sprintf("diff-%d-%d-%s.md", yearA, yearB, time.Now().Format("20060102-150405")))
+	if err := safefile.WriteFile(path, []byte(formatYearDiffMarkdown(diff)), 0644); err != nil {
+		return "", fmt.Errorf("failed to write year diff: %w", err)
+	}
+
+	logger.App.Info().Str("sessionId", sessionID).Int("yearA", yearA).Int("yearB", yearB).Str("path", path).Msg("Exported year diff")
+	return path, nil
+}
+
+// diffPlayerScores compares the scores of every player known in yearB
+func diffPlayerScores(gsA, gsB *store.GameStore) []PlayerYearDeltaInfo {
+	result := []PlayerYearDeltaInfo{}
+	for _, player := range sortedPlayers(gsB) {
+		scoreB := player.StoredScore
+		if scoreB == nil {
+			continue
+		}
+		scoreA := &store.StoredScore{}
+		if p, ok := gsA.Player(player.PlayerNumber); ok && p.StoredScore != nil {
+			scoreA = p.StoredScore
+		}
+		result = append(result, PlayerYearDeltaInfo{
+			Player:         player.NamePlural,
+			ScoreA:         scoreA.Score,
+			ScoreB:         scoreB.Score,
+			ScoreDelta:     scoreB.Score - scoreA.Score,
+			RankA:          scoreA.Rank,
+			RankB:          scoreB.Rank,
+			PlanetsDelta:   scoreB.Planets - scoreA.Planets,
+			ResourcesDelta: scoreB.Resources - scoreA.Resources,
+			ShipsDelta:     scoreShips(scoreB) - scoreShips(scoreA),
+		})
+	}
+	return result
+}
+
+// scoreShips returns the number of ships of a score
+func scoreShips(s *store.StoredScore) int {
+	return s.UnarmedShips + s.EscortShips + s.CapitalShips
+}
+
+// diffPlanetOwners lists the planets known in both years whose owner changed
+func diffPlanetOwners(gsA, gsB *store.GameStore) []PlanetOwnerChangeInfo {
+	ownersA := make(map[int]int)
+	for _, planet := range gsA.AllPlanets() {
+		ownersA[planet.PlanetNumber] = planet.Owner
+	}
+
+	result := []PlanetOwnerChangeInfo{}
+	for _, planet := range gsB.AllPlanets() {
+		ownerA, ok := ownersA[planet.PlanetNumber]
+		if !ok || ownerA == planet.Owner {
+			continue
+		}
+		result = append(result, PlanetOwnerChangeInfo{
+			PlanetNumber: planet.PlanetNumber,
+			Name:         planet.Name,
+			From:         playerName(gsA, ownerA),
+			To:           playerName(gsB, planet.Owner),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].PlanetNumber < result[j].PlanetNumber })
+	return result
+}
+
+// fleetKey identifies a fleet across years
+type fleetKey struct {
+	owner, number int
+}
+
+// diffFleets lists the fleets of the turn file owner built or destroyed
+// between the two years. Fleets of other players come and go with scanner
+// coverage and are left out. Merged fleets count as destroyed.
+func diffFleets(gsA, gsB *store.GameStore) []FleetChangeInfo {
+	owner := turnOwner(gsB)
+	if owner < 0 {
+		return []FleetChangeInfo{}
+	}
+
+	ownFleets := func(gs *store.GameStore) map[fleetKey]*store.FleetEntity {
+		fleets := make(map[fleetKey]*store.FleetEntity)
+		for _, fleet := range gs.AllFleets() {
+			if fleet.Owner == owner && !fleet.IsDead {
+				fleets[fleetKey{fleet.Owner, fleet.FleetNumber}] = fleet
+			}
+		}
+		return fleets
+	}
+	fleetsA, fleetsB := ownFleets(gsA), ownFleets(gsB)
+
+	result := []FleetChangeInfo{}
+	for key, fleet := range fleetsB {
+		if _, ok := fleetsA[key]; !ok {
+			result = append(result, FleetChangeInfo{Owner: playerName(gsB, owner), FleetNumber: key.number, Name: fleet.Name(), Ships: fleet.TotalShips(), Change: "built"})
+		}
+	}
+	for key, fleet := range fleetsA {
+		if _, ok := fleetsB[key]; !ok {
+			result = append(result, FleetChangeInfo{Owner: playerName(gsA, owner), FleetNumber: key.number, Name: fleet.Name(), Ships: fleet.TotalShips(), Change: "destroyed"})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].FleetNumber < result[j].FleetNumber })
+	return result
+}
+
+// turnOwner returns the player number of the owner of a turn file, the only
+// player whose full data it holds, or -1
+func turnOwner(gs *store.GameStore) int {
+	for _, player := range sortedPlayers(gs) {
+		if player.HasFullData {
+			return player.PlayerNumber
+		}
+	}
+	return -1
+}
+
+// diffTech lists the tech level changes of the players whose tech levels are
+// known in both years
+func diffTech(gsA, gsB *store.GameStore) []TechChangeInfo {
+	result := []TechChangeInfo{}
+	for _, player := range sortedPlayers(gsB) {
+		before, ok := gsA.Player(player.PlayerNumber)
+		if !player.HasFullData || !ok || !before.HasFullData {
+			continue
+		}
+		fields := []struct {
+			name     string
+			from, to int
+		}{
+			{"energy", before.Tech.Energy, player.Tech.Energy},
+			{"weapons", before.Tech.Weapons, player.Tech.Weapons},
+			{"propulsion", before.Tech.Propulsion, player.Tech.Propulsion},
+			{"construction", before.Tech.Construction, player.Tech.Construction},
+			{"electronics", before.Tech.Electronics, player.Tech.Electronics},
+			{"biotech", before.Tech.Biotech, player.Tech.Biotech},
+		}
+		for _, f := range fields {
+			if f.from != f.to {
+				result = append(result, TechChangeInfo{Player: player.NamePlural, Field: f.name, From: f.from, To: f.to})
+			}
+		}
+	}
+	return result
+}
+
+// formatYearDiffMarkdown formats a year diff as a Markdown document
+func formatYearDiffMarkdown(diff *YearDiffInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %d → %d\n", diff.YearA, diff.YearB)
+
+	if len(diff.Players) > 0 {
+		b.WriteString("\n## Scores\n\n| Player | Score | Change | Rank | Planets | Resources | Ships |\n|---|---:|---:|---|---:|---:|---:|\n")
+		for _, p := range diff.Players {
+			fmt.Fprintf(&b, "| %s | %d | %+d | %d → %d | %+d | %+d | %+d |\n",
+				p.Player, p.ScoreB, p.ScoreDelta, p.RankA, p.RankB, p.PlanetsDelta, p.ResourcesDelta, p.ShipsDelta)
+		}
+	}
+
+	if len(diff.Planets) > 0 {
+		b.WriteString("\n## Planets changing hands\n\n")
+		for _, p := range diff.Planets {
+			fmt.Fprintf(&b, "- **%s**: %s → %s\n", p.Name, ownerOrUnowned(p.From), ownerOrUnowned(p.To))
+		}
+	}
+
+	if len(diff.Fleets) > 0 {
+		b.WriteString("\n## Fleets\n\n")
+		for _, f := range diff.Fleets {
+			fmt.Fprintf(&b, "- %s: %s (%d ships)\n", f.Change, f.Name, f.Ships)
+		}
+	}
+
+	if len(diff.Tech) > 0 {
+		b.WriteString("\n## Tech\n\n")
+		for _, t := range diff.Tech {
+			fmt.Fprintf(&b, "- %s %s: %d → %d\n", t.Player, t.Field, t.From, t.To)
+		}
+	}
+
+	return b.String()
+}
+
+// ownerOrUnowned returns a planet owner name for display
+func ownerOrUnowned(owner string) string {
+	if owner == "" {
+		return "unowned"
+	}
+	return owner
+}
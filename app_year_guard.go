@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/neper-stars/houston/blocks"
+
+	"github.com/neper-stars/astrum/api"
+	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/monitor"
+)
+
+// =============================================================================
+// TURN YEAR SANITY GUARD
+// =============================================================================
+
+// maxClockSkew is how far the local clock may be from the server clock before
+// it is reported. Stars! years don't depend on the clock, but a clock this far
+// off usually comes with a restored or copied system, and it breaks deadlines.
+const maxClockSkew = 10 * time.Minute
+
+// CheckTurnYears looks for situations that cannot happen in a healthy game:
+// a local turn or order file for a year the server has not reached yet, or a
+// system clock far from the server's. These come from a game folder of
+// another game, a folder restored over a newer one or a server restored from
+// a backup, and are reported as such rather than as a plain year mismatch.
+func (a *App) CheckTurnYears(serverURL, sessionID string) (*TurnYearCheckInfo, error) {
+	client, mgr, err := a.sessionConnection(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	gameDir, err := a.sessionGameDir(serverURL, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	latestTurn, err := client.GetLatestTurn(mgr.GetContext(), sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest turn from server: %w", err)
+	}
+
+	check := &TurnYearCheckInfo{SessionID: sessionID, ServerYear: int(latestTurn.Year)}
+	if gs, err := loadLocalGameStore(gameDir); err == nil {
+		check.TurnYear = blocks.StarsBaseYear + int(gs.Turn)
+	}
+	if player, err := localPlayerNumber(gameDir); err == nil {
+		orderPath := monitor.ResolveFile(gameDir, fmt.Sprintf("game.x%d", player+1))
+		if validator, err := astrum.NewOrderValidator(orderPath); err == nil {
+			check.OrderYear = validator.Year()
+		}
+	}
+	skew, known := client.ClockSkew()
+	if known {
+		check.ClockSkewSeconds = int64(skew / time.Second)
+	}
+
+	check.Issues = turnYearIssues(check.ServerYear, check.TurnYear, check.OrderYear, skew, known)
+	check.OK = len(check.Issues) == 0
+	return check, nil
+}
+
+// checkOrderYear checks the year of an order file against the server year
+// before an upload. Orders behind the server are merely late; orders ahead of
+// it are impossible, which is reported with what may cause it and emitted as
+// "session:year-guard" (serverURL, sessionID, check).
+func (a *App) checkOrderYear(serverURL, sessionID string, client *api.Client, orderYear, serverYear int) error {
+	if orderYear == serverYear {
+		return nil
+	}
+	if orderYear < serverYear {
+		return fmt.Errorf("order year %d does not match server year %d", orderYear, serverYear)
+	}
+
+	skew, known := client.ClockSkew()
+	check := &TurnYearCheckInfo{
+		SessionID:  sessionID,
+		ServerYear: serverYear,
+		OrderYear:  orderYear,
+		Issues:     turnYearIssues(serverYear, 0, orderYear, skew, known),
+	}
+	if known {
+		check.ClockSkewSeconds = int64(skew / time.Second)
+	}
+
+	logger.App.Warn().
+		Str("sessionId", sessionID).
+		Int("orderYear", orderYear).
+		Int("serverYear", serverYear).
+		Dur("clockSkew", skew).
+		Msg("Order file is ahead of the server")
+
+	a.mu.RLock()
+	shuttingDown := a.shuttingDown
+	a.mu.RUnlock()
+	if !shuttingDown {
+		a.emit("session:year-guard", serverURL, sessionID, check)
+	}
+	return fmt.Errorf("order file is for year %d but the server is only at year %d, it comes from another game or a restored folder", orderYear, serverYear)
+}
+
+// turnYearIssues lists what is impossible about the local years of a game
+// given the server year, and a local clock too far from the server clock.
+// Zero years are unknown and not checked.
+func turnYearIssues(serverYear, turnYear, orderYear int, skew time.Duration, skewKnown bool) []TurnYearIssueInfo {
+	issues := []TurnYearIssueInfo{}
+
+	if turnYear > serverYear {
+		issues = append(issues, TurnYearIssueInfo{
+			Code:       "turn_ahead",
+			Message:    fmt.Sprintf("The local turn is for year %d but the server is only at year %d", turnYear, serverYear),
+			Suggestion: "The game folder holds another game, or the server was restored from a backup: download the latest turn again",
+		})
+	}
+	if orderYear > serverYear {
+		issues = append(issues, TurnYearIssueInfo{
+			Code:       "order_ahead",
+			Message:    fmt.Sprintf("The order file is for year %d but the server is only at year %d", orderYear, serverYear),
+			Suggestion: fmt.Sprintf("The order file comes from another game or a restored folder: download the turn of year %d and play it", serverYear),
+		})
+	}
+
+	if skewKnown && (skew > maxClockSkew || skew < -maxClockSkew) {
+		direction := "behind"
+		if skew < 0 {
+			direction, skew = "ahead of", -skew
+		}
+		issues = append(issues, TurnYearIssueInfo{
+			Code:       "clock_skew",
+			Message:    fmt.Sprintf("The system clock is %s %s the server clock", skew.Round(time.Minute), direction),
+			Suggestion: "Set the system clock to the right time, e.g. by turning automatic time synchronization on",
+		})
+	}
+	return issues
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTurnYearIssues_Healthy(t *testing.T) {
+	assert.Empty(t, turnYearIssues(2405, 2405, 2405, 3*time.Second, true))
+	assert.Empty(t, turnYearIssues(2405, 2404, 2404, 0, false), "files behind the server are only stale")
+}
+
+func TestTurnYearIssues_Ahead(t *testing.T) {
+	issues := turnYearIssues(2402, 2410, 2410, 0, false)
+
+	require.Len(t, issues, 2)
+	assert.Equal(t, "turn_ahead", issues[0].Code)
+	assert.Equal(t, "order_ahead", issues[1].Code)
+	assert.Contains(t, issues[1].Message, "year 2410")
+	assert.Contains(t, issues[1].Suggestion, "2402")
+}
+
+func TestTurnYearIssues_ClockSkew(t *testing.T) {
+	issues := turnYearIssues(2400, 0, 0, 2*time.Hour, true)
+	require.Len(t, issues, 1)
+	assert.Equal(t, "clock_skew", issues[0].Code)
+	assert.Contains(t, issues[0].Message, "2h0m0s behind")
+
+	issues = turnYearIssues(2400, 0, 0, -30*time.Minute, true)
+	require.Len(t, issues, 1)
+	assert.Contains(t, issues[0].Message, "30m0s ahead of")
+
+	assert.Empty(t, turnYearIssues(2400, 0, 0, 2*time.Hour, false), "unknown skew is not reported")
+}
+
+func TestClockFinding(t *testing.T) {
+	assert.Equal(t, selfTestPass, clockFinding(time.Second).Status)
+
+	finding := clockFinding(-time.Hour)
+	assert.Equal(t, "clock", finding.Check)
+	assert.Equal(t, selfTestFail, finding.Status)
+	assert.NotEmpty(t, finding.Suggestion)
+}
@@ -30,6 +30,60 @@ const BucketAppSettings = "app_settings"
 // BucketFileHashes is the bucket name for tracking file hashes
 const BucketFileHashes = "file_hashes"
 
+// BucketIgnoreLists is the bucket name for per-server invitation ignore lists
+const BucketIgnoreLists = "ignore_lists"
+
+// BucketInvitationTracking is the bucket name for per-server invitation first-seen times
+const BucketInvitationTracking = "invitation_tracking"
+
+// BucketMapPresets is the bucket name for named map render presets
+const BucketMapPresets = "map_presets"
+
+// BucketSessionMapSettings is the bucket name for per-session map settings
+const BucketSessionMapSettings = "session_map_settings"
+
+// BucketSubmissionLog is the bucket name for the log of uploaded orders
+const BucketSubmissionLog = "submission_log"
+
+// BucketSessionTimelines is the bucket name for per-session timeline events
+const BucketSessionTimelines = "session_timelines"
+
+// BucketSessionDirs is the bucket name for human-readable session directory names
+const BucketSessionDirs = "session_dirs"
+
+// BucketConfigAudit is the bucket name for the append-only log of configuration changes
+const BucketConfigAudit = "config_audit"
+
+// BucketTelemetry is the bucket name for the usage counts of the opt-in telemetry
+const BucketTelemetry = "telemetry"
+
+// BucketSessionCredentials is the bucket name for the credentials used by specific sessions
+const BucketSessionCredentials = "session_credentials"
+
+// BucketServerCache is the bucket name for the last known server data, shown while offline
+const BucketServerCache = "server_cache"
+
+// BucketSessionWatchDirs is the bucket name for the extra directories watched for the orders of a session
+const BucketSessionWatchDirs = "session_watch_dirs"
+
+// BucketProductionTemplates is the bucket name for named production queue templates
+const BucketProductionTemplates = "production_templates"
+
+// BucketProductionPlans is the bucket name for the per-planet production plans of a session
+const BucketProductionPlans = "production_plans"
+
+// BucketAnnouncementReads is the bucket name for the last server announcement read on each server
+const BucketAnnouncementReads = "announcement_reads"
+
+// BucketLocalGames is the bucket name for the games played without a server
+const BucketLocalGames = "local_games"
+
+// BucketSessionLaunchEnv is the bucket name for the environment variables set when launching Stars! for a session
+const BucketSessionLaunchEnv = "session_launch_env"
+
+// BucketLaunchHistory is the bucket name for the recent Stars! launches of each session
+const BucketLaunchHistory = "launch_history"
+
 // Open returns a BBolt database or an error
 // It will initialize one if none is found in the config dir
 // configPath should be the directory where the database file will be stored
@@ -78,6 +132,60 @@ func (db *DB) initBuckets() error {
 		if _, err := tx.CreateBucketIfNotExists([]byte(BucketFileHashes)); err != nil {
 			return err
 		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketIgnoreLists)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketInvitationTracking)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketMapPresets)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketSessionMapSettings)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketSubmissionLog)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketSessionTimelines)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketSessionDirs)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketConfigAudit)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketTelemetry)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketSessionCredentials)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketServerCache)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketSessionWatchDirs)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketProductionTemplates)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketProductionPlans)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketAnnouncementReads)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketLocalGames)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketSessionLaunchEnv)); err != nil {
+			return err
+		}
+		if _, err := tx.CreateBucketIfNotExists([]byte(BucketLaunchHistory)); err != nil {
+			return err
+		}
 		return nil
 	})
 }
@@ -87,6 +195,36 @@ func (db *DB) Close() error {
 	return db.bolt.Close()
 }
 
+// Lock is an exclusive lock on a file, held by one process at a time for as
+// long as it is open. It is taken like the lock of the database, so it works
+// the same on every platform.
+type Lock struct {
+	bolt *bolt.DB
+}
+
+// OpenLock takes the lock of a file, created if needed. Returns
+// ErrDatabaseLocked if another process holds it.
+func OpenLock(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+	boltDB, err := bolt.Open(path, 0600, &bolt.Options{
+		Timeout: 1 * time.Second,
+	})
+	if err != nil {
+		if errors.Is(err, berrors.ErrTimeout) {
+			return nil, ErrDatabaseLocked
+		}
+		return nil, fmt.Errorf("failed to open lock: %w", err)
+	}
+	return &Lock{bolt: boltDB}, nil
+}
+
+// Close releases the lock
+func (l *Lock) Close() error {
+	return l.bolt.Close()
+}
+
 // Get retrieves a value by key from a bucket
 func (db *DB) Get(bucket, key string) ([]byte, error) {
 	var value []byte
@@ -164,3 +302,109 @@ func (db *DB) Keys(bucket string) ([]string, error) {
 	})
 	return keys, err
 }
+
+// Tx is a read-write transaction, see Update
+type Tx struct {
+	tx *bolt.Tx
+}
+
+// Update runs fn in a single read-write transaction: the changes it makes are
+// all applied if fn returns nil, and all rolled back if it returns an error
+func (db *DB) Update(fn func(tx *Tx) error) error {
+	return db.bolt.Update(func(tx *bolt.Tx) error {
+		return fn(&Tx{tx: tx})
+	})
+}
+
+// bucket returns a bucket of the transaction
+func (t *Tx) bucket(name string) (*bolt.Bucket, error) {
+	b := t.tx.Bucket([]byte(name))
+	if b == nil {
+		return nil, fmt.Errorf("bucket %s not found", name)
+	}
+	return b, nil
+}
+
+// Get retrieves a value by key from a bucket, including changes made earlier in the transaction
+func (t *Tx) Get(bucket, key string) ([]byte, error) {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	v := b.Get([]byte(key))
+	if v == nil {
+		return nil, nil
+	}
+	// Copy the value since it's only valid within the transaction
+	value := make([]byte, len(v))
+	copy(value, v)
+	return value, nil
+}
+
+// Set stores a value by key in a bucket
+func (t *Tx) Set(bucket, key string, value []byte) error {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), value)
+}
+
+// Delete removes a key from a bucket
+func (t *Tx) Delete(bucket, key string) error {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	return b.Delete([]byte(key))
+}
+
+// Keys returns all keys in a bucket
+func (t *Tx) Keys(bucket string) ([]string, error) {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	err = b.ForEach(func(k, v []byte) error {
+		keys = append(keys, string(k))
+		return nil
+	})
+	return keys, err
+}
+
+// Clear removes every key of a bucket
+func (t *Tx) Clear(bucket string) error {
+	if _, err := t.bucket(bucket); err != nil {
+		return err
+	}
+	if err := t.tx.DeleteBucket([]byte(bucket)); err != nil {
+		return err
+	}
+	_, err := t.tx.CreateBucket([]byte(bucket))
+	return err
+}
+
+// Buckets returns the names of all the buckets
+func (t *Tx) Buckets() ([]string, error) {
+	var names []string
+	err := t.tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		names = append(names, string(name))
+		return nil
+	})
+	return names, err
+}
+
+// Append stores a value under the next key of a bucket. Keys are zero-padded
+// sequence numbers, so they sort in insertion order.
+func (t *Tx) Append(bucket string, value []byte) error {
+	b, err := t.bucket(bucket)
+	if err != nil {
+		return err
+	}
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(fmt.Sprintf("%020d", seq)), value)
+}
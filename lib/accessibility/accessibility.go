@@ -0,0 +1,39 @@
+// Package accessibility reads the accessibility preferences of the operating
+// system, so the interface can follow them without platform specific hacks
+// in the frontend.
+package accessibility
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// commandTimeout bounds the commands querying the desktop settings
+const commandTimeout = 2 * time.Second
+
+// Preferences are the accessibility preferences of the operating system
+type Preferences struct {
+	ReducedMotion bool // animations should be avoided
+	HighContrast  bool // a high contrast theme is in use
+}
+
+// Detect returns the current preferences. Preferences that can't be read
+// on this platform or desktop are reported as off.
+func Detect() Preferences {
+	return detect()
+}
+
+// commandOutput runs a settings command and returns its trimmed output,
+// empty when it fails
+func commandOutput(name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
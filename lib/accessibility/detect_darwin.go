@@ -0,0 +1,9 @@
+package accessibility
+
+// detect reads the Accessibility > Display settings
+func detect() Preferences {
+	return Preferences{
+		ReducedMotion: commandOutput("defaults", "read", "com.apple.universalaccess", "reduceMotion") == "1",
+		HighContrast:  commandOutput("defaults", "read", "com.apple.universalaccess", "increaseContrast") == "1",
+	}
+}
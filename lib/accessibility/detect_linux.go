@@ -0,0 +1,19 @@
+package accessibility
+
+import (
+	"os"
+	"strings"
+)
+
+// detect reads the GNOME settings, also used by most GTK based desktops
+func detect() Preferences {
+	var prefs Preferences
+
+	prefs.ReducedMotion = commandOutput("gsettings", "get", "org.gnome.desktop.interface", "enable-animations") == "false"
+
+	prefs.HighContrast = commandOutput("gsettings", "get", "org.gnome.desktop.a11y.interface", "high-contrast") == "true" ||
+		strings.Contains(strings.ToLower(commandOutput("gsettings", "get", "org.gnome.desktop.interface", "gtk-theme")), "highcontrast") ||
+		strings.Contains(strings.ToLower(os.Getenv("GTK_THEME")), "highcontrast")
+
+	return prefs
+}
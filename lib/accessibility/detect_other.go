@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package accessibility
+
+// detect reports no preference on platforms without a known settings store
+func detect() Preferences {
+	return Preferences{}
+}
@@ -0,0 +1,43 @@
+package accessibility
+
+import (
+	"strconv"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// highContrastOn is the HCF_HIGHCONTRASTON flag of the high contrast settings
+const highContrastOn = 0x1
+
+// detect reads the Ease of Access settings from the registry
+func detect() Preferences {
+	var prefs Preferences
+
+	// Animations are off when "Show animations in Windows" is unchecked
+	if value, ok := registryString(`Control Panel\Desktop\WindowMetrics`, "MinAnimate"); ok {
+		prefs.ReducedMotion = value == "0"
+	}
+
+	if value, ok := registryString(`Control Panel\Accessibility\HighContrast`, "Flags"); ok {
+		if flags, err := strconv.Atoi(value); err == nil {
+			prefs.HighContrast = flags&highContrastOn != 0
+		}
+	}
+
+	return prefs
+}
+
+// registryString reads a string value of the current user registry
+func registryString(path, name string) (string, bool) {
+	key, err := registry.OpenKey(registry.CURRENT_USER, path, registry.QUERY_VALUE)
+	if err != nil {
+		return "", false
+	}
+	defer key.Close()
+
+	value, _, err := key.GetStringValue(name)
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
@@ -0,0 +1,61 @@
+// Package artifact writes files generated by Astrum (maps, reports...) into
+// game directories according to the user's file-name collision policy.
+package artifact
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// Collision policies
+const (
+	PolicyOverwrite = "overwrite" // replace the existing file
+	PolicyTimestamp = "timestamp" // keep the existing file, add a timestamp suffix to the new one
+	PolicyPrompt    = "prompt"    // refuse with ExistsError so the user can be asked
+)
+
+// IsPolicy reports whether name is a known collision policy
+func IsPolicy(name string) bool {
+	return name == PolicyOverwrite || name == PolicyTimestamp || name == PolicyPrompt
+}
+
+// ExistsError is returned under PolicyPrompt when the target file already exists
+type ExistsError struct {
+	Path string
+}
+
+func (e *ExistsError) Error() string {
+	return fmt.Sprintf("file already exists: %s", filepath.Base(e.Path))
+}
+
+// Write stores data at path following policy and returns the path actually written.
+// overwrite forces replacing an existing file, e.g. once the user confirmed a prompt.
+func Write(path string, data []byte, policy string, overwrite bool) (string, error) {
+	if !overwrite && policy != PolicyOverwrite {
+		if _, err := os.Stat(path); err == nil {
+			if policy == PolicyPrompt {
+				return "", &ExistsError{Path: path}
+			}
+			path = timestamped(path, time.Now())
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := safefile.WriteFile(path, data, 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// timestamped inserts a timestamp before the extension of path
+func timestamped(path string, now time.Time) string {
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(path, ext), now.Format("20060102-150405"), ext)
+}
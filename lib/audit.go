@@ -0,0 +1,192 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/neper-stars/astrum/database"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// CONFIGURATION AUDIT LOG
+// =============================================================================
+
+// auditIgnoredFields are bookkeeping fields updated on their own, which would
+// bury real configuration changes in the audit log
+var auditIgnoredFields = map[string]bool{
+	"windowGeometry": true, // app settings, saved when the window closes
+	"windowLayouts":  true, // app settings, saved when the window closes
+	"last_connected": true, // server, saved on every connection
+}
+
+// auditRedactedFields are secret fields whose values are not recorded
+var auditRedactedFields = map[string]bool{
+	"kioskPinHash": true,
+}
+
+// credentialFields are the server fields holding credential references
+var credentialFields = map[string]bool{
+	"credential_refs":   true,
+	"default_cred_name": true,
+}
+
+// auditChange appends an entry for a mutation to the audit log, within the
+// transaction making the change. oldData and newData are the JSON encoded
+// values before and after (nil when added or removed); updates changing
+// nothing but ignored fields are not recorded.
+func auditChange(tx *database.Tx, kind, action, target string, oldData, newData []byte) error {
+	changes, err := configDiff(oldData, newData)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 && action == model.AuditActionUpdate {
+		return nil
+	}
+
+	if kind == model.AuditKindServer && action == model.AuditActionUpdate {
+		onlyCredentials := true
+		for _, change := range changes {
+			onlyCredentials = onlyCredentials && credentialFields[change.Field]
+		}
+		if onlyCredentials {
+			kind = model.AuditKindCredentials
+		}
+	}
+
+	data, err := jsoniter.Marshal(model.ConfigAuditEntry{
+		Time:    time.Now(),
+		Kind:    kind,
+		Action:  action,
+		Target:  target,
+		Source:  auditSource(),
+		Changes: changes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+	return tx.Append(database.BucketConfigAudit, data)
+}
+
+// configDiff compares the top-level fields of two JSON objects
+func configDiff(oldData, newData []byte) ([]model.ConfigChange, error) {
+	oldFields := make(map[string]jsoniter.RawMessage)
+	newFields := make(map[string]jsoniter.RawMessage)
+	if oldData != nil {
+		if err := jsoniter.Unmarshal(oldData, &oldFields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal previous value: %w", err)
+		}
+	}
+	if newData != nil {
+		if err := jsoniter.Unmarshal(newData, &newFields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal new value: %w", err)
+		}
+	}
+
+	fields := make([]string, 0, len(oldFields)+len(newFields))
+	for field := range oldFields {
+		fields = append(fields, field)
+	}
+	for field := range newFields {
+		if _, ok := oldFields[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []model.ConfigChange
+	for _, field := range fields {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		oldValue, newValue := auditValue(oldFields[field]), auditValue(newFields[field])
+		if oldValue != newValue {
+			if auditRedactedFields[field] {
+				oldValue, newValue = redactedValue(oldValue), redactedValue(newValue)
+			}
+			changes = append(changes, model.ConfigChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	return changes, nil
+}
+
+// auditValue returns a JSON value as recorded in the audit log, empty when unset
+func auditValue(raw jsoniter.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return ""
+	}
+	return string(raw)
+}
+
+// redactedValue hides a secret value, still telling whether it was set
+func redactedValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return `"[redacted]"`
+}
+
+// auditSkippedPackages are the packages between a caller and the audit log
+var auditSkippedPackages = []string{
+	"github.com/neper-stars/astrum/lib.",
+	"github.com/neper-stars/astrum/database.",
+	"go.etcd.io/bbolt.",
+}
+
+// auditSource returns the first function outside the config and database
+// packages on the call stack, e.g. "main.(*App).SetUseWine", telling which
+// action made a change
+func auditSource() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		skipped := false
+		for _, prefix := range auditSkippedPackages {
+			skipped = skipped || strings.HasPrefix(frame.Function, prefix)
+		}
+		if !skipped {
+			return strings.TrimPrefix(frame.Function, "github.com/neper-stars/astrum/")
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// GetConfigAuditLog returns the recorded configuration changes, newest first.
+// A limit of 0 or less returns all of them.
+func (c *Config) GetConfigAuditLog(limit int) ([]model.ConfigAuditEntry, error) {
+	allData, err := c.db.GetAll(database.BucketConfigAudit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config audit log: %w", err)
+	}
+
+	keys := make([]string, 0, len(allData))
+	for key := range allData {
+		keys = append(keys, key)
+	}
+	// Keys are zero-padded sequence numbers
+	sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	entries := make([]model.ConfigAuditEntry, 0, len(keys))
+	for _, key := range keys {
+		var entry model.ConfigAuditEntry
+		if err := jsoniter.Unmarshal(allData[key], &entry); err != nil {
+			fmt.Printf("Warning: failed to unmarshal config audit entry: %v\n", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
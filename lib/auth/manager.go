@@ -52,8 +52,13 @@ func (m *Manager) SetOnTokenRefreshed(fn func(newToken string)) {
 
 // Connect authenticates with the server and starts the auto-refresh loop
 func (m *Manager) Connect(nickname, apikey string) error {
+	return m.ConnectContext(m.ctx, nickname, apikey)
+}
+
+// ConnectContext is Connect with a context aborting the authentication
+func (m *Manager) ConnectContext(ctx context.Context, nickname, apikey string) error {
 	// Authenticate
-	token, err := m.client.Authenticate(m.ctx, nickname, apikey)
+	token, err := m.client.Authenticate(ctx, nickname, apikey)
 	if err != nil {
 		m.notifyConnectionState(false, err)
 		return fmt.Errorf("authentication failed: %w", err)
@@ -62,7 +67,7 @@ func (m *Manager) Connect(nickname, apikey string) error {
 	logger.Auth.Debug().Str("token_prefix", token[:20]).Msg("Authenticated successfully")
 
 	// Get user info
-	userInfo, err := m.client.GetUserInfo(m.ctx)
+	userInfo, err := m.client.GetUserInfo(ctx)
 	if err != nil {
 		m.notifyConnectionState(false, err)
 		return fmt.Errorf("failed to get user info: %w", err)
@@ -126,20 +131,50 @@ func (m *Manager) GetToken() string {
 	return m.client.GetToken()
 }
 
-// tokenRefreshLoop automatically refreshes the JWT token before it expires
+// Bounds of the margin before expiry at which tokens are refreshed
+const (
+	minRefreshMargin = 30 * time.Second
+	maxRefreshMargin = 5 * time.Minute
+)
+
+// refreshGracePeriod is how long a transiently failing token refresh is retried,
+// API requests being held meanwhile, before the connection is dropped
+const refreshGracePeriod = time.Minute
+
+// refreshRetryInterval is the delay between token refresh retries
+const refreshRetryInterval = 5 * time.Second
+
+// minRefreshDelay keeps a token with a short or unknown lifetime from being
+// refreshed in a tight loop
+const minRefreshDelay = 10 * time.Second
+
+// refreshDelay returns how long to wait before refreshing a token expiring at
+// expiry: a fifth of its remaining lifetime before it expires, within the
+// margin bounds, which leaves room for clock skew and slow requests
+func refreshDelay(now, expiry time.Time) time.Duration {
+	remaining := expiry.Sub(now)
+	margin := min(max(remaining/5, minRefreshMargin), maxRefreshMargin)
+	return max(remaining-margin, minRefreshDelay)
+}
+
+// tokenRefreshLoop automatically refreshes the JWT token before it expires,
+// scheduled from the expiry of the current token
 func (m *Manager) tokenRefreshLoop() {
 	defer m.wg.Done()
 
-	// Refresh token every 4 minutes (tokens expire in 5 minutes)
-	ticker := time.NewTicker(4 * time.Minute)
-	defer ticker.Stop()
+	timer := time.NewTimer(refreshDelay(time.Now(), m.client.TokenExpiry()))
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-m.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-timer.C:
 			newToken, err := m.client.RefreshToken(m.ctx)
+			if err != nil && api.IsTransient(err) {
+				logger.Auth.Warn().Err(err).Msg("Failed to refresh token, retrying during grace period")
+				newToken, err = m.refreshWithGrace()
+			}
 			if err != nil {
 				logger.Auth.Error().Err(err).Msg("Failed to refresh token")
 				m.mu.Lock()
@@ -148,11 +183,40 @@ func (m *Manager) tokenRefreshLoop() {
 				m.notifyConnectionState(false, err)
 				return
 			}
-			logger.Auth.Debug().Msg("Token refreshed successfully")
+			expiry := m.client.TokenExpiry()
+			logger.Auth.Debug().Time("expiry", expiry).Msg("Token refreshed successfully")
 
 			// Notify about the new token
 			m.notifyTokenRefreshed(newToken)
+			timer.Reset(refreshDelay(time.Now(), expiry))
+		}
+	}
+}
+
+// refreshWithGrace retries a token refresh that failed transiently, holding
+// the API requests meanwhile, until it succeeds, fails for good or
+// refreshGracePeriod is over
+func (m *Manager) refreshWithGrace() (string, error) {
+	m.client.BeginGrace()
+	defer m.client.EndGrace()
+
+	deadline := time.Now().Add(refreshGracePeriod)
+	for {
+		select {
+		case <-m.ctx.Done():
+			return "", m.ctx.Err()
+		case <-time.After(refreshRetryInterval):
+		}
+
+		token, err := m.client.RefreshToken(m.ctx)
+		if err == nil {
+			logger.Auth.Info().Msg("Token refreshed after grace period")
+			return token, nil
+		}
+		if !api.IsTransient(err) || time.Now().After(deadline) {
+			return "", err
 		}
+		logger.Auth.Debug().Err(err).Msg("Token refresh retry failed")
 	}
 }
 
@@ -1,10 +1,18 @@
 package lib
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -13,19 +21,51 @@ import (
 	"github.com/kirsle/configdir"
 
 	"github.com/neper-stars/astrum/database"
+	"github.com/neper-stars/astrum/lib/artifact"
+	"github.com/neper-stars/astrum/lib/safefile"
+	"github.com/neper-stars/astrum/lib/telemetry"
+	"github.com/neper-stars/astrum/lib/trash"
 	"github.com/neper-stars/astrum/model"
 )
 
+// configDirOverride replaces the config directory when set, see SetConfigDir
+var configDirOverride string
+
 func ConfigPath() string {
+	if configDirOverride != "" {
+		return configDirOverride
+	}
 	// on linux this resolves to something like: ~/.config/<appname>
 	// lower-cased AppName
 	return configdir.LocalConfig(strings.ToLower(AppName))
 }
 
+// SetConfigDir makes Astrum use another config directory, with its own
+// database and profiles, e.g. for a staging install next to the main one.
+// Must be called at startup, before anything reads the config directory.
+func SetConfigDir(dir string) error {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("invalid config directory %q: %w", dir, err)
+	}
+	configDirOverride = abs
+	return nil
+}
+
+// ConfigDirOverride returns the config directory set with SetConfigDir, "" if none
+func ConfigDirOverride() string {
+	return configDirOverride
+}
+
 func IconPath() string {
 	return filepath.Join(ConfigPath(), MainIcon)
 }
 
+// PluginsPath returns the directory where turn post-processing plugins are installed
+func PluginsPath() string {
+	return filepath.Join(ConfigPath(), "plugins")
+}
+
 // Config manages application configuration using BBolt for metadata
 // and system keyring for credentials
 type Config struct {
@@ -62,13 +102,82 @@ func (c *Config) AddServer(server model.Server) error {
 		return fmt.Errorf("failed to marshal server: %w", err)
 	}
 
-	if err := c.db.Set(database.BucketServers, server.URL, data); err != nil {
+	err = c.db.Update(func(tx *database.Tx) error {
+		oldData, err := tx.Get(database.BucketServers, server.URL)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(database.BucketServers, server.URL, data); err != nil {
+			return err
+		}
+		action := model.AuditActionUpdate
+		if oldData == nil {
+			action = model.AuditActionAdd
+		}
+		return auditChange(tx, model.AuditKindServer, action, server.URL, oldData, data)
+	})
+	if err != nil {
 		return fmt.Errorf("failed to save server: %w", err)
 	}
 
 	return nil
 }
 
+// MoveServer saves a server that changed URL and moves the data stored under
+// its old URL (ignore list, invitation tracking, announcement read state,
+// per-session settings and timelines) in a single transaction: either everything moves or nothing does.
+// Keyring credentials are not part of the database and must be moved separately.
+func (c *Config) MoveServer(oldURL string, server model.Server) error {
+	data, err := jsoniter.Marshal(server)
+	if err != nil {
+		return fmt.Errorf("failed to marshal server: %w", err)
+	}
+
+	err = c.db.Update(func(tx *database.Tx) error {
+		oldData, err := tx.Get(database.BucketServers, oldURL)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(database.BucketServers, server.URL, data); err != nil {
+			return err
+		}
+		if oldURL == server.URL {
+			return auditChange(tx, model.AuditKindServer, model.AuditActionUpdate, server.URL, oldData, data)
+		}
+		if err := auditChange(tx, model.AuditKindServer, model.AuditActionMove, server.URL, oldData, data); err != nil {
+			return err
+		}
+
+		for _, bucket := range []string{database.BucketIgnoreLists, database.BucketInvitationTracking, database.BucketAnnouncementReads} {
+			value, err := tx.Get(bucket, oldURL)
+			if err != nil {
+				return err
+			}
+			if value == nil {
+				continue
+			}
+			if err := tx.Set(bucket, server.URL, value); err != nil {
+				return err
+			}
+			if err := tx.Delete(bucket, oldURL); err != nil {
+				return err
+			}
+		}
+
+		for _, bucket := range []string{database.BucketSessionMapSettings, database.BucketSessionTimelines, database.BucketSessionCredentials, database.BucketServerCache, database.BucketSessionWatchDirs, database.BucketProductionPlans, database.BucketSessionLaunchEnv, database.BucketLaunchHistory} {
+			if err := moveKeys(tx, bucket, sessionKey(oldURL, ""), sessionKey(server.URL, "")); err != nil {
+				return err
+			}
+		}
+
+		return tx.Delete(database.BucketServers, oldURL)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move server: %w", err)
+	}
+	return nil
+}
+
 // GetServer retrieves a server by URL
 func (c *Config) GetServer(url string) (*model.Server, error) {
 	data, err := c.db.Get(database.BucketServers, url)
@@ -132,7 +241,7 @@ func (c *Config) SetServers(servers model.Servers) error {
 					_ = c.creds.Delete(key, cred.NickName)
 				}
 			}
-			if err := c.db.Delete(database.BucketServers, key); err != nil {
+			if err := c.deleteServer(key); err != nil {
 				fmt.Printf("Warning: failed to delete server %s: %v\n", key, err)
 			}
 		}
@@ -154,7 +263,28 @@ func (c *Config) UpdateServer(server model.Server) error {
 }
 
 // RemoveServer removes a server and its credentials
-func (c *Config) RemoveServer(url string) error {
+func (c *Config) RemoveServer(url string, deleteCredentials bool) error {
+	if deleteCredentials {
+		if err := c.DeleteServerCredentials(url); err != nil {
+			return err
+		}
+	}
+
+	// Delete the server
+	if err := c.deleteServer(url); err != nil {
+		return fmt.Errorf("failed to delete server: %w", err)
+	}
+
+	// The offline cache is useless once the server is gone
+	if err := c.DeleteServerCache(url); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	return nil
+}
+
+// DeleteServerCredentials removes the keyring credentials and serial key of a server
+func (c *Config) DeleteServerCredentials(url string) error {
 	// Get server to find credentials to delete
 	server, err := c.GetServer(url)
 	if err != nil {
@@ -169,15 +299,29 @@ func (c *Config) RemoveServer(url string) error {
 			}
 		}
 	}
-
-	// Delete the server
-	if err := c.db.Delete(database.BucketServers, url); err != nil {
-		return fmt.Errorf("failed to delete server: %w", err)
+	if err := c.creds.DeleteSerialKey(url); err != nil {
+		fmt.Printf("Warning: failed to delete serial key: %v\n", err)
 	}
-
 	return nil
 }
 
+// deleteServer deletes a server entry, recording it in the audit log
+func (c *Config) deleteServer(url string) error {
+	return c.db.Update(func(tx *database.Tx) error {
+		oldData, err := tx.Get(database.BucketServers, url)
+		if err != nil {
+			return err
+		}
+		if oldData == nil {
+			return nil
+		}
+		if err := tx.Delete(database.BucketServers, url); err != nil {
+			return err
+		}
+		return auditChange(tx, model.AuditKindServer, model.AuditActionRemove, url, oldData, nil)
+	})
+}
+
 // SaveCredential stores a credential in the keyring and updates the server
 func (c *Config) SaveCredential(serverURL, username, apiKey string) error {
 	// Store in keyring
@@ -224,290 +368,1906 @@ func (c *Config) RemoveCredential(serverURL, username string) error {
 }
 
 // =============================================================================
-// SERVERS DIRECTORY CONFIGURATION
+// INVITATION IGNORE LISTS
 // =============================================================================
 
-const (
-	// AppSettingsKey is the key for app settings in the database
-	AppSettingsKey = "settings"
-)
+// GetIgnoreList retrieves the invitation ignore list of a server
+// Returns an empty list if none has been saved yet
+func (c *Config) GetIgnoreList(serverURL string) (*model.IgnoreList, error) {
+	data, err := c.db.Get(database.BucketIgnoreLists, serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ignore list: %w", err)
+	}
+	if data == nil {
+		return &model.IgnoreList{}, nil
+	}
 
-// WindowGeometry stores window position and size
-type WindowGeometry struct {
-	X      int `json:"x"`
-	Y      int `json:"y"`
-	Width  int `json:"width"`
-	Height int `json:"height"`
-}
+	var list model.IgnoreList
+	if err := jsoniter.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal ignore list: %w", err)
+	}
 
-// AppSettings stores global application settings
-type AppSettings struct {
-	ServersDir         string          `json:"serversDir"`
-	AutoDownloadStars  *bool           `json:"autoDownloadStars"`  // nil means default (true)
-	ZoomLevel          *int            `json:"zoomLevel"`          // nil means default (100)
-	UseWine            *bool           `json:"useWine"`            // nil means default (false)
-	WinePrefixesDir    *string         `json:"winePrefixesDir"`    // nil means default (~/.config/astrum/wine_prefixes)
-	ValidWineInstall   *bool           `json:"validWineInstall"`   // nil means not checked yet (default: false)
-	WindowGeometry     *WindowGeometry `json:"windowGeometry"`     // nil means use defaults
-	EnableBrowserStars *bool           `json:"enableBrowserStars"` // nil means default (false) - experimental browser Stars! support
+	return &list, nil
 }
 
-// GetAutoDownloadStars returns the auto download setting (default: true)
-func (s *AppSettings) GetAutoDownloadStars() bool {
-	if s.AutoDownloadStars == nil {
-		return true // default
+// SetIgnoreList stores the invitation ignore list of a server
+func (c *Config) SetIgnoreList(serverURL string, list *model.IgnoreList) error {
+	data, err := jsoniter.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to marshal ignore list: %w", err)
 	}
-	return *s.AutoDownloadStars
-}
 
-// GetZoomLevel returns the zoom level as percentage (default: 100)
-func (s *AppSettings) GetZoomLevel() int {
-	if s.ZoomLevel == nil {
-		return 100 // default 100%
+	if err := c.db.Set(database.BucketIgnoreLists, serverURL, data); err != nil {
+		return fmt.Errorf("failed to save ignore list: %w", err)
 	}
-	return *s.ZoomLevel
+
+	return nil
 }
 
-// GetUseWine returns the use wine setting (default: false)
-func (s *AppSettings) GetUseWine() bool {
-	if s.UseWine == nil {
-		return false // default
+// DeleteIgnoreList removes the invitation ignore list of a server
+func (c *Config) DeleteIgnoreList(serverURL string) error {
+	if err := c.db.Delete(database.BucketIgnoreLists, serverURL); err != nil {
+		return fmt.Errorf("failed to delete ignore list: %w", err)
 	}
-	return *s.UseWine
+	return nil
 }
 
-// GetWinePrefixesDir returns the wine prefixes directory path (default: ~/.config/astrum/wine_prefixes)
-// This directory contains per-server wine prefixes, with each server getting its own subdirectory.
-func (s *AppSettings) GetWinePrefixesDir() string {
-	if s.WinePrefixesDir == nil {
-		return DefaultWinePrefixesDir()
+// GetInvitationTracking retrieves the invitation first-seen times of a server
+func (c *Config) GetInvitationTracking(serverURL string) (*model.InvitationTracking, error) {
+	data, err := c.db.Get(database.BucketInvitationTracking, serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invitation tracking: %w", err)
 	}
-	return *s.WinePrefixesDir
+	if data == nil {
+		return &model.InvitationTracking{}, nil
+	}
+
+	var tracking model.InvitationTracking
+	if err := jsoniter.Unmarshal(data, &tracking); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal invitation tracking: %w", err)
+	}
+
+	return &tracking, nil
 }
 
-// GetValidWineInstall returns the wine installation validation status (default: false)
-func (s *AppSettings) GetValidWineInstall() bool {
-	if s.ValidWineInstall == nil {
-		return false // default: not validated
+// SetInvitationTracking stores the invitation first-seen times of a server
+func (c *Config) SetInvitationTracking(serverURL string, tracking *model.InvitationTracking) error {
+	data, err := jsoniter.Marshal(tracking)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invitation tracking: %w", err)
 	}
-	return *s.ValidWineInstall
+
+	if err := c.db.Set(database.BucketInvitationTracking, serverURL, data); err != nil {
+		return fmt.Errorf("failed to save invitation tracking: %w", err)
+	}
+
+	return nil
 }
 
-// GetEnableBrowserStars returns the experimental browser Stars! setting (default: false)
-func (s *AppSettings) GetEnableBrowserStars() bool {
-	if s.EnableBrowserStars == nil {
-		return false // default: disabled
+// DeleteInvitationTracking removes the invitation first-seen times of a server
+func (c *Config) DeleteInvitationTracking(serverURL string) error {
+	if err := c.db.Delete(database.BucketInvitationTracking, serverURL); err != nil {
+		return fmt.Errorf("failed to delete invitation tracking: %w", err)
 	}
-	return *s.EnableBrowserStars
+	return nil
 }
 
-// DefaultWinePrefixesDir returns the default wine prefixes directory path
-// Each server will have its own wine prefix subdirectory under this path,
-// allowing different serial keys per server.
-func DefaultWinePrefixesDir() string {
-	home, err := os.UserHomeDir()
+// GetAnnouncementRead returns the version of the last announcement read on a
+// server, "" when none was
+func (c *Config) GetAnnouncementRead(serverURL string) (string, error) {
+	data, err := c.db.Get(database.BucketAnnouncementReads, serverURL)
 	if err != nil {
-		return filepath.Join(".", ".config", "astrum", "wine_prefixes")
+		return "", fmt.Errorf("failed to get announcement read state: %w", err)
 	}
-	return filepath.Join(home, ".config", "astrum", "wine_prefixes")
+	return string(data), nil
 }
 
-// DefaultServersDir returns the default servers directory path
-func DefaultServersDir() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		// Fallback to current directory
-		return filepath.Join(".", ".astrum", "servers")
+// SetAnnouncementRead records the last announcement read on a server
+func (c *Config) SetAnnouncementRead(serverURL, version string) error {
+	if err := c.db.Set(database.BucketAnnouncementReads, serverURL, []byte(version)); err != nil {
+		return fmt.Errorf("failed to save announcement read state: %w", err)
 	}
-	return filepath.Join(home, ".astrum", "servers")
+	return nil
 }
 
-// GetAppSettings retrieves the app settings from the database
-func (c *Config) GetAppSettings() (*AppSettings, error) {
-	data, err := c.db.Get(database.BucketAppSettings, AppSettingsKey)
+// DeleteAnnouncementRead removes the announcement read state of a server
+func (c *Config) DeleteAnnouncementRead(serverURL string) error {
+	if err := c.db.Delete(database.BucketAnnouncementReads, serverURL); err != nil {
+		return fmt.Errorf("failed to delete announcement read state: %w", err)
+	}
+	return nil
+}
+
+// submissionLogKey is the key of the order upload log in its bucket
+const submissionLogKey = "log"
+
+// GetSubmissionLog retrieves the log of uploaded orders
+func (c *Config) GetSubmissionLog() (*model.SubmissionLog, error) {
+	data, err := c.db.Get(database.BucketSubmissionLog, submissionLogKey)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get app settings: %w", err)
+		return nil, fmt.Errorf("failed to get submission log: %w", err)
 	}
 	if data == nil {
-		// Return default settings if not found
-		return &AppSettings{
-			ServersDir: DefaultServersDir(),
-		}, nil
+		return &model.SubmissionLog{}, nil
 	}
 
-	var settings AppSettings
-	if err := jsoniter.Unmarshal(data, &settings); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal app settings: %w", err)
+	var log model.SubmissionLog
+	if err := jsoniter.Unmarshal(data, &log); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal submission log: %w", err)
 	}
-
-	return &settings, nil
+	return &log, nil
 }
 
-// SetAppSettings stores the app settings in the database
-func (c *Config) SetAppSettings(settings *AppSettings) error {
-	data, err := jsoniter.Marshal(settings)
+// SetSubmissionLog stores the log of uploaded orders
+func (c *Config) SetSubmissionLog(log *model.SubmissionLog) error {
+	data, err := jsoniter.Marshal(log)
 	if err != nil {
-		return fmt.Errorf("failed to marshal app settings: %w", err)
+		return fmt.Errorf("failed to marshal submission log: %w", err)
 	}
 
-	if err := c.db.Set(database.BucketAppSettings, AppSettingsKey, data); err != nil {
-		return fmt.Errorf("failed to save app settings: %w", err)
+	if err := c.db.Set(database.BucketSubmissionLog, submissionLogKey, data); err != nil {
+		return fmt.Errorf("failed to save submission log: %w", err)
 	}
-
 	return nil
 }
 
-// GetServersDir retrieves the servers directory from settings
-func (c *Config) GetServersDir() (string, error) {
-	settings, err := c.GetAppSettings()
+// GetSessionTimeline retrieves the recorded events of a session
+func (c *Config) GetSessionTimeline(serverURL, sessionID string) (*model.SessionTimeline, error) {
+	data, err := c.db.Get(database.BucketSessionTimelines, sessionKey(serverURL, sessionID))
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to get session timeline: %w", err)
 	}
-	return settings.ServersDir, nil
+	if data == nil {
+		return &model.SessionTimeline{}, nil
+	}
+
+	var timeline model.SessionTimeline
+	if err := jsoniter.Unmarshal(data, &timeline); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session timeline: %w", err)
+	}
+	return &timeline, nil
 }
 
-// SetServersDir updates the servers directory in settings
-func (c *Config) SetServersDir(serversDir string) error {
-	settings, err := c.GetAppSettings()
+// SetSessionTimeline stores the recorded events of a session
+func (c *Config) SetSessionTimeline(serverURL, sessionID string, timeline *model.SessionTimeline) error {
+	data, err := jsoniter.Marshal(timeline)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to marshal session timeline: %w", err)
 	}
-	settings.ServersDir = serversDir
-	return c.SetAppSettings(settings)
+
+	if err := c.db.Set(database.BucketSessionTimelines, sessionKey(serverURL, sessionID), data); err != nil {
+		return fmt.Errorf("failed to save session timeline: %w", err)
+	}
+	return nil
 }
 
-// SetAutoDownloadStars updates the auto download stars setting
-func (c *Config) SetAutoDownloadStars(enabled bool) error {
-	settings, err := c.GetAppSettings()
+// DeleteServerSessionTimelines removes the timelines of all the sessions of a server
+func (c *Config) DeleteServerSessionTimelines(serverURL string) error {
+	err := c.db.Update(func(tx *database.Tx) error {
+		return deleteKeys(tx, database.BucketSessionTimelines, sessionKey(serverURL, ""))
+	})
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to delete session timelines: %w", err)
 	}
-	settings.AutoDownloadStars = &enabled
-	return c.SetAppSettings(settings)
+	return nil
 }
 
-// SetZoomLevel updates the zoom level setting (clamped to 50-200%)
-func (c *Config) SetZoomLevel(level int) error {
-	// Clamp to reasonable range
-	if level < 50 {
-		level = 50
+// GetLaunchHistory retrieves the recent Stars! launches of a session
+func (c *Config) GetLaunchHistory(serverURL, sessionID string) (*model.LaunchHistory, error) {
+	data, err := c.db.Get(database.BucketLaunchHistory, sessionKey(serverURL, sessionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get launch history: %w", err)
 	}
-	if level > 200 {
-		level = 200
+	if data == nil {
+		return &model.LaunchHistory{}, nil
 	}
 
-	settings, err := c.GetAppSettings()
-	if err != nil {
-		return err
+	var history model.LaunchHistory
+	if err := jsoniter.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal launch history: %w", err)
 	}
-	settings.ZoomLevel = &level
-	return c.SetAppSettings(settings)
+	return &history, nil
 }
 
-// SetUseWine updates the use wine setting
-func (c *Config) SetUseWine(enabled bool) error {
-	settings, err := c.GetAppSettings()
+// SetLaunchHistory stores the recent Stars! launches of a session
+func (c *Config) SetLaunchHistory(serverURL, sessionID string, history *model.LaunchHistory) error {
+	data, err := jsoniter.Marshal(history)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to marshal launch history: %w", err)
 	}
-	settings.UseWine = &enabled
-	return c.SetAppSettings(settings)
-}
 
-// SetWinePrefixesDir updates the wine prefixes directory path
-func (c *Config) SetWinePrefixesDir(prefixesDir string) error {
-	settings, err := c.GetAppSettings()
-	if err != nil {
-		return err
+	if err := c.db.Set(database.BucketLaunchHistory, sessionKey(serverURL, sessionID), data); err != nil {
+		return fmt.Errorf("failed to save launch history: %w", err)
 	}
-	settings.WinePrefixesDir = &prefixesDir
-	return c.SetAppSettings(settings)
+	return nil
 }
 
-// GetWinePrefixesDir returns the wine prefixes directory path from settings
-func (c *Config) GetWinePrefixesDir() (string, error) {
-	settings, err := c.GetAppSettings()
+// DeleteServerLaunchHistory removes the launch history of all the sessions of a server
+func (c *Config) DeleteServerLaunchHistory(serverURL string) error {
+	err := c.db.Update(func(tx *database.Tx) error {
+		return deleteKeys(tx, database.BucketLaunchHistory, sessionKey(serverURL, ""))
+	})
 	if err != nil {
-		return "", err
+		return fmt.Errorf("failed to delete launch history: %w", err)
 	}
-	return settings.GetWinePrefixesDir(), nil
+	return nil
 }
 
-// GetServerWinePrefix returns the wine prefix path for a specific server
-// Each server gets its own wine prefix to allow different serial keys per server.
-// Path format: <winePrefixesDir>/<sanitizedServerName>
-func (c *Config) GetServerWinePrefix(serverName string) (string, error) {
-	prefixesDir, err := c.GetWinePrefixesDir()
+// =============================================================================
+// MAP PRESETS
+// =============================================================================
+
+// GetMapPresets retrieves all named map presets sorted by name
+func (c *Config) GetMapPresets() ([]model.MapPreset, error) {
+	all, err := c.db.GetAll(database.BucketMapPresets)
 	if err != nil {
-		return "", err
+		return nil, fmt.Errorf("failed to get map presets: %w", err)
 	}
-	sanitizedName := sanitizeServerName(serverName)
-	return filepath.Join(prefixesDir, sanitizedName), nil
+
+	presets := make([]model.MapPreset, 0, len(all))
+	for name, data := range all {
+		var preset model.MapPreset
+		if err := jsoniter.Unmarshal(data, &preset); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal map preset %s: %w", name, err)
+		}
+		presets = append(presets, preset)
+	}
+
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets, nil
 }
 
-// GetUseWine returns the use wine setting from settings
-func (c *Config) GetUseWine() (bool, error) {
-	settings, err := c.GetAppSettings()
+// GetMapPreset retrieves a map preset by name, or nil if it does not exist
+func (c *Config) GetMapPreset(name string) (*model.MapPreset, error) {
+	data, err := c.db.Get(database.BucketMapPresets, name)
 	if err != nil {
-		return false, err
+		return nil, fmt.Errorf("failed to get map preset: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var preset model.MapPreset
+	if err := jsoniter.Unmarshal(data, &preset); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal map preset: %w", err)
+	}
+	return &preset, nil
+}
+
+// SetMapPreset adds or replaces a map preset
+func (c *Config) SetMapPreset(preset model.MapPreset) error {
+	if strings.TrimSpace(preset.Name) == "" {
+		return fmt.Errorf("map preset name is required")
+	}
+
+	data, err := jsoniter.Marshal(preset)
+	if err != nil {
+		return fmt.Errorf("failed to marshal map preset: %w", err)
+	}
+
+	if err := c.db.Set(database.BucketMapPresets, preset.Name, data); err != nil {
+		return fmt.Errorf("failed to save map preset: %w", err)
+	}
+	return nil
+}
+
+// DeleteMapPreset removes a map preset
+func (c *Config) DeleteMapPreset(name string) error {
+	if err := c.db.Delete(database.BucketMapPresets, name); err != nil {
+		return fmt.Errorf("failed to delete map preset: %w", err)
+	}
+	return nil
+}
+
+// sessionKey builds the key of per-session data
+func sessionKey(serverURL, sessionID string) string {
+	return serverURL + "\x00" + sessionID
+}
+
+// GetSessionMapSettings retrieves the map preferences of a session
+func (c *Config) GetSessionMapSettings(serverURL, sessionID string) (*model.SessionMapSettings, error) {
+	data, err := c.db.Get(database.BucketSessionMapSettings, sessionKey(serverURL, sessionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session map settings: %w", err)
+	}
+	if data == nil {
+		return &model.SessionMapSettings{}, nil
+	}
+
+	var settings model.SessionMapSettings
+	if err := jsoniter.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session map settings: %w", err)
+	}
+	return &settings, nil
+}
+
+// GetSessionCredential returns the nickname of the credential a session is
+// played with, or "" when it uses the server's default credential
+func (c *Config) GetSessionCredential(serverURL, sessionID string) (string, error) {
+	data, err := c.db.Get(database.BucketSessionCredentials, sessionKey(serverURL, sessionID))
+	if err != nil {
+		return "", fmt.Errorf("failed to get session credential: %w", err)
+	}
+	return string(data), nil
+}
+
+// SetSessionCredential sets the credential a session is played with, ""
+// going back to the server's default credential
+func (c *Config) SetSessionCredential(serverURL, sessionID, nickname string) error {
+	key := sessionKey(serverURL, sessionID)
+	var err error
+	if nickname == "" {
+		err = c.db.Delete(database.BucketSessionCredentials, key)
+	} else {
+		err = c.db.Set(database.BucketSessionCredentials, key, []byte(nickname))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save session credential: %w", err)
+	}
+	return nil
+}
+
+// DeleteServerSessionCredentials removes the credentials chosen for the sessions of a server
+func (c *Config) DeleteServerSessionCredentials(serverURL string) error {
+	err := c.db.Update(func(tx *database.Tx) error {
+		return deleteKeys(tx, database.BucketSessionCredentials, sessionKey(serverURL, ""))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete session credentials: %w", err)
+	}
+	return nil
+}
+
+// GetSessionWatchDirs returns the extra directories watched for the order
+// files of a session
+func (c *Config) GetSessionWatchDirs(serverURL, sessionID string) ([]string, error) {
+	data, err := c.db.Get(database.BucketSessionWatchDirs, sessionKey(serverURL, sessionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session watch directories: %w", err)
+	}
+	if data == nil {
+		return []string{}, nil
+	}
+
+	var dirs []string
+	if err := jsoniter.Unmarshal(data, &dirs); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session watch directories: %w", err)
+	}
+	return dirs, nil
+}
+
+// SetSessionWatchDirs sets the extra directories watched for the order files
+// of a session, none removing the entry
+func (c *Config) SetSessionWatchDirs(serverURL, sessionID string, dirs []string) error {
+	key := sessionKey(serverURL, sessionID)
+	if len(dirs) == 0 {
+		if err := c.db.Delete(database.BucketSessionWatchDirs, key); err != nil {
+			return fmt.Errorf("failed to save session watch directories: %w", err)
+		}
+		return nil
+	}
+
+	data, err := jsoniter.Marshal(dirs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session watch directories: %w", err)
+	}
+	if err := c.db.Set(database.BucketSessionWatchDirs, key, data); err != nil {
+		return fmt.Errorf("failed to save session watch directories: %w", err)
+	}
+	return nil
+}
+
+// DeleteServerSessionWatchDirs removes the extra directories watched for the
+// order files of all the sessions of a server
+func (c *Config) DeleteServerSessionWatchDirs(serverURL string) error {
+	err := c.db.Update(func(tx *database.Tx) error {
+		return deleteKeys(tx, database.BucketSessionWatchDirs, sessionKey(serverURL, ""))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete session watch directories: %w", err)
+	}
+	return nil
+}
+
+// GetSessionLaunchEnv returns the environment variables set when launching
+// Stars! for a session, by name
+func (c *Config) GetSessionLaunchEnv(serverURL, sessionID string) (map[string]string, error) {
+	data, err := c.db.Get(database.BucketSessionLaunchEnv, sessionKey(serverURL, sessionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session launch environment: %w", err)
+	}
+	if data == nil {
+		return map[string]string{}, nil
+	}
+
+	var env map[string]string
+	if err := jsoniter.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session launch environment: %w", err)
+	}
+	return env, nil
+}
+
+// SetSessionLaunchEnv sets the environment variables set when launching
+// Stars! for a session, none removing the entry
+func (c *Config) SetSessionLaunchEnv(serverURL, sessionID string, env map[string]string) error {
+	key := sessionKey(serverURL, sessionID)
+	if len(env) == 0 {
+		if err := c.db.Delete(database.BucketSessionLaunchEnv, key); err != nil {
+			return fmt.Errorf("failed to save session launch environment: %w", err)
+		}
+		return nil
+	}
+
+	data, err := jsoniter.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session launch environment: %w", err)
+	}
+	if err := c.db.Set(database.BucketSessionLaunchEnv, key, data); err != nil {
+		return fmt.Errorf("failed to save session launch environment: %w", err)
+	}
+	return nil
+}
+
+// DeleteServerLaunchEnv removes the launch environment variables of all the
+// sessions of a server
+func (c *Config) DeleteServerLaunchEnv(serverURL string) error {
+	err := c.db.Update(func(tx *database.Tx) error {
+		return deleteKeys(tx, database.BucketSessionLaunchEnv, sessionKey(serverURL, ""))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete session launch environments: %w", err)
+	}
+	return nil
+}
+
+// serverCacheEntry is a cached server response
+type serverCacheEntry struct {
+	CachedAt time.Time           `json:"cachedAt"`
+	Data     jsoniter.RawMessage `json:"data"`
+}
+
+// GetServerCache returns the JSON data cached for a server under key and when
+// it was cached, or nil when nothing is cached
+func (c *Config) GetServerCache(serverURL, key string) ([]byte, time.Time, error) {
+	data, err := c.db.Get(database.BucketServerCache, sessionKey(serverURL, key))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to get server cache: %w", err)
+	}
+	if data == nil {
+		return nil, time.Time{}, nil
+	}
+
+	var entry serverCacheEntry
+	if err := jsoniter.Unmarshal(data, &entry); err != nil {
+		return nil, time.Time{}, fmt.Errorf("failed to unmarshal server cache: %w", err)
+	}
+	return entry.Data, entry.CachedAt, nil
+}
+
+// SetServerCache caches the JSON data of a server response under key
+func (c *Config) SetServerCache(serverURL, key string, data []byte) error {
+	entry, err := jsoniter.Marshal(serverCacheEntry{CachedAt: time.Now(), Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to marshal server cache: %w", err)
+	}
+	if err := c.db.Set(database.BucketServerCache, sessionKey(serverURL, key), entry); err != nil {
+		return fmt.Errorf("failed to save server cache: %w", err)
+	}
+	return nil
+}
+
+// DeleteServerCache removes everything cached for a server
+func (c *Config) DeleteServerCache(serverURL string) error {
+	prefix := sessionKey(serverURL, "")
+	err := c.db.Update(func(tx *database.Tx) error {
+		keys, err := tx.Keys(database.BucketServerCache)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				if err := tx.Delete(database.BucketServerCache, key); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete server cache: %w", err)
+	}
+	return nil
+}
+
+// SetSessionMapSettings stores the map preferences of a session
+func (c *Config) SetSessionMapSettings(serverURL, sessionID string, settings *model.SessionMapSettings) error {
+	data, err := jsoniter.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session map settings: %w", err)
+	}
+
+	if err := c.db.Set(database.BucketSessionMapSettings, sessionKey(serverURL, sessionID), data); err != nil {
+		return fmt.Errorf("failed to save session map settings: %w", err)
+	}
+	return nil
+}
+
+// DeleteServerSessionMapSettings removes the map settings of all the sessions of a server
+func (c *Config) DeleteServerSessionMapSettings(serverURL string) error {
+	err := c.db.Update(func(tx *database.Tx) error {
+		return deleteKeys(tx, database.BucketSessionMapSettings, sessionKey(serverURL, ""))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete session map settings: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// LOCAL GAMES
+// =============================================================================
+
+// LocalGamesServer stands for the server URL and name of the games played
+// without a server, so that their directories (<serversdir>/local/...) and
+// per-session data are handled like the sessions of a server. No server can
+// be given that name.
+const LocalGamesServer = "local"
+
+// GetLocalGames retrieves all local games, oldest first
+func (c *Config) GetLocalGames() ([]model.LocalGame, error) {
+	all, err := c.db.GetAll(database.BucketLocalGames)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local games: %w", err)
+	}
+
+	games := make([]model.LocalGame, 0, len(all))
+	for id, data := range all {
+		var game model.LocalGame
+		if err := jsoniter.Unmarshal(data, &game); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal local game %s: %w", id, err)
+		}
+		games = append(games, game)
+	}
+
+	sort.Slice(games, func(i, j int) bool { return games[i].CreatedAt.Before(games[j].CreatedAt) })
+	return games, nil
+}
+
+// GetLocalGame retrieves a local game by ID, or nil if it does not exist
+func (c *Config) GetLocalGame(id string) (*model.LocalGame, error) {
+	data, err := c.db.Get(database.BucketLocalGames, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get local game: %w", err)
+	}
+	if data == nil {
+		return nil, nil
+	}
+
+	var game model.LocalGame
+	if err := jsoniter.Unmarshal(data, &game); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal local game: %w", err)
+	}
+	return &game, nil
+}
+
+// SetLocalGame adds or replaces a local game
+func (c *Config) SetLocalGame(game model.LocalGame) error {
+	if game.ID == "" {
+		return fmt.Errorf("local game ID is required")
+	}
+	if strings.TrimSpace(game.Name) == "" {
+		return fmt.Errorf("local game name is required")
+	}
+
+	data, err := jsoniter.Marshal(game)
+	if err != nil {
+		return fmt.Errorf("failed to marshal local game: %w", err)
+	}
+
+	if err := c.db.Set(database.BucketLocalGames, game.ID, data); err != nil {
+		return fmt.Errorf("failed to save local game: %w", err)
+	}
+	return nil
+}
+
+// DeleteLocalGame removes a local game and its directory alias, its directory
+// is left to the caller
+func (c *Config) DeleteLocalGame(id string) error {
+	if err := c.db.Delete(database.BucketLocalGames, id); err != nil {
+		return fmt.Errorf("failed to delete local game: %w", err)
+	}
+	if err := c.db.Delete(database.BucketSessionDirs, sessionDirKey(LocalGamesServer, id)); err != nil {
+		return fmt.Errorf("failed to remove local game directory alias: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// PRODUCTION PLANS
+// =============================================================================
+
+// GetProductionTemplates retrieves all production queue templates sorted by name
+func (c *Config) GetProductionTemplates() ([]model.ProductionTemplate, error) {
+	all, err := c.db.GetAll(database.BucketProductionTemplates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get production templates: %w", err)
+	}
+
+	templates := make([]model.ProductionTemplate, 0, len(all))
+	for name, data := range all {
+		var template model.ProductionTemplate
+		if err := jsoniter.Unmarshal(data, &template); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal production template %s: %w", name, err)
+		}
+		templates = append(templates, template)
+	}
+
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// SetProductionTemplate adds or replaces a production queue template
+func (c *Config) SetProductionTemplate(template model.ProductionTemplate) error {
+	if strings.TrimSpace(template.Name) == "" {
+		return fmt.Errorf("production template name is required")
+	}
+
+	data, err := jsoniter.Marshal(template)
+	if err != nil {
+		return fmt.Errorf("failed to marshal production template: %w", err)
+	}
+
+	if err := c.db.Set(database.BucketProductionTemplates, template.Name, data); err != nil {
+		return fmt.Errorf("failed to save production template: %w", err)
+	}
+	return nil
+}
+
+// DeleteProductionTemplate removes a production queue template
+func (c *Config) DeleteProductionTemplate(name string) error {
+	if err := c.db.Delete(database.BucketProductionTemplates, name); err != nil {
+		return fmt.Errorf("failed to delete production template: %w", err)
+	}
+	return nil
+}
+
+// GetProductionPlans returns the per-planet production plans of a session
+func (c *Config) GetProductionPlans(serverURL, sessionID string) ([]model.PlanetProductionPlan, error) {
+	data, err := c.db.Get(database.BucketProductionPlans, sessionKey(serverURL, sessionID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get production plans: %w", err)
+	}
+	if data == nil {
+		return []model.PlanetProductionPlan{}, nil
+	}
+
+	var plans []model.PlanetProductionPlan
+	if err := jsoniter.Unmarshal(data, &plans); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal production plans: %w", err)
+	}
+	return plans, nil
+}
+
+// SetProductionPlans sets the per-planet production plans of a session, none
+// removing the entry
+func (c *Config) SetProductionPlans(serverURL, sessionID string, plans []model.PlanetProductionPlan) error {
+	key := sessionKey(serverURL, sessionID)
+	if len(plans) == 0 {
+		if err := c.db.Delete(database.BucketProductionPlans, key); err != nil {
+			return fmt.Errorf("failed to save production plans: %w", err)
+		}
+		return nil
+	}
+
+	data, err := jsoniter.Marshal(plans)
+	if err != nil {
+		return fmt.Errorf("failed to marshal production plans: %w", err)
+	}
+	if err := c.db.Set(database.BucketProductionPlans, key, data); err != nil {
+		return fmt.Errorf("failed to save production plans: %w", err)
+	}
+	return nil
+}
+
+// DeleteServerProductionPlans removes the production plans of all the sessions of a server
+func (c *Config) DeleteServerProductionPlans(serverURL string) error {
+	err := c.db.Update(func(tx *database.Tx) error {
+		return deleteKeys(tx, database.BucketProductionPlans, sessionKey(serverURL, ""))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete production plans: %w", err)
+	}
+	return nil
+}
+
+// =============================================================================
+// SERVERS DIRECTORY CONFIGURATION
+// =============================================================================
+
+const (
+	// AppSettingsKey is the key for app settings in the database
+	AppSettingsKey = "settings"
+)
+
+// WindowGeometry stores window position and size
+type WindowGeometry struct {
+	X         int  `json:"x"`
+	Y         int  `json:"y"`
+	Width     int  `json:"width"`
+	Height    int  `json:"height"`
+	Maximized bool `json:"maximized,omitempty"` // X, Y, Width and Height are then the size before maximizing
+}
+
+// AppSettings stores global application settings
+type AppSettings struct {
+	ServersDir         string          `json:"serversDir"`
+	AutoDownloadStars  *bool           `json:"autoDownloadStars"`  // nil means default (true)
+	ZoomLevel          *int            `json:"zoomLevel"`          // nil means default (100)
+	UseWine            *bool           `json:"useWine"`            // nil means default (false)
+	WinePrefixesDir    *string         `json:"winePrefixesDir"`    // nil means default (~/.config/astrum/wine_prefixes)
+	ValidWineInstall   *bool           `json:"validWineInstall"`   // nil means not checked yet (default: false)
+	WindowGeometry     *WindowGeometry `json:"windowGeometry"`     // nil means use defaults - last saved, used for unknown monitor setups
+	EnableBrowserStars *bool           `json:"enableBrowserStars"` // nil means default (false) - experimental browser Stars! support
+
+	InvitationExpiryDays *int  `json:"invitationExpiryDays"` // nil means default (0) - never auto-decline old invitations
+	InvitationDigest     *bool `json:"invitationDigest"`     // nil means default (false) - no daily pending invitations reminder
+
+	LocalAPIEnabled *bool `json:"localApiEnabled"` // nil means default (false) - no localhost HTTP API
+	LocalAPIPort    *int  `json:"localApiPort"`    // nil means default (DefaultLocalAPIPort)
+
+	StatusFileEnabled *bool   `json:"statusFileEnabled"` // nil means default (false) - no status file for desktop widgets
+	StatusFilePath    *string `json:"statusFilePath"`    // nil means default (status.json in the profile directory)
+
+	SessionFeeds *bool `json:"sessionFeeds"` // nil means default (false) - no Atom feed written in the game directories
+
+	ArtifactCollisionPolicy *string `json:"artifactCollisionPolicy"` // nil means default (overwrite)
+
+	KioskPinHash *string `json:"kioskPinHash"` // nil means no guest mode PIN, see HashKioskPin
+	KioskLocked  *bool   `json:"kioskLocked"`  // nil means default (false) - destructive actions allowed
+
+	TelemetryEnabled  *bool   `json:"telemetryEnabled"`  // nil means default (false) - no usage reports sent
+	TelemetryEndpoint *string `json:"telemetryEndpoint"` // nil means no endpoint configured
+	TelemetryID       *string `json:"telemetryId"`       // random install ID, generated when telemetry is first enabled
+
+	DeviceID *string `json:"deviceId"` // random ID telling this machine from the other machines of the user, see GetDeviceID
+
+	SubmissionConfirmation *bool `json:"submissionConfirmation"` // nil means default (false) - no notification after each order upload
+	SubmissionDigest       *bool `json:"submissionDigest"`       // nil means default (false) - no weekly summary of uploaded orders
+	ReportTurnReads        *bool `json:"reportTurnReads"`        // nil means default (true) - tell session hosts when a turn is opened
+	DeadlineNotifications  *bool `json:"deadlineNotifications"`  // nil means default (true) - notify as the deadline of missing orders nears
+
+	WebSocketPingInterval *int  `json:"webSocketPingInterval"` // nil means default (30) - seconds between pings, 0 disables them
+	WebSocketPongWait     *int  `json:"webSocketPongWait"`     // nil means default (60) - seconds without traffic before reconnecting
+	WebSocketAdaptive     *bool `json:"webSocketAdaptive"`     // nil means default (false) - no faster heartbeat after dropped connections
+
+	RaceWorkshopDir *string `json:"raceWorkshopDir"` // nil means none - folder watched for races made with the Stars! race wizard
+
+	FileMode  *string `json:"fileMode"`  // nil means default (as created) - octal mode of game files, e.g. "0664"
+	DirMode   *string `json:"dirMode"`   // nil means default (as created) - octal mode of game directories, e.g. "0775"
+	FileGroup *string `json:"fileGroup"` // nil means default (the user's group) - group owning game files and directories
+
+	MinFreeDiskSpaceMB *int    `json:"minFreeDiskSpaceMb"` // nil means default (DefaultMinFreeDiskSpaceMB), 0 disables the check
+	LowDiskSpaceAction *string `json:"lowDiskSpaceAction"` // nil means default (refuse) - DiskSpaceRefuse or DiskSpaceWarn
+
+	OrderDebounceMs *int `json:"orderDebounceMs"` // nil means default (DefaultOrderDebounceMs) - quiet time after the last order file write
+	OrderSettleMs   *int `json:"orderSettleMs"`   // nil means default (DefaultOrderSettleMs), 0 disables the write-settle check
+
+	LogFormat *string `json:"logFormat"` // nil means default (console) - "console" or "json"
+	LogSyslog *bool   `json:"logSyslog"` // nil means default (false) - no copy of the log to syslog/journald
+
+	HardDelete *bool `json:"hardDelete"` // nil means default (false) - deleted directories go to the trash
+
+	HistoryRetentionYears *int `json:"historyRetentionYears"` // nil means default (0) - keep all the past years downloaded for review
+
+	ConcurrentLaunchPolicy *string `json:"concurrentLaunchPolicy"` // nil means default (warn) - ConcurrentLaunchAllow, ConcurrentLaunchWarn or ConcurrentLaunchBlock
+
+	StarsIniDefaults []StarsIniEntry `json:"starsIniDefaults,omitempty"` // stars.ini values applied when missing before launching Stars!
+	Hooks            []HookEntry     `json:"hooks,omitempty"`            // commands run on lifecycle events
+	EnabledPlugins   []string        `json:"enabledPlugins,omitempty"`   // plugins run after each turn download
+
+	WindowLayouts map[string]*WindowGeometry `json:"windowLayouts,omitempty"` // monitor setup (see ScreensKey) -> window geometry
+}
+
+// HookEntry is a command run when a lifecycle event occurs
+type HookEntry struct {
+	Event          string `json:"event"`
+	Command        string `json:"command"`
+	TimeoutSeconds int    `json:"timeoutSeconds"` // 0 means the hooks package default
+	Enabled        bool   `json:"enabled"`
+}
+
+// StarsIniEntry is a single stars.ini value
+type StarsIniEntry struct {
+	Section string `json:"section"`
+	Key     string `json:"key"`
+	Value   string `json:"value"`
+}
+
+// GetAutoDownloadStars returns the auto download setting (default: true)
+func (s *AppSettings) GetAutoDownloadStars() bool {
+	if s.AutoDownloadStars == nil {
+		return true // default
+	}
+	return *s.AutoDownloadStars
+}
+
+// GetZoomLevel returns the zoom level as percentage (default: 100)
+func (s *AppSettings) GetZoomLevel() int {
+	if s.ZoomLevel == nil {
+		return 100 // default 100%
+	}
+	return *s.ZoomLevel
+}
+
+// GetUseWine returns the use wine setting (default: false)
+func (s *AppSettings) GetUseWine() bool {
+	if s.UseWine == nil {
+		return false // default
+	}
+	return *s.UseWine
+}
+
+// GetWinePrefixesDir returns the wine prefixes directory path (default: ~/.config/astrum/wine_prefixes)
+// This directory contains per-server wine prefixes, with each server getting its own subdirectory.
+func (s *AppSettings) GetWinePrefixesDir() string {
+	if s.WinePrefixesDir == nil {
+		return DefaultWinePrefixesDir()
+	}
+	return *s.WinePrefixesDir
+}
+
+// GetValidWineInstall returns the wine installation validation status (default: false)
+func (s *AppSettings) GetValidWineInstall() bool {
+	if s.ValidWineInstall == nil {
+		return false // default: not validated
+	}
+	return *s.ValidWineInstall
+}
+
+// GetEnableBrowserStars returns the experimental browser Stars! setting (default: false)
+func (s *AppSettings) GetEnableBrowserStars() bool {
+	if s.EnableBrowserStars == nil {
+		return false // default: disabled
+	}
+	return *s.EnableBrowserStars
+}
+
+// GetInvitationExpiryDays returns the number of days after which pending invitations
+// are declined automatically (default: 0, disabled)
+func (s *AppSettings) GetInvitationExpiryDays() int {
+	if s.InvitationExpiryDays == nil {
+		return 0 // default: disabled
+	}
+	return *s.InvitationExpiryDays
+}
+
+// GetInvitationDigest returns the daily pending invitations reminder setting (default: false)
+func (s *AppSettings) GetInvitationDigest() bool {
+	if s.InvitationDigest == nil {
+		return false // default: disabled
+	}
+	return *s.InvitationDigest
+}
+
+// GetSubmissionConfirmation returns whether each order upload is confirmed by a notification (default: false)
+func (s *AppSettings) GetSubmissionConfirmation() bool {
+	if s.SubmissionConfirmation == nil {
+		return false // default: disabled
+	}
+	return *s.SubmissionConfirmation
+}
+
+// GetSubmissionDigest returns the weekly uploaded orders summary setting (default: false)
+func (s *AppSettings) GetSubmissionDigest() bool {
+	if s.SubmissionDigest == nil {
+		return false // default: disabled
+	}
+	return *s.SubmissionDigest
+}
+
+// GetLogFormat returns the format of the log lines (default: console)
+func (s *AppSettings) GetLogFormat() string {
+	if s.LogFormat == nil {
+		return "console" // default
+	}
+	return *s.LogFormat
+}
+
+// GetLogSyslog returns whether the log is copied to the system log (default: false)
+func (s *AppSettings) GetLogSyslog() bool {
+	if s.LogSyslog == nil {
+		return false // default: disabled
+	}
+	return *s.LogSyslog
+}
+
+// GetHardDelete returns whether deleted directories are removed for good
+// instead of going to the trash (default: false)
+func (s *AppSettings) GetHardDelete() bool {
+	if s.HardDelete == nil {
+		return false // default: to the trash
+	}
+	return *s.HardDelete
+}
+
+// GetHistoryRetentionYears returns how many past years downloaded for review
+// are kept per session, 0 keeping them all (default: 0)
+func (s *AppSettings) GetHistoryRetentionYears() int {
+	if s.HistoryRetentionYears == nil {
+		return 0 // default: keep all
+	}
+	return *s.HistoryRetentionYears
+}
+
+// GetConcurrentLaunchPolicy returns what launching Stars! does when it already
+// runs for the same session or Wine prefix (default: warn)
+func (s *AppSettings) GetConcurrentLaunchPolicy() string {
+	if s.ConcurrentLaunchPolicy == nil {
+		return ConcurrentLaunchWarn
+	}
+	return *s.ConcurrentLaunchPolicy
+}
+
+// GetReportTurnReads returns whether opening a turn is reported to the session hosts (default: true)
+func (s *AppSettings) GetReportTurnReads() bool {
+	if s.ReportTurnReads == nil {
+		return true // default
+	}
+	return *s.ReportTurnReads
+}
+
+// GetDeadlineNotifications returns whether nearing deadlines of missing orders are notified (default: true)
+func (s *AppSettings) GetDeadlineNotifications() bool {
+	if s.DeadlineNotifications == nil {
+		return true // default
+	}
+	return *s.DeadlineNotifications
+}
+
+// GetWebSocketPingInterval returns the seconds between WebSocket pings (default: 30)
+func (s *AppSettings) GetWebSocketPingInterval() int {
+	if s.WebSocketPingInterval == nil {
+		return 30 // default
+	}
+	return *s.WebSocketPingInterval
+}
+
+// GetWebSocketPongWait returns the seconds without traffic before the WebSocket is considered dead (default: 60)
+func (s *AppSettings) GetWebSocketPongWait() int {
+	if s.WebSocketPongWait == nil {
+		return 60 // default
+	}
+	return *s.WebSocketPongWait
+}
+
+// GetWebSocketAdaptive returns whether the heartbeat speeds up on flaky links (default: false)
+func (s *AppSettings) GetWebSocketAdaptive() bool {
+	if s.WebSocketAdaptive == nil {
+		return false // default: disabled
+	}
+	return *s.WebSocketAdaptive
+}
+
+// GetRaceWorkshopDir returns the folder watched for new race files (default: none)
+func (s *AppSettings) GetRaceWorkshopDir() string {
+	if s.RaceWorkshopDir == nil {
+		return ""
+	}
+	return *s.RaceWorkshopDir
+}
+
+// GetStatusFileEnabled returns whether the status file for desktop widgets is written (default: false)
+func (s *AppSettings) GetStatusFileEnabled() bool {
+	if s.StatusFileEnabled == nil {
+		return false
+	}
+	return *s.StatusFileEnabled
+}
+
+// GetStatusFilePath returns the path of the status file ("" = status.json in the profile directory)
+func (s *AppSettings) GetStatusFilePath() string {
+	if s.StatusFilePath == nil {
+		return ""
+	}
+	return *s.StatusFilePath
+}
+
+// GetSessionFeeds returns whether an Atom feed of its events is written in each game directory (default: false)
+func (s *AppSettings) GetSessionFeeds() bool {
+	if s.SessionFeeds == nil {
+		return false
+	}
+	return *s.SessionFeeds
+}
+
+// GetFileMode returns the octal mode of created game files ("" = as created)
+func (s *AppSettings) GetFileMode() string {
+	if s.FileMode == nil {
+		return ""
+	}
+	return *s.FileMode
+}
+
+// GetDirMode returns the octal mode of created game directories ("" = as created)
+func (s *AppSettings) GetDirMode() string {
+	if s.DirMode == nil {
+		return ""
+	}
+	return *s.DirMode
+}
+
+// GetFileGroup returns the group owning created game files ("" = the user's group)
+func (s *AppSettings) GetFileGroup() string {
+	if s.FileGroup == nil {
+		return ""
+	}
+	return *s.FileGroup
+}
+
+// FilePermissions returns the mode and group of created game files and
+// directories (default: as created, in the user's group)
+func (s *AppSettings) FilePermissions() (safefile.Permissions, error) {
+	var p safefile.Permissions
+	var err error
+	if s.FileMode != nil {
+		if p.FileMode, err = ParseFileMode(*s.FileMode); err != nil {
+			return p, err
+		}
+	}
+	if s.DirMode != nil {
+		if p.DirMode, err = ParseFileMode(*s.DirMode); err != nil {
+			return p, err
+		}
+	}
+	if s.FileGroup != nil {
+		p.Group = *s.FileGroup
+	}
+	return p, nil
+}
+
+// ParseFileMode parses an octal permission mode such as "0664", "" giving 0
+func ParseFileMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+	m, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || m > 0777 {
+		return 0, fmt.Errorf("invalid file mode: %s", mode)
+	}
+	return os.FileMode(m), nil
+}
+
+// GetMinFreeDiskSpaceMB returns the free space (MB) to keep when writing game
+// files, 0 when not checked (default: DefaultMinFreeDiskSpaceMB)
+func (s *AppSettings) GetMinFreeDiskSpaceMB() int {
+	if s.MinFreeDiskSpaceMB == nil {
+		return DefaultMinFreeDiskSpaceMB
+	}
+	return *s.MinFreeDiskSpaceMB
+}
+
+// GetLowDiskSpaceAction returns what to do when disk space runs low (default: refuse)
+func (s *AppSettings) GetLowDiskSpaceAction() string {
+	if s.LowDiskSpaceAction == nil {
+		return DiskSpaceRefuse
+	}
+	return *s.LowDiskSpaceAction
+}
+
+// GetOrderDebounceMs returns how long (ms) the order file watcher waits after
+// the last write before reading an order file (default: DefaultOrderDebounceMs)
+func (s *AppSettings) GetOrderDebounceMs() int {
+	if s.OrderDebounceMs == nil {
+		return DefaultOrderDebounceMs
+	}
+	return *s.OrderDebounceMs
+}
+
+// GetOrderSettleMs returns how long (ms) an order file must stay unchanged
+// before it is read, 0 when not checked (default: DefaultOrderSettleMs)
+func (s *AppSettings) GetOrderSettleMs() int {
+	if s.OrderSettleMs == nil {
+		return DefaultOrderSettleMs
+	}
+	return *s.OrderSettleMs
+}
+
+// Default order file watcher timing
+const (
+	DefaultOrderDebounceMs = 500
+	DefaultOrderSettleMs   = 1000
+)
+
+// DefaultMinFreeDiskSpaceMB is the default free space kept by downloads and backups
+const DefaultMinFreeDiskSpaceMB = 200
+
+// What downloads and backups do when they would leave less than the minimum free space
+const (
+	DiskSpaceRefuse = "refuse" // fail before writing anything
+	DiskSpaceWarn   = "warn"   // warn and go ahead
+)
+
+// What launching Stars! does when it already runs for the same session or Wine prefix
+const (
+	ConcurrentLaunchAllow = "allow" // launch silently
+	ConcurrentLaunchWarn  = "warn"  // warn and launch
+	ConcurrentLaunchBlock = "block" // refuse to launch
+)
+
+// DefaultLocalAPIPort is the default port of the localhost HTTP API
+const DefaultLocalAPIPort = 43117
+
+// GetLocalAPIEnabled returns whether the localhost HTTP API is enabled (default: false)
+func (s *AppSettings) GetLocalAPIEnabled() bool {
+	if s.LocalAPIEnabled == nil {
+		return false // default: disabled
+	}
+	return *s.LocalAPIEnabled
+}
+
+// GetLocalAPIPort returns the port of the localhost HTTP API (default: DefaultLocalAPIPort)
+func (s *AppSettings) GetLocalAPIPort() int {
+	if s.LocalAPIPort == nil {
+		return DefaultLocalAPIPort
+	}
+	return *s.LocalAPIPort
+}
+
+// GetArtifactCollisionPolicy returns what to do when a generated file already exists (default: overwrite)
+func (s *AppSettings) GetArtifactCollisionPolicy() string {
+	if s.ArtifactCollisionPolicy == nil {
+		return artifact.PolicyOverwrite
+	}
+	return *s.ArtifactCollisionPolicy
+}
+
+// GetKioskLocked returns whether guest mode is on, disabling destructive actions (default: false)
+func (s *AppSettings) GetKioskLocked() bool {
+	if s.KioskLocked == nil {
+		return false // default: unlocked
+	}
+	return *s.KioskLocked
+}
+
+// GetTelemetryEnabled returns whether usage reports are sent (default: false)
+func (s *AppSettings) GetTelemetryEnabled() bool {
+	if s.TelemetryEnabled == nil {
+		return false // default: disabled
+	}
+	return *s.TelemetryEnabled
+}
+
+// GetTelemetryEndpoint returns the URL usage reports are sent to (default: none)
+func (s *AppSettings) GetTelemetryEndpoint() string {
+	if s.TelemetryEndpoint == nil {
+		return ""
+	}
+	return *s.TelemetryEndpoint
+}
+
+// GetTelemetryID returns the random install ID of usage reports (default: none)
+func (s *AppSettings) GetTelemetryID() string {
+	if s.TelemetryID == nil {
+		return ""
+	}
+	return *s.TelemetryID
+}
+
+// DefaultWinePrefixesDir returns the default wine prefixes directory path
+// Each server will have its own wine prefix subdirectory under this path,
+// allowing different serial keys per server.
+func DefaultWinePrefixesDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".", ".config", "astrum", "wine_prefixes")
+	}
+	return filepath.Join(home, ".config", "astrum", "wine_prefixes")
+}
+
+// DefaultServersDir returns the default servers directory path
+func DefaultServersDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		// Fallback to current directory
+		return filepath.Join(".", ".astrum", "servers")
+	}
+	return filepath.Join(home, ".astrum", "servers")
+}
+
+// GetAppSettings retrieves the app settings from the database
+func (c *Config) GetAppSettings() (*AppSettings, error) {
+	data, err := c.db.Get(database.BucketAppSettings, AppSettingsKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get app settings: %w", err)
+	}
+	if data == nil {
+		// Return default settings if not found
+		return &AppSettings{
+			ServersDir: DefaultServersDir(),
+		}, nil
+	}
+
+	var settings AppSettings
+	if err := jsoniter.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal app settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// SetAppSettings stores the app settings in the database
+func (c *Config) SetAppSettings(settings *AppSettings) error {
+	data, err := jsoniter.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to marshal app settings: %w", err)
+	}
+
+	err = c.db.Update(func(tx *database.Tx) error {
+		oldData, err := tx.Get(database.BucketAppSettings, AppSettingsKey)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(database.BucketAppSettings, AppSettingsKey, data); err != nil {
+			return err
+		}
+		return auditChange(tx, model.AuditKindSettings, model.AuditActionUpdate, "", oldData, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save app settings: %w", err)
+	}
+
+	return nil
+}
+
+// GetServersDir retrieves the servers directory from settings
+func (c *Config) GetServersDir() (string, error) {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return "", err
+	}
+	return settings.ServersDir, nil
+}
+
+// SetServersDir updates the servers directory in settings
+func (c *Config) SetServersDir(serversDir string) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.ServersDir = serversDir
+	return c.SetAppSettings(settings)
+}
+
+// SetAutoDownloadStars updates the auto download stars setting
+func (c *Config) SetAutoDownloadStars(enabled bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.AutoDownloadStars = &enabled
+	return c.SetAppSettings(settings)
+}
+
+// SetZoomLevel updates the zoom level setting (clamped to 50-200%)
+func (c *Config) SetZoomLevel(level int) error {
+	// Clamp to reasonable range
+	if level < 50 {
+		level = 50
+	}
+	if level > 200 {
+		level = 200
+	}
+
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.ZoomLevel = &level
+	return c.SetAppSettings(settings)
+}
+
+// SetUseWine updates the use wine setting
+func (c *Config) SetUseWine(enabled bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.UseWine = &enabled
+	return c.SetAppSettings(settings)
+}
+
+// SetWinePrefixesDir updates the wine prefixes directory path
+func (c *Config) SetWinePrefixesDir(prefixesDir string) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.WinePrefixesDir = &prefixesDir
+	return c.SetAppSettings(settings)
+}
+
+// GetWinePrefixesDir returns the wine prefixes directory path from settings
+func (c *Config) GetWinePrefixesDir() (string, error) {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return "", err
+	}
+	return settings.GetWinePrefixesDir(), nil
+}
+
+// GetServerWinePrefix returns the wine prefix path for a specific server
+// Each server gets its own wine prefix to allow different serial keys per server.
+// Path format: <winePrefixesDir>/<sanitizedServerName>
+func (c *Config) GetServerWinePrefix(serverName string) (string, error) {
+	prefixesDir, err := c.GetWinePrefixesDir()
+	if err != nil {
+		return "", err
+	}
+	sanitizedName := sanitizeServerName(serverName)
+	return filepath.Join(prefixesDir, sanitizedName), nil
+}
+
+// GetUseWine returns the use wine setting from settings
+func (c *Config) GetUseWine() (bool, error) {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return false, err
 	}
 	return settings.GetUseWine(), nil
 }
 
-// SetValidWineInstall updates the wine installation validation status
-func (c *Config) SetValidWineInstall(valid bool) error {
+// SetValidWineInstall updates the wine installation validation status
+func (c *Config) SetValidWineInstall(valid bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.ValidWineInstall = &valid
+	return c.SetAppSettings(settings)
+}
+
+// GetValidWineInstall returns the wine installation validation status
+func (c *Config) GetValidWineInstall() (bool, error) {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return false, err
+	}
+	return settings.GetValidWineInstall(), nil
+}
+
+// SetEnableBrowserStars updates the experimental browser Stars! setting
+func (c *Config) SetEnableBrowserStars(enabled bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.EnableBrowserStars = &enabled
+	return c.SetAppSettings(settings)
+}
+
+// GetEnableBrowserStars returns the experimental browser Stars! setting
+func (c *Config) GetEnableBrowserStars() (bool, error) {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return false, err
+	}
+	return settings.GetEnableBrowserStars(), nil
+}
+
+// SetInvitationExpiryDays sets the number of days after which pending invitations are declined
+// 0 disables the auto-decline policy
+func (c *Config) SetInvitationExpiryDays(days int) error {
+	if days < 0 {
+		return fmt.Errorf("invitation expiry must not be negative: %d", days)
+	}
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.InvitationExpiryDays = &days
+	return c.SetAppSettings(settings)
+}
+
+// SetInvitationDigest enables or disables the daily pending invitations reminder
+func (c *Config) SetInvitationDigest(enabled bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.InvitationDigest = &enabled
+	return c.SetAppSettings(settings)
+}
+
+// SetSubmissionNotifications sets the order upload confirmation and weekly digest
+func (c *Config) SetSubmissionNotifications(confirmation, digest bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.SubmissionConfirmation = &confirmation
+	settings.SubmissionDigest = &digest
+	return c.SetAppSettings(settings)
+}
+
+// SetLogOutput sets the format of the log lines and whether they are copied to the system log
+func (c *Config) SetLogOutput(format string, syslog bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.LogFormat = &format
+	settings.LogSyslog = &syslog
+	return c.SetAppSettings(settings)
+}
+
+// SetHardDelete sets whether deleted directories are removed for good instead of going to the trash
+func (c *Config) SetHardDelete(enabled bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.HardDelete = &enabled
+	return c.SetAppSettings(settings)
+}
+
+// DeletePath deletes a file or directory, moving it to the trash unless hard
+// deletes are set. A path on another file system than the trash is not
+// deleted for good behind the user's back: it stays, with an error asking for
+// hard deletes.
+func (c *Config) DeletePath(path string) error {
+	if settings, err := c.GetAppSettings(); err == nil && settings.GetHardDelete() {
+		return os.RemoveAll(path)
+	}
+	if err := trash.Move(path); err != nil {
+		if errors.Is(err, trash.ErrCrossDevice) {
+			return fmt.Errorf("%w: %s was kept, turn on hard deletes to delete it", trash.ErrCrossDevice, path)
+		}
+		return err
+	}
+	return nil
+}
+
+// SetHistoryRetentionYears sets how many past years downloaded for review are
+// kept per session, 0 keeping them all
+func (c *Config) SetHistoryRetentionYears(years int) error {
+	if years < 0 {
+		return fmt.Errorf("invalid history retention: %d", years)
+	}
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.HistoryRetentionYears = &years
+	return c.SetAppSettings(settings)
+}
+
+// SetConcurrentLaunchPolicy sets what launching Stars! does when it already
+// runs for the same session or Wine prefix
+func (c *Config) SetConcurrentLaunchPolicy(policy string) error {
+	switch policy {
+	case ConcurrentLaunchAllow, ConcurrentLaunchWarn, ConcurrentLaunchBlock:
+	default:
+		return fmt.Errorf("unknown concurrent launch policy: %s", policy)
+	}
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.ConcurrentLaunchPolicy = &policy
+	return c.SetAppSettings(settings)
+}
+
+// SetReportTurnReads enables or disables reporting to the session hosts when a turn is opened
+func (c *Config) SetReportTurnReads(enabled bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.ReportTurnReads = &enabled
+	return c.SetAppSettings(settings)
+}
+
+// SetDeadlineNotifications enables or disables the notifications of nearing deadlines of missing orders
+func (c *Config) SetDeadlineNotifications(enabled bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.DeadlineNotifications = &enabled
+	return c.SetAppSettings(settings)
+}
+
+// SetFilePermissions sets the octal modes and the group of created game files
+// and directories, "" keeping the default
+func (c *Config) SetFilePermissions(fileMode, dirMode, group string) error {
+	if _, err := ParseFileMode(fileMode); err != nil {
+		return err
+	}
+	if _, err := ParseFileMode(dirMode); err != nil {
+		return err
+	}
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.FileMode = optionalString(fileMode)
+	settings.DirMode = optionalString(dirMode)
+	settings.FileGroup = optionalString(group)
+	return c.SetAppSettings(settings)
+}
+
+// optionalString returns nil for "", a pointer to s otherwise
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// SetDiskSpaceGuard sets the free space (MB, 0 to disable) kept by downloads
+// and backups, and whether they refuse or warn when it would run lower
+func (c *Config) SetDiskSpaceGuard(minFreeMB int, action string) error {
+	if minFreeMB < 0 {
+		return fmt.Errorf("invalid minimum free space: %d", minFreeMB)
+	}
+	if action != DiskSpaceRefuse && action != DiskSpaceWarn {
+		return fmt.Errorf("unknown low disk space action: %s", action)
+	}
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.MinFreeDiskSpaceMB = &minFreeMB
+	settings.LowDiskSpaceAction = &action
+	return c.SetAppSettings(settings)
+}
+
+// SetOrderWatchTiming sets the debounce and write-settle times (ms) of the
+// order file watcher, a settle time of 0 disabling the write-settle check
+func (c *Config) SetOrderWatchTiming(debounceMs, settleMs int) error {
+	if debounceMs <= 0 {
+		return fmt.Errorf("invalid order debounce: %d", debounceMs)
+	}
+	if settleMs < 0 {
+		return fmt.Errorf("invalid order settle time: %d", settleMs)
+	}
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.OrderDebounceMs = &debounceMs
+	settings.OrderSettleMs = &settleMs
+	return c.SetAppSettings(settings)
+}
+
+// GetRaceWorkshopDir returns the folder watched for new race files, "" if none
+func (c *Config) GetRaceWorkshopDir() (string, error) {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return "", err
+	}
+	return settings.GetRaceWorkshopDir(), nil
+}
+
+// SetRaceWorkshopDir sets the folder watched for new race files, "" for none
+func (c *Config) SetRaceWorkshopDir(dir string) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		settings.RaceWorkshopDir = nil
+	} else {
+		settings.RaceWorkshopDir = &dir
+	}
+	return c.SetAppSettings(settings)
+}
+
+// SetStatusFile enables or disables the status file for desktop widgets and
+// sets its path, "" for the default one
+func (c *Config) SetStatusFile(enabled bool, path string) error {
+	if path != "" && !filepath.IsAbs(path) {
+		return fmt.Errorf("status file path must be absolute: %s", path)
+	}
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.StatusFileEnabled = &enabled
+	if path == "" {
+		settings.StatusFilePath = nil
+	} else {
+		settings.StatusFilePath = &path
+	}
+	return c.SetAppSettings(settings)
+}
+
+// SetSessionFeeds enables or disables the Atom feeds of the game directories
+func (c *Config) SetSessionFeeds(enabled bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.SessionFeeds = &enabled
+	return c.SetAppSettings(settings)
+}
+
+// SetWebSocketHeartbeat sets the WebSocket keep-alive settings (in seconds)
+func (c *Config) SetWebSocketHeartbeat(pingInterval, pongWait int, adaptive bool) error {
+	if pingInterval < 0 || pingInterval > 300 {
+		return fmt.Errorf("invalid ping interval: %d", pingInterval)
+	}
+	if pongWait < 10 || pongWait > 600 {
+		return fmt.Errorf("invalid pong wait: %d", pongWait)
+	}
+	if pingInterval > 0 && pingInterval >= pongWait {
+		return fmt.Errorf("ping interval must be shorter than pong wait")
+	}
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.WebSocketPingInterval = &pingInterval
+	settings.WebSocketPongWait = &pongWait
+	settings.WebSocketAdaptive = &adaptive
+	return c.SetAppSettings(settings)
+}
+
+// SetLocalAPI enables or disables the localhost HTTP API and sets its port
+func (c *Config) SetLocalAPI(enabled bool, port int) error {
+	if port < 1024 || port > 65535 {
+		return fmt.Errorf("invalid local API port: %d", port)
+	}
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.LocalAPIEnabled = &enabled
+	settings.LocalAPIPort = &port
+	return c.SetAppSettings(settings)
+}
+
+// SetArtifactCollisionPolicy sets what to do when a generated file already exists
+func (c *Config) SetArtifactCollisionPolicy(policy string) error {
+	if !artifact.IsPolicy(policy) {
+		return fmt.Errorf("unknown collision policy: %s", policy)
+	}
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.ArtifactCollisionPolicy = &policy
+	return c.SetAppSettings(settings)
+}
+
+// SetKioskPin stores the hash of the guest mode PIN, see HashKioskPin.
+// An empty hash removes the PIN and turns guest mode off.
+func (c *Config) SetKioskPin(hash string) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	if hash == "" {
+		settings.KioskPinHash = nil
+		settings.KioskLocked = nil
+	} else {
+		settings.KioskPinHash = &hash
+	}
+	return c.SetAppSettings(settings)
+}
+
+// SetKioskLocked turns guest mode on or off
+func (c *Config) SetKioskLocked(locked bool) error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	if locked && settings.KioskPinHash == nil {
+		return fmt.Errorf("a PIN is required to lock guest mode")
+	}
+	settings.KioskLocked = &locked
+	return c.SetAppSettings(settings)
+}
+
+// SetTelemetry enables or disables usage reports and sets their endpoint.
+// The random install ID is generated the first time telemetry is enabled.
+func (c *Config) SetTelemetry(enabled bool, endpoint string) error {
+	if enabled {
+		u, err := url.Parse(endpoint)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("invalid telemetry endpoint: %q", endpoint)
+		}
+	}
+
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	if enabled && settings.TelemetryID == nil {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return fmt.Errorf("failed to generate telemetry ID: %w", err)
+		}
+		installID := hex.EncodeToString(id)
+		settings.TelemetryID = &installID
+	}
+	settings.TelemetryEnabled = &enabled
+	if endpoint == "" {
+		settings.TelemetryEndpoint = nil
+	} else {
+		settings.TelemetryEndpoint = &endpoint
+	}
+	return c.SetAppSettings(settings)
+}
+
+// GetDeviceID returns the random ID of this machine, reported to servers so
+// they can tell it from the other machines of the user. It is generated on
+// first use.
+func (c *Config) GetDeviceID() (string, error) {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return "", err
+	}
+	if settings.DeviceID != nil {
+		return *settings.DeviceID, nil
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate device ID: %w", err)
+	}
+	deviceID := hex.EncodeToString(id)
+	settings.DeviceID = &deviceID
+	if err := c.SetAppSettings(settings); err != nil {
+		return "", err
+	}
+	return deviceID, nil
+}
+
+// telemetryStateKey is the key of the unsent usage counts in their bucket
+const telemetryStateKey = "state"
+
+// GetTelemetryState retrieves the usage counts not sent yet
+func (c *Config) GetTelemetryState() (*telemetry.State, error) {
+	data, err := c.db.Get(database.BucketTelemetry, telemetryStateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get telemetry state: %w", err)
+	}
+	if data == nil {
+		return &telemetry.State{}, nil
+	}
+
+	var state telemetry.State
+	if err := jsoniter.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal telemetry state: %w", err)
+	}
+	return &state, nil
+}
+
+// SetTelemetryState stores the usage counts not sent yet
+func (c *Config) SetTelemetryState(state telemetry.State) error {
+	data, err := jsoniter.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telemetry state: %w", err)
+	}
+	if err := c.db.Set(database.BucketTelemetry, telemetryStateKey, data); err != nil {
+		return fmt.Errorf("failed to save telemetry state: %w", err)
+	}
+	return nil
+}
+
+// SetPluginEnabled enables or disables a turn post-processing plugin
+func (c *Config) SetPluginEnabled(name string, enabled bool) error {
 	settings, err := c.GetAppSettings()
 	if err != nil {
 		return err
 	}
-	settings.ValidWineInstall = &valid
+
+	plugins := make([]string, 0, len(settings.EnabledPlugins)+1)
+	for _, p := range settings.EnabledPlugins {
+		if p != name {
+			plugins = append(plugins, p)
+		}
+	}
+	if enabled {
+		plugins = append(plugins, name)
+	}
+
+	settings.EnabledPlugins = plugins
 	return c.SetAppSettings(settings)
 }
 
-// GetValidWineInstall returns the wine installation validation status
-func (c *Config) GetValidWineInstall() (bool, error) {
+// SetHook adds or replaces the hook of an event. An empty command removes it
+func (c *Config) SetHook(hook HookEntry) error {
 	settings, err := c.GetAppSettings()
 	if err != nil {
-		return false, err
+		return err
 	}
-	return settings.GetValidWineInstall(), nil
+
+	hooks := make([]HookEntry, 0, len(settings.Hooks)+1)
+	for _, h := range settings.Hooks {
+		if h.Event != hook.Event {
+			hooks = append(hooks, h)
+		}
+	}
+	if hook.Command != "" {
+		hooks = append(hooks, hook)
+	}
+
+	settings.Hooks = hooks
+	return c.SetAppSettings(settings)
 }
 
-// SetEnableBrowserStars updates the experimental browser Stars! setting
-func (c *Config) SetEnableBrowserStars(enabled bool) error {
+// SetStarsIniDefault adds or updates a stars.ini default applied before launching Stars!
+// An empty value removes the default
+func (c *Config) SetStarsIniDefault(entry StarsIniEntry) error {
 	settings, err := c.GetAppSettings()
 	if err != nil {
 		return err
 	}
-	settings.EnableBrowserStars = &enabled
+
+	defaults := make([]StarsIniEntry, 0, len(settings.StarsIniDefaults)+1)
+	for _, d := range settings.StarsIniDefaults {
+		if strings.EqualFold(d.Section, entry.Section) && strings.EqualFold(d.Key, entry.Key) {
+			continue
+		}
+		defaults = append(defaults, d)
+	}
+	if entry.Value != "" {
+		defaults = append(defaults, entry)
+	}
+
+	settings.StarsIniDefaults = defaults
 	return c.SetAppSettings(settings)
 }
 
-// GetEnableBrowserStars returns the experimental browser Stars! setting
-func (c *Config) GetEnableBrowserStars() (bool, error) {
-	settings, err := c.GetAppSettings()
-	if err != nil {
-		return false, err
+// ScreensKey identifies a monitor setup by the sizes of its screens, e.g. "1920x1080,2560x1440"
+func ScreensKey(sizes [][2]int) string {
+	parts := make([]string, 0, len(sizes))
+	for _, size := range sizes {
+		parts = append(parts, fmt.Sprintf("%dx%d", size[0], size[1]))
 	}
-	return settings.GetEnableBrowserStars(), nil
+	sort.Strings(parts)
+	return strings.Join(parts, ",")
 }
 
-// GetWindowGeometry returns the saved window geometry, or nil if not set
-func (c *Config) GetWindowGeometry() (*WindowGeometry, error) {
+// GetWindowGeometry returns the window geometry saved for a monitor setup,
+// the last saved one for an unknown setup, or nil if not set
+func (c *Config) GetWindowGeometry(screens string) (*WindowGeometry, error) {
 	settings, err := c.GetAppSettings()
 	if err != nil {
 		return nil, err
 	}
+	if geom, ok := settings.WindowLayouts[screens]; ok && geom != nil {
+		return geom, nil
+	}
 	return settings.WindowGeometry, nil
 }
 
-// SetWindowGeometry saves the window geometry
-func (c *Config) SetWindowGeometry(geom *WindowGeometry) error {
+// SetWindowGeometry saves the window geometry of a monitor setup
+func (c *Config) SetWindowGeometry(screens string, geom *WindowGeometry) error {
 	settings, err := c.GetAppSettings()
 	if err != nil {
 		return err
 	}
 	settings.WindowGeometry = geom
+	if screens != "" {
+		if settings.WindowLayouts == nil {
+			settings.WindowLayouts = make(map[string]*WindowGeometry)
+		}
+		settings.WindowLayouts[screens] = geom
+	}
+	return c.SetAppSettings(settings)
+}
+
+// ResetWindowGeometry forgets the window geometry of every monitor setup
+func (c *Config) ResetWindowGeometry() error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	settings.WindowGeometry = nil
+	settings.WindowLayouts = nil
 	return c.SetAppSettings(settings)
 }
 
@@ -518,13 +2278,63 @@ func (c *Config) EnsureServersDir() error {
 		return err
 	}
 
-	if err := os.MkdirAll(serversDir, 0755); err != nil {
+	if err := safefile.MkdirAll(serversDir, 0755); err != nil {
 		return fmt.Errorf("failed to create servers directory: %w", err)
 	}
 
 	return nil
 }
 
+// selfTestKey is the app settings key written and removed by CheckDatabase
+const selfTestKey = "selftest"
+
+// CheckDatabase writes, reads back and removes a value, to check that the
+// database is usable
+func (c *Config) CheckDatabase() error {
+	probe := []byte(time.Now().Format(time.RFC3339Nano))
+	if err := c.db.Set(database.BucketAppSettings, selfTestKey, probe); err != nil {
+		return fmt.Errorf("failed to write to database: %w", err)
+	}
+	data, err := c.db.Get(database.BucketAppSettings, selfTestKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from database: %w", err)
+	}
+	if !bytes.Equal(data, probe) {
+		return fmt.Errorf("database returned a different value than written")
+	}
+	if err := c.db.Delete(database.BucketAppSettings, selfTestKey); err != nil {
+		return fmt.Errorf("failed to delete from database: %w", err)
+	}
+	return nil
+}
+
+// CheckServersDir creates and removes a file in the servers directory, to
+// check that game files can be written
+func (c *Config) CheckServersDir() (string, error) {
+	if err := c.EnsureServersDir(); err != nil {
+		return "", err
+	}
+	serversDir, err := c.GetServersDir()
+	if err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(serversDir, ".astrum-selftest-*")
+	if err != nil {
+		return serversDir, fmt.Errorf("servers directory is not writable: %w", err)
+	}
+	_, writeErr := f.WriteString("selftest")
+	closeErr := f.Close()
+	removeErr := os.Remove(f.Name())
+	if err := errors.Join(writeErr, closeErr); err != nil {
+		return serversDir, fmt.Errorf("failed to write to servers directory: %w", err)
+	}
+	if removeErr != nil {
+		return serversDir, fmt.Errorf("failed to remove file from servers directory: %w", removeErr)
+	}
+	return serversDir, nil
+}
+
 // sanitizeServerName converts a server name to a filesystem-safe directory name
 func sanitizeServerName(name string) string {
 	// Replace all Unicode whitespace characters (space, tab, nbsp, etc.) with underscores
@@ -574,6 +2384,9 @@ func (c *Config) ValidateServerName(name string) error {
 // Returns the conflicting server's name if there's a collision, empty string otherwise.
 func (c *Config) CheckServerNameCollision(name string, excludeURL string) (string, error) {
 	sanitized := sanitizeServerName(name)
+	if strings.EqualFold(sanitized, LocalGamesServer) {
+		return LocalGamesServer, ErrServerNameCollision // Directory of the local games
+	}
 
 	servers, err := c.GetServers()
 	if err != nil {
@@ -594,16 +2407,320 @@ func (c *Config) CheckServerNameCollision(name string, excludeURL string) (strin
 	return "", nil
 }
 
+// ErrServerURLInvalid is returned for server URLs that can't be parsed or have no host
+var ErrServerURLInvalid = fmt.Errorf("invalid server URL")
+
+// NormalizeServerURL returns the canonical form of a server URL: https is
+// assumed when no scheme is given, scheme and host are lower-cased, default
+// ports, trailing slashes, queries and fragments are removed
+func NormalizeServerURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", ErrServerURLInvalid
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%w: scheme must be http or https", ErrServerURLInvalid)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "https" && port == "443") || (u.Scheme == "http" && port == "80") {
+		port = ""
+	}
+	if port != "" {
+		host = net.JoinHostPort(host, port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6 literal
+	}
+
+	normalized := url.URL{
+		Scheme: u.Scheme,
+		Host:   host,
+		Path:   strings.TrimRight(u.Path, "/"),
+	}
+	return normalized.String(), nil
+}
+
+// serverIdentity returns what tells two server URLs point to the same server:
+// the normalized URL without its scheme, as a server is often reachable over
+// both http and https
+func serverIdentity(serverURL string) string {
+	normalized, err := NormalizeServerURL(serverURL)
+	if err != nil {
+		normalized = serverURL
+	}
+	_, rest, _ := strings.Cut(normalized, "://")
+	return rest
+}
+
+// FindDuplicateServer returns the configured server pointing to the same
+// server as serverURL, ignoring the server stored under excludeURL, or nil
+func (c *Config) FindDuplicateServer(serverURL string, excludeURL string) (*model.Server, error) {
+	servers, err := c.GetServers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get servers: %w", err)
+	}
+
+	identity := serverIdentity(serverURL)
+	for _, srv := range servers {
+		if srv.URL != excludeURL && serverIdentity(srv.URL) == identity {
+			return &srv, nil
+		}
+	}
+	return nil, nil
+}
+
 // GetSessionGameDir calculates the game directory path for a session
-// Path format: <serversdir>/<servername>/<sessionID>
+// Path format: <serverdir>/<sessionID>, or <serverdir>/<Session_Name (shortid)>
+// once an alias was assigned
 func (c *Config) GetSessionGameDir(serverName, sessionID string) (string, error) {
-	serversDir, err := c.GetServersDir()
+	serverDir, err := c.GetServerDir(serverName)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(serverDir, c.sessionDirName(serverName, sessionID)), nil
+}
+
+// sessionDirKey builds the key of a session directory alias
+func sessionDirKey(serverName, sessionID string) string {
+	return sanitizeServerName(serverName) + "\x00" + sessionID
+}
+
+// sessionDirName returns the directory name of a session: its alias if it has one, its ID otherwise
+func (c *Config) sessionDirName(serverName, sessionID string) string {
+	data, err := c.db.Get(database.BucketSessionDirs, sessionDirKey(serverName, sessionID))
+	if err != nil || len(data) == 0 {
+		return sessionID
+	}
+	return string(data)
+}
+
+// sessionDirAlias builds the human-readable directory name of a session, e.g. "My_Game (3f2a9c1e)"
+func sessionDirAlias(sessionName, sessionID string) string {
+	name := sanitizeServerName(sessionName)
+	if name == "" {
+		return ""
+	}
+	shortID := strings.ReplaceAll(sessionID, "-", "")
+	if len(shortID) > 8 {
+		shortID = shortID[:8]
+	}
+	return fmt.Sprintf("%s (%s)", name, shortID)
+}
+
+// AssignSessionDirAlias gives a session directory a human-readable name built
+// from the session name, so games can be told apart when browsing the servers
+// directory. An existing directory is renamed. The alias is assigned once and
+// kept when the session is renamed, so paths stay stable. Must not be called
+// while the directory is being watched. Returns the game directory.
+func (c *Config) AssignSessionDirAlias(serverName, sessionID, sessionName string) (string, error) {
+	current, err := c.GetSessionGameDir(serverName, sessionID)
 	if err != nil {
 		return "", err
 	}
+	if filepath.Base(current) != sessionID {
+		return current, nil // Already aliased
+	}
 
-	sanitizedName := sanitizeServerName(serverName)
-	return filepath.Join(serversDir, sanitizedName, sessionID), nil
+	alias := sessionDirAlias(sessionName, sessionID)
+	if alias == "" {
+		return current, nil
+	}
+	target := filepath.Join(filepath.Dir(current), alias)
+	if _, err := os.Stat(target); err == nil {
+		return current, fmt.Errorf("session directory %s already exists", target)
+	}
+
+	moved := false
+	if _, err := os.Stat(current); err == nil {
+		if err := os.Rename(current, target); err != nil {
+			return current, fmt.Errorf("failed to rename session directory: %w", err)
+		}
+		moved = true
+	}
+
+	if err := c.db.Set(database.BucketSessionDirs, sessionDirKey(serverName, sessionID), []byte(alias)); err != nil {
+		if moved {
+			_ = os.Rename(target, current)
+		}
+		return current, fmt.Errorf("failed to save session directory alias: %w", err)
+	}
+
+	return target, nil
+}
+
+// SessionDirMatches returns whether a directory name made by hand designates a
+// session: its ID, its name as typed or sanitized, or its alias, ignoring case
+func SessionDirMatches(dirName, sessionName, sessionID string) bool {
+	if strings.EqualFold(dirName, sessionID) {
+		return true
+	}
+	if sessionName == "" {
+		return false
+	}
+	return strings.EqualFold(dirName, sessionName) ||
+		strings.EqualFold(dirName, sanitizeServerName(sessionName)) ||
+		strings.EqualFold(dirName, sessionDirAlias(sessionName, sessionID))
+}
+
+// HasSessionGame returns whether the game directory of a session holds a game,
+// rather than being created empty, waiting for the first turn
+func (c *Config) HasSessionGame(serverName, sessionID string) bool {
+	gameDir, err := c.GetSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(filepath.Join(gameDir, "game.xy"))
+	return err == nil
+}
+
+// AdoptSessionDir makes an existing directory of the server directory, e.g.
+// one copied by hand from another machine, the game directory of a session.
+// The directory keeps its name, recorded as the session alias. Fails when the
+// session already has a game; a game directory without one, e.g. holding only
+// stars.exe, is merged into the adopted directory. Must not be called while
+// the game directory is being watched. Returns the game directory.
+func (c *Config) AdoptSessionDir(serverName, sessionID, dirName string) (string, error) {
+	current, err := c.GetSessionGameDir(serverName, sessionID)
+	if err != nil {
+		return "", err
+	}
+	if c.HasSessionGame(serverName, sessionID) {
+		return current, fmt.Errorf("session already has the game directory %s", current)
+	}
+
+	target := filepath.Join(filepath.Dir(current), dirName)
+	if info, err := os.Stat(target); err != nil || !info.IsDir() {
+		return current, fmt.Errorf("no directory %s to adopt", target)
+	}
+	if target == current {
+		return target, nil
+	}
+
+	if err := mergeDirInto(current, target); err != nil {
+		return current, err
+	}
+	if err := c.db.Set(database.BucketSessionDirs, sessionDirKey(serverName, sessionID), []byte(dirName)); err != nil {
+		return current, fmt.Errorf("failed to save session directory alias: %w", err)
+	}
+	return target, nil
+}
+
+// mergeDirInto moves the entries of src missing from dst into dst, then
+// removes src with the entries dst already had. Nothing is done when src
+// does not exist.
+func mergeDirInto(src, dst string) error {
+	entries, err := os.ReadDir(src)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read directory: %w", err)
+	}
+	for _, entry := range entries {
+		target := filepath.Join(dst, entry.Name())
+		if _, err := os.Stat(target); err == nil {
+			continue
+		}
+		if err := os.Rename(filepath.Join(src, entry.Name()), target); err != nil {
+			return fmt.Errorf("failed to move %s: %w", entry.Name(), err)
+		}
+	}
+	if err := os.RemoveAll(src); err != nil {
+		return fmt.Errorf("failed to remove directory: %w", err)
+	}
+	return nil
+}
+
+// MoveSessionDirAliases moves the session directory aliases of a server to its new name
+func (c *Config) MoveSessionDirAliases(oldServerName, newServerName string) error {
+	oldPrefix := sanitizeServerName(oldServerName) + "\x00"
+	newPrefix := sanitizeServerName(newServerName) + "\x00"
+	if oldPrefix == newPrefix {
+		return nil
+	}
+
+	err := c.db.Update(func(tx *database.Tx) error {
+		return moveKeys(tx, database.BucketSessionDirs, oldPrefix, newPrefix)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move session directory aliases: %w", err)
+	}
+	return nil
+}
+
+// moveKeys renames the keys of a bucket starting with oldPrefix to start with newPrefix
+func moveKeys(tx *database.Tx, bucket, oldPrefix, newPrefix string) error {
+	keys, err := tx.Keys(bucket)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		rest, ok := strings.CutPrefix(key, oldPrefix)
+		if !ok {
+			continue
+		}
+		value, err := tx.Get(bucket, key)
+		if err != nil {
+			return err
+		}
+		if err := tx.Set(bucket, newPrefix+rest, value); err != nil {
+			return err
+		}
+		if err := tx.Delete(bucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteKeys deletes the keys of a bucket starting with prefix
+func deleteKeys(tx *database.Tx, bucket, prefix string) error {
+	keys, err := tx.Keys(bucket)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if err := tx.Delete(bucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sessionDirAliases returns the session IDs of the aliased directories of a server, by directory name
+func (c *Config) sessionDirAliases(serverName string) map[string]string {
+	result := make(map[string]string)
+	all, err := c.db.GetAll(database.BucketSessionDirs)
+	if err != nil {
+		return result
+	}
+
+	prefix := sanitizeServerName(serverName) + "\x00"
+	for key, alias := range all {
+		if sessionID, ok := strings.CutPrefix(key, prefix); ok {
+			result[string(alias)] = sessionID
+		}
+	}
+	return result
+}
+
+// SessionIDForDir returns the session ID of a session directory of a server,
+// resolving aliased directory names
+func (c *Config) SessionIDForDir(serverName, dirName string) string {
+	if sessionID, ok := c.sessionDirAliases(serverName)[dirName]; ok {
+		return sessionID
+	}
+	return dirName
 }
 
 // EnsureSessionGameDir creates the game directory for a session if it doesn't exist
@@ -613,7 +2730,7 @@ func (c *Config) EnsureSessionGameDir(serverName, sessionID string) (string, err
 		return "", err
 	}
 
-	if err := os.MkdirAll(gameDir, 0755); err != nil {
+	if err := safefile.MkdirAll(gameDir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create session game directory: %w", err)
 	}
 
@@ -623,16 +2740,99 @@ func (c *Config) EnsureSessionGameDir(serverName, sessionID string) (string, err
 // OldSessionsDir is the name of the directory where archived sessions are moved
 const OldSessionsDir = "ZZ_OLD_SESSIONS"
 
-// GetServerDir returns the server directory path
-// Path format: <serversdir>/<servername>
+// OldServersDir is the name of the servers directory subfolder where the
+// directories of removed servers are archived
+const OldServersDir = "ZZ_OLD_SERVERS"
+
+// ArchiveServerDir moves serverDir, the directory of a server with all its
+// sessions, to the archive of removed servers next to it. serverDir is
+// resolved with GetServerDir before the server is removed, as it may be in
+// the games directory of the server. Returns the archive path, or "" if the
+// server has no directory.
+func (c *Config) ArchiveServerDir(serverName, serverDir string) (string, error) {
+	if _, err := os.Stat(serverDir); os.IsNotExist(err) {
+		return "", nil // Nothing to archive
+	}
+
+	archiveDir := filepath.Join(filepath.Dir(serverDir), OldServersDir)
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	// Timestamped, as a server of the same name may be removed again later
+	targetDir := filepath.Join(archiveDir, filepath.Base(serverDir)+"_"+time.Now().Format("20060102_150405"))
+	if err := os.Rename(serverDir, targetDir); err != nil {
+		return "", fmt.Errorf("failed to move server directory to archive: %w", err)
+	}
+
+	// The session directory aliases went with the directory
+	if err := c.deleteSessionDirAliases(serverName); err != nil {
+		return targetDir, err
+	}
+
+	return targetDir, nil
+}
+
+// DeleteServerDir deletes serverDir, the directory of a server with all its
+// sessions, resolved as for ArchiveServerDir
+func (c *Config) DeleteServerDir(serverName, serverDir string) error {
+	if err := c.DeletePath(serverDir); err != nil {
+		return fmt.Errorf("failed to delete server directory: %w", err)
+	}
+	return c.deleteSessionDirAliases(serverName)
+}
+
+// deleteSessionDirAliases forgets the session directory names of a server
+func (c *Config) deleteSessionDirAliases(serverName string) error {
+	prefix := sessionDirKey(serverName, "")
+	err := c.db.Update(func(tx *database.Tx) error {
+		keys, err := tx.Keys(database.BucketSessionDirs)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if strings.HasPrefix(key, prefix) {
+				if err := tx.Delete(database.BucketSessionDirs, key); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove session directory aliases: %w", err)
+	}
+	return nil
+}
+
+// GetServerDir returns the server directory path, where all the paths of the
+// game files of a server are resolved from
+// Path format: <root>/<servername>, see GetServerGamesRoot
 func (c *Config) GetServerDir(serverName string) (string, error) {
-	serversDir, err := c.GetServersDir()
+	root, err := c.GetServerGamesRoot(serverName)
 	if err != nil {
 		return "", err
 	}
 
 	sanitizedName := sanitizeServerName(serverName)
-	return filepath.Join(serversDir, sanitizedName), nil
+	return filepath.Join(root, sanitizedName), nil
+}
+
+// GetServerGamesRoot returns the directory holding the directory of a
+// server: its games directory when set, the servers directory otherwise
+func (c *Config) GetServerGamesRoot(serverName string) (string, error) {
+	servers, err := c.GetServers()
+	if err != nil {
+		return "", fmt.Errorf("failed to get servers: %w", err)
+	}
+
+	sanitized := sanitizeServerName(serverName)
+	for _, srv := range servers {
+		if srv.GamesDir != "" && sanitizeServerName(srv.Name) == sanitized {
+			return srv.GamesDir, nil
+		}
+	}
+	return c.GetServersDir()
 }
 
 // ArchiveSessionDir moves a session directory to ZZ_OLD_SESSIONS within the server directory.
@@ -663,11 +2863,12 @@ func (c *Config) ArchiveSessionDir(serverName, sessionID string) (string, error)
 	}
 
 	// Generate unique name if target already exists (append timestamp)
-	targetDir := filepath.Join(archiveDir, sessionID)
+	dirName := filepath.Base(gameDir)
+	targetDir := filepath.Join(archiveDir, dirName)
 	if _, err := os.Stat(targetDir); err == nil {
 		// Target exists, append timestamp
 		timestamp := time.Now().Format("20060102_150405")
-		targetDir = filepath.Join(archiveDir, sessionID+"_"+timestamp)
+		targetDir = filepath.Join(archiveDir, dirName+"_"+timestamp)
 	}
 
 	// Move the session directory
@@ -675,11 +2876,16 @@ func (c *Config) ArchiveSessionDir(serverName, sessionID string) (string, error)
 		return "", fmt.Errorf("failed to move session directory to archive: %w", err)
 	}
 
+	// The alias went with the directory
+	if err := c.db.Delete(database.BucketSessionDirs, sessionDirKey(serverName, sessionID)); err != nil {
+		return targetDir, fmt.Errorf("failed to remove session directory alias: %w", err)
+	}
+
 	return targetDir, nil
 }
 
-// ListSessionDirs returns a list of session directory names in the server directory.
-// It excludes the ZZ_OLD_SESSIONS directory.
+// ListSessionDirs returns the session IDs of the session directories in the server
+// directory, resolving aliased directories. It excludes the ZZ_OLD_SESSIONS directory.
 func (c *Config) ListSessionDirs(serverName string) ([]string, error) {
 	serverDir, err := c.GetServerDir(serverName)
 	if err != nil {
@@ -696,9 +2902,15 @@ func (c *Config) ListSessionDirs(serverName string) ([]string, error) {
 		return nil, fmt.Errorf("failed to read server directory: %w", err)
 	}
 
+	aliases := c.sessionDirAliases(serverName)
+
 	var sessionIDs []string
 	for _, entry := range entries {
 		if entry.IsDir() && entry.Name() != OldSessionsDir {
+			if sessionID, ok := aliases[entry.Name()]; ok {
+				sessionIDs = append(sessionIDs, sessionID)
+				continue
+			}
 			sessionIDs = append(sessionIDs, entry.Name())
 		}
 	}
@@ -0,0 +1,9 @@
+// Package dialog shows native message boxes without a window, for errors that
+// occur before the Wails window exists (fatal startup errors, single instance).
+package dialog
+
+// Error shows a blocking error message box. It returns an error when no
+// dialog could be shown, so that the caller can fall back to logging.
+func Error(title, message string) error {
+	return showError(title, message)
+}
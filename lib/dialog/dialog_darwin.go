@@ -0,0 +1,17 @@
+package dialog
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// showError displays an alert through AppleScript
+func showError(title, message string) error {
+	script := `display alert "` + appleScriptString(title) + `" message "` + appleScriptString(message) + `" as critical buttons {"OK"} default button "OK"`
+	return exec.Command("osascript", "-e", script).Run()
+}
+
+// appleScriptString escapes a string for a double-quoted AppleScript literal
+func appleScriptString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
@@ -0,0 +1,27 @@
+package dialog
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// errNoDialogTool is returned when none of the supported dialog tools is installed
+var errNoDialogTool = errors.New("no dialog tool found (zenity, kdialog or xmessage)")
+
+// showError uses the first dialog tool available: zenity (GNOME), kdialog
+// (KDE), then xmessage (basic X11)
+func showError(title, message string) error {
+	candidates := [][]string{
+		{"zenity", "--error", "--title=" + title, "--text=" + message},
+		{"kdialog", "--error", message, "--title", title},
+		{"xmessage", "-center", message},
+	}
+
+	for _, args := range candidates {
+		if _, err := exec.LookPath(args[0]); err != nil {
+			continue
+		}
+		return exec.Command(args[0], args[1:]...).Run()
+	}
+	return errNoDialogTool
+}
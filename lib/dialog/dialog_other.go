@@ -0,0 +1,12 @@
+//go:build !linux && !darwin && !windows
+
+package dialog
+
+import (
+	"errors"
+)
+
+// showError has no native dialog to use on this platform
+func showError(title, message string) error {
+	return errors.New("no native dialog on this platform")
+}
@@ -0,0 +1,19 @@
+package dialog
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// showError displays a Win32 message box
+func showError(title, message string) error {
+	titlePtr, err := windows.UTF16PtrFromString(title)
+	if err != nil {
+		return err
+	}
+	messagePtr, err := windows.UTF16PtrFromString(message)
+	if err != nil {
+		return err
+	}
+	_, err = windows.MessageBox(0, messagePtr, titlePtr, windows.MB_OK|windows.MB_ICONERROR|windows.MB_SETFOREGROUND)
+	return err
+}
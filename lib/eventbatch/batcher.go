@@ -0,0 +1,197 @@
+// Package eventbatch spaces out the events sent to the frontend. Events of a
+// type set to Latest are held for a short window, and only the last one per
+// key is delivered, so a burst of updates of the same thing renders once.
+// Other events go through right away.
+package eventbatch
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Policy tells how events of a type are delivered
+type Policy int
+
+const (
+	// Immediate delivers every event right away
+	Immediate Policy = iota
+	// Latest holds events until the next flush and delivers the last one per key
+	Latest
+)
+
+// String returns the policy name
+func (p Policy) String() string {
+	switch p {
+	case Immediate:
+		return "immediate"
+	case Latest:
+		return "latest"
+	default:
+		return "unknown"
+	}
+}
+
+// ParsePolicy returns the policy of a name, see Policy.String
+func ParsePolicy(name string) (Policy, error) {
+	switch name {
+	case "immediate":
+		return Immediate, nil
+	case "latest":
+		return Latest, nil
+	default:
+		return Immediate, fmt.Errorf("unknown event policy: %s", name)
+	}
+}
+
+// Rule is the delivery of an event type. With Latest, the first KeyArgs
+// arguments of an event tell which thing it is about, e.g. 2 for events
+// carrying the server URL and session ID first; 0 keeps one event in all.
+type Rule struct {
+	Policy  Policy
+	KeyArgs int
+}
+
+// EmitFunc delivers an event to the frontend
+type EmitFunc func(name string, args ...any)
+
+// pending is an event held until the next flush
+type pending struct {
+	name string
+	args []any
+	seq  int // arrival order of the first event of the key
+}
+
+// Batcher delivers events according to the rule of their type
+type Batcher struct {
+	mu       sync.Mutex
+	emit     EmitFunc
+	interval time.Duration
+	rules    map[string]Rule // event name, or prefix ending with "*" -> rule
+	pending  map[string]*pending
+	seq      int
+	timer    *time.Timer
+	stopped  bool
+}
+
+// New creates a batcher flushing held events every interval
+func New(interval time.Duration, emit EmitFunc) *Batcher {
+	return &Batcher{
+		emit:     emit,
+		interval: interval,
+		rules:    make(map[string]Rule),
+		pending:  make(map[string]*pending),
+	}
+}
+
+// SetRule sets the delivery of an event type. A name ending with "*" applies
+// to every event starting with the rest, exact names taking precedence.
+func (b *Batcher) SetRule(name string, rule Rule) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rules[name] = rule
+}
+
+// Rules returns the rules set, by event name
+func (b *Batcher) Rules() map[string]Rule {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	rules := make(map[string]Rule, len(b.rules))
+	for name, rule := range b.rules {
+		rules[name] = rule
+	}
+	return rules
+}
+
+// Emit delivers an event, or holds it until the next flush
+func (b *Batcher) Emit(name string, args ...any) {
+	b.mu.Lock()
+	if b.stopped {
+		b.mu.Unlock()
+		return
+	}
+	rule := b.rule(name)
+	if rule.Policy == Immediate {
+		b.mu.Unlock()
+		b.emit(name, args...)
+		return
+	}
+
+	key := eventKey(name, args, rule.KeyArgs)
+	if p, ok := b.pending[key]; ok {
+		p.args = args
+	} else {
+		b.pending[key] = &pending{name: name, args: args, seq: b.seq}
+		b.seq++
+	}
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, b.Flush)
+	}
+	b.mu.Unlock()
+}
+
+// Flush delivers the held events, in the order they first arrived
+func (b *Batcher) Flush() {
+	b.mu.Lock()
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	events := make([]*pending, 0, len(b.pending))
+	for _, p := range b.pending {
+		events = append(events, p)
+	}
+	b.pending = make(map[string]*pending)
+	stopped := b.stopped
+	b.mu.Unlock()
+
+	if stopped {
+		return
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].seq < events[j].seq })
+	for _, p := range events {
+		b.emit(p.name, p.args...)
+	}
+}
+
+// Stop drops the held events and delivers nothing more, e.g. once the
+// frontend is gone
+func (b *Batcher) Stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.stopped = true
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	b.pending = make(map[string]*pending)
+}
+
+// rule returns the rule of an event: its exact name, else the longest
+// matching prefix, else Immediate. Must be called with mu held.
+func (b *Batcher) rule(name string) Rule {
+	if rule, ok := b.rules[name]; ok {
+		return rule
+	}
+	best, bestLen := Rule{}, -1
+	for pattern, rule := range b.rules {
+		prefix, ok := strings.CutSuffix(pattern, "*")
+		if ok && strings.HasPrefix(name, prefix) && len(prefix) > bestLen {
+			best, bestLen = rule, len(prefix)
+		}
+	}
+	return best
+}
+
+// eventKey identifies the thing an event is about
+func eventKey(name string, args []any, keyArgs int) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+	for i := 0; i < keyArgs && i < len(args); i++ {
+		sb.WriteByte(0)
+		fmt.Fprint(&sb, args[i])
+	}
+	return sb.String()
+}
@@ -0,0 +1,107 @@
+// Package feed writes Atom feeds (RFC 4287), so the events of a game can be
+// followed from any feed reader.
+package feed
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+// atomNamespace is the XML namespace of Atom documents
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// Feed is an Atom feed
+type Feed struct {
+	ID      string // permanent IRI of the feed
+	Title   string
+	Author  string
+	Link    string // optional alternate link
+	Entries []Entry
+}
+
+// Entry is an entry of a feed
+type Entry struct {
+	ID      string // permanent IRI of the entry, unique in the feed
+	Title   string
+	Summary string
+	Updated time.Time
+}
+
+// atomFeed and the types below are the XML form of a feed
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Link    *atomLink   `xml:"link,omitempty"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomEntry struct {
+	ID      string `xml:"id"`
+	Title   string `xml:"title"`
+	Updated string `xml:"updated"`
+	Summary string `xml:"summary,omitempty"`
+}
+
+// Marshal renders the feed as an Atom document. Entries are written in the
+// given order; the feed is as recent as its most recent entry, or as now
+// when it has none.
+func (f *Feed) Marshal(now time.Time) ([]byte, error) {
+	if f.ID == "" || f.Title == "" {
+		return nil, fmt.Errorf("feed needs an ID and a title")
+	}
+
+	updated := time.Time{}
+	doc := atomFeed{
+		XMLNS:   atomNamespace,
+		ID:      f.ID,
+		Title:   f.Title,
+		Author:  atomAuthor{Name: f.Author},
+		Entries: make([]atomEntry, 0, len(f.Entries)),
+	}
+	if f.Link != "" {
+		doc.Link = &atomLink{Href: f.Link, Rel: "alternate"}
+	}
+	for _, e := range f.Entries {
+		if e.ID == "" {
+			return nil, fmt.Errorf("feed entry %q has no ID", e.Title)
+		}
+		if e.Updated.After(updated) {
+			updated = e.Updated
+		}
+		doc.Entries = append(doc.Entries, atomEntry{
+			ID:      e.ID,
+			Title:   e.Title,
+			Updated: formatTime(e.Updated),
+			Summary: e.Summary,
+		})
+	}
+	if updated.IsZero() {
+		updated = now
+	}
+	doc.Updated = formatTime(updated)
+
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render feed: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+// formatTime formats a date as Atom expects (RFC 3339)
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
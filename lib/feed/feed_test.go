@@ -0,0 +1,54 @@
+package feed
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	generated := time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)
+	f := &Feed{
+		ID:     "https://neper.example/sessions/abc",
+		Title:  "My Game",
+		Author: "Astrum",
+		Entries: []Entry{
+			{ID: "https://neper.example/sessions/abc#turn-2401", Title: "Turn 2401 generated", Updated: generated},
+			{ID: "https://neper.example/sessions/abc#turn-2400", Title: "Turn 2400 generated", Updated: generated.Add(-time.Hour)},
+		},
+	}
+
+	data, err := f.Marshal(time.Now())
+	require.NoError(t, err)
+
+	var doc atomFeed
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, "My Game", doc.Title)
+	assert.Equal(t, "2026-10-16T08:00:00Z", doc.Updated, "the feed is as recent as its latest entry")
+	require.Len(t, doc.Entries, 2)
+	assert.Equal(t, "Turn 2401 generated", doc.Entries[0].Title)
+}
+
+func TestMarshalEmpty(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	f := &Feed{ID: "urn:test", Title: "Empty"}
+
+	data, err := f.Marshal(now)
+	require.NoError(t, err)
+
+	var doc atomFeed
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, "2026-10-16T09:00:00Z", doc.Updated)
+	assert.Empty(t, doc.Entries)
+}
+
+func TestMarshalRequiresIDs(t *testing.T) {
+	_, err := (&Feed{Title: "No ID"}).Marshal(time.Now())
+	assert.Error(t, err)
+
+	_, err = (&Feed{ID: "urn:test", Title: "Entry without ID", Entries: []Entry{{Title: "x"}}}).Marshal(time.Now())
+	assert.Error(t, err)
+}
@@ -3,12 +3,15 @@ package filehash
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 
 	"github.com/neper-stars/astrum/database"
 	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/safefile"
 )
 
 // KeySeparator is used to separate serverURL, sessionID, and filePath in DB keys
@@ -18,29 +21,65 @@ const KeySeparator = "\x00"
 // Hashes are persisted to the database for durability across app restarts
 // Keys are structured as: serverURL + KeySeparator + sessionID + KeySeparator + filePath
 type Tracker struct {
-	mu     sync.RWMutex
-	db     *database.DB
-	hashes map[string]string // compositeKey -> sha256 hex string (in-memory cache)
+	mu       sync.RWMutex
+	db       *database.DB
+	hashes   map[string]string // compositeKey -> sha256 hex string (in-memory cache)
+	loadOnce sync.Once
+	loadErr  error
 }
 
 // NewTracker creates a new file hash tracker with database persistence
 func NewTracker(db *database.DB) (*Tracker, error) {
-	t := &Tracker{
-		db:     db,
-		hashes: make(map[string]string),
-	}
+	t := NewLazyTracker(db)
 
 	// Load existing hashes from database
-	if err := t.loadFromDB(); err != nil {
+	if err := t.Load(); err != nil {
 		return nil, err
 	}
 
 	return t, nil
 }
 
-// makeKey creates a composite key from serverURL, sessionID, and filePath
+// NewLazyTracker creates a file hash tracker loading the stored hashes on
+// first use, or when Load is called, keeping them off the startup path
+func NewLazyTracker(db *database.DB) *Tracker {
+	return &Tracker{
+		db:     db,
+		hashes: make(map[string]string),
+	}
+}
+
+// Load loads the stored hashes if not done yet. A failed load leaves the
+// tracker empty: files are then all seen as changed and rewritten once.
+func (t *Tracker) Load() error {
+	t.loadOnce.Do(func() {
+		t.loadErr = t.loadFromDB()
+	})
+	return t.loadErr
+}
+
+// lock and rlock lock the tracker once the stored hashes are loaded
+func (t *Tracker) lock() {
+	_ = t.Load()
+	t.mu.Lock()
+}
+
+func (t *Tracker) rlock() {
+	_ = t.Load()
+	t.mu.RLock()
+}
+
+// makeKey creates a composite key from serverURL, sessionID, and filePath.
+// The file name is lowercased: Stars! writes GAME.X1 as well as game.x1 and
+// both must share the same hash.
 func makeKey(serverURL, sessionID, filePath string) string {
-	return serverURL + KeySeparator + sessionID + KeySeparator + filePath
+	return serverURL + KeySeparator + sessionID + KeySeparator + normalizePath(filePath)
+}
+
+// normalizePath lowercases the file name of a path, leaving its directory as is
+func normalizePath(filePath string) string {
+	dir, name := filepath.Split(filePath)
+	return dir + strings.ToLower(name)
 }
 
 // parseKey extracts serverURL, sessionID, and filePath from a composite key
@@ -63,6 +102,10 @@ func (t *Tracker) loadFromDB() error {
 	defer t.mu.Unlock()
 
 	for key, hash := range data {
+		// Keys saved before file names were normalized are read normalized
+		if serverURL, sessionID, filePath, ok := parseKey(key); ok {
+			key = makeKey(serverURL, sessionID, filePath)
+		}
 		t.hashes[key] = string(hash)
 	}
 
@@ -91,7 +134,7 @@ func ComputeFileHash(filePath string) (string, error) {
 // GetHash returns the stored hash for a file, or empty string if not tracked
 func (t *Tracker) GetHash(serverURL, sessionID, filePath string) string {
 	key := makeKey(serverURL, sessionID, filePath)
-	t.mu.RLock()
+	t.rlock()
 	defer t.mu.RUnlock()
 	return t.hashes[key]
 }
@@ -100,7 +143,7 @@ func (t *Tracker) GetHash(serverURL, sessionID, filePath string) string {
 func (t *Tracker) SetHash(serverURL, sessionID, filePath, hash string) error {
 	key := makeKey(serverURL, sessionID, filePath)
 
-	t.mu.Lock()
+	t.lock()
 	t.hashes[key] = hash
 	t.mu.Unlock()
 
@@ -142,7 +185,7 @@ func (t *Tracker) WriteFileIfChanged(serverURL, sessionID, filePath string, data
 	}
 
 	// Content changed or new file, write it
-	if err := os.WriteFile(filePath, data, perm); err != nil {
+	if err := safefile.WriteFile(filePath, data, perm); err != nil {
 		return false, err
 	}
 
@@ -168,7 +211,7 @@ func (t *Tracker) WriteFileIfChanged(serverURL, sessionID, filePath string, data
 func (t *Tracker) ForgetFile(serverURL, sessionID, filePath string) error {
 	key := makeKey(serverURL, sessionID, filePath)
 
-	t.mu.Lock()
+	t.lock()
 	delete(t.hashes, key)
 	t.mu.Unlock()
 
@@ -179,7 +222,7 @@ func (t *Tracker) ForgetFile(serverURL, sessionID, filePath string) error {
 func (t *Tracker) ForgetSession(serverURL, sessionID string) error {
 	prefix := serverURL + KeySeparator + sessionID + KeySeparator
 
-	t.mu.Lock()
+	t.lock()
 	var toDelete []string
 	for key := range t.hashes {
 		if strings.HasPrefix(key, prefix) {
@@ -210,11 +253,26 @@ func (t *Tracker) ForgetSession(serverURL, sessionID string) error {
 	return nil
 }
 
+// SessionHashes returns the hashes tracked for a session, by file path
+func (t *Tracker) SessionHashes(serverURL, sessionID string) map[string]string {
+	prefix := serverURL + KeySeparator + sessionID + KeySeparator
+
+	t.rlock()
+	defer t.mu.RUnlock()
+	result := make(map[string]string)
+	for key, hash := range t.hashes {
+		if path, ok := strings.CutPrefix(key, prefix); ok {
+			result[path] = hash
+		}
+	}
+	return result
+}
+
 // ForgetServer removes all hashes for a specific server
 func (t *Tracker) ForgetServer(serverURL string) error {
 	prefix := serverURL + KeySeparator
 
-	t.mu.Lock()
+	t.lock()
 	var toDelete []string
 	for key := range t.hashes {
 		if strings.HasPrefix(key, prefix) {
@@ -244,9 +302,139 @@ func (t *Tracker) ForgetServer(serverURL string) error {
 	return nil
 }
 
+// MigrateServer moves the hashes of a server to its new URL, so files and
+// orders already seen are not downloaded or uploaded again after a URL change
+func (t *Tracker) MigrateServer(oldURL, newURL string) error {
+	if oldURL == newURL {
+		return nil
+	}
+	prefix := oldURL + KeySeparator
+
+	t.rlock()
+	moved := make(map[string]string) // old key -> new key
+	for key := range t.hashes {
+		if strings.HasPrefix(key, prefix) {
+			moved[key] = newURL + KeySeparator + strings.TrimPrefix(key, prefix)
+		}
+	}
+	t.mu.RUnlock()
+
+	if err := t.rekey(moved); err != nil {
+		return err
+	}
+
+	logger.App.Debug().
+		Str("from", oldURL).
+		Str("to", newURL).
+		Int("moved", len(moved)).
+		Msg("Migrated server file hashes")
+
+	return nil
+}
+
+// MoveSessionDir rekeys the hashes of the files of a session after its game
+// directory moved from oldDir to newDir, so the files are not seen as new
+func (t *Tracker) MoveSessionDir(serverURL, sessionID, oldDir, newDir string) error {
+	moved, err := t.moveDir(serverURL+KeySeparator+sessionID+KeySeparator, oldDir, newDir)
+	if err != nil {
+		return err
+	}
+
+	logger.App.Debug().
+		Str("serverURL", serverURL).
+		Str("sessionID", sessionID).
+		Int("moved", moved).
+		Msg("Moved session file hashes")
+
+	return nil
+}
+
+// MoveServerDir rekeys the hashes of the files of every session of a server
+// after the server directory moved from oldDir to newDir
+func (t *Tracker) MoveServerDir(serverURL, oldDir, newDir string) error {
+	moved, err := t.moveDir(serverURL+KeySeparator, oldDir, newDir)
+	if err != nil {
+		return err
+	}
+
+	logger.App.Debug().
+		Str("serverURL", serverURL).
+		Int("moved", moved).
+		Msg("Moved server file hashes")
+
+	return nil
+}
+
+// moveDir rekeys the hashes of keys starting with keyPrefix whose file lives
+// under oldDir, to the same file under newDir. Returns the number of moved hashes.
+func (t *Tracker) moveDir(keyPrefix, oldDir, newDir string) (int, error) {
+	oldDir = filepath.Clean(oldDir)
+
+	t.rlock()
+	moved := make(map[string]string) // old key -> new key
+	for key := range t.hashes {
+		if !strings.HasPrefix(key, keyPrefix) {
+			continue
+		}
+		serverURL, sessionID, filePath, ok := parseKey(key)
+		if !ok || !strings.HasPrefix(filePath, oldDir+string(filepath.Separator)) {
+			continue
+		}
+		rel, err := filepath.Rel(oldDir, filePath)
+		if err != nil {
+			continue
+		}
+		moved[key] = makeKey(serverURL, sessionID, filepath.Join(newDir, rel))
+	}
+	t.mu.RUnlock()
+
+	if err := t.rekey(moved); err != nil {
+		return 0, err
+	}
+	return len(moved), nil
+}
+
+// rekey moves hashes from their old key to their new key, in a single
+// database transaction so that either all of them move or none does
+func (t *Tracker) rekey(moved map[string]string) error {
+	if len(moved) == 0 {
+		return nil
+	}
+
+	t.lock()
+	defer t.mu.Unlock()
+
+	err := t.db.Update(func(tx *database.Tx) error {
+		for oldKey, newKey := range moved {
+			hash, ok := t.hashes[oldKey]
+			if !ok {
+				continue // Forgotten meanwhile
+			}
+			if err := tx.Set(database.BucketFileHashes, newKey, []byte(hash)); err != nil {
+				return err
+			}
+			if err := tx.Delete(database.BucketFileHashes, oldKey); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to move file hashes: %w", err)
+	}
+
+	for oldKey, newKey := range moved {
+		if hash, ok := t.hashes[oldKey]; ok {
+			t.hashes[newKey] = hash
+			delete(t.hashes, oldKey)
+		}
+	}
+	return nil
+}
+
 // Clear removes all tracked hashes
 func (t *Tracker) Clear() error {
-	t.mu.Lock()
+	t.lock()
 	keys := make([]string, 0, len(t.hashes))
 	for key := range t.hashes {
 		keys = append(keys, key)
@@ -269,7 +457,7 @@ func (t *Tracker) Clear() error {
 
 // TrackedCount returns the number of tracked files
 func (t *Tracker) TrackedCount() int {
-	t.mu.RLock()
+	t.rlock()
 	defer t.mu.RUnlock()
 	return len(t.hashes)
 }
@@ -284,7 +472,7 @@ type FileInfo struct {
 
 // GetAllFiles returns info about all tracked files
 func (t *Tracker) GetAllFiles() []FileInfo {
-	t.mu.RLock()
+	t.rlock()
 	defer t.mu.RUnlock()
 
 	result := make([]FileInfo, 0, len(t.hashes))
@@ -307,7 +495,7 @@ func (t *Tracker) GetAllFiles() []FileInfo {
 func (t *Tracker) GetSessionFiles(serverURL, sessionID string) []FileInfo {
 	prefix := serverURL + KeySeparator + sessionID + KeySeparator
 
-	t.mu.RLock()
+	t.rlock()
 	defer t.mu.RUnlock()
 
 	var result []FileInfo
@@ -332,7 +520,7 @@ func (t *Tracker) GetSessionFiles(serverURL, sessionID string) []FileInfo {
 func (t *Tracker) GetServerFiles(serverURL string) []FileInfo {
 	prefix := serverURL + KeySeparator
 
-	t.mu.RLock()
+	t.rlock()
 	defer t.mu.RUnlock()
 
 	var result []FileInfo
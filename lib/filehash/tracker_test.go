@@ -207,6 +207,45 @@ func TestTracker_ForgetServerClearsAllSessions(t *testing.T) {
 	assert.Empty(t, tracker.GetHash(serverURL, "session-2", "order:2400"))
 }
 
+func TestTracker_MoveSessionDirRekeysFiles(t *testing.T) {
+	tracker, cleanup := setupTestTracker(t)
+	defer cleanup()
+
+	serverURL := "https://test.server.com"
+	sessionID := "session-123"
+	oldDir := filepath.Join("servers", "Test", sessionID)
+	newDir := filepath.Join("servers", "Test", "My_Game (session1)")
+
+	require.NoError(t, tracker.SetHash(serverURL, sessionID, filepath.Join(oldDir, "game.m1"), "turnhash"))
+	require.NoError(t, tracker.SetHash(serverURL, sessionID, "order:2400", "orderhash"))
+
+	require.NoError(t, tracker.MoveSessionDir(serverURL, sessionID, oldDir, newDir))
+
+	assert.Empty(t, tracker.GetHash(serverURL, sessionID, filepath.Join(oldDir, "game.m1")))
+	assert.Equal(t, "turnhash", tracker.GetHash(serverURL, sessionID, filepath.Join(newDir, "game.m1")))
+	assert.Equal(t, "orderhash", tracker.GetHash(serverURL, sessionID, "order:2400"), "Non-path keys are kept")
+}
+
+func TestTracker_MigrateServerKeepsHashes(t *testing.T) {
+	tracker, cleanup := setupTestTracker(t)
+	defer cleanup()
+
+	oldURL := "https://old.server.com"
+	newURL := "https://new.server.com"
+	otherURL := "https://other.server.com"
+
+	require.NoError(t, tracker.SetHash(oldURL, "session-1", "order:2400", "hash1"))
+	require.NoError(t, tracker.SetHash(oldURL, "session-2", "order:2401", "hash2"))
+	require.NoError(t, tracker.SetHash(otherURL, "session-3", "order:2400", "hash3"))
+
+	require.NoError(t, tracker.MigrateServer(oldURL, newURL))
+
+	assert.Empty(t, tracker.GetHash(oldURL, "session-1", "order:2400"))
+	assert.Equal(t, "hash1", tracker.GetHash(newURL, "session-1", "order:2400"))
+	assert.Equal(t, "hash2", tracker.GetHash(newURL, "session-2", "order:2401"))
+	assert.Equal(t, "hash3", tracker.GetHash(otherURL, "session-3", "order:2400"), "Other servers are untouched")
+}
+
 func TestTracker_DifferentServersSeparateHashes(t *testing.T) {
 	tracker, cleanup := setupTestTracker(t)
 	defer cleanup()
@@ -227,6 +266,24 @@ func TestTracker_DifferentServersSeparateHashes(t *testing.T) {
 	assert.Equal(t, "hash-server2", tracker.GetHash(server2, sessionID, orderKey))
 }
 
+func TestTracker_FileNameCaseInsensitive(t *testing.T) {
+	tracker, cleanup := setupTestTracker(t)
+	defer cleanup()
+
+	serverURL := "https://test.server.com"
+	sessionID := "session-123"
+	gameDir := filepath.Join(os.TempDir(), "Games", "session-123")
+
+	// Stars! may write GAME.X1 where game.x1 is expected
+	err := tracker.SetHash(serverURL, sessionID, filepath.Join(gameDir, "GAME.X1"), "hash-upper")
+	require.NoError(t, err)
+
+	assert.Equal(t, "hash-upper", tracker.GetHash(serverURL, sessionID, filepath.Join(gameDir, "game.x1")))
+
+	// The directory is not a Stars! file name and keeps its case
+	assert.Empty(t, tracker.GetHash(serverURL, sessionID, filepath.Join(os.TempDir(), "games", "session-123", "game.x1")))
+}
+
 func TestTracker_PersistenceAcrossRestart(t *testing.T) {
 	// Create a temporary directory for the test database
 	tmpDir, err := os.MkdirTemp("", "filehash_persist_test")
@@ -377,3 +434,23 @@ func TestOrderConflictDetectionLogic(t *testing.T) {
 	result = checkOrder(2401, orderData2401)
 	assert.Equal(t, ShouldSkip, result, "Same order year 2401 should skip")
 }
+
+func TestTracker_LazyLoad(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "filehash_test")
+	require.NoError(t, err)
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	db, err := database.Open(tmpDir)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	tracker, err := NewTracker(db)
+	require.NoError(t, err)
+	require.NoError(t, tracker.SetHash("https://server", "session", "order:2400", "stored-hash"))
+
+	// Loaded on first use, without calling Load
+	lazy := NewLazyTracker(db)
+	assert.Equal(t, "stored-hash", lazy.GetHash("https://server", "session", "order:2400"))
+	assert.NoError(t, lazy.Load())
+	assert.Equal(t, 1, lazy.TrackedCount())
+}
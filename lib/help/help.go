@@ -0,0 +1,183 @@
+// Package help serves the in-app help, Markdown topics embedded in the
+// binary so the help always matches the version of the app.
+package help
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed topics/*.md
+var topicsFS embed.FS
+
+// Topic is a help page
+type Topic struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Body  string `json:"body"` // Markdown, title included
+}
+
+// SearchResult is a topic matching a search
+type SearchResult struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Snippet string `json:"snippet"` // the first line matching the search
+}
+
+// screenTopics maps the screens of the app to the topic explaining them
+var screenTopics = map[string]string{
+	"race-builder":     "race-building",
+	"races":            "race-building",
+	"settings-wine":    "wine-setup",
+	"launch-stars":     "wine-setup",
+	"session-orders":   "order-auto-upload",
+	"settings-monitor": "order-auto-upload",
+}
+
+var (
+	loadOnce sync.Once
+	topics   map[string]*Topic
+	loadErr  error
+)
+
+// load parses the embedded topics once
+func load() (map[string]*Topic, error) {
+	loadOnce.Do(func() {
+		topics, loadErr = parseTopics(topicsFS)
+	})
+	return topics, loadErr
+}
+
+// parseTopics reads the topics of a folder, named after their file. The
+// title is the first "# " heading.
+func parseTopics(fsys fs.FS) (map[string]*Topic, error) {
+	files, err := fs.Glob(fsys, "topics/*.md")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*Topic, len(files))
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read help topic %s: %w", file, err)
+		}
+		id := strings.TrimSuffix(path.Base(file), ".md")
+		topic := &Topic{ID: id, Title: id, Body: string(data)}
+		for _, line := range strings.Split(topic.Body, "\n") {
+			if title, ok := strings.CutPrefix(line, "# "); ok {
+				topic.Title = strings.TrimSpace(title)
+				break
+			}
+		}
+		result[id] = topic
+	}
+	return result, nil
+}
+
+// Get returns a topic by ID
+func Get(id string) (*Topic, error) {
+	all, err := load()
+	if err != nil {
+		return nil, err
+	}
+	topic, ok := all[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown help topic: %s", id)
+	}
+	return topic, nil
+}
+
+// List returns all the topics, without their body, sorted by title
+func List() ([]Topic, error) {
+	all, err := load()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Topic, 0, len(all))
+	for _, t := range all {
+		result = append(result, Topic{ID: t.ID, Title: t.Title})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Title < result[j].Title })
+	return result, nil
+}
+
+// ForScreen returns the topic explaining a screen of the app
+func ForScreen(screen string) (*Topic, error) {
+	id, ok := screenTopics[screen]
+	if !ok {
+		return nil, fmt.Errorf("no help topic for screen: %s", screen)
+	}
+	return Get(id)
+}
+
+// Search returns the topics containing all the words of query, ignoring
+// case, those matching in their title first
+func Search(query string) ([]SearchResult, error) {
+	all, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return search(all, query), nil
+}
+
+func search(all map[string]*Topic, query string) []SearchResult {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return []SearchResult{}
+	}
+
+	type match struct {
+		result  SearchResult
+		inTitle int
+	}
+	var matches []match
+	for _, t := range all {
+		body := strings.ToLower(t.Body)
+		title := strings.ToLower(t.Title)
+		found, inTitle := true, 0
+		for _, w := range words {
+			if !strings.Contains(body, w) {
+				found = false
+				break
+			}
+			if strings.Contains(title, w) {
+				inTitle++
+			}
+		}
+		if !found {
+			continue
+		}
+		matches = append(matches, match{
+			result:  SearchResult{ID: t.ID, Title: t.Title, Snippet: snippet(t.Body, words[0])},
+			inTitle: inTitle,
+		})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].inTitle != matches[j].inTitle {
+			return matches[i].inTitle > matches[j].inTitle
+		}
+		return matches[i].result.Title < matches[j].result.Title
+	})
+	results := make([]SearchResult, len(matches))
+	for i, m := range matches {
+		results[i] = m.result
+	}
+	return results
+}
+
+// snippet returns the first line of body containing word
+func snippet(body, word string) string {
+	for _, line := range strings.Split(body, "\n") {
+		if strings.Contains(strings.ToLower(line), word) {
+			return strings.TrimSpace(strings.TrimLeft(line, "#-"))
+		}
+	}
+	return ""
+}
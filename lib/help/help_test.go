@@ -0,0 +1,50 @@
+package help
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicsAreEmbedded(t *testing.T) {
+	topics, err := List()
+	require.NoError(t, err)
+	require.NotEmpty(t, topics)
+
+	for _, summary := range topics {
+		topic, err := Get(summary.ID)
+		require.NoError(t, err)
+		assert.NotEqual(t, topic.ID, topic.Title, "topic %s has no title", topic.ID)
+		assert.NotEmpty(t, topic.Body)
+	}
+}
+
+func TestScreenTopicsExist(t *testing.T) {
+	for screen := range screenTopics {
+		_, err := ForScreen(screen)
+		assert.NoError(t, err, "screen %s", screen)
+	}
+
+	_, err := ForScreen("no-such-screen")
+	assert.Error(t, err)
+}
+
+func TestSearch(t *testing.T) {
+	all := map[string]*Topic{
+		"a": {ID: "a", Title: "Wine setup", Body: "# Wine setup\nInstall wine with 32-bit support."},
+		"b": {ID: "b", Title: "Orders", Body: "# Orders\nOrders upload.\nOn Linux, wine runs Stars!"},
+	}
+
+	results := search(all, "WINE")
+	require.Len(t, results, 2)
+	assert.Equal(t, "a", results[0].ID, "title matches come first")
+	assert.Equal(t, "On Linux, wine runs Stars!", results[1].Snippet)
+
+	results = search(all, "wine upload")
+	require.Len(t, results, 1)
+	assert.Equal(t, "b", results[0].ID)
+
+	assert.Empty(t, search(all, "  "))
+	assert.Empty(t, search(all, "serial"))
+}
@@ -0,0 +1,123 @@
+// Package hooks runs user-configured commands on Astrum lifecycle events,
+// the desktop equivalent of webhooks.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"sort"
+	"time"
+)
+
+// Lifecycle events a hook can be attached to
+const (
+	EventTurnDownloaded = "turn-downloaded"
+	EventOrderUploaded  = "order-uploaded"
+	EventGameFinished   = "game-finished"
+
+	// EventSubmissionDigest is not tied to a session, it runs with the weekly
+	// summary of uploaded orders
+	EventSubmissionDigest = "submission-digest"
+)
+
+// Events lists every supported event
+var Events = []string{EventTurnDownloaded, EventOrderUploaded, EventGameFinished, EventSubmissionDigest}
+
+// DefaultTimeout is used when a hook has no timeout configured
+const DefaultTimeout = 30 * time.Second
+
+// waitDelay bounds the wait for the output of a killed hook, which children
+// it started may keep open
+const waitDelay = 5 * time.Second
+
+// maxOutputSize bounds the captured output of a hook
+const maxOutputSize = 64 << 10
+
+// Result describes a finished hook run
+type Result struct {
+	Output   string
+	ExitCode int
+	Duration time.Duration
+	TimedOut bool
+}
+
+// IsEvent reports whether name is a supported event
+func IsEvent(name string) bool {
+	for _, e := range Events {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Run executes command with the event variables added to the environment, killing
+// it after timeout. Combined stdout and stderr are captured (truncated to 64 KiB).
+// A non-zero exit status is reported in Result, not as an error.
+func Run(command string, env map[string]string, timeout time.Duration) (*Result, error) {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, command)
+	cmd.WaitDelay = waitDelay
+	cmd.Env = os.Environ()
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cmd.Env = append(cmd.Env, k+"="+env[k])
+	}
+
+	out := &limitedBuffer{max: maxOutputSize}
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	start := time.Now()
+	err := cmd.Run()
+	result := &Result{
+		Output:   out.String(),
+		Duration: time.Since(start),
+		TimedOut: errors.Is(ctx.Err(), context.DeadlineExceeded),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	default:
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// limitedBuffer keeps the first max bytes written to it
+type limitedBuffer struct {
+	buf bytes.Buffer
+	max int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - b.buf.Len(); room > 0 {
+		if len(p) > room {
+			b.buf.Write(p[:room])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
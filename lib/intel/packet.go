@@ -0,0 +1,157 @@
+// Package intel reads and writes intel packets: signed, compressed files of
+// scanner-known planets and fleets that allied players exchange.
+//
+// A packet is a gzipped JSON envelope holding the payload, the ed25519 public
+// key of the sender and the signature of the payload. The signature proves the
+// packet was not altered after export and lets the receiver recognise senders
+// by their key fingerprint.
+package intel
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Version is the packet format version
+const Version = 1
+
+// FileExtension is the extension of intel packet files
+const FileExtension = ".astrum-intel"
+
+// maxPacketSize caps the decompressed size of a packet
+const maxPacketSize = 32 << 20
+
+// Planet is a planet as known by the sender
+type Planet struct {
+	Number      int    `json:"n"`
+	Name        string `json:"name"`
+	X           int    `json:"x"`
+	Y           int    `json:"y"`
+	Owner       int    `json:"o"` // -1 when unowned
+	Starbase    bool   `json:"sb,omitempty"`
+	Population  int64  `json:"pop,omitempty"`
+	Mines       int    `json:"mi,omitempty"`
+	Factories   int    `json:"fa,omitempty"`
+	Defenses    int    `json:"de,omitempty"`
+	Ironium     int    `json:"ic,omitempty"` // concentrations
+	Boranium    int    `json:"bc,omitempty"`
+	Germanium   int    `json:"gc,omitempty"`
+	Gravity     int    `json:"gr,omitempty"`
+	Temperature int    `json:"te,omitempty"`
+	Radiation   int    `json:"ra,omitempty"`
+}
+
+// Fleet is a fleet as known by the sender
+type Fleet struct {
+	Owner  int    `json:"o"`
+	Number int    `json:"n"`
+	Name   string `json:"name"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Ships  int    `json:"s"`
+	Warp   int    `json:"w,omitempty"`
+	DeltaX int    `json:"dx,omitempty"`
+	DeltaY int    `json:"dy,omitempty"`
+}
+
+// Payload is the shared intel
+type Payload struct {
+	GameID     uint32    `json:"gameId"`
+	Year       int       `json:"year"`
+	Sender     string    `json:"sender"` // race name of the sender
+	ExportedAt time.Time `json:"exportedAt"`
+	Planets    []Planet  `json:"planets,omitempty"`
+	Fleets     []Fleet   `json:"fleets,omitempty"`
+}
+
+// envelope is the serialized packet
+type envelope struct {
+	Version   int    `json:"v"`
+	Payload   []byte `json:"payload"`
+	PublicKey []byte `json:"key"`
+	Signature []byte `json:"sig"`
+}
+
+// GenerateKey creates a new signing key
+func GenerateKey() (ed25519.PrivateKey, error) {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate signing key: %w", err)
+	}
+	return key, nil
+}
+
+// Fingerprint returns a short identifier of a public key
+func Fingerprint(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// Encode signs a payload and returns the packet bytes
+func Encode(p *Payload, key ed25519.PrivateKey) ([]byte, error) {
+	payload, err := jsoniter.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode intel: %w", err)
+	}
+
+	env, err := jsoniter.Marshal(envelope{
+		Version:   Version,
+		Payload:   payload,
+		PublicKey: key.Public().(ed25519.PublicKey),
+		Signature: ed25519.Sign(key, payload),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode intel packet: %w", err)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(env); err != nil {
+		return nil, fmt.Errorf("failed to compress intel packet: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress intel packet: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode verifies a packet and returns its payload with the sender's key fingerprint
+func Decode(data []byte) (*Payload, string, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("not an intel packet: %w", err)
+	}
+	raw, err := io.ReadAll(io.LimitReader(zr, maxPacketSize))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decompress intel packet: %w", err)
+	}
+
+	var env envelope
+	if err := jsoniter.Unmarshal(raw, &env); err != nil {
+		return nil, "", fmt.Errorf("failed to decode intel packet: %w", err)
+	}
+	if env.Version != Version {
+		return nil, "", fmt.Errorf("unsupported intel packet version %d", env.Version)
+	}
+	if len(env.PublicKey) != ed25519.PublicKeySize {
+		return nil, "", errors.New("intel packet has an invalid sender key")
+	}
+	if !ed25519.Verify(env.PublicKey, env.Payload, env.Signature) {
+		return nil, "", errors.New("intel packet signature does not match, the file was altered")
+	}
+
+	var p Payload
+	if err := jsoniter.Unmarshal(env.Payload, &p); err != nil {
+		return nil, "", fmt.Errorf("failed to decode intel: %w", err)
+	}
+	return &p, Fingerprint(env.PublicKey), nil
+}
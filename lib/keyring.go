@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/zalando/go-keyring"
 )
@@ -13,6 +14,10 @@ const (
 	KeyringService = "astrum"
 )
 
+// ErrKeyringUnavailable is returned when the system keyring cannot be read,
+// e.g. its daemon is not running yet right after login, or it is locked
+var ErrKeyringUnavailable = errors.New("keyring unavailable")
+
 // CredentialStore handles secure storage of credentials in the OS keychain
 type CredentialStore struct {
 	service string
@@ -50,6 +55,10 @@ func (cs *CredentialStore) Set(serverURL, username, apiKey string, isDefault boo
 
 	key := cs.credentialKey(serverURL, username)
 	if err := keyring.Set(cs.service, key, string(data)); err != nil {
+		if errors.Is(err, keyring.ErrSetDataTooBig) {
+			// The macOS keychain limits the size of the stored data
+			return fmt.Errorf("credential is too large for the system keychain: %w", err)
+		}
 		return fmt.Errorf("failed to store credential in keyring: %w", err)
 	}
 
@@ -64,7 +73,7 @@ func (cs *CredentialStore) Get(serverURL, username string) (*StoredCredential, e
 		if errors.Is(err, keyring.ErrNotFound) {
 			return nil, nil // Credential not found
 		}
-		return nil, fmt.Errorf("failed to get credential from keyring: %w", err)
+		return nil, fmt.Errorf("failed to get credential from keyring: %w: %w", ErrKeyringUnavailable, err)
 	}
 
 	var cred StoredCredential
@@ -109,3 +118,136 @@ func (cs *CredentialStore) GetAPIKey(serverURL, username string) (string, error)
 	}
 	return cred.APIKey, nil
 }
+
+// serialKey generates the keyring key holding the Stars! serial of a server
+func (cs *CredentialStore) serialKey(serverURL string) string {
+	return fmt.Sprintf("%s#serial", serverURL)
+}
+
+// SetSerialKey stores the Stars! serial key assigned by a server
+func (cs *CredentialStore) SetSerialKey(serverURL, serial string) error {
+	if err := keyring.Set(cs.service, cs.serialKey(serverURL), serial); err != nil {
+		return fmt.Errorf("failed to store serial key in keyring: %w", err)
+	}
+	return nil
+}
+
+// GetSerialKey retrieves the Stars! serial key of a server, or "" if none is stored
+func (cs *CredentialStore) GetSerialKey(serverURL string) (string, error) {
+	serial, err := keyring.Get(cs.service, cs.serialKey(serverURL))
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get serial key from keyring: %w", err)
+	}
+	return serial, nil
+}
+
+// localAPITokenKey is the keyring key holding the local HTTP API token
+const localAPITokenKey = "local-api#token"
+
+// SetLocalAPIToken stores the token protecting the local HTTP API
+func (cs *CredentialStore) SetLocalAPIToken(token string) error {
+	if err := keyring.Set(cs.service, localAPITokenKey, token); err != nil {
+		return fmt.Errorf("failed to store local API token in keyring: %w", err)
+	}
+	return nil
+}
+
+// GetLocalAPIToken retrieves the local HTTP API token, or "" if none is stored
+func (cs *CredentialStore) GetLocalAPIToken() (string, error) {
+	token, err := keyring.Get(cs.service, localAPITokenKey)
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get local API token from keyring: %w", err)
+	}
+	return token, nil
+}
+
+// DeleteLocalAPIToken removes the local HTTP API token
+func (cs *CredentialStore) DeleteLocalAPIToken() error {
+	if err := keyring.Delete(cs.service, localAPITokenKey); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("failed to delete local API token from keyring: %w", err)
+	}
+	return nil
+}
+
+// intelSigningKeyKey is the keyring key holding the intel packet signing key
+const intelSigningKeyKey = "intel#signing-key"
+
+// SetIntelSigningKey stores the key (hex encoded) signing exported intel packets
+func (cs *CredentialStore) SetIntelSigningKey(key string) error {
+	if err := keyring.Set(cs.service, intelSigningKeyKey, key); err != nil {
+		return fmt.Errorf("failed to store intel signing key in keyring: %w", err)
+	}
+	return nil
+}
+
+// GetIntelSigningKey retrieves the intel signing key, or "" if none is stored
+func (cs *CredentialStore) GetIntelSigningKey() (string, error) {
+	key, err := keyring.Get(cs.service, intelSigningKeyKey)
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get intel signing key from keyring: %w", err)
+	}
+	return key, nil
+}
+
+// DeleteIntelSigningKey removes the intel signing key, a new one is made on the next export
+func (cs *CredentialStore) DeleteIntelSigningKey() error {
+	if err := keyring.Delete(cs.service, intelSigningKeyKey); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("failed to delete intel signing key from keyring: %w", err)
+	}
+	return nil
+}
+
+// selfTestKeyringKey is the keyring key written and removed by Check
+const selfTestKeyringKey = "selftest#probe"
+
+// Check stores, reads back and removes a value, to check that the system
+// keyring is usable
+func (cs *CredentialStore) Check() error {
+	probe := fmt.Sprintf("probe-%d", time.Now().UnixNano())
+	if err := keyring.Set(cs.service, selfTestKeyringKey, probe); err != nil {
+		return fmt.Errorf("failed to store in keyring: %w", err)
+	}
+	value, err := keyring.Get(cs.service, selfTestKeyringKey)
+	if err != nil {
+		return fmt.Errorf("failed to read from keyring: %w", err)
+	}
+	if value != probe {
+		return fmt.Errorf("keyring returned a different value than stored")
+	}
+	if err := keyring.Delete(cs.service, selfTestKeyringKey); err != nil {
+		return fmt.Errorf("failed to delete from keyring: %w", err)
+	}
+	return nil
+}
+
+// availabilityProbeKey is the keyring key read by Available, never written
+const availabilityProbeKey = "availability#probe"
+
+// Available tells whether the keyring can be read, without writing to it:
+// reading a missing key succeeds as soon as the keyring answers
+func (cs *CredentialStore) Available() error {
+	if _, err := keyring.Get(cs.service, availabilityProbeKey); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("%w: %w", ErrKeyringUnavailable, err)
+	}
+	return nil
+}
+
+// DeleteSerialKey removes the Stars! serial key of a server
+func (cs *CredentialStore) DeleteSerialKey(serverURL string) error {
+	if err := keyring.Delete(cs.service, cs.serialKey(serverURL)); err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return nil // Already deleted
+		}
+		return fmt.Errorf("failed to delete serial key from keyring: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package lib
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// =============================================================================
+// GUEST MODE PIN
+// =============================================================================
+
+// ErrKioskPinInvalid is returned for PINs that are not 4 to 8 digits
+var ErrKioskPinInvalid = fmt.Errorf("PIN must be 4 to 8 digits")
+
+// ValidateKioskPin checks that a guest mode PIN is 4 to 8 digits
+func ValidateKioskPin(pin string) error {
+	if len(pin) < 4 || len(pin) > 8 {
+		return ErrKioskPinInvalid
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return ErrKioskPinInvalid
+		}
+	}
+	return nil
+}
+
+// HashKioskPin returns the salted hash of a PIN, as "salt:hash" in hex
+func HashKioskPin(pin string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return hex.EncodeToString(salt) + ":" + hex.EncodeToString(kioskPinDigest(salt, pin)), nil
+}
+
+// CheckKioskPin tells whether a PIN matches a hash made by HashKioskPin
+func CheckKioskPin(hash, pin string) bool {
+	saltHex, digestHex, ok := strings.Cut(hash, ":")
+	if !ok {
+		return false
+	}
+	salt, err := hex.DecodeString(saltHex)
+	if err != nil {
+		return false
+	}
+	digest, err := hex.DecodeString(digestHex)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(digest, kioskPinDigest(salt, pin)) == 1
+}
+
+// kioskPinDigest hashes a salted PIN
+func kioskPinDigest(salt []byte, pin string) []byte {
+	sum := sha256.Sum256(append(append([]byte{}, salt...), pin...))
+	return sum[:]
+}
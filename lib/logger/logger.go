@@ -1,7 +1,12 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -23,49 +28,279 @@ var (
 	Monitor      *zerolog.Logger
 )
 
+// baseComponent is the level name of the base logger, which has no component field
+const baseComponent = "main"
+
+// levels holds the minimum level of each component, changed at runtime by SetLevel
+var levels = map[string]*atomic.Int32{}
+
+// logFile receives a copy of every log line once SetFile was called
+var logFile fileOutput
+
+// Log line formats, see SetOutput
+const (
+	FormatConsole = "console" // human-readable
+	FormatJSON    = "json"    // one JSON object per line, for log pipelines
+)
+
+// output is where every logger writes, changed by SetOutput
+var output struct {
+	mu     sync.RWMutex
+	writer zerolog.LevelWriter
+	syslog io.Closer // nil when not logging to syslog
+}
+
 // Init initializes all loggers with console output
 func Init(debug bool) {
-	// Configure console writer for human-readable output
-	output := zerolog.ConsoleWriter{
-		Out:        os.Stderr,
-		TimeFormat: time.RFC3339,
-	}
+	output.mu.Lock()
+	output.writer = newOutput(FormatConsole, nil)
+	output.mu.Unlock()
 
 	level := zerolog.InfoLevel
 	if debug {
 		level = zerolog.DebugLevel
 	}
 
+	// Levels are filtered per component, the loggers let everything through
+	newLogger := func(component string) zerolog.Logger {
+		l := &atomic.Int32{}
+		l.Store(int32(level))
+		levels[component] = l
+
+		ctx := zerolog.New(componentWriter{level: l}).With().Timestamp()
+		if component != baseComponent {
+			ctx = ctx.Str("component", component)
+		}
+		return ctx.Logger()
+	}
+
 	// Create base logger
-	baseLogger := zerolog.New(output).
-		Level(level).
-		With().
-		Timestamp().
-		Logger()
+	baseLogger := newLogger(baseComponent)
 	Logger = &baseLogger
 
 	// Create component-specific loggers (once, at startup)
-	apiLogger := baseLogger.With().Str("component", "api").Logger()
+	apiLogger := newLogger("api")
 	API = &apiLogger
 
-	wsLogger := baseLogger.With().Str("component", "websocket").Logger()
+	wsLogger := newLogger("websocket")
 	WebSocket = &wsLogger
 
-	appLogger := baseLogger.With().Str("component", "app").Logger()
+	appLogger := newLogger("app")
 	App = &appLogger
 
-	dbLogger := baseLogger.With().Str("component", "db").Logger()
+	dbLogger := newLogger("db")
 	DB = &dbLogger
 
-	authLogger := baseLogger.With().Str("component", "auth").Logger()
+	authLogger := newLogger("auth")
 	Auth = &authLogger
 
-	configLogger := baseLogger.With().Str("component", "config").Logger()
+	configLogger := newLogger("config")
 	Config = &configLogger
 
-	notifLogger := baseLogger.With().Str("component", "notification").Logger()
+	notifLogger := newLogger("notification")
 	Notification = &notifLogger
 
-	monitorLogger := baseLogger.With().Str("component", "monitor").Logger()
+	monitorLogger := newLogger("monitor")
 	Monitor = &monitorLogger
 }
+
+// Components returns the names of the components whose level can be set,
+// sorted
+func Components() []string {
+	names := make([]string, 0, len(levels))
+	for name := range levels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Level returns the current minimum level of a component
+func Level(component string) (zerolog.Level, error) {
+	l, ok := levels[component]
+	if !ok {
+		return zerolog.NoLevel, fmt.Errorf("unknown log component: %s", component)
+	}
+	return zerolog.Level(l.Load()), nil
+}
+
+// SetLevel changes the minimum level of a component while the app runs,
+// "all" changing every component
+func SetLevel(component string, level zerolog.Level) error {
+	if component == "all" {
+		for _, l := range levels {
+			l.Store(int32(level))
+		}
+		return nil
+	}
+	l, ok := levels[component]
+	if !ok {
+		return fmt.Errorf("unknown log component: %s", component)
+	}
+	l.Store(int32(level))
+	return nil
+}
+
+// SetOutput switches the log lines between the console and JSON formats, and
+// copies them to the system log (journald on systemd) when useSyslog is set.
+// The rotating log file follows the format too.
+func SetOutput(format string, useSyslog bool) error {
+	if format != FormatConsole && format != FormatJSON {
+		return fmt.Errorf("unknown log format: %s", format)
+	}
+
+	var sys syslogWriter
+	if useSyslog {
+		var err error
+		if sys, err = newSyslogWriter(); err != nil {
+			return fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+	}
+
+	output.mu.Lock()
+	previous := output.syslog
+	output.writer = newOutput(format, sys)
+	output.syslog = sys
+	output.mu.Unlock()
+
+	if previous != nil {
+		_ = previous.Close()
+	}
+	return nil
+}
+
+// newOutput builds the writer of the log lines: the console, the log file and
+// the system log when sys is not nil
+func newOutput(format string, sys syslogWriter) zerolog.LevelWriter {
+	var writers []io.Writer
+	if format == FormatJSON {
+		writers = append(writers, os.Stderr, &logFile)
+	} else {
+		// Console writers for human-readable output
+		writers = append(writers,
+			zerolog.ConsoleWriter{
+				Out:        os.Stderr,
+				TimeFormat: time.RFC3339,
+			},
+			zerolog.ConsoleWriter{
+				Out:        &logFile,
+				TimeFormat: time.RFC3339,
+				NoColor:    true,
+			},
+		)
+	}
+	if sys != nil {
+		writers = append(writers, sys)
+	}
+	return zerolog.MultiLevelWriter(writers...)
+}
+
+// componentWriter drops the events below the level of its component
+type componentWriter struct {
+	level *atomic.Int32
+}
+
+func (w componentWriter) Write(p []byte) (int, error) {
+	output.mu.RLock()
+	defer output.mu.RUnlock()
+	return output.writer.Write(p)
+}
+
+func (w componentWriter) WriteLevel(level zerolog.Level, p []byte) (int, error) {
+	if level < zerolog.Level(w.level.Load()) {
+		return len(p), nil
+	}
+	output.mu.RLock()
+	defer output.mu.RUnlock()
+	return output.writer.WriteLevel(level, p)
+}
+
+// =============================================================================
+// LOG FILE
+// =============================================================================
+
+// Log file rotation: the file is rotated past maxFileSize, keeping maxFileBackups
+// older files as <path>.1 (newest) to <path>.N
+const (
+	maxFileSize    = 10 << 20
+	maxFileBackups = 3
+)
+
+// SetFile starts copying the log to a rotating file at path, "" stopping it
+func SetFile(path string) error {
+	return logFile.open(path)
+}
+
+// File returns the path of the log file, "" when the log goes to the console only
+func File() string {
+	logFile.mu.Lock()
+	defer logFile.mu.Unlock()
+	return logFile.path
+}
+
+// fileOutput is a size-rotated log file, discarding writes while none is open
+type fileOutput struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	size int64
+}
+
+func (f *fileOutput) open(path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file != nil {
+		_ = f.file.Close()
+		f.file, f.path, f.size = nil, "", 0
+	}
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	f.file, f.path, f.size = file, path, info.Size()
+	return nil
+}
+
+func (f *fileOutput) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return len(p), nil
+	}
+	if f.size+int64(len(p)) > maxFileSize {
+		if f.rotate(); f.file == nil {
+			return len(p), nil
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate moves the current file to the backups and starts a new one
+func (f *fileOutput) rotate() {
+	_ = f.file.Close()
+	for i := maxFileBackups - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+	}
+	_ = os.Rename(f.path, f.path+".1")
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to rotate log file, file logging stopped: %v\n", err)
+		f.file, f.path = nil, ""
+		return
+	}
+	f.file, f.size = file, 0
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package logger
+
+import (
+	"log/syslog"
+
+	"github.com/rs/zerolog"
+)
+
+// syslogWriter is a level writer to the system log
+type syslogWriter interface {
+	zerolog.LevelWriter
+	Close() error
+}
+
+// syslogOutput writes to the system log with the level of each line
+type syslogOutput struct {
+	zerolog.LevelWriter
+	w *syslog.Writer
+}
+
+func (s syslogOutput) Close() error {
+	return s.w.Close()
+}
+
+// newSyslogWriter connects to the local system log, which journald collects
+// on systemd
+func newSyslogWriter() (syslogWriter, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, "astrum")
+	if err != nil {
+		return nil, err
+	}
+	return syslogOutput{LevelWriter: zerolog.SyslogLevelWriter(w), w: w}, nil
+}
@@ -0,0 +1,18 @@
+package logger
+
+import (
+	"errors"
+
+	"github.com/rs/zerolog"
+)
+
+// syslogWriter is a level writer to the system log
+type syslogWriter interface {
+	zerolog.LevelWriter
+	Close() error
+}
+
+// newSyslogWriter fails: Windows has no syslog
+func newSyslogWriter() (syslogWriter, error) {
+	return nil, errors.New("syslog is not available on Windows")
+}
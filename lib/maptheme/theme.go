@@ -0,0 +1,163 @@
+// Package maptheme recolors SVG maps produced by the houston map renderer.
+//
+// The renderer has fixed colors (black background, the classic Stars! empire
+// palette), so themes are applied by rewriting those colors in its SVG output.
+package maptheme
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Theme is a set of map colors, given as "#rrggbb"
+type Theme struct {
+	Background string   // map background
+	Foreground string   // starbase rings
+	Year       string   // year caption
+	Empire     []string // color of each player, by player number
+}
+
+// classicEmpire is the empire palette of the houston renderer
+var classicEmpire = []color.RGBA{
+	{255, 3, 3, 255},
+	{0, 66, 255, 255},
+	{28, 230, 185, 255},
+	{84, 0, 129, 255},
+	{255, 252, 1, 255},
+	{254, 138, 14, 255},
+	{32, 192, 0, 255},
+	{229, 91, 176, 255},
+	{149, 150, 151, 255},
+	{126, 191, 241, 255},
+	{16, 98, 70, 255},
+	{78, 42, 4, 255},
+	{255, 255, 255, 255},
+	{187, 115, 20, 255},
+	{200, 100, 100, 255},
+	{100, 100, 200, 255},
+}
+
+// classicYear is the year caption color of the houston renderer
+var classicYear = color.RGBA{0, 128, 255, 255}
+
+// Builtin themes
+var Builtin = map[string]Theme{
+	"classic": {
+		Background: "#000000",
+		Foreground: "#ffffff",
+		Year:       "#0080ff",
+	},
+	"dark": {
+		Background: "#1e1e2e",
+		Foreground: "#cdd6f4",
+		Year:       "#89b4fa",
+	},
+	"light": {
+		Background: "#ffffff",
+		Foreground: "#333333",
+		Year:       "#1a5fb4",
+		Empire: []string{
+			"#d40000", "#0035cc", "#12917a", "#540081", "#a89400", "#d46a00", "#1f7a00", "#b8327f",
+			"#5e5e5e", "#2f7fbf", "#10624a", "#4e2a04", "#222222", "#9a5e0d", "#a33c3c", "#3c3ca3",
+		},
+	},
+	// Okabe-Ito and Paul Tol colors, distinguishable with the common color vision deficiencies
+	"colorblind": {
+		Background: "#000000",
+		Foreground: "#ffffff",
+		Year:       "#56b4e9",
+		Empire: []string{
+			"#e69f00", "#56b4e9", "#009e73", "#f0e442", "#0072b2", "#d55e00", "#cc79a7", "#ffffff",
+			"#999999", "#882255", "#44aa99", "#ddcc77", "#117733", "#aa4499", "#88ccee", "#6666cc",
+		},
+	},
+}
+
+// Players is the number of empire colors of the renderer
+const Players = 16
+
+// EmpireColor returns the color of a player in the theme, falling back to the
+// classic palette
+func (t Theme) EmpireColor(player int) string {
+	if player >= 0 && player < len(t.Empire) && t.Empire[player] != "" {
+		return t.Empire[player]
+	}
+	if player >= 0 && player < len(classicEmpire) {
+		c := classicEmpire[player]
+		return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+	}
+	return ""
+}
+
+// Apply rewrites the colors of a rendered SVG map. Empty theme fields keep the
+// renderer's colors.
+func Apply(svg string, t Theme) (string, error) {
+	var pairs []string
+
+	if t.Background != "" {
+		bg, err := parseHex(t.Background)
+		if err != nil {
+			return "", err
+		}
+		pairs = append(pairs, `fill="black"`, fmt.Sprintf(`fill="%s"`, rgb(bg)))
+	}
+	if t.Foreground != "" {
+		fg, err := parseHex(t.Foreground)
+		if err != nil {
+			return "", err
+		}
+		pairs = append(pairs, `stroke="white"`, fmt.Sprintf(`stroke="%s"`, rgb(fg)))
+	}
+	if t.Year != "" {
+		year, err := parseHex(t.Year)
+		if err != nil {
+			return "", err
+		}
+		pairs = append(pairs, rgb(classicYear), rgb(year))
+	}
+	for i, hex := range t.Empire {
+		if i >= len(classicEmpire) {
+			break
+		}
+		if hex == "" {
+			continue
+		}
+		c, err := parseHex(hex)
+		if err != nil {
+			return "", err
+		}
+		from := classicEmpire[i]
+		pairs = append(pairs,
+			rgb(from), rgb(c),
+			fmt.Sprintf("rgba(%d,%d,%d,", from.R, from.G, from.B), fmt.Sprintf("rgba(%d,%d,%d,", c.R, c.G, c.B),
+		)
+	}
+
+	// A single replacer pass so a replaced color is never replaced again
+	return strings.NewReplacer(pairs...).Replace(svg), nil
+}
+
+// rgb formats a color the way the renderer does
+func rgb(c color.RGBA) string {
+	return fmt.Sprintf("rgb(%d,%d,%d)", c.R, c.G, c.B)
+}
+
+// Validate checks that a color is given as "#rrggbb"
+func Validate(s string) error {
+	_, err := parseHex(s)
+	return err
+}
+
+// parseHex parses a "#rrggbb" color
+func parseHex(s string) (color.RGBA, error) {
+	var c color.RGBA
+	if len(s) != 7 || s[0] != '#' {
+		return c, fmt.Errorf("invalid color %q: expected #rrggbb", s)
+	}
+	if _, err := fmt.Sscanf(s[1:], "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("invalid color %q: %w", s, err)
+	}
+	c.A = 255
+	return c, nil
+}
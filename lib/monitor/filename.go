@@ -0,0 +1,29 @@
+package monitor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ResolveFile returns the path of the file named name in dir, ignoring case.
+// Stars! sometimes writes GAME.X1 or GAME.M1, which a case-sensitive
+// filesystem would not find as game.x1. An exact match wins; without any
+// match the exact path is returned so callers report it as missing.
+func ResolveFile(dir, name string) string {
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return path
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(entry.Name(), name) {
+			return filepath.Join(dir, entry.Name())
+		}
+	}
+	return path
+}
@@ -3,6 +3,7 @@ package monitor
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/neper-stars/astrum/lib/logger"
 )
@@ -16,7 +17,9 @@ type Manager struct {
 	submitHandler SubmitHandler
 
 	// Callbacks for events
-	onOrderSubmitted func(sessionID string, year int, success bool, err error)
+	onOrderSubmitted   func(sessionID string, year int, success bool, err error)
+	onOrderQuarantined func(sessionID, quarantinePath string, err error)
+	onTurnFileRemoved  func(sessionID, filePath string)
 }
 
 // NewManager creates a new monitoring manager
@@ -35,6 +38,21 @@ func (m *Manager) SetOnOrderSubmitted(fn func(sessionID string, year int, succes
 	m.onOrderSubmitted = fn
 }
 
+// SetOnOrderQuarantined sets the callback for when an invalid order file is quarantined
+func (m *Manager) SetOnOrderQuarantined(fn func(sessionID, quarantinePath string, err error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onOrderQuarantined = fn
+}
+
+// SetOnTurnFileRemoved sets the callback for when the universe or turn file of
+// a watched game directory is removed
+func (m *Manager) SetOnTurnFileRemoved(fn func(sessionID, filePath string)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onTurnFileRemoved = fn
+}
+
 // Watch starts monitoring a session's game directory
 func (m *Manager) Watch(session WatchedSession) error {
 	m.mu.Lock()
@@ -68,6 +86,25 @@ func (m *Manager) Watch(session WatchedSession) error {
 	if err != nil {
 		return fmt.Errorf("failed to create watcher for session %s: %w", session.SessionID, err)
 	}
+	watcher.SetQuarantineHandler(func(_, sessionID, quarantinePath string, err error) {
+		m.mu.RLock()
+		callback := m.onOrderQuarantined
+		m.mu.RUnlock()
+
+		if callback != nil {
+			callback(sessionID, quarantinePath, err)
+		}
+	})
+
+	watcher.SetTurnFileRemovedHandler(func(_, sessionID, filePath string) {
+		m.mu.RLock()
+		callback := m.onTurnFileRemoved
+		m.mu.RUnlock()
+
+		if callback != nil {
+			callback(sessionID, filePath)
+		}
+	})
 
 	// Start watching
 	if err := watcher.Start(); err != nil {
@@ -84,6 +121,20 @@ func (m *Manager) Watch(session WatchedSession) error {
 	return nil
 }
 
+// ProcessNow uploads the order file of a watched session right away when it
+// changed since the given time, see SessionWatcher.ProcessNow. Returns whether
+// there was an order file to process.
+func (m *Manager) ProcessNow(sessionID string, since time.Time) bool {
+	m.mu.RLock()
+	watcher, exists := m.watchers[sessionID]
+	m.mu.RUnlock()
+
+	if !exists {
+		return false
+	}
+	return watcher.ProcessNow(since)
+}
+
 // Unwatch stops monitoring a specific session
 func (m *Manager) Unwatch(sessionID string) {
 	m.mu.Lock()
@@ -114,6 +165,35 @@ func (m *Manager) Stop() {
 	logger.Monitor.Info().Msg("Stopped all monitors")
 }
 
+// StopAll stops all watchers and returns the sessions they watched, so they
+// can be watched again later (e.g. once their directories moved)
+func (m *Manager) StopAll() []WatchedSession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sessions := make([]WatchedSession, 0, len(m.watchers))
+	for _, watcher := range m.watchers {
+		watcher.Stop()
+		sessions = append(sessions, watcher.session)
+	}
+	m.watchers = make(map[string]*SessionWatcher)
+
+	logger.Monitor.Info().Int("sessions", len(sessions)).Msg("Stopped all monitors")
+	return sessions
+}
+
+// Session returns the watched session with the given ID
+func (m *Manager) Session(sessionID string) (WatchedSession, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	watcher, ok := m.watchers[sessionID]
+	if !ok {
+		return WatchedSession{}, false
+	}
+	return watcher.session, true
+}
+
 // WatchedSessions returns a list of currently watched session IDs
 func (m *Manager) WatchedSessions() []string {
 	m.mu.RLock()
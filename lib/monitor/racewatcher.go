@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// raceFilePattern matches the race files saved by the Stars! race wizard
+var raceFilePattern = regexp.MustCompile(`(?i)\.r([1-9]|1[0-6])$`)
+
+// IsRaceFile returns whether a file name is a Stars! race file name
+func IsRaceFile(name string) bool {
+	return raceFilePattern.MatchString(name)
+}
+
+// RaceFileHandler is called when a race file was created or changed in a
+// watched directory, once Stars! is done writing it
+type RaceFileHandler func(dir, filePath string)
+
+// RaceWatcher watches directories for race files written by Stars!
+type RaceWatcher struct {
+	watcher *fsnotify.Watcher
+	handler RaceFileHandler
+
+	mu             sync.Mutex
+	dirs           map[string]bool
+	debounceTimers map[string]*time.Timer // file path -> pending handler call
+	stopCh         chan struct{}
+	stopped        bool
+}
+
+// NewRaceWatcher creates a race watcher and starts its event loop
+func NewRaceWatcher(handler RaceFileHandler) (*RaceWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fsnotify watcher: %w", err)
+	}
+
+	w := &RaceWatcher{
+		watcher:        watcher,
+		handler:        handler,
+		dirs:           make(map[string]bool),
+		debounceTimers: make(map[string]*time.Timer),
+		stopCh:         make(chan struct{}),
+	}
+	go w.eventLoop()
+	return w, nil
+}
+
+// Add starts watching a directory, doing nothing if it is already watched
+func (w *RaceWatcher) Add(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped || w.dirs[dir] {
+		return nil
+	}
+	if err := w.watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch directory %s: %w", dir, err)
+	}
+	w.dirs[dir] = true
+
+	logger.Monitor.Debug().Str("dir", dir).Msg("Watching directory for race files")
+	return nil
+}
+
+// Remove stops watching a directory
+func (w *RaceWatcher) Remove(dir string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.stopped || !w.dirs[dir] {
+		return
+	}
+	_ = w.watcher.Remove(dir)
+	delete(w.dirs, dir)
+}
+
+// Stop stops watching every directory
+func (w *RaceWatcher) Stop() {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return
+	}
+	w.stopped = true
+	for _, timer := range w.debounceTimers {
+		timer.Stop()
+	}
+	w.mu.Unlock()
+
+	close(w.stopCh)
+	_ = w.watcher.Close()
+}
+
+// eventLoop processes fsnotify events
+func (w *RaceWatcher) eventLoop() {
+	for {
+		select {
+		case <-w.stopCh:
+			return
+
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Monitor.Error().Err(err).Msg("Race watcher error")
+		}
+	}
+}
+
+// handleEvent debounces the writes of a race file, Stars! writes it several times
+func (w *RaceWatcher) handleEvent(event fsnotify.Event) {
+	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+		return
+	}
+	if !IsRaceFile(event.Name) {
+		return
+	}
+
+	filePath := event.Name
+	dir := filepath.Dir(filePath)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return
+	}
+	if timer, ok := w.debounceTimers[filePath]; ok {
+		timer.Stop()
+	}
+	w.debounceTimers[filePath] = time.AfterFunc(500*time.Millisecond, func() {
+		w.mu.Lock()
+		delete(w.debounceTimers, filePath)
+		stopped := w.stopped
+		w.mu.Unlock()
+		if !stopped {
+			w.handler(dir, filePath)
+		}
+	})
+}
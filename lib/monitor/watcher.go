@@ -1,17 +1,44 @@
 package monitor
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 
 	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/neper-stars/astrum/lib/platform"
+	"github.com/neper-stars/astrum/lib/safefile"
 )
 
+const (
+	// DefaultDebounce is how long the order file must go unmodified before it
+	// is processed, Stars! writes it several times during save
+	DefaultDebounce = 500 * time.Millisecond
+	// maxDebounceDelay caps the debounce delay grown by invalid order files
+	maxDebounceDelay = 30 * time.Second
+	// quarantineAfter is the number of invalid reads in a row after which a
+	// copy of the order file is quarantined
+	quarantineAfter = 3
+
+	// maxSettleChecks bounds the write-settle checks of an order file, a file
+	// still changing after them is processed anyway
+	maxSettleChecks = 10
+
+	// QuarantineDirName is the folder of the game directory receiving the
+	// copies of invalid order files
+	QuarantineDirName = "diagnostics"
+)
+
+// ErrInvalidOrderFile is wrapped by order file handlers when an order file
+// cannot be parsed, e.g. because it is truncated or still being written
+var ErrInvalidOrderFile = errors.New("invalid order file")
+
 // WatchedSession holds the state needed to monitor a session's game directory
 type WatchedSession struct {
 	ServerURL   string
@@ -19,6 +46,14 @@ type WatchedSession struct {
 	SessionID   string
 	PlayerOrder int // 0-indexed (file will be game.x{PlayerOrder+1})
 	GameDir     string
+
+	Debounce time.Duration // quiet time after the last write event, 0 means DefaultDebounce
+	Settle   time.Duration // time size and mtime must stay the same before reading, 0 disables the check
+
+	// ExtraDirs are other directories the player saves orders to (e.g. a
+	// synced folder). Order files found there are copied to GameDir, where
+	// they are picked up like orders saved by Stars! in the game directory.
+	ExtraDirs []string
 }
 
 // OrderFileHandler is called when a valid order file is detected
@@ -28,16 +63,27 @@ type OrderFileHandler func(filePath string) (year int, data []byte, err error)
 // SubmitHandler is called to submit the order to the server
 type SubmitHandler func(serverURL, sessionID string, year int, data []byte) error
 
+// QuarantineHandler is called when an order file kept failing validation and
+// a copy of it was quarantined to quarantinePath
+type QuarantineHandler func(serverURL, sessionID, quarantinePath string, err error)
+
+// TurnFileRemovedHandler is called when the universe or turn file of the game
+// directory was deleted or moved away
+type TurnFileRemovedHandler func(serverURL, sessionID, filePath string)
+
 // SessionWatcher monitors a single session's game directory for order files
 type SessionWatcher struct {
 	session WatchedSession
 	watcher *fsnotify.Watcher
 
-	orderHandler  OrderFileHandler
-	submitHandler SubmitHandler
+	orderHandler      OrderFileHandler
+	submitHandler     SubmitHandler
+	quarantineHandler QuarantineHandler
+	removedHandler    TurnFileRemovedHandler
 
 	mu            sync.Mutex
 	debounceTimer *time.Timer
+	invalidReads  int // invalid reads of the order file in a row
 	stopCh        chan struct{}
 	stopped       bool
 }
@@ -58,6 +104,20 @@ func NewSessionWatcher(session WatchedSession, orderHandler OrderFileHandler, su
 	}, nil
 }
 
+// SetQuarantineHandler sets the handler called when an order file is quarantined
+func (w *SessionWatcher) SetQuarantineHandler(handler QuarantineHandler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.quarantineHandler = handler
+}
+
+// SetTurnFileRemovedHandler sets the handler called when a turn file is removed
+func (w *SessionWatcher) SetTurnFileRemovedHandler(handler TurnFileRemovedHandler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removedHandler = handler
+}
+
 // Start begins watching the game directory
 func (w *SessionWatcher) Start() error {
 	// Ensure directory exists
@@ -77,9 +137,29 @@ func (w *SessionWatcher) Start() error {
 		return fmt.Errorf("failed to watch directory %s: %w", w.session.GameDir, err)
 	}
 
+	// Extra directories may be on removable or synced drives, a missing one
+	// must not prevent watching the game directory
+	for _, dir := range w.session.ExtraDirs {
+		if err := w.watcher.Add(dir); err != nil {
+			logger.Monitor.Warn().
+				Err(err).
+				Str("sessionID", w.session.SessionID).
+				Str("dir", dir).
+				Msg("Failed to watch extra order directory")
+			continue
+		}
+		// Orders saved there while not watching are imported now
+		if orderPath := ResolveFile(dir, w.expectedOrderFile()); fileExists(orderPath) {
+			w.mu.Lock()
+			w.scheduleLocked(orderPath)
+			w.mu.Unlock()
+		}
+	}
+
 	logger.Monitor.Info().
 		Str("sessionID", w.session.SessionID).
 		Str("gameDir", w.session.GameDir).
+		Strs("extraDirs", w.session.ExtraDirs).
 		Int("playerOrder", w.session.PlayerOrder).
 		Str("expectedFile", w.expectedOrderFile()).
 		Msg("Started watching session directory")
@@ -90,6 +170,12 @@ func (w *SessionWatcher) Start() error {
 	return nil
 }
 
+// fileExists returns whether path is an existing regular file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular()
+}
+
 // Stop stops watching the directory
 func (w *SessionWatcher) Stop() {
 	w.mu.Lock()
@@ -143,16 +229,40 @@ func (w *SessionWatcher) eventLoop() {
 	}
 }
 
+// isTurnFile returns whether path is the universe or turn file of the game directory
+func (w *SessionWatcher) isTurnFile(path string) bool {
+	if filepath.Dir(path) != filepath.Clean(w.session.GameDir) {
+		return false
+	}
+	name := filepath.Base(path)
+	return strings.EqualFold(name, "game.xy") || strings.EqualFold(name, fmt.Sprintf("game.m%d", w.session.PlayerOrder+1))
+}
+
 // handleEvent processes a single fsnotify event
 func (w *SessionWatcher) handleEvent(event fsnotify.Event) {
+	// Turn files deleted by the user or an antivirus
+	if (event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename)) && w.isTurnFile(event.Name) {
+		w.mu.Lock()
+		handler := w.removedHandler
+		w.mu.Unlock()
+		if handler != nil {
+			logger.Monitor.Warn().
+				Str("file", event.Name).
+				Str("sessionID", w.session.SessionID).
+				Msg("Turn file removed")
+			go handler(w.session.ServerURL, w.session.SessionID, event.Name)
+		}
+		return
+	}
+
 	// Only care about write and create events
 	if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
 		return
 	}
 
-	// Check if this is the order file we're looking for
+	// Check if this is the order file we're looking for, Stars! may write it upper case
 	fileName := filepath.Base(event.Name)
-	if fileName != w.expectedOrderFile() {
+	if !strings.EqualFold(fileName, w.expectedOrderFile()) {
 		return
 	}
 
@@ -163,13 +273,29 @@ func (w *SessionWatcher) handleEvent(event fsnotify.Event) {
 
 	// Debounce: Stars! writes multiple times during save
 	w.mu.Lock()
+	w.scheduleLocked(event.Name)
+	w.mu.Unlock()
+}
+
+// scheduleLocked (re)arms the debounce timer processing the order file. The
+// delay doubles with every invalid read in a row, a file written slowly (e.g.
+// to a network share) gets more time to be complete. Must hold w.mu.
+func (w *SessionWatcher) scheduleLocked(filePath string) {
+	if w.stopped {
+		return
+	}
 	if w.debounceTimer != nil {
 		w.debounceTimer.Stop()
 	}
-	w.debounceTimer = time.AfterFunc(500*time.Millisecond, func() {
-		w.processOrderFile(event.Name)
+	debounce := w.session.Debounce
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	delay := debounce << min(w.invalidReads, 6)
+	delay = min(delay, maxDebounceDelay)
+	w.debounceTimer = time.AfterFunc(delay, func() {
+		w.processOrderFile(filePath)
 	})
-	w.mu.Unlock()
 }
 
 // processOrderFile validates and submits an order file
@@ -186,8 +312,72 @@ func (w *SessionWatcher) processOrderFile(filePath string) {
 		Str("sessionID", w.session.SessionID).
 		Msg("Processing order file")
 
+	if !w.waitSettled(filePath) {
+		return
+	}
+
+	if filepath.Dir(filePath) != filepath.Clean(w.session.GameDir) {
+		w.importOrderFile(filePath)
+		return
+	}
+	w.submitOrderFile(filePath)
+}
+
+// ProcessNow uploads the order file of the game directory right away when it
+// changed since the given time, skipping the debounce and the settle wait.
+// Meant for when Stars! exited: the file is complete. Returns whether there
+// was an order file to process.
+func (w *SessionWatcher) ProcessNow(since time.Time) bool {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return false
+	}
+	if w.debounceTimer != nil {
+		w.debounceTimer.Stop()
+	}
+	w.mu.Unlock()
+
+	filePath := ResolveFile(w.session.GameDir, w.expectedOrderFile())
+	info, err := os.Stat(filePath)
+	if err != nil || info.ModTime().Before(since) {
+		return false
+	}
+
+	logger.Monitor.Debug().
+		Str("file", filePath).
+		Str("sessionID", w.session.SessionID).
+		Msg("Processing order file now")
+	w.submitOrderFile(filePath)
+	return true
+}
+
+// submitOrderFile validates and submits an order file of the game directory
+func (w *SessionWatcher) submitOrderFile(filePath string) {
 	// Call handler to validate and get order data
-	year, data, err := w.orderHandler(filePath)
+	var year int
+	var data []byte
+	err := safefile.Retry(func() (err error) {
+		year, data, err = w.orderHandler(filePath)
+		return err
+	})
+	if errors.Is(err, safefile.ErrLocked) {
+		logger.Monitor.Error().
+			Err(err).
+			Str("file", filePath).
+			Str("sessionID", w.session.SessionID).
+			Msg("Order file locked, save the orders again to retry")
+		return
+	}
+	if errors.Is(err, ErrInvalidOrderFile) {
+		w.orderFileInvalid(filePath, err)
+		return
+	}
+
+	w.mu.Lock()
+	w.invalidReads = 0
+	w.mu.Unlock()
+
 	if err != nil {
 		logger.Monitor.Debug().
 			Err(err).
@@ -216,3 +406,165 @@ func (w *SessionWatcher) processOrderFile(filePath string) {
 		Int("year", year).
 		Msg("Order submitted successfully")
 }
+
+// waitSettled waits for the order file to be done writing: its size and mtime
+// stayed the same for the settle time and no other process holds it open.
+// Slow writers, like Stars! under Wine, can pause for longer than the
+// debounce. Returns false when the file is gone or the watcher stopped.
+func (w *SessionWatcher) waitSettled(filePath string) bool {
+	settle := w.session.Settle
+	if settle <= 0 {
+		return true
+	}
+
+	prev, err := os.Stat(filePath)
+	if err != nil {
+		return false
+	}
+	for range maxSettleChecks {
+		select {
+		case <-w.stopCh:
+			return false
+		case <-time.After(settle):
+		}
+
+		cur, err := os.Stat(filePath)
+		if err != nil {
+			return false
+		}
+		if cur.Size() == prev.Size() && cur.ModTime().Equal(prev.ModTime()) && !platform.FileInUse(filePath) {
+			return true
+		}
+		prev = cur
+	}
+
+	logger.Monitor.Warn().
+		Str("file", filePath).
+		Str("sessionID", w.session.SessionID).
+		Msg("Order file still changing, processing it anyway")
+	return true
+}
+
+// importOrderFile copies a valid order file saved in an extra directory to
+// the game directory, whose watcher then uploads it. An order file of the
+// game directory more recent than the saved one is left alone.
+func (w *SessionWatcher) importOrderFile(filePath string) {
+	if _, _, err := w.orderHandler(filePath); err != nil {
+		if errors.Is(err, ErrInvalidOrderFile) {
+			w.orderFileInvalid(filePath, err)
+			return
+		}
+		logger.Monitor.Debug().
+			Err(err).
+			Str("file", filePath).
+			Msg("Order file not ready for import")
+		return
+	}
+
+	w.mu.Lock()
+	w.invalidReads = 0
+	w.mu.Unlock()
+
+	src, err := os.Stat(filePath)
+	if err != nil {
+		return
+	}
+	target := ResolveFile(w.session.GameDir, w.expectedOrderFile())
+	if dst, err := os.Stat(target); err == nil && !dst.ModTime().Before(src.ModTime()) {
+		logger.Monitor.Debug().
+			Str("file", filePath).
+			Str("target", target).
+			Msg("Game directory order file is up to date, not importing")
+		return
+	}
+
+	var data []byte
+	err = safefile.Retry(func() (err error) {
+		data, err = os.ReadFile(filePath)
+		return err
+	})
+	if err == nil {
+		err = safefile.WriteFile(target, data, 0644)
+	}
+	if err != nil {
+		logger.Monitor.Error().
+			Err(err).
+			Str("file", filePath).
+			Str("sessionID", w.session.SessionID).
+			Msg("Failed to import order file to game directory")
+		return
+	}
+
+	logger.Monitor.Info().
+		Str("file", filePath).
+		Str("target", target).
+		Str("sessionID", w.session.SessionID).
+		Msg("Imported order file from extra directory")
+}
+
+// orderFileInvalid backs off after an invalid read of the order file. The file
+// is read again later in case it was still being written; once it failed
+// quarantineAfter times in a row a copy is quarantined for diagnostics and only
+// the next change of the file is processed.
+func (w *SessionWatcher) orderFileInvalid(filePath string, err error) {
+	w.mu.Lock()
+	w.invalidReads++
+	invalidReads := w.invalidReads
+	if invalidReads < quarantineAfter {
+		w.scheduleLocked(filePath)
+	}
+	handler := w.quarantineHandler
+	w.mu.Unlock()
+
+	logger.Monitor.Warn().
+		Err(err).
+		Str("file", filePath).
+		Str("sessionID", w.session.SessionID).
+		Int("invalidReads", invalidReads).
+		Msg("Invalid order file")
+
+	if invalidReads != quarantineAfter {
+		return
+	}
+
+	quarantinePath, qErr := w.quarantine(filePath)
+	if qErr != nil {
+		logger.Monitor.Error().
+			Err(qErr).
+			Str("file", filePath).
+			Str("sessionID", w.session.SessionID).
+			Msg("Failed to quarantine invalid order file")
+		return
+	}
+
+	logger.Monitor.Warn().
+		Str("file", filePath).
+		Str("quarantinePath", quarantinePath).
+		Str("sessionID", w.session.SessionID).
+		Msg("Quarantined invalid order file")
+
+	if handler != nil {
+		handler(w.session.ServerURL, w.session.SessionID, quarantinePath, err)
+	}
+}
+
+// quarantine copies an order file to the diagnostics folder of the game
+// directory, under a timestamped name, and returns the path of the copy
+func (w *SessionWatcher) quarantine(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read order file: %w", err)
+	}
+
+	dir := filepath.Join(w.session.GameDir, QuarantineDirName)
+	if err := safefile.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create diagnostics directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%s.%s", filepath.Base(filePath), time.Now().Format("20060102-150405"))
+	quarantinePath := filepath.Join(dir, name)
+	if err := safefile.WriteFile(quarantinePath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write quarantined order file: %w", err)
+	}
+	return quarantinePath, nil
+}
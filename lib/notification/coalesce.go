@@ -0,0 +1,108 @@
+package notification
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/neper-stars/astrum/api"
+	"github.com/neper-stars/astrum/api/async"
+)
+
+// DefaultCoalesceWindow is how long identical notifications are gathered before delivery
+const DefaultCoalesceWindow = 500 * time.Millisecond
+
+// pendingNotification is a burst of identical notifications waiting for delivery
+type pendingNotification struct {
+	latest async.ResourceChange
+	count  int
+}
+
+// coalescer merges identical notifications received within a window into a
+// single delivery carrying the latest notification and the burst size.
+// Notifications are identical when they share type, resource ID and action
+// (player_control also compares metadata, as one session ID covers all players).
+type coalescer struct {
+	mu      sync.Mutex
+	window  time.Duration
+	pending map[string]*pendingNotification
+	deliver func(n async.ResourceChange, count int)
+}
+
+// newCoalescer creates a coalescer delivering merged notifications to deliver
+func newCoalescer(window time.Duration, deliver func(n async.ResourceChange, count int)) *coalescer {
+	return &coalescer{
+		window:  window,
+		pending: make(map[string]*pendingNotification),
+		deliver: deliver,
+	}
+}
+
+// add queues a notification, starting a window for the first of a burst.
+// With a zero window notifications are delivered right away.
+func (c *coalescer) add(n async.ResourceChange) {
+	c.mu.Lock()
+	if c.window <= 0 {
+		c.mu.Unlock()
+		c.deliver(n, 1)
+		return
+	}
+
+	key := coalesceKey(n)
+	if p, ok := c.pending[key]; ok {
+		p.latest = n
+		p.count++
+		c.mu.Unlock()
+		return
+	}
+	c.pending[key] = &pendingNotification{latest: n, count: 1}
+	window := c.window
+	c.mu.Unlock()
+
+	time.AfterFunc(window, func() { c.flush(key) })
+}
+
+// flush delivers the burst of a key
+func (c *coalescer) flush(key string) {
+	c.mu.Lock()
+	p, ok := c.pending[key]
+	delete(c.pending, key)
+	c.mu.Unlock()
+
+	if ok {
+		c.deliver(p.latest, p.count)
+	}
+}
+
+// setWindow changes the window of the next bursts
+func (c *coalescer) setWindow(window time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.window = window
+}
+
+// reset drops the notifications waiting for delivery
+func (c *coalescer) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = make(map[string]*pendingNotification)
+}
+
+// coalesceKey identifies identical notifications
+func coalesceKey(n async.ResourceChange) string {
+	key := deref(n.Type) + "|" + deref(n.ID) + "|" + deref(n.Action)
+	if deref(n.Type) == api.NotificationTypePlayerControl && n.Metadata != nil {
+		if meta, err := json.Marshal(n.Metadata); err == nil {
+			key += "|" + string(meta)
+		}
+	}
+	return key
+}
+
+// deref returns the dereferenced string or empty string if nil
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/neper-stars/astrum/api"
 	"github.com/neper-stars/astrum/api/async"
 	"github.com/neper-stars/astrum/lib/logger"
+	"github.com/rs/zerolog"
 )
 
 // Reconnection backoff settings
@@ -22,41 +23,55 @@ type Manager struct {
 	mu          sync.RWMutex
 	connected   bool
 	token       string           // Current token for reconnection attempts
+	quiet       bool             // Connection failures are expected, e.g. during a server maintenance
 	stopPolling chan struct{}
 	stopReconnect chan struct{}
 	pollWg      sync.WaitGroup
 	reconnectWg sync.WaitGroup
+	coalescer   *coalescer
 
 	// Callbacks
-	onNotification     func(async.ResourceChange)
+	onNotification     func(n async.ResourceChange, count int)
 	onConnectionChange func(connected bool)
 	onPollFallback     func() // Called when polling as fallback
 }
 
 // NewManager creates a new notification manager
 func NewManager(baseURL string) *Manager {
-	return &Manager{
+	m := &Manager{
 		client:        api.NewNotificationClient(baseURL),
 		stopPolling:   make(chan struct{}),
 		stopReconnect: make(chan struct{}),
 	}
+	m.coalescer = newCoalescer(DefaultCoalesceWindow, m.deliverNotification)
+	return m
 }
 
-// SetOnNotification sets the callback for received notifications
-func (m *Manager) SetOnNotification(fn func(async.ResourceChange)) {
+// SetOnNotification sets the callback for received notifications.
+// Identical notifications received in a burst are merged into one call with
+// the latest notification and the number of merged notifications.
+func (m *Manager) SetOnNotification(fn func(n async.ResourceChange, count int)) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.onNotification = fn
 
-	// Wire up to the client
-	m.client.SetOnNotify(func(n async.ResourceChange) {
-		m.mu.RLock()
-		callback := m.onNotification
-		m.mu.RUnlock()
-		if callback != nil {
-			callback(n)
-		}
-	})
+	// Wire up to the client, through the coalescer
+	m.client.SetOnNotify(m.coalescer.add)
+}
+
+// SetCoalesceWindow sets how long identical notifications are merged, 0 disables merging
+func (m *Manager) SetCoalesceWindow(window time.Duration) {
+	m.coalescer.setWindow(window)
+}
+
+// deliverNotification calls the notification callback with a merged burst
+func (m *Manager) deliverNotification(n async.ResourceChange, count int) {
+	m.mu.RLock()
+	callback := m.onNotification
+	m.mu.RUnlock()
+	if callback != nil {
+		callback(n, count)
+	}
 }
 
 // SetOnConnectionChange sets the callback for WebSocket connection state changes
@@ -82,7 +97,7 @@ func (m *Manager) Connect(token string) error {
 
 	// Set up error handler that triggers reconnection
 	m.client.SetOnError(func(err error) {
-		logger.Notification.Error().Err(err).Msg("WebSocket error")
+		m.failureLog(zerolog.ErrorLevel).Err(err).Msg("WebSocket error")
 		m.mu.Lock()
 		m.connected = false
 		m.mu.Unlock()
@@ -123,7 +138,7 @@ func (m *Manager) Reconnect(token string) error {
 
 	// Set up error handler that triggers reconnection
 	m.client.SetOnError(func(err error) {
-		logger.Notification.Error().Err(err).Msg("WebSocket error")
+		m.failureLog(zerolog.ErrorLevel).Err(err).Msg("WebSocket error")
 		m.mu.Lock()
 		m.connected = false
 		m.mu.Unlock()
@@ -165,6 +180,9 @@ func (m *Manager) Disconnect() {
 		m.client.Close()
 	}
 
+	// Drop notifications still waiting for delivery
+	m.coalescer.reset()
+
 	m.mu.Lock()
 	m.connected = false
 	m.token = ""
@@ -173,6 +191,26 @@ func (m *Manager) Disconnect() {
 	logger.Notification.Info().Msg("Disconnected")
 }
 
+// SetHeartbeat sets the WebSocket keep-alive settings, applied from the next connection
+func (m *Manager) SetHeartbeat(config api.HeartbeatConfig) {
+	m.client.SetHeartbeat(config)
+}
+
+// SetSessionSubscriptions limits per-session notifications to the given sessions
+func (m *Manager) SetSessionSubscriptions(sessionIDs []string) {
+	m.client.SetSessionSubscriptions(sessionIDs)
+}
+
+// Subscribe adds a session to the subscriptions (e.g. after joining it)
+func (m *Manager) Subscribe(sessionID string) {
+	m.client.Subscribe(sessionID)
+}
+
+// Unsubscribe removes a session from the subscriptions (e.g. after leaving it)
+func (m *Manager) Unsubscribe(sessionID string) {
+	m.client.Unsubscribe(sessionID)
+}
+
 // IsConnected returns whether the WebSocket is currently connected
 func (m *Manager) IsConnected() bool {
 	m.mu.RLock()
@@ -180,6 +218,12 @@ func (m *Manager) IsConnected() bool {
 	return m.connected
 }
 
+// SilentDeaths returns how many times the WebSocket stayed open while nothing
+// came through anymore, and was reconnected
+func (m *Manager) SilentDeaths() int {
+	return m.client.SilentDeaths()
+}
+
 // StartPolling starts the polling fallback loop
 func (m *Manager) StartPolling() {
 	m.mu.Lock()
@@ -288,7 +332,7 @@ func (m *Manager) reconnectLoop() {
 
 		// Attempt reconnection
 		if err := m.client.Reconnect(token); err != nil {
-			logger.Notification.Warn().Err(err).Msg("Reconnection attempt failed")
+			m.failureLog(zerolog.WarnLevel).Err(err).Msg("Reconnection attempt failed")
 
 			// Increase backoff (exponential)
 			backoff *= backoffFactor
@@ -334,6 +378,27 @@ func (m *Manager) pollLoop() {
 	}
 }
 
+// SetQuiet lowers connection failures to debug logs while they are expected,
+// e.g. during a planned server maintenance
+func (m *Manager) SetQuiet(quiet bool) {
+	m.mu.Lock()
+	m.quiet = quiet
+	m.mu.Unlock()
+}
+
+// failureLog returns the log event of a connection failure at the given
+// level, or at debug level when quiet
+func (m *Manager) failureLog(level zerolog.Level) *zerolog.Event {
+	m.mu.RLock()
+	quiet := m.quiet
+	m.mu.RUnlock()
+
+	if quiet {
+		return logger.Notification.Debug()
+	}
+	return logger.Notification.WithLevel(level)
+}
+
 // notifyConnectionChange calls the connection change callback if set
 func (m *Manager) notifyConnectionChange(connected bool) {
 	m.mu.RLock()
@@ -0,0 +1,23 @@
+package platform
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// FreeSpace returns the bytes available to the user on the filesystem holding
+// path. A path that does not exist yet is measured at its closest existing parent.
+func FreeSpace(path string) (uint64, error) {
+	path = filepath.Clean(path)
+	for {
+		if _, err := os.Stat(path); err == nil {
+			break
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			break
+		}
+		path = parent
+	}
+	return freeSpace(path)
+}
@@ -0,0 +1,8 @@
+package platform
+
+// FileInUse returns whether another process holds path open in a way that
+// prevents exclusive access, e.g. Stars! still writing a game file.
+// Files that can't be opened at all are reported as not in use.
+func FileInUse(path string) bool {
+	return fileInUse(path)
+}
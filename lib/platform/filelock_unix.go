@@ -0,0 +1,9 @@
+//go:build !windows
+
+package platform
+
+// fileInUse always returns false, Unix systems have no mandatory file locks:
+// open files, including those written by Stars! under Wine, can always be read
+func fileInUse(path string) bool {
+	return false
+}
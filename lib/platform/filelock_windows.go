@@ -0,0 +1,22 @@
+package platform
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// fileInUse opens path without sharing it, which Windows refuses with a
+// sharing violation while another process has it open
+func fileInUse(path string) bool {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+	h, err := windows.CreateFile(p, windows.GENERIC_READ, 0, nil, windows.OPEN_EXISTING, windows.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		return errors.Is(err, windows.ERROR_SHARING_VIOLATION)
+	}
+	_ = windows.CloseHandle(h)
+	return false
+}
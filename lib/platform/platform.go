@@ -0,0 +1,82 @@
+// Package platform gathers the operating system specific behaviors: opening
+// folders, finding Wine, preparing downloaded executables and delivering the
+// notification icon.
+package platform
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ErrWineNotFound is returned when no Wine installation was found
+var ErrWineNotFound = errors.New("wine not found")
+
+// OpenPath opens a file or directory with the default application
+// (the file explorer for directories)
+func OpenPath(path string) error {
+	return exec.Command(openCommand, path).Start()
+}
+
+// RevealPath opens the file explorer on the directory of a file with the file
+// selected, falling back to opening the directory where selecting is not
+// supported
+func RevealPath(path string) error {
+	if err := revealPath(path); err == nil {
+		return nil
+	}
+	return OpenPath(filepath.Dir(path))
+}
+
+// FindWine returns the path of the Wine binary to run Stars! with
+func FindWine() (string, error) {
+	for _, candidate := range wineCandidates() {
+		if filepath.IsAbs(candidate) {
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+			continue
+		}
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path, nil
+		}
+	}
+	return "", ErrWineNotFound
+}
+
+// WineCommand returns the Wine binary to run, "wine" when none was found so
+// that the error surfaces when running it
+func WineCommand() string {
+	if path, err := FindWine(); err == nil {
+		return path
+	}
+	return "wine"
+}
+
+// NotificationIcon returns the icon to give desktop notifications, caching
+// the icon data in cachePath where the notifier works better with a file
+func NotificationIcon(iconData []byte, cachePath string) any {
+	return notificationIcon(iconData, cachePath)
+}
+
+// writeIconFile writes the icon to cachePath unless it is already there
+func writeIconFile(iconData []byte, cachePath string) error {
+	if info, err := os.Stat(cachePath); err == nil && info.Size() == int64(len(iconData)) {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(cachePath, iconData, 0644)
+}
+
+// commandOutput runs a command and returns its trimmed output, empty when it fails
+func commandOutput(name string, args ...string) string {
+	out, err := exec.Command(name, args...).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,60 @@
+package platform
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// openCommand opens paths in Finder or the default application
+const openCommand = "open"
+
+// revealPath selects the file in Finder
+func revealPath(path string) error {
+	return exec.Command("open", "-R", path).Start()
+}
+
+// WineArch is the WINEARCH of Wine prefixes: macOS Wine only has 64-bit
+// prefixes, running 32-bit programs through WoW64
+const WineArch = ""
+
+// WineInstallHint tells how to install Wine
+const WineInstallHint = "Install Wine with Homebrew ('brew install --cask wine-stable') or install CrossOver."
+
+// wineCandidates lists the Wine binaries to look for, by preference
+func wineCandidates() []string {
+	candidates := []string{"wine", "wine64"}
+
+	crossOver := "CrossOver.app/Contents/SharedSupport/CrossOver/bin/wine"
+	candidates = append(candidates, filepath.Join("/Applications", crossOver))
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, "Applications", crossOver))
+	}
+	return candidates
+}
+
+// PrepareExecutable removes the quarantine attribute macOS puts on files
+// written by a downloaded app, which makes Gatekeeper block them under Wine
+func PrepareExecutable(path string) error {
+	out, err := exec.Command("xattr", "-d", "com.apple.quarantine", path).CombinedOutput()
+	if err != nil && !strings.Contains(string(out), "No such xattr") {
+		return err
+	}
+	return nil
+}
+
+// notificationIcon gives the notifier a file: it converts the icon to the
+// macOS format at each notification, from a temporary file when given data
+func notificationIcon(iconData []byte, cachePath string) any {
+	if err := writeIconFile(iconData, cachePath); err != nil {
+		return iconData
+	}
+	return cachePath
+}
+
+// WebViewVersion returns the name and version of the system WebView, which
+// is the WebKit shipped with Safari
+func WebViewVersion() (string, string) {
+	return "WKWebView", commandOutput("defaults", "read", "/Applications/Safari.app/Contents/Info", "CFBundleShortVersionString")
+}
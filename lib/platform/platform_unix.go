@@ -0,0 +1,71 @@
+//go:build !darwin && !windows
+
+package platform
+
+import (
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// openCommand opens paths with the desktop default application
+const openCommand = "xdg-open"
+
+// revealPath asks the file manager to select the file through the
+// org.freedesktop.FileManager1 D-Bus interface (Nautilus, Dolphin, Nemo...)
+func revealPath(path string) error {
+	// Commas separate the items of a dbus-send array
+	uri := strings.ReplaceAll((&url.URL{Scheme: "file", Path: path}).String(), ",", "%2C")
+	return exec.Command("dbus-send", "--session", "--print-reply",
+		"--dest=org.freedesktop.FileManager1", "--type=method_call",
+		"/org/freedesktop/FileManager1", "org.freedesktop.FileManager1.ShowItems",
+		"array:string:"+uri, "string:").Run()
+}
+
+// WineArch is the WINEARCH of Wine prefixes (Stars! is a 16-bit program
+// that needs a 32-bit prefix)
+const WineArch = "win32"
+
+// WineInstallHint tells how to install Wine
+const WineInstallHint = "Please install Wine (e.g., 'apt install wine' or 'dnf install wine')."
+
+// wineCandidates lists the Wine binaries to look for, by preference
+func wineCandidates() []string {
+	return []string{"wine"}
+}
+
+// PrepareExecutable has nothing to do on this platform
+func PrepareExecutable(path string) error {
+	return nil
+}
+
+// notificationIcon passes the icon data as is
+func notificationIcon(iconData []byte, cachePath string) any {
+	return iconData
+}
+
+// webKitPackages are the pkg-config names of WebKitGTK, newest first
+var webKitPackages = []string{"webkit2gtk-4.1", "webkit2gtk-4.0"}
+
+// WebViewVersion returns the name and version of the WebKitGTK library.
+// Without the development files, the version is the name of the loaded library.
+func WebViewVersion() (string, string) {
+	for _, pkg := range webKitPackages {
+		if version := commandOutput("pkg-config", "--modversion", pkg); version != "" {
+			return "WebKitGTK (" + pkg + ")", version
+		}
+	}
+
+	maps, err := os.ReadFile("/proc/self/maps")
+	if err != nil {
+		return "WebKitGTK", ""
+	}
+	for _, line := range strings.Split(string(maps), "\n") {
+		if i := strings.Index(line, "libwebkit2gtk-"); i >= 0 {
+			return "WebKitGTK", filepath.Base(line[i:])
+		}
+	}
+	return "WebKitGTK", ""
+}
@@ -0,0 +1,69 @@
+package platform
+
+import (
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// openCommand opens paths in the file explorer
+const openCommand = "explorer"
+
+// revealPath selects the file in the explorer. The command line is written
+// as is, explorer not parsing a quoted "/select,path" argument.
+func revealPath(path string) error {
+	cmd := exec.Command("explorer")
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: `explorer /select,"` + path + `"`}
+	return cmd.Start()
+}
+
+// WineArch is the WINEARCH of Wine prefixes (Stars! is a 16-bit program
+// that needs a 32-bit prefix)
+const WineArch = "win32"
+
+// WineInstallHint tells how to install Wine
+const WineInstallHint = "Wine is not needed on Windows: install OTVDM to run Stars! on 64-bit Windows."
+
+// wineCandidates lists the Wine binaries to look for, by preference
+func wineCandidates() []string {
+	return []string{"wine"}
+}
+
+// PrepareExecutable has nothing to do on Windows
+func PrepareExecutable(path string) error {
+	return nil
+}
+
+// notificationIcon passes the icon data as is
+func notificationIcon(iconData []byte, cachePath string) any {
+	return iconData
+}
+
+// webView2ClientKey is the EdgeUpdate client key of the WebView2 Runtime
+const webView2ClientKey = `Microsoft\EdgeUpdate\Clients\{F3017226-FE2A-4295-8BDF-00C3A9A7E4C5}`
+
+// WebViewVersion returns the name and version of the WebView2 Runtime,
+// read where its installer registers it
+func WebViewVersion() (string, string) {
+	locations := []struct {
+		root registry.Key
+		path string
+	}{
+		{registry.LOCAL_MACHINE, `SOFTWARE\WOW6432Node\` + webView2ClientKey},
+		{registry.LOCAL_MACHINE, `SOFTWARE\` + webView2ClientKey},
+		{registry.CURRENT_USER, `Software\` + webView2ClientKey},
+	}
+	for _, loc := range locations {
+		key, err := registry.OpenKey(loc.root, loc.path, registry.QUERY_VALUE)
+		if err != nil {
+			continue
+		}
+		version, _, err := key.GetStringValue("pv")
+		key.Close()
+		if err == nil && version != "" && version != "0.0.0.0" {
+			return "WebView2", version
+		}
+	}
+	return "WebView2", ""
+}
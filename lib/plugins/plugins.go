@@ -0,0 +1,140 @@
+// Package plugins discovers and runs turn post-processing plugins.
+//
+// A plugin is an executable dropped in the plugins directory. After a turn is
+// downloaded it is run with the following environment and may write any file
+// (custom reports, alternative maps...) to ASTRUM_OUTPUT_DIR:
+//
+//	ASTRUM_UNIVERSE_FILE  path of the .xy file
+//	ASTRUM_TURN_FILE      path of the .mN file
+//	ASTRUM_YEAR           game year of the turn
+//	ASTRUM_OUTPUT_DIR     directory where artifacts must be written
+//
+// WASM modules are not supported yet.
+package plugins
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/neper-stars/astrum/lib/hooks"
+)
+
+// Timeout bounds the run time of a plugin
+const Timeout = 2 * time.Minute
+
+// Plugin is an executable found in the plugins directory
+type Plugin struct {
+	Name string // file name without extension
+	Path string
+}
+
+// Input describes the turn handed to a plugin
+type Input struct {
+	UniverseFile string
+	TurnFile     string
+	Year         int
+	OutputDir    string
+}
+
+// Run is the outcome of a plugin run
+type Run struct {
+	Result    *hooks.Result
+	Artifacts []string // files found in the output directory after the run
+}
+
+// Discover lists the plugins of dir. A missing directory yields no plugin.
+func Discover(dir string) ([]Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read plugins directory: %w", err)
+	}
+
+	var plugins []Plugin
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || !isExecutable(entry.Name(), info.Mode()) {
+			continue
+		}
+		plugins = append(plugins, Plugin{
+			Name: strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())),
+			Path: filepath.Join(dir, entry.Name()),
+		})
+	}
+
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins, nil
+}
+
+// Execute runs a plugin on a turn and lists the artifacts it produced
+func Execute(p Plugin, in Input) (*Run, error) {
+	if err := os.MkdirAll(in.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create plugin output directory: %w", err)
+	}
+
+	result, err := hooks.Run(p.Path, map[string]string{
+		"ASTRUM_UNIVERSE_FILE": in.UniverseFile,
+		"ASTRUM_TURN_FILE":     in.TurnFile,
+		"ASTRUM_YEAR":          strconv.Itoa(in.Year),
+		"ASTRUM_OUTPUT_DIR":    in.OutputDir,
+	}, Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run plugin %s: %w", p.Name, err)
+	}
+
+	artifacts, err := ListArtifacts(in.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Run{Result: result, Artifacts: artifacts}, nil
+}
+
+// ListArtifacts returns the files below dir, relative to it
+func ListArtifacts(dir string) ([]string, error) {
+	var artifacts []string
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list plugin artifacts: %w", err)
+	}
+	return artifacts, nil
+}
+
+// isExecutable reports whether a plugins directory entry can be run
+func isExecutable(name string, mode os.FileMode) bool {
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".exe", ".bat", ".cmd":
+			return true
+		}
+		return false
+	}
+	return mode.IsRegular() && mode.Perm()&0111 != 0
+}
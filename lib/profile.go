@@ -0,0 +1,125 @@
+package lib
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/neper-stars/astrum/database"
+)
+
+// =============================================================================
+// CONFIGURATION PROFILES
+// =============================================================================
+
+// DefaultProfile is the profile using the config directory itself, as before
+// profiles existed
+const DefaultProfile = "default"
+
+// lastProfileFile is the file of the config directory remembering the profile
+// selected with SwitchProfile, used when none is given at startup
+const lastProfileFile = "profile"
+
+// profileNameRe matches valid profile names, used as directory names
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,31}$`)
+
+// ErrProfileNameInvalid is returned for profile names that can't be used as directory names
+var ErrProfileNameInvalid = fmt.Errorf("profile name must be 1-32 letters, digits, '-' or '_'")
+
+// ValidateProfileName checks that a profile name is usable
+func ValidateProfileName(name string) error {
+	if !profileNameRe.MatchString(name) {
+		return ErrProfileNameInvalid
+	}
+	return nil
+}
+
+// ProfilesPath returns the directory holding the non-default profiles
+func ProfilesPath() string {
+	return filepath.Join(ConfigPath(), "profiles")
+}
+
+// ProfilePath returns the directory of a profile's database
+func ProfilePath(name string) string {
+	if name == "" || name == DefaultProfile {
+		return ConfigPath()
+	}
+	return filepath.Join(ProfilesPath(), name)
+}
+
+// DefaultProfileServersDir returns the default servers directory of a profile,
+// so that new profiles don't share game files with the default one
+func DefaultProfileServersDir(name string) string {
+	if name == "" || name == DefaultProfile {
+		return DefaultServersDir()
+	}
+	return filepath.Join(filepath.Dir(DefaultServersDir()), "profiles", name, "servers")
+}
+
+// ListProfiles returns the default profile followed by the existing profiles, sorted by name
+func ListProfiles() []string {
+	profiles := []string{DefaultProfile}
+
+	entries, err := os.ReadDir(ProfilesPath())
+	if err != nil {
+		return profiles
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && ValidateProfileName(entry.Name()) == nil && entry.Name() != DefaultProfile {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return append(profiles, names...)
+}
+
+// LastProfile returns the profile last selected with SetLastProfile,
+// or the default profile
+func LastProfile() string {
+	data, err := os.ReadFile(filepath.Join(ConfigPath(), lastProfileFile))
+	if err != nil {
+		return DefaultProfile
+	}
+	name := strings.TrimSpace(string(data))
+	if ValidateProfileName(name) != nil {
+		return DefaultProfile
+	}
+	return name
+}
+
+// SetLastProfile remembers the profile to use when none is given at startup
+func SetLastProfile(name string) error {
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ConfigPath(), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(ConfigPath(), lastProfileFile), []byte(name+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to save profile selection: %w", err)
+	}
+	return nil
+}
+
+// InitProfile gives the settings of a new profile their profile-specific
+// defaults. Profiles that already have settings are left untouched.
+func (c *Config) InitProfile(name string) error {
+	if name == "" || name == DefaultProfile {
+		return nil
+	}
+
+	data, err := c.db.Get(database.BucketAppSettings, AppSettingsKey)
+	if err != nil {
+		return fmt.Errorf("failed to get app settings: %w", err)
+	}
+	if data != nil {
+		return nil
+	}
+
+	return c.SetAppSettings(&AppSettings{ServersDir: DefaultProfileServersDir(name)})
+}
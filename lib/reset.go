@@ -0,0 +1,86 @@
+package lib
+
+import (
+	"fmt"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/neper-stars/astrum/database"
+	"github.com/neper-stars/astrum/model"
+)
+
+// =============================================================================
+// CONFIGURATION EXPORT AND RESET
+// =============================================================================
+
+// ConfigExport is a copy of the configuration, taken before it is reset.
+// Credentials stay in the keyring and the guest mode PIN is left out.
+type ConfigExport struct {
+	ExportedAt time.Time     `json:"exportedAt"`
+	Settings   *AppSettings  `json:"settings"`
+	Servers    model.Servers `json:"servers"`
+}
+
+// ExportConfig returns the app settings and servers, JSON encoded
+func (c *Config) ExportConfig() ([]byte, error) {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return nil, err
+	}
+	settings.KioskPinHash = nil
+
+	servers, err := c.GetServers()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := jsoniter.MarshalIndent(ConfigExport{
+		ExportedAt: time.Now().UTC(),
+		Settings:   settings,
+		Servers:    servers,
+	}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config export: %w", err)
+	}
+	return data, nil
+}
+
+// ResetAppSettings puts every app setting back to its default, except the
+// servers directory holding the game files and the device ID other machines
+// of the user know this one by
+func (c *Config) ResetAppSettings() error {
+	settings, err := c.GetAppSettings()
+	if err != nil {
+		return err
+	}
+	return c.SetAppSettings(&AppSettings{
+		ServersDir: settings.ServersDir,
+		DeviceID:   settings.DeviceID,
+	})
+}
+
+// ClearData empties every bucket of the database but the app settings and
+// the configuration audit log, which records the reset. Servers should be
+// removed first, so their keyring credentials are deleted.
+func (c *Config) ClearData() error {
+	err := c.db.Update(func(tx *database.Tx) error {
+		buckets, err := tx.Buckets()
+		if err != nil {
+			return err
+		}
+		for _, bucket := range buckets {
+			if bucket == database.BucketAppSettings || bucket == database.BucketConfigAudit {
+				continue
+			}
+			if err := tx.Clear(bucket); err != nil {
+				return fmt.Errorf("bucket %s: %w", bucket, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to clear data: %w", err)
+	}
+	return nil
+}
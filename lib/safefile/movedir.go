@@ -0,0 +1,93 @@
+package safefile
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// rename is replaced in tests to simulate moves across disks
+var rename = os.Rename
+
+// MoveDir moves a directory to dst, which must not exist. When it cannot be
+// renamed, e.g. to another disk, it is copied then removed; a failed copy
+// removes what was copied and leaves src untouched.
+func MoveDir(src, dst string) error {
+	if _, err := os.Lstat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	if err := MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := rename(src, dst); err == nil {
+		return nil
+	}
+
+	if err := copyDir(src, dst); err != nil {
+		_ = os.RemoveAll(dst)
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	if err := os.RemoveAll(src); err != nil {
+		return fmt.Errorf("copied to %s but failed to remove %s: %w", dst, src, err)
+	}
+	return nil
+}
+
+// copyDir copies a directory tree, keeping file modes. Symbolic links are
+// copied as links.
+func copyDir(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		default:
+			return nil // Sockets, devices: nothing a game directory needs
+		}
+	})
+}
+
+// copyFile copies a regular file, synced before returning
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
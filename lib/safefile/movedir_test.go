@@ -0,0 +1,57 @@
+package safefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeGameDir(t *testing.T) string {
+	src := filepath.Join(t.TempDir(), "server", "game")
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "orders-history"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "game.m1"), []byte("turn"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "orders-history", "2400.x1"), []byte("orders"), 0600))
+	return src
+}
+
+func assertMoved(t *testing.T, src, dst string) {
+	assert.NoDirExists(t, src)
+	data, err := os.ReadFile(filepath.Join(dst, "game.m1"))
+	require.NoError(t, err)
+	assert.Equal(t, "turn", string(data))
+
+	info, err := os.Stat(filepath.Join(dst, "orders-history", "2400.x1"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestMoveDir(t *testing.T) {
+	src := makeGameDir(t)
+	dst := filepath.Join(t.TempDir(), "hdd", "server")
+
+	require.NoError(t, MoveDir(src, dst))
+	assertMoved(t, src, dst)
+}
+
+func TestMoveDirAcrossDisks(t *testing.T) {
+	rename = func(string, string) error { return errors.New("invalid cross-device link") }
+	t.Cleanup(func() { rename = os.Rename })
+
+	src := makeGameDir(t)
+	dst := filepath.Join(t.TempDir(), "hdd", "server")
+
+	require.NoError(t, MoveDir(src, dst))
+	assertMoved(t, src, dst)
+}
+
+func TestMoveDirExistingTarget(t *testing.T) {
+	src := makeGameDir(t)
+	dst := t.TempDir()
+
+	assert.Error(t, MoveDir(src, dst))
+	assert.FileExists(t, filepath.Join(src, "game.m1"))
+}
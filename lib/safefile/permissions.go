@@ -0,0 +1,137 @@
+package safefile
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// Permissions overrides the mode and group of the files and directories
+// created through this package, e.g. for game directories shared over Samba
+type Permissions struct {
+	FileMode os.FileMode // 0 keeps the mode asked by the caller
+	DirMode  os.FileMode // 0 keeps the mode asked by the caller
+	Group    string      // group name or ID, "" keeps the default group
+}
+
+var (
+	permMu sync.RWMutex
+	perms  Permissions
+	gid    = -1 // resolved Group, -1 when not set
+)
+
+// SetPermissions sets the permissions applied from now on. The group must
+// exist; groups are not supported on Windows.
+func SetPermissions(p Permissions) error {
+	id := -1
+	if p.Group != "" {
+		if runtime.GOOS == "windows" {
+			return fmt.Errorf("file groups are not supported on Windows")
+		}
+		var err error
+		if id, err = lookupGroup(p.Group); err != nil {
+			return err
+		}
+	}
+
+	permMu.Lock()
+	perms = p
+	gid = id
+	permMu.Unlock()
+	return nil
+}
+
+// lookupGroup resolves a group name or numeric ID
+func lookupGroup(group string) (int, error) {
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		if g, err = user.LookupGroupId(group); err != nil {
+			return -1, fmt.Errorf("unknown group: %s", group)
+		}
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// fileMode returns the mode of a new file. Executables stay executable by
+// whoever can read them.
+func fileMode(perm os.FileMode) os.FileMode {
+	permMu.RLock()
+	defer permMu.RUnlock()
+
+	if perms.FileMode == 0 {
+		return perm
+	}
+	mode := perms.FileMode
+	if perm&0111 != 0 {
+		mode |= (mode & 0444) >> 2
+	}
+	return mode
+}
+
+// applyOwnership sets the configured group of a new file or directory
+func applyOwnership(path string) error {
+	permMu.RLock()
+	id := gid
+	permMu.RUnlock()
+
+	if id < 0 {
+		return nil
+	}
+	if err := os.Chown(path, -1, id); err != nil {
+		return fmt.Errorf("failed to set group of %s: %w", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// MkdirAll creates a directory and its missing parents like os.MkdirAll,
+// applying the configured directory mode and group to those it creates. With
+// a group, directories get the setgid bit so the files created in them by
+// other programs, Stars! included, inherit it.
+func MkdirAll(path string, perm os.FileMode) error {
+	// Find the directories about to be created, outermost first
+	var created []string
+	for dir := filepath.Clean(path); ; {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		}
+		created = append([]string{dir}, created...)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	if err := os.MkdirAll(path, perm); err != nil {
+		return err
+	}
+
+	permMu.RLock()
+	mode := perms.DirMode
+	id := gid
+	permMu.RUnlock()
+	if mode == 0 && id < 0 {
+		return nil
+	}
+	if mode == 0 {
+		mode = perm
+	}
+	if id >= 0 && runtime.GOOS != "windows" {
+		mode |= os.ModeSetgid
+	}
+
+	for _, dir := range created {
+		// MkdirAll is subject to the umask, the mode is set explicitly
+		if err := os.Chmod(dir, mode); err != nil {
+			return err
+		}
+		if err := applyOwnership(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
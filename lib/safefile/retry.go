@@ -0,0 +1,36 @@
+package safefile
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrLocked is wrapped by the errors of operations still failing once the
+// retries of Retry are exhausted
+var ErrLocked = errors.New("file locked by another process")
+
+// Retry backoff: 50ms doubling, about 3s over all attempts
+const (
+	retryAttempts = 7
+	retryDelay    = 50 * time.Millisecond
+)
+
+// Retry runs op again with backoff while it fails because the file is locked
+// by another process, as happens on Windows while an antivirus scans a file
+// just written. Other errors are returned right away.
+func Retry(op func() error) error {
+	delay := retryDelay
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = op(); err == nil || !isLocked(err) {
+			return err
+		}
+		if attempt == retryAttempts {
+			break
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+	return fmt.Errorf("%w after %d attempts, an antivirus may be scanning it: %w", ErrLocked, retryAttempts, err)
+}
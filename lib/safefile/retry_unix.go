@@ -0,0 +1,9 @@
+//go:build !windows
+
+package safefile
+
+// isLocked always returns false, Unix systems have no mandatory file locks:
+// permission errors are not transient there
+func isLocked(err error) bool {
+	return false
+}
@@ -0,0 +1,15 @@
+package safefile
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// isLocked returns whether err is a sharing or lock violation, or an access
+// denied error, which Windows also reports for files held open by a scanner
+func isLocked(err error) bool {
+	return errors.Is(err, windows.ERROR_SHARING_VIOLATION) ||
+		errors.Is(err, windows.ERROR_LOCK_VIOLATION) ||
+		errors.Is(err, windows.ERROR_ACCESS_DENIED)
+}
@@ -0,0 +1,102 @@
+// Package safefile writes files atomically: data goes to a temporary file in
+// the destination directory, which is synced then renamed over the
+// destination, so a crash mid-write leaves either the old or the new file,
+// never a truncated one.
+package safefile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// File is a file being written atomically. Data written to it only reaches
+// its path on Commit; Close without Commit discards it.
+type File struct {
+	*os.File
+	path      string
+	perm      os.FileMode
+	committed bool
+}
+
+// Create starts writing the file at path, created with perm unless
+// SetPermissions set a file mode
+func Create(path string, perm os.FileMode) (*File, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &File{File: tmp, path: path, perm: perm}, nil
+}
+
+// Commit syncs the data to disk and moves the file to its path
+func (f *File) Commit() error {
+	if f.committed {
+		return nil
+	}
+	tmpPath := f.Name()
+	if err := f.Sync(); err != nil {
+		_ = f.File.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync %s: %w", filepath.Base(f.path), err)
+	}
+	if err := f.File.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, fileMode(f.perm)); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := applyOwnership(tmpPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := Retry(func() error { return os.Rename(tmpPath, f.path) }); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	f.committed = true
+	syncDir(filepath.Dir(f.path))
+	return nil
+}
+
+// Close discards the file unless it was committed
+func (f *File) Close() error {
+	if f.committed {
+		return nil
+	}
+	f.committed = true
+	err := f.File.Close()
+	_ = os.Remove(f.Name())
+	return err
+}
+
+// WriteFile writes data to path atomically, like os.WriteFile
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	f, err := Create(path, perm)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	return f.Commit()
+}
+
+// syncDir makes a rename in dir durable. Windows has no directory sync,
+// elsewhere it is best effort.
+func syncDir(dir string) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
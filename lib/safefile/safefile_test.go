@@ -0,0 +1,60 @@
+package safefile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileReplacesContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.m1")
+	require.NoError(t, os.WriteFile(path, []byte("old turn"), 0644))
+
+	require.NoError(t, WriteFile(path, []byte("new turn"), 0644))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new turn", string(data))
+	assertNoTempFiles(t, filepath.Dir(path))
+}
+
+func TestCloseWithoutCommitKeepsOldFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.m1")
+	require.NoError(t, os.WriteFile(path, []byte("old turn"), 0644))
+
+	f, err := Create(path, 0644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("half a tu"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "old turn", string(data))
+	assertNoTempFiles(t, filepath.Dir(path))
+}
+
+func TestCommitThenCloseIsNoop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.xy")
+
+	f, err := Create(path, 0644)
+	require.NoError(t, err)
+	_, err = f.Write([]byte("universe"))
+	require.NoError(t, err)
+	require.NoError(t, f.Commit())
+	require.NoError(t, f.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "universe", string(data))
+}
+
+func assertNoTempFiles(t *testing.T, dir string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -0,0 +1,185 @@
+// Package starsini reads and writes Stars! INI configuration files.
+// Sections, keys and comments are kept in their original order so files
+// edited by Stars! itself round-trip without surprises.
+package starsini
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/neper-stars/astrum/lib/safefile"
+)
+
+// lineEnding is the line terminator written to INI files (Stars! is a Windows program)
+const lineEnding = "\r\n"
+
+// File is a parsed INI file
+type File struct {
+	path     string
+	preamble []string // lines before the first section
+	sections []*section
+}
+
+type section struct {
+	name  string
+	lines []string // raw lines, including comments and blank lines
+}
+
+// Load parses the INI file at path. A missing file yields an empty File
+// that will be created on Save.
+func Load(path string) (*File, error) {
+	f := &File{path: path}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var current *section
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			current = &section{name: trimmed[1 : len(trimmed)-1]}
+			f.sections = append(f.sections, current)
+			continue
+		}
+		if current == nil {
+			f.preamble = append(f.preamble, line)
+		} else {
+			current.lines = append(current.lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	return f, nil
+}
+
+// Entry is a single key of a section
+type Entry struct {
+	Section string
+	Key     string
+	Value   string
+}
+
+// Entries returns all keys of the file in order
+func (f *File) Entries() []Entry {
+	var entries []Entry
+	for _, s := range f.sections {
+		for _, line := range s.lines {
+			if k, v, ok := splitKeyValue(line); ok {
+				entries = append(entries, Entry{Section: s.name, Key: k, Value: v})
+			}
+		}
+	}
+	return entries
+}
+
+// Path returns the location of the file
+func (f *File) Path() string {
+	return f.path
+}
+
+// Get returns the value of a key, matching section and key names case-insensitively
+func (f *File) Get(sectionName, key string) (string, bool) {
+	s := f.findSection(sectionName)
+	if s == nil {
+		return "", false
+	}
+	for _, line := range s.lines {
+		if k, v, ok := splitKeyValue(line); ok && strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Set sets the value of a key, creating the section and key if needed
+func (f *File) Set(sectionName, key, value string) {
+	s := f.findSection(sectionName)
+	if s == nil {
+		s = &section{name: sectionName}
+		f.sections = append(f.sections, s)
+	}
+
+	for i, line := range s.lines {
+		if k, _, ok := splitKeyValue(line); ok && strings.EqualFold(k, key) {
+			s.lines[i] = k + "=" + value // keep the existing key spelling
+			return
+		}
+	}
+
+	entry := key + "=" + value
+
+	// Insert before trailing blank lines so sections stay visually separated
+	insertAt := len(s.lines)
+	for insertAt > 0 && strings.TrimSpace(s.lines[insertAt-1]) == "" {
+		insertAt--
+	}
+	s.lines = append(s.lines[:insertAt], append([]string{entry}, s.lines[insertAt:]...)...)
+}
+
+// Delete removes a key, returning true if it was present
+func (f *File) Delete(sectionName, key string) bool {
+	s := f.findSection(sectionName)
+	if s == nil {
+		return false
+	}
+	for i, line := range s.lines {
+		if k, _, ok := splitKeyValue(line); ok && strings.EqualFold(k, key) {
+			s.lines = append(s.lines[:i], s.lines[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Save writes the file back to disk
+func (f *File) Save() error {
+	var buf bytes.Buffer
+	for _, line := range f.preamble {
+		buf.WriteString(line + lineEnding)
+	}
+	for _, s := range f.sections {
+		buf.WriteString("[" + s.name + "]" + lineEnding)
+		for _, line := range s.lines {
+			buf.WriteString(line + lineEnding)
+		}
+	}
+
+	if err := safefile.WriteFile(f.path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", f.path, err)
+	}
+	return nil
+}
+
+func (f *File) findSection(name string) *section {
+	for _, s := range f.sections {
+		if strings.EqualFold(s.name, name) {
+			return s
+		}
+	}
+	return nil
+}
+
+// splitKeyValue splits a "key=value" line, ignoring comments
+func splitKeyValue(line string) (key, value string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, ";") || strings.HasPrefix(trimmed, "#") {
+		return "", "", false
+	}
+	idx := strings.Index(trimmed, "=")
+	if idx < 0 {
+		return "", "", false
+	}
+	return strings.TrimSpace(trimmed[:idx]), strings.TrimSpace(trimmed[idx+1:]), true
+}
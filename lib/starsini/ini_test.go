@@ -0,0 +1,61 @@
+package starsini
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile_MissingFileIsEmpty(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), "stars.ini"))
+	require.NoError(t, err)
+
+	_, ok := f.Get("Misc", "Anything")
+	assert.False(t, ok, "Missing file should have no keys")
+}
+
+func TestFile_RoundTripKeepsUnknownLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stars.ini")
+	original := "; written by Stars!\r\n[Misc]\r\nSound=1\r\n\r\n[Other]\r\nKey=Value\r\n"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0644))
+
+	f, err := Load(path)
+	require.NoError(t, err)
+	require.NoError(t, f.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data), "Unmodified file should round-trip unchanged")
+}
+
+func TestFile_SetIsCaseInsensitive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stars.ini")
+	require.NoError(t, os.WriteFile(path, []byte("[Misc]\r\nSound=1\r\n\r\n"), 0644))
+
+	f, err := Load(path)
+	require.NoError(t, err)
+
+	f.Set("misc", "SOUND", "0")
+	f.Set("Misc", "Animation", "1")
+	require.NoError(t, f.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "[Misc]\r\nSound=0\r\nAnimation=1\r\n\r\n", string(data))
+
+	value, ok := f.Get("MISC", "animation")
+	assert.True(t, ok)
+	assert.Equal(t, "1", value)
+}
+
+func TestFile_Delete(t *testing.T) {
+	f, err := Load(filepath.Join(t.TempDir(), "stars.ini"))
+	require.NoError(t, err)
+
+	f.Set("Misc", "Sound", "1")
+	assert.True(t, f.Delete("Misc", "sound"))
+	assert.False(t, f.Delete("Misc", "sound"), "Deleting twice should report absence")
+}
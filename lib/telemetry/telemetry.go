@@ -0,0 +1,194 @@
+// Package telemetry counts coarse feature usage and error categories for the
+// opt-in usage reports. Nothing identifying is recorded: no server, session,
+// user name or file path, only counters and a random install ID.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Counted features
+const (
+	FeatureConnect       = "connect"
+	FeatureTurnDownload  = "turn_download"
+	FeatureOrdersUpload  = "orders_upload"
+	FeatureLaunchWine    = "launch_wine"
+	FeatureLaunchNative  = "launch_native"
+	FeatureMap           = "map"
+	FeatureAnimatedMap   = "animated_map"
+	FeatureExport        = "export"
+	FeatureIntelExchange = "intel_exchange"
+)
+
+// Counted error categories
+const (
+	ErrorConnect      = "connect"
+	ErrorTurnDownload = "turn_download"
+	ErrorLaunch       = "launch"
+	ErrorMap          = "map"
+)
+
+// Report is what is sent to the telemetry endpoint
+type Report struct {
+	InstallID string         `json:"install_id"` // random, generated when telemetry is enabled
+	OS        string         `json:"os"`
+	Arch      string         `json:"arch"`
+	Since     time.Time      `json:"since"` // start of the counting period, to the day
+	Features  map[string]int `json:"features"`
+	Errors    map[string]int `json:"errors"`
+}
+
+// State is the persisted state of a Recorder
+type State struct {
+	Since    time.Time      `json:"since"`
+	LastSent time.Time      `json:"last_sent,omitempty"`
+	Features map[string]int `json:"features,omitempty"`
+	Errors   map[string]int `json:"errors,omitempty"`
+}
+
+// Recorder counts features and errors since the last report sent
+type Recorder struct {
+	mu    sync.Mutex
+	state State
+}
+
+// NewRecorder creates a recorder with no counts
+func NewRecorder() *Recorder {
+	r := &Recorder{}
+	r.Restore(State{})
+	return r
+}
+
+// Count counts a use of a feature
+func (r *Recorder) Count(feature string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.Features[feature]++
+}
+
+// Error counts an error of a category
+func (r *Recorder) Error(category string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state.Errors[category]++
+}
+
+// Report returns the report of the counts so far
+func (r *Recorder) Report(installID string) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := Report{
+		InstallID: installID,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Since:     r.state.Since,
+		Features:  make(map[string]int, len(r.state.Features)),
+		Errors:    make(map[string]int, len(r.state.Errors)),
+	}
+	for k, v := range r.state.Features {
+		report.Features[k] = v
+	}
+	for k, v := range r.state.Errors {
+		report.Errors[k] = v
+	}
+	return report
+}
+
+// Sent removes the counts of a sent report, keeping the ones made meanwhile
+func (r *Recorder) Sent(report Report, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, v := range report.Features {
+		if r.state.Features[k] -= v; r.state.Features[k] <= 0 {
+			delete(r.state.Features, k)
+		}
+	}
+	for k, v := range report.Errors {
+		if r.state.Errors[k] -= v; r.state.Errors[k] <= 0 {
+			delete(r.state.Errors, k)
+		}
+	}
+	r.state.Since = day(at)
+	r.state.LastSent = at
+}
+
+// LastSent returns when the last report was sent, zero if never
+func (r *Recorder) LastSent() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state.LastSent
+}
+
+// State returns the state to persist
+func (r *Recorder) State() State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state := r.state
+	state.Features = make(map[string]int, len(r.state.Features))
+	state.Errors = make(map[string]int, len(r.state.Errors))
+	for k, v := range r.state.Features {
+		state.Features[k] = v
+	}
+	for k, v := range r.state.Errors {
+		state.Errors[k] = v
+	}
+	return state
+}
+
+// Restore replaces the counts with a persisted state
+func (r *Recorder) Restore(state State) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if state.Since.IsZero() {
+		state.Since = day(time.Now())
+	}
+	if state.Features == nil {
+		state.Features = make(map[string]int)
+	}
+	if state.Errors == nil {
+		state.Errors = make(map[string]int)
+	}
+	r.state = state
+}
+
+// day truncates a time to the day, so reports don't tell when the app ran
+func day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Send posts a report as JSON to the endpoint
+func Send(ctx context.Context, endpoint string, report Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telemetry report: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create telemetry request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telemetry report: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry endpoint returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// Package trash moves files and directories to the trash of the desktop
+// (XDG trash on Linux, the Finder trash on macOS, the Recycle Bin on Windows)
+// so that the user can get them back, instead of deleting them for good.
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrRestoreNotSupported is returned by Restore where the trash does not tell
+// where trashed files came from
+var ErrRestoreNotSupported = errors.New("restoring from the trash is not supported on this platform")
+
+// ErrNotFound is returned by Restore when no trashed file came from the path
+var ErrNotFound = errors.New("not found in the trash")
+
+// ErrCrossDevice is returned by Move when the path is on another file system
+// than the trash, e.g. a separate data disk or a tmpfs Wine prefix
+var ErrCrossDevice = errors.New("not on the file system of the trash")
+
+// Move moves a file or directory to the trash. A path that does not exist is
+// not an error, like os.RemoveAll.
+func Move(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(abs); os.IsNotExist(err) {
+		return nil
+	}
+	if err := moveToTrash(abs); err != nil {
+		return fmt.Errorf("failed to move %s to the trash: %w", abs, err)
+	}
+	return nil
+}
+
+// Restore moves the most recently trashed file or directory that came from
+// path back to it
+func Restore(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Lstat(abs); err == nil {
+		return fmt.Errorf("%s already exists", abs)
+	}
+	return restoreFromTrash(abs)
+}
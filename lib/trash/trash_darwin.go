@@ -0,0 +1,42 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// moveToTrash moves the file to ~/.Trash, renamed like Finder does when a
+// file of the same name is already there. Files on another volume return
+// ErrCrossDevice.
+func moveToTrash(path string) error {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return err
+	}
+	trash := filepath.Join(home, ".Trash")
+
+	target := filepath.Join(trash, filepath.Base(path))
+	if _, err := os.Lstat(target); err == nil {
+		ext := filepath.Ext(path)
+		name := strings.TrimSuffix(filepath.Base(path), ext)
+		target = filepath.Join(trash, name+" "+time.Now().Format("15.04.05")+ext)
+	}
+	if err := os.Rename(path, target); err != nil {
+		if errors.Is(err, syscall.EXDEV) {
+			return fmt.Errorf("%w: %w", ErrCrossDevice, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// restoreFromTrash is not supported: ~/.Trash keeps no record of the origin
+// of files trashed without Finder
+func restoreFromTrash(path string) error {
+	return ErrRestoreNotSupported
+}
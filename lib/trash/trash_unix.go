@@ -0,0 +1,162 @@
+//go:build !darwin && !windows
+
+package trash
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// trashInfoTime is the format of DeletionDate in .trashinfo files
+const trashInfoTime = "2006-01-02T15:04:05"
+
+// rename moves trashed files, replaced in tests
+var rename = os.Rename
+
+// homeTrash returns the trash directory of the user, $XDG_DATA_HOME/Trash
+func homeTrash() (string, error) {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(dataHome, "Trash"), nil
+}
+
+// moveToTrash follows the FreeDesktop.org trash specification: the file goes
+// to Trash/files and its origin is written to Trash/info/<name>.trashinfo.
+// Only the home trash is used, files on another file system return
+// ErrCrossDevice.
+func moveToTrash(path string) error {
+	trash, err := homeTrash()
+	if err != nil {
+		return err
+	}
+	filesDir := filepath.Join(trash, "files")
+	infoDir := filepath.Join(trash, "info")
+	for _, dir := range []string{filesDir, infoDir} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	// The info file is created exclusively to claim a unique name
+	base := filepath.Base(path)
+	var name string
+	var info *os.File
+	for i := 1; ; i++ {
+		name = base
+		if i > 1 {
+			name = base + "." + strconv.Itoa(i)
+		}
+		info, err = os.OpenFile(filepath.Join(infoDir, name+".trashinfo"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+	}
+
+	location := (&url.URL{Path: path}).EscapedPath()
+	_, err = fmt.Fprintf(info, "[Trash Info]\nPath=%s\nDeletionDate=%s\n", location, time.Now().Format(trashInfoTime))
+	if closeErr := info.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = rename(path, filepath.Join(filesDir, name))
+	}
+	if err != nil {
+		_ = os.Remove(info.Name())
+		if errors.Is(err, syscall.EXDEV) {
+			return fmt.Errorf("%w: %w", ErrCrossDevice, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// restoreFromTrash finds the .trashinfo files pointing to path and moves the
+// most recently deleted one back
+func restoreFromTrash(path string) error {
+	trash, err := homeTrash()
+	if err != nil {
+		return err
+	}
+	infoDir := filepath.Join(trash, "info")
+	entries, err := os.ReadDir(infoDir)
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	} else if err != nil {
+		return err
+	}
+
+	var latest string
+	var latestDate time.Time
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".trashinfo")
+		if !ok {
+			continue
+		}
+		origin, deleted, err := readTrashInfo(filepath.Join(infoDir, entry.Name()))
+		if err != nil || origin != path {
+			continue
+		}
+		if latest == "" || deleted.After(latestDate) {
+			latest, latestDate = name, deleted
+		}
+	}
+	if latest == "" {
+		return ErrNotFound
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(filepath.Join(trash, "files", latest), path); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(infoDir, latest+".trashinfo"))
+}
+
+// readTrashInfo returns the original path and deletion date of a trashed file
+func readTrashInfo(infoPath string) (string, time.Time, error) {
+	f, err := os.Open(infoPath)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer f.Close()
+
+	var origin string
+	var deleted time.Time
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Path":
+			if origin, err = url.PathUnescape(value); err != nil {
+				return "", time.Time{}, err
+			}
+		case "DeletionDate":
+			deleted, _ = time.ParseInLocation(trashInfoTime, value, time.Local)
+		}
+	}
+	if origin == "" {
+		return "", time.Time{}, fmt.Errorf("no path in %s", infoPath)
+	}
+	return origin, deleted, scanner.Err()
+}
@@ -0,0 +1,71 @@
+//go:build !darwin && !windows
+
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+func TestMain(m *testing.M) {
+	// Initialize logger for tests
+	logger.Init(false)
+	os.Exit(m.Run())
+}
+
+func TestMoveAndRestore(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	// Two files of the same name trashed one after the other
+	path := filepath.Join(dir, "game dir,1", "map.svg")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte("first"), 0644))
+	require.NoError(t, Move(path))
+	assert.NoFileExists(t, path)
+
+	require.NoError(t, os.WriteFile(path, []byte("second"), 0644))
+	require.NoError(t, Move(path))
+
+	trashed, err := os.ReadDir(filepath.Join(os.Getenv("XDG_DATA_HOME"), "Trash", "files"))
+	require.NoError(t, err)
+	assert.Len(t, trashed, 2)
+
+	require.NoError(t, Restore(path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, []string{"first", "second"}, string(data))
+
+	assert.Error(t, Restore(path), "the path exists again")
+}
+
+func TestMoveMissing(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	assert.NoError(t, Move(filepath.Join(t.TempDir(), "missing")))
+	assert.ErrorIs(t, Restore(filepath.Join(t.TempDir(), "missing")), ErrNotFound)
+}
+
+func TestMoveCrossDevice(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	t.Cleanup(func() { rename = os.Rename })
+	rename = func(oldpath, newpath string) error {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+	}
+
+	path := filepath.Join(t.TempDir(), "wine")
+	require.NoError(t, os.MkdirAll(filepath.Join(path, "drive_c"), 0755))
+
+	// The path stays where it is, with no trash info left behind
+	assert.ErrorIs(t, Move(path), ErrCrossDevice)
+	assert.DirExists(t, path)
+	infos, err := os.ReadDir(filepath.Join(os.Getenv("XDG_DATA_HOME"), "Trash", "info"))
+	require.NoError(t, err)
+	assert.Empty(t, infos)
+}
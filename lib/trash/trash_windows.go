@@ -0,0 +1,62 @@
+package trash
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var procSHFileOperationW = windows.NewLazySystemDLL("shell32.dll").NewProc("SHFileOperationW")
+
+// SHFileOperationW operation and flags
+const (
+	foDelete          = 0x3
+	fofSilent         = 0x4
+	fofNoConfirmation = 0x10
+	fofAllowUndo      = 0x40
+	fofNoErrorUI      = 0x400
+)
+
+// shFileOpStruct is SHFILEOPSTRUCTW
+type shFileOpStruct struct {
+	hwnd                  uintptr
+	wFunc                 uint32
+	pFrom                 *uint16
+	pTo                   *uint16
+	fFlags                uint16
+	fAnyOperationsAborted int32
+	hNameMappings         uintptr
+	lpszProgressTitle     *uint16
+}
+
+// moveToTrash sends the file to the Recycle Bin, through a delete that can
+// be undone
+func moveToTrash(path string) error {
+	// pFrom is a list of paths ending with an empty one
+	from, err := windows.UTF16FromString(path)
+	if err != nil {
+		return err
+	}
+	from = append(from, 0)
+
+	op := shFileOpStruct{
+		wFunc:  foDelete,
+		pFrom:  &from[0],
+		fFlags: fofAllowUndo | fofNoConfirmation | fofSilent | fofNoErrorUI,
+	}
+	ret, _, _ := procSHFileOperationW.Call(uintptr(unsafe.Pointer(&op)))
+	if ret != 0 {
+		return fmt.Errorf("SHFileOperation failed with code 0x%x", ret)
+	}
+	if op.fAnyOperationsAborted != 0 {
+		return fmt.Errorf("deletion aborted")
+	}
+	return nil
+}
+
+// restoreFromTrash is not supported: the Recycle Bin is only browsed through
+// the shell namespace
+func restoreFromTrash(path string) error {
+	return ErrRestoreNotSupported
+}
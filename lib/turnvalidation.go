@@ -19,6 +19,15 @@ func NewOrderValidator(fName string) (*OrderValidator, error) {
 	return &v, nil
 }
 
+// NewOrderValidatorFromBytes parses order file data, e.g. orders fetched from the server
+func NewOrderValidatorFromBytes(data []byte) (*OrderValidator, error) {
+	o, err := hs.NewOrderFromBytes(data)
+	if err != nil {
+		return nil, err
+	}
+	return &OrderValidator{order: o}, nil
+}
+
 func (o *OrderValidator) TurnIsSubmitted() bool {
 	return o.order.TurnSubmitted()
 }
@@ -26,3 +35,8 @@ func (o *OrderValidator) TurnIsSubmitted() bool {
 func (o *OrderValidator) Year() int {
 	return o.order.Year()
 }
+
+// PlayerIndex returns the 0-indexed player the orders belong to
+func (o *OrderValidator) PlayerIndex() int {
+	return o.order.Header.PlayerIndex()
+}
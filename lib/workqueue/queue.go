@@ -0,0 +1,178 @@
+// Package workqueue runs background work on a small pool of workers, taking
+// the highest priority task first.
+//
+// Running tasks are never interrupted. Instead one worker is always kept free
+// of low priority work, so long background jobs (map rendering, bulk
+// downloads) can't hold back turn handling queued behind them.
+package workqueue
+
+import (
+	"sync"
+
+	"github.com/neper-stars/astrum/lib/logger"
+)
+
+// Priority orders queued tasks
+type Priority int
+
+const (
+	// Low is for background work nobody waits for (map rendering, bulk downloads)
+	Low Priority = iota
+	// Normal is for routine work (plugins, reconciliation)
+	Normal
+	// High is for work the player is waiting for (turn ready handling, user actions)
+	High
+
+	priorities = 3
+)
+
+// String returns the priority name
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case Normal:
+		return "normal"
+	case High:
+		return "high"
+	default:
+		return "unknown"
+	}
+}
+
+// task is a queued unit of work
+type task struct {
+	name string
+	fn   func()
+}
+
+// Stats is a snapshot of the queue
+type Stats struct {
+	Queued  [priorities]int // queued tasks per priority
+	Running [priorities]int // running tasks per priority
+}
+
+// Depth returns the total number of queued tasks
+func (s Stats) Depth() int {
+	return s.Queued[Low] + s.Queued[Normal] + s.Queued[High]
+}
+
+// Queue is a priority work queue
+type Queue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	tasks   [priorities][]task
+	running [priorities]int
+	workers int
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// New starts a queue with the given number of workers (at least 2, so that
+// one is left for higher priority work)
+func New(workers int) *Queue {
+	q := &Queue{workers: max(workers, 2)}
+	q.cond = sync.NewCond(&q.mu)
+
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Submit queues a task. Tasks submitted after Stop are dropped.
+func (q *Queue) Submit(priority Priority, name string, fn func()) {
+	if priority < Low || priority > High {
+		priority = Normal
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopped {
+		logger.App.Debug().Str("task", name).Msg("Work queue stopped, dropping task")
+		return
+	}
+	q.tasks[priority] = append(q.tasks[priority], task{name: name, fn: fn})
+	q.cond.Signal()
+}
+
+// Stats returns a snapshot of queued and running tasks
+func (q *Queue) Stats() Stats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var s Stats
+	for p := range priorities {
+		s.Queued[p] = len(q.tasks[p])
+		s.Running[p] = q.running[p]
+	}
+	return s
+}
+
+// Stop drops queued tasks and waits for the running ones to finish
+func (q *Queue) Stop() {
+	q.mu.Lock()
+	q.stopped = true
+	q.tasks = [priorities][]task{}
+	q.cond.Broadcast()
+	q.mu.Unlock()
+
+	q.wg.Wait()
+}
+
+// next picks the task to run, or returns false when none can run now.
+// Must be called with q.mu held.
+func (q *Queue) next() (task, Priority, bool) {
+	for p := High; p >= Low; p-- {
+		if len(q.tasks[p]) == 0 {
+			continue
+		}
+		// Keep a worker free of low priority work
+		if p == Low && q.running[Low] >= q.workers-1 {
+			continue
+		}
+		t := q.tasks[p][0]
+		q.tasks[p] = q.tasks[p][1:]
+		return t, p, true
+	}
+	return task{}, 0, false
+}
+
+// work runs tasks until the queue is stopped
+func (q *Queue) work() {
+	defer q.wg.Done()
+
+	for {
+		q.mu.Lock()
+		t, p, ok := q.next()
+		for !ok && !q.stopped {
+			q.cond.Wait()
+			t, p, ok = q.next()
+		}
+		if !ok {
+			q.mu.Unlock()
+			return
+		}
+		q.running[p]++
+		q.mu.Unlock()
+
+		q.run(t)
+
+		q.mu.Lock()
+		q.running[p]--
+		// A finished low priority task may let a waiting one run
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// run executes a task, logging a panic instead of crashing the app
+func (q *Queue) run(t task) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.App.Error().Interface("panic", r).Str("task", t.name).Msg("Background task panicked")
+		}
+	}()
+	t.fn()
+}
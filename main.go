@@ -6,16 +6,21 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/wailsapp/wails/v2"
+	wailslogger "github.com/wailsapp/wails/v2/pkg/logger"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
 	"github.com/wailsapp/wails/v2/pkg/options/linux"
+	"github.com/wailsapp/wails/v2/pkg/options/windows"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 
 	"github.com/neper-stars/astrum/database"
 	astrum "github.com/neper-stars/astrum/lib"
+	"github.com/neper-stars/astrum/lib/dialog"
 	"github.com/neper-stars/astrum/lib/logger"
 )
 
@@ -25,74 +30,232 @@ var assets embed.FS
 //go:embed resources/astrum.png
 var appIcon []byte
 
+// Default size of the main window, before any saved geometry is restored
+const (
+	defaultWindowWidth  = 1440
+	defaultWindowHeight = 900
+)
+
 // Check if running in binding generation mode
 var bindingMode = os.Getenv("ASTRUM_BINDING_MODE") == "true"
 
-// checkSingleInstance verifies no other instance is running by trying to open the database.
-// Returns nil if we can proceed, or an error if another instance is running.
-func checkSingleInstance() error {
+// Safe mode disables WebView GPU acceleration and logs verbosely, for systems
+// showing a blank window. Enabled with --safe-mode or ASTRUM_SAFE_MODE=true.
+var safeMode = os.Getenv("ASTRUM_SAFE_MODE") == "true" || slices.Contains(os.Args[1:], "--safe-mode")
+
+// safeModeEnv are the environment variables set in safe mode, read by the WebView
+var safeModeEnv = map[string]string{
+	// WebKitGTK: no accelerated compositing nor DMA-BUF renderer, the usual
+	// causes of blank windows with some GPU drivers
+	"WEBKIT_DISABLE_COMPOSITING_MODE": "1",
+	"WEBKIT_DISABLE_DMABUF_RENDERER":  "1",
+	// WebView2: no GPU, log to the WebView2 user data folder
+	"WEBVIEW2_ADDITIONAL_BROWSER_ARGUMENTS": "--disable-gpu --enable-logging --v=1",
+}
+
+// allowMultiple lets this instance share its servers directory with another
+// running instance, which is refused otherwise: both would watch the same game
+// folders and upload the same orders. Enabled with --allow-multiple or
+// ASTRUM_ALLOW_MULTIPLE=true.
+var allowMultiple = os.Getenv("ASTRUM_ALLOW_MULTIPLE") == "true" || slices.Contains(os.Args[1:], "--allow-multiple")
+
+// instanceLockFile is the file of a servers directory locked by the instance
+// using it
+const instanceLockFile = ".astrum-instance.lock"
+
+// selectConfigDir returns the config directory given with the --config-dir
+// flag or the ASTRUM_CONFIG_DIR env var, "" for the default one
+func selectConfigDir(args []string) string {
+	dir := os.Getenv("ASTRUM_CONFIG_DIR")
+	for i, arg := range args {
+		// Parsed by hand, as the remaining arguments belong to Wails
+		if value, ok := strings.CutPrefix(arg, "--config-dir="); ok {
+			dir = value
+		} else if arg == "--config-dir" && i+1 < len(args) {
+			dir = args[i+1]
+		}
+	}
+	return dir
+}
+
+// selectProfile returns the configuration profile to use: the --profile flag,
+// then the ASTRUM_PROFILE env var, then the profile last switched to
+func selectProfile(args []string) (string, error) {
+	profile := os.Getenv("ASTRUM_PROFILE")
+	for i, arg := range args {
+		// Parsed by hand, as the remaining arguments belong to Wails
+		if value, ok := strings.CutPrefix(arg, "--profile="); ok {
+			profile = value
+		} else if arg == "--profile" && i+1 < len(args) {
+			profile = args[i+1]
+		}
+	}
+
+	if profile == "" {
+		return astrum.LastProfile(), nil
+	}
+	if err := astrum.ValidateProfileName(profile); err != nil {
+		return "", fmt.Errorf("invalid profile %q: %w", profile, err)
+	}
+	return profile, nil
+}
+
+// checkSingleInstance verifies no other instance is running the profile by
+// trying to open its database: the lock is per database, so instances of
+// other profiles or config directories run side by side. Returns the servers
+// directory of the profile, "" if unknown, or an error if another instance is
+// running the profile.
+func checkSingleInstance(profile string) (string, error) {
 	if bindingMode {
-		return nil // Skip check in binding mode
+		return "", nil // Skip check in binding mode
 	}
 
 	// Try to open the database to check if another instance has it locked
-	db, err := database.Open(astrum.ConfigPath())
+	db, err := database.Open(astrum.ProfilePath(profile))
 	if err != nil {
 		if errors.Is(err, database.ErrDatabaseLocked) {
-			return fmt.Errorf("another instance of Astrum is already running")
+			running := "another instance of Astrum is already running"
+			if profile != astrum.DefaultProfile {
+				running = fmt.Sprintf("another instance of Astrum is already running the %s profile", profile)
+			}
+			return "", fmt.Errorf("%s. To run another instance next to it, start it with another profile (--profile) or config directory (--config-dir)", running)
 		}
 		// Other database errors are not instance-related, let startup handle them
-		return nil
+		return "", nil
 	}
 	// Close it immediately - the actual startup will open it again
-	if err := db.Close(); err != nil {
-		logger.Logger.Warn().Err(err).Msg("Failed to close database after instance check")
+	defer func() {
+		if err := db.Close(); err != nil {
+			logger.Logger.Warn().Err(err).Msg("Failed to close database after instance check")
+		}
+	}()
+
+	config, err := astrum.NewConfig(db)
+	if err != nil {
+		return "", nil
+	}
+	// New profiles get a servers directory of their own
+	if err := config.InitProfile(profile); err != nil {
+		logger.Logger.Warn().Err(err).Str("profile", profile).Msg("Failed to initialize profile")
 	}
-	return nil
+	serversDir, err := config.GetServersDir()
+	if err != nil {
+		return "", nil
+	}
+	return serversDir, nil
 }
 
-// showErrorDialog displays an error message using zenity (Linux) or similar
+// lockServersDir takes the lock of a servers directory for as long as this
+// instance runs. A directory used by another instance is refused, unless
+// --allow-multiple is given, in which case the lock is left to the other
+// instance and the returned warnings tell what to expect.
+func lockServersDir(serversDir string) (*database.Lock, []string, error) {
+	if bindingMode || serversDir == "" {
+		return nil, nil, nil
+	}
+
+	lock, err := database.OpenLock(filepath.Join(serversDir, instanceLockFile))
+	if err == nil {
+		return lock, nil, nil
+	}
+	if !errors.Is(err, database.ErrDatabaseLocked) {
+		// Not instance-related, the servers directory is checked at startup
+		logger.Logger.Warn().Err(err).Str("dir", serversDir).Msg("Failed to lock servers directory")
+		return nil, nil, nil
+	}
+
+	if !allowMultiple {
+		return nil, nil, fmt.Errorf("another instance of Astrum is using the servers directory %s: both would watch and upload the same orders. Give this profile a servers directory of its own, or start with --allow-multiple to run them side by side anyway", serversDir)
+	}
+	warnings := []string{
+		fmt.Sprintf("Another instance of Astrum is using the servers directory %s", serversDir),
+		"Both instances watch the same game folders: orders may be uploaded twice, or by the wrong account",
+		"If both enable the local API on the same port, only the first one started serves it",
+	}
+	for _, warning := range warnings {
+		logger.Logger.Warn().Str("dir", serversDir).Msg(warning)
+	}
+	return nil, warnings, nil
+}
+
+// showErrorDialog displays an error message in a native message box,
+// falling back to the log when none can be shown
 func showErrorDialog(message string) {
-	// Try zenity first (common on GNOME)
-	if _, err := exec.LookPath("zenity"); err == nil {
-		cmd := exec.Command("zenity", "--error", "--title=Astrum", "--text="+message)
-		_ = cmd.Run()
-		return
-	}
-	// Try kdialog (KDE)
-	if _, err := exec.LookPath("kdialog"); err == nil {
-		cmd := exec.Command("kdialog", "--error", message, "--title", "Astrum")
-		_ = cmd.Run()
-		return
-	}
-	// Try xmessage (basic X11)
-	if _, err := exec.LookPath("xmessage"); err == nil {
-		cmd := exec.Command("xmessage", "-center", message)
-		_ = cmd.Run()
-		return
-	}
-	// Fallback to just logging
-	logger.Logger.Error().Msg(message)
+	if err := dialog.Error("Astrum", message); err != nil {
+		logger.Logger.Warn().Err(err).Msg("Failed to show error dialog")
+		logger.Logger.Error().Msg(message)
+	}
 }
 
 func main() {
-	// Initialize logger (debug mode can be controlled via env var)
+	// Initialize logger (debug mode can be controlled via env var, safe mode logs verbosely too)
 	debug := os.Getenv("ASTRUM_DEBUG") == "true"
-	logger.Init(debug)
+	logger.Init(debug || safeMode)
+
+	// Profiling endpoints are for maintainers, only exposed when debug mode is asked for
+	if debug && !bindingMode {
+		startProfilingServer(pprofPort())
+	}
+
+	if safeMode {
+		for key, value := range safeModeEnv {
+			if err := os.Setenv(key, value); err != nil {
+				logger.Logger.Warn().Err(err).Str("key", key).Msg("Failed to set safe mode environment")
+			}
+		}
+		logger.Logger.Info().Msg("Safe mode: WebView GPU acceleration disabled")
+	}
+
+	var logLevel wailslogger.LogLevel    // zero means the Wails defaults
+	var gpuPolicy linux.WebviewGpuPolicy // zero means always accelerated
+	var windowsOptions *windows.Options
+	if safeMode {
+		logLevel = wailslogger.DEBUG
+		gpuPolicy = linux.WebviewGpuPolicyNever
+		windowsOptions = &windows.Options{WebviewGpuIsDisabled: true}
+	}
+
+	if dir := selectConfigDir(os.Args[1:]); dir != "" {
+		if err := astrum.SetConfigDir(dir); err != nil {
+			showErrorDialog(err.Error())
+			os.Exit(1)
+		}
+		logger.Logger.Info().Str("dir", astrum.ConfigPath()).Msg("Using config directory")
+	}
+
+	profile, err := selectProfile(os.Args[1:])
+	if err != nil {
+		showErrorDialog(err.Error())
+		os.Exit(1)
+	}
 
 	// Check for another running instance before starting Wails
-	if err := checkSingleInstance(); err != nil {
+	serversDir, err := checkSingleInstance(profile)
+	if err != nil {
 		showErrorDialog(err.Error())
 		os.Exit(1)
 	}
+	serversLock, instanceWarnings, err := lockServersDir(serversDir)
+	if err != nil {
+		showErrorDialog(err.Error())
+		os.Exit(1)
+	}
+	if serversLock != nil {
+		defer func() {
+			if err := serversLock.Close(); err != nil {
+				logger.Logger.Warn().Err(err).Msg("Failed to release servers directory lock")
+			}
+		}()
+	}
 
-	app := NewApp()
+	app := NewApp(profile)
 	app.SetNotificationIcon(appIcon)
+	app.SetInstanceWarnings(instanceWarnings)
 
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:     "Astrum",
-		Width:     1440,
-		Height:    900,
+		Width:     defaultWindowWidth,
+		Height:    defaultWindowHeight,
 		MinWidth:  800,
 		MinHeight: 600,
 		AssetServer: &assetserver.Options{
@@ -108,17 +271,23 @@ func main() {
 			}
 			app.startup(ctx)
 		},
+		OnDomReady:    app.domReady,
 		OnBeforeClose: app.beforeClose,
 		OnShutdown:    app.shutdown,
 		Bind: []interface{}{
 			app,
 		},
+		LogLevel:           logLevel,
+		LogLevelProduction: logLevel,
 		Linux: &linux.Options{
-			Icon: appIcon,
+			Icon:             appIcon,
+			WebviewGpuPolicy: gpuPolicy,
 		},
+		Windows: windowsOptions,
 	})
 
 	if err != nil {
+		showErrorDialog(fmt.Sprintf("Error starting application: %v", err))
 		logger.Logger.Fatal().Err(err).Msg("Error starting application")
 	}
 }
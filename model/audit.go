@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+)
+
+// Kinds of audited configuration
+const (
+	AuditKindSettings    = "settings"
+	AuditKindServer      = "server"
+	AuditKindCredentials = "credentials"
+)
+
+// Audited configuration actions
+const (
+	AuditActionAdd    = "add"
+	AuditActionUpdate = "update"
+	AuditActionMove   = "move"
+	AuditActionRemove = "remove"
+)
+
+// ConfigChange is a field changed by a configuration mutation.
+// Values are JSON encoded, empty when the field was not set.
+type ConfigChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+// ConfigAuditEntry records a configuration mutation
+type ConfigAuditEntry struct {
+	Time    time.Time      `json:"time"`
+	Kind    string         `json:"kind"`             // AuditKind*
+	Action  string         `json:"action"`           // AuditAction*
+	Target  string         `json:"target,omitempty"` // server URL, empty for app settings
+	Source  string         `json:"source,omitempty"` // function that requested the change
+	Changes []ConfigChange `json:"changes,omitempty"`
+}
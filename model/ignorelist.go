@@ -0,0 +1,84 @@
+package model
+
+import (
+	"time"
+)
+
+// IgnoreMode controls what happens to invitations from an ignored user
+type IgnoreMode string
+
+const (
+	// IgnoreModeHide keeps the invitation on the server but hides it locally
+	IgnoreModeHide IgnoreMode = "hide"
+	// IgnoreModeDecline declines the invitation on the server as soon as it is seen
+	IgnoreModeDecline IgnoreMode = "decline"
+)
+
+// MaxAutoDeclinedEntries is the number of auto-declined invitations kept in the audit trail
+const MaxAutoDeclinedEntries = 100
+
+// IgnoredUser is a user whose invitations are hidden or auto-declined
+type IgnoredUser struct {
+	UserProfileID string     `json:"user_profile_id"`
+	Nickname      string     `json:"nickname,omitempty"`
+	Mode          IgnoreMode `json:"mode"`
+	AddedAt       time.Time  `json:"added_at"`
+}
+
+// AutoDeclinedInvitation is an audit entry for an invitation declined because of the ignore list
+type AutoDeclinedInvitation struct {
+	InvitationID    string    `json:"invitation_id"`
+	SessionID       string    `json:"session_id"`
+	SessionName     string    `json:"session_name,omitempty"`
+	InviterID       string    `json:"inviter_id"`
+	InviterNickname string    `json:"inviter_nickname,omitempty"`
+	DeclinedAt      time.Time `json:"declined_at"`
+}
+
+// IgnoreList is the local invitation ignore list of a server
+type IgnoreList struct {
+	Users        []IgnoredUser            `json:"users,omitempty"`
+	AutoDeclined []AutoDeclinedInvitation `json:"auto_declined,omitempty"`
+}
+
+// Find returns the ignored user entry for a user profile ID, or nil
+func (l *IgnoreList) Find(userProfileID string) *IgnoredUser {
+	for i := range l.Users {
+		if l.Users[i].UserProfileID == userProfileID {
+			return &l.Users[i]
+		}
+	}
+	return nil
+}
+
+// AddOrUpdate adds a user to the ignore list or updates its mode
+func (l *IgnoreList) AddOrUpdate(user IgnoredUser) {
+	if existing := l.Find(user.UserProfileID); existing != nil {
+		existing.Mode = user.Mode
+		if user.Nickname != "" {
+			existing.Nickname = user.Nickname
+		}
+		return
+	}
+	l.Users = append(l.Users, user)
+}
+
+// Remove removes a user from the ignore list, returning true if it was present
+func (l *IgnoreList) Remove(userProfileID string) bool {
+	for i := range l.Users {
+		if l.Users[i].UserProfileID == userProfileID {
+			l.Users = append(l.Users[:i], l.Users[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// RecordAutoDeclined appends an entry to the audit trail, dropping the oldest
+// entries beyond MaxAutoDeclinedEntries
+func (l *IgnoreList) RecordAutoDeclined(entry AutoDeclinedInvitation) {
+	l.AutoDeclined = append(l.AutoDeclined, entry)
+	if len(l.AutoDeclined) > MaxAutoDeclinedEntries {
+		l.AutoDeclined = l.AutoDeclined[len(l.AutoDeclined)-MaxAutoDeclinedEntries:]
+	}
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+)
+
+// InvitationTracking records when pending invitations were first seen on a server.
+// The server does not expose invitation creation dates, so expiry is computed
+// from the first time the client saw each invitation.
+type InvitationTracking struct {
+	FirstSeen  map[string]time.Time `json:"first_seen,omitempty"` // invitation ID -> first seen
+	LastDigest time.Time            `json:"last_digest,omitempty"`
+}
+
+// Sync records new invitation IDs as seen now and forgets the ones no longer pending
+func (t *InvitationTracking) Sync(invitationIDs []string, now time.Time) {
+	if t.FirstSeen == nil {
+		t.FirstSeen = make(map[string]time.Time)
+	}
+
+	pending := make(map[string]bool, len(invitationIDs))
+	for _, id := range invitationIDs {
+		pending[id] = true
+		if _, ok := t.FirstSeen[id]; !ok {
+			t.FirstSeen[id] = now
+		}
+	}
+
+	for id := range t.FirstSeen {
+		if !pending[id] {
+			delete(t.FirstSeen, id)
+		}
+	}
+}
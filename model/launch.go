@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+)
+
+// maxLaunchRecords bounds the launches kept per session
+const maxLaunchRecords = 50
+
+// LaunchRecord is a run of Stars! for a session, kept for troubleshooting
+type LaunchRecord struct {
+	StartedAt  time.Time `json:"started_at"`
+	EndedAt    time.Time `json:"ended_at,omitempty"` // zero if Stars! did not start
+	Dir        string    `json:"dir"`
+	File       string    `json:"file"` // turn or host file opened
+	Wine       bool      `json:"wine"`
+	WinePrefix string    `json:"wine_prefix,omitempty"`
+	Env        []string  `json:"env,omitempty"` // NAME=value set for the session, the inherited environment is left out
+	ExitCode   int       `json:"exit_code"`     // -1 if Stars! did not start or was killed
+	Error      string    `json:"error,omitempty"`
+	Stderr     string    `json:"stderr,omitempty"` // end of the error output, where Wine reports its failures
+}
+
+// LaunchHistory holds the recent launches of a session
+type LaunchHistory struct {
+	Launches []LaunchRecord `json:"launches,omitempty"`
+}
+
+// Add appends a launch, dropping the oldest ones past the limit
+func (h *LaunchHistory) Add(r LaunchRecord) {
+	h.Launches = append(h.Launches, r)
+	if len(h.Launches) > maxLaunchRecords {
+		h.Launches = h.Launches[len(h.Launches)-maxLaunchRecords:]
+	}
+}
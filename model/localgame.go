@@ -0,0 +1,14 @@
+package model
+
+import (
+	"time"
+)
+
+// LocalGame is a game played without a server, e.g. solo or hotseat: its
+// files only live in its game directory and turns are generated with the
+// local stars.exe
+type LocalGame struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
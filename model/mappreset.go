@@ -0,0 +1,25 @@
+package model
+
+// MapPreset is a named set of map render options
+type MapPreset struct {
+	Name                string `json:"name"`
+	Width               int    `json:"width"`
+	Height              int    `json:"height"`
+	ShowNames           bool   `json:"show_names"`
+	ShowFleets          bool   `json:"show_fleets"`
+	ShowFleetPaths      int    `json:"show_fleet_paths"`
+	ShowMines           bool   `json:"show_mines"`
+	ShowWormholes       bool   `json:"show_wormholes"`
+	ShowLegend          bool   `json:"show_legend"`
+	ShowScannerCoverage bool   `json:"show_scanner_coverage"`
+	Theme               string `json:"theme,omitempty"`
+}
+
+// SessionMapSettings holds the map preferences of a session
+type SessionMapSettings struct {
+	DefaultPreset string `json:"default_preset,omitempty"` // preset used when none is requested
+	AutoGenerate  bool   `json:"auto_generate,omitempty"`  // render a map on every new turn
+	AutoPNG       bool   `json:"auto_png,omitempty"`       // also save a PNG of the automatic map
+
+	EmpireColors map[int]string `json:"empire_colors,omitempty"` // player number → "#rrggbb", overrides the theme palette
+}
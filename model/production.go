@@ -0,0 +1,28 @@
+package model
+
+// Kinds of production items
+const (
+	ProductionItemStandard = "standard" // factories, mines, auto-build items...
+	ProductionItemDesign   = "design"   // one of the player's ship or starbase designs
+)
+
+// ProductionItem is an entry of a planned production queue
+type ProductionItem struct {
+	Kind  string `json:"kind"`
+	ID    int    `json:"id"` // standard item ID or design number
+	Count int    `json:"count"`
+}
+
+// ProductionTemplate is a named, reusable production queue
+type ProductionTemplate struct {
+	Name  string           `json:"name"`
+	Items []ProductionItem `json:"items"`
+}
+
+// PlanetProductionPlan is the queue planned for a planet, either a template
+// or its own items
+type PlanetProductionPlan struct {
+	PlanetNumber int              `json:"planet_number"`
+	Template     string           `json:"template,omitempty"`
+	Items        []ProductionItem `json:"items,omitempty"`
+}
@@ -23,10 +23,42 @@ type Server struct {
 	LastConnected   time.Time      `json:"last_connected,omitempty"`
 	DefaultCredName string         `json:"default_cred_name,omitempty"`
 	Order           int            `json:"order"` // Display order in server bar (0-indexed)
+
+	// AutoConnectOnStartup connects the server with its default credential
+	// when the app starts; unset means enabled
+	AutoConnectOnStartup *bool `json:"auto_connect_on_startup,omitempty"`
+
+	// GamesDir holds the directory of the server instead of the servers
+	// directory, e.g. to keep archived games on another disk; empty means
+	// the servers directory
+	GamesDir string `json:"games_dir,omitempty"`
+
+	// MonitoringHandedOff leaves the order auto-upload of the server to
+	// another machine of the user
+	MonitoringHandedOff bool `json:"monitoring_handed_off,omitempty"`
+	// MonitoringClaimedAt is when this machine last took the order
+	// auto-upload over from the other machines of the user
+	MonitoringClaimedAt time.Time `json:"monitoring_claimed_at,omitempty"`
+
+	// SmokeTestCredName is the stored credential the server smoke test plays
+	// with, an account of its own so the test never touches real games
+	SmokeTestCredName string `json:"smoke_test_cred_name,omitempty"`
+
+	// ShareGameStats submits the statistics of our finished games to the
+	// server leaderboard, opt-in
+	ShareGameStats bool `json:"share_game_stats,omitempty"`
 }
 
 type Servers []Server
 
+// GetAutoConnectOnStartup returns whether the server is connected at startup (default: true)
+func (s *Server) GetAutoConnectOnStartup() bool {
+	if s.AutoConnectOnStartup == nil {
+		return true
+	}
+	return *s.AutoConnectOnStartup
+}
+
 // GetDefaultCredentialRef returns the default credential reference for a server
 func (s *Server) GetDefaultCredentialRef() *CredentialRef {
 	// If default cred name is set, find it
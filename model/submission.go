@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+)
+
+// SubmissionRecord is an order upload
+type SubmissionRecord struct {
+	ServerURL   string    `json:"server_url"`
+	SessionID   string    `json:"session_id"`
+	SessionName string    `json:"session_name,omitempty"`
+	Year        int       `json:"year"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+}
+
+// SubmissionLog is the history of order uploads kept for the weekly digest
+type SubmissionLog struct {
+	Records    []SubmissionRecord `json:"records,omitempty"`
+	LastDigest time.Time          `json:"last_digest,omitempty"`
+}
+
+// Prune drops the records older than maxAge
+func (l *SubmissionLog) Prune(now time.Time, maxAge time.Duration) {
+	kept := l.Records[:0]
+	for _, r := range l.Records {
+		if now.Sub(r.UploadedAt) < maxAge {
+			kept = append(kept, r)
+		}
+	}
+	l.Records = kept
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"time"
+)
+
+// Session timeline event types
+const (
+	TimelineTurnGenerated = "turn-generated"
+	TimelineOrderSent     = "order-submitted"
+	TimelinePlayerOrders  = "player-submitted" // another player's orders are in, Detail is their nickname
+	TimelinePlayerJoined  = "player-joined"
+	TimelinePlayerLeft    = "player-left"
+	TimelineRulesChanged  = "rules-changed"
+	TimelineBackup        = "backup"
+	TimelineHostChanged   = "host-changed" // the session was handed over, Detail is the new host
+)
+
+// maxTimelineEvents bounds the events kept per session
+const maxTimelineEvents = 1000
+
+// TimelineEvent is something that happened in a session
+type TimelineEvent struct {
+	Type   string    `json:"type"`
+	Time   time.Time `json:"time"`
+	Year   int       `json:"year,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// SessionTimeline holds the recorded events of a session
+type SessionTimeline struct {
+	Events  []TimelineEvent `json:"events,omitempty"`
+	Members []string        `json:"members,omitempty"` // user profile IDs at the last session update, to detect joins and departures
+}
+
+// Add appends an event, dropping the oldest ones past the limit
+func (t *SessionTimeline) Add(e TimelineEvent) {
+	t.Events = append(t.Events, e)
+	if len(t.Events) > maxTimelineEvents {
+		t.Events = t.Events[len(t.Events)-maxTimelineEvents:]
+	}
+}